@@ -1,17 +1,29 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
 	"runtime"
+	"sort"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"prompter-cli/internal/app"
 	"prompter-cli/internal/config"
+	"prompter-cli/internal/logging"
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/internal/template"
 	"prompter-cli/pkg/models"
 )
 
+// cancelTimeout releases the --timeout context once the command has finished
+var cancelTimeout context.CancelFunc = func() {}
+
 // Build-time variables injected via ldflags
 var (
 	version   = "dev"
@@ -34,6 +46,56 @@ the clipboard content is appended to the base prompt.
 Interactive mode can be controlled via config (interactive_default), overridden with 
 -i (force interactive) or -y (force non-interactive).`,
 	Args: cobra.MaximumNArgs(1),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Every command loads its config separately, so pass strict modes on the way
+		// the strict_config and template_strict settings are read from the environment
+		if strict, _ := cmd.Flags().GetBool("strict-config"); strict {
+			if err := os.Setenv("PROMPTER_STRICT_CONFIG", "true"); err != nil {
+				return err
+			}
+		}
+		if strict, _ := cmd.Flags().GetBool("strict-templates"); strict {
+			if err := os.Setenv("PROMPTER_TEMPLATE_STRICT", "true"); err != nil {
+				return err
+			}
+		}
+		// Likewise for logging, which is also set up here so loading the config
+		// is traced too
+		level, _ := cmd.Flags().GetString("log-level")
+		file, _ := cmd.Flags().GetString("log-file")
+		if level != "" {
+			if err := os.Setenv("PROMPTER_LOG_LEVEL", level); err != nil {
+				return err
+			}
+		}
+		if file != "" {
+			if err := os.Setenv("PROMPTER_LOG_FILE", file); err != nil {
+				return err
+			}
+		}
+		if level != "" || file != "" {
+			if err := logging.Configure(level, file); err != nil {
+				return err
+			}
+		}
+		if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+			if err := os.Setenv("PROMPTER_QUIET", "true"); err != nil {
+				return err
+			}
+			logging.SetQuiet(true)
+		}
+		for _, conflict := range customFlagConflicts {
+			slog.Warn(conflict)
+		}
+
+		// Limit the whole command, generation and output included
+		if timeout, _ := cmd.Flags().GetDuration("timeout"); timeout > 0 {
+			ctx, cancel := context.WithTimeoutCause(cmd.Context(), timeout, fmt.Errorf("timed out after %s (--timeout)", timeout))
+			cancelTimeout = cancel
+			cmd.SetContext(ctx)
+		}
+		return nil
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Check if version flag is set
 		if versionFlag, _ := cmd.Flags().GetBool("version"); versionFlag {
@@ -43,10 +105,31 @@ Interactive mode can be controlled via config (interactive_default), overridden
 
 		request, err := buildRequestFromFlags(cmd, args)
 		if err != nil {
-			return fmt.Errorf("invalid arguments: %w", err)
+			return invalidArguments("%w", err)
+		}
+
+		// Fill in what the flags left unset from the preset
+		if preset, _ := cmd.Flags().GetString("preset"); preset != "" {
+			if err := app.UsePreset(request, preset); err != nil {
+				return err
+			}
+		}
+
+		// Keep the prompt up to date with the included files
+		if watch, _ := cmd.Flags().GetBool("watch-context"); watch {
+			interval, _ := cmd.Flags().GetDuration("watch-interval")
+			if interval <= 0 {
+				return invalidArguments("--watch-interval must be positive")
+			}
+			return app.Watch(cmd.Context(), request, interval)
+		}
+
+		// Run entirely from an inputs file when one is given
+		if inputsPath, _ := cmd.Flags().GetString("inputs"); inputsPath != "" {
+			return app.RunWithInputs(cmd.Context(), request, inputsPath)
 		}
 
-		return app.Run(request)
+		return app.Run(cmd.Context(), request)
 	},
 }
 
@@ -103,7 +186,7 @@ var addCmd = &cobra.Command{
 		
 		// Validate that both flags are not set
 		if request.ForceInteractive && request.ForceNonInteractive {
-			return fmt.Errorf("cannot use both --interactive and --yes flags")
+			return invalidArguments("cannot use both --interactive and --yes flags")
 		}
 		
 		// Set initial interactive mode (will be resolved after config loading)
@@ -115,20 +198,633 @@ var addCmd = &cobra.Command{
 			content = args[0]
 		}
 		
-		// Get flags
-		preName, _ := cmd.Flags().GetString("pre")
-		postName, _ := cmd.Flags().GetString("post")
-		fromClipboard, _ := cmd.Flags().GetBool("clipboard")
-		overwrite, _ := cmd.Flags().GetBool("overwrite")
+		// Get flags
+		preName, _ := cmd.Flags().GetString("pre")
+		postName, _ := cmd.Flags().GetString("post")
+		fromClipboard, _ := cmd.Flags().GetBool("clipboard")
+		overwrite, _ := cmd.Flags().GetBool("overwrite")
+		
+		return app.AddTemplate(request, content, preName, postName, fromClipboard, overwrite)
+	},
+}
+
+var promptsCmd = &cobra.Command{
+	Use:   "prompts",
+	Short: "Open prompts directory in editor",
+	Long:  "Open the configured prompts directory in the default editor for easy template management.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		
+		// Get config path from flag
+		if configPath, err := cmd.Flags().GetString("config"); err == nil {
+			request.ConfigPath = configPath
+		}
+		
+		return app.OpenPromptsDirectory(request)
+	},
+}
+
+var testCmd = &cobra.Command{
+	Use:   "test [template...]",
+	Short: "Snapshot-test templates against fixture data",
+	Long: `Render each template that has a fixture file beside it (e.g. review.md.testdata.json)
+and compare the output against its golden file (review.md.golden). Fixtures can also
+live in the tests directory of a prompts location, as tests/review/input.json with the
+expected output in tests/review/expected.md.
+
+Use --update to regenerate the golden files from the current template output.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		
+		// Get config path from flag
+		if configPath, err := cmd.Flags().GetString("config"); err == nil {
+			request.ConfigPath = configPath
+		}
+		
+		update, _ := cmd.Flags().GetBool("update")
+		
+		return app.TestTemplates(request, args, update)
+	},
+}
+
+var helpersCmd = &cobra.Command{
+	Use:   "helpers [name...]",
+	Short: "List template helper functions",
+	Long:  "List every function available inside templates (built-in, Sprig, and plugin-provided) with its signature, description, and a rendered example.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		
+		// Get config path from flag
+		if configPath, err := cmd.Flags().GetString("config"); err == nil {
+			request.ConfigPath = configPath
+		}
+		
+		asJSON, _ := cmd.Flags().GetBool("json")
+		
+		return app.ListHelpers(request, args, asJSON)
+	},
+}
+
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "List plugins and what they provide",
+	Long:  "List the executables in plugins_location with the template functions, content sources (--source), and output targets (-t plugin:name) each provides.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+
+		if configPath, err := cmd.Flags().GetString("config"); err == nil {
+			request.ConfigPath = configPath
+		}
+
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		return app.ListPlugins(request, asJSON)
+	},
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve prompt generation over a local HTTP API",
+	Long:  "Answer POST /generate with a prompt request as JSON and GET /templates with the template catalog, so web UIs, launcher extensions, and other tools can generate prompts with one long-lived process.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+
+		if configPath, err := cmd.Flags().GetString("config"); err == nil {
+			request.ConfigPath = configPath
+		}
+
+		addr, _ := cmd.Flags().GetString("addr")
+		origins, _ := cmd.Flags().GetStringArray("allow-origin")
+
+		return app.Serve(cmd.Context(), request, addr, origins)
+	},
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [base-prompt]",
+	Short: "Regenerate a prompt whenever its files change",
+	Long: `Generate a prompt, then regenerate it into the target whenever the included files,
+the fix file, or the prompt templates change, until Ctrl-C. With a file target this
+keeps a live context file up to date for another tool to read:
+
+  prompter watch "review this" -d --pre review -t file:.context.md
+  prompter watch --fix --fix-file test.log -t file:.fix.md
+
+Changes are noticed as soon as they're saved, and saves within --debounce of each
+other refresh the prompt once.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request, err := requestFromFlags(cmd, args)
+		if err != nil {
+			return invalidArguments("%w", err)
+		}
+
+		if request.FixMode, err = cmd.Flags().GetBool("fix"); err != nil {
+			return invalidArguments("invalid fix flag: %w", err)
+		}
+		if request.FixFile, err = cmd.Flags().GetString("fix-file"); err != nil {
+			return invalidArguments("invalid fix-file flag: %w", err)
+		}
+		if request.FixCommand, err = cmd.Flags().GetString("fix-command"); err != nil {
+			return invalidArguments("invalid fix-command flag: %w", err)
+		}
+		if (request.FixFile != "" || request.FixCommand != "") && !request.FixMode {
+			return invalidArguments("--fix-file and --fix-command can only be used with --fix")
+		}
+
+		debounce, _ := cmd.Flags().GetDuration("debounce")
+		if debounce < 0 {
+			return invalidArguments("--debounce must not be negative")
+		}
+
+		return app.WatchChanges(cmd.Context(), request, debounce)
+	},
+}
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a config file, prompts directory, and starter templates",
+	Long: `Set up prompter by creating a config file and a prompts directory with pre/ and
+post/ subdirectories and a starter set of templates. The wizard asks whether to set
+up globally (~/.config/prompter, or the directory of -c) or for the current project
+(.prmpt/), the default target, and the editor.
+
+With -y the defaults are used: a global setup targeting the clipboard. An existing
+config file is kept unless you confirm replacing it or pass --force; existing
+templates are never overwritten.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		
+		// Get config path from flag
+		if configPath, err := cmd.Flags().GetString("config"); err == nil {
+			request.ConfigPath = configPath
+		}
+		
+		request.ForceNonInteractive, _ = cmd.Flags().GetBool("yes")
+		
+		var options app.InitOptions
+		options.Project, _ = cmd.Flags().GetBool("project")
+		options.Global, _ = cmd.Flags().GetBool("global")
+		options.Force, _ = cmd.Flags().GetBool("force")
+		
+		return app.Init(request, options)
+	},
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade an outdated config file to the current format",
+	Long: `Rewrite the config file using the current config_version, renaming and moving
+settings from older releases. The planned changes are shown and confirmed before
+the file is written (skip the confirmation with -y); the original is kept alongside
+it with a .bak suffix, timestamped when an earlier backup would be overwritten.
+
+Outdated files are already upgraded in memory on every run, so this only makes the
+change permanent.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		
+		// Get config path from flag
+		if configPath, err := cmd.Flags().GetString("config"); err == nil {
+			request.ConfigPath = configPath
+		}
+		
+		assumeYes, _ := cmd.Flags().GetBool("yes")
+		
+		return app.MigrateConfig(request, assumeYes)
+	},
+}
+
+// migrateConfigCmd is the name config migrate had before it joined the config command
+var migrateConfigCmd = &cobra.Command{
+	Use:        "migrate-config",
+	Short:      configMigrateCmd.Short,
+	Deprecated: "use 'prompter config migrate' instead",
+	Args:       cobra.NoArgs,
+	RunE:       configMigrateCmd.RunE,
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and change config settings",
+	Long: `Read and change settings without editing TOML by hand. Keys are written as in the
+config file, with tables joined by dots: target, openai.model, vars.team,
+recipe.review.pre.`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the value of a setting",
+	Long: `Print a setting as prompter resolves it, including project configs and PROMPTER_
+environment variables. Lists print one item per line; tables such as openai print
+one "key = value" line per setting.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		request.ConfigPath, _ = cmd.Flags().GetString("config")
+		
+		return app.GetConfigValue(request, args[0])
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a setting in the config file",
+	Long: `Write a setting to the config file (~/.config/prompter/config.toml, or the file
+given with -c), keeping its comments and layout. The value is converted to the
+setting's type and the whole configuration is validated before anything is written.
+Lists are given as comma-separated values or a TOML array:
+
+  prompter config set target stdout
+  prompter config set exclude_patterns "vendor/, dist/"
+  prompter config set recipe.review.pre review`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		request.ConfigPath, _ = cmd.Flags().GetString("config")
+		
+		return app.SetConfigValue(request, args[0], args[1])
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the configuration and where each setting comes from",
+	Long: `Print the settings made in the config file and project configs. With --resolved,
+print every setting as prompter resolves it (flags > PROMPTER_ environment variables
+> project configs > config file > defaults) together with the source of its value;
+--json prints the same as JSON.
+
+The setting flags of the main command (--target, --editor, --fix-file, --max-tokens,
+--model, and --var) can be given to see how they combine with the rest.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		request.ConfigPath, _ = cmd.Flags().GetString("config")
+		request.Target, _ = cmd.Flags().GetString("target")
+		request.Editor, _ = cmd.Flags().GetString("editor")
+		request.FixFile, _ = cmd.Flags().GetString("fix-file")
+		request.MaxTokens, _ = cmd.Flags().GetInt("max-tokens")
+		request.Model, _ = cmd.Flags().GetString("model")
+		
+		vars, err := parseVarFlags(cmd)
+		if err != nil {
+			return err
+		}
+		request.Vars = vars
+		
+		resolved, _ := cmd.Flags().GetBool("resolved")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		
+		return app.ShowConfig(request, resolved, asJSON)
+	},
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the config file in the configured editor",
+	Long: `Open the config file in the configured editor and validate it once the editor
+exits.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		request.ConfigPath, _ = cmd.Flags().GetString("config")
+		
+		return app.EditConfig(request)
+	},
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run <recipe> [base-prompt]",
+	Short: "Run a named recipe from the config",
+	Long: `Run a recipe defined in a [recipe.<name>] config section. A recipe bundles
+templates, files, directory inclusion, fix-mode capture, and target so a whole
+workflow runs by name. Flags given on the command line override the recipe.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request, err := requestFromFlags(cmd, args[1:])
+		if err != nil {
+			return invalidArguments("%w", err)
+		}
+
+		return app.RunRecipe(cmd.Context(), request, args[0])
+	},
+}
+
+var continueCmd = &cobra.Command{
+	Use:   "continue [id]",
+	Short: "Write a follow-up to a previous prompt",
+	Long: `Load a prompt from history (the most recent one unless an id or id prefix is
+given) and render a follow-up with the continuation template. Only the framed
+follow-up is output, ready to paste into the same conversation.
+
+The continuation template is continue.md at the root of the prompts directory,
+falling back to a built-in version. It can reference the earlier prompt as
+{{.Previous.Prompt}} and {{.Previous.BasePrompt}}.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		
+		// Get config path from flag
+		if configPath, err := cmd.Flags().GetString("config"); err == nil {
+			request.ConfigPath = configPath
+		}
+		
+		request.BasePrompt, _ = cmd.Flags().GetString("prompt")
+		request.Target, _ = cmd.Flags().GetString("target")
+		request.ForceNonInteractive, _ = cmd.Flags().GetBool("yes")
+		request.ForceInteractive, _ = cmd.Flags().GetBool("interactive")
+		request.Verbose, _ = cmd.Flags().GetBool("verbose")
+		request.NoRedact, _ = cmd.Flags().GetBool("no-redact")
+		request.Model, _ = cmd.Flags().GetString("model")
+		
+		var id string
+		if len(args) > 0 {
+			id = args[0]
+		}
+		
+		return app.Continue(cmd.Context(), request, id)
+	},
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Browse, search, and replay previous prompts",
+	Long: `Every generated prompt is saved to history together with the templates, files,
+variables, and target that produced it and its token count. Entries are referenced
+by id or a unique id prefix.`,
+}
+
+var historyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recent prompts, newest first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		request.ConfigPath, _ = cmd.Flags().GetString("config")
+		limit, _ := cmd.Flags().GetInt("limit")
+		
+		return app.ListHistory(request, limit)
+	},
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Print a prompt from history and the request that produced it",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		request.ConfigPath, _ = cmd.Flags().GetString("config")
+		
+		return app.ShowHistory(request, args[0])
+	},
+}
+
+var historySearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Find prompts containing every word of the query",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		request.ConfigPath, _ = cmd.Flags().GetString("config")
+		limit, _ := cmd.Flags().GetInt("limit")
+		
+		return app.SearchHistory(request, strings.Join(args, " "), limit)
+	},
+}
+
+var historyReplayCmd = &cobra.Command{
+	Use:   "replay <id> [base-prompt]",
+	Short: "Generate a prompt again from a history entry",
+	Long: `Re-run the request recorded in a history entry against the current files. Flags
+and a base prompt given here replace the recorded values, so a replay can switch
+templates, target, or variables:
+
+  prompter history replay 20240102-1504 --pre review -t stdout
+
+Replays run non-interactively unless -i is given.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request, err := requestFromFlags(cmd, args[1:])
+		if err != nil {
+			return invalidArguments("%w", err)
+		}
+		
+		return app.ReplayHistory(cmd.Context(), request, args[0])
+	},
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show how often each template is used",
+	Long: `Show the locally recorded usage of each template: how often it was used, rerun
+with the same input, followed up with continue, or abandoned during the interactive
+selection. The most used and recently used templates are listed first in the
+interactive selectors.
+
+Stats never leave this machine. Disable them with stats_enabled = false or clear
+them with 'prompter stats reset'.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		
+		// Get config path from flag
+		if configPath, err := cmd.Flags().GetString("config"); err == nil {
+			request.ConfigPath = configPath
+		}
+		
+		return app.ShowStats(request)
+	},
+}
+
+var statsResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Delete all recorded template stats",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		
+		// Get config path from flag
+		if configPath, err := cmd.Flags().GetString("config"); err == nil {
+			request.ConfigPath = configPath
+		}
+		
+		return app.ResetStats(request)
+	},
+}
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "Inspect and manage prompt templates",
+}
+
+var templatesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List templates with their location and description",
+	Long: `List every pre and post template prompter can resolve (local, global, custom, and
+built-in), with the source it comes from, its description from frontmatter, and
+whether it is a .default template. Templates shadowed by a same-named template
+earlier in the lookup order are not shown.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		
+		// Get config path from flag
+		if configPath, err := cmd.Flags().GetString("config"); err == nil {
+			request.ConfigPath = configPath
+		}
+		
+		asJSON, _ := cmd.Flags().GetBool("json")
+		
+		return app.ListTemplateCatalog(request, asJSON)
+	},
+}
+
+var templatesNewCmd = &cobra.Command{
+	Use:   "new <name>",
+	Short: "Scaffold a new template",
+	Long: `Create <name>.md in the pre (or, with --post, post) directory of the prompts
+location. The file starts with commented-out frontmatter and a comment listing the
+data available to templates; neither is included in generated prompts. A name such
+as go/review creates the template in a go/ namespace subdirectory. Use --edit to
+open it in the configured editor.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		
+		// Get config path from flag
+		if configPath, err := cmd.Flags().GetString("config"); err == nil {
+			request.ConfigPath = configPath
+		}
+		
+		kind := "pre"
+		if post, _ := cmd.Flags().GetBool("post"); post {
+			kind = "post"
+		}
+		edit, _ := cmd.Flags().GetBool("edit")
+		
+		return app.NewTemplate(request, args[0], kind, edit)
+	},
+}
+
+var templatesEditCmd = &cobra.Command{
+	Use:   "edit <name>",
+	Short: "Open a template in the configured editor",
+	Long: `Find a template by name the same way generation does (case-insensitive, local
+before global before custom prompts, .default files matched by their display name)
+and open it in the configured editor. Built-in templates are copied to the prompts
+location first so your edits take their place.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		
+		// Get config path from flag
+		if configPath, err := cmd.Flags().GetString("config"); err == nil {
+			request.ConfigPath = configPath
+		}
+		
+		return app.EditTemplate(request, args[0])
+	},
+}
+
+var templatesTestCmd = &cobra.Command{
+	Use:   "test [template...]",
+	Short: "Snapshot-test templates against fixture data",
+	Long:  testCmd.Long,
+	RunE:  testCmd.RunE,
+}
+
+var templatesPreviewCmd = &cobra.Command{
+	Use:   "preview <name>",
+	Short: "Render a template with sample data",
+	Long: `Render a template with made-up data, so you can see what it produces before using it:
+a placeholder prompt, two sample files, git information with recent commits, a small
+diff, and captured test output. Variables get their default, their first option, or a
+placeholder such as <name>; set them with --var.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		
+		// Get config path from flag
+		if configPath, err := cmd.Flags().GetString("config"); err == nil {
+			request.ConfigPath = configPath
+		}
+		
+		vars, err := parseVarFlags(cmd)
+		if err != nil {
+			return err
+		}
+		
+		return app.PreviewTemplate(request, args[0], vars)
+	},
+}
+
+var templatesLintCmd = &cobra.Command{
+	Use:   "lint [name|path...]",
+	Short: "Check templates for syntax errors, unknown helpers, and unknown fields",
+	Long: `Parse templates and check the data they reference against what templates are
+given: syntax errors, unknown helpers, and fields that don't exist (such as
+.Git.Branchh) are errors, and .Vars fields not declared in the frontmatter are
+warnings. Templates are given by name, by path (as a pre-commit hook passes them),
+or all at once with --all. Issues are printed as path:line:column: message, and
+the command fails when any error is found.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
+		
+		// Get config path from flag
+		if configPath, err := cmd.Flags().GetString("config"); err == nil {
+			request.ConfigPath = configPath
+		}
+		
+		all, _ := cmd.Flags().GetBool("all")
+		if !all && len(args) == 0 {
+			return fmt.Errorf("give template names or paths to lint, or --all")
+		}
+		
+		return app.LintTemplates(request, args, all)
+	},
+}
+
+var templatesSyncCmd = &cobra.Command{
+	Use:   "sync [source...]",
+	Short: "Clone or update the git repositories of shared templates",
+	Long: `Clone each [[template_sources]] repository into template_sources_location, or
+update an earlier clone to the latest commit of its ref, discarding local changes.
+Synced sources are searched for templates after the prompts_location directories.
+Give source names to sync only those.
+
+Example config:
+  [[template_sources]]
+  url = "git@github.com:org/prompts.git"
+  ref = "main"`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := models.NewPromptRequest()
 		
-		return app.AddTemplate(request, content, preName, postName, fromClipboard, overwrite)
+		// Get config path from flag
+		if configPath, err := cmd.Flags().GetString("config"); err == nil {
+			request.ConfigPath = configPath
+		}
+		
+		return app.SyncTemplateSources(request, args)
 	},
 }
 
-var promptsCmd = &cobra.Command{
-	Use:   "prompts",
-	Short: "Open prompts directory in editor",
-	Long:  "Open the configured prompts directory in the default editor for easy template management.",
+var templatesInstallCmd = &cobra.Command{
+	Use:   "install <url|path|name>",
+	Short: "Install a template or bundle from a URL or the registry",
+	Long: `Download a template (.md) or a bundle manifest (.toml) listing several templates
+into the prompts location, from an http(s) URL, a local path, or by name from the
+registry set with template_registry. Where each template came from is recorded in a
+provenance block of its frontmatter.
+
+A template is only replaced by one of the same name when it was installed from the
+same URL, so running install again updates it. Use --force to replace other
+templates, or --name to install a single template under another name. A template's
+kind is taken from its directory (.../post/name.md is a post-template), or --post.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		request := models.NewPromptRequest()
 		
@@ -137,55 +833,268 @@ var promptsCmd = &cobra.Command{
 			request.ConfigPath = configPath
 		}
 		
-		return app.OpenPromptsDirectory(request)
+		kind := ""
+		if post, _ := cmd.Flags().GetBool("post"); post {
+			kind = "post"
+		}
+		name, _ := cmd.Flags().GetString("name")
+		force, _ := cmd.Flags().GetBool("force")
+		
+		return app.InstallTemplate(request, args[0], kind, name, force)
 	},
 }
 
 func init() {
+	// Unknown flags and unparsable values are invalid arguments too
+	rootCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return orchestrator.WithCategory(err, orchestrator.ErrValidationFailed)
+	})
+
 	// Add subcommands
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(promptsCmd)
+	rootCmd.AddCommand(testCmd)
+	rootCmd.AddCommand(helpersCmd)
+	rootCmd.AddCommand(pluginsCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(migrateConfigCmd)
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configMigrateCmd)
+	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(continueCmd)
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyListCmd)
+	historyCmd.AddCommand(historyShowCmd)
+	historyCmd.AddCommand(historySearchCmd)
+	historyCmd.AddCommand(historyReplayCmd)
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.AddCommand(statsResetCmd)
+	rootCmd.AddCommand(templatesCmd)
+	templatesCmd.AddCommand(templatesListCmd)
+	templatesCmd.AddCommand(templatesNewCmd)
+	templatesCmd.AddCommand(templatesEditCmd)
+	templatesCmd.AddCommand(templatesPreviewCmd)
+	templatesCmd.AddCommand(templatesTestCmd)
+	templatesCmd.AddCommand(templatesLintCmd)
+	templatesCmd.AddCommand(templatesSyncCmd)
+	templatesCmd.AddCommand(templatesInstallCmd)
 	
 	// Add command specific flags
 	addCmd.Flags().StringP("pre", "p", "", "create a pre-template with the specified name")
 	addCmd.Flags().StringP("post", "o", "", "create a post-template with the specified name")
 	addCmd.Flags().BoolP("clipboard", "b", false, "create template from clipboard content")
 	addCmd.Flags().BoolP("overwrite", "r", false, "overwrite existing template file without prompting")
+	testCmd.Flags().Bool("update", false, "regenerate golden files from current output")
+	templatesTestCmd.Flags().Bool("update", false, "regenerate golden files from current output")
+	helpersCmd.Flags().Bool("json", false, "output helper reference as JSON")
+	pluginsCmd.Flags().Bool("json", false, "output plugins as JSON")
+	serveCmd.Flags().String("addr", "127.0.0.1:8787", "address to listen on")
+	serveCmd.Flags().StringArray("allow-origin", []string{}, "browser origin allowed to call the API, such as http://localhost:3000 (repeatable)")
+
+	// Watch generates like the main command, so it takes the same content flags as run
+	addContentFlags(watchCmd, "")
+	watchCmd.Flags().BoolP("fix", "f", false, "fix mode - regenerate the fix prompt whenever --fix-file changes")
+	watchCmd.Flags().String("fix-file", "", "file containing command output to fix, rewritten by the command being fixed")
+	watchCmd.Flags().String("fix-command", "", "command that produced the output to fix, shown with it in the prompt")
+	watchCmd.Flags().Duration("debounce", 200*time.Millisecond, "how long to wait for further changes before regenerating")
+	templatesListCmd.Flags().Bool("json", false, "output templates as JSON")
+	templatesNewCmd.Flags().Bool("post", false, "create a post-template instead of a pre-template")
+	templatesNewCmd.Flags().BoolP("edit", "e", false, "open the new template in the configured editor")
+	templatesPreviewCmd.Flags().StringArray("var", []string{}, "template variable as key=value, available as .Vars.key (repeatable)")
+	templatesLintCmd.Flags().Bool("all", false, "lint every template prompter can resolve")
+	templatesInstallCmd.Flags().Bool("post", false, "install a single template as a post-template")
+	templatesInstallCmd.Flags().String("name", "", "name to install a single template under")
+	templatesInstallCmd.Flags().Bool("force", false, "replace templates of the same name installed from elsewhere")
+
+	configShowCmd.Flags().Bool("resolved", false, "print every resolved setting with its source")
+	configShowCmd.Flags().Bool("json", false, "print the resolved settings as JSON")
+	configShowCmd.Flags().StringP("target", "t", "", "output target, as given to the main command")
+	configShowCmd.Flags().StringP("editor", "e", "", "editor, as given to the main command")
+	configShowCmd.Flags().String("fix-file", "", "fix file, as given to the main command")
+	configShowCmd.Flags().Int("max-tokens", 0, "token budget, as given to the main command")
+	configShowCmd.Flags().String("model", "", "model preset, as given to the main command")
+	configShowCmd.Flags().StringArray("var", []string{}, "template variable as key=value, as given to the main command (repeatable)")
+
+	initCmd.Flags().Bool("project", false, "set up .prmpt/ in the current directory")
+	initCmd.Flags().Bool("global", false, "set up the global config (the default with -y)")
+	initCmd.Flags().Bool("force", false, "replace an existing config file without asking")
+
+	continueCmd.Flags().StringP("prompt", "p", "", "follow-up prompt")
+	continueCmd.Flags().StringP("target", "t", "", "output target (clipboard, stdout, osc52, editor, openai, anthropic, ollama, file:/path, file+:/path, tmux:pane, http:url, plugin:name)")
+	continueCmd.Flags().String("model", "", "model preset for the tokenizer and file format (overrides model)")
+	continueCmd.Flags().Bool("no-redact", false, "keep API keys, tokens, and other secrets instead of replacing them with placeholders")
+	addContentFlags(runCmd, "the recipe")
+	addContentFlags(historyReplayCmd, "the recorded one")
+	historyListCmd.Flags().Int("limit", 20, "number of prompts to list (0 for all)")
+	historySearchCmd.Flags().Int("limit", 0, "maximum number of matches to list (0 for all)")
 
 	// Global flags
 	rootCmd.PersistentFlags().StringP("config", "c", "", "config file path (default ~/.config/prompter/config.toml)")
 	rootCmd.PersistentFlags().BoolP("yes", "y", false, "noninteractive mode - use defaults without prompts")
 	rootCmd.PersistentFlags().BoolP("interactive", "i", false, "force interactive mode (overrides config default)")
 	rootCmd.PersistentFlags().BoolP("version", "v", false, "print version information")
+	rootCmd.PersistentFlags().Bool("verbose", false, "report skipped files and other details on stderr")
+	rootCmd.PersistentFlags().Bool("strict-config", false, "fail on unknown config settings instead of warning (same as strict_config = true)")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "stop generating and sending the prompt after this long, such as 30s or 2m (default no limit)")
+	rootCmd.PersistentFlags().String("log-level", "", "least severe messages logged: debug, info, warn, or error (same as log_level)")
+	rootCmd.PersistentFlags().String("log-file", "", "append log messages to this file; warnings still go to stderr (same as log_file)")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "only report errors on stderr, without warnings or status messages (same as quiet = true)")
+	rootCmd.PersistentFlags().Bool("strict-templates", false, "fail when a template references undefined data instead of rendering <no value> (same as template_strict = true)")
 
 	// Main command flags
-	rootCmd.Flags().StringP("pre", "p", "", "pre-template name")
-	rootCmd.Flags().StringP("post", "o", "", "post-template name")
-	rootCmd.Flags().StringSlice("file", []string{}, "files to include")
-	rootCmd.Flags().BoolP("directory", "d", false, "include current directory")
-	rootCmd.Flags().StringP("target", "t", "", "output target (clipboard, stdout, file:/path)")
+	addContentFlags(rootCmd, "")
+	rootCmd.Flags().StringArray("source", []string{}, "content from a plugin source to include, as name or name:arg (repeatable)")
 	rootCmd.Flags().StringP("editor", "e", "", "editor to open prompt in")
 	rootCmd.Flags().BoolP("fix", "f", false, "fix mode - process captured command output")
-	rootCmd.Flags().String("fix-file", "", "file containing command output to fix (overrides config)")
+	rootCmd.Flags().String("fix-file", "", "file containing command output to fix, or - for stdin (overrides config)")
+	rootCmd.Flags().String("fix-command", "", "command that produced the output to fix, shown with it in the prompt")
 	rootCmd.Flags().BoolP("numbers", "n", false, "enable number key selection for templates")
-	rootCmd.Flags().BoolP("clipboard", "b", false, "append clipboard content to prompt (or use as base prompt if none provided)")
+	rootCmd.Flags().Bool("tui", false, "collect inputs in a full-screen interface with a live preview of the prompt")
+	rootCmd.Flags().Bool("json", false, "print the prompt with its templates, files, token counts, and git info as JSON on stdout instead of sending it to the target")
+	rootCmd.Flags().String("inputs", "", "run non-interactively with every input read from a .toml or .json file")
+	rootCmd.Flags().Bool("watch-context", false, "regenerate the prompt and refresh the target whenever included files change")
+	rootCmd.Flags().Duration("watch-interval", time.Second, "how often --watch-context checks for changes")
+	rootCmd.Flags().String("preset", "", "apply a [preset.<name>] from the config; flags given on the command line override it")
 	
 	// Register custom template flags dynamically
 	registerCustomTemplateFlags()
+
+	// Describe configured recipes in help and completion
+	registerRecipes()
+
+	// Run configured presets as commands, after every root flag is defined
+	registerPresets()
+
+	// Complete template names for --pre and --post from the prompts directories
+	registerTemplateCompletions()
+
+	// Complete --model with the model presets
+	registerModelCompletions()
+}
+
+// addContentFlags defines the flags choosing templates, content, and target shared by
+// the commands that generate a prompt. overrides names what the template, target,
+// and budget flags replace, such as "the recipe", or is empty for the config.
+func addContentFlags(cmd *cobra.Command, overrides string) {
+	override := func(setting string) string {
+		if overrides != "" {
+			setting = overrides
+		}
+		return " (overrides " + setting + ")"
+	}
+	// Templates and target have no single setting to name for the main command
+	replaces := ""
+	if overrides != "" {
+		replaces = override("")
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceP("pre", "p", nil, "pre-template name, repeatable or comma-separated"+replaces)
+	flags.StringSliceP("post", "o", nil, "post-template name, repeatable or comma-separated"+replaces)
+	flags.StringSlice("file", []string{}, "files to include, optionally as path:start-end for a line range")
+	flags.StringArray("symbol", []string{}, "Go function, method, or type to include, as pkg.Name or pkg.Type.Method (repeatable)")
+	flags.BoolP("directory", "d", false, "include current directory")
+	flags.StringP("target", "t", "", "output target (clipboard, stdout, osc52, editor, openai, anthropic, ollama, file:/path, file+:/path, tmux:pane, http:url, plugin:name)"+replaces)
+	flags.BoolP("clipboard", "b", false, "append clipboard content to prompt (or use as base prompt if none provided)")
+	flags.StringArray("var", []string{}, "template variable as key=value, available as .Vars.key (repeatable)")
+	flags.StringArray("exclude", []string{}, "gitignore-style pattern left out of the included directory (repeatable)")
+	flags.Int("max-tokens", 0, "token budget for embedded file contents"+override("max_tokens"))
+	flags.String("model", "", "model preset setting the token budget, tokenizer, and file format"+override("model"))
+	flags.Bool("diff", false, "include unstaged changes (git diff) as .Diff and in the prompt")
+	flags.Bool("staged", false, "include staged changes (git diff --staged) as .Diff and in the prompt")
+	flags.String("diff-against", "", "include the changes since a ref (git diff <ref>) as .Diff and in the prompt")
+	flags.Bool("outline", false, "include outlines of Go files (declarations and doc comments) instead of their contents")
+	flags.Int("with-deps", 0, "also include the packages that included Go files import from their module, following imports this many levels deep")
+	flags.Lookup("with-deps").NoOptDefVal = "1"
+	flags.Bool("todos", false, "collect TODO, FIXME, and HACK comments from the included files as .Todos")
+	flags.Bool("with-docs", false, "add the project's README, CONTRIBUTING.md, and docs overviews to the prompt (same as include_docs = true)")
+	flags.Bool("no-redact", false, "keep API keys, tokens, and other secrets instead of replacing them with placeholders")
 }
 
 // buildRequestFromFlags constructs a PromptRequest from command flags and arguments
 func buildRequestFromFlags(cmd *cobra.Command, args []string) (*models.PromptRequest, error) {
+	request, err := requestFromFlags(cmd, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set initial interactive mode (will be resolved after config loading)
+	request.Interactive = true // Default, will be overridden by config resolution
+
+	if request.Sources, err = cmd.Flags().GetStringArray("source"); err != nil {
+		return nil, fmt.Errorf("invalid source flag: %w", err)
+	}
+
+	if request.Editor, err = cmd.Flags().GetString("editor"); err != nil {
+		return nil, fmt.Errorf("invalid editor flag: %w", err)
+	}
+	// Track if --editor flag was explicitly set
+	request.EditorRequested = cmd.Flags().Changed("editor")
+
+	if request.FixMode, err = cmd.Flags().GetBool("fix"); err != nil {
+		return nil, fmt.Errorf("invalid fix flag: %w", err)
+	}
+
+	// Handle fix-file flag (overrides config)
+	if fixFile, err := cmd.Flags().GetString("fix-file"); err != nil {
+		return nil, fmt.Errorf("invalid fix-file flag: %w", err)
+	} else if fixFile != "" {
+		request.FixFile = fixFile
+	}
+
+	if request.FixCommand, err = cmd.Flags().GetString("fix-command"); err != nil {
+		return nil, fmt.Errorf("invalid fix-command flag: %w", err)
+	}
+	if request.FixCommand != "" && !request.FixMode {
+		return nil, fmt.Errorf("--fix-command can only be used with --fix")
+	}
+
+	if request.NumberSelect, err = cmd.Flags().GetBool("numbers"); err != nil {
+		return nil, fmt.Errorf("invalid numbers flag: %w", err)
+	}
+
+	if request.TUI, err = cmd.Flags().GetBool("tui"); err != nil {
+		return nil, fmt.Errorf("invalid tui flag: %w", err)
+	}
+	if request.TUI && request.ForceNonInteractive {
+		return nil, fmt.Errorf("cannot use both --tui and --yes flags")
+	}
+	if request.TUI && request.FixMode {
+		return nil, fmt.Errorf("cannot use --tui in fix mode")
+	}
+
+	if request.JSON, err = cmd.Flags().GetBool("json"); err != nil {
+		return nil, fmt.Errorf("invalid json flag: %w", err)
+	}
+	if request.JSON && (request.Target != "" || request.EditorRequested) {
+		return nil, fmt.Errorf("cannot use --json with --target or --editor")
+	}
+
+	// Handle custom template flags
+	if err := applyCustomTemplateFlags(cmd, request); err != nil {
+		return nil, fmt.Errorf("invalid custom template flag: %w", err)
+	}
+
+	return request, nil
+}
+
+// requestFromFlags builds a request from the base prompt argument, the global flags,
+// and the flags defined by addContentFlags
+func requestFromFlags(cmd *cobra.Command, args []string) (*models.PromptRequest, error) {
 	request := models.NewPromptRequest()
 
-	// Get base prompt from positional argument
 	if len(args) > 0 {
 		request.BasePrompt = strings.TrimSpace(args[0])
 	}
 
-	// Extract flags
 	var err error
 
 	if request.ConfigPath, err = cmd.Flags().GetString("config"); err != nil {
@@ -196,38 +1105,44 @@ func buildRequestFromFlags(cmd *cobra.Command, args []string) (*models.PromptReq
 	if request.ForceNonInteractive, err = cmd.Flags().GetBool("yes"); err != nil {
 		return nil, fmt.Errorf("invalid yes flag: %w", err)
 	}
-	
+
 	if request.ForceInteractive, err = cmd.Flags().GetBool("interactive"); err != nil {
 		return nil, fmt.Errorf("invalid interactive flag: %w", err)
 	}
-	
+
 	// Validate that both flags are not set
 	if request.ForceInteractive && request.ForceNonInteractive {
 		return nil, fmt.Errorf("cannot use both --interactive and --yes flags")
 	}
-	
-	// Set initial interactive mode (will be resolved after config loading)
-	request.Interactive = true // Default, will be overridden by config resolution
 
-	if request.PreTemplate, err = cmd.Flags().GetString("pre"); err != nil {
+	if request.Verbose, err = cmd.Flags().GetBool("verbose"); err != nil {
+		return nil, fmt.Errorf("invalid verbose flag: %w", err)
+	}
+
+	preNames, err := cmd.Flags().GetStringSlice("pre")
+	if err != nil {
 		return nil, fmt.Errorf("invalid pre flag: %w", err)
 	}
+	request.PreTemplate = models.JoinTemplateNames(preNames)
 
-	if request.PostTemplate, err = cmd.Flags().GetString("post"); err != nil {
+	postNames, err := cmd.Flags().GetStringSlice("post")
+	if err != nil {
 		return nil, fmt.Errorf("invalid post flag: %w", err)
 	}
+	request.PostTemplate = models.JoinTemplateNames(postNames)
 
 	if request.Files, err = cmd.Flags().GetStringSlice("file"); err != nil {
 		return nil, fmt.Errorf("invalid file flag: %w", err)
 	}
 
-	var includeDirectory bool
-	if includeDirectory, err = cmd.Flags().GetBool("directory"); err != nil {
-		return nil, fmt.Errorf("invalid directory flag: %w", err)
+	if request.Symbols, err = cmd.Flags().GetStringArray("symbol"); err != nil {
+		return nil, fmt.Errorf("invalid symbol flag: %w", err)
 	}
-	
+
 	// If --directory flag is set, use current directory
-	if includeDirectory {
+	if includeDirectory, err := cmd.Flags().GetBool("directory"); err != nil {
+		return nil, fmt.Errorf("invalid directory flag: %w", err)
+	} else if includeDirectory {
 		if cwd, err := os.Getwd(); err == nil {
 			request.Directory = cwd
 		} else {
@@ -239,69 +1154,130 @@ func buildRequestFromFlags(cmd *cobra.Command, args []string) (*models.PromptReq
 		return nil, fmt.Errorf("invalid target flag: %w", err)
 	}
 
-	if request.Editor, err = cmd.Flags().GetString("editor"); err != nil {
-		return nil, fmt.Errorf("invalid editor flag: %w", err)
+	if request.FromClipboard, err = cmd.Flags().GetBool("clipboard"); err != nil {
+		return nil, fmt.Errorf("invalid clipboard flag: %w", err)
 	}
-	// Track if --editor flag was explicitly set
-	request.EditorRequested = cmd.Flags().Changed("editor")
 
-	if request.FixMode, err = cmd.Flags().GetBool("fix"); err != nil {
-		return nil, fmt.Errorf("invalid fix flag: %w", err)
+	if request.Vars, err = parseVarFlags(cmd); err != nil {
+		return nil, err
 	}
 
-	// Handle fix-file flag (overrides config)
-	if fixFile, err := cmd.Flags().GetString("fix-file"); err != nil {
-		return nil, fmt.Errorf("invalid fix-file flag: %w", err)
-	} else if fixFile != "" {
-		request.FixFile = fixFile
+	if request.Exclude, err = cmd.Flags().GetStringArray("exclude"); err != nil {
+		return nil, fmt.Errorf("invalid exclude flag: %w", err)
 	}
 
-	if request.NumberSelect, err = cmd.Flags().GetBool("numbers"); err != nil {
-		return nil, fmt.Errorf("invalid numbers flag: %w", err)
+	if request.MaxTokens, err = cmd.Flags().GetInt("max-tokens"); err != nil {
+		return nil, fmt.Errorf("invalid max-tokens flag: %w", err)
+	} else if request.MaxTokens < 0 {
+		return nil, fmt.Errorf("invalid max-tokens flag: %d (must be positive)", request.MaxTokens)
 	}
 
-	if request.FromClipboard, err = cmd.Flags().GetBool("clipboard"); err != nil {
-		return nil, fmt.Errorf("invalid clipboard flag: %w", err)
+	if err := parseDiffFlags(cmd, request); err != nil {
+		return nil, err
 	}
 
-	// Handle custom template flags
-	if err := applyCustomTemplateFlags(cmd, request); err != nil {
-		return nil, fmt.Errorf("invalid custom template flag: %w", err)
+	if request.Outline, err = cmd.Flags().GetBool("outline"); err != nil {
+		return nil, fmt.Errorf("invalid outline flag: %w", err)
+	}
+
+	if request.WithDeps, err = cmd.Flags().GetInt("with-deps"); err != nil {
+		return nil, fmt.Errorf("invalid with-deps flag: %w", err)
+	} else if request.WithDeps < 0 {
+		return nil, fmt.Errorf("invalid with-deps flag: %d (must not be negative)", request.WithDeps)
+	}
+
+	if request.Todos, err = cmd.Flags().GetBool("todos"); err != nil {
+		return nil, fmt.Errorf("invalid todos flag: %w", err)
+	}
+
+	if request.WithDocs, err = cmd.Flags().GetBool("with-docs"); err != nil {
+		return nil, fmt.Errorf("invalid with-docs flag: %w", err)
+	}
+
+	if request.Model, err = cmd.Flags().GetString("model"); err != nil {
+		return nil, fmt.Errorf("invalid model flag: %w", err)
+	}
+
+	if request.NoRedact, err = cmd.Flags().GetBool("no-redact"); err != nil {
+		return nil, fmt.Errorf("invalid no-redact flag: %w", err)
 	}
 
 	return request, nil
 }
 
-// getFirstTemplateFromDir returns the first template name found in a directory
-func getFirstTemplateFromDir(dir string) (string, error) {
-	entries, err := os.ReadDir(dir)
+// parseVarFlags reads the repeatable --var key=value flag into a map
+func parseVarFlags(cmd *cobra.Command) (map[string]string, error) {
+	values, err := cmd.Flags().GetStringArray("var")
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("invalid var flag: %w", err)
 	}
-	
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	vars := make(map[string]string, len(values))
+	for _, value := range values {
+		name, val, ok := strings.Cut(value, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid var flag %q: expected key=value", value)
 		}
-		
-		name := entry.Name()
-		// Only include .md files
-		if strings.HasSuffix(name, ".md") {
-			// Remove .md extension and .default. prefix if present
-			templateName := name[:len(name)-3] // Remove .md
-			
-			// Remove .default. prefix if present
-			if len(templateName) > 9 && templateName[:9] == ".default." {
-				templateName = templateName[9:]
-			}
-			
-			return templateName, nil
+		vars[name] = val
+	}
+	return vars, nil
+}
+
+// invalidArguments reports a problem with the command line, which exits with
+// orchestrator.ExitValidation
+func invalidArguments(format string, args ...any) error {
+	return orchestrator.WithCategory(fmt.Errorf("invalid arguments: "+format, args...), orchestrator.ErrValidationFailed)
+}
+
+// parseDiffFlags sets the change set requested with --diff, --staged, or --diff-against
+func parseDiffFlags(cmd *cobra.Command, request *models.PromptRequest) error {
+	working, err := cmd.Flags().GetBool("diff")
+	if err != nil {
+		return fmt.Errorf("invalid diff flag: %w", err)
+	}
+	staged, err := cmd.Flags().GetBool("staged")
+	if err != nil {
+		return fmt.Errorf("invalid staged flag: %w", err)
+	}
+	against, err := cmd.Flags().GetString("diff-against")
+	if err != nil {
+		return fmt.Errorf("invalid diff-against flag: %w", err)
+	}
+
+	selected := 0
+	for _, set := range []bool{working, staged, against != ""} {
+		if set {
+			selected++
 		}
 	}
-	
-	return "", fmt.Errorf("no .md templates found")
+	if selected > 1 {
+		return fmt.Errorf("use only one of --diff, --staged, and --diff-against")
+	}
+
+	switch {
+	case working:
+		request.DiffMode = models.DiffWorking
+	case staged:
+		request.DiffMode = models.DiffStaged
+	case against != "":
+		request.DiffMode = models.DiffRef
+		request.DiffBase = against
+	}
+	return nil
 }
 
+// customFlags maps the flags registered for custom templates to the name of their
+// custom template, leaving out those that collided with a built-in flag
+var customFlags = make(map[string]string)
+
+// customFlagConflicts describes the custom template flags and shorthands left out
+// because a built-in flag already uses them, warned about on every run
+var customFlagConflicts []string
+
 // applyCustomTemplateFlags checks for custom template flags and applies them to the request
 func applyCustomTemplateFlags(cmd *cobra.Command, request *models.PromptRequest) error {
 	// Load config to get custom templates
@@ -320,103 +1296,278 @@ func applyCustomTemplateFlags(cmd *cobra.Command, request *models.PromptRequest)
 	}
 	
 	// Check each custom template flag
-	for _, customTemplate := range resolvedCfg.CustomTemplates {
-		flagName := customTemplate.Flag
+	for name, customTemplate := range resolvedCfg.CustomTemplates {
+		flag := cmd.Flags().Lookup(customTemplate.Flag)
+		if flag == nil || customFlags[customTemplate.Flag] != name || !flag.Changed {
+			continue
+		}
+		value := flag.Value.String()
+		if customTemplate.Variable == "" && value != "true" {
+			continue // --flag=false
+		}
+
+		// Find the first available template in the custom location
+		templateName, err := template.CustomTemplateName(customTemplate)
+		if err != nil {
+			return err
+		}
 		
-		// Check if this custom template flag is set
-		if flagSet, err := cmd.Flags().GetBool(flagName); err == nil && flagSet {
-			// Find the first available template in the custom location
-			templateDir := customTemplate.Location
-			if customTemplate.Type == "post" {
-				templateDir = fmt.Sprintf("%s/post", customTemplate.Location)
-			} else {
-				templateDir = fmt.Sprintf("%s/pre", customTemplate.Location)
+		// Apply the template based on its type
+		if customTemplate.Type == "post" {
+			// Only set if not already set by another custom template
+			if request.PostTemplate == "" {
+				request.PostTemplate = templateName
 			}
-			
-			// Get the first template from the directory
-			templateName, err := getFirstTemplateFromDir(templateDir)
-			if err != nil {
-				return fmt.Errorf("no templates found in custom template location %s: %w", templateDir, err)
+		} else {
+			// Only set if not already set by another custom template
+			if request.PreTemplate == "" {
+				request.PreTemplate = templateName
 			}
-			
-			// Apply the template based on its type
-			if customTemplate.Type == "post" {
-				// Only set if not already set by another custom template
-				if request.PostTemplate == "" {
-					request.PostTemplate = templateName
-				}
-			} else {
-				// Only set if not already set by another custom template
-				if request.PreTemplate == "" {
-					request.PreTemplate = templateName
-				}
+		}
+
+		// The flag's value fills the template's variable, unless given with --var
+		if customTemplate.Variable != "" {
+			if request.Vars == nil {
+				request.Vars = make(map[string]string)
 			}
-			
-			// If the custom template has interactive=false, set non-interactive mode
-			if !customTemplate.Interactive {
-				request.ForceNonInteractive = true
+			if _, ok := request.Vars[customTemplate.Variable]; !ok {
+				request.Vars[customTemplate.Variable] = value
 			}
 		}
+		
+		// If the custom template has interactive=false, set non-interactive mode
+		if !customTemplate.Interactive {
+			request.ForceNonInteractive = true
+		}
 	}
 	
 	return nil
 }
 
-// registerCustomTemplateFlags loads config and registers custom template flags
+// registerCustomTemplateFlags loads config and registers custom template flags. Flags
+// whose name a built-in flag already uses are left out, and shorthands a built-in flag
+// already uses are dropped, both noted in customFlagConflicts.
 func registerCustomTemplateFlags() {
 	// Try to load config to discover custom templates
 	configManager := config.NewManager()
 	
-	// Get config path from environment or use default
-	configPath := ""
-	if len(os.Args) > 1 {
-		// Look for -c or --config flag in args
-		for i, arg := range os.Args {
-			if (arg == "-c" || arg == "--config") && i+1 < len(os.Args) {
-				configPath = os.Args[i+1]
-				break
-			}
-			if strings.HasPrefix(arg, "--config=") {
-				configPath = strings.TrimPrefix(arg, "--config=")
-				break
-			}
-		}
-	}
-	
 	// Load configuration
-	_, err := configManager.Load(configPath)
+	_, err := configManager.Load(configPathFromArgs())
 	if err != nil {
 		// If config loading fails, continue without custom templates
 		return
 	}
 	
+	// Invalid custom templates are reported when the command loads its config
 	resolvedCfg, err := configManager.Resolve()
-	if err != nil {
+	if err != nil || configManager.Validate(resolvedCfg) != nil {
 		return
 	}
-	
+
+	// Persistent flags only join the command's flags when it runs, and cobra adds
+	// help then too
+	builtinFlag := func(name string) bool {
+		return name == "help" || rootCmd.Flags().Lookup(name) != nil || rootCmd.PersistentFlags().Lookup(name) != nil
+	}
+	builtinShorthand := func(shorthand string) bool {
+		return shorthand == "h" || rootCmd.Flags().ShorthandLookup(shorthand) != nil || rootCmd.PersistentFlags().ShorthandLookup(shorthand) != nil
+	}
+
+	names := make([]string, 0, len(resolvedCfg.CustomTemplates))
+	for name := range resolvedCfg.CustomTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
 	// Register flags for each custom template
-	for name, customTemplate := range resolvedCfg.CustomTemplates {
+	for _, name := range names {
+		customTemplate := resolvedCfg.CustomTemplates[name]
 		flagName := customTemplate.Flag
 		shorthand := customTemplate.Shorthand
+
+		if builtinFlag(flagName) {
+			customFlagConflicts = append(customFlagConflicts, fmt.Sprintf("custom_template.%s: --%s is a built-in flag, so the custom template has no flag", name, flagName))
+			continue
+		}
+		if shorthand != "" && builtinShorthand(shorthand) {
+			customFlagConflicts = append(customFlagConflicts, fmt.Sprintf("custom_template.%s: -%s is a built-in flag's shorthand, so only --%s selects the custom template", name, shorthand, flagName))
+			shorthand = ""
+		}
 		
 		// Use custom description if provided, otherwise use default
 		var description string
 		if customTemplate.Description != "" {
 			description = customTemplate.Description
 		} else {
-			description = fmt.Sprintf("use custom template '%s' from %s", name, customTemplate.Location)
+			description = fmt.Sprintf("use %s-template '%s' from %s", customTemplate.Type, name, customTemplate.Location)
 		}
 		
-		// Add the flag (avoid conflicts with existing flags)
-		if rootCmd.Flags().Lookup(flagName) == nil {
-			if shorthand != "" && rootCmd.Flags().ShorthandLookup(shorthand) == nil {
-				rootCmd.Flags().BoolP(flagName, shorthand, false, description)
-			} else {
-				rootCmd.Flags().Bool(flagName, false, description)
+		if customTemplate.Variable != "" {
+			description += fmt.Sprintf(", with the value as .Vars.%s", customTemplate.Variable)
+			rootCmd.Flags().StringP(flagName, shorthand, "", description)
+		} else {
+			rootCmd.Flags().BoolP(flagName, shorthand, false, description)
+		}
+		customFlags[flagName] = name
+	}
+}
+
+// registerRecipes loads config and lists the configured recipes in the run command's
+// help and shell completion
+func registerRecipes() {
+	configManager := config.NewManager()
+	if _, err := configManager.Load(configPathFromArgs()); err != nil {
+		return
+	}
+
+	resolvedCfg, err := configManager.Resolve()
+	if err != nil || len(resolvedCfg.Recipes) == 0 {
+		return
+	}
+
+	names := app.RecipeNames(resolvedCfg)
+
+	var help strings.Builder
+	help.WriteString("\n\nRecipes:")
+	for _, name := range names {
+		if description := resolvedCfg.Recipes[name].Description; description != "" {
+			help.WriteString(fmt.Sprintf("\n  %-12s %s", name, description))
+		} else {
+			help.WriteString(fmt.Sprintf("\n  %s", name))
+		}
+	}
+	runCmd.Long += help.String()
+
+	runCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var completions []string
+		for _, name := range names {
+			completions = append(completions, name+"\t"+resolvedCfg.Recipes[name].Description)
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// registerPresets loads config, completes --preset with the configured presets, and
+// adds a command for each preset that doesn't share its name with a built-in command,
+// so "prompter review" runs like "prompter --preset review"
+func registerPresets() {
+	configManager := config.NewManager()
+	if _, err := configManager.Load(configPathFromArgs()); err != nil {
+		return
+	}
+
+	resolvedCfg, err := configManager.Resolve()
+	if err != nil || len(resolvedCfg.Presets) == 0 {
+		return
+	}
+
+	names := app.PresetNames(resolvedCfg)
+
+	rootCmd.RegisterFlagCompletionFunc("preset", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var completions []string
+		for _, name := range names {
+			completions = append(completions, name+"\t"+resolvedCfg.Presets[name].Description)
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	builtin := make(map[string]bool)
+	for _, cmd := range rootCmd.Commands() {
+		builtin[cmd.Name()] = true
+		for _, alias := range cmd.Aliases {
+			builtin[alias] = true
+		}
+	}
+	for _, name := range names {
+		if builtin[name] || name == "help" || name == "completion" {
+			continue // Still available with --preset
+		}
+
+		short := resolvedCfg.Presets[name].Description
+		if short == "" {
+			short = fmt.Sprintf("Run the %s preset from the config", name)
+		}
+		presetCmd := &cobra.Command{
+			Use:   name + " [base-prompt]",
+			Short: short,
+			Long: fmt.Sprintf(`Run with the [preset.%s] config section applied, the same as
+prompter --preset %s. Flags given on the command line override the preset.`, name, name),
+			Args: cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := cmd.Flags().Set("preset", name); err != nil {
+					return err
+				}
+				return rootCmd.RunE(cmd, args)
+			},
+		}
+		// Share the root flags, completions included
+		presetCmd.Flags().AddFlagSet(rootCmd.Flags())
+		rootCmd.AddCommand(presetCmd)
+	}
+}
+
+// registerTemplateCompletions completes --pre and --post with the templates found in
+// the prompts directories when the completion is requested, so new templates are
+// offered without regenerating the completion script. After a comma, the next name is
+// completed.
+func registerTemplateCompletions() {
+	complete := func(kind string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			request := models.NewPromptRequest()
+			request.ConfigPath, _ = cmd.Flags().GetString("config")
+
+			// Complete the last of several comma-separated names
+			prefix := toComplete[:strings.LastIndex(toComplete, ",")+1]
+			var completions []string
+			for _, completion := range app.TemplateCompletions(request, kind) {
+				completions = append(completions, prefix+completion)
 			}
+			return completions, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+
+	for _, cmd := range []*cobra.Command{rootCmd, runCmd, watchCmd, historyReplayCmd} {
+		cmd.RegisterFlagCompletionFunc("pre", complete("pre"))
+		cmd.RegisterFlagCompletionFunc("post", complete("post"))
+	}
+}
+
+// registerModelCompletions completes --model with the built-in and configured model
+// presets
+func registerModelCompletions() {
+	complete := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		configPath, _ := cmd.Flags().GetString("config")
+		configManager := config.NewManager()
+		if _, err := configManager.Load(configPath); err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		resolvedCfg, err := configManager.Resolve()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return config.ModelNames(resolvedCfg), cobra.ShellCompDirectiveNoFileComp
+	}
+
+	for _, cmd := range []*cobra.Command{rootCmd, runCmd, watchCmd, continueCmd, historyReplayCmd, configShowCmd} {
+		cmd.RegisterFlagCompletionFunc("model", complete)
+	}
+}
+
+// configPathFromArgs finds the -c/--config value in the raw arguments, for
+// config-driven setup that runs before flags are parsed
+func configPathFromArgs() string {
+	for i, arg := range os.Args {
+		if (arg == "-c" || arg == "--config") && i+1 < len(os.Args) {
+			return os.Args[i+1]
+		}
+		if strings.HasPrefix(arg, "--config=") {
+			return strings.TrimPrefix(arg, "--config=")
 		}
 	}
+	return ""
 }
 
 func main() {
@@ -424,9 +1575,24 @@ func main() {
 	rootCmd.SilenceUsage = true
 	rootCmd.SilenceErrors = true
 	
-	if err := rootCmd.Execute(); err != nil {
+	// Ctrl-C cancels the command's context so file walks, commands, and requests stop
+	// cleanly; a second Ctrl-C exits right away
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	cancelTimeout()
+	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Fprintln(os.Stderr, "Interrupted")
+			os.Exit(130)
+		}
+		// Each error category has its own exit code, see orchestrator.ExitCode
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(orchestrator.ExitCode(err))
 	}
 }
 
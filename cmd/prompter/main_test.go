@@ -1,9 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"prompter-cli/pkg/models"
 )
 
@@ -31,6 +37,21 @@ func TestBuildRequestFromFlags(t *testing.T) {
 				Files:        []string{},
 			},
 		},
+		{
+			name: "several templates",
+			args: []string{"test prompt"},
+			flags: map[string]string{
+				"pre":  "persona, review",
+				"post": "terse",
+			},
+			expected: &models.PromptRequest{
+				BasePrompt:   "test prompt",
+				PreTemplate:  "persona,review",
+				PostTemplate: "terse",
+				Interactive:  true,
+				Files:        []string{},
+			},
+		},
 		{
 			name: "noninteractive mode",
 			args: []string{"test prompt"},
@@ -57,6 +78,30 @@ func TestBuildRequestFromFlags(t *testing.T) {
 				Files:               []string{},
 			},
 		},
+		{
+			name: "fix mode from stdin",
+			flags: map[string]string{
+				"fix-file":    "-",
+				"fix-command": "go test ./...",
+			},
+			boolFlags: map[string]bool{
+				"fix": true,
+			},
+			expected: &models.PromptRequest{
+				FixMode:     true,
+				FixFile:     "-",
+				FixCommand:  "go test ./...",
+				Interactive: true,
+				Files:       []string{},
+			},
+		},
+		{
+			name: "fix command without fix mode should error",
+			flags: map[string]string{
+				"fix-command": "go test ./...",
+			},
+			wantErr: true,
+		},
 		{
 			name: "number selection mode",
 			args: []string{"test prompt"},
@@ -107,6 +152,195 @@ func TestBuildRequestFromFlags(t *testing.T) {
 				Files:            []string{},
 			},
 		},
+		{
+			name: "template variables",
+			args: []string{"test prompt"},
+			flags: map[string]string{
+				"var": "ticket=ABC-123",
+			},
+			expected: &models.PromptRequest{
+				BasePrompt:  "test prompt",
+				Interactive: true,
+				Files:       []string{},
+				Vars:        map[string]string{"ticket": "ABC-123"},
+			},
+		},
+		{
+			name: "exclude patterns",
+			args: []string{"test prompt"},
+			flags: map[string]string{
+				"exclude": "*_test.go",
+			},
+			expected: &models.PromptRequest{
+				BasePrompt:  "test prompt",
+				Interactive: true,
+				Files:       []string{},
+				Exclude:     []string{"*_test.go"},
+			},
+		},
+		{
+			name: "diff against a ref",
+			args: []string{"test prompt"},
+			flags: map[string]string{
+				"diff-against": "main",
+			},
+			expected: &models.PromptRequest{
+				BasePrompt:  "test prompt",
+				Interactive: true,
+				Files:       []string{},
+				DiffMode:    models.DiffRef,
+				DiffBase:    "main",
+			},
+		},
+		{
+			name: "symbols",
+			args: []string{"test prompt"},
+			flags: map[string]string{
+				"symbol": "content.Outline",
+			},
+			expected: &models.PromptRequest{
+				BasePrompt:  "test prompt",
+				Interactive: true,
+				Files:       []string{},
+				Symbols:     []string{"content.Outline"},
+			},
+		},
+		{
+			name: "plugin sources",
+			args: []string{"test prompt"},
+			flags: map[string]string{
+				"source": "jira:PROJ-1",
+			},
+			expected: &models.PromptRequest{
+				BasePrompt:  "test prompt",
+				Interactive: true,
+				Files:       []string{},
+				Sources:     []string{"jira:PROJ-1"},
+			},
+		},
+		{
+			name: "outline mode",
+			args: []string{"test prompt"},
+			boolFlags: map[string]bool{
+				"outline": true,
+			},
+			expected: &models.PromptRequest{
+				BasePrompt:  "test prompt",
+				Interactive: true,
+				Files:       []string{},
+				Outline:     true,
+			},
+		},
+		{
+			name: "dependency depth",
+			args: []string{"test prompt"},
+			flags: map[string]string{
+				"with-deps": "2",
+			},
+			expected: &models.PromptRequest{
+				BasePrompt:  "test prompt",
+				Interactive: true,
+				Files:       []string{},
+				WithDeps:    2,
+			},
+		},
+		{
+			name: "redaction turned off",
+			args: []string{"test prompt"},
+			boolFlags: map[string]bool{
+				"no-redact": true,
+			},
+			expected: &models.PromptRequest{
+				BasePrompt:  "test prompt",
+				Interactive: true,
+				Files:       []string{},
+				NoRedact:    true,
+			},
+		},
+		{
+			name: "todo comments",
+			args: []string{"test prompt"},
+			flags: map[string]string{
+				"todos": "true",
+			},
+			expected: &models.PromptRequest{
+				BasePrompt:  "test prompt",
+				Interactive: true,
+				Files:       []string{},
+				Todos:       true,
+			},
+		},
+		{
+			name: "negative dependency depth should error",
+			flags: map[string]string{
+				"with-deps": "-1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "conflicting diff flags should error",
+			flags: map[string]string{
+				"diff-against": "main",
+			},
+			boolFlags: map[string]bool{
+				"staged": true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative token budget should error",
+			flags: map[string]string{
+				"max-tokens": "-1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "malformed template variable should error",
+			flags: map[string]string{
+				"var": "ticket",
+			},
+			wantErr: true,
+		},
+		{
+			name: "full-screen interface",
+			boolFlags: map[string]bool{
+				"tui": true,
+			},
+			expected: &models.PromptRequest{
+				Interactive: true,
+				Files:       []string{},
+				TUI:         true,
+			},
+		},
+		{
+			name: "full-screen interface with yes should error",
+			boolFlags: map[string]bool{
+				"tui": true,
+				"yes": true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "json output",
+			boolFlags: map[string]bool{
+				"json": true,
+			},
+			expected: &models.PromptRequest{
+				Interactive: true,
+				Files:       []string{},
+				JSON:        true,
+			},
+		},
+		{
+			name: "json output with a target should error",
+			flags: map[string]string{
+				"target": "stdout",
+			},
+			boolFlags: map[string]bool{
+				"json": true,
+			},
+			wantErr: true,
+		},
 		{
 			name: "conflicting interactive flags should error",
 			boolFlags: map[string]bool{
@@ -124,17 +358,17 @@ func TestBuildRequestFromFlags(t *testing.T) {
 			// Add flags to command
 			cmd.Flags().String("config", "", "")
 			cmd.Flags().Bool("yes", false, "")
-			cmd.Flags().String("pre", "", "")
-			cmd.Flags().String("post", "", "")
-			cmd.Flags().StringSlice("file", []string{}, "")
-			cmd.Flags().BoolP("directory", "d", false, "")
-			cmd.Flags().String("target", "", "")
+			cmd.Flags().BoolP("interactive", "i", false, "")
+			cmd.Flags().Bool("verbose", false, "")
+			addContentFlags(cmd, "")
+			cmd.Flags().StringArray("source", []string{}, "")
 			cmd.Flags().String("editor", "", "")
 			cmd.Flags().Bool("fix", false, "")
 			cmd.Flags().String("fix-file", "", "")
+			cmd.Flags().String("fix-command", "", "")
 			cmd.Flags().BoolP("numbers", "n", false, "")
-			cmd.Flags().BoolP("clipboard", "b", false, "")
-			cmd.Flags().BoolP("interactive", "i", false, "")
+			cmd.Flags().Bool("tui", false, "")
+			cmd.Flags().Bool("json", false, "")
 			
 			// Set flag values
 			for flag, value := range tt.flags {
@@ -179,10 +413,19 @@ func TestBuildRequestFromFlags(t *testing.T) {
 			if result.FixMode != tt.expected.FixMode {
 				t.Errorf("FixMode = %v, expected %v", result.FixMode, tt.expected.FixMode)
 			}
+			if result.FixFile != tt.expected.FixFile || result.FixCommand != tt.expected.FixCommand {
+				t.Errorf("Fix = %q %q, expected %q %q", result.FixFile, result.FixCommand, tt.expected.FixFile, tt.expected.FixCommand)
+			}
 			
 			if result.NumberSelect != tt.expected.NumberSelect {
 				t.Errorf("NumberSelect = %v, expected %v", result.NumberSelect, tt.expected.NumberSelect)
 			}
+			if result.TUI != tt.expected.TUI {
+				t.Errorf("TUI = %v, expected %v", result.TUI, tt.expected.TUI)
+			}
+			if result.JSON != tt.expected.JSON {
+				t.Errorf("JSON = %v, expected %v", result.JSON, tt.expected.JSON)
+			}
 			
 			if result.FromClipboard != tt.expected.FromClipboard {
 				t.Errorf("FromClipboard = %v, expected %v", result.FromClipboard, tt.expected.FromClipboard)
@@ -195,8 +438,130 @@ func TestBuildRequestFromFlags(t *testing.T) {
 			if result.ForceNonInteractive != tt.expected.ForceNonInteractive {
 				t.Errorf("ForceNonInteractive = %v, expected %v", result.ForceNonInteractive, tt.expected.ForceNonInteractive)
 			}
+			
+			if !reflect.DeepEqual(result.Vars, tt.expected.Vars) {
+				t.Errorf("Vars = %v, expected %v", result.Vars, tt.expected.Vars)
+			}
+
+			if len(result.Exclude) != 0 || len(tt.expected.Exclude) != 0 {
+				if !reflect.DeepEqual(result.Exclude, tt.expected.Exclude) {
+					t.Errorf("Exclude = %v, expected %v", result.Exclude, tt.expected.Exclude)
+				}
+			}
+
+			if result.DiffMode != tt.expected.DiffMode || result.DiffBase != tt.expected.DiffBase {
+				t.Errorf("Diff = %q %q, expected %q %q", result.DiffMode, result.DiffBase, tt.expected.DiffMode, tt.expected.DiffBase)
+			}
+
+			if len(result.Symbols) != 0 || len(tt.expected.Symbols) != 0 {
+				if !reflect.DeepEqual(result.Symbols, tt.expected.Symbols) {
+					t.Errorf("Symbols = %v, expected %v", result.Symbols, tt.expected.Symbols)
+				}
+			}
+			if len(result.Sources) != 0 || len(tt.expected.Sources) != 0 {
+				if !reflect.DeepEqual(result.Sources, tt.expected.Sources) {
+					t.Errorf("Sources = %v, expected %v", result.Sources, tt.expected.Sources)
+				}
+			}
+
+			if result.Outline != tt.expected.Outline {
+				t.Errorf("Outline = %v, expected %v", result.Outline, tt.expected.Outline)
+			}
+
+			if result.NoRedact != tt.expected.NoRedact {
+				t.Errorf("NoRedact = %v, expected %v", result.NoRedact, tt.expected.NoRedact)
+			}
+
+			if result.WithDeps != tt.expected.WithDeps {
+				t.Errorf("WithDeps = %d, expected %d", result.WithDeps, tt.expected.WithDeps)
+			}
 		})
 	}
 }
 
-// TestValidateRequest removed - validation is now handled by the orchestrator
\ No newline at end of file
+func TestContentFlagsShared(t *testing.T) {
+	reference := &cobra.Command{}
+	addContentFlags(reference, "")
+
+	for _, cmd := range []*cobra.Command{rootCmd, runCmd, watchCmd, historyReplayCmd} {
+		reference.Flags().VisitAll(func(flag *pflag.Flag) {
+			if cmd.Flags().Lookup(flag.Name) == nil {
+				t.Errorf("%s is missing --%s", cmd.Name(), flag.Name)
+			}
+		})
+		for _, name := range []string{"pre", "post", "model"} {
+			if _, ok := cmd.GetFlagCompletionFunc(name); !ok {
+				t.Errorf("%s doesn't complete --%s", cmd.Name(), name)
+			}
+		}
+	}
+}
+
+func TestTemplateFlagCompletion(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	promptsDir := t.TempDir()
+	for _, name := range []string{"persona", "review"} {
+		path := filepath.Join(promptsDir, "pre", name+".md")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("Template"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(configPath, []byte("prompts_location = \""+promptsDir+"\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, command := range [][]string{{}, {"run", "recipe"}, {"watch"}} {
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		rootCmd.SetErr(&bytes.Buffer{})
+		// After a comma, the next of several names is completed
+		rootCmd.SetArgs(append(append([]string{"__complete"}, command...), "-c", configPath, "--pre", "persona,"))
+		err := rootCmd.Execute()
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+		rootCmd.SetArgs(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(out.String(), "persona,review\n") {
+			t.Errorf("%v: completions = %q, want persona,review", command, out.String())
+		}
+	}
+}
+
+// TestValidateRequest removed - validation is now handled by the orchestrator
+func TestConfigMigrateCommands(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	// migrate-config is the deprecated name of config migrate
+	for _, command := range [][]string{{"config", "migrate"}, {"migrate-config"}} {
+		configPath := filepath.Join(t.TempDir(), "config.toml")
+		if err := os.WriteFile(configPath, []byte("[defaults]\npre = \"review\"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		rootCmd.SetErr(&out)
+		rootCmd.SetArgs(append(command, "-y", "-c", configPath))
+		err := rootCmd.Execute()
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+		rootCmd.SetArgs(nil)
+		if err != nil {
+			t.Fatalf("%v: %v", command, err)
+		}
+
+		migrated, _ := os.ReadFile(configPath)
+		if !strings.Contains(string(migrated), "default_pre = 'review'") || !strings.Contains(string(migrated), "config_version") {
+			t.Errorf("%v: expected the config to be migrated, got:\n%s", command, migrated)
+		}
+		if deprecated := strings.Contains(out.String(), "use 'prompter config migrate' instead"); deprecated != (command[0] == "migrate-config") {
+			t.Errorf("%v: deprecation warning shown = %v, output %q", command, deprecated, out.String())
+		}
+	}
+}
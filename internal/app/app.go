@@ -1,24 +1,33 @@
 package app
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"github.com/atotto/clipboard"
+	"golang.org/x/term"
+	"prompter-cli/internal/content"
 	"prompter-cli/internal/interactive"
 	"prompter-cli/internal/interfaces"
 	"prompter-cli/internal/orchestrator"
+	"prompter-cli/internal/stats"
 	"prompter-cli/internal/template"
 	"prompter-cli/pkg/models"
 )
 
-// Run executes the main application logic
-func Run(request *models.PromptRequest) error {
+// Run executes the main application logic. Cancelling ctx, as Ctrl-C or --timeout do,
+// stops generation and output without recording the prompt.
+func Run(ctx context.Context, request *models.PromptRequest) error {
 	// Create orchestrator first to load configuration
 	orch := orchestrator.New()
+	orch.SetModel(request.Model)
 
 	// Load configuration to get the correct prompts location
 	cfg, err := orch.LoadConfiguration(request.ConfigPath)
@@ -29,41 +38,195 @@ func Run(request *models.PromptRequest) error {
 	// Resolve interactive mode based on flags and config
 	resolveInteractiveMode(request, cfg)
 
+	// Template variables from the config apply unless given for this run
+	request.Vars = orchestrator.MergeVars(cfg.Vars, request.Vars)
+
+	// Catch an outdated prompt still waiting to be pasted
+	warnIfStale(cfg)
+
 	// Create interactive prompter with the configured prompts location
 	prompter := interactive.NewPrompter(cfg.PromptsLocation)
+	prompter.SetPromptsLocations(orchestrator.PromptsLocations(cfg))
+	prompter.SetUsage(loadUsage(cfg))
+	prompter.SetCustomTemplates(cfg.CustomTemplates)
+	if editor, err := resolveEditor(cfg); err == nil {
+		prompter.SetEditor(editor)
+	}
+	if cfg.ConfirmBeforeOutput {
+		prompter.SetConfirmation(previewFunc(ctx, orch))
+	}
 
 	// Collect missing inputs interactively if needed
-	if err := prompter.CollectMissingInputs(request); err != nil {
+	if err := collectInputs(ctx, orch, prompter, request, cfg); err != nil {
+		if interactive.IsAbort(err) {
+			recordTemplateStats(cfg, request.PreTemplate, request.PostTemplate, stats.SignalAbort)
+		}
 		return fmt.Errorf("failed to collect inputs: %w", err)
 	}
 
-	// Generate the prompt
-	prompt, err := orch.GeneratePrompt(request)
+	// Generate the prompt, offering another template when one isn't found
+	prompt, err := orch.GeneratePrompt(ctx, request)
+	for err != nil && request.Interactive && orchestrator.IsRecoverableError(err) {
+		if recoverErr := recoverInteractively(prompter, request, err); recoverErr != nil {
+			break
+		}
+		prompt, err = orch.GeneratePrompt(ctx, request)
+	}
 	if err != nil {
 		return fmt.Errorf("prompt generation failed: %w", err)
 	}
 
-	// Output the prompt
-	if err := orch.OutputPrompt(prompt, request, cfg); err != nil {
+	// Output the prompt, or print it with its metadata for --json, offering stdout or
+	// a file when the clipboard fails
+	if request.JSON {
+		err = printReport(orch.Report())
+	} else {
+		err = orch.OutputPrompt(ctx, prompt, request, cfg)
+		for err != nil && request.Interactive && orchestrator.IsRecoverableError(err) {
+			if recoverErr := recoverInteractively(prompter, request, err); recoverErr != nil {
+				break
+			}
+			err = orch.OutputPrompt(ctx, prompt, request, cfg)
+		}
+	}
+	if err != nil {
 		return fmt.Errorf("output failed: %w", err)
 	}
 
+	signals := []stats.Signal{stats.SignalUse}
+	if isRerun(cfg, request) {
+		signals = append(signals, stats.SignalRerun)
+	}
+	recordTemplateStats(cfg, request.PreTemplate, request.PostTemplate, signals...)
+
+	if edited := orch.EditedPrompt(); edited != "" {
+		prompt = edited // Record what was sent
+	}
+	recordHistory(cfg, orch.Tokenizer(), request, prompt, "")
 	return nil
 }
 
+// recoverInteractively asks the user how to get past a recoverable error: another
+// template in place of one that wasn't found, or another target when output failed.
+// The request is updated to retry with; an error means the user gave up.
+func recoverInteractively(prompter *interactive.Prompter, request *models.PromptRequest, err error) error {
+	var prompterErr *orchestrator.PrompterError
+	if !errors.As(err, &prompterErr) {
+		return err
+	}
+
+	switch prompterErr.Type {
+	case orchestrator.ErrTemplateNotFound:
+		return prompter.ReplaceTemplate(request, prompterErr.Subject)
+	case orchestrator.ErrOutputFailed:
+		target, err := prompter.ChooseOutput(prompterErr.Subject)
+		if err != nil {
+			return err
+		}
+		request.Target = target
+		return nil
+	}
+	return err
+}
+
+// printReport writes report to stdout as indented JSON, leaving the markup common
+// in prompts unescaped
+func printReport(report *orchestrator.Report) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("failed to encode prompt report: %w", err)
+	}
+	return nil
+}
+
+// missingInputs marks a report of missing inputs as a validation failure, so it
+// exits with orchestrator.ExitValidation
+func missingInputs(err error) error {
+	if errors.Is(err, interactive.ErrMissingInputs) {
+		return orchestrator.WithCategory(err, orchestrator.ErrValidationFailed)
+	}
+	return err
+}
+
+// collectInputs fills in missing inputs, in the full-screen interface when --tui or
+// tui = true asks for it and stdin and stdout are terminals
+func collectInputs(ctx context.Context, orch *orchestrator.Orchestrator, prompter *interactive.Prompter, request *models.PromptRequest, cfg *interfaces.Config) error {
+	// Without questions the default templates are used, so their variables are
+	// checked along with the rest of the inputs
+	if !request.Interactive {
+		if request.PreTemplate == "" {
+			request.PreTemplate = cfg.DefaultPre
+		}
+		if request.PostTemplate == "" {
+			request.PostTemplate = cfg.DefaultPost
+		}
+	}
+
+	useTUI := request.Interactive && !request.FixMode && (request.TUI || cfg.TUI)
+	if useTUI && !hasTerminal() {
+		if request.TUI {
+			return fmt.Errorf("--tui needs a terminal")
+		}
+		useTUI = false // Set in the config, so fall back to the questions
+	}
+	if !useTUI {
+		return missingInputs(prompter.CollectMissingInputs(request))
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	// Without a file list, the files pane still offers the requested files
+	files, _ := content.ListFiles(cwd, orchestrator.ContentOptions(cfg, request))
+
+	return prompter.CollectInputsTUI(request, files, previewFunc(ctx, orch))
+}
+
+// previewFunc renders previews for the interactive prompter with orch
+func previewFunc(ctx context.Context, orch *orchestrator.Orchestrator) interactive.PreviewFunc {
+	return func(request *models.PromptRequest) (*interactive.Preview, error) {
+		preview, err := orch.PreviewPrompt(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		return &interactive.Preview{
+			Prompt:    preview.Prompt,
+			System:    preview.System,
+			Tokens:    preview.Tokens,
+			Bytes:     preview.Bytes,
+			Files:     preview.Files,
+			Warnings:  preview.Warnings,
+			Templates: preview.Templates,
+			Target:    preview.Target,
+		}, nil
+	}
+}
+
 // resolveInteractiveMode determines the final interactive mode based on flags and config
 func resolveInteractiveMode(request *models.PromptRequest, cfg *interfaces.Config) {
 	// Priority: explicit flags > config default
-	if request.ForceInteractive {
+	if request.ForceInteractive || request.TUI {
 		request.Interactive = true
 	} else if request.ForceNonInteractive {
 		request.Interactive = false
+	} else if cfg.InteractiveDefault && !hasTerminal() {
+		// In CI, cron, and pipes nobody can answer, so questions would only hang
+		slog.Debug("no terminal, running non-interactively")
+		request.Interactive = false
 	} else {
 		// Use config default
 		request.Interactive = cfg.InteractiveDefault
 	}
 }
 
+// hasTerminal reports whether stdin and stdout are both terminals, as questions need
+func hasTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
 // getDefaultPromptsLocation returns the default prompts location
 func getDefaultPromptsLocation() string {
 	// Try to get from current working directory first
@@ -125,6 +288,8 @@ func ListTemplates(request *models.PromptRequest) error {
 		
 		if isCustom {
 			fmt.Printf("  - %s (custom: %s)\n", displayPath, customName)
+		} else if isSharedLocation(cfg, location) {
+			fmt.Printf("  - %s (shared)\n", displayPath)
 		} else {
 			fmt.Printf("  - %s\n", displayPath)
 		}
@@ -159,8 +324,26 @@ func ListTemplates(request *models.PromptRequest) error {
 		}
 	}
 
+	// Add built-in templates that aren't shadowed by files on disk
+	for _, tmpl := range template.EmbeddedTemplateNames("pre") {
+		if _, exists := allPreTemplates[tmpl]; !exists {
+			allPreTemplates[tmpl] = template.EmbeddedPrefix
+		}
+	}
+	for _, tmpl := range template.EmbeddedTemplateNames("post") {
+		if _, exists := allPostTemplates[tmpl]; !exists {
+			allPostTemplates[tmpl] = template.EmbeddedPrefix
+		}
+	}
+
 	// Helper function to get template label
 	getTemplateLabel := func(location string) string {
+		if location == template.EmbeddedPrefix {
+			return " (built-in)"
+		}
+		if isSharedLocation(cfg, location) {
+			return " (shared)"
+		}
 		// Check if it's local
 		if len(locations) > 1 && location != cfg.PromptsLocation {
 			// Check if it's a custom template
@@ -206,6 +389,17 @@ func ListTemplates(request *models.PromptRequest) error {
 	return nil
 }
 
+// isSharedLocation reports whether location is a prompts_location entry after the first
+func isSharedLocation(cfg *interfaces.Config, location string) bool {
+	locations := orchestrator.PromptsLocations(cfg)
+	for _, shared := range locations[1:] {
+		if location == shared && location != cfg.PromptsLocation {
+			return true
+		}
+	}
+	return false
+}
+
 // listTemplatesInDir lists all .md files in a directory
 func listTemplatesInDir(dir string) ([]string, error) {
 	entries, err := os.ReadDir(dir)
@@ -395,22 +589,33 @@ func OpenPromptsDirectory(request *models.PromptRequest) error {
 	}
 
 	// Get the editor command
-	editor := cfg.Editor
-	if editor == "" {
-		// Fallback to environment variables
-		if envEditor := os.Getenv("EDITOR"); envEditor != "" {
-			editor = envEditor
-		} else if envEditor := os.Getenv("VISUAL"); envEditor != "" {
-			editor = envEditor
-		} else {
-			return fmt.Errorf("no editor configured. Set 'editor' in config file or EDITOR/VISUAL environment variable")
-		}
+	editor, err := resolveEditor(cfg)
+	if err != nil {
+		return err
 	}
 
 	fmt.Printf("Opening prompts directory in %s: %s\n", editor, contractPath(cfg.PromptsLocation))
 
-	// Execute the editor command
-	cmd := exec.Command(editor, cfg.PromptsLocation)
+	return openInEditor(editor, cfg.PromptsLocation)
+}
+
+// resolveEditor returns the configured editor, falling back to $EDITOR and $VISUAL
+func resolveEditor(cfg *interfaces.Config) (string, error) {
+	if cfg.Editor != "" {
+		return cfg.Editor, nil
+	}
+	if envEditor := os.Getenv("EDITOR"); envEditor != "" {
+		return envEditor, nil
+	}
+	if envEditor := os.Getenv("VISUAL"); envEditor != "" {
+		return envEditor, nil
+	}
+	return "", fmt.Errorf("no editor configured. Set 'editor' in config file or EDITOR/VISUAL environment variable")
+}
+
+// openInEditor opens path in editor attached to the terminal and waits for it to exit
+func openInEditor(editor, path string) error {
+	cmd := exec.Command(editor, path)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
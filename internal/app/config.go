@@ -0,0 +1,236 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"sort"
+	"text/tabwriter"
+
+	"prompter-cli/internal/config"
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/pkg/models"
+)
+
+// GetConfigValue prints the value of a setting as prompter resolves it, including
+// project configs and PROMPTER_ environment variables. Lists print one item per line
+// and tables print one "key = value" line per setting.
+func GetConfigValue(request *models.PromptRequest, key string) error {
+	manager := config.NewManager()
+	if _, err := manager.Load(request.ConfigPath); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	value, ok := manager.Get(key)
+	if !ok {
+		return config.UnknownKeyError(key)
+	}
+	return printConfigValue("", value)
+}
+
+// printConfigValue prints a setting for GetConfigValue, prefixing table settings with
+// the table name
+func printConfigValue(prefix string, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			if _, isTable := v[key].(map[string]interface{}); isTable {
+				if err := printConfigValue(name, v[key]); err != nil {
+					return err
+				}
+				continue
+			}
+			encoded, err := config.EncodeValue(v[key])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s = %s\n", name, encoded)
+		}
+	default:
+		if list := reflect.ValueOf(value); list.Kind() == reflect.Slice {
+			for i := 0; i < list.Len(); i++ {
+				item := list.Index(i).Interface()
+				if _, isTable := item.(map[string]interface{}); isTable {
+					// Entries of a list of tables, such as template_sources
+					encoded, err := config.EncodeValue(item)
+					if err != nil {
+						return err
+					}
+					item = encoded
+				}
+				fmt.Println(item)
+			}
+			return nil
+		}
+		fmt.Println(value)
+	}
+	return nil
+}
+
+// ShowConfig prints the settings made in the config file and project configs. With
+// resolved, it prints every setting after flags > env > project configs > config file >
+// defaults precedence with the source of each value, as JSON with asJSON. The flags
+// are the setting overrides carried by request.
+func ShowConfig(request *models.PromptRequest, resolved, asJSON bool) error {
+	manager := config.NewManager()
+	if _, err := manager.Load(request.ConfigPath); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	manager.SetFlag("target", request.Target)
+	manager.SetFlag("editor", request.Editor)
+	manager.SetFlag("fix_file", request.FixFile)
+	manager.SetFlag("max_tokens", request.MaxTokens)
+	manager.SetFlag("model", request.Model)
+	for name, value := range request.Vars {
+		manager.SetFlag("vars."+name, value)
+	}
+
+	settings := manager.Settings()
+
+	if asJSON {
+		encoded, err := json.MarshalIndent(settings, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode config: %w", err)
+		}
+		fmt.Println(string(encoded))
+		return nil
+	}
+
+	if !resolved {
+		path, err := config.ResolveConfigPath(request.ConfigPath)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err == nil {
+			fmt.Printf("# %s\n", contractPath(path))
+		} else {
+			fmt.Printf("# %s (not found, using defaults)\n", contractPath(path))
+		}
+		for _, project := range manager.ProjectConfigs() {
+			fmt.Printf("# %s (project)\n", contractPath(project))
+		}
+
+		for _, setting := range settings {
+			if setting.Source != config.SourceFile && setting.Source != config.SourceProject {
+				continue
+			}
+			encoded, err := config.EncodeValue(setting.Value)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s = %s\n", setting.Key, encoded)
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, setting := range settings {
+		encoded, err := config.EncodeValue(setting.Value)
+		if err != nil {
+			return err
+		}
+		source := setting.Source
+		switch setting.Source {
+		case config.SourceEnv:
+			source += " (" + setting.Origin + ")"
+		case config.SourceFile, config.SourceProject:
+			source += " (" + contractPath(setting.Origin) + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", setting.Key, encoded, source)
+	}
+	return w.Flush()
+}
+
+// SetConfigValue writes a setting to the config file (the global one, or the one
+// given with -c) after checking that the resulting configuration is valid. The rest
+// of the file, comments included, is left as it was.
+func SetConfigValue(request *models.PromptRequest, key, raw string) error {
+	path, err := config.ResolveConfigPath(request.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	// Unknown keys and invalid values exit with orchestrator.ExitValidation
+	value, err := config.ParseValue(key, raw)
+	if err != nil {
+		return orchestrator.WithCategory(err, orchestrator.ErrValidationFailed)
+	}
+
+	manager := config.NewManager()
+	if _, err := manager.Load(path); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	if err := manager.Set(key, value); err != nil {
+		return orchestrator.WithCategory(fmt.Errorf("cannot set %s: %w", key, err), orchestrator.ErrValidationFailed)
+	}
+
+	if err := config.SetFileValue(path, key, value); err != nil {
+		return err
+	}
+	encoded, _ := config.EncodeValue(value)
+	fmt.Printf("Set %s = %s in %s\n", key, encoded, contractPath(path))
+
+	// A project config or environment variable may still take precedence
+	reloaded := config.NewManager()
+	if _, err := reloaded.Load(path); err == nil {
+		if effective, _ := reloaded.Get(key); fmt.Sprint(effective) != fmt.Sprint(value) {
+			fmt.Fprintf(os.Stderr, "Note: %s is overridden by a project config or PROMPTER_ environment variable here (effective value: %v)\n", key, effective)
+		}
+	}
+	return nil
+}
+
+// EditConfig opens the config file in the configured editor and checks it once the
+// editor exits
+func EditConfig(request *models.PromptRequest) error {
+	path, err := config.ResolveConfigPath(request.ConfigPath)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("config file not found: %s (create one with 'prompter init')", contractPath(path))
+	}
+
+	// A broken config can still be opened to fix it, using $EDITOR or $VISUAL
+	cfg, err := config.NewManager().Load(path)
+	if err != nil {
+		cfg = &interfaces.Config{}
+	}
+	editor, err := resolveEditor(cfg)
+	if err != nil {
+		return err
+	}
+	if err := openInEditor(editor, path); err != nil {
+		return err
+	}
+
+	manager := config.NewManager()
+	cfg, err = manager.Load(path)
+	if err == nil {
+		err = manager.Validate(cfg)
+	}
+	if err != nil {
+		return fmt.Errorf("%s has errors, run 'prompter config edit' to fix them: %w", contractPath(path), err)
+	}
+	for _, key := range manager.UnknownKeys() {
+		slog.Warn(key.String())
+	}
+	fmt.Printf("Config is valid: %s\n", contractPath(path))
+	return nil
+}
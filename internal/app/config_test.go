@@ -0,0 +1,36 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/pkg/models"
+)
+
+func TestSetConfigValue_Invalid(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	writeFile(t, configPath, "max_tokens = 1000\n")
+	request := models.NewPromptRequest()
+	request.ConfigPath = configPath
+
+	tests := []struct {
+		key, value string
+	}{
+		{"max_tokens", "abc"},     // Not a number
+		{"target", "nowhere"},     // Fails validation
+		{"no_such_setting", "on"}, // Unknown key
+	}
+	for _, tt := range tests {
+		err := SetConfigValue(request, tt.key, tt.value)
+		if code := orchestrator.ExitCode(err); code != orchestrator.ExitValidation {
+			t.Errorf("config set %s %s: exit code %d (%v), want %d", tt.key, tt.value, code, err, orchestrator.ExitValidation)
+		}
+	}
+	if content, _ := os.ReadFile(configPath); string(content) != "max_tokens = 1000\n" {
+		t.Errorf("expected the config file to be left alone, got %q", content)
+	}
+}
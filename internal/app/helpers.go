@@ -0,0 +1,80 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/internal/template"
+	"prompter-cli/pkg/models"
+)
+
+// ListHelpers prints a reference of every template function available to templates,
+// optionally filtered by name, with a rendered example for each helper that has one
+func ListHelpers(request *models.PromptRequest, names []string, asJSON bool) error {
+	// Create orchestrator to load configuration (plugins are configured there)
+	orch := orchestrator.New()
+
+	if _, err := orch.LoadConfiguration(request.ConfigPath); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	processor, ok := orch.GetTemplateProcessor().(*template.Processor)
+	if !ok {
+		return fmt.Errorf("template processor does not support helper documentation")
+	}
+
+	docs := filterHelperDocs(processor.HelperDocs(), names)
+	if len(names) > 0 && len(docs) == 0 {
+		return fmt.Errorf("no template helper named %s", strings.Join(names, ", "))
+	}
+
+	if asJSON {
+		encoded, err := json.MarshalIndent(docs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode helpers: %w", err)
+		}
+		fmt.Println(string(encoded))
+		return nil
+	}
+
+	source := ""
+	for _, doc := range docs {
+		if doc.Source != source {
+			source = doc.Source
+			fmt.Printf("%s helpers:\n\n", strings.ToUpper(source[:1])+source[1:])
+		}
+
+		fmt.Printf("  %s\n", doc.Signature)
+		fmt.Printf("      %s\n", doc.Description)
+		if doc.Example != "" {
+			fmt.Printf("      Example: %s\n", doc.Example)
+			// Only built-in and sprig helpers are rendered; plugin helpers may have side effects
+			if output, err := processor.RenderExample(doc.Example); err == nil {
+				fmt.Printf("      Output:  %s\n", strings.ReplaceAll(output, "\n", "\n               "))
+			}
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// filterHelperDocs returns the docs whose names match any of names (case-insensitive)
+func filterHelperDocs(docs []template.HelperDoc, names []string) []template.HelperDoc {
+	if len(names) == 0 {
+		return docs
+	}
+
+	var filtered []template.HelperDoc
+	for _, doc := range docs {
+		for _, name := range names {
+			if strings.EqualFold(doc.Name, name) {
+				filtered = append(filtered, doc)
+				break
+			}
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,495 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/atotto/clipboard"
+	"prompter-cli/internal/content"
+	"prompter-cli/internal/history"
+	"prompter-cli/internal/interactive"
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/internal/redact"
+	"prompter-cli/internal/stats"
+	"prompter-cli/internal/tokenizer"
+	"prompter-cli/pkg/models"
+)
+
+// historyStore returns the configured history store
+func historyStore(cfg *interfaces.Config) *history.Store {
+	return history.NewStore(cfg.HistoryLocation, cfg.HistoryLimit)
+}
+
+// recordHistory saves a generated prompt to history when enabled, with its size
+// counted by tok. Failures are reported as warnings since the prompt has already been
+// delivered.
+func recordHistory(cfg *interfaces.Config, tok tokenizer.Tokenizer, request *models.PromptRequest, prompt, parentID string) *history.Entry {
+	if !cfg.HistoryEnabled {
+		return nil
+	}
+
+	workDir, _ := os.Getwd()
+	entry := &history.Entry{
+		WorkDir:           workDir,
+		Snapshot:          history.TakeSnapshot(workDir, includedPaths(cfg, request)),
+		Prompt:            prompt,
+		Tokens:            tok.Count(prompt),
+		Tokenizer:         tok.Name(),
+		BasePrompt:        request.BasePrompt,
+		PreTemplate:       request.PreTemplate,
+		PostTemplate:      request.PostTemplate,
+		Files:             request.Files,
+		Symbols:           request.Symbols,
+		Directory:         request.Directory,
+		DirectoryStrategy: request.DirectoryStrategy,
+		Exclude:           request.Exclude,
+		MaxTokens:         request.MaxTokens,
+		Model:             request.Model,
+		Outline:           request.Outline,
+		WithDeps:          request.WithDeps,
+		Todos:             request.Todos,
+		WithDocs:          request.WithDocs,
+		Vars:              request.Vars,
+		Target:            request.Target,
+		ParentID:          parentID,
+	}
+	if target := entryTarget(entry, cfg); models.IsFileTarget(target) {
+		entry.OutputFile, _ = orchestrator.FileTargetPath(target, request, cfg)
+	}
+	redactEntry(entry, cfg, request)
+	if err := historyStore(cfg).Save(entry); err != nil {
+		slog.Warn(fmt.Sprintf("failed to save prompt history: %v", err))
+		return nil
+	}
+	return entry
+}
+
+// redactEntry replaces secrets in the base prompt and variables of an entry, as they
+// already are in its prompt, unless redaction is turned off
+func redactEntry(entry *history.Entry, cfg *interfaces.Config, request *models.PromptRequest) {
+	if !cfg.Redact || request.NoRedact {
+		return
+	}
+	redactor, err := redact.New(cfg.RedactPatterns)
+	if err != nil {
+		return // Reported when generating
+	}
+
+	entry.BasePrompt, _ = redactor.Redact(entry.BasePrompt)
+	if len(entry.Vars) > 0 {
+		vars := make(map[string]string, len(entry.Vars))
+		for name, value := range entry.Vars {
+			vars[name], _ = redactor.Redact(value)
+		}
+		entry.Vars = vars
+	}
+}
+
+// includedPaths returns the absolute paths of every file a request draws on
+func includedPaths(cfg *interfaces.Config, request *models.PromptRequest) []string {
+	var paths []string
+	for _, spec := range request.Files {
+		file, _, err := content.SplitFileSpec(spec)
+		if err != nil {
+			continue
+		}
+		if abs, err := filepath.Abs(file); err == nil {
+			paths = append(paths, abs)
+		}
+	}
+
+	if request.Directory != "" {
+		if files, err := content.ListFiles(request.Directory, orchestrator.ContentOptions(cfg, request)); err == nil {
+			for _, file := range files {
+				if abs, err := filepath.Abs(filepath.Join(request.Directory, file)); err == nil {
+					paths = append(paths, abs)
+				}
+			}
+		}
+	}
+
+	return paths
+}
+
+// entryTarget returns the target a history entry was sent to
+func entryTarget(entry *history.Entry, cfg *interfaces.Config) string {
+	if entry.Target != "" {
+		return entry.Target
+	}
+	return cfg.Target
+}
+
+// holdsPrompt reports whether a file's contents are the prompt, or end with it when
+// prompts are appended to the file
+func holdsPrompt(held, prompt string, appended bool) bool {
+	if !appended {
+		return held == prompt
+	}
+	return strings.HasSuffix(strings.TrimRight(held, "\n"), strings.TrimRight(prompt, "\n"))
+}
+
+// warnIfStale warns when the last prompt is still sitting in its clipboard or file
+// target but the files it was built from have changed since
+func warnIfStale(cfg *interfaces.Config) {
+	if !cfg.HistoryEnabled {
+		return
+	}
+
+	entry, err := historyStore(cfg).Latest()
+	if err != nil {
+		return
+	}
+
+	target := entryTarget(entry, cfg)
+
+	var location string
+	switch {
+	case target == models.TargetClipboard:
+		held, err := clipboard.ReadAll()
+		if err != nil || held != entry.Prompt {
+			return
+		}
+		location = "your clipboard"
+	case models.IsFileTarget(target):
+		path := entry.OutputFile
+		if path == "" {
+			// Recorded before output files were; resolve the target as output does
+			request := &models.PromptRequest{BasePrompt: entry.BasePrompt, PreTemplate: entry.PreTemplate, PostTemplate: entry.PostTemplate, Vars: entry.Vars}
+			if path, err = orchestrator.FileTargetPath(target, request, cfg); err != nil {
+				return
+			}
+		}
+		held, err := os.ReadFile(path)
+		if err != nil || !holdsPrompt(string(held), entry.Prompt, strings.HasPrefix(target, models.TargetAppendPrefix)) {
+			return
+		}
+		location = contractPath(path)
+	default:
+		return
+	}
+
+	staleness := entry.Snapshot.Compare(entry.WorkDir)
+	if !staleness.Stale() {
+		return
+	}
+
+	var reasons []string
+	if n := len(staleness.ChangedFiles); n > 0 {
+		shown := staleness.ChangedFiles
+		if len(shown) > 3 {
+			shown = shown[:3]
+		}
+		names := make([]string, len(shown))
+		for i, path := range shown {
+			names[i] = contractPath(path)
+		}
+		more := ""
+		if n > len(shown) {
+			more = fmt.Sprintf(", and %d more", n-len(shown))
+		}
+		reasons = append(reasons, fmt.Sprintf("%d of %d files changed (%s%s)", n, staleness.TrackedFiles, strings.Join(names, ", "), more))
+	}
+	if staleness.CommitChanged {
+		reasons = append(reasons, "new commits were made")
+	}
+
+	slog.Warn(fmt.Sprintf("the prompt in %s (generated %s) is stale: %s",
+		location, entry.Time.Format("Jan 2 15:04"), strings.Join(reasons, " and ")))
+}
+
+// Continue renders a follow-up to a prompt from history (the latest when id is
+// empty) and outputs only the framed follow-up
+func Continue(ctx context.Context, request *models.PromptRequest, id string) error {
+	orch := orchestrator.New()
+	orch.SetModel(request.Model)
+
+	cfg, err := orch.LoadConfiguration(request.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	resolveInteractiveMode(request, cfg)
+
+	store := historyStore(cfg)
+	var previous *history.Entry
+	if id == "" {
+		previous, err = store.Latest()
+	} else {
+		previous, err = store.Get(id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load previous prompt: %w", err)
+	}
+
+	// Ask for the follow-up when it wasn't given on the command line
+	if request.BasePrompt == "" && request.Interactive {
+		prompter := interactive.NewPrompter(cfg.PromptsLocation)
+		if request.BasePrompt, err = prompter.CollectFollowUp(); err != nil {
+			return fmt.Errorf("failed to collect follow-up prompt: %w", err)
+		}
+	}
+
+	prompt, err := orch.GenerateContinuation(request, interfaces.PreviousPrompt{
+		ID:         previous.ID,
+		Time:       previous.Time,
+		Prompt:     previous.Prompt,
+		BasePrompt: previous.BasePrompt,
+	})
+	if err != nil {
+		return fmt.Errorf("prompt generation failed: %w", err)
+	}
+
+	if err := orch.OutputPrompt(ctx, prompt, request, cfg); err != nil {
+		return fmt.Errorf("output failed: %w", err)
+	}
+
+	// Credit the templates of the prompt being followed up on
+	recordTemplateStats(cfg, previous.PreTemplate, previous.PostTemplate, stats.SignalContinuation)
+
+	if edited := orch.EditedPrompt(); edited != "" {
+		prompt = edited // Record what was sent
+	}
+	recordHistory(cfg, orch.Tokenizer(), request, prompt, previous.ID)
+	return nil
+}
+
+// ListHistory prints the most recent prompts in history, all of them when limit is 0
+func ListHistory(request *models.PromptRequest, limit int) error {
+	cfg, err := orchestrator.New().LoadConfiguration(request.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	entries, err := historyStore(cfg).List()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		if !cfg.HistoryEnabled {
+			fmt.Println("Prompt history is disabled (history_enabled = false).")
+		} else {
+			fmt.Println("No prompts in history yet.")
+		}
+		return nil
+	}
+
+	return printHistory(entries, limit)
+}
+
+// SearchHistory prints the prompts in history matching every word of query
+func SearchHistory(request *models.PromptRequest, query string, limit int) error {
+	cfg, err := orchestrator.New().LoadConfiguration(request.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	entries, err := historyStore(cfg).Search(query)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Printf("No prompts in history match %q.\n", query)
+		return nil
+	}
+
+	return printHistory(entries, limit)
+}
+
+// printHistory writes a table of history entries, newest first
+func printHistory(entries []*history.Entry, limit int) error {
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTIME\tTOKENS\tTEMPLATES\tPROMPT")
+	for _, entry := range entries {
+		tokens := "-"
+		if entry.Tokens > 0 {
+			tokens = fmt.Sprintf("%d", entry.Tokens)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			entry.ID, entry.Time.Format("2006-01-02 15:04"), tokens, historyTemplates(entry), historySummary(entry))
+	}
+	return w.Flush()
+}
+
+// historyTemplates describes the templates an entry was generated with
+func historyTemplates(entry *history.Entry) string {
+	var names []string
+	for _, name := range models.TemplateNames(entry.PreTemplate) {
+		names = append(names, "pre/"+name)
+	}
+	for _, name := range models.TemplateNames(entry.PostTemplate) {
+		names = append(names, "post/"+name)
+	}
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, ", ")
+}
+
+// historySummary returns the first line of an entry's base prompt (or of the prompt
+// itself when there was none), shortened for listings
+func historySummary(entry *history.Entry) string {
+	text := entry.BasePrompt
+	if strings.TrimSpace(text) == "" {
+		text = entry.Prompt
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+
+	const maxLength = 60
+	if runes := []rune(line); len(runes) > maxLength {
+		return string(runes[:maxLength-3]) + "..."
+	}
+	return line
+}
+
+// ShowHistory prints a prompt from history with the request that produced it
+func ShowHistory(request *models.PromptRequest, id string) error {
+	cfg, err := orchestrator.New().LoadConfiguration(request.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	entry, err := historyStore(cfg).Get(id)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(w, "%s:\t%s\n", name, value)
+		}
+	}
+	field("ID", entry.ID)
+	field("Time", entry.Time.Format("2006-01-02 15:04:05"))
+	if entry.Tokens > 0 {
+		field("Tokens", fmt.Sprintf("%d (%s)", entry.Tokens, entry.Tokenizer))
+	}
+	field("Pre-template", entry.PreTemplate)
+	field("Post-template", entry.PostTemplate)
+	field("Base prompt", entry.BasePrompt)
+	field("Files", strings.Join(entry.Files, ", "))
+	field("Symbols", strings.Join(entry.Symbols, ", "))
+	field("Directory", contractPath(entry.Directory))
+	field("Exclude", strings.Join(entry.Exclude, ", "))
+	if entry.MaxTokens > 0 {
+		field("Max tokens", fmt.Sprintf("%d", entry.MaxTokens))
+	}
+	field("Model", entry.Model)
+	if entry.Outline {
+		field("Outline", "yes")
+	}
+	if entry.WithDeps > 0 {
+		field("With deps", fmt.Sprintf("%d", entry.WithDeps))
+	}
+	if entry.Todos {
+		field("Todos", "yes")
+	}
+	if entry.WithDocs {
+		field("With docs", "yes")
+	}
+	for _, name := range sortedKeys(entry.Vars) {
+		field("Var "+name, entry.Vars[name])
+	}
+	field("Target", entry.Target)
+	field("Follows", entry.ParentID)
+	field("Working dir", contractPath(entry.WorkDir))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%s\n", entry.Prompt)
+	return nil
+}
+
+// ReplayHistory generates a prompt again from the request recorded in history.
+// Values set on request (from flags or arguments) replace the recorded ones, so a
+// replay can tweak the templates, target, or variables. It runs non-interactively
+// unless -i is given.
+func ReplayHistory(ctx context.Context, request *models.PromptRequest, id string) error {
+	cfg, err := orchestrator.New().LoadConfiguration(request.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	entry, err := historyStore(cfg).Get(id)
+	if err != nil {
+		return err
+	}
+
+	ApplyHistoryEntry(request, entry)
+	if !request.ForceInteractive {
+		request.ForceNonInteractive = true
+	}
+
+	return Run(ctx, request)
+}
+
+// ApplyHistoryEntry fills in request fields from a history entry. Values already set
+// on the request take precedence; files, exclude patterns, and variables are combined.
+// Relative files are resolved against the directory the entry was generated in.
+func ApplyHistoryEntry(request *models.PromptRequest, entry *history.Entry) {
+	if request.BasePrompt == "" {
+		request.BasePrompt = entry.BasePrompt
+	}
+	if request.PreTemplate == "" {
+		request.PreTemplate = entry.PreTemplate
+	}
+	if request.PostTemplate == "" {
+		request.PostTemplate = entry.PostTemplate
+	}
+
+	var files []string
+	for _, file := range entry.Files {
+		if !filepath.IsAbs(file) && entry.WorkDir != "" {
+			file = filepath.Join(entry.WorkDir, file)
+		}
+		files = append(files, file)
+	}
+	request.Files = append(files, request.Files...)
+	request.Symbols = append(append([]string{}, entry.Symbols...), request.Symbols...)
+
+	if request.Directory == "" {
+		request.Directory = entry.Directory
+	}
+	if request.DirectoryStrategy == "" {
+		request.DirectoryStrategy = entry.DirectoryStrategy
+	}
+	request.Exclude = append(append([]string{}, entry.Exclude...), request.Exclude...)
+	if request.MaxTokens == 0 {
+		request.MaxTokens = entry.MaxTokens
+	}
+	if request.Model == "" {
+		request.Model = entry.Model
+	}
+	request.Outline = request.Outline || entry.Outline
+	if request.WithDeps == 0 {
+		request.WithDeps = entry.WithDeps
+	}
+	request.Todos = request.Todos || entry.Todos
+	request.WithDocs = request.WithDocs || entry.WithDocs
+	request.Vars = orchestrator.MergeVars(entry.Vars, request.Vars)
+	if request.Target == "" {
+		request.Target = entry.Target
+	}
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
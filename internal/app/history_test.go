@@ -0,0 +1,109 @@
+package app
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/tokenizer"
+	"prompter-cli/pkg/models"
+)
+
+// captureWarnings sends log messages to a buffer for the rest of the test
+func captureWarnings(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func TestWarnIfStale_FileTargets(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string // %s is replaced with the output directory
+		file   string // Output file, relative to the output directory
+		held   string // Contents besides the prompt
+	}{
+		{name: "file", target: "file:%s/prompt.md", file: "prompt.md"},
+		{name: "appended", target: "file+:%s/prompts.md", file: "prompts.md", held: "earlier prompt\n\n---\n\n"},
+		{name: "placeholders", target: "file:%s/{{.Pre}}.md", file: "review.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			included := filepath.Join(dir, "main.go")
+			writeFile(t, included, "package main\n")
+
+			cfg := &interfaces.Config{HistoryEnabled: true, HistoryLocation: t.TempDir(), HistoryLimit: 10}
+			request := models.NewPromptRequest()
+			request.Files = []string{included}
+			request.PreTemplate = "review"
+			request.Target = strings.ReplaceAll(tt.target, "%s", dir)
+
+			prompt := "review main.go\n"
+			writeFile(t, filepath.Join(dir, tt.file), tt.held+prompt)
+			if recordHistory(cfg, tokenizer.NewApproximate(), request, prompt, "") == nil {
+				t.Fatal("expected the prompt to be recorded")
+			}
+
+			warnings := captureWarnings(t)
+			warnIfStale(cfg)
+			if warnings.Len() != 0 {
+				t.Fatalf("expected no warning before files change, got %s", warnings)
+			}
+
+			writeFile(t, included, "package main\n\nfunc main() {}\n")
+			warnIfStale(cfg)
+			if !strings.Contains(warnings.String(), "is stale") {
+				t.Errorf("expected a stale prompt warning, got %q", warnings)
+			}
+		})
+	}
+}
+
+func TestRecordHistory_Redacts(t *testing.T) {
+	token := "ghp_" + strings.Repeat("a", 36)
+	request := models.NewPromptRequest()
+	request.BasePrompt = "why does " + token + " fail?"
+	request.Vars = map[string]string{"token": token, "ticket": "PLAT-1"}
+
+	cfg := &interfaces.Config{HistoryEnabled: true, HistoryLocation: t.TempDir(), HistoryLimit: 10, Redact: true}
+	entry := recordHistory(cfg, tokenizer.NewApproximate(), request, "why does [REDACTED:github-token] fail?", "")
+	if entry == nil {
+		t.Fatal("expected the prompt to be recorded")
+	}
+	saved, err := historyStore(cfg).Get(entry.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(saved.BasePrompt, token) || saved.Vars["token"] != "[REDACTED:github-token]" || saved.Vars["ticket"] != "PLAT-1" {
+		t.Errorf("expected secrets to be redacted before saving, got %q, %v", saved.BasePrompt, saved.Vars)
+	}
+	if request.Vars["token"] != token {
+		t.Error("expected the request's variables to be left alone")
+	}
+
+	// --no-redact keeps them, as it does in the prompt
+	request.NoRedact = true
+	if entry = recordHistory(cfg, tokenizer.NewApproximate(), request, request.BasePrompt, ""); entry == nil || entry.BasePrompt != request.BasePrompt {
+		t.Errorf("expected --no-redact to save the base prompt as given, got %+v", entry)
+	}
+}
+
+func TestHoldsPrompt(t *testing.T) {
+	if !holdsPrompt("a\n\n---\n\nprompt\n", "prompt", true) {
+		t.Error("expected an appended file ending with the prompt to hold it")
+	}
+	if holdsPrompt("prompt\n\n---\n\nlater\n", "prompt", true) {
+		t.Error("expected a file with a later prompt appended not to hold it")
+	}
+	if holdsPrompt("a\nprompt", "prompt", false) {
+		t.Error("expected a written file to hold only the prompt")
+	}
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"prompter-cli/internal/config"
+	"prompter-cli/internal/interactive"
+	"prompter-cli/internal/template"
+	"prompter-cli/pkg/models"
+)
+
+// InitOptions are the flags of the init command
+type InitOptions struct {
+	Project bool // Set up .prmpt/ in the current directory
+	Global  bool // Set up the global config without asking
+	Force   bool // Overwrite an existing config file without asking
+}
+
+// Init creates a config file, a prompts directory with pre/ and post/ subdirectories,
+// and the starter templates, either globally or for the current project. Existing
+// templates are never overwritten.
+func Init(request *models.PromptRequest, options InitOptions) error {
+	if options.Project && options.Global {
+		return fmt.Errorf("cannot use both --project and --global")
+	}
+
+	// The config may not exist yet, so only the flags decide whether to ask
+	interactiveMode := !request.ForceNonInteractive
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "nvim"
+	}
+	choices := interactive.SetupChoices{
+		Project:          options.Project,
+		AskScope:         !options.Project && !options.Global,
+		Target:           models.TargetClipboard,
+		Editor:           editor,
+		StarterTemplates: true,
+	}
+
+	prompter := interactive.NewPrompter("")
+	if interactiveMode {
+		if err := prompter.CollectSetup(&choices); err != nil {
+			return fmt.Errorf("failed to collect setup choices: %w", err)
+		}
+	}
+
+	var configPath, promptsDir string
+	if choices.Project {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		configPath = filepath.Join(cwd, config.ProjectDir, "config.toml")
+		promptsDir = filepath.Join(cwd, config.ProjectDir, "prompts")
+	} else {
+		var err error
+		if configPath, err = config.ResolveConfigPath(request.ConfigPath); err != nil {
+			return err
+		}
+		promptsDir = filepath.Join(filepath.Dir(configPath), "prompts")
+	}
+
+	for _, kind := range []string{"pre", "post"} {
+		if err := os.MkdirAll(filepath.Join(promptsDir, kind), 0755); err != nil {
+			return fmt.Errorf("failed to create prompts directory: %w", err)
+		}
+	}
+
+	// Keep an existing config unless replacing it is confirmed
+	writeConfig := true
+	if _, err := os.Stat(configPath); err == nil && !options.Force {
+		writeConfig = false
+		if interactiveMode {
+			overwrite, err := prompter.ConfirmConfigOverwrite(contractPath(configPath))
+			if err != nil {
+				return fmt.Errorf("failed to get overwrite confirmation: %w", err)
+			}
+			writeConfig = overwrite
+		}
+	}
+	if writeConfig {
+		if err := os.WriteFile(configPath, []byte(initConfig(choices, promptsDir)), 0644); err != nil {
+			return fmt.Errorf("failed to write config file: %w", err)
+		}
+		fmt.Printf("Created config: %s\n", contractPath(configPath))
+	} else {
+		fmt.Printf("Kept existing config: %s (use --force to replace it)\n", contractPath(configPath))
+	}
+
+	fmt.Printf("Prompts directory: %s (pre/ and post/)\n", contractPath(promptsDir))
+
+	if choices.StarterTemplates {
+		added, err := installStarterTemplates(promptsDir)
+		if err != nil {
+			return err
+		}
+		if len(added) > 0 {
+			fmt.Printf("Added starter templates: %s\n", strings.Join(added, ", "))
+		}
+	}
+
+	if choices.Project {
+		fmt.Printf("\nThe project settings and the templates in %s are used when prompter runs\nfrom this directory.\n", contractPath(promptsDir))
+	}
+	return nil
+}
+
+// initConfig renders the config file written by init
+func initConfig(choices interactive.SetupChoices, promptsDir string) string {
+	var b strings.Builder
+	b.WriteString("# Prompter configuration created by 'prompter init'.\n")
+	b.WriteString("# See example-config.toml in the prompter repository for every option.\n\n")
+	fmt.Fprintf(&b, "config_version = %d\n", config.CurrentConfigVersion)
+	if !choices.Project {
+		// Project templates are found through the working directory instead, and
+		// the editor is only read from the global config
+		fmt.Fprintf(&b, "prompts_location = %q\n", contractPath(promptsDir))
+		fmt.Fprintf(&b, "editor = %q\n", choices.Editor)
+	}
+	fmt.Fprintf(&b, "target = %q\n", choices.Target)
+	return b.String()
+}
+
+// installStarterTemplates copies the built-in templates into promptsDir, skipping any
+// that already exist there, and returns the ones added as kind/name
+func installStarterTemplates(promptsDir string) ([]string, error) {
+	var added []string
+	for _, kind := range []string{"pre", "post"} {
+		for _, name := range template.EmbeddedTemplateNames(kind) {
+			destination := filepath.Join(promptsDir, kind, name+".md")
+			if _, err := os.Stat(destination); err == nil {
+				continue
+			}
+
+			content, _ := template.EmbeddedTemplate(kind, name)
+			if err := os.WriteFile(destination, []byte(content), 0644); err != nil {
+				return added, fmt.Errorf("failed to write template file: %w", err)
+			}
+			added = append(added, kind+"/"+name)
+		}
+	}
+	return added, nil
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompter-cli/internal/config"
+	"prompter-cli/internal/template"
+	"prompter-cli/pkg/models"
+)
+
+// initRequest returns a noninteractive request using configPath
+func initRequest(t *testing.T, configPath string) *models.PromptRequest {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("EDITOR", "vi")
+	request := models.NewPromptRequest()
+	request.ConfigPath = configPath
+	request.ForceNonInteractive = true
+	return request
+}
+
+func TestInit_Global(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.toml")
+	if err := Init(initRequest(t, configPath), InitOptions{Global: true}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("expected a config file: %v", err)
+	}
+	for _, want := range []string{"config_version", "prompts_location", `editor = "vi"`, `target = "clipboard"`} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("config is missing %q:\n%s", want, content)
+		}
+	}
+
+	promptsDir := filepath.Join(dir, "prompts")
+	for _, kind := range []string{"pre", "post"} {
+		for _, name := range template.EmbeddedTemplateNames(kind) {
+			if _, err := os.Stat(filepath.Join(promptsDir, kind, name+".md")); err != nil {
+				t.Errorf("expected the starter template %s/%s: %v", kind, name, err)
+			}
+		}
+	}
+}
+
+func TestInit_Project(t *testing.T) {
+	cwd := t.TempDir()
+	t.Chdir(cwd)
+	globalPath := filepath.Join(t.TempDir(), "config.toml")
+	if err := Init(initRequest(t, globalPath), InitOptions{Project: true}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(cwd, config.ProjectDir, "config.toml"))
+	if err != nil {
+		t.Fatalf("expected a project config: %v", err)
+	}
+	// Project templates are found through the working directory, and the editor is
+	// only read from the global config
+	for _, unwanted := range []string{"prompts_location", "editor"} {
+		if strings.Contains(string(content), unwanted) {
+			t.Errorf("expected the project config not to set %s:\n%s", unwanted, content)
+		}
+	}
+	for _, kind := range []string{"pre", "post"} {
+		if info, err := os.Stat(filepath.Join(cwd, config.ProjectDir, "prompts", kind)); err != nil || !info.IsDir() {
+			t.Errorf("expected the project prompts/%s directory: %v", kind, err)
+		}
+	}
+	if _, err := os.Stat(globalPath); err == nil {
+		t.Error("expected the global config not to be written")
+	}
+}
+
+func TestInit_KeepsExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.toml")
+	writeFile(t, configPath, "editor = \"emacs\"\n")
+	edited := filepath.Join(dir, "prompts", "pre", "explain.md")
+	writeFile(t, edited, "My explain template")
+
+	request := initRequest(t, configPath)
+	if err := Init(request, InitOptions{Global: true}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if content, _ := os.ReadFile(configPath); string(content) != "editor = \"emacs\"\n" {
+		t.Errorf("expected the existing config to be kept, got %q", content)
+	}
+	if content, _ := os.ReadFile(edited); string(content) != "My explain template" {
+		t.Errorf("expected the existing template to be kept, got %q", content)
+	}
+
+	// --force replaces the config but still never the templates
+	if err := Init(request, InitOptions{Global: true, Force: true}); err != nil {
+		t.Fatalf("Init --force failed: %v", err)
+	}
+	if content, _ := os.ReadFile(configPath); !strings.Contains(string(content), "config_version") {
+		t.Errorf("expected --force to replace the config, got %q", content)
+	}
+	if content, _ := os.ReadFile(edited); string(content) != "My explain template" {
+		t.Errorf("expected --force to keep the existing template, got %q", content)
+	}
+}
+
+func TestInit_ProjectAndGlobal(t *testing.T) {
+	request := initRequest(t, filepath.Join(t.TempDir(), "config.toml"))
+	if err := Init(request, InitOptions{Project: true, Global: true}); err == nil {
+		t.Error("expected --project with --global to fail")
+	}
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"prompter-cli/internal/inputs"
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/internal/template"
+	"prompter-cli/pkg/models"
+)
+
+// RunWithInputs fills the request from an inputs file, validates that the run can
+// complete without prompting, and then generates the prompt non-interactively
+func RunWithInputs(ctx context.Context, request *models.PromptRequest, inputsPath string) error {
+	if request.ForceInteractive {
+		return fmt.Errorf("cannot use --inputs with --interactive")
+	}
+
+	in, err := inputs.Load(inputsPath)
+	if err != nil {
+		return err
+	}
+
+	absPath, err := filepath.Abs(inputsPath)
+	if err != nil {
+		absPath = inputsPath
+	}
+	in.Apply(request, filepath.Dir(absPath))
+
+	// Inputs files are for unattended runs, so never fall back to prompting
+	request.ForceNonInteractive = true
+	request.Interactive = false
+
+	if err := inputs.Validate(request); err != nil {
+		return err
+	}
+
+	if err := validateInputTemplates(request); err != nil {
+		return err
+	}
+
+	return Run(ctx, request)
+}
+
+// validateInputTemplates checks that the requested templates (or the configured
+// defaults that will be used in their place) exist and parse
+func validateInputTemplates(request *models.PromptRequest) error {
+	orch := orchestrator.New()
+
+	cfg, err := orch.LoadConfiguration(request.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	processor, ok := orch.GetTemplateProcessor().(*template.Processor)
+	if !ok || request.FixMode {
+		return nil
+	}
+
+	pre := request.PreTemplate
+	if pre == "" {
+		pre = cfg.DefaultPre
+	}
+	post := request.PostTemplate
+	if post == "" {
+		post = cfg.DefaultPost
+	}
+
+	for _, name := range append(models.TemplateNames(pre), models.TemplateNames(post)...) {
+		if _, err := processor.LoadTemplate(name); err != nil {
+			return fmt.Errorf("invalid inputs: template %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
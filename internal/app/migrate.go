@@ -0,0 +1,59 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"prompter-cli/internal/config"
+	"prompter-cli/internal/interactive"
+	"prompter-cli/pkg/models"
+)
+
+// MigrateConfig upgrades an outdated config file to the current format after
+// showing the planned changes and asking for confirmation (skipped with assumeYes)
+func MigrateConfig(request *models.PromptRequest, assumeYes bool) error {
+	path, err := config.ResolveConfigPath(request.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("config file not found: %s", contractPath(path))
+	}
+
+	migration, err := config.PlanMigration(path)
+	if err != nil {
+		return err
+	}
+	if migration == nil {
+		fmt.Printf("%s is already at config_version %d\n", contractPath(path), config.CurrentConfigVersion)
+		return nil
+	}
+
+	fmt.Printf("Migrating %s from version %d to %d:\n", contractPath(path), migration.FromVersion, migration.ToVersion)
+	for _, change := range migration.Changes {
+		fmt.Printf("  - %s\n", change)
+	}
+	fmt.Printf("  - set config_version = %d\n", migration.ToVersion)
+	fmt.Println("Comments in the file will not be preserved; the original is kept as a backup.")
+
+	if !assumeYes {
+		prompter := interactive.NewPrompter("")
+		confirmed, err := prompter.ConfirmConfigMigration(contractPath(path), migration.FromVersion, migration.ToVersion)
+		if err != nil {
+			return fmt.Errorf("failed to get migration confirmation: %w", err)
+		}
+		if !confirmed {
+			fmt.Println("Migration cancelled.")
+			return nil
+		}
+	}
+
+	backupPath, err := migration.Write()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Migrated %s (backup: %s)\n", contractPath(path), contractPath(backupPath))
+	return nil
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompter-cli/internal/config"
+	"prompter-cli/pkg/models"
+)
+
+func TestMigrateConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	legacy := "directory_strategy = \"fs\"\n\n[defaults]\npre = \"review\"\n"
+	writeFile(t, configPath, legacy)
+	request := models.NewPromptRequest()
+	request.ConfigPath = configPath
+
+	if err := MigrateConfig(request, true); err != nil {
+		t.Fatalf("MigrateConfig failed: %v", err)
+	}
+	migrated, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{fmt.Sprintf("config_version = %d", config.CurrentConfigVersion), "default_pre = 'review'", "directory_strategy = 'filesystem'"} {
+		if !strings.Contains(string(migrated), want) {
+			t.Errorf("migrated config is missing %q:\n%s", want, migrated)
+		}
+	}
+	if backup, _ := os.ReadFile(configPath + ".bak"); string(backup) != legacy {
+		t.Errorf("expected the original to be kept as a backup, got %q", backup)
+	}
+
+	// A current config is left alone
+	if err := MigrateConfig(request, true); err != nil {
+		t.Fatalf("MigrateConfig of a current config failed: %v", err)
+	}
+	if again, _ := os.ReadFile(configPath); string(again) != string(migrated) {
+		t.Errorf("expected a current config to be left alone, got:\n%s", again)
+	}
+}
+
+func TestMigrateConfig_KeepsEarlierBackup(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.toml")
+	legacy := "[defaults]\npre = \"review\"\n"
+	writeFile(t, configPath, legacy)
+	writeFile(t, configPath+".bak", "earlier backup\n")
+	request := models.NewPromptRequest()
+	request.ConfigPath = configPath
+
+	if err := MigrateConfig(request, true); err != nil {
+		t.Fatalf("MigrateConfig failed: %v", err)
+	}
+	if backup, _ := os.ReadFile(configPath + ".bak"); string(backup) != "earlier backup\n" {
+		t.Errorf("expected the earlier backup to be kept, got %q", backup)
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, "config.2*.bak"))
+	if len(backups) != 1 {
+		t.Fatalf("expected a timestamped backup, found %v", backups)
+	}
+	if backup, _ := os.ReadFile(backups[0]); string(backup) != legacy {
+		t.Errorf("expected the original in the timestamped backup, got %q", backup)
+	}
+}
+
+func TestMigrateConfig_Missing(t *testing.T) {
+	request := models.NewPromptRequest()
+	request.ConfigPath = filepath.Join(t.TempDir(), "config.toml")
+	if err := MigrateConfig(request, true); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected a config not found error, got %v", err)
+	}
+}
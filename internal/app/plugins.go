@@ -0,0 +1,64 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/internal/plugin"
+	"prompter-cli/pkg/models"
+)
+
+// ListPlugins prints the plugins found in plugins_location and the functions,
+// sources, and targets each provides. Plugins that can't be used are reported as
+// warnings while loading the configuration.
+func ListPlugins(request *models.PromptRequest, asJSON bool) error {
+	orch := orchestrator.New()
+	cfg, err := orch.LoadConfiguration(request.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	plugins := orch.Plugins().Plugins
+	if asJSON {
+		if plugins == nil {
+			plugins = []*plugin.Plugin{}
+		}
+		encoded, err := json.MarshalIndent(plugins, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode plugins: %w", err)
+		}
+		fmt.Println(string(encoded))
+		return nil
+	}
+
+	if len(plugins) == 0 {
+		fmt.Printf("No plugins found in %s.\n", contractPath(cfg.PluginsLocation))
+		return nil
+	}
+
+	for _, p := range plugins {
+		fmt.Printf("%s  %s\n", p.Name, contractPath(p.Path))
+		if p.Description != "" {
+			fmt.Printf("  %s\n", p.Description)
+		}
+		printCapabilities("functions", p.Functions)
+		printCapabilities("sources", p.Sources)
+		printCapabilities("targets", p.Targets)
+		fmt.Println()
+	}
+	return nil
+}
+
+// printCapabilities prints one kind of capability a plugin provides, if any
+func printCapabilities(kind string, capabilities []plugin.Capability) {
+	if len(capabilities) == 0 {
+		return
+	}
+	names := make([]string, len(capabilities))
+	for i, capability := range capabilities {
+		names[i] = capability.Name
+	}
+	fmt.Printf("  %-10s %s\n", kind+":", strings.Join(names, ", "))
+}
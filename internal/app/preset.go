@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/pkg/models"
+)
+
+// UsePreset fills in request fields from a named preset in the config
+func UsePreset(request *models.PromptRequest, name string) error {
+	orch := orchestrator.New()
+
+	cfg, err := orch.LoadConfiguration(request.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	preset, ok := cfg.Presets[name]
+	if !ok {
+		if len(cfg.Presets) == 0 {
+			return fmt.Errorf("unknown preset %q: no [preset.<name>] sections are configured", name)
+		}
+		return fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(PresetNames(cfg), ", "))
+	}
+
+	ApplyPreset(request, preset)
+	return nil
+}
+
+// ApplyPreset fills in request fields from a preset. Values already set on the
+// request (from flags or arguments) take precedence; files, excludes, and variables
+// are combined.
+func ApplyPreset(request *models.PromptRequest, preset interfaces.Preset) {
+	if request.PreTemplate == "" {
+		request.PreTemplate = preset.Pre
+	}
+	if request.PostTemplate == "" {
+		request.PostTemplate = preset.Post
+	}
+	if len(preset.Files) > 0 {
+		request.Files = append(append([]string{}, preset.Files...), request.Files...)
+	}
+	if len(preset.Vars) > 0 {
+		request.Vars = orchestrator.MergeVars(preset.Vars, request.Vars)
+	}
+	if preset.Directory && request.Directory == "" {
+		if cwd, err := os.Getwd(); err == nil {
+			request.Directory = cwd
+		} else {
+			request.Directory = "."
+		}
+	}
+	if request.DirectoryStrategy == "" {
+		request.DirectoryStrategy = preset.DirectoryStrategy
+	}
+	if len(preset.Exclude) > 0 {
+		request.Exclude = append(append([]string{}, preset.Exclude...), request.Exclude...)
+	}
+	if request.Target == "" {
+		request.Target = preset.Target
+	}
+}
+
+// PresetNames returns the configured preset names in sorted order
+func PresetNames(cfg *interfaces.Config) []string {
+	names := make([]string, 0, len(cfg.Presets))
+	for name := range cfg.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/pkg/models"
+)
+
+// RunRecipe applies a named recipe from the config to the request and generates the prompt
+func RunRecipe(ctx context.Context, request *models.PromptRequest, name string) error {
+	orch := orchestrator.New()
+
+	cfg, err := orch.LoadConfiguration(request.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	recipe, ok := cfg.Recipes[name]
+	if !ok {
+		if len(cfg.Recipes) == 0 {
+			return fmt.Errorf("unknown recipe %q: no [recipe.<name>] sections are configured", name)
+		}
+		return fmt.Errorf("unknown recipe %q (available: %s)", name, strings.Join(RecipeNames(cfg), ", "))
+	}
+
+	ApplyRecipe(request, recipe)
+
+	return Run(ctx, request)
+}
+
+// ApplyRecipe fills in request fields from a recipe. Values already set on the
+// request (from flags or arguments) take precedence; files are combined.
+func ApplyRecipe(request *models.PromptRequest, recipe interfaces.Recipe) {
+	if request.BasePrompt == "" {
+		request.BasePrompt = recipe.Prompt
+	}
+	if request.PreTemplate == "" {
+		request.PreTemplate = recipe.Pre
+	}
+	if request.PostTemplate == "" {
+		request.PostTemplate = recipe.Post
+	}
+	if len(recipe.Files) > 0 {
+		request.Files = append(append([]string{}, recipe.Files...), request.Files...)
+	}
+	if recipe.Directory && request.Directory == "" {
+		if cwd, err := os.Getwd(); err == nil {
+			request.Directory = cwd
+		} else {
+			request.Directory = "."
+		}
+	}
+	if request.DirectoryStrategy == "" {
+		request.DirectoryStrategy = recipe.DirectoryStrategy
+	}
+	if recipe.Fix {
+		request.FixMode = true
+	}
+	if recipe.Outline {
+		request.Outline = true
+	}
+	if request.Target == "" {
+		request.Target = recipe.Target
+	}
+}
+
+// RecipeNames returns the configured recipe names in sorted order
+func RecipeNames(cfg *interfaces.Config) []string {
+	names := make([]string, 0, len(cfg.Recipes))
+	for name := range cfg.Recipes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
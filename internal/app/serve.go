@@ -0,0 +1,55 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/internal/server"
+	"prompter-cli/pkg/models"
+)
+
+// Serve answers the local HTTP API on addr until ctx is done. Browser pages may only
+// call it from origins.
+func Serve(ctx context.Context, request *models.PromptRequest, addr string, origins []string) error {
+	orch := orchestrator.New()
+
+	// Config problems are reported once at startup; later warnings go in the responses
+	if _, err := orch.LoadConfiguration(request.ConfigPath); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	orch.SetSilent(true)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	httpServer := &http.Server{
+		Handler:           server.New(orch, request.ConfigPath, origins).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.Serve(listener)
+	}()
+	fmt.Fprintf(os.Stderr, "Serving prompts on http://%s (Ctrl-C to stop)\n", listener.Addr())
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to stop server: %w", err)
+	}
+	return nil
+}
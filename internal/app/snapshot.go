@@ -0,0 +1,62 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/internal/template"
+	"prompter-cli/pkg/models"
+)
+
+// TestTemplates renders templates against their fixture data and compares the
+// output with golden files, optionally regenerating the golden files
+func TestTemplates(request *models.PromptRequest, names []string, update bool) error {
+	// Create orchestrator to load configuration
+	orch := orchestrator.New()
+
+	if _, err := orch.LoadConfiguration(request.ConfigPath); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	processor, ok := orch.GetTemplateProcessor().(*template.Processor)
+	if !ok {
+		return fmt.Errorf("template processor does not support snapshot testing")
+	}
+
+	results, err := processor.RunSnapshots(names, update)
+	if err != nil {
+		return err
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("No template fixtures found (add <template>.md%s beside a template, or %s)\n",
+			template.FixtureSuffix, filepath.Join(template.TestsDir, "<template>", template.TestInputFile))
+		return nil
+	}
+
+	failed := 0
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			failed++
+			fmt.Printf("ERROR   %s: %v\n", result.Name, result.Err)
+		case result.Updated:
+			fmt.Printf("UPDATED %s -> %s\n", result.Name, contractPath(result.GoldenPath))
+		case result.Passed:
+			fmt.Printf("PASS    %s\n", result.Name)
+		default:
+			failed++
+			fmt.Printf("FAIL    %s\n", result.Name)
+			fmt.Print(result.Diff)
+		}
+	}
+
+	fmt.Printf("\n%d passed, %d failed\n", len(results)-failed, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d template snapshot(s) failed", failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"fmt"
+
+	"prompter-cli/internal/config"
+	"prompter-cli/internal/git"
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/pkg/models"
+)
+
+// SyncTemplateSources clones or updates the template sources named, or all of them,
+// into template_sources_location. A source that fails is reported and the rest are
+// still synced.
+func SyncTemplateSources(request *models.PromptRequest, names []string) error {
+	orch := orchestrator.New()
+	cfg, err := orch.LoadConfiguration(request.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	if len(cfg.TemplateSources) == 0 {
+		fmt.Println("No template sources configured. Add a [[template_sources]] entry with the url of a git repository to the config file.")
+		return nil
+	}
+
+	sources := cfg.TemplateSources
+	if len(names) > 0 {
+		byName := make(map[string]interfaces.TemplateSource, len(sources))
+		for _, source := range sources {
+			byName[source.Name] = source
+		}
+		sources = nil
+		for _, name := range names {
+			source, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("no template source named %q", name)
+			}
+			sources = append(sources, source)
+		}
+	}
+
+	failed := 0
+	for _, source := range sources {
+		dir := config.TemplateSourceDir(cfg, source)
+		commit, err := git.Sync(source.URL, source.Ref, dir)
+		if err != nil {
+			failed++
+			fmt.Printf("%s: %v\n", source.Name, err)
+			continue
+		}
+		ref := source.Ref
+		if ref == "" {
+			ref = "default branch"
+		}
+		fmt.Printf("%s: %s at %s (%s)\n", source.Name, contractPath(config.TemplateSourceLocation(cfg, source)), commit, ref)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d template sources failed to sync", failed, len(sources))
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/internal/stats"
+	"prompter-cli/pkg/models"
+)
+
+// statsStore returns the configured template stats store
+func statsStore(cfg *interfaces.Config) *stats.Store {
+	return stats.NewStore(cfg.StatsLocation)
+}
+
+// loadUsage returns the recorded template usage, or nil when stats are disabled or
+// unreadable so the selectors fall back to their normal order
+func loadUsage(cfg *interfaces.Config) *stats.Stats {
+	if !cfg.StatsEnabled {
+		return nil
+	}
+	usage, err := statsStore(cfg).Load()
+	if err != nil {
+		slog.Warn(err.Error())
+		return nil
+	}
+	return usage
+}
+
+// recordTemplateStats counts a signal for the pre and post templates of a request.
+// Failures are reported as warnings since they never affect the prompt itself.
+func recordTemplateStats(cfg *interfaces.Config, preTemplate, postTemplate string, signals ...stats.Signal) {
+	if !cfg.StatsEnabled || (preTemplate == "" && postTemplate == "") {
+		return
+	}
+
+	now := time.Now()
+	err := statsStore(cfg).Update(func(s *stats.Stats) {
+		for _, signal := range signals {
+			for _, name := range models.TemplateNames(preTemplate) {
+				s.Record("pre", name, signal, now)
+			}
+			for _, name := range models.TemplateNames(postTemplate) {
+				s.Record("post", name, signal, now)
+			}
+		}
+	})
+	if err != nil {
+		slog.Warn(fmt.Sprintf("failed to save template stats: %v", err))
+	}
+}
+
+// isRerun reports whether the request repeats the most recent prompt in history,
+// which usually means the previous result wasn't good enough
+func isRerun(cfg *interfaces.Config, request *models.PromptRequest) bool {
+	if !cfg.HistoryEnabled {
+		return false
+	}
+	latest, err := historyStore(cfg).Latest()
+	if err != nil {
+		return false
+	}
+	return latest.BasePrompt == request.BasePrompt &&
+		latest.PreTemplate == request.PreTemplate &&
+		latest.PostTemplate == request.PostTemplate
+}
+
+// ShowStats prints the recorded usage of every template
+func ShowStats(request *models.PromptRequest) error {
+	orch := orchestrator.New()
+
+	cfg, err := orch.LoadConfiguration(request.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	if !cfg.StatsEnabled {
+		fmt.Println("Template stats are disabled (stats_enabled = false).")
+		return nil
+	}
+
+	usage, err := statsStore(cfg).Load()
+	if err != nil {
+		return err
+	}
+
+	entries := usage.Entries("")
+	if len(entries) == 0 {
+		fmt.Println("No template usage recorded yet.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TEMPLATE\tUSES\tRERUNS\tCONTINUATIONS\tABORTS\tLAST USED")
+	for _, entry := range entries {
+		lastUsed := "-"
+		if !entry.LastUsed.IsZero() {
+			lastUsed = entry.LastUsed.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s/%s\t%d\t%d\t%d\t%d\t%s\n",
+			entry.Kind, entry.Name, entry.Uses, entry.Reruns, entry.Continuations, entry.Aborts, lastUsed)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nStats are stored locally in %s\n", contractPath(statsStore(cfg).Path()))
+	return nil
+}
+
+// ResetStats deletes all recorded template usage
+func ResetStats(request *models.PromptRequest) error {
+	orch := orchestrator.New()
+
+	cfg, err := orch.LoadConfiguration(request.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	if err := statsStore(cfg).Reset(); err != nil {
+		return err
+	}
+
+	fmt.Println("Template stats cleared.")
+	return nil
+}
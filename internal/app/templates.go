@@ -0,0 +1,320 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/internal/template"
+	"prompter-cli/pkg/models"
+)
+
+// templateProcessor loads the configuration and returns the configured template processor
+func templateProcessor(request *models.PromptRequest) (*template.Processor, error) {
+	orch := orchestrator.New()
+
+	if _, err := orch.LoadConfiguration(request.ConfigPath); err != nil {
+		return nil, fmt.Errorf("configuration error: %w", err)
+	}
+
+	processor, ok := orch.GetTemplateProcessor().(*template.Processor)
+	if !ok {
+		return nil, fmt.Errorf("template processor does not support listing templates")
+	}
+	return processor, nil
+}
+
+// ListTemplateCatalog prints every pre and post template with its location, source,
+// and frontmatter description, or the same data as JSON
+func ListTemplateCatalog(request *models.PromptRequest, asJSON bool) error {
+	processor, err := templateProcessor(request)
+	if err != nil {
+		return err
+	}
+
+	catalog := processor.Catalog()
+
+	if asJSON {
+		if catalog == nil {
+			catalog = []template.TemplateInfo{}
+		}
+		encoded, err := json.MarshalIndent(catalog, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode templates: %w", err)
+		}
+		fmt.Println(string(encoded))
+		return nil
+	}
+
+	if len(catalog) == 0 {
+		fmt.Println("No templates found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tSOURCE\tDESCRIPTION\tPATH")
+	for _, info := range catalog {
+		name := info.Name
+		if info.Default {
+			name += " (default)"
+		}
+		description := info.Description
+		if info.Error != "" {
+			description = "error: " + info.Error
+		}
+		path := info.Path
+		if info.Source != template.SourceBuiltin {
+			path = contractPath(path)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", info.Kind, name, info.Source, description, path)
+	}
+	return w.Flush()
+}
+
+// TemplateCompletions returns the names of the templates of kind ("pre" or "post"),
+// each followed by a tab and its description for shell completion. Errors yield no
+// completions rather than breaking the shell.
+func TemplateCompletions(request *models.PromptRequest, kind string) []string {
+	processor, err := templateProcessor(request)
+	if err != nil {
+		return nil
+	}
+
+	var completions []string
+	for _, info := range processor.Catalog() {
+		if info.Kind != kind {
+			continue
+		}
+		if info.Description != "" {
+			completions = append(completions, info.Name+"\t"+info.Description)
+		} else {
+			completions = append(completions, info.Name)
+		}
+	}
+	return completions
+}
+
+// NewTemplate scaffolds a template of kind ("pre" or "post") in the prompts location,
+// with commented frontmatter and the available data documented inline, and
+// optionally opens it in the editor
+func NewTemplate(request *models.PromptRequest, name, kind string, edit bool) error {
+	orch := orchestrator.New()
+
+	cfg, err := orch.LoadConfiguration(request.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	name = strings.TrimSuffix(strings.TrimSpace(name), ".md")
+	if !template.ValidTemplateName(name) {
+		return fmt.Errorf("invalid template name %q", name)
+	}
+
+	// A namespaced name such as go/review goes in a subdirectory
+	templatePath := filepath.Join(cfg.PromptsLocation, kind, filepath.FromSlash(name)+".md")
+	templateDir := filepath.Dir(templatePath)
+	if _, err := os.Stat(templatePath); err == nil {
+		return fmt.Errorf("template file already exists: %s", contractPath(templatePath))
+	}
+
+	if err := os.MkdirAll(templateDir, 0755); err != nil {
+		return fmt.Errorf("failed to create template directory: %w", err)
+	}
+	if err := os.WriteFile(templatePath, []byte(template.Scaffold(name, kind)), 0644); err != nil {
+		return fmt.Errorf("failed to write template file: %w", err)
+	}
+
+	fmt.Printf("Created %s template: %s\n", kind, contractPath(templatePath))
+
+	if !edit {
+		return nil
+	}
+
+	editor, err := resolveEditor(cfg)
+	if err != nil {
+		return err
+	}
+	return openInEditor(editor, templatePath)
+}
+
+// EditTemplate opens the template a name resolves to in the editor. A built-in
+// template is first copied to the prompts location so the edited copy takes its place.
+func EditTemplate(request *models.PromptRequest, name string) error {
+	orch := orchestrator.New()
+
+	cfg, err := orch.LoadConfiguration(request.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	processor, ok := orch.GetTemplateProcessor().(*template.Processor)
+	if !ok {
+		return fmt.Errorf("template processor does not support resolving templates")
+	}
+
+	templatePath, err := processor.ResolveTemplate(name)
+	if err != nil {
+		return err
+	}
+
+	if template.IsEmbeddedPath(templatePath) {
+		if templatePath, err = copyBuiltinTemplate(templatePath, cfg.PromptsLocation); err != nil {
+			return err
+		}
+	}
+
+	editor, err := resolveEditor(cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Opening %s in %s\n", contractPath(templatePath), editor)
+	return openInEditor(editor, templatePath)
+}
+
+// copyBuiltinTemplate writes a built-in template into the prompts location, returning
+// the new path
+func copyBuiltinTemplate(embeddedPath, promptsLocation string) (string, error) {
+	relPath := strings.TrimPrefix(embeddedPath, template.EmbeddedPrefix)
+	kind, file := filepath.Split(filepath.FromSlash(relPath))
+	content, ok := template.EmbeddedTemplate(filepath.Clean(kind), strings.TrimSuffix(file, ".md"))
+	if !ok {
+		return "", fmt.Errorf("built-in template not found: %s", relPath)
+	}
+
+	destination := filepath.Join(promptsLocation, relPath)
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return "", fmt.Errorf("failed to create template directory: %w", err)
+	}
+	if err := os.WriteFile(destination, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write template file: %w", err)
+	}
+
+	fmt.Printf("Copied built-in template to %s; the copy now takes its place\n", contractPath(destination))
+	return destination, nil
+}
+
+// InstallTemplate downloads a template, or a bundle of them, into the prompts location
+// and lints what it installed. kind and name apply to a single template and are
+// inferred from its URL when empty.
+func InstallTemplate(request *models.PromptRequest, source, kind, name string, force bool) error {
+	orch := orchestrator.New()
+
+	cfg, err := orch.LoadConfiguration(request.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	processor, ok := orch.GetTemplateProcessor().(*template.Processor)
+	if !ok {
+		return fmt.Errorf("template processor does not support installing templates")
+	}
+
+	results, err := template.Install(source, template.InstallOptions{
+		PromptsLocation: cfg.PromptsLocation,
+		Registry:        cfg.TemplateRegistry,
+		Kind:            kind,
+		Name:            name,
+		Force:           force,
+		Timeout:         time.Duration(cfg.FetchTimeoutMS) * time.Millisecond,
+	})
+	for _, result := range results {
+		fmt.Printf("%s %s template %s: %s\n", result.Status, result.Kind, result.Name, contractPath(result.Path))
+		if result.Status == template.InstallUnchanged {
+			continue
+		}
+		// A template that doesn't lint is still installed, to be fixed in place
+		for _, issue := range processor.Lint(result.Path) {
+			fmt.Printf("  %s\n", issue)
+		}
+	}
+	return err
+}
+
+// LintTemplates checks templates given by name or path, or every template with all,
+// printing each issue as path:line:column: message. Only errors, not warnings, make
+// it fail.
+func LintTemplates(request *models.PromptRequest, names []string, all bool) error {
+	processor, err := templateProcessor(request)
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	if all {
+		for _, info := range processor.Catalog() {
+			paths = append(paths, info.Path)
+		}
+	}
+	for _, name := range names {
+		// Paths are linted as given, so hooks can pass the files they were run on
+		if template.IsTemplatePath(name) {
+			paths = append(paths, name)
+			continue
+		}
+		path, err := processor.ResolveTemplate(name)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+	}
+
+	errors, warnings := 0, 0
+	for _, path := range paths {
+		for _, issue := range processor.Lint(path) {
+			if issue.Warning {
+				warnings++
+			} else {
+				errors++
+			}
+			fmt.Println(issue)
+		}
+	}
+
+	fmt.Printf("%d template(s) checked, %d error(s), %d warning(s)\n", len(paths), errors, warnings)
+	if errors > 0 {
+		return fmt.Errorf("%d template error(s)", errors)
+	}
+	return nil
+}
+
+// PreviewTemplate renders a template with sample data, giving each variable it reads
+// its default or a placeholder unless vars sets it, and prints the result
+func PreviewTemplate(request *models.PromptRequest, name string, vars map[string]string) error {
+	processor, err := templateProcessor(request)
+	if err != nil {
+		return err
+	}
+
+	variables, err := processor.TemplateVariables(name)
+	if err != nil {
+		return err
+	}
+	tmpl, err := processor.LoadTemplate(name)
+	if err != nil {
+		return err
+	}
+
+	data := template.SampleData()
+	data.Vars = orchestrator.MergeVars(template.SampleVars(variables), vars)
+
+	system, err := processor.ExecuteSystem(tmpl, data)
+	if err != nil {
+		return err
+	}
+	rendered, err := processor.Execute(tmpl, data)
+	if err != nil {
+		return err
+	}
+
+	if system != "" {
+		fmt.Printf("--- system ---\n%s\n--- prompt ---\n", system)
+	}
+	fmt.Println(strings.TrimRight(rendered, "\n"))
+	return nil
+}
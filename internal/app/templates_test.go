@@ -0,0 +1,112 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompter-cli/internal/template"
+	"prompter-cli/pkg/models"
+)
+
+// recordingEditor writes a config whose editor records the file it was asked to
+// open, returning the request and a function reading that file
+func recordingEditor(t *testing.T, promptsDir string) (*models.PromptRequest, func() string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	record := filepath.Join(dir, "opened")
+	editor := filepath.Join(dir, "editor")
+	writeFile(t, editor, "#!/bin/sh\nprintf '%s' \"$1\" > "+record+"\n")
+	if err := os.Chmod(editor, 0755); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "config.toml")
+	writeFile(t, configPath, "prompts_location = \""+promptsDir+"\"\neditor = \""+editor+"\"\n")
+
+	request := models.NewPromptRequest()
+	request.ConfigPath = configPath
+	return request, func() string {
+		opened, err := os.ReadFile(record)
+		if err != nil {
+			t.Fatalf("the editor wasn't run: %v", err)
+		}
+		return string(opened)
+	}
+}
+
+func TestEditTemplate(t *testing.T) {
+	promptsDir := t.TempDir()
+	review := filepath.Join(promptsDir, "pre", "Review.md")
+	writeFile(t, review, "Review:")
+	request, opened := recordingEditor(t, promptsDir)
+
+	// Names are looked up ignoring case, as when generating
+	if err := EditTemplate(request, "review"); err != nil {
+		t.Fatalf("EditTemplate failed: %v", err)
+	}
+	if got := opened(); got != review {
+		t.Errorf("opened %s, want %s", got, review)
+	}
+}
+
+func TestEditTemplate_Builtin(t *testing.T) {
+	promptsDir := t.TempDir()
+	request, opened := recordingEditor(t, promptsDir)
+
+	// A built-in template is copied into the prompts directory to be edited there
+	if err := EditTemplate(request, "explain"); err != nil {
+		t.Fatalf("EditTemplate failed: %v", err)
+	}
+	copied := filepath.Join(promptsDir, "pre", "explain.md")
+	if got := opened(); got != copied {
+		t.Errorf("opened %s, want %s", got, copied)
+	}
+	content, err := os.ReadFile(copied)
+	builtin, _ := template.EmbeddedTemplate("pre", "explain")
+	if err != nil || string(content) != builtin {
+		t.Errorf("expected the built-in template to be copied, got %q, %v", content, err)
+	}
+}
+
+func TestEditTemplate_NotFound(t *testing.T) {
+	request, _ := recordingEditor(t, t.TempDir())
+
+	err := EditTemplate(request, "nonexistent")
+	if !errors.Is(err, template.ErrNotFound) || !strings.Contains(err.Error(), "nonexistent") {
+		t.Errorf("error = %v, want a template not found error", err)
+	}
+}
+
+func TestTemplateCompletions(t *testing.T) {
+	promptsDir := t.TempDir()
+	writeFile(t, filepath.Join(promptsDir, "pre", "persona.md"), "---\ndescription: Senior reviewer persona\n---\nYou are a reviewer.")
+	writeFile(t, filepath.Join(promptsDir, "post", "terse.md"), "Be terse.")
+	request, _ := recordingEditor(t, promptsDir)
+
+	pre := TemplateCompletions(request, "pre")
+	for _, want := range []string{"persona\tSenior reviewer persona", "explain"} {
+		if !containsPrefix(pre, want) {
+			t.Errorf("pre completions %v are missing %q", pre, want)
+		}
+	}
+	if containsPrefix(pre, "terse") {
+		t.Errorf("pre completions %v include a post-template", pre)
+	}
+	if post := TemplateCompletions(request, "post"); !containsPrefix(post, "terse") {
+		t.Errorf("post completions %v are missing terse", post)
+	}
+}
+
+// containsPrefix reports whether a completion starts with want, which may leave out
+// the description
+func containsPrefix(completions []string, want string) bool {
+	for _, completion := range completions {
+		if completion == want || strings.HasPrefix(completion, want+"\t") {
+			return true
+		}
+	}
+	return false
+}
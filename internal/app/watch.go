@@ -0,0 +1,380 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"prompter-cli/internal/content"
+	"prompter-cli/internal/interactive"
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/internal/template"
+	"prompter-cli/pkg/models"
+)
+
+// fileStamp identifies a version of a watched file
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watch generates the prompt, then keeps regenerating it and rewriting the target
+// whenever an included file changes, until ctx is done
+func Watch(ctx context.Context, request *models.PromptRequest, interval time.Duration) error {
+	if request.FixMode {
+		return fmt.Errorf("--watch-context cannot be used with fix mode")
+	}
+	if len(request.Files) == 0 && request.Directory == "" {
+		return fmt.Errorf("--watch-context needs files (--file) or a directory (-d) to watch")
+	}
+
+	orch, cfg, err := prepareWatch(request, "--watch-context")
+	if err != nil {
+		return err
+	}
+
+	stamps := watchStamps(request, cfg)
+	if err := generateAndOutput(ctx, orch, request, cfg); err != nil {
+		return err
+	}
+	status(cfg, "Watching %d files for changes (Ctrl-C to stop)", len(stamps))
+
+	// Later refreshes happen silently apart from a status line
+	orch.SetQuiet(true)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			status(cfg, "Stopped watching")
+			return nil
+		case <-ticker.C:
+			current := watchStamps(request, cfg)
+			changed := changedStamps(stamps, current)
+			if changed == 0 {
+				continue
+			}
+			stamps = current
+			refresh(ctx, orch, request, cfg, changed)
+		}
+	}
+}
+
+// WatchChanges generates the prompt, then waits for the filesystem to report changes
+// to the included files, the fix file, or the prompt templates and regenerates it into
+// the target, until ctx is done. Changes are collected for debounce before the prompt
+// is regenerated, so saving several files at once refreshes it once.
+func WatchChanges(ctx context.Context, request *models.PromptRequest, debounce time.Duration) error {
+	if request.FixMode && (request.FixFile == "" || request.FixFile == models.FixFileStdin) {
+		return fmt.Errorf("watch needs --fix-file in fix mode, since command output on stdin can only be read once")
+	}
+	if len(request.Files) == 0 && request.Directory == "" && !request.FixMode {
+		return fmt.Errorf("watch needs files (--file), a directory (-d), or a fix file (--fix --fix-file) to watch")
+	}
+
+	orch, cfg, err := prepareWatch(request, "watch")
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch files: %w", err)
+	}
+	defer watcher.Close()
+
+	stamps := changeStamps(orch, request, cfg)
+	watchDirectories(watcher, stamps, request, orch)
+	if err := generateAndOutput(ctx, orch, request, cfg); err != nil {
+		return err
+	}
+	status(cfg, "Watching %d files for changes (Ctrl-C to stop)", len(stamps))
+
+	// Later refreshes happen silently apart from a status line
+	orch.SetQuiet(true)
+
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			status(cfg, "Stopped watching")
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			// New directories aren't watched until they're added
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watcher.Add(event.Name); err != nil {
+						slog.Debug("not watching directory", "dir", event.Name, "error", err)
+					}
+				}
+			}
+			settled = time.After(debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("watching files failed", "error", err)
+		case <-settled:
+			settled = nil
+
+			// Events also come for files that aren't part of the prompt, such as
+			// ignored build output, so only refresh when a stamp changed
+			current := changeStamps(orch, request, cfg)
+			changed := changedStamps(stamps, current)
+			if changed == 0 {
+				continue
+			}
+			stamps = current
+			watchDirectories(watcher, stamps, request, orch)
+			refresh(ctx, orch, request, cfg, changed)
+		}
+	}
+}
+
+// prepareWatch checks that the request can be regenerated over and over, loads the
+// configuration, and collects missing inputs once, up front. The mode names the
+// command or flag in errors.
+func prepareWatch(request *models.PromptRequest, mode string) (*orchestrator.Orchestrator, *interfaces.Config, error) {
+	if request.EditorRequested {
+		return nil, nil, fmt.Errorf("%s cannot be used with --editor", mode)
+	}
+	if request.TUI {
+		return nil, nil, fmt.Errorf("%s cannot be used with --tui", mode)
+	}
+	if request.JSON {
+		return nil, nil, fmt.Errorf("%s cannot be used with --json", mode)
+	}
+
+	orch := orchestrator.New()
+
+	cfg, err := orch.LoadConfiguration(request.ConfigPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("configuration error: %w", err)
+	}
+
+	resolveInteractiveMode(request, cfg)
+
+	target := request.Target
+	if target == "" {
+		target = cfg.Target
+	}
+	if models.IsModelTarget(target) || strings.HasPrefix(target, models.TargetHTTPPrefix) {
+		return nil, nil, fmt.Errorf("%s can't be used with the %s target, since every refresh would send a new request", mode, target)
+	}
+	if target == models.TargetEditor {
+		return nil, nil, fmt.Errorf("%s can't be used with the editor target, since every refresh would open the editor", mode)
+	}
+	if strings.HasPrefix(target, models.TargetTmuxPrefix) || strings.HasPrefix(target, models.TargetAppendPrefix) {
+		return nil, nil, fmt.Errorf("%s can't be used with the %s target, since every refresh would add the prompt again", mode, target)
+	}
+
+	prompter := interactive.NewPrompter(cfg.PromptsLocation)
+	prompter.SetPromptsLocations(orchestrator.PromptsLocations(cfg))
+	prompter.SetCustomTemplates(cfg.CustomTemplates)
+	if editor, err := resolveEditor(cfg); err == nil {
+		prompter.SetEditor(editor)
+	}
+	if err := missingInputs(prompter.CollectMissingInputs(request)); err != nil {
+		return nil, nil, fmt.Errorf("failed to collect inputs: %w", err)
+	}
+
+	return orch, cfg, nil
+}
+
+// refresh regenerates the prompt after changed files changed, reporting failures
+// without stopping, since the next save may fix the problem
+func refresh(ctx context.Context, orch *orchestrator.Orchestrator, request *models.PromptRequest, cfg *interfaces.Config, changed int) {
+	if err := generateAndOutput(ctx, orch, request, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Refresh failed: %v\n", err)
+		return
+	}
+	status(cfg, "Prompt refreshed at %s (%d files changed)", time.Now().Format("15:04:05"), changed)
+}
+
+// status writes a status line to stderr unless quiet is set
+func status(cfg *interfaces.Config, format string, args ...any) {
+	if !cfg.Quiet {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	}
+}
+
+// generateAndOutput generates the prompt and writes it to the request's target
+func generateAndOutput(ctx context.Context, orch *orchestrator.Orchestrator, request *models.PromptRequest, cfg *interfaces.Config) error {
+	prompt, err := orch.GeneratePrompt(ctx, request)
+	if err != nil {
+		return fmt.Errorf("prompt generation failed: %w", err)
+	}
+
+	if err := orch.OutputPrompt(ctx, prompt, request, cfg); err != nil {
+		return fmt.Errorf("output failed: %w", err)
+	}
+
+	return nil
+}
+
+// watchStamps records the modification time and size of every included file but a
+// file target, so writing the prompt doesn't trigger another refresh
+func watchStamps(request *models.PromptRequest, cfg *interfaces.Config) map[string]fileStamp {
+	stamps := includedStamps(request, cfg)
+	dropTarget(stamps, request, cfg)
+	return stamps
+}
+
+// includedStamps records the modification time and size of every included file
+func includedStamps(request *models.PromptRequest, cfg *interfaces.Config) map[string]fileStamp {
+	var paths []string
+	for _, spec := range request.Files {
+		if file, _, err := content.SplitFileSpec(spec); err == nil {
+			paths = append(paths, file)
+		}
+	}
+
+	if request.Directory != "" {
+		if files, err := content.ListFiles(request.Directory, orchestrator.ContentOptions(cfg, request)); err == nil {
+			for _, file := range files {
+				paths = append(paths, filepath.Join(request.Directory, file))
+			}
+		}
+	}
+
+	stamps := make(map[string]fileStamp, len(paths))
+	for _, path := range paths {
+		stamps[path] = stampOf(path)
+	}
+	return stamps
+}
+
+// changedStamps counts files that were added, removed, or modified between two snapshots
+func changedStamps(previous, current map[string]fileStamp) int {
+	changed := 0
+	for path, stamp := range current {
+		if old, ok := previous[path]; !ok || old != stamp {
+			changed++
+		}
+	}
+	for path := range previous {
+		if _, ok := current[path]; !ok {
+			changed++
+		}
+	}
+	return changed
+}
+
+// changeStamps records the files watch regenerates the prompt for: the included
+// files, the fix file, and the templates in the prompts directories. A file target
+// is left out so writing the prompt doesn't trigger another refresh.
+func changeStamps(orch *orchestrator.Orchestrator, request *models.PromptRequest, cfg *interfaces.Config) map[string]fileStamp {
+	stamps := includedStamps(request, cfg)
+	if request.FixMode {
+		stamps[request.FixFile] = stampOf(request.FixFile)
+	}
+	for _, location := range templateLocations(orch) {
+		filepath.WalkDir(location, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if entry.IsDir() {
+				if path != location && strings.HasPrefix(entry.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			stamps[path] = stampOf(path)
+			return nil
+		})
+	}
+
+	dropTarget(stamps, request, cfg)
+	return stamps
+}
+
+// dropTarget removes the file the prompt is written to from stamps
+func dropTarget(stamps map[string]fileStamp, request *models.PromptRequest, cfg *interfaces.Config) {
+	target := request.Target
+	if target == "" {
+		target = cfg.Target
+	}
+	if !models.IsFileTarget(target) {
+		return
+	}
+	output, err := orchestrator.FileTargetPath(target, request, cfg)
+	if err != nil {
+		return
+	}
+	for path := range stamps {
+		if sameFile(path, output) {
+			delete(stamps, path)
+		}
+	}
+}
+
+// watchDirectories asks the watcher for events in every directory holding a stamped
+// file, the included directory, and the prompts directories. Directories that don't
+// exist yet are skipped.
+func watchDirectories(watcher *fsnotify.Watcher, stamps map[string]fileStamp, request *models.PromptRequest, orch *orchestrator.Orchestrator) {
+	dirs := templateLocations(orch)
+	if request.Directory != "" {
+		dirs = append(dirs, request.Directory)
+	}
+	for path := range stamps {
+		dirs = append(dirs, filepath.Dir(path))
+	}
+
+	watched := make(map[string]bool)
+	for _, path := range watcher.WatchList() {
+		watched[path] = true
+	}
+	for _, dir := range dirs {
+		if dir = filepath.Clean(dir); watched[dir] {
+			continue
+		}
+		watched[dir] = true
+		if err := watcher.Add(dir); err != nil {
+			slog.Debug("not watching directory", "dir", dir, "error", err)
+		}
+	}
+}
+
+// templateLocations returns the prompts directories templates are loaded from
+func templateLocations(orch *orchestrator.Orchestrator) []string {
+	processor, ok := orch.GetTemplateProcessor().(*template.Processor)
+	if !ok {
+		return nil
+	}
+	var locations []string
+	for _, location := range processor.GetPromptLocations() {
+		if location != "" {
+			locations = append(locations, location)
+		}
+	}
+	return locations
+}
+
+// stampOf returns the stamp of a file, the zero stamp when it doesn't exist
+func stampOf(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+// sameFile reports whether two paths name the same file
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
@@ -0,0 +1,170 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/pkg/models"
+)
+
+// writeFile writes content to path, failing the test on error
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// watchRequest returns a noninteractive request including dir and writing to a file
+// in it, with a config that keeps output quiet
+func watchRequest(t *testing.T, dir string) *models.PromptRequest {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	writeFile(t, configPath, "quiet = true\nhistory_enabled = false\nstats_enabled = false\nprompts_location = \""+filepath.Join(dir, "prompts")+"\"\n")
+
+	request := models.NewPromptRequest()
+	request.ConfigPath = configPath
+	request.BasePrompt = "review this"
+	request.ForceNonInteractive = true
+	request.Directory = dir
+	request.Target = models.TargetFilePrefix + filepath.Join(dir, "ctx.md")
+	return request
+}
+
+// waitForFile waits until path exists and returns its stamp
+func waitForFile(t *testing.T, path string) fileStamp {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if stamp := stampOf(path); stamp != (fileStamp{}) {
+			return stamp
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%s was never written", path)
+	return fileStamp{}
+}
+
+func TestWatchStamps_LeavesOutFileTarget(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	writeFile(t, filepath.Join(dir, "ctx.md"), "old prompt\n")
+
+	request := models.NewPromptRequest()
+	request.Directory = dir
+	cfg := &interfaces.Config{Target: models.TargetFilePrefix + filepath.Join(dir, "ctx.md")}
+
+	for _, target := range []string{"", models.TargetAppendPrefix + filepath.Join(dir, "ctx.md")} {
+		request.Target = target
+		stamps := watchStamps(request, cfg)
+		if _, ok := stamps[filepath.Join(dir, "main.go")]; !ok {
+			t.Errorf("target %q: expected main.go to be watched, got %v", target, stamps)
+		}
+		if _, ok := stamps[filepath.Join(dir, "ctx.md")]; ok {
+			t.Errorf("target %q: expected the output file not to be watched", target)
+		}
+	}
+}
+
+func TestChangeStamps(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	writeFile(t, filepath.Join(dir, "fix.log"), "FAIL\n")
+
+	request := models.NewPromptRequest()
+	request.Files = []string{filepath.Join(dir, "main.go") + ":1-1"}
+	request.FixMode = true
+	request.FixFile = filepath.Join(dir, "fix.log")
+	request.Target = models.TargetFilePrefix + filepath.Join(dir, "main.go")
+
+	stamps := changeStamps(orchestrator.New(), request, &interfaces.Config{})
+	if _, ok := stamps[request.FixFile]; !ok {
+		t.Errorf("expected the fix file to be watched, got %v", stamps)
+	}
+	if _, ok := stamps[filepath.Join(dir, "main.go")]; ok {
+		t.Errorf("expected the output file not to be watched even when included")
+	}
+}
+
+func TestChangedStamps(t *testing.T) {
+	now := time.Now()
+	previous := map[string]fileStamp{
+		"same":    {modTime: now, size: 1},
+		"edited":  {modTime: now, size: 1},
+		"removed": {modTime: now, size: 1},
+	}
+	current := map[string]fileStamp{
+		"same":   {modTime: now, size: 1},
+		"edited": {modTime: now.Add(time.Second), size: 1},
+		"added":  {modTime: now, size: 1},
+	}
+	if changed := changedStamps(previous, current); changed != 3 {
+		t.Errorf("changedStamps = %d, want 3", changed)
+	}
+	if changed := changedStamps(current, current); changed != 0 {
+		t.Errorf("changedStamps of the same snapshot = %d, want 0", changed)
+	}
+}
+
+func TestWatch_FileTargetDoesNotRefreshItself(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	request := watchRequest(t, dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Watch(ctx, request, 10*time.Millisecond) }()
+
+	output := filepath.Join(dir, "ctx.md")
+	written := waitForFile(t, output)
+	time.Sleep(200 * time.Millisecond)
+	if stampOf(output) != written {
+		t.Error("expected writing the prompt not to trigger a refresh")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+}
+
+func TestWatchChanges_RefreshesOnChange(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	request := watchRequest(t, dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- WatchChanges(ctx, request, 10*time.Millisecond) }()
+
+	output := filepath.Join(dir, "ctx.md")
+	written := waitForFile(t, output)
+	time.Sleep(200 * time.Millisecond)
+	if stampOf(output) != written {
+		t.Fatal("expected writing the prompt not to trigger a refresh")
+	}
+
+	// Saving an included file regenerates the prompt
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {}\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for stampOf(output) == written && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if stampOf(output) == written {
+		t.Error("expected a change to an included file to refresh the prompt")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("WatchChanges failed: %v", err)
+	}
+}
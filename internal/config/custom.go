@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"prompter-cli/internal/interfaces"
+)
+
+var (
+	// customFlagName matches the flag names custom templates may use
+	customFlagName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)
+
+	// customShorthand matches a custom template flag's one-character shorthand
+	customShorthand = regexp.MustCompile(`^[A-Za-z0-9]$`)
+
+	// variableName matches the template variables a custom template flag's value can set
+	variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// validateCustomTemplates checks each custom template's type, flag, shorthand, and
+// variable, and that no two custom templates use the same flag or shorthand.
+// Collisions with built-in flags are found when the flags are registered.
+func validateCustomTemplates(templates map[string]interfaces.CustomTemplate) error {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	flags := make(map[string]string, len(templates))
+	shorthands := make(map[string]string, len(templates))
+	for _, name := range names {
+		custom := templates[name]
+		if custom.Type != "pre" && custom.Type != "post" {
+			return fmt.Errorf("custom_template.%s: invalid type: %s (must be 'pre' or 'post')", name, custom.Type)
+		}
+		if !customFlagName.MatchString(custom.Flag) {
+			return fmt.Errorf("custom_template.%s: invalid flag: %q (must be letters, digits, and '-', not starting with '-')", name, custom.Flag)
+		}
+		if other, ok := flags[custom.Flag]; ok {
+			return fmt.Errorf("custom_template.%s: flag --%s is already used by custom_template.%s", name, custom.Flag, other)
+		}
+		flags[custom.Flag] = name
+
+		if custom.Shorthand != "" {
+			if !customShorthand.MatchString(custom.Shorthand) {
+				return fmt.Errorf("custom_template.%s: invalid shorthand: %q (must be a single letter or digit)", name, custom.Shorthand)
+			}
+			if other, ok := shorthands[custom.Shorthand]; ok {
+				return fmt.Errorf("custom_template.%s: shorthand -%s is already used by custom_template.%s", name, custom.Shorthand, other)
+			}
+			shorthands[custom.Shorthand] = name
+		}
+
+		if custom.Variable != "" && !variableName.MatchString(custom.Variable) {
+			return fmt.Errorf("custom_template.%s: invalid variable: %q (must be letters, digits, and '_', not starting with a digit)", name, custom.Variable)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+var (
+	tableHeaderPattern = regexp.MustCompile(`^\s*\[([^\[\]]+)\]\s*(#.*)?$`)
+	assignmentPattern  = regexp.MustCompile(`^\s*((?:[A-Za-z0-9_-]+|"[^"]*"|'[^']*')(?:\s*\.\s*(?:[A-Za-z0-9_-]+|"[^"]*"|'[^']*'))*)\s*=`)
+	bareKeyPattern     = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	quotedTextPattern  = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'[^']*'`)
+)
+
+// SetFileValue sets key to value in the TOML config file at path. Only the lines
+// holding the key change, so comments and layout are kept; a missing key is added to
+// its table and a missing table to the end of the file. A missing file is created.
+func SetFileValue(path, key string, value interface{}) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		data = []byte(fmt.Sprintf("config_version = %d\n", CurrentConfigVersion))
+	} else if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	updated, err := setTOMLValue(string(data), strings.ToLower(key), value)
+	if err != nil {
+		return err
+	}
+
+	// Never replace the file with one that no longer parses
+	var settings map[string]interface{}
+	if err := toml.Unmarshal([]byte(updated), &settings); err != nil {
+		return fmt.Errorf("failed to update %s in %s: %w", key, path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("failed to write config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// setTOMLValue returns content with key set to value
+func setTOMLValue(content, key string, value interface{}) (string, error) {
+	table, name := "", key
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		table, name = key[:i], key[i+1:]
+	}
+
+	encoded, err := EncodeValue(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	line := formatTOMLKey(name) + " = " + encoded
+
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	current := ""
+	tableFound := table == ""
+	lastInTable := -1 // Last line of the table's final assignment, or its header
+	firstHeader := -1
+	for i := 0; i < len(lines); i++ {
+		if match := tableHeaderPattern.FindStringSubmatch(lines[i]); match != nil {
+			current = normalizeTOMLKey(match[1])
+			if firstHeader < 0 {
+				firstHeader = i
+			}
+			if current == table {
+				tableFound = true
+				lastInTable = i
+			}
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), "[[") {
+			current = "[[" // Entries of an array of tables are never edited
+			continue
+		}
+
+		match := assignmentPattern.FindStringSubmatch(lines[i])
+		if match == nil {
+			continue
+		}
+		end := valueEnd(lines, i)
+		assigned := normalizeTOMLKey(match[1])
+		if current != "" {
+			assigned = current + "." + assigned
+		}
+		if assigned == key {
+			updated := append(append(append([]string{}, lines[:i]...), line), lines[end+1:]...)
+			return strings.Join(updated, "\n") + "\n", nil
+		}
+		if current == table {
+			lastInTable = end
+		}
+		i = end
+	}
+
+	switch {
+	case !tableFound:
+		if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "["+table+"]", line)
+	case lastInTable >= 0:
+		lines = insertLines(lines, lastInTable+1, line)
+	case firstHeader >= 0:
+		// Top-level keys go above the first table and the comments introducing it
+		at := firstHeader
+		for at > 0 && strings.HasPrefix(strings.TrimSpace(lines[at-1]), "#") {
+			at--
+		}
+		lines = insertLines(lines, at, line, "")
+	default:
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// valueEnd returns the last line of the value assigned on line i, which differs from
+// i for multi-line strings and arrays
+func valueEnd(lines []string, i int) int {
+	rest := strings.TrimSpace(lines[i][strings.Index(lines[i], "=")+1:])
+
+	for _, delimiter := range []string{`"""`, `'''`} {
+		if !strings.HasPrefix(rest, delimiter) {
+			continue
+		}
+		if strings.Contains(rest[len(delimiter):], delimiter) {
+			return i
+		}
+		for j := i + 1; j < len(lines); j++ {
+			if strings.Contains(lines[j], delimiter) {
+				return j
+			}
+		}
+		return len(lines) - 1
+	}
+
+	if !strings.HasPrefix(rest, "[") && !strings.HasPrefix(rest, "{") {
+		return i
+	}
+	depth := 0
+	for j := i; j < len(lines); j++ {
+		text := rest
+		if j > i {
+			text = lines[j]
+		}
+		text = quotedTextPattern.ReplaceAllString(text, "")
+		if hash := strings.Index(text, "#"); hash >= 0 {
+			text = text[:hash]
+		}
+		depth += strings.Count(text, "[") + strings.Count(text, "{") - strings.Count(text, "]") - strings.Count(text, "}")
+		if depth <= 0 {
+			return j
+		}
+	}
+	return len(lines) - 1
+}
+
+// EncodeValue returns value written as a TOML value, with strings double-quoted like
+// the rest of prompter's config files
+func EncodeValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return quoteTOMLString(v)
+	case []string:
+		quoted := make([]string, len(v))
+		for i, item := range v {
+			var err error
+			if quoted[i], err = quoteTOMLString(item); err != nil {
+				return "", err
+			}
+		}
+		return "[" + strings.Join(quoted, ", ") + "]", nil
+	case []interface{}:
+		// Lists read back by viper
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				items = nil
+				break
+			}
+			items[i] = s
+		}
+		if items != nil {
+			return EncodeValue(items)
+		}
+	}
+
+	// Tables, as in a list of [[template_sources]], are kept on the one line
+	var b strings.Builder
+	encoder := toml.NewEncoder(&b)
+	encoder.SetTablesInline(true)
+	if err := encoder.Encode(map[string]interface{}{"v": value}); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.TrimPrefix(b.String(), "v = ")), nil
+}
+
+// quoteTOMLString returns s as a TOML basic string. JSON string escapes are a subset
+// of TOML's.
+func quoteTOMLString(s string) (string, error) {
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(s); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// formatTOMLKey quotes name when it is not a valid bare key
+func formatTOMLKey(name string) string {
+	if bareKeyPattern.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// normalizeTOMLKey returns a dotted key or table name without quotes or spacing and
+// in lower case, the way viper reads it
+func normalizeTOMLKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if len(part) >= 2 && (part[0] == '"' || part[0] == '\'') {
+			part = part[1 : len(part)-1]
+		}
+		parts[i] = strings.ToLower(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// insertLines returns lines with extra inserted before index at
+func insertLines(lines []string, at int, extra ...string) []string {
+	updated := append([]string{}, lines[:at]...)
+	updated = append(updated, extra...)
+	return append(updated, lines[at:]...)
+}
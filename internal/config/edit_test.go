@@ -0,0 +1,168 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const editableConfig = `# Prompter configuration
+config_version = 2
+target = "clipboard" # where prompts go
+exclude_patterns = [
+  "vendor/",
+  "[generated]",
+]
+
+# Model settings
+[openai]
+model = "gpt-4o"
+
+[vars]
+team = "core"
+`
+
+func TestSetTOMLValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value interface{}
+		want  string
+	}{
+		{
+			name:  "replaces a top-level key",
+			key:   "target",
+			value: "stdout",
+			want:  "# Prompter configuration\nconfig_version = 2\ntarget = \"stdout\"\nexclude_patterns = [\n",
+		},
+		{
+			name:  "replaces a multi-line array",
+			key:   "exclude_patterns",
+			value: []string{"dist/"},
+			want:  "target = \"clipboard\" # where prompts go\nexclude_patterns = [\"dist/\"]\n\n# Model settings\n",
+		},
+		{
+			name:  "adds a top-level key above the first table",
+			key:   "max_tokens",
+			value: int64(8000),
+			want:  "]\nmax_tokens = 8000\n\n# Model settings\n[openai]\n",
+		},
+		{
+			name:  "replaces a key in a table",
+			key:   "openai.model",
+			value: "gpt-4.1",
+			want:  "[openai]\nmodel = \"gpt-4.1\"\n\n[vars]\n",
+		},
+		{
+			name:  "adds a key to an existing table",
+			key:   "vars.lang",
+			value: "go",
+			want:  "[vars]\nteam = \"core\"\nlang = \"go\"\n",
+		},
+		{
+			name:  "adds a missing table",
+			key:   "recipe.review.pre",
+			value: "review",
+			want:  "team = \"core\"\n\n[recipe.review]\npre = \"review\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := setTOMLValue(editableConfig, tt.key, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("result does not contain %q:\n%s", tt.want, got)
+			}
+			if !strings.Contains(got, "# Prompter configuration\n") || !strings.Contains(got, "# Model settings\n") {
+				t.Errorf("comments were not kept:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestSetFileValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompter", "config.toml")
+
+	if err := SetFileValue(path, "editor", "vim"); err != nil {
+		t.Fatalf("SetFileValue on a missing file failed: %v", err)
+	}
+	if err := SetFileValue(path, "skip_heuristics", []string{"lockfile"}); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager()
+	cfg, err := manager.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Editor != "vim" || !reflect.DeepEqual(cfg.SkipHeuristics, []string{"lockfile"}) || cfg.ConfigVersion != CurrentConfigVersion {
+		t.Errorf("unexpected config: editor=%q skip_heuristics=%v version=%d", cfg.Editor, cfg.SkipHeuristics, cfg.ConfigVersion)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("config file not created: %v", err)
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		key     string
+		raw     string
+		want    interface{}
+		wantErr bool
+	}{
+		{key: "editor", raw: "code --wait", want: "code --wait"},
+		{key: "embed_content", raw: "true", want: true},
+		{key: "max_tokens", raw: "8000", want: int64(8000)},
+		{key: "openai.temperature", raw: "0.2", want: 0.2},
+		{key: "exclude_patterns", raw: "vendor/, dist/", want: []string{"vendor/", "dist/"}},
+		{key: "skip_heuristics", raw: `["lockfile"]`, want: []string{"lockfile"}},
+		{key: "recipe.review.fix", raw: "yes", wantErr: true},
+		{key: "vars.team", raw: "core", want: "core"},
+		{key: "max_tokens", raw: "many", wantErr: true},
+		{key: "openai", raw: "gpt", wantErr: true},
+		{key: "no_such_key", raw: "1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseValue(tt.key, tt.raw)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseValue(%q, %q) = %v, want an error", tt.key, tt.raw, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseValue(%q, %q) failed: %v", tt.key, tt.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseValue(%q, %q) = %#v, want %#v", tt.key, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestManager_Set(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // Validate creates the default prompts directory
+	manager := NewManager()
+	if _, err := manager.Load(filepath.Join(t.TempDir(), "config.toml")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := manager.Set("target", "printer"); err == nil {
+		t.Error("expected an invalid target to be rejected")
+	}
+	if err := manager.Set("target", "stdout"); err != nil {
+		t.Errorf("Set failed: %v", err)
+	}
+	if value, ok := manager.Get("target"); !ok || value != "stdout" {
+		t.Errorf("Get(target) = %v, %v", value, ok)
+	}
+	if _, ok := manager.Get("no_such_key"); ok {
+		t.Error("expected an unknown key to be reported")
+	}
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"prompter-cli/internal/subprocess"
+	"prompter-cli/pkg/models"
+)
+
+// commandTimeout limits each $(command) substitution in a config value
+const commandTimeout = 10 * time.Second
+
+// expansionPattern matches ${NAME} environment variables and $(command) substitutions
+var expansionPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$\(([^()]*)\)`)
+
+// expandValue replaces ${NAME} in value with the environment variable, unset ones
+// with nothing, and $(command) with the output of run. Substitutions are left as
+// written when run is nil, and the first failing command is returned.
+func expandValue(value string, run func(command string) (string, error)) (string, error) {
+	if !strings.Contains(value, "$") {
+		return value, nil
+	}
+
+	var firstErr error
+	expanded := expansionPattern.ReplaceAllStringFunc(value, func(match string) string {
+		groups := expansionPattern.FindStringSubmatch(match)
+		if groups[1] != "" {
+			return os.Getenv(groups[1])
+		}
+		if run == nil || firstErr != nil {
+			return match
+		}
+		output, err := run(strings.TrimSpace(groups[2]))
+		if err != nil {
+			firstErr = err
+			return match
+		}
+		return output
+	})
+	return expanded, firstErr
+}
+
+// expand expands the value of key like expandPath, also running $(command)
+// substitutions when expand_commands is set. Those in a value read from a project
+// config are left as written, since a checked-out repository shouldn't run commands
+// the user only enabled for their own config. The first failing command is kept for
+// Resolve to report.
+func (m *Manager) expand(key, value string) string {
+	var run func(string) (string, error)
+	if m.v.GetBool("expand_commands") && !m.fromProject(key) {
+		run = m.runCommand
+	}
+	expanded, err := expandValue(value, run)
+	if err != nil && m.expandErr == nil {
+		m.expandErr = err
+	}
+	return expandHome(expanded)
+}
+
+// expandTarget expands the path of a file: or file+: target, the value of key
+func (m *Manager) expandTarget(key, target string) string {
+	for _, prefix := range []string{models.TargetFilePrefix, models.TargetAppendPrefix} {
+		if strings.HasPrefix(target, prefix) {
+			return prefix + m.expand(key, strings.TrimPrefix(target, prefix))
+		}
+	}
+	return target
+}
+
+// expandTargets expands the paths of the file targets in targets, the value of key
+func (m *Manager) expandTargets(key string, targets []string) []string {
+	expanded := make([]string, len(targets))
+	for i, target := range targets {
+		expanded[i] = m.expandTarget(key, target)
+	}
+	return expanded
+}
+
+// commandResult is the outcome of a $(command) substitution, run once per process
+type commandResult struct {
+	once   sync.Once
+	output string
+	err    error
+}
+
+// commandResults holds a *commandResult for each command run, shared by every
+// manager since each command loads the config with one of its own
+var commandResults sync.Map
+
+// runCommand runs a $(command) substitution with sh -c and returns its output
+// without trailing newlines. Each command runs once per process; a failure is
+// returned to every manager expanding it.
+func (m *Manager) runCommand(command string) (string, error) {
+	value, _ := commandResults.LoadOrStore(command, &commandResult{})
+	result := value.(*commandResult)
+	result.once.Do(func() {
+		result.output, result.err = runShellCommand(command)
+	})
+	return result.output, result.err
+}
+
+// runShellCommand runs command with sh -c, killing it after commandTimeout
+func runShellCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := subprocess.Shell(ctx, command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("timed out after %s", commandTimeout)
+		}
+		if detail := strings.TrimSpace(stderr.String()); detail != "" {
+			err = fmt.Errorf("%w: %s", err, detail)
+		}
+		return "", fmt.Errorf("config command $(%s) failed: %w", command, err)
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandValue(t *testing.T) {
+	t.Setenv("PROMPTER_TEST_ROOT", "/srv/prompts")
+	run := func(command string) (string, error) { return "<" + command + ">", nil }
+
+	tests := []struct {
+		name     string
+		value    string
+		run      func(string) (string, error)
+		expected string
+	}{
+		{"environment variable", "${PROMPTER_TEST_ROOT}/team", nil, "/srv/prompts/team"},
+		{"unset variable", "${PROMPTER_TEST_UNSET}/team", nil, "/team"},
+		{"unbraced variable", "$PROMPTER_TEST_ROOT/team", nil, "$PROMPTER_TEST_ROOT/team"},
+		{"command disabled", "$(hostname)/fix.txt", nil, "$(hostname)/fix.txt"},
+		{"command", "/tmp/$( hostname )/fix.txt", run, "/tmp/<hostname>/fix.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := expandValue(tt.value, tt.run)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expandValue(%s) = %s, expected %s", tt.value, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestManager_Resolve_Expansion(t *testing.T) {
+	t.Setenv("PROMPTER_TEST_ROOT", "/srv")
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.toml")
+	content := `fix_file = "/tmp/$(echo fixes)/${PROMPTER_TEST_ROOT}.txt"
+target = "file:${PROMPTER_TEST_ROOT}/prompt.md"
+target_fallbacks = ["file+:$(echo /var)/prompts.md", "stdout"]
+`
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager()
+	if _, err := manager.Load(configFile); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	config, err := manager.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	// Commands only run once turned on
+	if config.FixFile != "/tmp/$(echo fixes)//srv.txt" {
+		t.Errorf("FixFile = %s", config.FixFile)
+	}
+	if config.Target != "file:/srv/prompt.md" {
+		t.Errorf("Target = %s", config.Target)
+	}
+
+	manager.v.Set("expand_commands", true)
+	config, err = manager.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if config.FixFile != "/tmp/fixes//srv.txt" {
+		t.Errorf("FixFile = %s", config.FixFile)
+	}
+	if config.TargetFallbacks[0] != "file+:/var/prompts.md" || config.TargetFallbacks[1] != "stdout" {
+		t.Errorf("TargetFallbacks = %v", config.TargetFallbacks)
+	}
+
+	manager.v.Set("fix_file", "$(echo broken >&2; exit 3)")
+	if _, err := manager.Resolve(); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("expected the failing command to be reported, got %v", err)
+	}
+}
+
+func TestManager_Load_ProjectConfigExpandCommands(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	project := "expand_commands = true\nfix_file = \"$(echo /tmp)/fix.txt\"\nlocal_prompts_location = \"${PROMPTER_TEST_UNSET}/prompts\"\n"
+	if err := os.WriteFile(filepath.Join(repo, ".prmpt.toml"), []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+	global := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(global, []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(repo)
+
+	manager := NewManager()
+	if _, err := manager.Load(global); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	config, err := manager.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if config.FixFile != "$(echo /tmp)/fix.txt" {
+		t.Errorf("expected a project config not to turn on commands, FixFile = %s", config.FixFile)
+	}
+	if config.LocalPromptsLocation != "/prompts" {
+		t.Errorf("expected expansion before resolving against the project, got %s", config.LocalPromptsLocation)
+	}
+}
+
+func TestManager_Load_ProjectConfigKeepsCommands(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	pwned := filepath.Join(t.TempDir(), "pwned")
+	project := "fix_file = \"$(touch " + pwned + ")\"\n"
+	if err := os.WriteFile(filepath.Join(repo, ".prmpt.toml"), []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+	global := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(global, []byte("expand_commands = true\nlog_file = \"$(echo /tmp)/prompter.log\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(repo)
+	config, err := NewManager().Load(global)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	// Commands enabled in the global config only run in its own values
+	if config.LogFile != "/tmp/prompter.log" {
+		t.Errorf("expected the global value's command to run, LogFile = %s", config.LogFile)
+	}
+	if config.FixFile != "$(touch "+pwned+")" {
+		t.Errorf("expected the project value to be left as written, FixFile = %s", config.FixFile)
+	}
+	if _, err := os.Stat(pwned); err == nil {
+		t.Error("a command in a project config value ran")
+	}
+}
+
+func TestManager_CommandsRunOncePerProcess(t *testing.T) {
+	dir := t.TempDir()
+	runs := filepath.Join(dir, "runs")
+	configFile := filepath.Join(dir, "config.toml")
+	content := "expand_commands = true\nfix_file = \"$(echo run >> " + runs + "; echo /tmp/fix.txt)\"\n"
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every command loads the config with its own manager
+	for range 3 {
+		config, err := NewManager().Load(configFile)
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if config.FixFile != "/tmp/fix.txt" {
+			t.Errorf("FixFile = %s", config.FixFile)
+		}
+	}
+	if data, _ := os.ReadFile(runs); strings.Count(string(data), "run") != 1 {
+		t.Errorf("expected the command to run once, ran %d times", strings.Count(string(data), "run"))
+	}
+}
@@ -0,0 +1,302 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/viper"
+	"prompter-cli/internal/suggest"
+)
+
+// optionalKeys are the settings read without a default, mapped to a zero value of
+// their type
+var optionalKeys = map[string]interface{}{
+	"ignore_file":        "", // Defaults to the directory of the config file when loaded
+	"tokenizer_file":     "",
+	"model":              "",
+	"pipeline":           []string{},
+	"exclude_patterns":   []string{},
+	"disabled_plugins":   []string{},
+	"fetch_allow":        []string{},
+	"template_sources":   []map[string]interface{}{},
+	"template_registry":  "",
+	"openai.temperature": 0.0,
+	"ollama.output_file": "",
+}
+
+// stringTables are the tables whose entries are names of the user's choosing mapped to
+// strings
+var stringTables = map[string]bool{
+	"vars":            true,
+	"redact_patterns": true,
+	"webhook.headers": true,
+}
+
+// tableFields are the fields of each [<table>.<name>] entry, mapped to a zero value of
+// their type
+var tableFields = map[string]map[string]interface{}{
+	"custom_template": {
+		"location":    "",
+		"interactive": false,
+		"flag":        "",
+		"shorthand":   "",
+		"type":        "",
+		"description": "",
+		"variable":    "",
+	},
+	"wasm_plugin": {
+		"path":       "",
+		"functions":  []string{},
+		"timeout_ms": 0,
+	},
+	"helper": {
+		"command":    "",
+		"args":       []string{},
+		"timeout_ms": 0,
+		"cache_ttl":  0,
+	},
+	"models": {
+		"context_window":  0,
+		"response_tokens": 0,
+		"tokenizer":       "",
+		"file_format":     "",
+	},
+	"recipe": {
+		"description":        "",
+		"prompt":             "",
+		"pre":                "",
+		"post":               "",
+		"files":              []string{},
+		"directory":          false,
+		"directory_strategy": "",
+		"fix":                false,
+		"outline":            false,
+		"target":             "",
+	},
+	"preset": {
+		"description":        "",
+		"pre":                "",
+		"post":               "",
+		"files":              []string{},
+		"directory":          false,
+		"directory_strategy": "",
+		"exclude":            []string{},
+		"target":             "",
+	},
+}
+
+// tableStringTables are the fields of [<table>.<name>] entries that are themselves
+// tables of names of the user's choosing mapped to strings
+var tableStringTables = map[string]string{
+	"preset": "vars",
+}
+
+// keyZero returns a zero value of the type of the setting named by key, and false
+// when key is not a setting prompter reads. Tables such as openai are not settings.
+func keyZero(key string) (interface{}, bool) {
+	key = strings.ToLower(key)
+	if zero, ok := optionalKeys[key]; ok {
+		return zero, true
+	}
+
+	for table := range stringTables {
+		if name, ok := strings.CutPrefix(key, table+"."); ok {
+			return "", name != "" && !strings.Contains(name, ".")
+		}
+	}
+	parts := strings.Split(key, ".")
+	if fields, ok := tableFields[parts[0]]; ok {
+		if field, ok := tableStringTables[parts[0]]; ok && len(parts) == 4 && parts[1] != "" && parts[2] == field {
+			return "", parts[3] != ""
+		}
+		if len(parts) != 3 || parts[1] == "" {
+			return nil, false
+		}
+		zero, ok := fields[parts[2]]
+		return zero, ok
+	}
+
+	defaults := viper.New()
+	setDefaults(defaults)
+	if !defaults.IsSet(key) {
+		return nil, false
+	}
+	value := defaults.Get(key)
+	if _, isTable := value.(map[string]interface{}); isTable {
+		return nil, false
+	}
+	return value, true
+}
+
+// KnownKey reports whether key names a setting prompter reads
+func KnownKey(key string) bool {
+	_, ok := keyZero(key)
+	return ok
+}
+
+// UnknownKey is a setting in a config file that prompter does not read
+type UnknownKey struct {
+	Key        string
+	Path       string // Config file setting it
+	Suggestion string // Closest known key, empty when none is close
+}
+
+// String describes the unknown key with its suggestion
+func (k UnknownKey) String() string {
+	message := fmt.Sprintf("unknown config key %q in %s", k.Key, k.Path)
+	if k.Suggestion != "" {
+		message += fmt.Sprintf(" (did you mean %q?)", k.Suggestion)
+	}
+	return message
+}
+
+// UnknownKeys returns the settings in the loaded config file and project configs that
+// prompter does not read, usually misspellings, sorted by key
+func (m *Manager) UnknownKeys() []UnknownKey {
+	var unknown []UnknownKey
+	for _, key := range m.v.AllKeys() {
+		path := m.origins[key]
+		if path == "" {
+			if !m.v.InConfig(key) {
+				continue // Defaults and overrides
+			}
+			path = m.path
+		}
+		if KnownKey(key) {
+			continue
+		}
+		if table, ok := m.v.Get(key).(map[string]interface{}); ok && len(table) == 0 {
+			continue // An empty table such as [recipe.draft]
+		}
+		unknown = append(unknown, UnknownKey{Key: key, Path: path, Suggestion: SuggestKey(key)})
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].Key < unknown[j].Key })
+	return unknown
+}
+
+// UnknownKeyError returns the error for a key prompter does not read, suggesting the
+// closest known key
+func UnknownKeyError(key string) error {
+	if suggestion := SuggestKey(strings.ToLower(key)); suggestion != "" {
+		return fmt.Errorf("unknown config key %q (did you mean %q?)", key, suggestion)
+	}
+	return fmt.Errorf("unknown config key %q", key)
+}
+
+// SuggestKey returns the known key closest to a misspelled one, or "" when none is
+// close enough to be a likely typo
+func SuggestKey(key string) string {
+	defaults := viper.New()
+	setDefaults(defaults)
+	candidates := defaults.AllKeys()
+	for name := range optionalKeys {
+		candidates = append(candidates, name)
+	}
+
+	// Keep the user's name for entries of named tables
+	parts := strings.Split(key, ".")
+	for table := range stringTables {
+		if strings.Count(table, ".")+2 == len(parts) {
+			candidates = append(candidates, table+"."+parts[len(parts)-1])
+		}
+	}
+	switch len(parts) {
+	case 3:
+		for table, fields := range tableFields {
+			for field := range fields {
+				candidates = append(candidates, table+"."+parts[1]+"."+field)
+			}
+		}
+	}
+	sort.Strings(candidates)
+
+	best, bestDistance := "", len(key)/3+1
+	for _, candidate := range candidates {
+		if distance := suggest.Distance(key, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// ParseValue converts a value given on the command line to the type of the setting
+// named by key. Lists are written as a TOML array or as comma-separated values.
+func ParseValue(key, raw string) (interface{}, error) {
+	zero, ok := keyZero(key)
+	if !ok {
+		return nil, UnknownKeyError(key)
+	}
+
+	switch reflect.ValueOf(zero).Kind() {
+	case reflect.Bool:
+		value, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false, got %q", key, raw)
+		}
+		return value, nil
+	case reflect.Int, reflect.Int64:
+		value, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a whole number, got %q", key, raw)
+		}
+		return value, nil
+	case reflect.Float64:
+		value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number, got %q", key, raw)
+		}
+		return value, nil
+	case reflect.Slice:
+		return parseList(key, raw)
+	default:
+		// prompts_location is also accepted as a list of directories
+		if key == "prompts_location" && strings.HasPrefix(strings.TrimSpace(raw), "[") {
+			return parseList(key, raw)
+		}
+		return raw, nil
+	}
+}
+
+// parseList converts a list written as a TOML array or as comma-separated values
+func parseList(key, raw string) ([]string, error) {
+	trimmed := strings.TrimSpace(raw)
+	if strings.HasPrefix(trimmed, "[") {
+		var parsed struct {
+			V []string `toml:"v"`
+		}
+		if err := toml.Unmarshal([]byte("v = "+trimmed), &parsed); err != nil {
+			return nil, fmt.Errorf("%s must be a list of strings: %w", key, err)
+		}
+		if parsed.V == nil {
+			parsed.V = []string{}
+		}
+		return parsed.V, nil
+	}
+	values := []string{}
+	for _, value := range strings.Split(trimmed, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+// Get returns the loaded value of a setting or table, including project configs and
+// environment overrides, and false when key names neither
+func (m *Manager) Get(key string) (interface{}, bool) {
+	if !m.v.IsSet(key) && !KnownKey(key) {
+		return nil, false
+	}
+	return m.v.Get(key), true
+}
+
+// Set overrides a setting with value and validates the resulting configuration. Only
+// the loaded configuration changes; the config file is written with SetFileValue.
+func (m *Manager) Set(key string, value interface{}) error {
+	m.v.Set(key, value)
+	return m.Validate(m.getConfigFromViper())
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManager_UnknownKeys(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // Validate creates the default prompts directory
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := `defualt_pre = "review"
+target = "stdout"
+colour = "blue"
+
+[openai]
+modle = "gpt-4.1"
+
+[recipe.review]
+pre = "review"
+pots = "strict"
+
+[vars]
+anything = "goes"
+
+[webhook.headers]
+authorization = "Bearer ${HOOK_TOKEN}"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager()
+	cfg, err := manager.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []UnknownKey{
+		{Key: "colour", Path: path},
+		{Key: "defualt_pre", Path: path, Suggestion: "default_pre"},
+		{Key: "openai.modle", Path: path, Suggestion: "openai.model"},
+		{Key: "recipe.review.pots", Path: path, Suggestion: "recipe.review.post"},
+	}
+	got := manager.UnknownKeys()
+	if len(got) != len(want) {
+		t.Fatalf("UnknownKeys = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("UnknownKeys[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// Unknown keys are only warnings unless strict_config is set
+	if err := manager.Validate(cfg); err != nil {
+		t.Errorf("Validate failed without strict_config: %v", err)
+	}
+	cfg.StrictConfig = true
+	err = manager.Validate(cfg)
+	if err == nil || !strings.Contains(err.Error(), `"defualt_pre"`) || !strings.Contains(err.Error(), `did you mean "default_pre"`) {
+		t.Errorf("unexpected strict Validate error: %v", err)
+	}
+}
+
+func TestSuggestKey(t *testing.T) {
+	tests := map[string]string{
+		"edtor":            "editor",
+		"max_token":        "max_tokens",
+		"var.team":         "vars.team",
+		"webhok.headers.x": "webhook.headers.x",
+		"recipes.fix.post": "recipe.fix.post",
+		"something_else":   "",
+	}
+	for key, want := range tests {
+		if got := SuggestKey(key); got != want {
+			t.Errorf("SuggestKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -1,19 +1,39 @@
 package config
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
+	"prompter-cli/internal/content"
+	"prompter-cli/internal/git"
+	"prompter-cli/internal/history"
 	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/llm"
+	"prompter-cli/internal/logging"
+	"prompter-cli/internal/plugin"
+	"prompter-cli/internal/redact"
+	"prompter-cli/internal/stats"
+	"prompter-cli/internal/tokenizer"
+	"prompter-cli/internal/webhook"
+	"prompter-cli/pkg/models"
 )
 
 // Manager implements the ConfigManager interface
 type Manager struct {
-	v     *viper.Viper
-	flags map[string]interface{} // Store flag values for precedence
+	v         *viper.Viper
+	flags     map[string]interface{} // Store flag values for precedence
+	migration *MigrationResult       // Pending upgrade of an outdated config file
+	projects  []string               // Project config files merged over the global config by the last Load
+	path      string                 // Config file read by the last Load
+	origins   map[string]string      // Project config file each project setting was read from
+	expandErr error                  // First $(command) that failed while reading the config
 }
 
 // NewManager creates a new configuration manager
@@ -48,18 +68,150 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("default_pre", "")
 	v.SetDefault("default_post", "")
 	v.SetDefault("fix_file", "/tmp/prompter-fix.txt")
+	v.SetDefault("fix_include_files", true)
+	v.SetDefault("fix_context_lines", 10)
 	v.SetDefault("directory_strategy", "git")
 	v.SetDefault("target", "clipboard")
+	v.SetDefault("editor_target", models.TargetClipboard)
+	v.SetDefault("target_fallbacks", []string{models.TargetOSC52, models.TargetStdout})
 	v.SetDefault("interactive_default", true)
+	v.SetDefault("tui", false)
+	v.SetDefault("confirm_before_output", false)
+	v.SetDefault("config_version", CurrentConfigVersion)
+	v.SetDefault("strict_config", false)
+	v.SetDefault("embed_content", false)
+	v.SetDefault("max_tokens", 0)
+	v.SetDefault("tokenizer", tokenizer.Default)
+	v.SetDefault("budget_strategy", content.StrategyPriority)
+	v.SetDefault("openai.model", llm.DefaultOpenAIModel)
+	v.SetDefault("openai.api_key_env", llm.DefaultOpenAIKeyEnv)
+	v.SetDefault("openai.base_url", llm.DefaultOpenAIBaseURL)
+	v.SetDefault("anthropic.model", llm.DefaultAnthropicModel)
+	v.SetDefault("anthropic.api_key_env", llm.DefaultAnthropicKeyEnv)
+	v.SetDefault("anthropic.base_url", llm.DefaultAnthropicBaseURL)
+	v.SetDefault("anthropic.max_tokens", llm.DefaultAnthropicMaxTokens)
+	v.SetDefault("ollama.model", llm.DefaultOllamaModel)
+	v.SetDefault("ollama.base_url", llm.DefaultOllamaBaseURL)
+	v.SetDefault("webhook.format", webhook.DefaultFormat)
+	v.SetDefault("webhook.timeout_ms", int(webhook.DefaultTimeout/time.Millisecond))
+	v.SetDefault("max_file_size_bytes", 65536)
+	v.SetDefault("diff_context_lines", 3)
+	v.SetDefault("range_context_lines", 0)
+	v.SetDefault("file_format", content.DefaultFormat)
+	v.SetDefault("skip_heuristics", content.DefaultHeuristics)
+	v.SetDefault("content_cache", true)
+	v.SetDefault("redact", true)
+	v.SetDefault("git_recent_commits", git.DefaultRecentCommits)
+	v.SetDefault("project_indirect_dependencies", false)
+	v.SetDefault("tree_depth", content.DefaultTreeDepth)
+	v.SetDefault("include_docs", false)
+	v.SetDefault("docs_token_share", content.DefaultDocsTokenShare)
+	v.SetDefault("log_level", logging.DefaultLevel)
+	v.SetDefault("log_file", "")
+	v.SetDefault("quiet", false)
+	v.SetDefault("history_enabled", true)
+	v.SetDefault("history_location", history.DefaultLocation)
+	v.SetDefault("history_limit", 500)
+	v.SetDefault("stats_enabled", true)
+	v.SetDefault("stats_location", stats.DefaultLocation)
+	v.SetDefault("plugins_location", "~/.config/prompter/plugins")
+	v.SetDefault("plugin_timeout_ms", int(plugin.DefaultTimeout/time.Millisecond))
+	v.SetDefault("template_strict", false)
+	v.SetDefault("template_separator", "\n\n")
+	v.SetDefault("template_sources_location", DefaultTemplateSourcesLocation)
+	v.SetDefault("allow_exec", false)
+	v.SetDefault("expand_commands", false)
+	v.SetDefault("exec_timeout_ms", 10000)
+	v.SetDefault("exec_max_bytes", 65536)
+	v.SetDefault("fetch_timeout_ms", 10000)
+	v.SetDefault("fetch_max_bytes", 65536)
 }
 
 // Load loads configuration from the specified path
 func (m *Manager) Load(path string) (*interfaces.Config, error) {
+	path, err := ResolveConfigPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m.migration = nil
+	m.projects = nil
+	m.path = path
+	m.origins = make(map[string]string)
+
+	// The global ignore file lives beside the config file unless configured otherwise
+	m.v.SetDefault("ignore_file", filepath.Join(filepath.Dir(path), content.PrmptignoreFile))
+
+	// Check if config file exists
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		// Config file doesn't exist, use defaults
+		slog.Debug("config file not found, using defaults", "path", path)
+		if err := m.mergeProjectConfigs(); err != nil {
+			return nil, err
+		}
+		traceEnvOverrides()
+		return m.getConfigFromViper(), nil
+	}
+	slog.Debug("loading config file", "path", path)
+
+	m.v.SetConfigFile(path)
+
+	// Upgrade outdated config files in memory; the file itself is only
+	// rewritten when the user confirms the migration
+	migration, err := PlanMigration(path)
+	if err != nil {
+		return nil, err
+	}
+	if migration != nil {
+		data, err := migration.Encode()
+		if err != nil {
+			return nil, err
+		}
+		if err := m.v.ReadConfig(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("failed to read migrated config file %s: %w", path, err)
+		}
+		m.migration = migration
+		slog.Debug("config file migrated in memory", "path", path, "from", migration.FromVersion, "to", migration.ToVersion)
+	} else if err := m.v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	if err := m.mergeProjectConfigs(); err != nil {
+		return nil, err
+	}
+	traceEnvOverrides()
+
+	return m.getConfigFromViper(), nil
+}
+
+// traceEnvOverrides logs the PROMPTER_ environment variables, which take precedence
+// over the config files
+func traceEnvOverrides() {
+	if !slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	for _, variable := range os.Environ() {
+		name, _, _ := strings.Cut(variable, "=")
+		if key, ok := strings.CutPrefix(name, "PROMPTER_"); ok {
+			slog.Debug("config setting overridden by environment", "key", strings.ToLower(key), "variable", name)
+		}
+	}
+}
+
+// PendingMigration returns the migration applied in memory by the last Load, or nil
+// when the config file was already current
+func (m *Manager) PendingMigration() *MigrationResult {
+	return m.migration
+}
+
+// ResolveConfigPath returns the config file path to load, defaulting to
+// ~/.config/prompter/config.toml and expanding a leading tilde
+func ResolveConfigPath(path string) (string, error) {
 	if path == "" {
 		// Use default config path
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get user home directory: %w", err)
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
 		}
 		path = filepath.Join(homeDir, ".config", "prompter", "config.toml")
 	}
@@ -68,24 +220,12 @@ func (m *Manager) Load(path string) (*interfaces.Config, error) {
 	if strings.HasPrefix(path, "~/") {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get user home directory: %w", err)
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
 		}
 		path = filepath.Join(homeDir, path[2:])
 	}
 
-	// Check if config file exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		// Config file doesn't exist, use defaults
-		return m.getConfigFromViper(), nil
-	}
-
-	m.v.SetConfigFile(path)
-
-	if err := m.v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
-	}
-
-	return m.getConfigFromViper(), nil
+	return path, nil
 }
 
 // SetFlag sets a flag value for precedence resolution
@@ -95,10 +235,20 @@ func (m *Manager) SetFlag(key string, value interface{}) {
 
 // Resolve applies precedence rules (flags > env > config > defaults)
 func (m *Manager) Resolve() (*interfaces.Config, error) {
+	m.expandErr = nil
 	config := m.getConfigFromViper()
+	if m.expandErr != nil {
+		return nil, m.expandErr
+	}
 
 	// Apply flag overrides (highest precedence)
+	for key, value := range m.flags {
+		if value != nil && value != "" {
+			slog.Debug("config setting overridden by flag", "key", key)
+		}
+	}
 	m.applyFlagOverrides(config)
+	applyModel(config)
 
 	return config, nil
 }
@@ -108,6 +258,7 @@ func (m *Manager) applyFlagOverrides(config *interfaces.Config) {
 	if val, exists := m.flags["prompts_location"]; exists && val != nil {
 		if str, ok := val.(string); ok && str != "" {
 			config.PromptsLocation = expandPath(str)
+			config.PromptsLocations = []string{config.PromptsLocation}
 		}
 	}
 
@@ -153,6 +304,12 @@ func (m *Manager) applyFlagOverrides(config *interfaces.Config) {
 		}
 	}
 
+	if val, exists := m.flags["model"]; exists && val != nil {
+		if str, ok := val.(string); ok && str != "" {
+			config.Model = str
+		}
+	}
+
 	if val, exists := m.flags["interactive_default"]; exists && val != nil {
 		if b, ok := val.(bool); ok {
 			config.InteractiveDefault = b
@@ -166,6 +323,20 @@ func (m *Manager) Validate(config *interfaces.Config) error {
 		return fmt.Errorf("config cannot be nil")
 	}
 
+	// Validate config version
+	if config.ConfigVersion > CurrentConfigVersion {
+		return fmt.Errorf("config_version %d is newer than this prompter supports (%d)", config.ConfigVersion, CurrentConfigVersion)
+	}
+
+	// Unknown settings are only reported as warnings unless strict_config is set
+	if unknown := m.UnknownKeys(); len(unknown) > 0 && config.StrictConfig {
+		messages := make([]string, len(unknown))
+		for i, key := range unknown {
+			messages[i] = key.String()
+		}
+		return fmt.Errorf("%s", strings.Join(messages, "; "))
+	}
+
 	// Validate directory strategy
 	validStrategies := map[string]bool{
 		"git":        true,
@@ -176,13 +347,140 @@ func (m *Manager) Validate(config *interfaces.Config) error {
 	}
 
 	// Validate target
-	validTargets := map[string]bool{
-		"clipboard": true,
-		"stdout":    true,
+	if !models.ValidTarget(config.Target) {
+		return fmt.Errorf("invalid target: %s (must be %s)", config.Target, models.TargetUsage)
+	}
+	if config.EditorTarget != "" && (!models.ValidTarget(config.EditorTarget) || config.EditorTarget == models.TargetEditor) {
+		return fmt.Errorf("invalid editor_target: %s (must be %s, other than 'editor')", config.EditorTarget, models.TargetUsage)
 	}
-	// Also allow file: prefix
-	if !validTargets[config.Target] && !strings.HasPrefix(config.Target, "file:") {
-		return fmt.Errorf("invalid target: %s (must be 'clipboard', 'stdout', or 'file:/path')", config.Target)
+	for _, fallback := range config.TargetFallbacks {
+		if !models.ValidTarget(fallback) || fallback == models.TargetEditor {
+			return fmt.Errorf("invalid target_fallbacks entry: %s (must be %s, other than 'editor')", fallback, models.TargetUsage)
+		}
+	}
+
+	// Validate content limits
+	if config.MaxTokens < 0 {
+		return fmt.Errorf("invalid max_tokens: %d (must be 0 for unlimited or positive)", config.MaxTokens)
+	}
+	if config.BudgetStrategy != "" && config.BudgetStrategy != content.StrategyPriority && config.BudgetStrategy != content.StrategyRelevance {
+		return fmt.Errorf("invalid budget_strategy: %s (must be '%s' or '%s')", config.BudgetStrategy, content.StrategyPriority, content.StrategyRelevance)
+	}
+	if err := tokenizer.Validate(config.Tokenizer, config.TokenizerFile); err != nil {
+		return fmt.Errorf("invalid tokenizer: %w", err)
+	}
+	if config.MaxFileSizeBytes < 0 {
+		return fmt.Errorf("invalid max_file_size_bytes: %d (must not be negative)", config.MaxFileSizeBytes)
+	}
+	if config.PluginTimeoutMS < 0 {
+		return fmt.Errorf("invalid plugin_timeout_ms: %d (must not be negative)", config.PluginTimeoutMS)
+	}
+	if config.ExecTimeoutMS < 0 {
+		return fmt.Errorf("invalid exec_timeout_ms: %d (must not be negative)", config.ExecTimeoutMS)
+	}
+	if config.ExecMaxBytes < 0 {
+		return fmt.Errorf("invalid exec_max_bytes: %d (must not be negative)", config.ExecMaxBytes)
+	}
+	if config.FetchTimeoutMS < 0 {
+		return fmt.Errorf("invalid fetch_timeout_ms: %d (must not be negative)", config.FetchTimeoutMS)
+	}
+	if config.FetchMaxBytes < 0 {
+		return fmt.Errorf("invalid fetch_max_bytes: %d (must not be negative)", config.FetchMaxBytes)
+	}
+	if config.DiffContextLines < 0 {
+		return fmt.Errorf("invalid diff_context_lines: %d (must not be negative)", config.DiffContextLines)
+	}
+	if config.RangeContextLines < 0 {
+		return fmt.Errorf("invalid range_context_lines: %d (must not be negative)", config.RangeContextLines)
+	}
+	if config.FixContextLines < 0 {
+		return fmt.Errorf("invalid fix_context_lines: %d (must not be negative)", config.FixContextLines)
+	}
+	if _, err := content.ParseFormat(config.FileFormat); err != nil {
+		return err
+	}
+	if err := content.ValidateHeuristics(config.SkipHeuristics); err != nil {
+		return fmt.Errorf("invalid skip_heuristics: %w", err)
+	}
+	if t := config.OpenAI.Temperature; t != nil && (*t < 0 || *t > 2) {
+		return fmt.Errorf("invalid openai.temperature: %g (must be between 0 and 2)", *t)
+	}
+	if config.Anthropic.MaxTokens < 0 {
+		return fmt.Errorf("invalid anthropic.max_tokens: %d (must be positive)", config.Anthropic.MaxTokens)
+	}
+	if err := validateModels(config); err != nil {
+		return err
+	}
+	if !webhook.ValidFormat(config.Webhook.Format) {
+		return fmt.Errorf("invalid webhook.format: %s (must be 'json' or 'raw')", config.Webhook.Format)
+	}
+	if config.Webhook.TimeoutMS < 0 {
+		return fmt.Errorf("invalid webhook.timeout_ms: %d (must not be negative)", config.Webhook.TimeoutMS)
+	}
+	if config.GitRecentCommits < 0 {
+		return fmt.Errorf("invalid git_recent_commits: %d (must be 0 for none or positive)", config.GitRecentCommits)
+	}
+	if config.TreeDepth < 0 {
+		return fmt.Errorf("invalid tree_depth: %d (must be 0 for none or positive)", config.TreeDepth)
+	}
+	if config.DocsTokenShare < 0 || config.DocsTokenShare > 1 {
+		return fmt.Errorf("invalid docs_token_share: %g (must be between 0 and 1)", config.DocsTokenShare)
+	}
+	if config.LogLevel != "" {
+		if _, err := logging.ParseLevel(config.LogLevel); err != nil {
+			return fmt.Errorf("invalid log_level: %w", err)
+		}
+	}
+	if config.HistoryLimit < 0 {
+		return fmt.Errorf("invalid history_limit: %d (must be 0 for unlimited or positive)", config.HistoryLimit)
+	}
+
+	// Validate recipes
+	for name, recipe := range config.Recipes {
+		if recipe.DirectoryStrategy != "" && !validStrategies[recipe.DirectoryStrategy] {
+			return fmt.Errorf("recipe.%s: invalid directory_strategy: %s (must be 'git' or 'filesystem')", name, recipe.DirectoryStrategy)
+		}
+		if recipe.Target != "" && !models.ValidTarget(recipe.Target) {
+			return fmt.Errorf("recipe.%s: invalid target: %s (must be %s)", name, recipe.Target, models.TargetUsage)
+		}
+	}
+
+	// Validate presets
+	for name, preset := range config.Presets {
+		if preset.DirectoryStrategy != "" && !validStrategies[preset.DirectoryStrategy] {
+			return fmt.Errorf("preset.%s: invalid directory_strategy: %s (must be 'git' or 'filesystem')", name, preset.DirectoryStrategy)
+		}
+		if preset.Target != "" && !models.ValidTarget(preset.Target) {
+			return fmt.Errorf("preset.%s: invalid target: %s (must be %s)", name, preset.Target, models.TargetUsage)
+		}
+	}
+
+	if err := validateTemplateSources(config.TemplateSources); err != nil {
+		return err
+	}
+	if err := validateCustomTemplates(config.CustomTemplates); err != nil {
+		return err
+	}
+
+	// Validate wasm plugins
+	for name, plugin := range config.WasmPlugins {
+		if plugin.Path == "" {
+			return fmt.Errorf("wasm_plugin.%s: path is required", name)
+		}
+		if len(plugin.Functions) == 0 {
+			return fmt.Errorf("wasm_plugin.%s: at least one function must be listed", name)
+		}
+	}
+
+	// Validate external helpers
+	for name, helper := range config.Helpers {
+		if helper.Command == "" {
+			return fmt.Errorf("helper.%s: command is required", name)
+		}
+	}
+
+	if _, err := redact.CompilePatterns(config.RedactPatterns); err != nil {
+		return err
 	}
 
 	// Validate prompts location exists or can be created
@@ -213,10 +511,11 @@ func (m *Manager) getConfigFromViper() *interfaces.Config {
 			shorthand := m.v.GetString(fmt.Sprintf("custom_template.%s.shorthand", name))
 			templateType := m.v.GetString(fmt.Sprintf("custom_template.%s.type", name))
 			description := m.v.GetString(fmt.Sprintf("custom_template.%s.description", name))
+			variable := m.v.GetString(fmt.Sprintf("custom_template.%s.variable", name))
 			
 			// If location is not set, default to prompts_location/name
 			if location == "" {
-				location = filepath.Join(m.v.GetString("prompts_location"), name)
+				location = filepath.Join(m.primaryPromptsLocation(), name)
 			}
 			
 			// If flag is not set, default to the template name
@@ -230,27 +529,177 @@ func (m *Manager) getConfigFromViper() *interfaces.Config {
 			}
 			
 			customTemplates[name] = interfaces.CustomTemplate{
-				Location:    expandPath(location),
+				Location:    m.expand(fmt.Sprintf("custom_template.%s.location", name), location),
 				Interactive: interactive,
 				Flag:        flag,
 				Shorthand:   shorthand,
 				Type:        templateType,
 				Description: description,
+				Variable:    variable,
+			}
+		}
+	}
+	
+	// Parse WebAssembly helper plugins
+	wasmPlugins := make(map[string]interfaces.WasmPlugin)
+	if m.v.IsSet("wasm_plugin") {
+		for name := range m.v.GetStringMap("wasm_plugin") {
+			pathKey := fmt.Sprintf("wasm_plugin.%s.path", name)
+			wasmPlugins[name] = interfaces.WasmPlugin{
+				Path:      m.expand(pathKey, m.v.GetString(pathKey)),
+				Functions: m.v.GetStringSlice(fmt.Sprintf("wasm_plugin.%s.functions", name)),
+				TimeoutMS: m.v.GetInt(fmt.Sprintf("wasm_plugin.%s.timeout_ms", name)),
+			}
+		}
+	}
+	
+	// Parse external-process template helpers
+	helpers := make(map[string]interfaces.HelperCommand)
+	if m.v.IsSet("helper") {
+		for name := range m.v.GetStringMap("helper") {
+			helpers[name] = interfaces.HelperCommand{
+				Command:   expandPath(m.v.GetString(fmt.Sprintf("helper.%s.command", name))),
+				Args:      m.v.GetStringSlice(fmt.Sprintf("helper.%s.args", name)),
+				TimeoutMS: m.v.GetInt(fmt.Sprintf("helper.%s.timeout_ms", name)),
+				CacheTTL:  m.v.GetInt(fmt.Sprintf("helper.%s.cache_ttl", name)),
+			}
+		}
+	}
+	
+	// Parse named recipes
+	recipes := make(map[string]interfaces.Recipe)
+	if m.v.IsSet("recipe") {
+		for name := range m.v.GetStringMap("recipe") {
+			recipes[name] = interfaces.Recipe{
+				Description:       m.v.GetString(fmt.Sprintf("recipe.%s.description", name)),
+				Prompt:            m.v.GetString(fmt.Sprintf("recipe.%s.prompt", name)),
+				Pre:               m.v.GetString(fmt.Sprintf("recipe.%s.pre", name)),
+				Post:              m.v.GetString(fmt.Sprintf("recipe.%s.post", name)),
+				Files:             m.v.GetStringSlice(fmt.Sprintf("recipe.%s.files", name)),
+				Directory:         m.v.GetBool(fmt.Sprintf("recipe.%s.directory", name)),
+				DirectoryStrategy: m.v.GetString(fmt.Sprintf("recipe.%s.directory_strategy", name)),
+				Fix:               m.v.GetBool(fmt.Sprintf("recipe.%s.fix", name)),
+				Outline:           m.v.GetBool(fmt.Sprintf("recipe.%s.outline", name)),
+				Target:            m.v.GetString(fmt.Sprintf("recipe.%s.target", name)),
+			}
+		}
+	}
+
+	// Parse named presets
+	presets := make(map[string]interfaces.Preset)
+	if m.v.IsSet("preset") {
+		for name := range m.v.GetStringMap("preset") {
+			presets[name] = interfaces.Preset{
+				Description:       m.v.GetString(fmt.Sprintf("preset.%s.description", name)),
+				Pre:               m.v.GetString(fmt.Sprintf("preset.%s.pre", name)),
+				Post:              m.v.GetString(fmt.Sprintf("preset.%s.post", name)),
+				Files:             m.v.GetStringSlice(fmt.Sprintf("preset.%s.files", name)),
+				Vars:              m.v.GetStringMapString(fmt.Sprintf("preset.%s.vars", name)),
+				Directory:         m.v.GetBool(fmt.Sprintf("preset.%s.directory", name)),
+				DirectoryStrategy: m.v.GetString(fmt.Sprintf("preset.%s.directory_strategy", name)),
+				Exclude:           m.v.GetStringSlice(fmt.Sprintf("preset.%s.exclude", name)),
+				Target:            m.v.GetString(fmt.Sprintf("preset.%s.target", name)),
 			}
 		}
 	}
+
+	openAI := interfaces.OpenAIConfig{
+		Model:     m.v.GetString("openai.model"),
+		APIKeyEnv: m.v.GetString("openai.api_key_env"),
+		BaseURL:   m.v.GetString("openai.base_url"),
+	}
+	if m.v.IsSet("openai.temperature") {
+		temperature := m.v.GetFloat64("openai.temperature")
+		openAI.Temperature = &temperature
+	}
 	
 	return &interfaces.Config{
-		PromptsLocation:      expandPath(m.v.GetString("prompts_location")),
-		LocalPromptsLocation: expandPath(m.v.GetString("local_prompts_location")),
+		ConfigVersion:        m.v.GetInt("config_version"),
+		StrictConfig:         m.v.GetBool("strict_config"),
+		PromptsLocation:      m.primaryPromptsLocation(),
+		PromptsLocations:     m.promptsLocations(),
+		LocalPromptsLocation: m.expand("local_prompts_location", m.v.GetString("local_prompts_location")),
 		Editor:               m.v.GetString("editor"),
 		DefaultPre:           m.v.GetString("default_pre"),
 		DefaultPost:          m.v.GetString("default_post"),
-		FixFile:              expandPath(m.v.GetString("fix_file")),
+		FixFile:              m.expand("fix_file", m.v.GetString("fix_file")),
+		FixIncludeFiles:      m.v.GetBool("fix_include_files"),
+		FixContextLines:      m.v.GetInt("fix_context_lines"),
 		DirectoryStrategy:    m.v.GetString("directory_strategy"),
-		Target:               m.v.GetString("target"),
+		Target:               m.expandTarget("target", m.v.GetString("target")),
+		TargetFallbacks:      m.expandTargets("target_fallbacks", m.v.GetStringSlice("target_fallbacks")),
+		EditorTarget:         m.expandTarget("editor_target", m.v.GetString("editor_target")),
+		Model:                m.v.GetString("model"),
+		Models:               m.readModels(),
 		InteractiveDefault:   m.v.GetBool("interactive_default"),
+		TUI:                  m.v.GetBool("tui"),
+		ConfirmBeforeOutput:  m.v.GetBool("confirm_before_output"),
 		CustomTemplates:      customTemplates,
+		WasmPlugins:          wasmPlugins,
+		Helpers:              helpers,
+		Recipes:              recipes,
+		Presets:              presets,
+		TemplateSources:      m.readTemplateSources(),
+		SourcesLocation:      m.expand("template_sources_location", m.v.GetString("template_sources_location")),
+		TemplateRegistry:     m.expand("template_registry", m.v.GetString("template_registry")),
+		Pipeline:             m.v.GetStringSlice("pipeline"),
+		Vars:                 m.v.GetStringMapString("vars"),
+		EmbedContent:         m.v.GetBool("embed_content"),
+		MaxTokens:            m.v.GetInt("max_tokens"),
+		BudgetStrategy:       m.v.GetString("budget_strategy"),
+		Tokenizer:            m.v.GetString("tokenizer"),
+		TokenizerFile:        m.expand("tokenizer_file", m.v.GetString("tokenizer_file")),
+		MaxFileSizeBytes:     m.v.GetInt64("max_file_size_bytes"),
+		DiffContextLines:     m.v.GetInt("diff_context_lines"),
+		RangeContextLines:    m.v.GetInt("range_context_lines"),
+		FileFormat:           m.v.GetString("file_format"),
+		IgnoreFile:           m.expand("ignore_file", m.v.GetString("ignore_file")),
+		ExcludePatterns:      m.v.GetStringSlice("exclude_patterns"),
+		SkipHeuristics:       m.v.GetStringSlice("skip_heuristics"),
+		ContentCache:         m.v.GetBool("content_cache"),
+		Redact:               m.v.GetBool("redact"),
+		RedactPatterns:       m.v.GetStringMapString("redact_patterns"),
+		OpenAI:               openAI,
+		Anthropic: interfaces.AnthropicConfig{
+			Model:     m.v.GetString("anthropic.model"),
+			APIKeyEnv: m.v.GetString("anthropic.api_key_env"),
+			BaseURL:   m.v.GetString("anthropic.base_url"),
+			MaxTokens: m.v.GetInt("anthropic.max_tokens"),
+		},
+		Ollama: interfaces.OllamaConfig{
+			Model:      m.v.GetString("ollama.model"),
+			BaseURL:    m.v.GetString("ollama.base_url"),
+			OutputFile: m.expand("ollama.output_file", m.v.GetString("ollama.output_file")),
+		},
+		Webhook: interfaces.WebhookConfig{
+			Format:    m.v.GetString("webhook.format"),
+			Headers:   m.v.GetStringMapString("webhook.headers"),
+			TimeoutMS: m.v.GetInt("webhook.timeout_ms"),
+		},
+		GitRecentCommits:     m.v.GetInt("git_recent_commits"),
+		ProjectIndirectDeps:  m.v.GetBool("project_indirect_dependencies"),
+		TreeDepth:            m.v.GetInt("tree_depth"),
+		IncludeDocs:          m.v.GetBool("include_docs"),
+		DocsTokenShare:       m.v.GetFloat64("docs_token_share"),
+		LogLevel:             m.v.GetString("log_level"),
+		LogFile:              m.expand("log_file", m.v.GetString("log_file")),
+		Quiet:                m.v.GetBool("quiet"),
+		HistoryEnabled:       m.v.GetBool("history_enabled"),
+		HistoryLocation:      m.expand("history_location", m.v.GetString("history_location")),
+		HistoryLimit:         m.v.GetInt("history_limit"),
+		StatsEnabled:         m.v.GetBool("stats_enabled"),
+		StatsLocation:        m.expand("stats_location", m.v.GetString("stats_location")),
+		PluginsLocation:      m.expand("plugins_location", m.v.GetString("plugins_location")),
+		DisabledPlugins:      m.v.GetStringSlice("disabled_plugins"),
+		PluginTimeoutMS:      m.v.GetInt("plugin_timeout_ms"),
+		TemplateStrict:       m.v.GetBool("template_strict"),
+		TemplateSeparator:    m.v.GetString("template_separator"),
+		AllowExec:            m.v.GetBool("allow_exec"),
+		ExecTimeoutMS:        m.v.GetInt("exec_timeout_ms"),
+		ExecMaxBytes:         m.v.GetInt("exec_max_bytes"),
+		FetchAllow:           m.v.GetStringSlice("fetch_allow"),
+		FetchTimeoutMS:       m.v.GetInt("fetch_timeout_ms"),
+		FetchMaxBytes:        m.v.GetInt("fetch_max_bytes"),
 	}
 }
 
@@ -260,7 +709,9 @@ func (m *Manager) MergeConfig(other *interfaces.Config) {
 		return
 	}
 
-	if other.PromptsLocation != "" {
+	if len(other.PromptsLocations) > 1 {
+		m.v.Set("prompts_location", other.PromptsLocations)
+	} else if other.PromptsLocation != "" {
 		m.v.Set("prompts_location", other.PromptsLocation)
 	}
 	if other.LocalPromptsLocation != "" {
@@ -290,8 +741,48 @@ func (m *Manager) MergeConfig(other *interfaces.Config) {
 	m.v.Set("interactive_default", other.InteractiveDefault)
 }
 
-// expandPath expands ~ to user home directory
+// promptsLocations reads prompts_location, either one directory or a list of them
+// searched in order, with each entry expanded
+func (m *Manager) promptsLocations() []string {
+	var locations []string
+	switch value := m.v.Get("prompts_location").(type) {
+	case []interface{}:
+		for _, entry := range value {
+			if location, ok := entry.(string); ok && location != "" {
+				locations = append(locations, m.expand("prompts_location", location))
+			}
+		}
+	case []string:
+		for _, location := range value {
+			if location != "" {
+				locations = append(locations, m.expand("prompts_location", location))
+			}
+		}
+	default:
+		if location := m.v.GetString("prompts_location"); location != "" {
+			locations = append(locations, m.expand("prompts_location", location))
+		}
+	}
+	return locations
+}
+
+// primaryPromptsLocation returns the first prompts_location entry, where new
+// templates are written
+func (m *Manager) primaryPromptsLocation() string {
+	if locations := m.promptsLocations(); len(locations) > 0 {
+		return locations[0]
+	}
+	return ""
+}
+
+// expandPath expands ${NAME} environment variables and ~ to user home directory
 func expandPath(path string) string {
+	expanded, _ := expandValue(path, nil)
+	return expandHome(expanded)
+}
+
+// expandHome expands a leading ~ to user home directory
+func expandHome(path string) string {
 	if !strings.HasPrefix(path, "~/") {
 		return path
 	}
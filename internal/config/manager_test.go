@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"prompter-cli/internal/interfaces"
@@ -73,6 +74,26 @@ target = "stdout"
 
 }
 
+func TestManager_Load_PromptsLocationList(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	settings := "prompts_location = [\"/personal/prompts\", \"~/team/prompts\"]\n"
+	if err := os.WriteFile(configPath, []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager()
+	config, err := manager.Load(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	home, _ := os.UserHomeDir()
+	want := []string{"/personal/prompts", filepath.Join(home, "team/prompts")}
+	if config.PromptsLocation != want[0] || !reflect.DeepEqual(config.PromptsLocations, want) {
+		t.Errorf("PromptsLocation, PromptsLocations = %s, %v; want %s, %v", config.PromptsLocation, config.PromptsLocations, want[0], want)
+	}
+}
+
 func TestManager_Validate(t *testing.T) {
 	manager := NewManager()
 	
@@ -119,6 +140,58 @@ func TestManager_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "invalid log level",
+			config: &interfaces.Config{
+				DirectoryStrategy: "git",
+				Target:            "clipboard",
+				LogLevel:          "verbose",
+			},
+			wantErr: true,
+		},
+		{
+			name: "custom templates sharing a shorthand",
+			config: &interfaces.Config{
+				DirectoryStrategy: "git",
+				Target:            "clipboard",
+				CustomTemplates: map[string]interfaces.CustomTemplate{
+					"jira":   {Flag: "jira", Shorthand: "j", Type: "pre"},
+					"jquery": {Flag: "jquery", Shorthand: "j", Type: "post"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "custom template with a value",
+			config: &interfaces.Config{
+				DirectoryStrategy: "git",
+				Target:            "clipboard",
+				CustomTemplates: map[string]interfaces.CustomTemplate{
+					"jira": {Flag: "jira", Shorthand: "j", Type: "pre", Variable: "ticket"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid custom template flag",
+			config: &interfaces.Config{
+				DirectoryStrategy: "git",
+				Target:            "clipboard",
+				CustomTemplates: map[string]interfaces.CustomTemplate{
+					"jira": {Flag: "--jira", Type: "pre"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "editor as a target fallback",
+			config: &interfaces.Config{
+				DirectoryStrategy: "git",
+				Target:            "clipboard",
+				TargetFallbacks:   []string{"stdout", "editor"},
+			},
+			wantErr: true,
+		},
 	}
 	
 	for _, tt := range tests {
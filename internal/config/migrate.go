@@ -0,0 +1,237 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// CurrentConfigVersion is the config_version written by this release. Config files
+// without a config_version are treated as version 1.
+const CurrentConfigVersion = 2
+
+// Migration upgrades raw config settings from one version to the next
+type Migration struct {
+	From        int
+	Description string
+	// Apply rewrites settings in place and returns a line for every change made
+	Apply func(settings map[string]interface{}) []string
+}
+
+// migrations are applied in order to bring a config file up to CurrentConfigVersion
+var migrations = []Migration{
+	{
+		From:        1,
+		Description: "normalize directory strategy names and move legacy sections",
+		Apply:       migrateV1ToV2,
+	},
+}
+
+// MigrationResult describes the upgrade of a config file
+type MigrationResult struct {
+	Path        string
+	FromVersion int
+	ToVersion   int
+	Changes     []string               // Settings that were renamed or moved, empty when only the version changes
+	Settings    map[string]interface{} // Migrated settings, ready to be written
+}
+
+// PlanMigration reads a config file and returns the changes needed to bring it up to
+// CurrentConfigVersion. It returns nil when the file is already current.
+func PlanMigration(path string) (*MigrationResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	settings := make(map[string]interface{})
+	if err := toml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	version, err := settingsVersion(settings)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if version > CurrentConfigVersion {
+		return nil, fmt.Errorf("%s: config_version %d is newer than this prompter supports (%d); please upgrade prompter", path, version, CurrentConfigVersion)
+	}
+	if version == CurrentConfigVersion {
+		return nil, nil
+	}
+
+	result := &MigrationResult{
+		Path:        path,
+		FromVersion: version,
+		ToVersion:   CurrentConfigVersion,
+		Settings:    settings,
+	}
+	for _, migration := range migrations {
+		if migration.From < version {
+			continue
+		}
+		result.Changes = append(result.Changes, migration.Apply(settings)...)
+	}
+	settings["config_version"] = int64(CurrentConfigVersion)
+
+	return result, nil
+}
+
+// Encode renders the migrated settings as TOML
+func (r *MigrationResult) Encode() ([]byte, error) {
+	data, err := toml.Marshal(r.Settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+	return data, nil
+}
+
+// Write saves the migrated settings over the original file, keeping a copy of the
+// original next to it with a .bak suffix, timestamped when an earlier backup has that
+// name. Comments in the original are not preserved.
+func (r *MigrationResult) Write() (string, error) {
+	data, err := r.Encode()
+	if err != nil {
+		return "", err
+	}
+
+	original, err := os.ReadFile(r.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read config file %s: %w", r.Path, err)
+	}
+
+	backupPath, err := writeBackup(r.Path, original)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(r.Path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write migrated config %s: %w", r.Path, err)
+	}
+
+	return backupPath, nil
+}
+
+// writeBackup copies data to path with a .bak suffix, or to a timestamped .bak when
+// that already exists, so an earlier backup is never overwritten
+func writeBackup(path string, data []byte) (string, error) {
+	candidates := []string{
+		path + ".bak",
+		strings.TrimSuffix(path, ".toml") + "." + time.Now().Format("20060102-150405") + ".bak",
+	}
+	for _, backupPath := range candidates {
+		file, err := os.OpenFile(backupPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to write backup %s: %w", backupPath, err)
+		}
+		_, err = file.Write(data)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to write backup %s: %w", backupPath, err)
+		}
+		return backupPath, nil
+	}
+	return "", fmt.Errorf("failed to write backup: %s already exists", candidates[len(candidates)-1])
+}
+
+// settingsVersion reads config_version from raw settings, defaulting to 1
+func settingsVersion(settings map[string]interface{}) (int, error) {
+	raw, ok := settings["config_version"]
+	if !ok {
+		return 1, nil
+	}
+	version, ok := raw.(int64)
+	if !ok || version < 1 {
+		return 0, fmt.Errorf("invalid config_version %v (must be a positive integer)", raw)
+	}
+	return int(version), nil
+}
+
+// legacyStrategies maps directory strategy names accepted by older releases to current ones
+var legacyStrategies = map[string]string{
+	"fs":           "filesystem",
+	"find":         "filesystem",
+	"walk":         "filesystem",
+	"git-ls-files": "git",
+	"gitignore":    "git",
+}
+
+// legacyDefaults maps keys of the old [defaults] section to their top-level names
+var legacyDefaults = map[string]string{
+	"pre":    "default_pre",
+	"post":   "default_post",
+	"target": "target",
+	"editor": "editor",
+}
+
+// migrateV1ToV2 renames legacy strategy values and moves the [defaults] and
+// [custom_templates] sections to their current locations
+func migrateV1ToV2(settings map[string]interface{}) []string {
+	var changes []string
+
+	if strategy, ok := settings["directory_strategy"].(string); ok {
+		if renamed, legacy := legacyStrategies[strategy]; legacy {
+			settings["directory_strategy"] = renamed
+			changes = append(changes, fmt.Sprintf("directory_strategy %q renamed to %q", strategy, renamed))
+		}
+	}
+
+	if defaults, ok := settings["defaults"].(map[string]interface{}); ok {
+		keys := make([]string, 0, len(defaults))
+		for key := range defaults {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			target, known := legacyDefaults[key]
+			if !known {
+				changes = append(changes, fmt.Sprintf("dropped unknown setting defaults.%s", key))
+				continue
+			}
+			if _, exists := settings[target]; exists {
+				changes = append(changes, fmt.Sprintf("dropped defaults.%s (%s is already set)", key, target))
+				continue
+			}
+			settings[target] = defaults[key]
+			changes = append(changes, fmt.Sprintf("moved defaults.%s to %s", key, target))
+		}
+		delete(settings, "defaults")
+	}
+
+	if legacy, ok := settings["custom_templates"].(map[string]interface{}); ok {
+		current, _ := settings["custom_template"].(map[string]interface{})
+		if current == nil {
+			current = make(map[string]interface{})
+		}
+
+		names := make([]string, 0, len(legacy))
+		for name := range legacy {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if _, exists := current[name]; exists {
+				changes = append(changes, fmt.Sprintf("dropped custom_templates.%s (custom_template.%s is already set)", name, name))
+				continue
+			}
+			current[name] = legacy[name]
+			changes = append(changes, fmt.Sprintf("moved custom_templates.%s to custom_template.%s", name, name))
+		}
+		settings["custom_template"] = current
+		delete(settings, "custom_templates")
+	}
+
+	return changes
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const legacyConfig = `directory_strategy = "fs"
+editor = "vim"
+
+[defaults]
+pre = "review"
+editor = "nano"
+
+[custom_templates.docs]
+location = "/tmp/docs"
+`
+
+func TestPlanMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(legacyConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	migration, err := PlanMigration(path)
+	if err != nil {
+		t.Fatalf("PlanMigration failed: %v", err)
+	}
+	if migration == nil {
+		t.Fatal("expected a migration for a config without config_version")
+	}
+	if migration.FromVersion != 1 || migration.ToVersion != CurrentConfigVersion {
+		t.Errorf("versions = %d -> %d", migration.FromVersion, migration.ToVersion)
+	}
+
+	wantChanges := []string{
+		`directory_strategy "fs" renamed to "filesystem"`,
+		"dropped defaults.editor (editor is already set)",
+		"moved defaults.pre to default_pre",
+		"moved custom_templates.docs to custom_template.docs",
+	}
+	if strings.Join(migration.Changes, "\n") != strings.Join(wantChanges, "\n") {
+		t.Errorf("Changes = %q, want %q", migration.Changes, wantChanges)
+	}
+
+	// The file is untouched until Write is called
+	if data, _ := os.ReadFile(path); string(data) != legacyConfig {
+		t.Error("PlanMigration modified the config file")
+	}
+
+	backupPath, err := migration.Write()
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if data, _ := os.ReadFile(backupPath); string(data) != legacyConfig {
+		t.Error("backup does not contain the original config")
+	}
+
+	// A migrated file needs no further migration
+	again, err := PlanMigration(path)
+	if err != nil {
+		t.Fatalf("PlanMigration after Write failed: %v", err)
+	}
+	if again != nil {
+		t.Errorf("expected no migration after Write, got %+v", again)
+	}
+}
+
+func TestPlanMigration_NewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("config_version = 99\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := PlanMigration(path); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("expected newer-version error, got %v", err)
+	}
+}
+
+func TestManager_Load_MigratesInMemory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(legacyConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager()
+	config, err := manager.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if config.ConfigVersion != CurrentConfigVersion {
+		t.Errorf("ConfigVersion = %d, want %d", config.ConfigVersion, CurrentConfigVersion)
+	}
+	if config.DirectoryStrategy != "filesystem" {
+		t.Errorf("DirectoryStrategy = %q, want filesystem", config.DirectoryStrategy)
+	}
+	if config.DefaultPre != "review" {
+		t.Errorf("DefaultPre = %q, want review", config.DefaultPre)
+	}
+	if config.Editor != "vim" {
+		t.Errorf("Editor = %q, want vim", config.Editor)
+	}
+	if _, ok := config.CustomTemplates["docs"]; !ok {
+		t.Error("expected custom template docs to be migrated")
+	}
+	if manager.PendingMigration() == nil {
+		t.Error("expected a pending migration")
+	}
+	if err := manager.Validate(config); err != nil {
+		t.Errorf("migrated config failed validation: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"prompter-cli/internal/content"
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/tokenizer"
+)
+
+// BuiltinModels are the model presets available without configuration. A
+// [models.<name>] table with the same name replaces the fields it sets.
+var BuiltinModels = map[string]interfaces.ModelPreset{
+	"claude-sonnet": {ContextWindow: 200000, ResponseTokens: 16000, Tokenizer: tokenizer.Claude, FileFormat: "xml"},
+	"claude-opus":   {ContextWindow: 200000, ResponseTokens: 16000, Tokenizer: tokenizer.Claude, FileFormat: "xml"},
+	"gpt-4o":        {ContextWindow: 128000, ResponseTokens: 16000, FileFormat: "markdown"},
+	"local":         {ContextWindow: 8192, ResponseTokens: 1024, Tokenizer: tokenizer.Bytes, FileFormat: "markdown"},
+}
+
+// readModels returns the built-in presets merged with the [models.<name>] tables
+func (m *Manager) readModels() map[string]interfaces.ModelPreset {
+	presets := make(map[string]interfaces.ModelPreset, len(BuiltinModels))
+	for name, preset := range BuiltinModels {
+		presets[name] = preset
+	}
+
+	for name := range m.v.GetStringMap("models") {
+		preset := presets[name]
+		key := func(field string) string { return fmt.Sprintf("models.%s.%s", name, field) }
+		if m.v.IsSet(key("context_window")) {
+			preset.ContextWindow = m.v.GetInt(key("context_window"))
+		}
+		if m.v.IsSet(key("response_tokens")) {
+			preset.ResponseTokens = m.v.GetInt(key("response_tokens"))
+		}
+		if m.v.IsSet(key("tokenizer")) {
+			preset.Tokenizer = m.v.GetString(key("tokenizer"))
+		}
+		if m.v.IsSet(key("file_format")) {
+			preset.FileFormat = m.v.GetString(key("file_format"))
+		}
+		presets[name] = preset
+	}
+	return presets
+}
+
+// applyModel applies the preset named by the model setting: its tokenizer and file
+// format replace the configured ones, and its context window less the tokens kept
+// for the reply becomes max_tokens. Unknown models are left to Validate.
+func applyModel(config *interfaces.Config) {
+	preset, ok := config.Models[config.Model]
+	if config.Model == "" || !ok {
+		return
+	}
+
+	if preset.Tokenizer != "" {
+		config.Tokenizer = preset.Tokenizer
+	}
+	if preset.FileFormat != "" {
+		config.FileFormat = preset.FileFormat
+	}
+	if preset.ContextWindow > 0 {
+		config.MaxTokens = preset.ContextWindow - preset.ResponseTokens
+	}
+}
+
+// validateModels checks the model presets and that the model setting names one
+func validateModels(config *interfaces.Config) error {
+	for name, preset := range config.Models {
+		if preset.ContextWindow < 0 {
+			return fmt.Errorf("models.%s: invalid context_window: %d (must not be negative)", name, preset.ContextWindow)
+		}
+		if preset.ResponseTokens < 0 {
+			return fmt.Errorf("models.%s: invalid response_tokens: %d (must not be negative)", name, preset.ResponseTokens)
+		}
+		if preset.ContextWindow > 0 && preset.ResponseTokens >= preset.ContextWindow {
+			return fmt.Errorf("models.%s: invalid response_tokens: %d (must be less than context_window)", name, preset.ResponseTokens)
+		}
+		if preset.Tokenizer != "" {
+			if _, ok := tokenizer.EncodingForModel(preset.Tokenizer); !ok {
+				return fmt.Errorf("models.%s: invalid tokenizer: %s (must be a model name or one of %s)", name, preset.Tokenizer, strings.Join(tokenizer.Names(), ", "))
+			}
+		}
+		if _, err := content.ParseFormat(preset.FileFormat); err != nil {
+			return fmt.Errorf("models.%s: %w", name, err)
+		}
+	}
+
+	if config.Model != "" {
+		if _, ok := config.Models[config.Model]; !ok {
+			return fmt.Errorf("invalid model: %s (must be one of %s)", config.Model, strings.Join(ModelNames(config), ", "))
+		}
+	}
+	return nil
+}
+
+// ModelNames returns the names of the model presets in config, sorted
+func ModelNames(config *interfaces.Config) []string {
+	names := make([]string, 0, len(config.Models))
+	for name := range config.Models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManager_ModelPresets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	settings := `max_tokens = 5000
+file_format = "plain"
+
+[models.gpt-4o]
+response_tokens = 28000
+
+[models.tiny]
+context_window = 4096
+response_tokens = 96
+tokenizer = "bytes"
+`
+	if err := os.WriteFile(path, []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		model     string
+		maxTokens int
+		tokenizer string
+		format    string
+	}{
+		{model: "", maxTokens: 5000, tokenizer: "claude", format: "plain"},
+		{model: "claude-sonnet", maxTokens: 184000, tokenizer: "claude", format: "xml"},
+		{model: "gpt-4o", maxTokens: 100000, tokenizer: "claude", format: "markdown"},
+		{model: "tiny", maxTokens: 4000, tokenizer: "bytes", format: "plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			manager := NewManager()
+			if _, err := manager.Load(path); err != nil {
+				t.Fatal(err)
+			}
+			manager.SetFlag("model", tt.model)
+
+			cfg, err := manager.Resolve()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := manager.Validate(cfg); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if cfg.MaxTokens != tt.maxTokens || cfg.Tokenizer != tt.tokenizer || cfg.FileFormat != tt.format {
+				t.Errorf("max_tokens, tokenizer, file_format = %d, %s, %s; want %d, %s, %s",
+					cfg.MaxTokens, cfg.Tokenizer, cfg.FileFormat, tt.maxTokens, tt.tokenizer, tt.format)
+			}
+		})
+	}
+}
+
+func TestManager_ValidateModels(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     string
+	}{
+		{name: "unknown model", settings: "model = \"gpt-9\"\n", want: "invalid model: gpt-9"},
+		{name: "response exceeds window", settings: "[models.small]\ncontext_window = 100\nresponse_tokens = 100\n", want: "models.small: invalid response_tokens"},
+		{name: "unknown tokenizer", settings: "[models.small]\ntokenizer = \"words\"\n", want: "models.small: invalid tokenizer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.settings), 0644); err != nil {
+				t.Fatal(err)
+			}
+			manager := NewManager()
+			if _, err := manager.Load(path); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := manager.Resolve()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = manager.Validate(cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManager_Load_Presets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := `config_version = 2
+
+[preset.review]
+description = "Review the current directory"
+pre = "persona,review"
+directory = true
+exclude = ["vendor/"]
+target = "stdout"
+
+[preset.review.vars]
+lang = "go"
+
+[preset.broken]
+directory_strategy = "svn"
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager()
+	config, err := manager.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	review, ok := config.Presets["review"]
+	if !ok {
+		t.Fatal("expected preset review to be parsed")
+	}
+	if review.Pre != "persona,review" || !review.Directory || review.Target != "stdout" || review.Description == "" {
+		t.Errorf("unexpected preset: %+v", review)
+	}
+	if len(review.Exclude) != 1 || review.Exclude[0] != "vendor/" || review.Vars["lang"] != "go" {
+		t.Errorf("Exclude, Vars = %v, %v", review.Exclude, review.Vars)
+	}
+	for _, key := range []string{"preset.review.vars.lang", "preset.review.exclude"} {
+		if !KnownKey(key) {
+			t.Errorf("KnownKey(%q) = false", key)
+		}
+	}
+
+	config.PromptsLocation = ""
+	if err := manager.Validate(config); err == nil || !strings.Contains(err.Error(), "preset.broken") {
+		t.Errorf("expected preset.broken validation error, got %v", err)
+	}
+}
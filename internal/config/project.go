@@ -0,0 +1,301 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+	"prompter-cli/pkg/models"
+)
+
+// ProjectDir is the per-project directory created by 'prompter init --project',
+// holding a config file and a prompts directory
+const ProjectDir = ".prmpt"
+
+// ProjectConfigNames are the project config files looked for in each directory, in
+// order of preference; only the first one found in a directory is used
+var ProjectConfigNames = []string{".prmpt.toml", ".prompter.toml", filepath.Join(ProjectDir, "config.toml")}
+
+// projectPathKeys are the settings holding paths, which are resolved against the
+// project config's directory when relative so they work from any subdirectory
+var projectPathKeys = []string{
+	"prompts_location",
+	"local_prompts_location",
+	"fix_file",
+	"ignore_file",
+	"tokenizer_file",
+	"history_location",
+	"stats_location",
+	"template_sources_location",
+	"ollama.output_file",
+}
+
+// globalOnlyKeys are settings a project config can't set, since they would let a
+// checked-out repository run commands as soon as prompter runs in it or send
+// prompts and API keys somewhere the user didn't choose
+var globalOnlyKeys = []string{
+	"expand_commands",
+	"pipeline", // exec: stages run shell commands
+	"allow_exec",
+	"plugins_location",
+	"wasm_plugin",
+	"helper",
+	"editor",
+	"openai.base_url",
+	"anthropic.base_url",
+	"ollama.base_url",
+	"fetch_allow",
+	"template_sources", // cloned and fetched with git as soon as templates are synced
+	"redact",           // turning it off would send the project's secrets along with its files
+}
+
+// projectTargetKeys are the settings naming output targets, which a project config
+// can set to anything but an http: webhook or a file outside the project; recipes and
+// presets have a target too
+var projectTargetKeys = []string{"target", "editor_target", "target_fallbacks"}
+
+// warnedProjectSettings holds the path and key of every project setting already
+// reported as ignored, since the config is loaded more than once per process
+var warnedProjectSettings sync.Map
+
+// warnIgnored reports a project setting that was dropped, once per process
+func warnIgnored(message, key, path string) {
+	if _, warned := warnedProjectSettings.LoadOrStore(path+"\x00"+key, true); !warned {
+		slog.Warn(message, "key", key, "path", path)
+	}
+}
+
+// FindProjectConfigs returns the project config files that apply in dir: one per
+// directory from the repository root down to dir, outermost first, so closer files
+// override farther ones. Outside a git repository only dir itself is checked.
+func FindProjectConfigs(dir string) []string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+
+	// Walk up to the repository root, falling back to dir alone
+	dirs := []string{dir}
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			dirs = dirs[:1]
+			break
+		}
+		current = parent
+		dirs = append(dirs, current)
+	}
+
+	var files []string
+	for i := len(dirs) - 1; i >= 0; i-- {
+		for _, name := range ProjectConfigNames {
+			path := filepath.Join(dirs[i], name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				files = append(files, path)
+				break
+			}
+		}
+	}
+	return files
+}
+
+// ProjectConfigs returns the project config files merged over the global config by
+// the last Load, outermost first
+func (m *Manager) ProjectConfigs() []string {
+	return m.projects
+}
+
+// mergeProjectConfigs merges the project config files for the working directory over
+// the loaded global config
+func (m *Manager) mergeProjectConfigs() error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+
+	for _, path := range FindProjectConfigs(cwd) {
+		settings, err := readProjectConfig(path)
+		if err != nil {
+			return err
+		}
+		if err := m.v.MergeConfigMap(settings); err != nil {
+			return fmt.Errorf("failed to merge project config %s: %w", path, err)
+		}
+		m.projects = append(m.projects, path)
+		keys := settingKeys("", settings)
+		for _, key := range keys {
+			m.origins[key] = path
+		}
+		slog.Debug("merged project config", "path", path, "keys", strings.Join(keys, ","))
+	}
+	return nil
+}
+
+// fromProject reports whether the value of key was read from a project config rather
+// than the global config or a PROMPTER_ environment variable
+func (m *Manager) fromProject(key string) bool {
+	return m.origins[key] != "" && os.Getenv(envName(key)) == ""
+}
+
+// settingKeys returns the dotted keys of every value in settings
+func settingKeys(prefix string, settings map[string]interface{}) []string {
+	var keys []string
+	for name, value := range settings {
+		key := prefix + name
+		if table, ok := value.(map[string]interface{}); ok {
+			keys = append(keys, settingKeys(key+".", table)...)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// resolveProjectPath resolves a relative path from a project config against baseDir
+func resolveProjectPath(baseDir, value string) string {
+	if value == "" || filepath.IsAbs(value) || strings.HasPrefix(value, "~/") || strings.HasPrefix(value, "$") {
+		return value
+	}
+	return filepath.Join(baseDir, value)
+}
+
+// readProjectConfig reads a project config file, resolving relative paths against
+// its directory. Its config_version is ignored since only the global file is migrated,
+// and the global-only settings since commands only run when the user configured them.
+func readProjectConfig(path string) (map[string]interface{}, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read project config %s: %w", path, err)
+	}
+
+	baseDir := filepath.Dir(path)
+	if filepath.Base(baseDir) == ProjectDir {
+		baseDir = filepath.Dir(baseDir) // .prmpt/config.toml is relative to the project
+	}
+	for _, key := range projectPathKeys {
+		// prompts_location may also be a list of directories
+		if list, ok := v.Get(key).([]interface{}); ok {
+			resolved := make([]interface{}, len(list))
+			for i, entry := range list {
+				resolved[i] = entry
+				if value, ok := entry.(string); ok {
+					resolved[i] = resolveProjectPath(baseDir, value)
+				}
+			}
+			v.Set(key, resolved)
+			continue
+		}
+		if value := v.GetString(key); value != "" {
+			v.Set(key, resolveProjectPath(baseDir, value))
+		}
+	}
+
+	settings := v.AllSettings()
+	delete(settings, "config_version")
+	for _, key := range globalOnlyKeys {
+		if dropSetting(settings, key) {
+			warnIgnored("ignoring a setting only read from the global config", key, path)
+		}
+	}
+	checkProjectTargets(settings, "", path, baseDir)
+	for _, table := range []string{"recipe", "preset"} {
+		entries, _ := settings[table].(map[string]interface{})
+		for name, entry := range entries {
+			if fields, ok := entry.(map[string]interface{}); ok {
+				checkProjectTargets(fields, table+"."+name+".", path, baseDir)
+			}
+		}
+	}
+	return settings, nil
+}
+
+// checkProjectTargets removes the target settings in settings naming an http: target
+// or a file outside root, whose keys are reported with prefix, and resolves the paths
+// of the remaining file targets against root
+func checkProjectTargets(settings map[string]interface{}, prefix, path, root string) {
+	for _, key := range projectTargetKeys {
+		switch value := settings[key].(type) {
+		case string:
+			target, reason := projectTarget(value, root)
+			if reason != "" {
+				delete(settings, key)
+				warnIgnored("ignoring "+reason, prefix+key, path)
+				continue
+			}
+			settings[key] = target
+		case []interface{}:
+			resolved := make([]interface{}, len(value))
+			for i, entry := range value {
+				resolved[i] = entry
+				target, ok := entry.(string)
+				if !ok {
+					continue
+				}
+				var reason string
+				if resolved[i], reason = projectTarget(target, root); reason != "" {
+					resolved = nil
+					delete(settings, key)
+					warnIgnored("ignoring "+reason, prefix+key, path)
+					break
+				}
+			}
+			if resolved != nil {
+				settings[key] = resolved
+			}
+		}
+	}
+}
+
+// projectTarget checks a target from a project config, returning it with a file
+// path resolved against root, or why it isn't allowed: http: targets post the prompt
+// elsewhere, and file targets must stay within the project
+func projectTarget(target, root string) (string, string) {
+	if strings.HasPrefix(target, models.TargetHTTPPrefix) {
+		return "", "an http: target only read from the global config"
+	}
+	for _, prefix := range []string{models.TargetAppendPrefix, models.TargetFilePrefix} {
+		if !strings.HasPrefix(target, prefix) {
+			continue
+		}
+		file := strings.TrimPrefix(target, prefix)
+		if file == "" {
+			return target, ""
+		}
+		if filepath.IsAbs(file) || strings.HasPrefix(file, "~") || strings.Contains(file, "$") {
+			return "", "a file target outside the project"
+		}
+		resolved := filepath.Join(root, file)
+		if rel, err := filepath.Rel(root, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", "a file target outside the project"
+		}
+		return prefix + resolved, ""
+	}
+	return target, ""
+}
+
+// dropSetting removes the dotted key from settings, reporting whether it was set
+func dropSetting(settings map[string]interface{}, key string) bool {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		table, ok := settings[part].(map[string]interface{})
+		if !ok {
+			return false
+		}
+		settings = table
+	}
+	last := parts[len(parts)-1]
+	if _, ok := settings[last]; !ok {
+		return false
+	}
+	delete(settings, last)
+	return true
+}
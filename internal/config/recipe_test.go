@@ -0,0 +1,50 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManager_Load_Recipes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := `config_version = 2
+
+[recipe.review]
+description = "Review the current directory"
+pre = "review"
+directory = true
+directory_strategy = "filesystem"
+files = ["go.mod"]
+target = "stdout"
+
+[recipe.broken]
+target = "printer"
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager()
+	config, err := manager.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	review, ok := config.Recipes["review"]
+	if !ok {
+		t.Fatal("expected recipe review to be parsed")
+	}
+	if review.Pre != "review" || !review.Directory || review.DirectoryStrategy != "filesystem" || review.Target != "stdout" {
+		t.Errorf("unexpected recipe: %+v", review)
+	}
+	if len(review.Files) != 1 || review.Files[0] != "go.mod" {
+		t.Errorf("Files = %v, want [go.mod]", review.Files)
+	}
+
+	config.PromptsLocation = ""
+	if err := manager.Validate(config); err == nil || !strings.Contains(err.Error(), "recipe.broken") {
+		t.Errorf("expected recipe.broken validation error, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Sources of a resolved setting, from highest to lowest precedence
+const (
+	SourceFlag    = "flag"
+	SourceEnv     = "env"
+	SourceProject = "project"
+	SourceFile    = "file"
+	SourceDefault = "default"
+)
+
+// Setting is a resolved setting and where its value came from
+type Setting struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+	Origin string      `json:"origin,omitempty"` // Environment variable or config file behind the value
+}
+
+// Settings returns every setting that has a value after flags > env > project configs >
+// config file > defaults precedence, sorted by key, with the source of each value.
+// Flags are those given with SetFlag.
+func (m *Manager) Settings() []Setting {
+	defaults := viper.New()
+	setDefaults(defaults)
+
+	seen := make(map[string]bool)
+	var keys []string
+	add := func(key string) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range defaults.AllKeys() {
+		add(key)
+	}
+	for _, key := range m.v.AllKeys() {
+		add(key)
+	}
+	for key := range optionalKeys {
+		add(key)
+	}
+	for key := range m.flags {
+		add(key)
+	}
+	sort.Strings(keys)
+
+	settings := make([]Setting, 0, len(keys))
+	for _, key := range keys {
+		if value, ok := m.flags[key]; ok && !isZeroFlag(value) {
+			settings = append(settings, Setting{Key: key, Value: value, Source: SourceFlag})
+			continue
+		}
+
+		setting := Setting{Key: key, Value: m.v.Get(key)}
+		env := envName(key)
+		switch {
+		case os.Getenv(env) != "":
+			setting.Source, setting.Origin = SourceEnv, env
+		case m.origins[key] != "":
+			setting.Source, setting.Origin = SourceProject, m.origins[key]
+		case m.v.InConfig(key):
+			setting.Source, setting.Origin = SourceFile, m.path
+		case setting.Value != nil:
+			setting.Source = SourceDefault
+		default:
+			continue // An optional setting left unset
+		}
+		settings = append(settings, setting)
+	}
+	return settings
+}
+
+// envName returns the environment variable that overrides key
+func envName(key string) string {
+	return "PROMPTER_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// isZeroFlag reports whether a flag value is empty, which leaves the setting to the
+// lower precedence sources
+func isZeroFlag(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case int:
+		return v == 0
+	case []string:
+		return len(v) == 0
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManager_Settings(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(repo, ".prmpt.toml")
+	if err := os.WriteFile(project, []byte("default_pre = \"review\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	global := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(global, []byte("editor = \"vim\"\ntarget = \"stdout\"\ndefault_pre = \"question\"\n\n[openai]\nmodel = \"gpt-4.1\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(repo)
+	t.Setenv("PROMPTER_EDITOR", "nano")
+	manager := NewManager()
+	if _, err := manager.Load(global); err != nil {
+		t.Fatal(err)
+	}
+	manager.SetFlag("target", "clipboard")
+	manager.SetFlag("max_tokens", 0) // Unset flags leave the setting alone
+
+	settings := make(map[string]Setting)
+	for _, setting := range manager.Settings() {
+		settings[setting.Key] = setting
+	}
+
+	tests := []struct {
+		key    string
+		value  interface{}
+		source string
+		origin string
+	}{
+		{key: "target", value: "clipboard", source: SourceFlag},
+		{key: "editor", value: "nano", source: SourceEnv, origin: "PROMPTER_EDITOR"},
+		{key: "default_pre", value: "review", source: SourceProject, origin: project},
+		{key: "openai.model", value: "gpt-4.1", source: SourceFile, origin: global},
+		{key: "max_tokens", value: 0, source: SourceDefault},
+	}
+	for _, tt := range tests {
+		got, ok := settings[tt.key]
+		if !ok {
+			t.Errorf("%s missing from Settings", tt.key)
+			continue
+		}
+		if got.Value != tt.value || got.Source != tt.source || got.Origin != tt.origin {
+			t.Errorf("%s = %+v, want value %v from %s %s", tt.key, got, tt.value, tt.source, tt.origin)
+		}
+	}
+
+	if _, ok := settings["tokenizer_file"]; ok {
+		t.Error("expected unset optional settings to be left out")
+	}
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"prompter-cli/internal/interfaces"
+)
+
+// DefaultTemplateSourcesLocation is where template sources are cloned by default
+const DefaultTemplateSourcesLocation = "~/.cache/prompter/template-sources"
+
+// sourceName matches the names template sources are cloned under
+var sourceName = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// readTemplateSources returns the [[template_sources]] entries in the order they're
+// configured. A source without a name is named after the last element of its URL.
+func (m *Manager) readTemplateSources() []interfaces.TemplateSource {
+	var entries []map[string]interface{}
+	switch value := m.v.Get("template_sources").(type) {
+	case []map[string]interface{}:
+		entries = value
+	case []interface{}:
+		for _, entry := range value {
+			if table, ok := entry.(map[string]interface{}); ok {
+				entries = append(entries, table)
+			}
+		}
+	}
+
+	var sources []interfaces.TemplateSource
+	for _, entry := range entries {
+		field := func(name string) string {
+			if value, ok := entry[name]; ok && value != nil {
+				return fmt.Sprint(value)
+			}
+			return ""
+		}
+		source := interfaces.TemplateSource{
+			Name: field("name"),
+			URL:  field("url"),
+			Ref:  field("ref"),
+			Path: field("path"),
+		}
+		if source.Name == "" {
+			source.Name = SourceNameFromURL(source.URL)
+		}
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+// SourceNameFromURL names a source after the last element of its repository URL,
+// without the .git suffix: git@github.com:org/prompts.git becomes prompts
+func SourceNameFromURL(url string) string {
+	url = strings.TrimRight(url, "/")
+	if i := strings.LastIndexAny(url, "/:"); i >= 0 {
+		url = url[i+1:]
+	}
+	return strings.TrimSuffix(url, ".git")
+}
+
+// TemplateSourceDir returns the directory source is cloned into
+func TemplateSourceDir(config *interfaces.Config, source interfaces.TemplateSource) string {
+	return filepath.Join(config.SourcesLocation, source.Name)
+}
+
+// TemplateSourceLocation returns the directory of source searched for templates: its
+// clone, or the path within it
+func TemplateSourceLocation(config *interfaces.Config, source interfaces.TemplateSource) string {
+	return filepath.Join(TemplateSourceDir(config, source), filepath.FromSlash(source.Path))
+}
+
+// validateTemplateSources checks each source has a URL and a unique name usable as a
+// directory, that neither its URL nor its ref could be taken by git for an option,
+// and that its path stays inside the clone
+func validateTemplateSources(sources []interfaces.TemplateSource) error {
+	seen := make(map[string]bool, len(sources))
+	for i, source := range sources {
+		if source.URL == "" {
+			return fmt.Errorf("template_sources[%d]: url is required", i)
+		}
+		if strings.HasPrefix(source.URL, "-") {
+			return fmt.Errorf("template_sources[%d]: invalid url: %s", i, source.URL)
+		}
+		if strings.HasPrefix(source.Ref, "-") {
+			return fmt.Errorf("template_sources[%d]: invalid ref: %s", i, source.Ref)
+		}
+		if !sourceName.MatchString(source.Name) {
+			return fmt.Errorf("template_sources[%d]: invalid name: %q (set name to letters, digits, '.', '_', or '-')", i, source.Name)
+		}
+		if seen[source.Name] {
+			return fmt.Errorf("template_sources[%d]: duplicate name: %s", i, source.Name)
+		}
+		seen[source.Name] = true
+
+		if source.Path != "" {
+			cleaned := path.Clean(filepath.ToSlash(source.Path))
+			if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+				return fmt.Errorf("template_sources[%d]: invalid path: %s (must be relative to the repository)", i, source.Path)
+			}
+		}
+	}
+	return nil
+}
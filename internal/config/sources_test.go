@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManager_TemplateSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	settings := `template_sources_location = "/cache/sources"
+
+[[template_sources]]
+url = "git@example.com:org/prompts.git"
+
+[[template_sources]]
+name = "team"
+url = "https://example.com/org/shared-templates"
+ref = "v2"
+path = "prompts"
+`
+	if err := os.WriteFile(path, []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager()
+	cfg, err := manager.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Validate(cfg); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if unknown := manager.UnknownKeys(); len(unknown) != 0 {
+		t.Errorf("UnknownKeys() = %v", unknown)
+	}
+
+	if len(cfg.TemplateSources) != 2 {
+		t.Fatalf("TemplateSources = %+v, want 2 entries", cfg.TemplateSources)
+	}
+	if got := cfg.TemplateSources[0]; got.Name != "prompts" || got.Ref != "" {
+		t.Errorf("first source = %+v, want the name prompts from its URL", got)
+	}
+	if got := TemplateSourceLocation(cfg, cfg.TemplateSources[0]); got != filepath.Join("/cache/sources", "prompts") {
+		t.Errorf("TemplateSourceLocation() = %q", got)
+	}
+	if got := TemplateSourceLocation(cfg, cfg.TemplateSources[1]); got != filepath.Join("/cache/sources", "team", "prompts") {
+		t.Errorf("TemplateSourceLocation() = %q", got)
+	}
+}
+
+func TestManager_ValidateTemplateSources(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     string
+	}{
+		{name: "missing url", settings: "[[template_sources]]\nname = \"team\"\n", want: "template_sources[0]: url is required"},
+		{name: "duplicate name", settings: "[[template_sources]]\nurl = \"a/prompts.git\"\n[[template_sources]]\nurl = \"b/prompts\"\n", want: "template_sources[1]: duplicate name: prompts"},
+		{name: "invalid name", settings: "[[template_sources]]\nurl = \"a/b\"\nname = \"../b\"\n", want: "template_sources[0]: invalid name"},
+		{name: "ref taken for an option", settings: "[[template_sources]]\nurl = \"a/b\"\nref = \"--upload-pack=touch pwned\"\n", want: "template_sources[0]: invalid ref"},
+		{name: "path outside the repository", settings: "[[template_sources]]\nurl = \"a/b\"\npath = \"../other\"\n", want: "template_sources[0]: invalid path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.settings), 0644); err != nil {
+				t.Fatal(err)
+			}
+			manager := NewManager()
+			cfg, err := manager.Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = manager.Validate(cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package content
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cacheVersion is part of every cache key, so changing how files are processed only
+// needs a bump to stop older entries from being used
+const cacheVersion = 2
+
+// Entries unused for cacheMaxAge are removed, then the least recently used ones until
+// the cache is no larger than cacheMaxBytes
+const (
+	cacheMaxAge   = 30 * 24 * time.Hour
+	cacheMaxBytes = 100 << 20
+)
+
+// DefaultCacheDir returns the directory processed files are cached in between runs,
+// such as ~/.cache/prompter/content on Linux and ~/Library/Caches/prompter/content
+// on macOS, or "" when the system has no cache directory
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "prompter", "content")
+}
+
+// cacheEntry is a processed file as stored in the cache, valid while the file keeps
+// the size and modification time it had when it was read
+type cacheEntry struct {
+	Size    int64 `json:"size"`
+	ModTime int64 `json:"mod_time"`
+
+	Reason     string         `json:"reason,omitempty"` // Why the file was skipped, empty when collected
+	Content    string         `json:"content,omitempty"`
+	Tokens     int            `json:"tokens,omitempty"`
+	TotalLines int            `json:"total_lines,omitempty"`
+	Truncated  bool           `json:"truncated,omitempty"`
+	Ranges     []LineRange    `json:"ranges,omitempty"`
+	Note       string         `json:"note,omitempty"`
+	Outline    bool           `json:"outline,omitempty"`
+	Redactions map[string]int `json:"redactions,omitempty"`
+}
+
+// cachePath names the cache file of a file read with the given settings. The key
+// covers everything that changes how the file is processed, so a file read whole and
+// as a line range, or with two tokenizers, are cached apart.
+func (c *Collector) cachePath(absPath string, explicit bool, lines []LineRange) string {
+	hash := sha256.New()
+	for _, part := range []string{
+		strconv.Itoa(cacheVersion),
+		absPath,
+		strconv.FormatBool(explicit),
+		formatRanges(lines),
+		strconv.FormatBool(c.options.Outline),
+		strconv.FormatInt(c.options.MaxFileSize, 10),
+		strconv.Itoa(c.options.DiffContext),
+		strconv.Itoa(c.options.RangeContext),
+		strings.Join(c.options.SkipHeuristics, ","),
+		c.options.Tokenizer.Name(),
+		c.options.Redactor.Key(),
+	} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return filepath.Join(c.options.CacheDir, hex.EncodeToString(hash.Sum(nil))+".json")
+}
+
+// cached returns the entry at path if the file is unchanged since it was stored,
+// marking the entry as used so pruning keeps it
+func cached(path string, info os.FileInfo) (*cacheEntry, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if json.Unmarshal(data, &entry) != nil || entry.Size != info.Size() || entry.ModTime != info.ModTime().UnixNano() {
+		return nil, false
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return &entry, true
+}
+
+// storeCached writes a processed file, or the reason it was skipped, to path. The
+// entry is written to a temporary file first so concurrent runs never read half of
+// it; failures are ignored since the cache only saves work.
+func storeCached(path string, info os.FileInfo, file File, reason string) {
+	entry := cacheEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Reason: reason}
+	if reason == "" {
+		entry.Content = file.Content
+		entry.Tokens = file.Tokens
+		entry.TotalLines = file.TotalLines
+		entry.Truncated = file.Truncated
+		entry.Ranges = file.Ranges
+		entry.Note = file.Note
+		entry.Outline = file.Outline
+		entry.Redactions = file.Redactions
+	}
+	data, err := json.Marshal(entry)
+	if err != nil || os.MkdirAll(filepath.Dir(path), 0755) != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || os.Rename(tmp.Name(), path) != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// pruneCache removes the entries in dir unused for cacheMaxAge as of now, then the
+// least recently used ones until the rest fit in cacheMaxBytes. Entries are marked
+// used by their modification time; failures are ignored like those of storeCached.
+func pruneCache(dir string, now time.Time) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	type cacheFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []cacheFile
+	var total int64
+	for _, dirEntry := range dirEntries {
+		info, err := dirEntry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		path := filepath.Join(dir, dirEntry.Name())
+		if now.Sub(info.ModTime()) > cacheMaxAge {
+			os.Remove(path)
+			continue
+		}
+		files = append(files, cacheFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, file := range files {
+		if total <= cacheMaxBytes {
+			break
+		}
+		os.Remove(file.path)
+		total -= file.size
+	}
+}
@@ -0,0 +1,143 @@
+package content
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"prompter-cli/internal/redact"
+)
+
+// testRedactor returns a redactor with the built-in rules
+func testRedactor(t *testing.T) *redact.Redactor {
+	t.Helper()
+	redactor, err := redact.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return redactor
+}
+
+func TestCollector_Cache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	writeFile(t, path, "package main\n")
+	options := Options{Strategy: "filesystem", MaxFileSize: 100, CacheDir: filepath.Join(t.TempDir(), "content"), Redactor: testRedactor(t)}
+
+	collect := func() File {
+		t.Helper()
+		files, _, err := NewCollector(options).Collect([]string{path}, "")
+		if err != nil || len(files) != 1 {
+			t.Fatalf("Collect = %+v, %v", files, err)
+		}
+		return files[0]
+	}
+
+	collect()
+	entries, err := filepath.Glob(filepath.Join(options.CacheDir, "*.json"))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("cache entries = %v, %v; want one", entries, err)
+	}
+
+	// Rewrite the entry so a cache hit is told apart from reading the file
+	var entry cacheEntry
+	data, err := os.ReadFile(entries[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	entry.Content = "package cached\n"
+	if data, err = json.Marshal(entry); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, entries[0], string(data))
+
+	if file := collect(); file.Content != "package cached\n" || file.Path != path || file.Language != "go" {
+		t.Errorf("expected the cached entry, got %+v", file)
+	}
+
+	writeFile(t, path, "package main\n\nfunc main() {}\n")
+	if file := collect(); file.Content != "package main\n\nfunc main() {}\n" {
+		t.Errorf("expected the changed file to be read again, got %q", file.Content)
+	}
+}
+
+func TestCollector_CacheSkipReason(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "image.bin"), "PNG\x00\x01")
+	options := Options{Strategy: "filesystem", CacheDir: t.TempDir(), Redactor: testRedactor(t)}
+
+	for range 2 {
+		_, skipped, err := NewCollector(options).Collect(nil, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(skipped) != 1 || skipped[0].Reason != "binary" {
+			t.Errorf("skipped = %+v, want image.bin as binary", skipped)
+		}
+	}
+}
+
+func TestCollector_CacheRedacted(t *testing.T) {
+	dir := t.TempDir()
+	token := "ghp_" + strings.Repeat("a", 36)
+	path := filepath.Join(dir, "config.go")
+	writeFile(t, path, "const token = \""+token+"\"\n")
+	cacheDir := t.TempDir()
+
+	// Without redaction the cache would hold the secret, so it isn't used
+	if _, _, err := NewCollector(Options{CacheDir: cacheDir}).Collect([]string{path}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(cacheDir); len(entries) != 0 {
+		t.Fatalf("expected nothing cached without a redactor, got %d entries", len(entries))
+	}
+
+	options := Options{CacheDir: cacheDir, Redactor: testRedactor(t)}
+	for i := range 2 {
+		files, _, err := NewCollector(options).Collect([]string{path}, "")
+		if err != nil || len(files) != 1 {
+			t.Fatalf("Collect = %+v, %v", files, err)
+		}
+		if !files[0].Redacted || files[0].Redactions["github-token"] != 1 || strings.Contains(files[0].Content, token) {
+			t.Errorf("run %d: expected the token to be redacted and counted, got %+v", i+1, files[0])
+		}
+	}
+
+	entries, _ := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+	if len(entries) != 1 {
+		t.Fatalf("cache entries = %v; want one", entries)
+	}
+	if data, _ := os.ReadFile(entries[0]); strings.Contains(string(data), token) {
+		t.Error("expected the cache to hold only redacted content")
+	}
+}
+
+func TestPruneCache(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	age := func(name string, size int, unused time.Duration) string {
+		path := filepath.Join(dir, name)
+		writeFile(t, path, strings.Repeat("x", size))
+		if err := os.Chtimes(path, now.Add(-unused), now.Add(-unused)); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	stale := age("stale.json", 10, cacheMaxAge+time.Hour)
+	oldest := age("oldest.json", cacheMaxBytes/2, 3*time.Hour)
+	older := age("older.json", cacheMaxBytes/2, 2*time.Hour)
+	recent := age("recent.json", 10, time.Hour)
+
+	pruneCache(dir, now)
+	for path, kept := range map[string]bool{stale: false, oldest: false, older: true, recent: true} {
+		if _, err := os.Stat(path); (err == nil) != kept {
+			t.Errorf("%s: kept = %v, want %v", filepath.Base(path), err == nil, kept)
+		}
+	}
+}
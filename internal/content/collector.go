@@ -0,0 +1,579 @@
+package content
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"prompter-cli/internal/redact"
+	"prompter-cli/internal/tokenizer"
+)
+
+// DefaultMaxFileSize is the per-file size limit used when none is configured
+const DefaultMaxFileSize = 64 * 1024
+
+// File is a collected file with the data used to decide whether it fits the budget
+type File struct {
+	Path     string  // Path as given or relative to the collected directory
+	AbsPath  string  // Absolute path on disk
+	Language string  // Language tag for code fences, empty when unknown
+	Content  string  // File contents
+	Size     int64   // Size in bytes
+	Tokens   int     // Estimated token cost
+	Score    float64 // Relevance to the request, higher is better
+	Explicit bool    // Requested directly with --file rather than found in a directory
+	Changed  bool    // Has uncommitted git changes, or is untracked
+	ModTime  time.Time
+
+	TotalLines int // Line count of the file on disk
+
+	Truncated bool        // Content was cut to fit MaxFileSize
+	Ranges    []LineRange // Lines of the original file present in Content when truncated
+	Note      string      // Describes the truncation, empty when the file is complete
+	Outline   bool        // Content is an outline of declarations, see Outline
+
+	Redacted   bool           // Secrets in Content were replaced by Options.Redactor
+	Redactions map[string]int // Secrets replaced, by rule name
+}
+
+// Skipped records a file that was not collected and why
+type Skipped struct {
+	Path   string
+	Reason string
+}
+
+// Options controls content collection
+type Options struct {
+	Strategy    string // "git" or "filesystem"
+	MaxFileSize int64  // Files larger than this are truncated, 0 uses DefaultMaxFileSize
+	DiffContext int    // Unchanged lines kept around changed hunks when truncating
+
+	// IgnoreFiles are .prmptignore-style files applied on top of the project's own
+	// .prmptignore, such as the one in the config directory
+	IgnoreFiles []string
+
+	// Exclude holds gitignore-style patterns, relative to the collected directory,
+	// for paths to leave out of directory listings
+	Exclude []string
+
+	// SkipHeuristics names the checks that leave minified, lockfile, or generated
+	// directory files out (see DefaultHeuristics); files passed explicitly are kept
+	SkipHeuristics []string
+
+	// Tokenizer counts File.Tokens, nil uses the Claude approximation
+	Tokenizer tokenizer.Tokenizer
+
+	// Outline replaces the contents of files in languages Outline supports with their
+	// declarations and doc comments
+	Outline bool
+
+	// RangeContext is the number of lines kept around requested line ranges and symbols
+	RangeContext int
+
+	// DependencyDepth is how many levels of imports within the same module are
+	// followed from explicit Go files to collect the packages they use, see GoDependencies
+	DependencyDepth int
+
+	// Context stops directory listings and collection when it's done, nil never does
+	Context context.Context
+
+	// Redactor replaces secrets in each file as it's read, nil leaves them
+	Redactor *redact.Redactor
+
+	// CacheDir holds processed files between runs, so unchanged files aren't read and
+	// tokenized again; empty disables the cache, see DefaultCacheDir. Only redacted
+	// files are cached, so it's also unused without a Redactor.
+	CacheDir string
+}
+
+// context returns the options' Context, or a background context when there is none
+func (o Options) context() context.Context {
+	if o.Context == nil {
+		return context.Background()
+	}
+	return o.Context
+}
+
+// readWorkers is how many files Collect reads at once. Reading mostly waits on the
+// disk, so this is more than most machines have cores.
+const readWorkers = 16
+
+// Collector reads files and directories into File values
+type Collector struct {
+	options Options
+	mu      sync.Mutex                 // Guards changed, which concurrent reads fill
+	changed map[string]map[string]bool // Changed files by repository root, filled lazily
+	pruned  sync.Once                  // Prunes the cache before it's first used
+}
+
+// NewCollector creates a collector with the given options
+func NewCollector(options Options) *Collector {
+	if options.MaxFileSize <= 0 {
+		options.MaxFileSize = DefaultMaxFileSize
+	}
+	if options.DiffContext < 0 {
+		options.DiffContext = 0
+	}
+	if options.RangeContext < 0 {
+		options.RangeContext = 0
+	}
+	if options.Tokenizer == nil {
+		options.Tokenizer = tokenizer.NewApproximate()
+	}
+	return &Collector{options: options, changed: make(map[string]map[string]bool)}
+}
+
+// Collect reads the explicit files, the packages they import when DependencyDepth is
+// set, and then every eligible file under dir (if set). Files are read concurrently
+// but returned in that order. Files that can't be embedded are returned in the skipped
+// list instead of failing.
+func (c *Collector) Collect(files []string, dir string) ([]File, []Skipped, error) {
+	var collected []File
+	var skipped []Skipped
+	seen := make(map[string]bool)
+	ctx := c.options.context()
+
+	// Files are queued in order, skipping those already queued, then read together
+	var queue []readJob
+	add := func(displayPath, path string, explicit bool, lines []LineRange) {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			absPath = path
+		}
+		if seen[absPath] {
+			return
+		}
+		seen[absPath] = true
+		queue = append(queue, readJob{displayPath: displayPath, absPath: absPath, explicit: explicit, lines: lines})
+	}
+	read := func() error {
+		results, err := c.readAll(ctx, queue)
+		if err != nil {
+			return err
+		}
+		for i, result := range results {
+			job := queue[i]
+			if result.reason != "" {
+				slog.Debug("skipped file", "path", job.displayPath, "reason", result.reason)
+				skipped = append(skipped, Skipped{Path: job.displayPath, Reason: result.reason})
+				continue
+			}
+			slog.Debug("collected file", "path", job.displayPath, "explicit", job.explicit)
+			result.file.Explicit = job.explicit
+			collected = append(collected, result.file)
+		}
+		queue = nil
+		return nil
+	}
+
+	// Line ranges of the same file are combined into one excerpt, and a file also
+	// requested without a range is included whole
+	var paths []string
+	requested := make(map[string][]LineRange)
+	whole := make(map[string]bool)
+	for _, spec := range files {
+		path, lines, err := SplitFileSpec(spec)
+		if err != nil {
+			slog.Debug("skipped file", "path", spec, "reason", err.Error())
+			skipped = append(skipped, Skipped{Path: spec, Reason: err.Error()})
+			continue
+		}
+		if _, ok := requested[path]; !ok && !whole[path] {
+			paths = append(paths, path)
+		}
+		if lines == nil {
+			whole[path] = true
+		} else {
+			requested[path] = append(requested[path], *lines)
+		}
+	}
+	for _, path := range paths {
+		if whole[path] {
+			add(path, path, true, nil)
+		} else {
+			add(path, path, true, requested[path])
+		}
+	}
+	// The dependencies are found from the explicit files, so those are read first
+	if err := read(); err != nil {
+		return nil, nil, err
+	}
+
+	if c.options.DependencyDepth > 0 {
+		var sources []string
+		for _, file := range collected {
+			sources = append(sources, file.AbsPath)
+		}
+		wd, _ := os.Getwd()
+		for _, path := range GoDependencies(sources, c.options.DependencyDepth) {
+			displayPath := path
+			if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+				displayPath = rel
+			}
+			add(displayPath, path, false, nil)
+		}
+	}
+
+	if dir != "" {
+		paths, err := c.listDirectory(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, rel := range paths {
+			add(rel, filepath.Join(dir, rel), false, nil)
+		}
+	}
+
+	if err := read(); err != nil {
+		return nil, nil, err
+	}
+	return collected, skipped, nil
+}
+
+// readJob is a file queued for reading by Collect
+type readJob struct {
+	displayPath string
+	absPath     string
+	explicit    bool
+	lines       []LineRange
+}
+
+// readResult is a file read by readAll, or the reason it was skipped
+type readResult struct {
+	file   File
+	reason string
+}
+
+// readAll reads the files of jobs with up to readWorkers at once, returning their
+// results in the same order. It stops handing out files once ctx is done.
+func (c *Collector) readAll(ctx context.Context, jobs []readJob) ([]readResult, error) {
+	results := make([]readResult, len(jobs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(readWorkers, len(jobs)) {
+		wg.Go(func() {
+			for i := range next {
+				job := jobs[i]
+				results[i].file, results[i].reason = c.readFile(job.displayPath, job.absPath, job.explicit, job.lines)
+			}
+		})
+	}
+
+feed:
+	for i := range jobs {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+	return results, nil
+}
+
+// readFile loads a single file, or only the given lines of it, returning a skip reason
+// when it can't be embedded. The skip heuristics only apply to files found in a directory,
+// and files unchanged since an earlier run come from the cache when CacheDir and
+// Redactor are set.
+func (c *Collector) readFile(displayPath, absPath string, explicit bool, lines []LineRange) (File, string) {
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return File{}, "not found"
+	}
+	if info.IsDir() {
+		return File{}, "is a directory"
+	}
+	if info.Size() > maxReadSize {
+		return File{}, fmt.Sprintf("larger than %d bytes", maxReadSize)
+	}
+
+	// Files with uncommitted changes are truncated around their diff, which changes
+	// without the file's size or modification time changing, so they aren't cached
+	changed := c.isChanged(absPath)
+	if c.options.CacheDir == "" || c.options.Redactor == nil || changed {
+		return c.processFile(displayPath, absPath, info, changed, explicit, lines)
+	}
+	c.pruned.Do(func() { pruneCache(c.options.CacheDir, time.Now()) })
+	cachePath := c.cachePath(absPath, explicit, lines)
+	if entry, ok := cached(cachePath, info); ok {
+		if entry.Reason != "" {
+			return File{}, entry.Reason
+		}
+		return File{
+			Path:       displayPath,
+			AbsPath:    absPath,
+			Language:   LanguageFor(displayPath),
+			Content:    entry.Content,
+			Size:       info.Size(),
+			ModTime:    info.ModTime(),
+			TotalLines: entry.TotalLines,
+			Tokens:     entry.Tokens,
+			Truncated:  entry.Truncated,
+			Ranges:     entry.Ranges,
+			Note:       entry.Note,
+			Outline:    entry.Outline,
+			Redacted:   true,
+			Redactions: entry.Redactions,
+		}, ""
+	}
+	file, reason := c.processFile(displayPath, absPath, info, changed, explicit, lines)
+	if !strings.HasPrefix(reason, "unreadable") {
+		storeCached(cachePath, info, file, reason)
+	}
+	return file, reason
+}
+
+// processFile reads and processes a file readFile has checked the size of
+func (c *Collector) processFile(displayPath, absPath string, info os.FileInfo, changed, explicit bool, lines []LineRange) (File, string) {
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return File{}, fmt.Sprintf("unreadable: %v", err)
+	}
+	if isBinary(data) {
+		return File{}, "binary"
+	}
+	if !explicit {
+		if reason := skipReason(displayPath, data, c.options.SkipHeuristics); reason != "" {
+			return File{}, reason
+		}
+	}
+
+	file := File{
+		Path:       displayPath,
+		AbsPath:    absPath,
+		Language:   LanguageFor(displayPath),
+		Content:    string(data),
+		Size:       info.Size(),
+		ModTime:    info.ModTime(),
+		Changed:    changed,
+		TotalLines: countLines(data),
+	}
+	switch {
+	case len(lines) > 0:
+		if !selectLines(&file, lines, c.options.RangeContext) {
+			return File{}, fmt.Sprintf("lines %s are past the end of the file (%d lines)", formatRanges(lines), file.TotalLines)
+		}
+	case c.options.Outline && c.outline(&file, data):
+	case info.Size() > c.options.MaxFileSize:
+		c.truncate(&file)
+	}
+	if c.options.Redactor != nil {
+		file.Content, file.Redactions = c.options.Redactor.Redact(file.Content)
+		file.Redacted = true
+	}
+	file.Tokens = c.options.Tokenizer.Count(file.Content)
+
+	return file, ""
+}
+
+// isChanged reports whether git shows uncommitted changes to a file
+func (c *Collector) isChanged(absPath string) bool {
+	root, ok := repositoryRoot(filepath.Dir(absPath))
+	if !ok {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changed, ok := c.changed[root]
+	if !ok {
+		changed = changedFiles(root)
+		c.changed[root] = changed
+	}
+	return changed[absPath]
+}
+
+// countLines counts lines, including a final line without a newline
+func countLines(data []byte) int {
+	lines := bytes.Count(data, []byte("\n"))
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		lines++
+	}
+	return lines
+}
+
+// listDirectory returns the files under dir relative to it, using the configured strategy
+func (c *Collector) listDirectory(dir string) ([]string, error) {
+	return ListFiles(dir, c.options)
+}
+
+// ListFiles returns the files under dir relative to it. The "git" strategy lists
+// tracked and unignored files, falling back to a filesystem walk outside a repository.
+// With either strategy, paths matched by the project's .prmptignore, by any of the
+// option's IgnoreFiles, or by its Exclude patterns are left out.
+func ListFiles(dir string, options Options) ([]string, error) {
+	ctx := options.context()
+	var paths []string
+	var err error
+	if options.Strategy == "git" {
+		paths, err = gitFiles(ctx, dir)
+	}
+	if options.Strategy != "git" || err != nil {
+		// Not a git repository, fall back to walking the filesystem
+		slog.Debug("listing files by walking the filesystem", "dir", dir, "strategy", options.Strategy)
+		if paths, err = walkFiles(ctx, dir); err != nil {
+			return nil, err
+		}
+	}
+	return excludeIgnored(dir, paths, options), nil
+}
+
+// Glob returns the files under dir matching pattern, relative to dir with forward
+// slashes and sorted. The pattern uses gitignore glob syntax, where "**/" matches any
+// number of directories, and the files are listed as ListFiles lists them, so ignored
+// and excluded files never match.
+func Glob(dir, pattern string, options Options) ([]string, error) {
+	matcher, err := regexp.Compile("^" + globToRegexp(strings.TrimPrefix(filepath.ToSlash(pattern), "./")) + "$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+	}
+
+	paths, err := ListFiles(dir, options)
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, path := range paths {
+		if path = filepath.ToSlash(path); matcher.MatchString(path) {
+			matches = append(matches, path)
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// gitFiles lists tracked and untracked-but-not-ignored files under dir
+func gitFiles(ctx context.Context, dir string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", "ls-files", "--cached", "--others", "--exclude-standard")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-files failed: %w", err)
+	}
+
+	var paths []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, filepath.FromSlash(line))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// walkFiles lists regular files under dir, skipping hidden files and directories and
+// anything excluded by .gitignore files in dir, its subdirectories, or the directories
+// above it within the same repository. The walk stops early when ctx is done.
+func walkFiles(ctx context.Context, dir string) ([]string, error) {
+	ignore := NewIgnoreMatcher()
+	ignore.addAncestorGitignores(dir)
+
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		if path != dir && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			if path != dir && ignore.Match(path, true) {
+				return filepath.SkipDir
+			}
+			// Patterns apply to this directory's contents, after those of its parents
+			_ = ignore.AddFile(filepath.Join(path, GitignoreFile), path)
+			return nil
+		}
+		if !entry.Type().IsRegular() || ignore.Match(path, false) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, rel)
+		return nil
+	})
+	if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+	return paths, nil
+}
+
+// isBinary reports whether data looks like a binary file: a NUL byte in the first
+// 8KB (the same heuristic git uses), or a high share of control characters there
+func isBinary(data []byte) bool {
+	if len(data) > sniffSize {
+		data = data[:sniffSize]
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return true
+	}
+
+	control := 0
+	for _, b := range data {
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' && b != '\f' && b != '\b' && b != 0x1b {
+			control++
+		}
+	}
+	return len(data) > 0 && control*10 > len(data)
+}
+
+// languages maps file extensions to code fence language tags
+var languages = map[string]string{
+	".go":   "go",
+	".py":   "python",
+	".js":   "javascript",
+	".jsx":  "jsx",
+	".ts":   "typescript",
+	".tsx":  "tsx",
+	".rs":   "rust",
+	".rb":   "ruby",
+	".java": "java",
+	".kt":   "kotlin",
+	".c":    "c",
+	".h":    "c",
+	".cpp":  "cpp",
+	".cs":   "csharp",
+	".sh":   "bash",
+	".md":   "markdown",
+	".json": "json",
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".toml": "toml",
+	".sql":  "sql",
+	".html": "html",
+	".css":  "css",
+}
+
+// LanguageFor returns the code fence language for a path, or "" when unknown
+func LanguageFor(path string) string {
+	if filepath.Base(path) == "Makefile" {
+		return "makefile"
+	}
+	return languages[strings.ToLower(filepath.Ext(path))]
+}
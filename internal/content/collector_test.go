@@ -0,0 +1,137 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompter-cli/internal/tokenizer"
+)
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollector_Collect(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	writeFile(t, filepath.Join(dir, "docs", "guide.md"), "# Guide\n")
+	writeFile(t, filepath.Join(dir, ".hidden", "secret.txt"), "secret")
+	writeFile(t, filepath.Join(dir, "image.bin"), "PNG\x00\x01")
+	writeFile(t, filepath.Join(dir, "big.txt"), strings.Repeat("0123456789\n", 20))
+
+	collector := NewCollector(Options{Strategy: "filesystem", MaxFileSize: 100})
+	explicit := filepath.Join(dir, "main.go")
+	files, skipped, err := collector.Collect([]string{explicit, "missing.go"}, dir)
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	if len(files) != 3 {
+		t.Fatalf("got %d files, want 3: %+v", len(files), files)
+	}
+	if files[0].Path != explicit || !files[0].Explicit || files[0].Language != "go" {
+		t.Errorf("unexpected explicit file: %+v", files[0])
+	}
+	if files[1].Path != "big.txt" || !files[1].Truncated || files[1].Note != "showing first 9 of 20 lines" {
+		t.Errorf("expected big.txt to be truncated to its head: %+v", files[1])
+	}
+	if files[2].Path != filepath.Join("docs", "guide.md") || files[2].Explicit {
+		t.Errorf("unexpected directory file: %+v", files[2])
+	}
+	if files[2].Tokens != tokenizer.NewApproximate().Count("# Guide\n") {
+		t.Errorf("Tokens = %d", files[2].Tokens)
+	}
+
+	reasons := make(map[string]string)
+	for _, skip := range skipped {
+		reasons[skip.Path] = skip.Reason
+	}
+	if reasons["missing.go"] != "not found" {
+		t.Errorf("missing.go reason = %q", reasons["missing.go"])
+	}
+	if reasons["image.bin"] != "binary" {
+		t.Errorf("image.bin reason = %q", reasons["image.bin"])
+	}
+}
+
+func TestLanguageFor(t *testing.T) {
+	tests := map[string]string{
+		"main.go":        "go",
+		"src/App.TSX":    "tsx",
+		"Makefile":       "makefile",
+		"notes.unknown":  "",
+		"config/app.yml": "yaml",
+	}
+	for path, want := range tests {
+		if got := LanguageFor(path); got != want {
+			t.Errorf("LanguageFor(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestListFiles_Canceled(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ListFiles(dir, Options{Strategy: "filesystem", Context: ctx}); !errors.Is(err, context.Canceled) {
+		t.Errorf("ListFiles() with a canceled context = %v, want context.Canceled", err)
+	}
+	if _, _, err := NewCollector(Options{Context: ctx}).Collect([]string{filepath.Join(dir, "main.go")}, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("Collect() with a canceled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestCollector_CollectKeepsOrder(t *testing.T) {
+	dir := t.TempDir()
+	for i := range 100 {
+		writeFile(t, filepath.Join(dir, fmt.Sprintf("pkg%d", i%7), fmt.Sprintf("file%03d.go", i)), fmt.Sprintf("package p\n\nconst N = %d\n", i))
+	}
+	explicit := filepath.Join(dir, "pkg3", "file010.go")
+
+	options := Options{Strategy: "filesystem"}
+	listed, err := ListFiles(dir, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, skipped, err := NewCollector(options).Collect([]string{explicit, "missing.go"}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The explicit file comes first, then the directory in listing order without it again
+	if len(files) != len(listed) || files[0].AbsPath != explicit || !files[0].Explicit {
+		t.Fatalf("Collect() returned %d files starting with %s, want %d starting with %s", len(files), files[0].Path, len(listed), explicit)
+	}
+	want := make([]string, 0, len(listed))
+	for _, rel := range listed {
+		if filepath.Join(dir, rel) != explicit {
+			want = append(want, rel)
+		}
+	}
+	for i, file := range files[1:] {
+		if file.Path != want[i] {
+			t.Fatalf("files[%d] = %s, want %s", i+1, file.Path, want[i])
+		}
+		var n int
+		fmt.Sscanf(filepath.Base(file.Path), "file%d.go", &n)
+		if !strings.Contains(file.Content, fmt.Sprintf("N = %d\n", n)) {
+			t.Errorf("%s has the contents of another file: %q", file.Path, file.Content)
+		}
+	}
+	if len(skipped) != 1 || skipped[0].Path != "missing.go" {
+		t.Errorf("skipped = %+v, want missing.go", skipped)
+	}
+}
@@ -0,0 +1,138 @@
+package content
+
+import (
+	"bufio"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// goModule is a Go module found by its go.mod file
+type goModule struct {
+	path string // Module path from the module directive
+	root string // Directory containing go.mod
+}
+
+// packageDir returns the directory of an import path within the module
+func (m goModule) packageDir(importPath string) (string, bool) {
+	if importPath == m.path {
+		return m.root, true
+	}
+	if rest, ok := strings.CutPrefix(importPath, m.path+"/"); ok {
+		return filepath.Join(m.root, filepath.FromSlash(rest)), true
+	}
+	return "", false
+}
+
+// GoDependencies returns the Go files of the packages the given Go files import from
+// their own module, following imports up to depth levels: 1 takes the packages the
+// files import directly, 2 adds the packages those import, and so on. Test files,
+// standard library packages, and other modules are left out. Paths are absolute and
+// grouped by package in the order the imports were found.
+func GoDependencies(files []string, depth int) []string {
+	modules := make(map[string]*goModule) // By directory, nil outside a module
+	visited := make(map[string]bool)      // Package directories already taken
+
+	var dependencies []string
+	frontier := files
+	for level := 0; level < depth && len(frontier) > 0; level++ {
+		var next []string
+		for _, file := range frontier {
+			abs, err := filepath.Abs(file)
+			if err != nil || filepath.Ext(abs) != ".go" {
+				continue
+			}
+			module := findModule(filepath.Dir(abs), modules)
+			if module == nil {
+				continue
+			}
+			for _, importPath := range goImports(abs) {
+				dir, ok := module.packageDir(importPath)
+				if !ok || visited[dir] {
+					continue
+				}
+				visited[dir] = true
+				packageFiles := goPackageFiles(dir)
+				dependencies = append(dependencies, packageFiles...)
+				next = append(next, packageFiles...)
+			}
+		}
+		frontier = next
+	}
+	return dependencies
+}
+
+// findModule returns the module containing dir, or nil when there is none
+func findModule(dir string, cache map[string]*goModule) *goModule {
+	if module, ok := cache[dir]; ok {
+		return module
+	}
+
+	var module *goModule
+	if path, ok := modulePath(filepath.Join(dir, "go.mod")); ok {
+		module = &goModule{path: path, root: dir}
+	} else if parent := filepath.Dir(dir); parent != dir {
+		module = findModule(parent, cache)
+	}
+	cache[dir] = module
+	return module
+}
+
+// modulePath reads the module directive of a go.mod file
+func modulePath(goMod string) (string, bool) {
+	file, err := os.Open(goMod)
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line, _, _ = strings.Cut(line, "//"); !strings.HasPrefix(line, "module") {
+			continue
+		}
+		path := strings.TrimSpace(strings.TrimPrefix(line, "module"))
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			path = unquoted
+		}
+		return path, path != ""
+	}
+	return "", false
+}
+
+// goImports returns the import paths of a Go file, nil when it doesn't parse
+func goImports(path string) []string {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+	imports := make([]string, 0, len(file.Imports))
+	for _, spec := range file.Imports {
+		if importPath, err := strconv.Unquote(spec.Path.Value); err == nil {
+			imports = append(imports, importPath)
+		}
+	}
+	return imports
+}
+
+// goPackageFiles lists the non-test Go files of the package in dir, sorted
+func goPackageFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
@@ -0,0 +1,80 @@
+package content
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeModule creates a module where main imports api, api imports store, and store
+// imports only the standard library
+func writeModule(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/app // the app\n\ngo 1.22\n")
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/api\"\n\t\"example.com/other/lib\"\n)\n\nfunc main() { fmt.Println(api.Serve()) }\n")
+	writeFile(t, filepath.Join(dir, "api", "api.go"), "package api\n\nimport \"example.com/app/store\"\n\nfunc Serve() string { return store.Get() }\n")
+	writeFile(t, filepath.Join(dir, "api", "routes.go"), "package api\n")
+	writeFile(t, filepath.Join(dir, "api", "api_test.go"), "package api\n")
+	writeFile(t, filepath.Join(dir, "store", "store.go"), "package store\n\nimport \"strings\"\n\nfunc Get() string { return strings.ToUpper(\"x\") }\n")
+	return dir
+}
+
+func TestGoDependencies(t *testing.T) {
+	dir := writeModule(t)
+	main := filepath.Join(dir, "main.go")
+
+	direct := GoDependencies([]string{main}, 1)
+	want := []string{filepath.Join(dir, "api", "api.go"), filepath.Join(dir, "api", "routes.go")}
+	if len(direct) != len(want) || direct[0] != want[0] || direct[1] != want[1] {
+		t.Errorf("depth 1 = %v, want %v", direct, want)
+	}
+
+	all := GoDependencies([]string{main}, 3)
+	want = append(want, filepath.Join(dir, "store", "store.go"))
+	if len(all) != len(want) || all[2] != want[2] {
+		t.Errorf("depth 3 = %v, want %v", all, want)
+	}
+
+	if deps := GoDependencies([]string{filepath.Join(dir, "store", "store.go")}, 2); len(deps) != 0 {
+		t.Errorf("expected no dependencies for a standard library import, got %v", deps)
+	}
+}
+
+func TestCollector_Dependencies(t *testing.T) {
+	dir := writeModule(t)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	collector := NewCollector(Options{Strategy: "filesystem", DependencyDepth: 1})
+	files, _, err := collector.Collect([]string{"main.go", filepath.Join("api", "api.go")}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// api.go was named explicitly, so only routes.go is added for the api package, and
+	// store is a direct import of api.go
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	want := []string{"main.go", filepath.Join("api", "api.go"), filepath.Join("api", "routes.go"), filepath.Join("store", "store.go")}
+	if len(paths) != len(want) {
+		t.Fatalf("collected %v, want %v", paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("collected %v, want %v", paths, want)
+			break
+		}
+	}
+	if files[2].Explicit || files[3].Explicit {
+		t.Error("expected dependencies not to be marked explicit")
+	}
+}
@@ -0,0 +1,123 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Heuristics that skip directory files whose contents would only inflate the prompt.
+// Binary files are always skipped; these can be turned off with skip_heuristics.
+const (
+	HeuristicMinified  = "minified"  // Minified JavaScript and CSS, and source maps
+	HeuristicLockfile  = "lockfile"  // Dependency lockfiles such as package-lock.json and go.sum
+	HeuristicGenerated = "generated" // Files marked as generated, such as "Code generated ... DO NOT EDIT."
+)
+
+// DefaultHeuristics enables every skip heuristic
+var DefaultHeuristics = []string{HeuristicMinified, HeuristicLockfile, HeuristicGenerated}
+
+// ValidateHeuristics reports the first unknown heuristic name
+func ValidateHeuristics(names []string) error {
+	for _, name := range names {
+		switch name {
+		case HeuristicMinified, HeuristicLockfile, HeuristicGenerated:
+		default:
+			return fmt.Errorf("unknown skip heuristic %q (must be %s)", name, strings.Join(DefaultHeuristics, ", "))
+		}
+	}
+	return nil
+}
+
+// sniffSize is how much of a file the content checks look at
+const sniffSize = 8000
+
+// lockfiles are dependency lockfiles recognized by name
+var lockfiles = map[string]bool{
+	"package-lock.json":   true,
+	"npm-shrinkwrap.json": true,
+	"yarn.lock":           true,
+	"pnpm-lock.yaml":      true,
+	"bun.lockb":           true,
+	"go.sum":              true,
+	"go.work.sum":         true,
+	"cargo.lock":          true,
+	"gemfile.lock":        true,
+	"poetry.lock":         true,
+	"pipfile.lock":        true,
+	"uv.lock":             true,
+	"composer.lock":       true,
+	"podfile.lock":        true,
+	"packages.lock.json":  true,
+	"flake.lock":          true,
+	"mix.lock":            true,
+	"pubspec.lock":        true,
+}
+
+// minifiable are the extensions checked for minified content
+var minifiable = map[string]bool{
+	".js":  true,
+	".mjs": true,
+	".cjs": true,
+	".css": true,
+}
+
+// generatedMarker matches the comments code generators put near the top of a file
+var generatedMarker = regexp.MustCompile(`(?i)(code generated .*do not edit|@generated|auto-?generated.*do not (edit|modify)|this file (is|was) (automatically |auto-?)?generated)`)
+
+// skipReason returns why a directory file should be left out under the enabled
+// heuristics, or "" when it should be collected
+func skipReason(path string, data []byte, heuristics []string) string {
+	for _, heuristic := range heuristics {
+		switch heuristic {
+		case HeuristicLockfile:
+			if lockfiles[strings.ToLower(filepath.Base(path))] {
+				return "lockfile"
+			}
+		case HeuristicMinified:
+			if isMinified(path, data) {
+				return "minified"
+			}
+		case HeuristicGenerated:
+			if isGenerated(data) {
+				return "generated"
+			}
+		}
+	}
+	return ""
+}
+
+// isMinified reports whether a web asset is minified: named .min, a source map, or
+// made of a few very long lines
+func isMinified(path string, data []byte) bool {
+	name := strings.ToLower(filepath.Base(path))
+	ext := filepath.Ext(name)
+	if ext == ".map" || (minifiable[ext] && strings.HasSuffix(strings.TrimSuffix(name, ext), ".min")) {
+		return true
+	}
+	if !minifiable[ext] || len(data) < 1024 {
+		return false
+	}
+
+	lines := bytes.Count(data, []byte("\n")) + 1
+	return len(data)/lines > 500
+}
+
+// isGenerated reports whether one of the first lines carries a generated-code marker
+func isGenerated(data []byte) bool {
+	if len(data) > sniffSize {
+		data = data[:sniffSize]
+	}
+	lines := bytes.SplitN(data, []byte("\n"), 21)
+	if len(lines) > 20 {
+		lines = lines[:20]
+	}
+	for _, line := range lines {
+		if generatedMarker.Match(line) {
+			return true
+		}
+	}
+	return false
+}
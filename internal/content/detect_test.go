@@ -0,0 +1,74 @@
+package content
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSkipReason(t *testing.T) {
+	minified := "function a(){" + strings.Repeat("return 1;", 200) + "}"
+	tests := []struct {
+		name string
+		path string
+		data string
+		want string
+	}{
+		{"source", "main.go", "package main\n", ""},
+		{"lockfile", "web/package-lock.json", "{}", "lockfile"},
+		{"go.sum", "go.sum", "example.com/x v1.0.0 h1:abc=\n", "lockfile"},
+		{"min suffix", "static/app.min.js", "var a=1", "minified"},
+		{"source map", "static/app.js.map", "{}", "minified"},
+		{"long lines", "static/bundle.js", minified, "minified"},
+		{"long lines outside web assets", "data.txt", minified, ""},
+		{"go generated", "api.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n", "generated"},
+		{"at generated", "schema.ts", "/**\n * @generated\n */\nexport type A = {}\n", "generated"},
+		{"marker too deep", "notes.md", strings.Repeat("line\n", 30) + "Code generated by hand. DO NOT EDIT.\n", ""},
+	}
+	for _, tt := range tests {
+		if got := skipReason(tt.path, []byte(tt.data), DefaultHeuristics); got != tt.want {
+			t.Errorf("%s: skipReason(%q) = %q, want %q", tt.name, tt.path, got, tt.want)
+		}
+	}
+
+	if got := skipReason("go.sum", []byte("x"), []string{HeuristicMinified}); got != "" {
+		t.Errorf("disabled lockfile heuristic still skipped go.sum: %q", got)
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	if !isBinary([]byte("PNG\x00data")) {
+		t.Error("expected NUL bytes to be binary")
+	}
+	if !isBinary([]byte("\x01\x02\x03\x04abc\x05\x06")) {
+		t.Error("expected mostly control characters to be binary")
+	}
+	if isBinary([]byte("plain text\twith tabs\r\n\x1b[31mcolor\x1b[0m\n")) {
+		t.Error("expected text with tabs and escapes not to be binary")
+	}
+}
+
+func TestCollector_SkipHeuristics(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	writeFile(t, filepath.Join(dir, "yarn.lock"), "# yarn lockfile v1\n")
+	writeFile(t, filepath.Join(dir, "gen.go"), "// Code generated by stringer. DO NOT EDIT.\n\npackage main\n")
+
+	collector := NewCollector(Options{Strategy: "filesystem", SkipHeuristics: DefaultHeuristics})
+	explicit := filepath.Join(dir, "yarn.lock")
+	files, skipped, err := collector.Collect([]string{explicit}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	if strings.Join(paths, ",") != explicit+",main.go" {
+		t.Errorf("collected %v, want the explicit lockfile and main.go", paths)
+	}
+	if len(skipped) != 1 || skipped[0].Path != "gen.go" || skipped[0].Reason != "generated" {
+		t.Errorf("skipped = %+v, want gen.go as generated", skipped)
+	}
+}
@@ -0,0 +1,55 @@
+package content
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultDocsTokenShare is the share of the token budget project documentation may use
+const DefaultDocsTokenShare = 0.2
+
+// docFiles are the documents at the project root included as project documentation,
+// in order. Of the READMEs, only the first found is used.
+var docFiles = [][]string{
+	{"README.md", "README.rst", "README.txt", "README"},
+	{"CONTRIBUTING.md"},
+}
+
+// DocsDirectory holds overviews included as project documentation: the markdown files
+// directly inside it, without those of its subdirectories
+const DocsDirectory = "docs"
+
+// DocFiles returns the absolute paths of the project documentation found from dir: the
+// README and CONTRIBUTING.md of the project root (the root of the git repository, or
+// dir outside one), followed by the markdown files directly inside its docs directory
+// in name order
+func DocFiles(dir string) []string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+	root := ProjectRoot(absDir)
+
+	var paths []string
+	for _, names := range docFiles {
+		for _, name := range names {
+			path := filepath.Join(root, name)
+			if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+				paths = append(paths, path)
+				break
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(root, DocsDirectory))
+	if err != nil {
+		return paths
+	}
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.EqualFold(filepath.Ext(entry.Name()), ".md") {
+			paths = append(paths, filepath.Join(root, DocsDirectory, entry.Name()))
+		}
+	}
+	return paths
+}
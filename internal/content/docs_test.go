@@ -0,0 +1,38 @@
+package content
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDocFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, path := range []string{
+		".git/HEAD",
+		"README.rst",
+		"README",
+		"CONTRIBUTING.md",
+		"docs/usage.md",
+		"docs/ARCHITECTURE.MD",
+		"docs/diagram.png",
+		"docs/api/endpoints.md",
+		"cmd/app/main.go",
+	} {
+		writeFile(t, filepath.Join(root, path), "x\n")
+	}
+
+	want := []string{
+		filepath.Join(root, "README.rst"),
+		filepath.Join(root, "CONTRIBUTING.md"),
+		filepath.Join(root, "docs", "ARCHITECTURE.MD"),
+		filepath.Join(root, "docs", "usage.md"),
+	}
+	// Documentation is found from the root of the repository
+	if got := DocFiles(filepath.Join(root, "cmd", "app")); !reflect.DeepEqual(got, want) {
+		t.Errorf("DocFiles() = %v, want %v", got, want)
+	}
+	if got := DocFiles(t.TempDir()); len(got) != 0 {
+		t.Errorf("DocFiles() without documentation = %v", got)
+	}
+}
@@ -0,0 +1,77 @@
+package content
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// FormatPresets are built-in embedding formats selectable by name with the file_format setting
+var FormatPresets = map[string]string{
+	"markdown": "{{.Path}}\n```{{.Language}}\n{{.Content}}\n```{{if .Truncated}}\n(truncated: {{.Note}}){{end}}",
+	"xml":      "<file path=\"{{.Path}}\"{{if .Language}} language=\"{{.Language}}\"{{end}}{{if .Lines}} lines=\"{{.Lines}}\"{{end}}>\n{{.Content}}\n</file>{{if .Truncated}}\n<!-- truncated: {{.Note}} -->{{end}}",
+	"plain":    "==> {{.Path}}{{if .Truncated}} ({{.Note}}){{end}} <==\n{{.Content}}",
+}
+
+// DefaultFormat is the preset used when no file_format is configured
+const DefaultFormat = "markdown"
+
+// FileView is the data available to a file_format template
+type FileView struct {
+	Path      string // Path as given or relative to the collected directory
+	Language  string // Code fence language, empty when unknown
+	Content   string // File contents without the trailing newline
+	Lines     string // Included line ranges such as "1-40, 88-120", empty when complete
+	Truncated bool   // Whether only part of the file is included
+	Note      string // Describes the truncation
+	Tokens    int    // Estimated tokens of the included content
+}
+
+// ParseFormat compiles a file_format value, which is either a preset name or a
+// template string
+func ParseFormat(format string) (*template.Template, error) {
+	if format == "" {
+		format = DefaultFormat
+	}
+	if preset, ok := FormatPresets[format]; ok {
+		format = preset
+	}
+
+	tmpl, err := template.New("file_format").Option("missingkey=error").Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file_format: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Format renders each file with the given file_format and joins them with blank lines
+func Format(files []File, format string) (string, error) {
+	tmpl, err := ParseFormat(format)
+	if err != nil {
+		return "", err
+	}
+
+	parts := make([]string, 0, len(files))
+	for _, file := range files {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, newFileView(file)); err != nil {
+			return "", fmt.Errorf("failed to format %s: %w", file.Path, err)
+		}
+		parts = append(parts, b.String())
+	}
+
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// newFileView builds the template data for a file
+func newFileView(file File) FileView {
+	return FileView{
+		Path:      file.Path,
+		Language:  file.Language,
+		Content:   strings.TrimRight(file.Content, "\n"),
+		Lines:     formatRanges(file.Ranges),
+		Truncated: file.Truncated,
+		Note:      file.Note,
+		Tokens:    file.Tokens,
+	}
+}
@@ -0,0 +1,53 @@
+package content
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	files := []File{
+		{Path: "main.go", Language: "go", Content: "package main\n"},
+		{Path: "big.txt", Content: "head\n", Truncated: true, Ranges: []LineRange{{1, 1}, {5, 9}}, Note: "showing changed lines 1, 5-9 of 20"},
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{
+			name:   "default markdown",
+			format: "",
+			want:   "main.go\n```go\npackage main\n```\n\nbig.txt\n```\nhead\n```\n(truncated: showing changed lines 1, 5-9 of 20)",
+		},
+		{
+			name:   "xml preset",
+			format: "xml",
+			want:   "<file path=\"main.go\" language=\"go\">\npackage main\n</file>\n\n<file path=\"big.txt\" lines=\"1, 5-9\">\nhead\n</file>\n<!-- truncated: showing changed lines 1, 5-9 of 20 -->",
+		},
+		{
+			name:   "custom template",
+			format: "## {{.Path}} ({{.Tokens}} tokens)\n{{.Content}}",
+			want:   "## main.go (0 tokens)\npackage main\n\n## big.txt (0 tokens)\nhead",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(files, tt.format)
+			if err != nil {
+				t.Fatalf("Format failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Format =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFormat_Errors(t *testing.T) {
+	if _, err := ParseFormat("{{.Path"); err == nil {
+		t.Error("expected a parse error")
+	}
+	if _, err := Format([]File{{Path: "a"}}, "{{.Missing}}"); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
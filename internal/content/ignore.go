@@ -0,0 +1,300 @@
+package content
+
+import (
+	"bufio"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// GitignoreFile is the per-directory ignore file honored by the filesystem strategy
+const GitignoreFile = ".gitignore"
+
+// PrmptignoreFile excludes paths from content collection whatever the directory strategy.
+// It is read from the project root and from the config directory.
+const PrmptignoreFile = ".prmptignore"
+
+// ignoreRule is a single pattern from an ignore file
+type ignoreRule struct {
+	base    string // Directory the pattern is relative to, as an absolute slash path
+	pattern *regexp.Regexp
+	negate  bool // Pattern started with "!" and re-includes matching paths
+	dirOnly bool // Pattern ended with "/" and only matches directories
+}
+
+// IgnoreMatcher matches paths against gitignore-style patterns collected from any
+// number of ignore files. As in git, later patterns take precedence over earlier
+// ones, so files deeper in the tree should be added after their parents.
+type IgnoreMatcher struct {
+	rules []ignoreRule
+}
+
+// NewIgnoreMatcher creates a matcher with no patterns
+func NewIgnoreMatcher() *IgnoreMatcher {
+	return &IgnoreMatcher{}
+}
+
+// AddFile adds the patterns of an ignore file, relative to base. A missing file is
+// not an error.
+func (m *IgnoreMatcher) AddFile(path, base string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	m.AddPatterns(lines, base)
+	return nil
+}
+
+// AddPatterns adds gitignore-style patterns relative to the directory base
+func (m *IgnoreMatcher) AddPatterns(lines []string, base string) {
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		absBase = base
+	}
+	absBase = filepath.ToSlash(absBase)
+
+	for _, line := range lines {
+		if rule, ok := parseIgnoreLine(line); ok {
+			rule.base = absBase
+			m.rules = append(m.rules, rule)
+		}
+	}
+}
+
+// Match reports whether a path is ignored. Only the path itself is tested, so
+// callers walking a tree should skip the contents of ignored directories.
+func (m *IgnoreMatcher) Match(path string, isDir bool) bool {
+	if len(m.rules) == 0 {
+		return false
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	absPath = filepath.ToSlash(absPath)
+
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		rel, ok := relativeTo(absPath, rule.base)
+		if !ok {
+			continue
+		}
+		if rule.pattern.MatchString(rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// Excludes reports whether path, or any directory above it up to (but not including)
+// stop, is ignored
+func (m *IgnoreMatcher) Excludes(path, stop string) bool {
+	if m.Match(path, false) {
+		return true
+	}
+	for dir := filepath.Dir(path); dir != stop && strings.HasPrefix(dir, stop); dir = filepath.Dir(dir) {
+		if m.Match(dir, true) {
+			return true
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	return false
+}
+
+// relativeTo returns path relative to base when path is inside base
+func relativeTo(path, base string) (string, bool) {
+	if base == "/" {
+		return strings.TrimPrefix(path, "/"), path != "/"
+	}
+	if !strings.HasPrefix(path, base+"/") {
+		return "", false
+	}
+	return path[len(base)+1:], true
+}
+
+// parseIgnoreLine converts one line of an ignore file into a rule
+func parseIgnoreLine(line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// A slash anywhere but the end anchors the pattern to the ignore file's directory
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(?:.*/)?" + expr + "$"
+	}
+
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.pattern = pattern
+	return rule, true
+}
+
+// globToRegexp translates a gitignore glob into a regular expression body
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*' && strings.HasPrefix(glob[i:], "**"):
+			atStart := i == 0 || glob[i-1] == '/'
+			if atStart && i+2 < len(glob) && glob[i+2] == '/' {
+				b.WriteString("(?:.*/)?") // "**/" matches zero or more directories
+				i += 2
+			} else {
+				b.WriteString(".*") // "dir/**" matches everything inside dir
+				i++
+			}
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// addAncestorGitignores adds the .gitignore files of the directories above dir, up to
+// the root of the git repository containing it. Nothing is added outside a repository.
+func (m *IgnoreMatcher) addAncestorGitignores(dir string) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return
+	}
+
+	root, ok := repositoryRoot(absDir)
+	if !ok || root == absDir {
+		return
+	}
+
+	var ancestors []string
+	for current := filepath.Dir(absDir); ; current = filepath.Dir(current) {
+		ancestors = append(ancestors, current)
+		if current == root {
+			break
+		}
+	}
+
+	// Outermost first, so deeper ignore files take precedence
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		_ = m.AddFile(filepath.Join(ancestors[i], GitignoreFile), ancestors[i])
+	}
+}
+
+// repositoryRoot returns the nearest directory at or above absDir containing .git
+func repositoryRoot(absDir string) (string, bool) {
+	for current := absDir; ; current = filepath.Dir(current) {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current, true
+		}
+		if filepath.Dir(current) == current {
+			return "", false
+		}
+	}
+}
+
+// ProjectRoot returns the repository root containing dir, or dir itself outside a repository
+func ProjectRoot(absDir string) string {
+	if root, ok := repositoryRoot(absDir); ok {
+		return root
+	}
+	return absDir
+}
+
+// excludeIgnored removes the paths (relative to dir) matched by the project's
+// .prmptignore or by the extra ignore files, whose patterns are relative to the
+// project root, or by the exclude patterns, which are relative to dir
+func excludeIgnored(dir string, paths []string, options Options) []string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return paths
+	}
+	root := ProjectRoot(absDir)
+
+	matcher := NewIgnoreMatcher()
+	for _, file := range options.IgnoreFiles {
+		if file != "" {
+			_ = matcher.AddFile(file, root)
+		}
+	}
+	// The project's own file is added after the global one so it can re-include paths
+	_ = matcher.AddFile(filepath.Join(root, PrmptignoreFile), root)
+	// Exclude patterns come from the current run or config and always win
+	matcher.AddPatterns(options.Exclude, absDir)
+	if len(matcher.rules) == 0 {
+		return paths
+	}
+
+	kept := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !matcher.Excludes(filepath.Join(absDir, path), root) {
+			kept = append(kept, path)
+		} else {
+			slog.Debug("excluded file by ignore rules", "path", path)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,166 @@
+package content
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIgnoreMatcher_Match(t *testing.T) {
+	base := t.TempDir()
+	matcher := NewIgnoreMatcher()
+	matcher.AddPatterns([]string{
+		"# comment",
+		"",
+		"*.log",
+		"!keep.log",
+		"build/",
+		"/root-only.txt",
+		"docs/*.tmp",
+		"**/generated/**",
+		"a/**/z.txt",
+		`\#literal`,
+	}, base)
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"debug.log", false, true},
+		{"nested/dir/debug.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"src/build", true, true},
+		{"build", false, false}, // dir-only pattern
+		{"root-only.txt", false, true},
+		{"sub/root-only.txt", false, false},
+		{"docs/x.tmp", false, true},
+		{"docs/deep/x.tmp", false, false},
+		{"pkg/generated/out.go", false, true},
+		{"a/z.txt", false, true},
+		{"a/b/c/z.txt", false, true},
+		{"#literal", false, true},
+		{"main.go", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := matcher.Match(filepath.Join(base, tt.path), tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+
+	if matcher.Match(filepath.Join(t.TempDir(), "debug.log"), false) {
+		t.Error("patterns should not apply outside their base directory")
+	}
+}
+
+func TestListFiles_FilesystemHonorsGitignore(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".gitignore"), "node_modules/\n*.log\n")
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	writeFile(t, filepath.Join(dir, "debug.log"), "log")
+	writeFile(t, filepath.Join(dir, "node_modules", "lib", "index.js"), "js")
+	writeFile(t, filepath.Join(dir, "web", ".gitignore"), "dist\n!important.log\n")
+	writeFile(t, filepath.Join(dir, "web", "dist", "bundle.js"), "js")
+	writeFile(t, filepath.Join(dir, "web", "app.js"), "js")
+	writeFile(t, filepath.Join(dir, "web", "important.log"), "log")
+	writeFile(t, filepath.Join(dir, "other", "dist", "keep.js"), "js")
+
+	paths, err := ListFiles(dir, Options{Strategy: "filesystem"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"main.go",
+		filepath.Join("other", "dist", "keep.js"),
+		filepath.Join("web", "app.js"),
+		filepath.Join("web", "important.log"),
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("ListFiles = %v, want %v", paths, want)
+	}
+}
+
+func TestListFiles_AncestorGitignore(t *testing.T) {
+	repo := t.TempDir()
+	writeFile(t, filepath.Join(repo, ".git", "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(repo, ".gitignore"), "*.gen.go\n")
+	writeFile(t, filepath.Join(repo, "pkg", "api.go"), "package pkg\n")
+	writeFile(t, filepath.Join(repo, "pkg", "api.gen.go"), "package pkg\n")
+
+	paths, err := ListFiles(filepath.Join(repo, "pkg"), Options{Strategy: "filesystem"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(paths, []string{"api.go"}) {
+		t.Errorf("expected the repository .gitignore to apply, got %v", paths)
+	}
+}
+
+func TestListFiles_Prmptignore(t *testing.T) {
+	repo := t.TempDir()
+	writeFile(t, filepath.Join(repo, ".git", "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(repo, PrmptignoreFile), "fixtures/\n!secrets.example.env\n")
+	writeFile(t, filepath.Join(repo, "src", "main.go"), "package main\n")
+	writeFile(t, filepath.Join(repo, "src", "fixtures", "big.json"), "{}")
+	writeFile(t, filepath.Join(repo, "src", "secrets.env"), "TOKEN=1")
+	writeFile(t, filepath.Join(repo, "src", "secrets.example.env"), "TOKEN=")
+
+	global := filepath.Join(t.TempDir(), PrmptignoreFile)
+	writeFile(t, global, "*.env\n")
+
+	paths, err := ListFiles(filepath.Join(repo, "src"), Options{Strategy: "filesystem", IgnoreFiles: []string{global}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"main.go", "secrets.example.env"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("ListFiles = %v, want %v", paths, want)
+	}
+}
+
+func TestListFiles_Exclude(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	writeFile(t, filepath.Join(dir, "main_test.go"), "package main\n")
+	writeFile(t, filepath.Join(dir, "testdata", "case.json"), "{}")
+	writeFile(t, filepath.Join(dir, "pkg", "util_test.go"), "package pkg\n")
+
+	paths, err := ListFiles(dir, Options{Strategy: "filesystem", Exclude: []string{"*_test.go", "testdata/"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(paths, []string{"main.go"}) {
+		t.Errorf("ListFiles = %v, want [main.go]", paths)
+	}
+}
+
+func TestGlob(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, PrmptignoreFile), "internal/\n")
+	writeFile(t, filepath.Join(dir, "api.proto"), "syntax = \"proto3\";\n")
+	writeFile(t, filepath.Join(dir, "proto", "v1", "user.proto"), "syntax = \"proto3\";\n")
+	writeFile(t, filepath.Join(dir, "internal", "secret.proto"), "syntax = \"proto3\";\n")
+	writeFile(t, filepath.Join(dir, "docs", "README.md"), "# Docs\n")
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{pattern: "**/*.proto", want: []string{"api.proto", "proto/v1/user.proto"}},
+		{pattern: "proto/*/*.proto", want: []string{"proto/v1/user.proto"}},
+		{pattern: "./docs/*.md", want: []string{"docs/README.md"}},
+		{pattern: "*.md", want: nil},
+	}
+	for _, tt := range tests {
+		got, err := Glob(dir, tt.pattern, Options{Strategy: "filesystem"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Glob(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+)
+
+// Outline returns a condensed view of source code that keeps declarations and their
+// doc comments but drops imports, function bodies, and composite literals. It reports
+// false when the language has no outline support or the source doesn't parse, in
+// which case the file should be included whole.
+func Outline(path string, src []byte) (string, bool) {
+	switch LanguageFor(path) {
+	case "go":
+		return outlineGo(path, src)
+	default:
+		return "", false
+	}
+}
+
+// outline replaces a file's contents with its outline, reporting false when the file
+// can't be outlined
+func (c *Collector) outline(file *File, data []byte) bool {
+	outline, ok := Outline(file.Path, data)
+	if !ok {
+		return false
+	}
+	file.Content = outline
+	file.Outline = true
+	file.Truncated = true
+	file.Note = fmt.Sprintf("outline of %d lines, declarations and doc comments only", file.TotalLines)
+	return true
+}
+
+// outlineGo outlines a Go file: the package clause, type definitions, constants,
+// variables with their types, and function signatures, each with its doc comment
+func outlineGo(path string, src []byte) (string, bool) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return "", false
+	}
+
+	// Comments inside removed bodies and values would otherwise be printed in their place
+	var removed []ast.Node
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Body != nil {
+				removed = append(removed, decl.Body)
+				decl.Body = nil
+			}
+		case *ast.GenDecl:
+			if decl.Tok == token.VAR {
+				for _, spec := range decl.Specs {
+					removed = append(removed, elideValues(spec.(*ast.ValueSpec))...)
+				}
+			}
+		}
+	}
+	var comments []*ast.CommentGroup
+	for _, group := range file.Comments {
+		if !within(group, removed) {
+			comments = append(comments, group)
+		}
+	}
+
+	var b strings.Builder
+	if file.Doc != nil {
+		b.WriteString(commentText(file.Doc))
+	}
+	b.WriteString("package " + file.Name.Name + "\n")
+
+	config := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := config.Fprint(&buf, fset, &printer.CommentedNode{Node: decl, Comments: comments}); err != nil {
+			return "", false
+		}
+		b.WriteString("\n" + buf.String() + "\n")
+	}
+	return b.String(), true
+}
+
+// elideValues replaces composite and function literal values in a var spec with
+// their types, since the signature is what matters in an outline, and returns the
+// removed values
+func elideValues(spec *ast.ValueSpec) []ast.Node {
+	if len(spec.Values) != 1 || len(spec.Names) != 1 {
+		return nil
+	}
+	value := spec.Values[0]
+	var typ ast.Expr
+	switch literal := value.(type) {
+	case *ast.CompositeLit:
+		typ = literal.Type
+	case *ast.FuncLit:
+		typ = literal.Type
+	}
+	if typ == nil {
+		return nil
+	}
+	if spec.Type == nil {
+		spec.Type = typ
+	}
+	spec.Values = nil
+	return []ast.Node{value}
+}
+
+// within reports whether a comment lies inside any of the nodes
+func within(group *ast.CommentGroup, nodes []ast.Node) bool {
+	for _, node := range nodes {
+		if group.Pos() >= node.Pos() && group.End() <= node.End() {
+			return true
+		}
+	}
+	return false
+}
+
+// commentText returns a comment group as it appears in the source
+func commentText(group *ast.CommentGroup) string {
+	var b strings.Builder
+	for _, comment := range group.List {
+		b.WriteString(comment.Text + "\n")
+	}
+	return b.String()
+}
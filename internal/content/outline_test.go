@@ -0,0 +1,106 @@
+package content
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const outlineSource = `// Package shapes measures shapes.
+package shapes
+
+import (
+	"fmt"
+	"math"
+)
+
+// Scale multiplies every dimension
+const Scale = 2
+
+// names maps kinds to labels
+var names = map[string]string{
+	"circle": "Circle", // round
+}
+
+var count int
+
+// Circle is a round shape
+type Circle struct {
+	Radius float64 // Distance from the center
+}
+
+// Area returns the area of the circle
+func (c Circle) Area() float64 {
+	// Pi times the radius squared
+	return math.Pi * c.Radius * c.Radius
+}
+
+func describe(c Circle) string {
+	return fmt.Sprintf("%s of %v", names["circle"], c.Area())
+}
+`
+
+const outlineWant = `// Package shapes measures shapes.
+package shapes
+
+// Scale multiplies every dimension
+const Scale = 2
+
+// names maps kinds to labels
+var names map[string]string
+
+var count int
+
+// Circle is a round shape
+type Circle struct {
+	Radius float64 // Distance from the center
+}
+
+// Area returns the area of the circle
+func (c Circle) Area() float64
+
+func describe(c Circle) string
+`
+
+func TestOutline(t *testing.T) {
+	got, ok := Outline("shapes.go", []byte(outlineSource))
+	if !ok {
+		t.Fatal("expected Go source to be outlined")
+	}
+	if got != outlineWant {
+		t.Errorf("Outline =\n%s\nwant\n%s", got, outlineWant)
+	}
+
+	if _, ok := Outline("shapes.go", []byte("package shapes\nfunc {")); ok {
+		t.Error("expected source that doesn't parse not to be outlined")
+	}
+	if _, ok := Outline("shapes.py", []byte("def area(): pass\n")); ok {
+		t.Error("expected an unsupported language not to be outlined")
+	}
+}
+
+func TestCollector_Outline(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "shapes.go"), outlineSource)
+	writeFile(t, filepath.Join(dir, "notes.md"), "# Notes\n")
+
+	collector := NewCollector(Options{Strategy: "filesystem", Outline: true})
+	files, _, err := collector.Collect(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("got %d files, want 2: %+v", len(files), files)
+	}
+
+	notes, shapes := files[0], files[1]
+	if notes.Outline || notes.Content != "# Notes\n" {
+		t.Errorf("expected notes.md to be included whole: %+v", notes)
+	}
+	if !shapes.Outline || shapes.Content != outlineWant || !strings.HasPrefix(shapes.Note, "outline of 32 lines") {
+		t.Errorf("unexpected outlined file: %+v", shapes)
+	}
+	if len(shapes.Ranges) != 0 {
+		t.Errorf("expected no line ranges for an outline, got %v", shapes.Ranges)
+	}
+}
@@ -0,0 +1,147 @@
+package content
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// Packing methods reported in Packing.Method
+const (
+	MethodAll      = "all"      // Everything fit, no selection needed
+	MethodKnapsack = "knapsack" // Exact 0/1 knapsack over scaled token costs
+	MethodGreedy   = "greedy"   // Score-per-token greedy for inputs too large for the exact solver
+)
+
+// knapsackBuckets bounds the capacity dimension of the knapsack table; token costs
+// are scaled down so the table never exceeds this width
+const knapsackBuckets = 4096
+
+// maxKnapsackCells bounds the size of the knapsack table before falling back to greedy
+const maxKnapsackCells = 4_000_000
+
+// Packing is the result of fitting files into a token budget
+type Packing struct {
+	Selected  []File // Files to include, in collection order
+	Dropped   []File // Files left out, in collection order
+	Shortened []File // Selected files that were cut to fit the budget
+	Tokens    int    // Total tokens of the selected files
+	Budget    int    // Token budget, 0 when unlimited
+	Method    string // How the selection was made
+}
+
+// Pack chooses the subset of files with the highest total relevance whose token
+// cost fits within budget. A budget of 0 or less means unlimited.
+func Pack(files []File, budget int) Packing {
+	packing := Packing{Budget: budget, Method: MethodAll}
+
+	total := 0
+	for _, file := range files {
+		total += file.Tokens
+	}
+	if budget <= 0 || total <= budget {
+		packing.Selected = append(packing.Selected, files...)
+		packing.Tokens = total
+		return packing
+	}
+
+	var chosen []bool
+	scale := (budget + knapsackBuckets - 1) / knapsackBuckets
+	capacity := budget / scale
+	if len(files)*(capacity+1) <= maxKnapsackCells {
+		chosen = knapsack(files, capacity, scale)
+		packing.Method = MethodKnapsack
+	} else {
+		chosen = greedy(files, budget)
+		packing.Method = MethodGreedy
+	}
+
+	for i, file := range files {
+		if chosen[i] {
+			packing.Selected = append(packing.Selected, file)
+			packing.Tokens += file.Tokens
+		} else {
+			slog.Debug("dropped file to fit the token budget", "path", file.Path, "tokens", file.Tokens, "method", packing.Method)
+			packing.Dropped = append(packing.Dropped, file)
+		}
+	}
+
+	return packing
+}
+
+// knapsack solves 0/1 knapsack with costs rounded up to multiples of scale, so the
+// selection never exceeds the real budget
+func knapsack(files []File, capacity, scale int) []bool {
+	costs := make([]int, len(files))
+	for i, file := range files {
+		costs[i] = (file.Tokens + scale - 1) / scale
+	}
+
+	// best[c] is the highest score achievable with cost c; take[i][c] records the choice
+	best := make([]float64, capacity+1)
+	take := make([][]bool, len(files))
+	for i := range files {
+		take[i] = make([]bool, capacity+1)
+		for c := capacity; c >= costs[i]; c-- {
+			if candidate := best[c-costs[i]] + files[i].Score; candidate > best[c] {
+				best[c] = candidate
+				take[i][c] = true
+			}
+		}
+	}
+
+	chosen := make([]bool, len(files))
+	c := capacity
+	for i := len(files) - 1; i >= 0; i-- {
+		if take[i][c] {
+			chosen[i] = true
+			c -= costs[i]
+		}
+	}
+	return chosen
+}
+
+// greedy picks files by descending score per token until the budget is used
+func greedy(files []File, budget int) []bool {
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+	}
+	density := func(file File) float64 {
+		return file.Score / float64(file.Tokens+1)
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return density(files[order[a]]) > density(files[order[b]])
+	})
+
+	chosen := make([]bool, len(files))
+	remaining := budget
+	for _, i := range order {
+		if files[i].Tokens <= remaining {
+			chosen[i] = true
+			remaining -= files[i].Tokens
+		}
+	}
+	return chosen
+}
+
+// Report describes the packing decision in a few human-readable lines
+func (p Packing) Report() string {
+	var b strings.Builder
+
+	if p.Budget > 0 {
+		fmt.Fprintf(&b, "Context: %d of %d files, %d/%d tokens (%s)", len(p.Selected), len(p.Selected)+len(p.Dropped), p.Tokens, p.Budget, p.Method)
+	} else {
+		fmt.Fprintf(&b, "Context: %d files, %d tokens (no budget)", len(p.Selected), p.Tokens)
+	}
+
+	for _, file := range p.Shortened {
+		fmt.Fprintf(&b, "\n  truncated %s (%s)", file.Path, file.Note)
+	}
+	for _, file := range p.Dropped {
+		fmt.Fprintf(&b, "\n  dropped %s (%d tokens, relevance %.2f)", file.Path, file.Tokens, file.Score)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package content
+
+import (
+	"strings"
+	"testing"
+)
+
+func paths(files []File) string {
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Path)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestPack_NoBudget(t *testing.T) {
+	files := []File{{Path: "a", Tokens: 100}, {Path: "b", Tokens: 200}}
+
+	packing := Pack(files, 0)
+	if packing.Method != MethodAll || len(packing.Selected) != 2 || packing.Tokens != 300 {
+		t.Errorf("unexpected packing: %+v", packing)
+	}
+}
+
+func TestPack_KnapsackBeatsFirstCome(t *testing.T) {
+	// Taking files in order would keep only "big"; the best subset is the two small ones
+	files := []File{
+		{Path: "big", Tokens: 80, Score: 5},
+		{Path: "small1", Tokens: 50, Score: 4},
+		{Path: "small2", Tokens: 50, Score: 4},
+	}
+
+	packing := Pack(files, 100)
+	if packing.Method != MethodKnapsack {
+		t.Errorf("Method = %s, want %s", packing.Method, MethodKnapsack)
+	}
+	if got := paths(packing.Selected); got != "small1,small2" {
+		t.Errorf("Selected = %s, want small1,small2", got)
+	}
+	if got := paths(packing.Dropped); got != "big" {
+		t.Errorf("Dropped = %s, want big", got)
+	}
+	if packing.Tokens > packing.Budget {
+		t.Errorf("Tokens %d exceed budget %d", packing.Tokens, packing.Budget)
+	}
+}
+
+func TestPack_ScaledCostsStayWithinBudget(t *testing.T) {
+	var files []File
+	for i := 0; i < 50; i++ {
+		files = append(files, File{Path: string(rune('a' + i%26)), Tokens: 997 + i*13, Score: float64(i%7 + 1)})
+	}
+
+	packing := Pack(files, 20000)
+	if packing.Tokens > 20000 {
+		t.Errorf("Tokens %d exceed budget", packing.Tokens)
+	}
+	if len(packing.Selected)+len(packing.Dropped) != len(files) {
+		t.Errorf("files lost during packing")
+	}
+}
+
+func TestGreedy(t *testing.T) {
+	files := []File{
+		{Path: "dense", Tokens: 10, Score: 5},
+		{Path: "sparse", Tokens: 100, Score: 6},
+		{Path: "medium", Tokens: 20, Score: 4},
+	}
+
+	chosen := greedy(files, 40)
+	if !chosen[0] || chosen[1] || !chosen[2] {
+		t.Errorf("chosen = %v, want [true false true]", chosen)
+	}
+}
+
+func TestPacking_Report(t *testing.T) {
+	packing := Pack([]File{
+		{Path: "keep.go", Tokens: 10, Score: 3},
+		{Path: "drop.go", Tokens: 50, Score: 1},
+	}, 20)
+
+	report := packing.Report()
+	if !strings.Contains(report, "1 of 2 files") || !strings.Contains(report, "dropped drop.go (50 tokens") {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+}
+
+func TestScore(t *testing.T) {
+	files := []File{
+		{Path: "internal/auth/login.go", Content: "func Login() {}"},
+		{Path: "internal/cache/cache.go", Content: "package cache"},
+		{Path: "main.go", Explicit: true},
+	}
+
+	Score(files, "Why does the login flow fail?")
+
+	if files[0].Score <= files[1].Score {
+		t.Errorf("expected login.go (%v) to outscore cache.go (%v)", files[0].Score, files[1].Score)
+	}
+	if files[2].Score <= files[0].Score {
+		t.Errorf("expected explicit main.go (%v) to outscore login.go (%v)", files[2].Score, files[0].Score)
+	}
+}
@@ -0,0 +1,242 @@
+package content
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"prompter-cli/internal/tokenizer"
+)
+
+// Budget strategies selectable with the budget_strategy setting
+const (
+	StrategyPriority  = "priority"  // Explicit, then git-changed, then other files, newest first
+	StrategyRelevance = "relevance" // Highest total relevance to the prompt, see Pack
+)
+
+// Packing methods reported in Packing.Method by Prioritize and FitInOrder
+const (
+	MethodPriority = "priority" // Files were taken in priority order
+	MethodInOrder  = "in order" // Files were taken in the order given
+)
+
+// minTruncateTokens is the smallest remaining budget worth filling with part of a file;
+// below it the file is dropped instead
+const minTruncateTokens = 64
+
+// priorityTier ranks a file: explicit files first, then files with uncommitted changes
+func priorityTier(file File) int {
+	switch {
+	case file.Explicit:
+		return 0
+	case file.Changed:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Prioritize fits files into budget in priority order: files named with --file, then
+// files with uncommitted git changes, then the rest, each group most recently modified
+// first. Files are kept whole while they fit; one that doesn't is cut to the remaining
+// budget when enough is left and dropped otherwise. A budget of 0 or less means
+// unlimited. Selected files stay in collection order.
+func Prioritize(files []File, budget int, tok tokenizer.Tokenizer) Packing {
+	packing := Packing{Budget: budget, Method: MethodAll}
+
+	total := 0
+	for _, file := range files {
+		total += file.Tokens
+	}
+	if budget <= 0 || total <= budget {
+		packing.Selected = append(packing.Selected, files...)
+		packing.Tokens = total
+		return packing
+	}
+	packing.Method = MethodPriority
+
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		fa, fb := files[order[a]], files[order[b]]
+		if ta, tb := priorityTier(fa), priorityTier(fb); ta != tb {
+			return ta < tb
+		}
+		return fa.ModTime.After(fb.ModTime)
+	})
+	return fit(packing, files, order, tok)
+}
+
+// FitInOrder fits files into budget the way Prioritize does, but ranks them in the
+// order given, such as the frames of a stack trace
+func FitInOrder(files []File, budget int, tok tokenizer.Tokenizer) Packing {
+	packing := Packing{Budget: budget, Method: MethodAll}
+
+	total := 0
+	for _, file := range files {
+		total += file.Tokens
+	}
+	if budget <= 0 || total <= budget {
+		packing.Selected = append(packing.Selected, files...)
+		packing.Tokens = total
+		return packing
+	}
+	packing.Method = MethodInOrder
+
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+	}
+	return fit(packing, files, order, tok)
+}
+
+// fit keeps files whole in the ranking given by order while they fit the packing's
+// budget, cutting the first that doesn't when enough is left and dropping the rest.
+// Selected files stay in collection order.
+func fit(packing Packing, files []File, order []int, tok tokenizer.Tokenizer) Packing {
+	if tok == nil {
+		tok = tokenizer.NewApproximate()
+	}
+
+	kept := make([]*File, len(files))
+	remaining := packing.Budget
+	for _, i := range order {
+		file := files[i]
+		switch {
+		case file.Tokens <= remaining:
+			kept[i] = &file
+		case remaining >= minTruncateTokens:
+			if cut, ok := truncateToBudget(file, remaining, tok); ok {
+				kept[i] = &cut
+			}
+		}
+		if kept[i] != nil {
+			remaining -= kept[i].Tokens
+		}
+	}
+
+	for i, file := range files {
+		if kept[i] == nil {
+			packing.Dropped = append(packing.Dropped, file)
+			continue
+		}
+		if kept[i].Tokens < file.Tokens {
+			packing.Shortened = append(packing.Shortened, *kept[i])
+		}
+		packing.Selected = append(packing.Selected, *kept[i])
+		packing.Tokens += kept[i].Tokens
+	}
+
+	return packing
+}
+
+// truncateToBudget keeps whole lines from the top of a file's content while they fit
+// within budget tokens, reporting false when not even the first line fits
+func truncateToBudget(file File, budget int, tok tokenizer.Tokenizer) (File, bool) {
+	lines := strings.SplitAfter(file.Content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var b strings.Builder
+	used, kept := 0, 0
+	for _, line := range lines {
+		cost := tok.Count(line)
+		if used+cost > budget {
+			break
+		}
+		b.WriteString(line)
+		used += cost
+		kept++
+	}
+	if kept == 0 {
+		return file, false
+	}
+
+	file.Content = b.String()
+	file.Tokens = used
+	if file.Outline {
+		// Outline lines don't map to lines of the file
+		file.Note = fmt.Sprintf("showing the first %d lines of the outline to fit the token budget", kept)
+		return file, true
+	}
+	if file.Truncated {
+		file.Ranges = clipRanges(file.Ranges, kept)
+	} else {
+		file.Ranges = []LineRange{{Start: 1, End: kept}}
+	}
+	file.Truncated = true
+	file.Note = fmt.Sprintf("showing lines %s of %d to fit the token budget", formatRanges(file.Ranges), file.TotalLines)
+	return file, true
+}
+
+// clipRanges returns the ranges of an excerpt built by joinRanges that are covered by
+// its first kept lines, counting the "..." marker lines between ranges
+func clipRanges(ranges []LineRange, kept int) []LineRange {
+	var clipped []LineRange
+	pos := 0
+	for i, r := range ranges {
+		if i > 0 || r.Start > 1 {
+			pos++ // "..." marker
+		}
+		if pos >= kept {
+			break
+		}
+		n := r.End - r.Start + 1
+		if pos+n > kept {
+			clipped = append(clipped, LineRange{Start: r.Start, End: r.Start + kept - pos - 1})
+			break
+		}
+		clipped = append(clipped, r)
+		pos += n
+	}
+	return clipped
+}
+
+// Summary describes what was left out of the prompt, for appending after the embedded
+// files so the reader knows the context is incomplete. It is empty when nothing was.
+func (p Packing) Summary() string {
+	if len(p.Dropped) == 0 && len(p.Shortened) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Some content was left out to fit the %d-token budget:", p.Budget)
+	for _, file := range p.Shortened {
+		fmt.Fprintf(&b, "\n- %s was truncated (%s)", file.Path, file.Note)
+	}
+	for _, file := range p.Dropped {
+		fmt.Fprintf(&b, "\n- %s was omitted (%d tokens)", file.Path, file.Tokens)
+	}
+	return b.String()
+}
+
+// changedFiles returns the absolute paths of files with uncommitted changes, including
+// untracked ones, in the repository at root
+func changedFiles(root string) map[string]bool {
+	cmd := exec.Command("git", "status", "--porcelain=v1", "-z", "--untracked-files=all")
+	cmd.Dir = root
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	changed := make(map[string]bool)
+	entries := strings.Split(string(output), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		changed[filepath.Join(root, filepath.FromSlash(entry[3:]))] = true
+		// Renames and copies are followed by the original path
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++
+		}
+	}
+	return changed
+}
@@ -0,0 +1,158 @@
+package content
+
+import (
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"prompter-cli/internal/tokenizer"
+)
+
+func TestPrioritize_Order(t *testing.T) {
+	now := time.Now()
+	files := []File{
+		{Path: "old.go", Tokens: 40, ModTime: now.Add(-time.Hour)},
+		{Path: "new.go", Tokens: 40, ModTime: now},
+		{Path: "changed.go", Tokens: 40, Changed: true, ModTime: now.Add(-2 * time.Hour)},
+		{Path: "explicit.go", Tokens: 40, Explicit: true, ModTime: now.Add(-3 * time.Hour)},
+	}
+
+	packing := Prioritize(files, 120, tokenizer.NewApproximate())
+	if packing.Method != MethodPriority {
+		t.Errorf("Method = %q", packing.Method)
+	}
+	// Explicit, then changed, then the newest of the rest; selection keeps collection order
+	if got := paths(packing.Selected); got != "new.go,changed.go,explicit.go" {
+		t.Errorf("Selected = %s", got)
+	}
+	if got := paths(packing.Dropped); got != "old.go" {
+		t.Errorf("Dropped = %s", got)
+	}
+}
+
+func TestFitInOrder(t *testing.T) {
+	now := time.Now()
+	files := []File{
+		{Path: "handler.py", Tokens: 40, Explicit: true, ModTime: now.Add(-time.Hour)},
+		{Path: "service.py", Tokens: 40, Explicit: true, ModTime: now},
+		{Path: "db.py", Tokens: 40, Explicit: true, ModTime: now},
+	}
+
+	// The first files are kept whatever their modification times
+	packing := FitInOrder(files, 80, tokenizer.NewApproximate())
+	if packing.Method != MethodInOrder {
+		t.Errorf("Method = %q", packing.Method)
+	}
+	if got := paths(packing.Selected); got != "handler.py,service.py" {
+		t.Errorf("Selected = %s", got)
+	}
+	if got := paths(packing.Dropped); got != "db.py" {
+		t.Errorf("Dropped = %s", got)
+	}
+
+	if packing := FitInOrder(files, 0, nil); packing.Method != MethodAll || len(packing.Selected) != 3 {
+		t.Errorf("expected everything without a budget, got %+v", packing)
+	}
+}
+
+func TestPrioritize_Truncates(t *testing.T) {
+	bytes, _ := tokenizer.New(tokenizer.Bytes, "")
+	content := strings.Repeat("0123456789abcde\n", 40) // 40 lines of 4 tokens
+	files := []File{
+		{Path: "explicit.go", Content: "x", Tokens: 20, Explicit: true},
+		{Path: "big.go", Content: content, Tokens: 160, TotalLines: 40},
+	}
+
+	packing := Prioritize(files, 100, bytes)
+	if len(packing.Selected) != 2 || len(packing.Shortened) != 1 || len(packing.Dropped) != 0 {
+		t.Fatalf("unexpected packing: %+v", packing)
+	}
+	big := packing.Selected[1]
+	if !big.Truncated || big.Tokens != 80 || strings.Count(big.Content, "\n") != 20 {
+		t.Errorf("expected big.go cut to 20 lines, got %d tokens:\n%s", big.Tokens, big.Content)
+	}
+	if big.Note != "showing lines 1-20 of 40 to fit the token budget" {
+		t.Errorf("Note = %q", big.Note)
+	}
+	if packing.Tokens > 100 {
+		t.Errorf("Tokens = %d, over budget", packing.Tokens)
+	}
+
+	summary := packing.Summary()
+	if !strings.Contains(summary, "100-token budget") || !strings.Contains(summary, "big.go was truncated") {
+		t.Errorf("unexpected summary:\n%s", summary)
+	}
+}
+
+func TestPrioritize_DropsWhenTooLittleLeft(t *testing.T) {
+	files := []File{
+		{Path: "a.go", Tokens: 90, Explicit: true},
+		{Path: "b.go", Content: strings.Repeat("line\n", 100), Tokens: 200},
+	}
+
+	packing := Prioritize(files, 100, tokenizer.NewApproximate())
+	if paths(packing.Dropped) != "b.go" || len(packing.Shortened) != 0 {
+		t.Errorf("expected b.go to be dropped, got %+v", packing)
+	}
+	if !strings.Contains(packing.Summary(), "b.go was omitted (200 tokens)") {
+		t.Errorf("unexpected summary:\n%s", packing.Summary())
+	}
+	if Prioritize(files, 0, nil).Summary() != "" {
+		t.Error("expected no summary without a budget")
+	}
+}
+
+func TestClipRanges(t *testing.T) {
+	// Excerpt: "...", lines 5-7, "...", lines 20-25
+	ranges := []LineRange{{Start: 5, End: 7}, {Start: 20, End: 25}}
+	tests := map[int][]LineRange{
+		1:  nil,
+		3:  {{Start: 5, End: 6}},
+		5:  {{Start: 5, End: 7}},
+		7:  {{Start: 5, End: 7}, {Start: 20, End: 21}},
+		20: ranges,
+	}
+	for kept, want := range tests {
+		if got := clipRanges(ranges, kept); !reflect.DeepEqual(got, want) {
+			t.Errorf("clipRanges(%d) = %v, want %v", kept, got, want)
+		}
+	}
+}
+
+func TestCollector_Changed(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	writeFile(t, filepath.Join(dir, "clean.go"), "package main\n")
+	writeFile(t, filepath.Join(dir, "edited.go"), "package main\n")
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	writeFile(t, filepath.Join(dir, "edited.go"), "package main\n\nfunc main() {}\n")
+	writeFile(t, filepath.Join(dir, "new", "added.go"), "package new\n")
+
+	files, _, err := NewCollector(Options{Strategy: "filesystem"}).Collect(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := make(map[string]bool)
+	for _, file := range files {
+		changed[file.Path] = file.Changed
+	}
+	want := map[string]bool{"clean.go": false, "edited.go": true, filepath.Join("new", "added.go"): true}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("changed = %v, want %v", changed, want)
+	}
+}
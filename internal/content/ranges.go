@@ -0,0 +1,57 @@
+package content
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// lineSuffix matches the ":start-end" or ":line" suffix of a file spec
+var lineSuffix = regexp.MustCompile(`^(.+):(\d+)(?:-(\d+))?$`)
+
+// SplitFileSpec separates a --file value of the form path:start-end or path:line into
+// the path and the requested lines, which are nil when the whole file is requested.
+// A path that exists as given is never split, so file names with colons keep working.
+func SplitFileSpec(spec string) (string, *LineRange, error) {
+	match := lineSuffix.FindStringSubmatch(spec)
+	if match == nil {
+		return spec, nil, nil
+	}
+	if _, err := os.Stat(spec); err == nil {
+		return spec, nil, nil
+	}
+
+	start, _ := strconv.Atoi(match[2])
+	end := start
+	if match[3] != "" {
+		end, _ = strconv.Atoi(match[3])
+	}
+	if start < 1 || end < start {
+		return "", nil, fmt.Errorf("invalid line range %s in %s", strings.TrimPrefix(spec, match[1]+":"), spec)
+	}
+	return match[1], &LineRange{Start: start, End: end}, nil
+}
+
+// selectLines cuts a file down to the requested lines plus context lines around each,
+// reporting false when none of them are in the file
+func selectLines(file *File, requested []LineRange, context int) bool {
+	lines := strings.SplitAfter(file.Content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	ranges := expandRanges(requested, context, len(lines))
+	if len(ranges) == 0 {
+		return false
+	}
+	if len(ranges) == 1 && ranges[0].Start == 1 && ranges[0].End == len(lines) {
+		return true // The ranges cover the whole file, so it's included as is
+	}
+	file.Content = joinRanges(lines, ranges)
+	file.Ranges = ranges
+	file.Truncated = true
+	file.Note = fmt.Sprintf("showing lines %s of %d", formatRanges(ranges), len(lines))
+	return true
+}
@@ -0,0 +1,93 @@
+package content
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitFileSpec(t *testing.T) {
+	dir := t.TempDir()
+	colon := filepath.Join(dir, "notes:12")
+	writeFile(t, colon, "kept whole\n")
+
+	tests := []struct {
+		spec  string
+		path  string
+		lines *LineRange
+	}{
+		{spec: "main.go", path: "main.go"},
+		{spec: "main.go:100-180", path: "main.go", lines: &LineRange{Start: 100, End: 180}},
+		{spec: "main.go:42", path: "main.go", lines: &LineRange{Start: 42, End: 42}},
+		{spec: "main.go:abc", path: "main.go:abc"},
+		{spec: colon, path: colon},
+	}
+	for _, tt := range tests {
+		path, lines, err := SplitFileSpec(tt.spec)
+		if err != nil {
+			t.Errorf("SplitFileSpec(%q) failed: %v", tt.spec, err)
+			continue
+		}
+		if path != tt.path || (lines == nil) != (tt.lines == nil) || (lines != nil && *lines != *tt.lines) {
+			t.Errorf("SplitFileSpec(%q) = %q, %v, want %q, %v", tt.spec, path, lines, tt.path, tt.lines)
+		}
+	}
+
+	for _, spec := range []string{"main.go:180-100", "main.go:0"} {
+		if _, _, err := SplitFileSpec(spec); err == nil {
+			t.Errorf("expected SplitFileSpec(%q) to fail", spec)
+		}
+	}
+}
+
+func TestCollector_LineRanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lines.txt")
+	var b strings.Builder
+	for i := 1; i <= 20; i++ {
+		b.WriteString("line " + strings.Repeat("x", i) + "\n")
+	}
+	writeFile(t, path, b.String())
+	other := filepath.Join(dir, "other.txt")
+	writeFile(t, other, "one\ntwo\n")
+
+	collector := NewCollector(Options{Strategy: "filesystem", RangeContext: 1})
+	files, skipped, err := collector.Collect([]string{path + ":5-6", path + ":15", other, other + ":1", other + ":9"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 0 {
+		t.Errorf("unexpected skipped files: %+v", skipped)
+	}
+	if len(files) != 2 {
+		t.Fatalf("got %d files, want 2: %+v", len(files), files)
+	}
+
+	lines := files[0]
+	want := []LineRange{{Start: 4, End: 7}, {Start: 14, End: 16}}
+	if len(lines.Ranges) != 2 || lines.Ranges[0] != want[0] || lines.Ranges[1] != want[1] {
+		t.Errorf("Ranges = %v, want %v", lines.Ranges, want)
+	}
+	if !lines.Truncated || lines.Note != "showing lines 4-7, 14-16 of 20" || !strings.HasPrefix(lines.Content, "...\nline xxxx\n") {
+		t.Errorf("unexpected excerpt: %+v", lines)
+	}
+	if files[1].Truncated || files[1].Content != "one\ntwo\n" {
+		t.Errorf("expected a file also requested whole to be complete: %+v", files[1])
+	}
+
+	files, _, err = collector.Collect([]string{other + ":2"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Truncated || files[0].Note != "" || files[0].Content != "one\ntwo\n" {
+		t.Errorf("expected a range covering the whole file to include it as is: %+v", files)
+	}
+
+	_, skipped, err = collector.Collect([]string{other + ":9-12"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 1 || !strings.Contains(skipped[0].Reason, "past the end") {
+		t.Errorf("expected a range past the end to be skipped, got %+v", skipped)
+	}
+}
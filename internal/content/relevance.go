@@ -0,0 +1,69 @@
+package content
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Relevance weights. Every file starts with a base score so that, with no other
+// signal, the packer still prefers fitting more files over fewer.
+const (
+	baseScore          = 1.0
+	explicitBonus      = 10.0 // Files named with --file matter most
+	pathMatchWeight    = 3.0  // Per query term found in the path
+	contentMatchWeight = 0.25 // Per query term occurrence in the content
+	maxContentMatches  = 8    // Occurrences counted per term, so long files don't dominate
+)
+
+// stopWords are ignored when extracting query terms
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "this": true, "that": true,
+	"from": true, "into": true, "are": true, "was": true, "how": true, "what": true,
+	"why": true, "can": true, "you": true, "please": true, "make": true, "should": true,
+}
+
+// Score sets the relevance score of each file for the given query (usually the base prompt)
+func Score(files []File, query string) {
+	terms := queryTerms(query)
+
+	for i := range files {
+		score := baseScore
+		if files[i].Explicit {
+			score += explicitBonus
+		}
+
+		path := strings.ToLower(filepath.ToSlash(files[i].Path))
+		content := strings.ToLower(files[i].Content)
+		for _, term := range terms {
+			if strings.Contains(path, term) {
+				score += pathMatchWeight
+			}
+			matches := strings.Count(content, term)
+			if matches > maxContentMatches {
+				matches = maxContentMatches
+			}
+			score += float64(matches) * contentMatchWeight
+		}
+
+		files[i].Score = score
+	}
+}
+
+// queryTerms splits a query into distinct lowercase words of three or more characters
+func queryTerms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+
+	var terms []string
+	seen := make(map[string]bool)
+	for _, word := range words {
+		if len(word) < 3 || stopWords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return terms
+}
@@ -0,0 +1,162 @@
+package content
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CollectSymbols finds the Go functions, methods, and types named by symbols in the
+// files under dir and reads each with its doc comment. A symbol is written Name,
+// pkg.Name, Type.Method, or pkg.Type.Method, where pkg is the package name, and every
+// declaration it matches is collected. Symbols that match nothing are returned in the
+// skipped list.
+func (c *Collector) CollectSymbols(symbols []string, dir string) ([]File, []Skipped, error) {
+	if len(symbols) == 0 {
+		return nil, nil, nil
+	}
+	paths, err := c.listDirectory(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var collected []File
+	var skipped []Skipped
+	found := make(map[string]bool)
+	for _, rel := range paths {
+		if filepath.Ext(rel) != ".go" {
+			continue
+		}
+		path := filepath.Join(dir, rel)
+		src, err := os.ReadFile(path)
+		if err != nil || !mentionsAny(src, symbols) {
+			continue
+		}
+		fset := token.NewFileSet()
+		parsed, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+
+		for _, decl := range goDeclarations(fset, parsed) {
+			for _, symbol := range symbols {
+				if !decl.matches(symbol) {
+					continue
+				}
+				absPath, err := filepath.Abs(path)
+				if err != nil {
+					absPath = path
+				}
+				file, reason := c.readFile(rel, absPath, true, []LineRange{decl.lines})
+				if reason != "" {
+					skipped = append(skipped, Skipped{Path: symbol, Reason: reason})
+					continue
+				}
+				file.Explicit = true
+				file.Note = fmt.Sprintf("showing %s at lines %s of %d", symbol, formatRanges(file.Ranges), file.TotalLines)
+				collected = append(collected, file)
+				found[symbol] = true
+			}
+		}
+	}
+
+	for _, symbol := range symbols {
+		if !found[symbol] {
+			skipped = append(skipped, Skipped{Path: symbol, Reason: "no Go function, method, or type by that name"})
+		}
+	}
+	return collected, skipped, nil
+}
+
+// goDeclaration is a function, method, or type declared in a Go file
+type goDeclaration struct {
+	pkg      string    // Package name
+	receiver string    // Receiver type name for methods
+	name     string    // Function, method, or type name
+	lines    LineRange // Lines of the declaration and its doc comment
+}
+
+// matches reports whether a symbol names the declaration
+func (d goDeclaration) matches(symbol string) bool {
+	name := d.name
+	if d.receiver != "" {
+		name = d.receiver + "." + d.name
+	}
+	return symbol == name || symbol == d.pkg+"."+name
+}
+
+// goDeclarations lists the functions, methods, and types declared in a file
+func goDeclarations(fset *token.FileSet, file *ast.File) []goDeclaration {
+	lines := func(doc *ast.CommentGroup, node ast.Node) LineRange {
+		start := node.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		return LineRange{Start: fset.Position(start).Line, End: fset.Position(node.End()).Line}
+	}
+
+	var declarations []goDeclaration
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			declarations = append(declarations, goDeclaration{
+				pkg:      file.Name.Name,
+				receiver: receiverName(decl),
+				name:     decl.Name.Name,
+				lines:    lines(decl.Doc, decl),
+			})
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.TypeSpec)
+				// A lone type keeps its "type" keyword; grouped types are taken alone
+				r := lines(spec.Doc, spec)
+				if !decl.Lparen.IsValid() {
+					r = lines(decl.Doc, decl)
+				}
+				declarations = append(declarations, goDeclaration{pkg: file.Name.Name, name: spec.Name.Name, lines: r})
+			}
+		}
+	}
+	return declarations
+}
+
+// receiverName returns the type name of a method's receiver, or "" for functions
+func receiverName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return ""
+	}
+	expr := decl.Recv.List[0].Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr: // Generic receivers such as List[T]
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// mentionsAny reports whether src contains the last part of any symbol, to skip
+// parsing files that can't declare it
+func mentionsAny(src []byte, symbols []string) bool {
+	text := string(src)
+	for _, symbol := range symbols {
+		if strings.Contains(text, symbol[strings.LastIndex(symbol, ".")+1:]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package content
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const symbolSource = `package server
+
+import "net/http"
+
+type (
+	// Handler serves requests
+	Handler struct{}
+
+	// Option configures a Handler
+	Option func(*Handler)
+)
+
+// Config holds settings
+type Config struct {
+	Addr string
+}
+
+// HandleRequest answers a request
+func HandleRequest(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	HandleRequest(w, r)
+}
+`
+
+func TestCollector_CollectSymbols(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "server", "server.go"), symbolSource)
+	writeFile(t, filepath.Join(dir, "README.md"), "HandleRequest\n")
+
+	collector := NewCollector(Options{Strategy: "filesystem"})
+	files, skipped, err := collector.CollectSymbols([]string{"server.HandleRequest", "Handler.ServeHTTP", "server.Option", "Config", "Missing"}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(skipped) != 1 || skipped[0].Path != "Missing" {
+		t.Errorf("expected only Missing to be skipped, got %+v", skipped)
+	}
+	if len(files) != 4 {
+		t.Fatalf("got %d files, want 4: %+v", len(files), files)
+	}
+
+	// Declarations are collected in file order
+	want := []struct {
+		lines   LineRange
+		content string
+	}{
+		{LineRange{Start: 9, End: 10}, "...\n\t// Option configures a Handler\n\tOption func(*Handler)\n...\n"},
+		{LineRange{Start: 13, End: 16}, "...\n// Config holds settings\ntype Config struct {\n\tAddr string\n}\n...\n"},
+		{LineRange{Start: 18, End: 21}, "...\n// HandleRequest answers a request\nfunc HandleRequest(w http.ResponseWriter, r *http.Request) {\n\tw.WriteHeader(http.StatusOK)\n}\n...\n"},
+		{LineRange{Start: 23, End: 26}, "...\n// ServeHTTP implements http.Handler\nfunc (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {\n\tHandleRequest(w, r)\n}\n"},
+	}
+	for i, file := range files {
+		if file.Path != filepath.Join("server", "server.go") || !file.Explicit {
+			t.Errorf("unexpected file %d: %+v", i, file)
+		}
+		if len(file.Ranges) != 1 || file.Ranges[0] != want[i].lines {
+			t.Errorf("file %d Ranges = %v, want %v", i, file.Ranges, want[i].lines)
+		}
+		if file.Content != want[i].content {
+			t.Errorf("file %d Content = %q, want %q", i, file.Content, want[i].content)
+		}
+	}
+	if !strings.HasPrefix(files[2].Note, "showing server.HandleRequest at lines 18-21") {
+		t.Errorf("Note = %q", files[2].Note)
+	}
+}
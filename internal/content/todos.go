@@ -0,0 +1,127 @@
+package content
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Todo is a TODO, FIXME, or HACK comment found in a file
+type Todo struct {
+	Path string // Path as given or relative to the scanned directory
+	Line int
+	Tag  string // "TODO", "FIXME", or "HACK"
+	Text string // Rest of the comment, e.g. "handle empty input"
+}
+
+// todoComment matches a tag following a comment marker, with an optional author or
+// ticket in parentheses and a colon, capturing the tag and the rest of the comment
+var todoComment = regexp.MustCompile(`(?://|#|/\*|\*|--|;|<!--)\s*(TODO|FIXME|HACK)\b(?:\([^)]*\))?:?\s*(.*)$`)
+
+// FindTodos scans the files, and every eligible file under dir (if set), for TODO,
+// FIXME, and HACK comments. Files are chosen as Collect chooses them, so a file given
+// with a line range is only scanned within it, and binary, ignored, and skipped files
+// are left out. Files that can't be read are passed over.
+func FindTodos(files []string, dir string, options Options) ([]Todo, error) {
+	var todos []Todo
+	seen := make(map[string]bool)
+
+	scan := func(displayPath, path string, explicit bool, lines []LineRange) {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			absPath = path
+		}
+		if seen[absPath] {
+			return
+		}
+		seen[absPath] = true
+
+		info, err := os.Stat(absPath)
+		if err != nil || info.IsDir() || info.Size() > maxReadSize {
+			return
+		}
+		data, err := os.ReadFile(absPath)
+		if err != nil || isBinary(data) {
+			return
+		}
+		if !explicit && skipReason(displayPath, data, options.SkipHeuristics) != "" {
+			return
+		}
+		todos = append(todos, scanTodos(displayPath, data, lines)...)
+	}
+
+	var paths []string
+	requested := make(map[string][]LineRange)
+	whole := make(map[string]bool)
+	for _, spec := range files {
+		path, lines, err := SplitFileSpec(spec)
+		if err != nil {
+			continue
+		}
+		if _, ok := requested[path]; !ok && !whole[path] {
+			paths = append(paths, path)
+		}
+		if lines == nil {
+			whole[path] = true
+		} else {
+			requested[path] = append(requested[path], *lines)
+		}
+	}
+	for _, path := range paths {
+		if whole[path] {
+			scan(path, path, true, nil)
+		} else {
+			scan(path, path, true, requested[path])
+		}
+	}
+
+	if dir != "" {
+		paths, err := ListFiles(dir, options)
+		if err != nil {
+			return nil, err
+		}
+		ctx := options.context()
+		for _, rel := range paths {
+			if ctx.Err() != nil {
+				return nil, context.Cause(ctx)
+			}
+			scan(rel, filepath.Join(dir, rel), false, nil)
+		}
+	}
+
+	return todos, nil
+}
+
+// scanTodos returns the comments of a file, only those within lines when any are given
+func scanTodos(path string, data []byte, lines []LineRange) []Todo {
+	var todos []Todo
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxReadSize)
+	for number := 1; scanner.Scan(); number++ {
+		if len(lines) > 0 && !inRanges(number, lines) {
+			continue
+		}
+		match := todoComment.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		text := strings.TrimSpace(match[2])
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(text, "*/"), "-->"))
+		todos = append(todos, Todo{Path: path, Line: number, Tag: match[1], Text: text})
+	}
+	return todos
+}
+
+// inRanges reports whether line is within any of ranges
+func inRanges(line int, ranges []LineRange) bool {
+	for _, r := range ranges {
+		if line >= r.Start && line <= r.End {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package content
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindTodos(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\n// TODO: handle flags\nfunc main() {\n\tx := 1 // FIXME(ann) overflow\n}\n")
+	writeFile(t, filepath.Join(dir, "lib", "util.py"), "# HACK work around the old API\ntodo = []  # not a TODOs marker\n")
+	writeFile(t, filepath.Join(dir, "style.css"), "/* TODO use variables */\n")
+	writeFile(t, filepath.Join(dir, "notes.txt"), "TODO without a comment marker\n")
+	writeFile(t, filepath.Join(dir, "vendor", "dep.go"), "// TODO vendored\n")
+	writeFile(t, filepath.Join(dir, ".gitignore"), "vendor/\n")
+
+	todos, err := FindTodos(nil, dir, Options{Strategy: "filesystem"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Todo{
+		{Path: filepath.Join("lib", "util.py"), Line: 1, Tag: "HACK", Text: "work around the old API"},
+		{Path: "main.go", Line: 3, Tag: "TODO", Text: "handle flags"},
+		{Path: "main.go", Line: 5, Tag: "FIXME", Text: "overflow"},
+		{Path: "style.css", Line: 1, Tag: "TODO", Text: "use variables"},
+	}
+	if !reflect.DeepEqual(todos, want) {
+		t.Errorf("FindTodos() = %+v\nwant %+v", todos, want)
+	}
+}
+
+func TestFindTodos_LineRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	writeFile(t, path, "// TODO one\n// TODO two\n// TODO three\n")
+
+	todos, err := FindTodos([]string{path + ":2-3", path + ":2"}, "", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(todos) != 2 || todos[0].Text != "two" || todos[1].Line != 3 {
+		t.Errorf("FindTodos() with a line range = %+v", todos)
+	}
+}
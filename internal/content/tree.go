@@ -0,0 +1,106 @@
+package content
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultTreeDepth is how many directory levels Tree shows by default, as tree -L 3 does
+const DefaultTreeDepth = 3
+
+// maxTreeEntries is how many entries of a directory Tree lists before summarizing the rest
+const maxTreeEntries = 50
+
+// treeNode is a directory in a file tree, or a file when children is nil
+type treeNode struct {
+	children map[string]*treeNode
+	files    int // Files anywhere below the directory
+}
+
+// Tree renders the files under dir as an indented tree like the tree command prints,
+// down to depth levels. The files are listed as ListFiles lists them, so ignored and
+// excluded files and directories without any files are left out. Directories come
+// before files, and those at the depth limit show how many files they hold.
+func Tree(dir string, depth int, options Options) (string, error) {
+	paths, err := ListFiles(dir, options)
+	if err != nil {
+		return "", err
+	}
+
+	root := &treeNode{children: map[string]*treeNode{}}
+	for _, path := range paths {
+		node := root
+		parts := strings.Split(filepath.ToSlash(path), "/")
+		for i, part := range parts {
+			node.files++
+			child, ok := node.children[part]
+			if !ok {
+				child = &treeNode{}
+				if i < len(parts)-1 {
+					child.children = map[string]*treeNode{}
+				}
+				node.children[part] = child
+			}
+			node = child
+		}
+	}
+
+	name := filepath.Base(dir)
+	if abs, err := filepath.Abs(dir); err == nil {
+		name = filepath.Base(abs)
+	}
+	var b strings.Builder
+	b.WriteString(name + "/\n")
+	writeTree(&b, root, "", depth)
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// writeTree writes the entries of node, each line starting with prefix
+func writeTree(b *strings.Builder, node *treeNode, prefix string, depth int) {
+	names := make([]string, 0, len(node.children))
+	for name := range node.children {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		iDir, jDir := node.children[names[i]].children != nil, node.children[names[j]].children != nil
+		if iDir != jDir {
+			return iDir
+		}
+		return names[i] < names[j]
+	})
+
+	more := 0
+	if len(names) > maxTreeEntries {
+		names, more = names[:maxTreeEntries], len(names)-maxTreeEntries
+	}
+	for i, name := range names {
+		child := node.children[name]
+		branch, indent := "├── ", "│   "
+		if i == len(names)-1 && more == 0 {
+			branch, indent = "└── ", "    "
+		}
+
+		switch {
+		case child.children == nil:
+			b.WriteString(prefix + branch + name + "\n")
+		case depth <= 1:
+			fmt.Fprintf(b, "%s%s%s/ (%s)\n", prefix, branch, name, plural(child.files, "file"))
+		default:
+			b.WriteString(prefix + branch + name + "/\n")
+			writeTree(b, child, prefix+indent, depth-1)
+		}
+	}
+	if more > 0 {
+		fmt.Fprintf(b, "%s└── … %d more\n", prefix, more)
+	}
+}
+
+// plural formats a count with its noun, adding an "s" unless count is 1
+func plural(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
@@ -0,0 +1,69 @@
+package content
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "project")
+	for _, path := range []string{
+		"go.mod",
+		"README.md",
+		"cmd/app/main.go",
+		"internal/parser/parser.go",
+		"internal/parser/lexer/lexer.go",
+		"internal/parser/lexer/token.go",
+		"node_modules/left-pad/index.js",
+		".hidden/secret",
+	} {
+		writeFile(t, filepath.Join(dir, path), "x\n")
+	}
+	writeFile(t, filepath.Join(dir, ".gitignore"), "node_modules/\n")
+
+	tree, err := Tree(dir, 3, Options{Strategy: "filesystem"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"project/",
+		"├── cmd/",
+		"│   └── app/",
+		"│       └── main.go",
+		"├── internal/",
+		"│   └── parser/",
+		"│       ├── lexer/ (2 files)",
+		"│       └── parser.go",
+		"├── README.md",
+		"└── go.mod",
+	}, "\n")
+	if tree != want {
+		t.Errorf("Tree() =\n%s\nwant\n%s", tree, want)
+	}
+
+	tree, err = Tree(dir, 1, Options{Strategy: "filesystem", Exclude: []string{"cmd/"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "project/\n├── internal/ (3 files)\n├── README.md\n└── go.mod"; tree != want {
+		t.Errorf("Tree() with depth 1 =\n%s\nwant\n%s", tree, want)
+	}
+}
+
+func TestTree_ManyEntries(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < maxTreeEntries+5; i++ {
+		writeFile(t, filepath.Join(dir, fmt.Sprintf("file%03d.txt", i)), "x\n")
+	}
+
+	tree, err := Tree(dir, 1, Options{Strategy: "filesystem"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(tree, "\n")
+	if len(lines) != maxTreeEntries+2 || lines[len(lines)-1] != "└── … 5 more" {
+		t.Errorf("Tree() has %d lines ending %q", len(lines), lines[len(lines)-1])
+	}
+}
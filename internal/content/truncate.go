@@ -0,0 +1,161 @@
+package content
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultDiffContext is the number of unchanged lines kept around each changed hunk
+const DefaultDiffContext = 3
+
+// maxReadSize bounds how much of an oversized file is read in order to truncate it
+const maxReadSize = 16 * 1024 * 1024
+
+// LineRange is an inclusive, 1-based range of lines
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// String formats the range as "start-end", or a single line number
+func (r LineRange) String() string {
+	if r.Start == r.End {
+		return strconv.Itoa(r.Start)
+	}
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// hunkHeader matches the new-file side of a unified diff hunk header
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// truncate shortens an oversized file. When git reports local modifications, only
+// the changed hunks plus surrounding context are kept, since the changed region is
+// almost always what the prompt is about; otherwise the head of the file is kept.
+func (c *Collector) truncate(file *File) {
+	lines := strings.SplitAfter(file.Content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	if changed, err := changedLines(file.AbsPath); err == nil && len(changed) > 0 {
+		ranges := expandRanges(changed, c.options.DiffContext, len(lines))
+		if excerpt := joinRanges(lines, ranges); int64(len(excerpt)) <= c.options.MaxFileSize {
+			file.Content = excerpt
+			file.Ranges = ranges
+			file.Truncated = true
+			file.Note = fmt.Sprintf("showing changed lines %s of %d", formatRanges(ranges), len(lines))
+			return
+		}
+	}
+
+	// Keep whole lines from the top until the size limit is reached
+	var b strings.Builder
+	kept := 0
+	for _, line := range lines {
+		if int64(b.Len()+len(line)) > c.options.MaxFileSize {
+			break
+		}
+		b.WriteString(line)
+		kept++
+	}
+
+	file.Content = b.String()
+	file.Truncated = true
+	if kept > 0 {
+		file.Ranges = []LineRange{{Start: 1, End: kept}}
+	}
+	file.Note = fmt.Sprintf("showing first %d of %d lines", kept, len(lines))
+}
+
+// changedLines returns the line ranges of a file that differ from HEAD
+func changedLines(absPath string) ([]LineRange, error) {
+	cmd := exec.Command("git", "diff", "--no-color", "--unified=0", "HEAD", "--", filepath.Base(absPath))
+	cmd.Dir = filepath.Dir(absPath)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff failed: %w", err)
+	}
+
+	var ranges []LineRange
+	for _, line := range strings.Split(string(output), "\n") {
+		match := hunkHeader.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		start, _ := strconv.Atoi(match[1])
+		count := 1
+		if match[2] != "" {
+			count, _ = strconv.Atoi(match[2])
+		}
+		if count == 0 {
+			// Pure deletion: keep the line after the removed ones as the anchor
+			count = 1
+			if start == 0 {
+				start = 1
+			}
+		}
+		ranges = append(ranges, LineRange{Start: start, End: start + count - 1})
+	}
+	return ranges, nil
+}
+
+// expandRanges widens each range by context lines, clamps it to the file, and merges
+// ranges that touch or overlap
+func expandRanges(ranges []LineRange, context, total int) []LineRange {
+	var merged []LineRange
+	for _, r := range ranges {
+		start := r.Start - context
+		if start < 1 {
+			start = 1
+		}
+		end := r.End + context
+		if end > total {
+			end = total
+		}
+		if start > end {
+			continue
+		}
+
+		if n := len(merged); n > 0 && start <= merged[n-1].End+1 {
+			if end > merged[n-1].End {
+				merged[n-1].End = end
+			}
+			continue
+		}
+		merged = append(merged, LineRange{Start: start, End: end})
+	}
+	return merged
+}
+
+// joinRanges renders the selected lines, marking skipped regions with "..."
+func joinRanges(lines []string, ranges []LineRange) string {
+	var b strings.Builder
+	for i, r := range ranges {
+		if i > 0 || r.Start > 1 {
+			b.WriteString("...\n")
+		}
+		for _, line := range lines[r.Start-1 : r.End] {
+			b.WriteString(line)
+		}
+		if !strings.HasSuffix(lines[r.End-1], "\n") {
+			b.WriteString("\n")
+		}
+	}
+	if n := len(ranges); n > 0 && ranges[n-1].End < len(lines) {
+		b.WriteString("...\n")
+	}
+	return b.String()
+}
+
+// formatRanges renders ranges as a comma-separated list
+func formatRanges(ranges []LineRange) string {
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ", ")
+}
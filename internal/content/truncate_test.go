@@ -0,0 +1,79 @@
+package content
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandRanges(t *testing.T) {
+	got := expandRanges([]LineRange{{2, 2}, {6, 7}, {20, 20}}, 2, 21)
+	want := []LineRange{{1, 9}, {18, 21}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expandRanges = %v, want %v", got, want)
+	}
+}
+
+func TestJoinRanges(t *testing.T) {
+	lines := []string{"a\n", "b\n", "c\n", "d\n", "e\n"}
+	got := joinRanges(lines, []LineRange{{2, 2}, {4, 4}})
+	if want := "...\nb\n...\nd\n...\n"; got != want {
+		t.Errorf("joinRanges = %q, want %q", got, want)
+	}
+}
+
+func TestCollector_TruncatesToChangedHunks(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+
+	var lines []string
+	for i := 1; i <= 100; i++ {
+		lines = append(lines, fmt.Sprintf("line %03d", i))
+	}
+	path := filepath.Join(dir, "large.txt")
+	writeFile(t, path, strings.Join(lines, "\n")+"\n")
+
+	git("init", "-q")
+	git("add", "large.txt")
+	git("commit", "-q", "-m", "initial")
+
+	// Modify line 50 so the diff has a single hunk in the middle of the file
+	lines[49] = "line 050 changed"
+	writeFile(t, path, strings.Join(lines, "\n")+"\n")
+
+	collector := NewCollector(Options{MaxFileSize: 200, DiffContext: 2})
+	files, _, err := collector.Collect([]string{path}, "")
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	file := files[0]
+	if !file.Truncated || file.Note != "showing changed lines 48-52 of 100" {
+		t.Fatalf("unexpected truncation: %+v", file)
+	}
+	want := "...\nline 048\nline 049\nline 050 changed\nline 051\nline 052\n...\n"
+	if file.Content != want {
+		t.Errorf("Content = %q, want %q", file.Content, want)
+	}
+
+	// Unmodified large files fall back to the head of the file
+	os.WriteFile(filepath.Join(dir, "other.txt"), []byte(strings.Join(lines, "\n")), 0644)
+	files, _, _ = collector.Collect([]string{filepath.Join(dir, "other.txt")}, "")
+	if !strings.HasPrefix(files[0].Note, "showing first") {
+		t.Errorf("expected head truncation, got %q", files[0].Note)
+	}
+}
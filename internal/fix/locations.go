@@ -0,0 +1,242 @@
+// Package fix finds the source files and lines that failing command output refers
+// to, such as compiler errors and stack trace frames, so fix mode can include them in
+// the prompt.
+package fix
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"prompter-cli/internal/interfaces"
+)
+
+var (
+	// goReference matches the file:line[:column] references printed by go build, go vet,
+	// and go test, and the frames of panic stack traces
+	goReference = regexp.MustCompile(`^\s*(?:vet: )?((?:[A-Za-z]:)?[^\s:]+\.go):(\d+)(?::(\d+))?(?::\s*(.*)|\s+\+0x[0-9a-f]+)?\s*$`)
+
+	// testResult matches the line go test prints for each package after its output
+	testResult = regexp.MustCompile(`^(?:FAIL|ok)\s+(\S+)\s`)
+
+	// pythonFrame matches a frame of a Python traceback: File "app.py", line 3, in main
+	pythonFrame = regexp.MustCompile(`^\s+File "([^"]+)", line (\d+)(?:, in (.+))?$`)
+
+	// jsFrame matches a frame of a Node.js stack trace: at main (/app/index.js:3:9)
+	jsFrame = regexp.MustCompile(`^\s+at (?:(.+?) \()?(?:file://)?((?:[A-Za-z]:)?[^\s():]+\.(?:[cm]?js|jsx|[cm]?ts|tsx)):(\d+):(\d+)\)?$`)
+
+	// dependencyDir matches the directories installed dependencies live in
+	dependencyDir = regexp.MustCompile(`[/\\](?:node_modules|site-packages|dist-packages|vendor)[/\\]`)
+)
+
+// testEvent is a line of go test -json output
+type testEvent struct {
+	Action  string
+	Package string
+	Output  string
+}
+
+// Parse returns the existing files and lines referenced by output from go build,
+// go vet, go test, or go test -json run in dir, and by the frames of Go panics,
+// Python tracebacks, and Node.js stack traces. Locations are in the order they first
+// appear, with the frames of each trace innermost first, so the code closest to the
+// failure ranks first. go test names files relative to their package, so those are
+// found through the module that contains dir.
+func Parse(output, dir string) []interfaces.FixLocation {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+	module := findModule(dir)
+	// Only the project's own files are of interest, not the standard library or
+	// dependencies in stack traces
+	project := findRepository(dir)
+	if project == "" {
+		project = module.root
+	}
+	if project == "" {
+		project = dir
+	}
+
+	var locations []interfaces.FixLocation
+	seen := make(map[string]bool)
+	add := func(path string, line, column int, message string) {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if _, ok := relativeTo(project, path); !ok || dependencyDir.MatchString(path) {
+			return
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			return
+		}
+		if rel, ok := relativeTo(dir, path); ok {
+			path = rel
+		}
+		key := path + ":" + strconv.Itoa(line)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		locations = append(locations, interfaces.FixLocation{Path: path, Line: line, Column: column, Message: message})
+	}
+
+	// Plain go test output names the package after its failures, so bare file names
+	// wait for it
+	type pending struct {
+		name         string
+		line, column int
+		message      string
+	}
+	var waiting []pending
+	resolve := func(pkg string) {
+		for _, p := range waiting {
+			if pkgDir := module.dir(pkg); pkgDir != "" {
+				add(filepath.Join(pkgDir, p.name), p.line, p.column, p.message)
+			} else {
+				add(filepath.Join(dir, p.name), p.line, p.column, p.message)
+			}
+		}
+		waiting = nil
+	}
+
+	// Python prints the innermost frame last, so a traceback's frames are added in
+	// reverse once it ends
+	var traceback []interfaces.FixLocation
+	endTraceback := func() {
+		for i := len(traceback) - 1; i >= 0; i-- {
+			add(traceback[i].Path, traceback[i].Line, 0, traceback[i].Message)
+		}
+		traceback = nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text, pkg := scanner.Text(), ""
+
+		if match := pythonFrame.FindStringSubmatch(text); match != nil {
+			line, _ := strconv.Atoi(match[2])
+			traceback = append(traceback, interfaces.FixLocation{Path: match[1], Line: line, Message: match[3]})
+			continue
+		}
+		if traceback != nil && !strings.HasPrefix(text, " ") {
+			endTraceback() // The exception follows the last frame, unindented
+		}
+
+		if match := jsFrame.FindStringSubmatch(text); match != nil {
+			line, _ := strconv.Atoi(match[3])
+			column, _ := strconv.Atoi(match[4])
+			add(match[2], line, column, match[1])
+			continue
+		}
+
+		if strings.HasPrefix(text, "{") {
+			var event testEvent
+			if json.Unmarshal([]byte(text), &event) == nil && event.Action != "" {
+				if event.Action != "output" && event.Action != "build-output" {
+					continue
+				}
+				text, pkg = strings.TrimRight(event.Output, "\n"), event.Package
+			}
+		}
+
+		if match := testResult.FindStringSubmatch(text); match != nil && pkg == "" {
+			resolve(match[1])
+			continue
+		}
+
+		match := goReference.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+		path := match[1]
+		line, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		message := strings.TrimSpace(match[4])
+
+		switch {
+		case filepath.IsAbs(path) || strings.ContainsAny(path, `/\`):
+			add(path, line, column, message)
+		case pkg != "":
+			if pkgDir := module.dir(pkg); pkgDir != "" {
+				add(filepath.Join(pkgDir, path), line, column, message)
+			}
+		default:
+			waiting = append(waiting, pending{path, line, column, message})
+		}
+	}
+	endTraceback()
+	resolve("")
+
+	return locations
+}
+
+// relativeTo returns path relative to base, reporting false when it's outside base
+func relativeTo(base, path string) (string, bool) {
+	rel, err := filepath.Rel(base, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// findRepository walks up from dir to the root of the git repository containing it,
+// returning "" outside one
+func findRepository(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// module is the Go module containing the directory output was parsed in
+type module struct {
+	path string // Module path from go.mod
+	root string // Directory holding go.mod
+}
+
+// findModule walks up from dir to the nearest go.mod, returning a zero module
+// without one
+func findModule(dir string) module {
+	for {
+		if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "module" {
+					return module{path: strings.Trim(fields[1], `"`), root: dir}
+				}
+			}
+			return module{}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return module{}
+		}
+		dir = parent
+	}
+}
+
+// dir returns the directory of the package with import path pkg when it's in the
+// module, or ""
+func (m module) dir(pkg string) string {
+	if m.path == "" || pkg == "" {
+		return ""
+	}
+	if pkg == m.path {
+		return m.root
+	}
+	if rest, ok := strings.CutPrefix(pkg, m.path+"/"); ok {
+		return filepath.Join(m.root, filepath.FromSlash(rest))
+	}
+	return ""
+}
@@ -0,0 +1,185 @@
+package fix
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"prompter-cli/internal/interfaces"
+)
+
+// writeModule creates a module with a main package and an internal/parse package
+func writeModule(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                         "module example.com/app\n\ngo 1.22\n",
+		"main.go":                        "package main\n",
+		"internal/parse/parse.go":        "package parse\n",
+		"internal/parse/parse_test.go":   "package parse\n",
+		"internal/format/format_test.go": "package format\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestParse(t *testing.T) {
+	root := writeModule(t)
+
+	tests := []struct {
+		name   string
+		output string
+		want   []interfaces.FixLocation
+	}{
+		{
+			name: "go build",
+			output: "# example.com/app/internal/parse\n" +
+				"internal/parse/parse.go:12:5: undefined: tokenize\n" +
+				"internal/parse/parse.go:12:9: too many errors\n" +
+				"./main.go:3:2: \"fmt\" imported and not used\n",
+			want: []interfaces.FixLocation{
+				{Path: "internal/parse/parse.go", Line: 12, Column: 5, Message: "undefined: tokenize"},
+				{Path: "main.go", Line: 3, Column: 2, Message: `"fmt" imported and not used`},
+			},
+		},
+		{
+			name:   "go vet",
+			output: "# example.com/app\nvet: ./main.go:8:2: fmt.Printf format %d has arg name of wrong type string\n",
+			want: []interfaces.FixLocation{
+				{Path: "main.go", Line: 8, Column: 2, Message: "fmt.Printf format %d has arg name of wrong type string"},
+			},
+		},
+		{
+			name: "go test",
+			output: "--- FAIL: TestParse (0.00s)\n" +
+				"    parse_test.go:21: got 2 tokens, want 3\n" +
+				"FAIL\n" +
+				"FAIL\texample.com/app/internal/parse\t0.004s\n" +
+				"--- FAIL: TestFormat (0.00s)\n" +
+				"    format_test.go:7: unexpected output\n" +
+				"FAIL\texample.com/app/internal/format\t0.003s\n",
+			want: []interfaces.FixLocation{
+				{Path: "internal/parse/parse_test.go", Line: 21, Message: "got 2 tokens, want 3"},
+				{Path: "internal/format/format_test.go", Line: 7, Message: "unexpected output"},
+			},
+		},
+		{
+			name: "go test -json",
+			output: `{"Action":"run","Package":"example.com/app/internal/parse","Test":"TestParse"}` + "\n" +
+				`{"Action":"output","Package":"example.com/app/internal/parse","Test":"TestParse","Output":"    parse_test.go:21: got 2 tokens, want 3\n"}` + "\n" +
+				`{"Action":"output","Package":"example.com/app/internal/parse","Output":"FAIL\texample.com/app/internal/parse\t0.004s\n"}` + "\n" +
+				`{"Action":"fail","Package":"example.com/app/internal/parse"}` + "\n",
+			want: []interfaces.FixLocation{
+				{Path: "internal/parse/parse_test.go", Line: 21, Message: "got 2 tokens, want 3"},
+			},
+		},
+		{
+			name: "panic",
+			output: "panic: runtime error: index out of range [3] with length 3\n\n" +
+				"goroutine 7 [running]:\n" +
+				"example.com/app/internal/parse.Parse(...)\n" +
+				"\t" + filepath.Join(root, "internal/parse/parse.go") + ":40 +0x1d\n" +
+				"testing.tRunner(0xc000003380, 0x5a6f30)\n" +
+				"\t/usr/local/go/src/testing/testing.go:1792 +0xf4\n",
+			want: []interfaces.FixLocation{
+				{Path: "internal/parse/parse.go", Line: 40},
+			},
+		},
+		{
+			name:   "missing files and other output",
+			output: "internal/gone/gone.go:3:1: syntax error\nBuild complete: 3 files\n",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.output, root); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Subdirectory(t *testing.T) {
+	root := writeModule(t)
+
+	// Paths stay relative to where the command ran, and package-relative names still
+	// resolve through the module
+	got := Parse("    parse_test.go:21: failed\nFAIL\texample.com/app/internal/parse\t0.004s\n", filepath.Join(root, "internal", "parse"))
+	want := []interfaces.FixLocation{{Path: "parse_test.go", Line: 21, Message: "failed"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParse_Tracebacks(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{".git/HEAD", "app/main.py", "app/db.py", "src/index.js", "src/util.ts", "node_modules/lib/index.js", "venv/lib/python3.12/site-packages/requests/api.py"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		output string
+		want   []interfaces.FixLocation
+	}{
+		{
+			name: "python",
+			output: "Traceback (most recent call last):\n" +
+				"  File \"" + filepath.Join(root, "app/main.py") + "\", line 12, in <module>\n" +
+				"    main()\n" +
+				"  File \"app/db.py\", line 40, in connect\n" +
+				"    return requests.get(url)\n" +
+				"  File \"" + filepath.Join(root, "venv/lib/python3.12/site-packages/requests/api.py") + "\", line 73, in get\n" +
+				"    return request(\"get\", url)\n" +
+				"ConnectionError: refused\n",
+			want: []interfaces.FixLocation{
+				{Path: "app/db.py", Line: 40, Message: "connect"},
+				{Path: "app/main.py", Line: 12, Message: "<module>"},
+			},
+		},
+		{
+			name: "node",
+			output: "TypeError: Cannot read properties of undefined (reading 'id')\n" +
+				"    at parse (" + filepath.Join(root, "src/util.ts") + ":8:14)\n" +
+				"    at " + filepath.Join(root, "node_modules/lib/index.js") + ":3:1\n" +
+				"    at main (file://" + filepath.Join(root, "src/index.js") + ":21:3)\n" +
+				"    at node:internal/main/run_main_module:28:49\n",
+			want: []interfaces.FixLocation{
+				{Path: "src/util.ts", Line: 8, Column: 14, Message: "parse"},
+				{Path: "src/index.js", Line: 21, Column: 3, Message: "main"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.output, root); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// Frames anywhere in the repository count, even when run from a subdirectory
+	got := Parse("  File \""+filepath.Join(root, "app/main.py")+"\", line 12, in <module>\n", filepath.Join(root, "src"))
+	want := []interfaces.FixLocation{{Path: filepath.Join(root, "app/main.py"), Line: 12, Message: "<module>"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,172 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/pkg/models"
+)
+
+// Diff collects the changes selected by mode (models.DiffWorking, models.DiffStaged,
+// or models.DiffRef against base) in the repository containing dir, with
+// contextLines of unchanged lines around each hunk
+func Diff(dir, mode, base string, contextLines int) (interfaces.DiffInfo, error) {
+	args := []string{"diff", "--no-color", "--no-ext-diff", "--find-renames", "--unified=" + strconv.Itoa(contextLines)}
+	description := "git diff"
+	switch mode {
+	case models.DiffWorking:
+	case models.DiffStaged:
+		args = append(args, "--staged")
+		description += " --staged"
+	case models.DiffRef:
+		if base == "" {
+			return interfaces.DiffInfo{}, fmt.Errorf("no ref given to diff against")
+		}
+		args = append(args, base, "--")
+		description += " " + base
+	default:
+		return interfaces.DiffInfo{}, fmt.Errorf("unknown diff mode %q", mode)
+	}
+
+	if _, err := run(dir, "rev-parse", "--git-dir"); err != nil {
+		return interfaces.DiffInfo{}, fmt.Errorf("%s requires a git repository", description)
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return interfaces.DiffInfo{}, fmt.Errorf("%s failed: %s", description, msg)
+		}
+		return interfaces.DiffInfo{}, fmt.Errorf("%s failed: %w", description, err)
+	}
+
+	return interfaces.DiffInfo{
+		Mode:    mode,
+		Base:    base,
+		Command: description,
+		Raw:     stdout.String(),
+		Files:   ParseDiff(stdout.String()),
+	}, nil
+}
+
+// ParseDiff splits unified diff output from git into per-file changes and hunks
+func ParseDiff(diff string) []interfaces.FileDiff {
+	var files []interfaces.FileDiff
+	var file *interfaces.FileDiff
+	var hunk *interfaces.DiffHunk
+	var patch, hunkLines []string
+	var oldRemaining, newRemaining int // Lines of the current hunk still to come
+
+	flushHunk := func() {
+		if hunk != nil {
+			hunk.Content = strings.Join(hunkLines, "\n")
+			file.Hunks = append(file.Hunks, *hunk)
+			hunk, hunkLines = nil, nil
+		}
+	}
+	flushFile := func() {
+		if file != nil {
+			flushHunk()
+			file.Patch = strings.Join(patch, "\n")
+			files = append(files, *file)
+			file, patch = nil, nil
+		}
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			flushFile()
+			file = &interfaces.FileDiff{Status: "modified"}
+			// Paths from the header are replaced by the ---/+++ lines when present
+			if a, b, ok := strings.Cut(strings.TrimPrefix(line, "diff --git "), " b/"); ok {
+				file.OldPath, file.Path = strings.TrimPrefix(a, "a/"), b
+			}
+		}
+		if file == nil {
+			continue
+		}
+		patch = append(patch, line)
+
+		// The hunk header's line counts tell where it ends, since blank context lines
+		// may have lost their leading space
+		if hunk != nil && (oldRemaining > 0 || newRemaining > 0 || strings.HasPrefix(line, "\\")) {
+			hunkLines = append(hunkLines, line)
+			switch {
+			case strings.HasPrefix(line, "+"):
+				file.Additions++
+				newRemaining--
+			case strings.HasPrefix(line, "-"):
+				file.Deletions++
+				oldRemaining--
+			case strings.HasPrefix(line, "\\"):
+			default:
+				oldRemaining--
+				newRemaining--
+			}
+			continue
+		}
+		flushHunk()
+
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			hunk = parseHunkHeader(line)
+			oldRemaining, newRemaining = hunk.OldLines, hunk.NewLines
+		case strings.HasPrefix(line, "new file mode"):
+			file.Status = "added"
+		case strings.HasPrefix(line, "deleted file mode"):
+			file.Status = "deleted"
+		case strings.HasPrefix(line, "rename from "):
+			file.Status, file.OldPath = "renamed", strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to "):
+			file.Path = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "Binary files "):
+			file.Binary = true
+		case strings.HasPrefix(line, "--- a/"):
+			file.OldPath = strings.TrimPrefix(line, "--- a/")
+		case strings.HasPrefix(line, "+++ b/"):
+			file.Path = strings.TrimPrefix(line, "+++ b/")
+		}
+	}
+	flushFile()
+
+	// Added and deleted files have no old or new path
+	for i := range files {
+		switch files[i].Status {
+		case "added":
+			files[i].OldPath = ""
+		case "deleted":
+			files[i].Path = files[i].OldPath
+		}
+	}
+	return files
+}
+
+// parseHunkHeader parses "@@ -a,b +c,d @@ section" into a hunk
+func parseHunkHeader(line string) *interfaces.DiffHunk {
+	hunk := &interfaces.DiffHunk{Header: line}
+	fields := strings.Fields(line)
+	if len(fields) >= 3 {
+		hunk.OldStart, hunk.OldLines = parseRange(strings.TrimPrefix(fields[1], "-"))
+		hunk.NewStart, hunk.NewLines = parseRange(strings.TrimPrefix(fields[2], "+"))
+	}
+	return hunk
+}
+
+// parseRange parses "start,count" where a missing count means one line
+func parseRange(value string) (int, int) {
+	startText, countText, hasCount := strings.Cut(value, ",")
+	start, _ := strconv.Atoi(startText)
+	if !hasCount {
+		return start, 1
+	}
+	count, _ := strconv.Atoi(countText)
+	return start, count
+}
@@ -0,0 +1,129 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompter-cli/pkg/models"
+)
+
+const sampleDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@ package main
+ import "fmt"
++import "os"
+
+-func main() {}
++func main() { os.Exit(0) }
+@@ -10 +11,2 @@ func helper() {
++	// added
++	return
+diff --git a/new.txt b/new.txt
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1 @@
++hello
+\ No newline at end of file
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+index 4444444..0000000
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+diff --git a/a.go b/b.go
+similarity index 100%
+rename from a.go
+rename to b.go
+diff --git a/logo.png b/logo.png
+index 5555555..6666666 100644
+Binary files a/logo.png and b/logo.png differ
+`
+
+func TestParseDiff(t *testing.T) {
+	files := ParseDiff(sampleDiff)
+	if len(files) != 5 {
+		t.Fatalf("got %d files, want 5: %+v", len(files), files)
+	}
+
+	main := files[0]
+	if main.Path != "main.go" || main.OldPath != "main.go" || main.Status != "modified" || main.Additions != 4 || main.Deletions != 1 {
+		t.Errorf("unexpected main.go diff: %+v", main)
+	}
+	if len(main.Hunks) != 2 {
+		t.Fatalf("got %d hunks, want 2", len(main.Hunks))
+	}
+	first := main.Hunks[0]
+	if first.OldStart != 1 || first.OldLines != 3 || first.NewStart != 1 || first.NewLines != 4 || !strings.HasSuffix(first.Header, "@@ package main") {
+		t.Errorf("unexpected first hunk: %+v", first)
+	}
+	if first.Content != " import \"fmt\"\n+import \"os\"\n\n-func main() {}\n+func main() { os.Exit(0) }" {
+		t.Errorf("unexpected hunk content: %q", first.Content)
+	}
+	if second := main.Hunks[1]; second.OldStart != 10 || second.OldLines != 1 || second.NewStart != 11 || second.NewLines != 2 {
+		t.Errorf("unexpected second hunk: %+v", second)
+	}
+	if !strings.HasPrefix(main.Patch, "diff --git a/main.go b/main.go\n") || strings.Contains(main.Patch, "new.txt") {
+		t.Errorf("unexpected patch: %q", main.Patch)
+	}
+
+	if added := files[1]; added.Path != "new.txt" || added.OldPath != "" || added.Status != "added" || added.Additions != 1 {
+		t.Errorf("unexpected added file: %+v", added)
+	}
+	if deleted := files[2]; deleted.Path != "old.txt" || deleted.Status != "deleted" || deleted.Deletions != 1 {
+		t.Errorf("unexpected deleted file: %+v", deleted)
+	}
+	if renamed := files[3]; renamed.Path != "b.go" || renamed.OldPath != "a.go" || renamed.Status != "renamed" {
+		t.Errorf("unexpected renamed file: %+v", renamed)
+	}
+	if binary := files[4]; binary.Path != "logo.png" || !binary.Binary || len(binary.Hunks) != 0 {
+		t.Errorf("unexpected binary file: %+v", binary)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	dir, git := newRepo(t)
+	commitFile(t, git, dir, "a.txt", "one\n", "Add a")
+	git("tag", "v1")
+	commitFile(t, git, dir, "a.txt", "one\ntwo\n", "Extend a")
+
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\ntwo\nthree\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	working, err := Diff(dir, models.DiffWorking, "", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(working.Files) != 1 || working.Files[0].Additions != 1 || working.Command != "git diff" {
+		t.Errorf("unexpected working diff: %+v", working)
+	}
+
+	staged, err := Diff(dir, models.DiffStaged, "", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(staged.Files) != 0 {
+		t.Errorf("expected nothing staged, got %+v", staged.Files)
+	}
+
+	since, err := Diff(dir, models.DiffRef, "v1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(since.Files) != 1 || since.Files[0].Additions != 2 || since.Command != "git diff v1" {
+		t.Errorf("unexpected diff against v1: %+v", since)
+	}
+
+	if _, err := Diff(dir, models.DiffRef, "no-such-ref", 3); err == nil {
+		t.Error("expected an unknown ref to fail")
+	}
+	if _, err := Diff(t.TempDir(), models.DiffWorking, "", 3); err == nil || !strings.Contains(err.Error(), "requires a git repository") {
+		t.Errorf("unexpected error outside a repository: %v", err)
+	}
+}
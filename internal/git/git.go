@@ -0,0 +1,85 @@
+// Package git reads repository information for templates with the git command
+package git
+
+import (
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"prompter-cli/internal/interfaces"
+)
+
+// DefaultRecentCommits is how many commit subjects templates get by default
+const DefaultRecentCommits = 5
+
+// Info returns information about the repository containing dir, including the
+// subjects of up to recentCommits commits. Outside a repository, or when git is not
+// installed, it returns an empty GitInfo.
+func Info(dir string, recentCommits int) interfaces.GitInfo {
+	root, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return interfaces.GitInfo{}
+	}
+
+	info := interfaces.GitInfo{Root: root}
+	info.Branch, _ = run(dir, "symbolic-ref", "--short", "-q", "HEAD")
+	info.Commit, _ = run(dir, "rev-parse", "HEAD") // Fails before the first commit
+	if status, err := run(dir, "status", "--porcelain"); err == nil {
+		info.Dirty = status != ""
+	}
+
+	remote := "origin"
+	if upstream, err := run(dir, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); err == nil {
+		info.Upstream = upstream
+		if name, err := run(dir, "config", "branch."+info.Branch+".remote"); err == nil && name != "." {
+			remote = name
+		}
+		if counts, err := run(dir, "rev-list", "--left-right", "--count", "HEAD...@{upstream}"); err == nil {
+			if fields := strings.Fields(counts); len(fields) == 2 {
+				info.Ahead, _ = strconv.Atoi(fields[0])
+				info.Behind, _ = strconv.Atoi(fields[1])
+			}
+		}
+	}
+	if remoteURL, err := run(dir, "remote", "get-url", remote); err == nil {
+		info.Remote = redactURL(remoteURL)
+	}
+
+	if recentCommits > 0 && info.Commit != "" {
+		log, err := run(dir, "log", "-n", strconv.Itoa(recentCommits), "--format=%h%x09%s")
+		if err == nil && log != "" {
+			for _, line := range strings.Split(log, "\n") {
+				hash, subject, _ := strings.Cut(line, "\t")
+				info.RecentCommits = append(info.RecentCommits, interfaces.GitCommit{Hash: hash, Subject: subject})
+			}
+		}
+	}
+
+	return info
+}
+
+// run runs git in dir and returns its trimmed output
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// redactURL removes credentials from a remote URL so tokens embedded in it never
+// reach a prompt. SSH user names such as the git in git@github.com:org/repo.git are kept.
+func redactURL(remote string) string {
+	parsed, err := url.Parse(remote)
+	if err != nil || parsed.User == nil {
+		return remote
+	}
+	if _, hasPassword := parsed.User.Password(); !hasPassword && parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return remote
+	}
+	parsed.User = nil
+	return parsed.String()
+}
@@ -0,0 +1,59 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Sync clones the repository at url into dir, or updates an earlier clone to the
+// latest commit of ref, discarding changes made in it. An empty ref follows the
+// remote's default branch. Only the latest commit is fetched. It returns the
+// abbreviated hash of the commit checked out.
+func Sync(url, ref, dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return "", err
+		}
+		args := []string{"clone", "--quiet", "--depth", "1"}
+		if ref != "" {
+			args = append(args, "--branch="+ref)
+		}
+		if err := runRemote("", append(args, "--", url, dir)...); err != nil {
+			return "", err
+		}
+	} else {
+		// The URL may have changed in the config since the clone
+		if err := runRemote(dir, "remote", "set-url", "--", "origin", url); err != nil {
+			return "", err
+		}
+		target := ref
+		if target == "" {
+			target = "HEAD"
+		}
+		if err := runRemote(dir, "fetch", "--quiet", "--depth", "1", "--", "origin", target); err != nil {
+			return "", err
+		}
+		if err := runRemote(dir, "reset", "--quiet", "--hard", "FETCH_HEAD"); err != nil {
+			return "", err
+		}
+	}
+	return run(dir, "rev-parse", "--short", "HEAD")
+}
+
+// runRemote runs git in dir for a command that may reach a remote, failing instead
+// of prompting for credentials, and reports what git printed when it fails
+func runRemote(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if message := strings.TrimSpace(string(output)); message != "" {
+			return fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return nil
+}
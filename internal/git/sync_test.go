@@ -0,0 +1,59 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSync(t *testing.T) {
+	dir, git := newRepo(t)
+	commitFile(t, git, dir, "review.md", "v1\n", "Add review")
+	git("push", "-q", "-u", "origin", "main")
+	upstream := filepath.Join(filepath.Dir(dir), "upstream.git")
+	clone := filepath.Join(t.TempDir(), "sources", "prompts")
+
+	first, err := Sync(upstream, "", clone)
+	if err != nil {
+		t.Fatalf("Sync() clone error = %v", err)
+	}
+	if first == "" {
+		t.Error("Sync() returned no commit")
+	}
+
+	// Local edits are discarded and the new commit checked out
+	commitFile(t, git, dir, "review.md", "v2\n", "Update review")
+	git("push", "-q", "origin", "main")
+	if err := os.WriteFile(filepath.Join(clone, "review.md"), []byte("edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	second, err := Sync(upstream, "main", clone)
+	if err != nil {
+		t.Fatalf("Sync() update error = %v", err)
+	}
+	if second == first {
+		t.Errorf("Sync() stayed at %s after a new commit", first)
+	}
+	content, err := os.ReadFile(filepath.Join(clone, "review.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "v2\n" {
+		t.Errorf("review.md = %q, want v2", content)
+	}
+
+	// A ref is never taken for an option, whether cloning or updating
+	pwned := filepath.Join(t.TempDir(), "pwned")
+	for _, target := range []string{clone, filepath.Join(t.TempDir(), "fresh")} {
+		if _, err := Sync(upstream, "--upload-pack=touch "+pwned, target); err == nil {
+			t.Errorf("Sync() into %s with an option for a ref succeeded", target)
+		}
+	}
+	if _, err := os.Stat(pwned); err == nil {
+		t.Error("Sync() ran a command given as the ref")
+	}
+
+	if _, err := Sync(upstream, "no-such-branch", filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Sync() of a missing branch succeeded")
+	}
+}
@@ -0,0 +1,237 @@
+package history
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultLocation is where history is stored when history_location isn't configured
+const DefaultLocation = "~/.local/share/prompter/history"
+
+// Entry is a generated prompt and the request that produced it
+type Entry struct {
+	ID                string            `json:"id"`
+	Time              time.Time         `json:"time"`
+	Prompt            string            `json:"prompt"`
+	Tokens            int               `json:"tokens,omitempty"`    // Size of Prompt, counted with Tokenizer
+	Tokenizer         string            `json:"tokenizer,omitempty"` // Tokenizer name, see the tokenizer package
+	BasePrompt        string            `json:"base_prompt,omitempty"`
+	PreTemplate       string            `json:"pre_template,omitempty"`
+	PostTemplate      string            `json:"post_template,omitempty"`
+	Files             []string          `json:"files,omitempty"`
+	Symbols           []string          `json:"symbols,omitempty"`
+	Directory         string            `json:"directory,omitempty"`
+	DirectoryStrategy string            `json:"directory_strategy,omitempty"`
+	Exclude           []string          `json:"exclude,omitempty"`
+	MaxTokens         int               `json:"max_tokens,omitempty"`
+	Model             string            `json:"model,omitempty"`
+	Outline           bool              `json:"outline,omitempty"`
+	WithDeps          int               `json:"with_deps,omitempty"`
+	Todos             bool              `json:"todos,omitempty"`
+	WithDocs          bool              `json:"with_docs,omitempty"`
+	Vars              map[string]string `json:"vars,omitempty"`
+	Target            string            `json:"target,omitempty"`
+	OutputFile        string            `json:"output_file,omitempty"` // File a file: or file+: target wrote, placeholders resolved
+	ParentID          string            `json:"parent_id,omitempty"`   // Set on follow-ups created with continue
+	WorkDir           string            `json:"work_dir,omitempty"`
+	Snapshot          Snapshot          `json:"snapshot"` // Working tree state used for stale-context warnings
+}
+
+// Matches reports whether every word of query appears, ignoring case, in the
+// entry's prompt, base prompt, or template names
+func (e *Entry) Matches(query string) bool {
+	text := strings.ToLower(strings.Join([]string{e.Prompt, e.BasePrompt, e.PreTemplate, e.PostTemplate}, "\n"))
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// Store keeps history entries as JSON files in a directory, one per prompt
+type Store struct {
+	dir   string
+	limit int
+}
+
+// NewStore creates a store in dir that keeps at most limit entries (0 for no limit)
+func NewStore(dir string, limit int) *Store {
+	return &Store{dir: dir, limit: limit}
+}
+
+// Save assigns the entry an ID and timestamp if missing, writes it, and prunes the
+// oldest entries beyond the limit
+func (s *Store) Save(entry *Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if entry.ID == "" {
+		entry.ID = newID(entry.Time)
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(s.dir, entry.ID+".json"), data, 0600); err != nil {
+		return fmt.Errorf("failed to write history entry: %w", err)
+	}
+
+	return s.prune()
+}
+
+// Get returns the entry with the given ID or unique ID prefix
+func (s *Store) Get(id string) (*Entry, error) {
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for _, candidate := range ids {
+		if candidate == id {
+			return s.read(candidate)
+		}
+		if strings.HasPrefix(candidate, id) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("history entry not found: %s", id)
+	case 1:
+		return s.read(matches[0])
+	default:
+		return nil, fmt.Errorf("history id %s is ambiguous (%d matches)", id, len(matches))
+	}
+}
+
+// Latest returns the most recent entry
+func (s *Store) Latest() (*Entry, error) {
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("history is empty")
+	}
+	return s.read(ids[len(ids)-1])
+}
+
+// List returns every entry, newest first
+func (s *Store) List() ([]*Entry, error) {
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*Entry, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		entry, err := s.read(ids[i])
+		if err != nil {
+			continue // Skip unreadable entries rather than failing the whole listing
+		}
+		entries = append(entries, entry)
+	}
+	// IDs only resolve to the second, so order entries saved within one by time
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.After(entries[j].Time)
+	})
+	return entries, nil
+}
+
+// Search returns the entries matching query, newest first
+func (s *Store) Search(query string) ([]*Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*Entry
+	for _, entry := range entries {
+		if entry.Matches(query) {
+			matches = append(matches, entry)
+		}
+	}
+	return matches, nil
+}
+
+// ids returns the stored entry IDs, oldest first
+func (s *Store) ids() ([]string, error) {
+	dirEntries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	var ids []string
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(name, ".json"))
+	}
+	// IDs start with a timestamp, so lexical order is chronological
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// read loads a single entry by exact ID
+func (s *Store) read(id string) (*Entry, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, id+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history entry %s: %w", id, err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse history entry %s: %w", id, err)
+	}
+	return &entry, nil
+}
+
+// prune removes the oldest entries beyond the store's limit
+func (s *Store) prune() error {
+	if s.limit <= 0 {
+		return nil
+	}
+
+	ids, err := s.ids()
+	if err != nil {
+		return err
+	}
+
+	for len(ids) > s.limit {
+		if err := os.Remove(filepath.Join(s.dir, ids[0]+".json")); err != nil {
+			return fmt.Errorf("failed to prune history: %w", err)
+		}
+		ids = ids[1:]
+	}
+	return nil
+}
+
+// newID returns a sortable, unique entry ID such as 20240102-150405-a1b2c3
+func newID(t time.Time) string {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return t.Format("20060102-150405.000000")
+	}
+	return t.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
@@ -0,0 +1,98 @@
+package history
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore_SaveGetLatest(t *testing.T) {
+	store := NewStore(t.TempDir(), 0)
+	base := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	first := &Entry{Prompt: "first", Time: base}
+	second := &Entry{Prompt: "second", Time: base.Add(time.Minute)}
+	for _, entry := range []*Entry{first, second} {
+		if err := store.Save(entry); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	if !strings.HasPrefix(first.ID, "20240102-150405-") {
+		t.Errorf("unexpected ID %q", first.ID)
+	}
+
+	latest, err := store.Latest()
+	if err != nil || latest.Prompt != "second" {
+		t.Errorf("Latest = %+v, %v", latest, err)
+	}
+
+	got, err := store.Get(first.ID[:len("20240102-150405")])
+	if err != nil || got.Prompt != "first" {
+		t.Errorf("Get by prefix = %+v, %v", got, err)
+	}
+
+	if _, err := store.Get("20240102"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("expected ambiguous prefix error, got %v", err)
+	}
+	if _, err := store.Get("nope"); err == nil {
+		t.Error("expected not found error")
+	}
+
+	entries, err := store.List()
+	if err != nil || len(entries) != 2 || entries[0].Prompt != "second" {
+		t.Errorf("List = %+v, %v", entries, err)
+	}
+}
+
+func TestStore_Prune(t *testing.T) {
+	store := NewStore(t.TempDir(), 2)
+	base := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := store.Save(&Entry{Prompt: string(rune('a' + i)), Time: base.Add(time.Duration(i) * time.Second)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, _ := store.List()
+	if len(entries) != 2 || entries[0].Prompt != "d" || entries[1].Prompt != "c" {
+		t.Errorf("expected the two newest entries, got %+v", entries)
+	}
+}
+
+func TestStore_EmptyLatest(t *testing.T) {
+	store := NewStore(t.TempDir()+"/missing", 0)
+	if _, err := store.Latest(); err == nil {
+		t.Error("expected an error for empty history")
+	}
+}
+
+func TestStore_Search(t *testing.T) {
+	store := NewStore(t.TempDir(), 0)
+	base := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	entries := []*Entry{
+		{Prompt: "Fix the login bug", PreTemplate: "debug", Time: base},
+		{Prompt: "Review the login form", PreTemplate: "review", Time: base.Add(time.Minute)},
+		{Prompt: "Write release notes", Time: base.Add(2 * time.Minute)},
+	}
+	for _, entry := range entries {
+		if err := store.Save(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	matches, err := store.Search("LOGIN")
+	if err != nil || len(matches) != 2 || matches[0].PreTemplate != "review" {
+		t.Errorf("Search(LOGIN) = %+v, %v", matches, err)
+	}
+
+	// Every word must match, template names included
+	matches, _ = store.Search("login debug")
+	if len(matches) != 1 || matches[0].Prompt != "Fix the login bug" {
+		t.Errorf("Search(login debug) = %+v", matches)
+	}
+
+	if matches, _ := store.Search("deploy"); len(matches) != 0 {
+		t.Errorf("expected no matches, got %+v", matches)
+	}
+}
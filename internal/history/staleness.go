@@ -0,0 +1,89 @@
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// maxTrackedFiles bounds how many file hashes an entry records
+const maxTrackedFiles = 2000
+
+// Snapshot records the state of the working tree that a prompt was built from
+type Snapshot struct {
+	GitCommit  string            `json:"git_commit,omitempty"`
+	FileHashes map[string]string `json:"file_hashes,omitempty"` // Absolute path to content hash, "" when missing
+}
+
+// Staleness describes how the working tree has moved on since a snapshot
+type Staleness struct {
+	CommitChanged bool
+	ChangedFiles  []string // Tracked files whose content changed or that were removed
+	TrackedFiles  int
+}
+
+// Stale reports whether anything the prompt was built from has changed
+func (s Staleness) Stale() bool {
+	return s.CommitChanged || len(s.ChangedFiles) > 0
+}
+
+// TakeSnapshot hashes the given files and records the current git commit of dir
+func TakeSnapshot(dir string, paths []string) Snapshot {
+	snapshot := Snapshot{GitCommit: gitCommit(dir)}
+
+	if len(paths) > maxTrackedFiles {
+		paths = paths[:maxTrackedFiles]
+	}
+	if len(paths) > 0 {
+		snapshot.FileHashes = make(map[string]string, len(paths))
+		for _, path := range paths {
+			snapshot.FileHashes[path] = hashFile(path)
+		}
+	}
+
+	return snapshot
+}
+
+// Compare checks the snapshot against the current state of the working tree
+func (s Snapshot) Compare(dir string) Staleness {
+	staleness := Staleness{TrackedFiles: len(s.FileHashes)}
+
+	if s.GitCommit != "" {
+		if current := gitCommit(dir); current != "" && current != s.GitCommit {
+			staleness.CommitChanged = true
+		}
+	}
+
+	for path, hash := range s.FileHashes {
+		if hashFile(path) != hash {
+			staleness.ChangedFiles = append(staleness.ChangedFiles, path)
+		}
+	}
+	sort.Strings(staleness.ChangedFiles)
+
+	return staleness
+}
+
+// hashFile returns a short content hash, or "" when the file can't be read
+func hashFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// gitCommit returns the HEAD commit of the repository containing dir, or ""
+func gitCommit(dir string) string {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
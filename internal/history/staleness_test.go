@@ -0,0 +1,42 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshot_Compare(t *testing.T) {
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "kept.go")
+	edited := filepath.Join(dir, "edited.go")
+	removed := filepath.Join(dir, "removed.go")
+	for _, path := range []string{kept, edited, removed} {
+		if err := os.WriteFile(path, []byte("package main\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshot := TakeSnapshot(dir, []string{kept, edited, removed})
+	if staleness := snapshot.Compare(dir); staleness.Stale() {
+		t.Fatalf("fresh snapshot reported stale: %+v", staleness)
+	}
+
+	if err := os.WriteFile(edited, []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(removed); err != nil {
+		t.Fatal(err)
+	}
+
+	staleness := snapshot.Compare(dir)
+	if !staleness.Stale() || staleness.TrackedFiles != 3 {
+		t.Fatalf("unexpected staleness: %+v", staleness)
+	}
+	if len(staleness.ChangedFiles) != 2 || staleness.ChangedFiles[0] != edited || staleness.ChangedFiles[1] != removed {
+		t.Errorf("ChangedFiles = %v", staleness.ChangedFiles)
+	}
+	if staleness.CommitChanged {
+		t.Error("CommitChanged should be false outside a repository")
+	}
+}
@@ -0,0 +1,153 @@
+package inputs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"prompter-cli/pkg/models"
+)
+
+// Inputs holds every value the interactive flow would otherwise ask for, so a run
+// can be fully described by a file and never wait on a terminal
+type Inputs struct {
+	BasePrompt        string            `toml:"base_prompt" json:"base_prompt"`
+	Pre               string            `toml:"pre" json:"pre"`
+	Post              string            `toml:"post" json:"post"`
+	Files             []string          `toml:"files" json:"files"`
+	Directory         string            `toml:"directory" json:"directory"`
+	DirectoryStrategy string            `toml:"directory_strategy" json:"directory_strategy"`
+	Exclude           []string          `toml:"exclude" json:"exclude"`
+	Fix               bool              `toml:"fix" json:"fix"`
+	FixFile           string            `toml:"fix_file" json:"fix_file"`
+	Target            string            `toml:"target" json:"target"`
+	Vars              map[string]string `toml:"vars" json:"vars"`
+}
+
+// Load reads an inputs file. The format is chosen by extension (.toml or .json) and
+// unknown keys are rejected so typos fail loudly instead of being ignored.
+func Load(path string) (*Inputs, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inputs file %s: %w", path, err)
+	}
+
+	var in Inputs
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&in); err != nil {
+			return nil, fmt.Errorf("failed to parse inputs file %s: %w", path, err)
+		}
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&in); err != nil {
+			return nil, fmt.Errorf("failed to parse inputs file %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported inputs file %s (must be .toml or .json)", path)
+	}
+
+	return &in, nil
+}
+
+// Apply copies the inputs onto a request. Values already set on the request (from
+// flags or arguments) take precedence; files are combined. Relative paths are
+// resolved against baseDir, normally the directory holding the inputs file.
+func (in *Inputs) Apply(request *models.PromptRequest, baseDir string) {
+	resolve := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(baseDir, path)
+	}
+
+	if request.BasePrompt == "" {
+		request.BasePrompt = in.BasePrompt
+	}
+	if request.PreTemplate == "" {
+		request.PreTemplate = in.Pre
+	}
+	if request.PostTemplate == "" {
+		request.PostTemplate = in.Post
+	}
+	for _, file := range in.Files {
+		request.Files = append(request.Files, resolve(file))
+	}
+	if request.Directory == "" {
+		request.Directory = resolve(in.Directory)
+	}
+	if request.DirectoryStrategy == "" {
+		request.DirectoryStrategy = in.DirectoryStrategy
+	}
+	request.Exclude = append(request.Exclude, in.Exclude...)
+	if in.Fix {
+		request.FixMode = true
+	}
+	if request.FixFile == "" {
+		request.FixFile = resolve(in.FixFile)
+	}
+	if request.Target == "" {
+		request.Target = in.Target
+	}
+	if len(in.Vars) > 0 {
+		if request.Vars == nil {
+			request.Vars = make(map[string]string)
+		}
+		for name, value := range in.Vars {
+			if _, set := request.Vars[name]; !set {
+				request.Vars[name] = value
+			}
+		}
+	}
+}
+
+// Validate checks that a request can run to completion without prompting. Every
+// problem found is reported together rather than stopping at the first.
+func Validate(request *models.PromptRequest) error {
+	var problems []string
+
+	if request.FixMode {
+		// Without a fix file, fix mode would re-run a command from shell history
+		if request.FixFile == "" {
+			problems = append(problems, "fix_file is required in fix mode")
+		} else if _, err := os.Stat(request.FixFile); err != nil {
+			problems = append(problems, fmt.Sprintf("fix_file %s does not exist", request.FixFile))
+		}
+	} else if strings.TrimSpace(request.BasePrompt) == "" && !request.FromClipboard {
+		problems = append(problems, "base_prompt is required")
+	}
+
+	for _, file := range request.Files {
+		if info, err := os.Stat(file); err != nil {
+			problems = append(problems, fmt.Sprintf("file %s does not exist", file))
+		} else if info.IsDir() {
+			problems = append(problems, fmt.Sprintf("file %s is a directory (use directory instead)", file))
+		}
+	}
+
+	if request.Directory != "" {
+		if info, err := os.Stat(request.Directory); err != nil || !info.IsDir() {
+			problems = append(problems, fmt.Sprintf("directory %s does not exist", request.Directory))
+		}
+	}
+
+	if request.DirectoryStrategy != "" && request.DirectoryStrategy != "git" && request.DirectoryStrategy != "filesystem" {
+		problems = append(problems, fmt.Sprintf("invalid directory_strategy %s (must be 'git' or 'filesystem')", request.DirectoryStrategy))
+	}
+
+	if request.Target != "" && !models.ValidTarget(request.Target) {
+		problems = append(problems, fmt.Sprintf("invalid target %s (must be %s)", request.Target, models.TargetUsage))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid inputs:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
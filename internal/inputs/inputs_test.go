@@ -0,0 +1,110 @@
+package inputs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompter-cli/pkg/models"
+)
+
+func TestLoad_TOMLAndJSON(t *testing.T) {
+	dir := t.TempDir()
+
+	tomlPath := filepath.Join(dir, "inputs.toml")
+	tomlData := `base_prompt = "summarize"
+pre = "question"
+files = ["notes.md"]
+
+[vars]
+audience = "reviewers"
+`
+	if err := os.WriteFile(tomlPath, []byte(tomlData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	jsonPath := filepath.Join(dir, "inputs.json")
+	jsonData := `{"base_prompt": "summarize", "pre": "question", "files": ["notes.md"], "vars": {"audience": "reviewers"}}`
+	if err := os.WriteFile(jsonPath, []byte(jsonData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{tomlPath, jsonPath} {
+		in, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load(%s) failed: %v", path, err)
+		}
+		if in.BasePrompt != "summarize" || in.Pre != "question" || len(in.Files) != 1 || in.Vars["audience"] != "reviewers" {
+			t.Errorf("unexpected inputs from %s: %+v", path, in)
+		}
+	}
+}
+
+func TestLoad_RejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inputs.toml")
+	if err := os.WriteFile(path, []byte("base_promt = \"typo\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+}
+
+func TestApply_FlagsTakePrecedence(t *testing.T) {
+	in := &Inputs{
+		BasePrompt: "from file",
+		Pre:        "question",
+		Files:      []string{"notes.md", "/abs/other.md"},
+		Target:     "stdout",
+		Vars:       map[string]string{"a": "file", "b": "file"},
+	}
+	request := models.NewPromptRequest()
+	request.BasePrompt = "from flag"
+	request.Vars = map[string]string{"a": "flag"}
+
+	in.Apply(request, "/base")
+
+	if request.BasePrompt != "from flag" || request.PreTemplate != "question" || request.Target != "stdout" {
+		t.Errorf("unexpected request: %+v", request)
+	}
+	if strings.Join(request.Files, ",") != filepath.Join("/base", "notes.md")+",/abs/other.md" {
+		t.Errorf("Files = %v", request.Files)
+	}
+	if request.Vars["a"] != "flag" || request.Vars["b"] != "file" {
+		t.Errorf("Vars = %v", request.Vars)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(existing, []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := &models.PromptRequest{BasePrompt: "go", Files: []string{existing}, Directory: dir, Target: "stdout"}
+	if err := Validate(valid); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalid := &models.PromptRequest{
+		Files:     []string{filepath.Join(dir, "missing.go")},
+		Directory: filepath.Join(dir, "nope"),
+		Target:    "printer",
+	}
+	err := Validate(invalid)
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{"base_prompt is required", "missing.go does not exist", "nope does not exist", "invalid target printer"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+
+	if err := Validate(&models.PromptRequest{FixMode: true}); err == nil || !strings.Contains(err.Error(), "fix_file is required") {
+		t.Errorf("expected fix_file error, got %v", err)
+	}
+}
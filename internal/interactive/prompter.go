@@ -2,31 +2,101 @@ package interactive
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
 
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/terminal"
 	"github.com/atotto/clipboard"
 	"golang.org/x/term"
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/stats"
+	"prompter-cli/internal/template"
 	"prompter-cli/pkg/models"
 )
 
+// ErrSelectionCancelled is returned when the user cancels a number-key selection
+var ErrSelectionCancelled = errors.New("selection cancelled")
+
+// ErrMissingInputs is returned when required inputs are missing in non-interactive mode
+var ErrMissingInputs = errors.New("missing required inputs")
+
+// usageSectionSize is how many most used and recently used templates are listed
+// at the top of the template selectors
+const usageSectionSize = 3
+
 // Prompter handles interactive user input collection
 type Prompter struct {
-	promptsLocation string
+	promptsLocation  string
+	promptsLocations []string     // Every prompts location searched, promptsLocation first
+	usage            *stats.Stats // Template usage used to rank the selectors, nil when disabled
+	editor           string       // Editor offered for writing the base prompt, empty when none is configured
+	confirm          PreviewFunc  // Renders the summary shown before generating, nil to skip it
+	customTemplates  map[string]interfaces.CustomTemplate
 }
 
 // NewPrompter creates a new interactive prompter
 func NewPrompter(promptsLocation string) *Prompter {
 	return &Prompter{
-		promptsLocation: promptsLocation,
+		promptsLocation:  promptsLocation,
+		promptsLocations: []string{promptsLocation},
 	}
 }
 
+// SetPromptsLocations sets the prompts locations searched for templates in order,
+// for a prompts_location given as a list
+func (p *Prompter) SetPromptsLocations(locations []string) {
+	if len(locations) == 0 {
+		return
+	}
+	p.promptsLocation = locations[0]
+	p.promptsLocations = locations
+}
+
+// processor returns a template processor searching the prompter's locations
+func (p *Prompter) processor() *template.Processor {
+	processor := template.NewProcessor(p.promptsLocation)
+	processor.SetPromptsLocations(p.promptsLocations)
+	processor.SetCustomTemplates(p.customTemplates)
+	return processor
+}
+
+// SetCustomTemplates sets the configured custom templates. Those with interactive =
+// true are offered after the template selectors.
+func (p *Prompter) SetCustomTemplates(customTemplates map[string]interfaces.CustomTemplate) {
+	p.customTemplates = customTemplates
+}
+
+// SetUsage sets the template usage stats used to list most used and recently used
+// templates first in the selectors
+func (p *Prompter) SetUsage(usage *stats.Stats) {
+	p.usage = usage
+}
+
+// SetEditor sets the editor offered for writing a multi-line base prompt
+func (p *Prompter) SetEditor(editor string) {
+	p.editor = editor
+}
+
+// SetConfirmation makes CollectMissingInputs show a summary of the prompt that
+// preview renders and ask whether to proceed before returning
+func (p *Prompter) SetConfirmation(preview PreviewFunc) {
+	p.confirm = preview
+}
+
+// IsAbort reports whether err means the user cancelled an interactive prompt
+func IsAbort(err error) bool {
+	return errors.Is(err, terminal.InterruptErr) || errors.Is(err, ErrSelectionCancelled)
+}
+
 // CollectMissingInputs prompts the user for any missing required inputs
 func (p *Prompter) CollectMissingInputs(request *models.PromptRequest) error {
 	// Handle clipboard reading - append to existing prompt or use as base prompt
@@ -38,7 +108,11 @@ func (p *Prompter) CollectMissingInputs(request *models.PromptRequest) error {
 	}
 
 	if !request.Interactive {
-		return nil // Skip interactive prompts in noninteractive mode
+		// Nothing can be asked for, so report everything that's missing at once
+		if missing := p.missingInputs(request); len(missing) > 0 {
+			return fmt.Errorf("%w, which can't be asked for in non-interactive mode:\n  %s", ErrMissingInputs, strings.Join(missing, "\n  "))
+		}
+		return nil
 	}
 
 	// Collect base prompt if missing and not in fix mode (only in interactive mode)
@@ -48,6 +122,33 @@ func (p *Prompter) CollectMissingInputs(request *models.PromptRequest) error {
 		}
 	}
 
+	// Ask for the selections, starting over from the given inputs each time the
+	// summary is sent back for editing
+	given := *request
+	given.Vars = copyVars(request.Vars)
+	for {
+		if err := p.collectSelections(request); err != nil {
+			return err
+		}
+
+		edit, err := p.showConfirmationSummary(request)
+		if err != nil {
+			return fmt.Errorf("failed to confirm inputs: %w", err)
+		}
+		if !edit {
+			return nil
+		}
+
+		request.PreTemplate = given.PreTemplate
+		request.PostTemplate = given.PostTemplate
+		request.Vars = copyVars(given.Vars)
+		request.Directory = given.Directory
+	}
+}
+
+// collectSelections asks for the templates, their variables, and the directory
+// when they weren't given
+func (p *Prompter) collectSelections(request *models.PromptRequest) error {
 	// Collect pre-template if not specified
 	if request.PreTemplate == "" && !request.FixMode {
 		if err := p.promptForPreTemplate(request); err != nil {
@@ -62,6 +163,20 @@ func (p *Prompter) CollectMissingInputs(request *models.PromptRequest) error {
 		}
 	}
 
+	// Offer the custom templates meant for the guided flow
+	if !request.FixMode {
+		if err := p.promptForCustomTemplates(request); err != nil {
+			return fmt.Errorf("failed to collect custom templates: %w", err)
+		}
+	}
+
+	// Ask for variables declared in the selected templates' frontmatter
+	if !request.FixMode {
+		if err := p.promptForVariables(request); err != nil {
+			return fmt.Errorf("failed to collect template variables: %w", err)
+		}
+	}
+
 	// Collect directory inclusion if not specified
 	if request.Directory == "" && len(request.Files) == 0 && !request.FixMode {
 		if err := p.promptForDirectoryInclusion(request); err != nil {
@@ -69,12 +184,46 @@ func (p *Prompter) CollectMissingInputs(request *models.PromptRequest) error {
 		}
 	}
 
-	// Show confirmation summary
-	if err := p.showConfirmationSummary(request); err != nil {
-		return fmt.Errorf("user cancelled operation: %w", err)
+	return nil
+}
+
+// missingInputs describes the inputs a non-interactive request needs but wasn't
+// given: the base prompt, and the required variables of its templates
+func (p *Prompter) missingInputs(request *models.PromptRequest) []string {
+	if request.FixMode {
+		return nil // Fix mode builds the prompt from the command output
 	}
 
-	return nil
+	var missing []string
+	if request.BasePrompt == "" {
+		missing = append(missing, "base prompt: pass it as an argument, or use --clipboard")
+	}
+
+	processor := p.processor()
+	for _, name := range append(models.TemplateNames(request.PreTemplate), models.TemplateNames(request.PostTemplate)...) {
+		variables, err := processor.TemplateVariables(name)
+		if err != nil {
+			continue // Missing or broken templates are reported when the prompt is generated
+		}
+		for _, variable := range variables {
+			if _, ok := request.Vars[variable.Name]; !ok && variable.Required {
+				missing = append(missing, fmt.Sprintf("variable %s of template %s: pass --var %s=VALUE", variable.Name, name, variable.Name))
+			}
+		}
+	}
+	return missing
+}
+
+// copyVars returns a copy of vars so edits to it don't reach the original
+func copyVars(vars map[string]string) map[string]string {
+	if vars == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(vars))
+	for name, value := range vars {
+		copied[name] = value
+	}
+	return copied
 }
 
 // appendClipboardToPrompt reads from clipboard and appends to existing prompt or uses as base prompt
@@ -106,33 +255,105 @@ func (p *Prompter) appendClipboardToPrompt(request *models.PromptRequest) error
 	return nil
 }
 
-// promptForBasePrompt asks the user to enter a base prompt
+// promptForBasePrompt asks the user to enter a base prompt, or to leave it empty and
+// write it in the editor when one is configured
 func (p *Prompter) promptForBasePrompt(request *models.PromptRequest) error {
 	prompt := &survey.Input{
 		Message: "Enter your base prompt:",
 		Help:    "This is the main prompt text that will be sent to the AI",
 	}
 
+	var options []survey.AskOpt
+	if p.editor != "" {
+		prompt.Message = fmt.Sprintf("Enter your base prompt (or press Enter to write it in %s):", p.editor)
+	} else {
+		options = append(options, survey.WithValidator(survey.Required))
+	}
+
 	var basePrompt string
-	if err := survey.AskOne(prompt, &basePrompt, survey.WithValidator(survey.Required)); err != nil {
+	if err := survey.AskOne(prompt, &basePrompt, options...); err != nil {
 		return err
 	}
 
 	request.BasePrompt = strings.TrimSpace(basePrompt)
+	if request.BasePrompt != "" || p.editor == "" {
+		return nil
+	}
+
+	composed, err := p.composeInEditor("")
+	if err != nil {
+		return err
+	}
+	if composed == "" {
+		return fmt.Errorf("the base prompt was left empty in %s", p.editor)
+	}
+	request.BasePrompt = composed
 	return nil
 }
 
+// composeInEditor opens the editor on a temporary file holding text and returns what
+// was saved, trimmed
+func (p *Prompter) composeInEditor(text string) (string, error) {
+	path, err := writePromptFile(text)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command(p.editor, path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("failed to launch editor %s: %w", p.editor, err)
+	}
+
+	return readPromptFile(path)
+}
+
+// writePromptFile writes text to a temporary file to be edited, returning its path
+func writePromptFile(text string) (string, error) {
+	file, err := os.CreateTemp("", "prompter-prompt-*.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// readPromptFile returns the trimmed contents of a file from writePromptFile and
+// removes it
+func readPromptFile(path string) (string, error) {
+	defer os.Remove(path)
+	composed, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read temporary file: %w", err)
+	}
+	return strings.TrimSpace(string(composed)), nil
+}
+
 // promptForPreTemplate asks the user to select a pre-template
 func (p *Prompter) promptForPreTemplate(request *models.PromptRequest) error {
 	templates, err := p.findTemplates("pre")
 	if err != nil {
 		return fmt.Errorf("failed to find pre templates: %w", err)
 	}
+	templates = appendEmbeddedTemplates(templates, "pre")
 
 	// Build options with proper ordering: defaults first, then "None", then regulars
 	options := p.buildOptionsWithNone(templates, "pre")
+	options, labels := p.rankByUsage(options, "pre")
+	labels = p.describeTemplates(options, labels)
 
-	selected, err := p.selectTemplate(options, "Select a pre-template (prepended to prompt):", "Pre-templates are added before your base prompt", request.NumberSelect)
+	selected, err := p.selectLabeledTemplate(options, labels, "Select a pre-template (prepended to prompt):", "Pre-templates are added before your base prompt", request.NumberSelect)
 	if err != nil {
 		return err
 	}
@@ -150,11 +371,14 @@ func (p *Prompter) promptForPostTemplate(request *models.PromptRequest) error {
 	if err != nil {
 		return fmt.Errorf("failed to find post templates: %w", err)
 	}
+	templates = appendEmbeddedTemplates(templates, "post")
 
 	// Build options with proper ordering: defaults first, then "None", then regulars
 	options := p.buildOptionsWithNone(templates, "post")
+	options, labels := p.rankByUsage(options, "post")
+	labels = p.describeTemplates(options, labels)
 
-	selected, err := p.selectTemplate(options, "Select a post-template (appended to prompt):", "Post-templates are added after your base prompt", request.NumberSelect)
+	selected, err := p.selectLabeledTemplate(options, labels, "Select a post-template (appended to prompt):", "Post-templates are added after your base prompt", request.NumberSelect)
 	if err != nil {
 		return err
 	}
@@ -166,6 +390,170 @@ func (p *Prompter) promptForPostTemplate(request *models.PromptRequest) error {
 	return nil
 }
 
+// promptForCustomTemplates offers each custom template with interactive = true that
+// the request doesn't use yet. One whose flag takes a value asks for the value, an
+// empty answer leaving the template out; the others ask whether to add it.
+func (p *Prompter) promptForCustomTemplates(request *models.PromptRequest) error {
+	names := make([]string, 0, len(p.customTemplates))
+	for name, custom := range p.customTemplates {
+		if custom.Interactive {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		custom := p.customTemplates[name]
+		templateName, err := template.CustomTemplateName(custom)
+		if err != nil {
+			continue // An empty location has nothing to offer
+		}
+		value := &request.PreTemplate
+		if custom.Type == "post" {
+			value = &request.PostTemplate
+		}
+		if slices.ContainsFunc(models.TemplateNames(*value), func(selected string) bool {
+			return strings.EqualFold(selected, templateName)
+		}) {
+			continue
+		}
+
+		label := custom.Description
+		if label == "" {
+			label = fmt.Sprintf("%s-template '%s'", custom.Type, name)
+		}
+
+		if _, given := request.Vars[custom.Variable]; custom.Variable != "" && !given {
+			input := &survey.Input{
+				Message: fmt.Sprintf("%s, %s (empty to skip):", label, custom.Variable),
+				Help:    fmt.Sprintf("Fills .Vars.%s, as --%s VALUE does", custom.Variable, custom.Flag),
+			}
+			var answer string
+			if err := survey.AskOne(input, &answer); err != nil {
+				return err
+			}
+			if answer = strings.TrimSpace(answer); answer == "" {
+				continue
+			}
+			if request.Vars == nil {
+				request.Vars = make(map[string]string)
+			}
+			request.Vars[custom.Variable] = answer
+		} else {
+			add, err := p.selectYesNo(fmt.Sprintf("Add %s?", label), fmt.Sprintf("Same as --%s", custom.Flag), false, request.NumberSelect)
+			if err != nil {
+				return err
+			}
+			if !add {
+				continue
+			}
+		}
+
+		*value = models.JoinTemplateNames(append(models.TemplateNames(*value), templateName))
+	}
+
+	return nil
+}
+
+// promptForVariables asks for each variable the selected templates read (declared in
+// frontmatter or referenced as .Vars fields) that doesn't already have a value
+func (p *Prompter) promptForVariables(request *models.PromptRequest) error {
+	processor := p.processor()
+
+	for _, name := range append(models.TemplateNames(request.PreTemplate), models.TemplateNames(request.PostTemplate)...) {
+		variables, err := processor.TemplateVariables(name)
+		if err != nil {
+			continue // Missing or broken templates are reported when the prompt is generated
+		}
+
+		for _, variable := range variables {
+			if _, ok := request.Vars[variable.Name]; ok {
+				continue
+			}
+
+			value, err := p.askVariable(variable, name)
+			if err != nil {
+				return err
+			}
+
+			if request.Vars == nil {
+				request.Vars = make(map[string]string)
+			}
+			request.Vars[variable.Name] = value
+		}
+	}
+
+	return nil
+}
+
+// askVariable asks for a single template variable using an input suited to its type
+func (p *Prompter) askVariable(variable template.Variable, templateName string) (string, error) {
+	message := variable.Prompt
+	if message == "" {
+		message = variable.Name
+	}
+	message = fmt.Sprintf("%s (%s):", strings.TrimSuffix(message, ":"), templateName)
+
+	switch variable.Type {
+	case template.VariableBool:
+		prompt := &survey.Confirm{
+			Message: message,
+			Help:    variable.Description,
+			Default: variable.Default == "true",
+		}
+		var value bool
+		if err := survey.AskOne(prompt, &value); err != nil {
+			return "", err
+		}
+		return strconv.FormatBool(value), nil
+
+	case template.VariableChoice:
+		prompt := &survey.Select{
+			Message: message,
+			Help:    variable.Description,
+			Options: variable.Options,
+		}
+		for _, option := range variable.Options {
+			if option == variable.Default {
+				prompt.Default = option
+			}
+		}
+		var value string
+		if err := survey.AskOne(prompt, &value); err != nil {
+			return "", err
+		}
+		return value, nil
+	}
+
+	prompt := &survey.Input{
+		Message: message,
+		Help:    variable.Description,
+		Default: variable.Default,
+	}
+	var options []survey.AskOpt
+	if variable.Required {
+		options = append(options, survey.WithValidator(survey.Required))
+	}
+	if variable.Type == template.VariableNumber {
+		options = append(options, survey.WithValidator(func(answer interface{}) error {
+			text := strings.TrimSpace(fmt.Sprint(answer))
+			if text == "" && !variable.Required {
+				return nil
+			}
+			if _, err := strconv.ParseFloat(text, 64); err != nil {
+				return fmt.Errorf("%s must be a number", variable.Name)
+			}
+			return nil
+		}))
+	}
+
+	var value string
+	if err := survey.AskOne(prompt, &value, options...); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(value), nil
+}
+
 // promptForDirectoryInclusion asks whether to include directory context
 func (p *Prompter) promptForDirectoryInclusion(request *models.PromptRequest) error {
 	includeDirectory, err := p.selectYesNo(
@@ -190,52 +578,97 @@ func (p *Prompter) promptForDirectoryInclusion(request *models.PromptRequest) er
 	return nil
 }
 
-// showConfirmationSummary displays a summary and asks for confirmation
-func (p *Prompter) showConfirmationSummary(request *models.PromptRequest) error {
-	// Skip confirmation prompt entirely
-	return nil
-}
+// Choices offered by the confirmation summary
+const (
+	confirmProceed = "Proceed"
+	confirmEdit    = "Edit selections"
+	confirmAbort   = "Abort"
+)
 
-// findTemplates discovers available templates in the specified subdirectory
-func (p *Prompter) findTemplates(subdir string) ([]string, error) {
-	templateDir := filepath.Join(p.promptsLocation, subdir)
-	
-	// Check if directory exists
-	if _, err := os.Stat(templateDir); os.IsNotExist(err) {
-		return []string{}, nil // Return empty list if directory doesn't exist
+// showConfirmationSummary prints the templates, context size, and target of the
+// prompt about to be generated and asks whether to proceed. It reports whether
+// the selections should be asked again, and returns ErrSelectionCancelled on abort.
+func (p *Prompter) showConfirmationSummary(request *models.PromptRequest) (bool, error) {
+	if p.confirm == nil || request.FixMode {
+		return false, nil
+	}
+
+	preview, err := p.confirm(request)
+	if err != nil {
+		return false, err
 	}
+	fmt.Print(formatSummary(preview))
 
-	entries, err := os.ReadDir(templateDir)
+	selected, err := p.selectTemplate(
+		[]string{confirmProceed, confirmEdit, confirmAbort},
+		"Generate this prompt?",
+		"Edit selections asks for the templates, variables, and directory again",
+		request.NumberSelect,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read template directory %s: %w", templateDir, err)
+		return false, err
 	}
 
+	switch selected {
+	case confirmEdit:
+		return true, nil
+	case confirmAbort:
+		return false, ErrSelectionCancelled
+	}
+	return false, nil
+}
+
+// formatSummary renders the confirmation summary for a preview
+func formatSummary(preview *Preview) string {
+	templates := "none"
+	if len(preview.Templates) > 0 {
+		templates = strings.Join(preview.Templates, ", ")
+	}
+
+	var b strings.Builder
+	b.WriteString("\nSummary:\n")
+	fmt.Fprintf(&b, "  Templates: %s\n", templates)
+	fmt.Fprintf(&b, "  Files:     %d\n", preview.Files)
+	fmt.Fprintf(&b, "  Size:      %d bytes, ~%d tokens\n", preview.Bytes, preview.Tokens)
+	fmt.Fprintf(&b, "  Target:    %s\n", preview.Target)
+	for _, warning := range preview.Warnings {
+		fmt.Fprintf(&b, "  Warning: %s\n", warning)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// findTemplates discovers available templates in the specified subdirectory of each
+// prompts location, defaults first. A template shadows same-named templates in the
+// locations after it.
+func (p *Prompter) findTemplates(subdir string) ([]string, error) {
 	var defaultTemplates []string
 	var regularTemplates []string
-	
-	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
-			// Remove .md extension for processing
-			name := strings.TrimSuffix(entry.Name(), ".md")
-			
-			// Check if this is a default template
-			if strings.Contains(name, ".default.") {
-				// Strip the .default. part for display
-				displayName := strings.ReplaceAll(name, ".default.", ".")
-				// Remove any leading/trailing dots that might result
-				displayName = strings.Trim(displayName, ".")
-				defaultTemplates = append(defaultTemplates, displayName)
-			} else if strings.HasSuffix(name, ".default") {
-				// Handle case where .default is at the end
-				displayName := strings.TrimSuffix(name, ".default")
-				defaultTemplates = append(defaultTemplates, displayName)
-			} else {
+	seen := make(map[string]bool)
+
+	for _, location := range p.promptsLocations {
+		defaults, regular, err := readTemplateDir(filepath.Join(location, subdir))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range defaults {
+			if !seen[strings.ToLower(name)] {
+				defaultTemplates = append(defaultTemplates, name)
+			}
+		}
+		for _, name := range regular {
+			if !seen[strings.ToLower(name)] {
 				regularTemplates = append(regularTemplates, name)
 			}
 		}
+		for _, name := range append(defaults, regular...) {
+			seen[strings.ToLower(name)] = true
+		}
 	}
 
-	// Combine lists with defaults first
+	// Combine lists with defaults first, each grouped by namespace
+	groupByNamespace(defaultTemplates)
+	groupByNamespace(regularTemplates)
 	var templates []string
 	templates = append(templates, defaultTemplates...)
 	templates = append(templates, regularTemplates...)
@@ -243,6 +676,56 @@ func (p *Prompter) findTemplates(subdir string) ([]string, error) {
 	return templates, nil
 }
 
+// readTemplateDir lists the display names of the templates in a directory and its
+// namespace subdirectories, split into .default templates and the rest. A missing
+// directory has none.
+func readTemplateDir(templateDir string) ([]string, []string, error) {
+	files, err := template.ReadTemplateDir(templateDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read template directory %s: %w", templateDir, err)
+	}
+
+	var defaults []string
+	var regular []string
+	for _, file := range files {
+		if file.Default {
+			defaults = append(defaults, file.Name)
+		} else {
+			regular = append(regular, file.Name)
+		}
+	}
+	return defaults, regular, nil
+}
+
+// groupByNamespace orders templates so those of a namespace are listed together,
+// templates without one first, keeping the order within each group
+func groupByNamespace(templates []string) {
+	sort.SliceStable(templates, func(i, j int) bool {
+		first, second := template.Namespace(templates[i]), template.Namespace(templates[j])
+		if first == "" || second == "" {
+			return first == "" && second != ""
+		}
+		return strings.ToLower(first) < strings.ToLower(second)
+	})
+}
+
+// appendEmbeddedTemplates adds built-in templates that aren't shadowed by a template on disk
+func appendEmbeddedTemplates(templates []string, subdir string) []string {
+	for _, name := range template.EmbeddedTemplateNames(subdir) {
+		shadowed := false
+		for _, existing := range templates {
+			if strings.EqualFold(existing, name) {
+				shadowed = true
+				break
+			}
+		}
+		if !shadowed {
+			templates = append(templates, name)
+		}
+	}
+	return templates
+}
+
 
 
 // buildOptionsWithNone constructs the options list with proper ordering:
@@ -250,45 +733,22 @@ func (p *Prompter) findTemplates(subdir string) ([]string, error) {
 func (p *Prompter) buildOptionsWithNone(templates []string, subdir string) []string {
 	// We need to separate default templates from regular templates
 	// to insert "None" in the right place
-	templateDir := filepath.Join(p.promptsLocation, subdir)
-	
+	defaultNames := make(map[string]bool)
+	for _, location := range p.promptsLocations {
+		defaults, _, _ := readTemplateDir(filepath.Join(location, subdir))
+		for _, name := range defaults {
+			defaultNames[name] = true
+		}
+	}
+
 	var defaultTemplates []string
 	var regularTemplates []string
-	
-	// Check if directory exists
-	if entries, err := os.ReadDir(templateDir); err == nil {
-		// Build a map of which templates are defaults
-		defaultNames := make(map[string]bool)
-		
-		for _, entry := range entries {
-			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
-				name := strings.TrimSuffix(entry.Name(), ".md")
-				
-				// Check if this is a default template
-				if strings.Contains(name, ".default.") || strings.HasSuffix(name, ".default") {
-					var displayName string
-					if strings.Contains(name, ".default.") {
-						displayName = strings.ReplaceAll(name, ".default.", ".")
-						displayName = strings.Trim(displayName, ".")
-					} else {
-						displayName = strings.TrimSuffix(name, ".default")
-					}
-					defaultNames[displayName] = true
-				}
-			}
-		}
-		
-		// Separate templates based on whether they're defaults
-		for _, template := range templates {
-			if defaultNames[template] {
-				defaultTemplates = append(defaultTemplates, template)
-			} else {
-				regularTemplates = append(regularTemplates, template)
-			}
+	for _, template := range templates {
+		if defaultNames[template] {
+			defaultTemplates = append(defaultTemplates, template)
+		} else {
+			regularTemplates = append(regularTemplates, template)
 		}
-	} else {
-		// Fallback: if we can't read the directory, treat all as regular
-		regularTemplates = templates
 	}
 	
 	// Build final options list: defaults first, then "None", then regulars
@@ -300,14 +760,81 @@ func (p *Prompter) buildOptionsWithNone(templates []string, subdir string) []str
 	return options
 }
 
+// rankByUsage moves the most used and recently used templates to the top of the
+// options, returning a label for each moved template
+func (p *Prompter) rankByUsage(options []string, kind string) ([]string, map[string]string) {
+	if p.usage == nil {
+		return options, nil
+	}
+
+	available := make(map[string]bool, len(options))
+	for _, option := range options {
+		available[option] = true
+	}
+
+	var ranked []string
+	labels := make(map[string]string)
+	addSection := func(names []string, label string) {
+		for _, name := range names {
+			if available[name] && labels[name] == "" {
+				ranked = append(ranked, name)
+				labels[name] = label
+			}
+		}
+	}
+	addSection(p.usage.MostUsed(kind, usageSectionSize), "most used")
+	addSection(p.usage.RecentlyUsed(kind, usageSectionSize), "recently used")
+
+	if len(ranked) == 0 {
+		return options, nil
+	}
+	for _, option := range options {
+		if labels[option] == "" {
+			ranked = append(ranked, option)
+		}
+	}
+	return ranked, labels
+}
+
+// describeTemplates adds the frontmatter description of each template to its label
+func (p *Prompter) describeTemplates(options []string, labels map[string]string) map[string]string {
+	processor := p.processor()
+
+	for _, option := range options {
+		if option == "None" {
+			continue
+		}
+		meta, err := processor.TemplateMetadata(option)
+		if err != nil || meta == nil || meta.Description == "" {
+			continue
+		}
+
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		if label := labels[option]; label != "" {
+			labels[option] = label + " - " + meta.Description
+		} else {
+			labels[option] = meta.Description
+		}
+	}
+
+	return labels
+}
+
 // selectTemplate handles template selection with optional number key support
 func (p *Prompter) selectTemplate(options []string, message, help string, numberSelect bool) (string, error) {
+	return p.selectLabeledTemplate(options, nil, message, help, numberSelect)
+}
+
+// selectLabeledTemplate is selectTemplate with a label shown next to some options
+func (p *Prompter) selectLabeledTemplate(options []string, labels map[string]string, message, help string, numberSelect bool) (string, error) {
 	if len(options) == 0 {
 		return "None", nil
 	}
 
 	if numberSelect {
-		return p.selectTemplateWithNumbers(options, message, help)
+		return p.selectTemplateWithNumbers(options, labels, message, help)
 	}
 
 	// Use regular survey selection
@@ -316,6 +843,11 @@ func (p *Prompter) selectTemplate(options []string, message, help string, number
 		Options: options,
 		Help:    help,
 	}
+	if len(labels) > 0 {
+		prompt.Description = func(value string, index int) string {
+			return labels[value]
+		}
+	}
 
 	var selected string
 	if err := survey.AskOne(prompt, &selected); err != nil {
@@ -326,7 +858,7 @@ func (p *Prompter) selectTemplate(options []string, message, help string, number
 }
 
 // selectTemplateWithNumbers displays numbered options and allows instant selection by number key
-func (p *Prompter) selectTemplateWithNumbers(options []string, message, help string) (string, error) {
+func (p *Prompter) selectTemplateWithNumbers(options []string, labels map[string]string, message, help string) (string, error) {
 	fmt.Printf("\n%s\n", message)
 	if help != "" {
 		fmt.Printf("  %s (Press number key for instant selection or use arrow keys)\n", help)
@@ -335,7 +867,11 @@ func (p *Prompter) selectTemplateWithNumbers(options []string, message, help str
 
 	// Display numbered options
 	for i, option := range options {
-		fmt.Printf("  %d. %s\n", i+1, option)
+		if label := labels[option]; label != "" {
+			fmt.Printf("  %d. %s (%s)\n", i+1, option, label)
+		} else {
+			fmt.Printf("  %d. %s\n", i+1, option)
+		}
 	}
 	fmt.Println()
 
@@ -386,7 +922,7 @@ func (p *Prompter) selectTemplateWithNumbers(options []string, message, help str
 		// Handle Escape or Ctrl+C
 		if char == 27 || char == 3 {
 			fmt.Println()
-			return "", fmt.Errorf("selection cancelled")
+			return "", ErrSelectionCancelled
 		}
 
 		// For any other key, continue waiting
@@ -509,7 +1045,7 @@ func (p *Prompter) selectYesNoWithNumbers(message, help string, defaultValue boo
 		// Handle Escape or Ctrl+C
 		if char == 27 || char == 3 {
 			fmt.Println()
-			return false, fmt.Errorf("selection cancelled")
+			return false, ErrSelectionCancelled
 		}
 
 		// For any other key, continue waiting
@@ -613,4 +1149,99 @@ func (p *Prompter) ConfirmOverwrite(filePath string) (bool, error) {
 	}
 
 	return overwrite, nil
-}
\ No newline at end of file
+}
+
+// ConfirmConfigMigration asks the user whether to rewrite an outdated config file
+func (p *Prompter) ConfirmConfigMigration(filePath string, fromVersion, toVersion int) (bool, error) {
+	migratePrompt := &survey.Confirm{
+		Message: fmt.Sprintf("Upgrade %s from version %d to %d?", filePath, fromVersion, toVersion),
+		Default: true,
+	}
+
+	var migrate bool
+	if err := survey.AskOne(migratePrompt, &migrate); err != nil {
+		return false, err
+	}
+
+	return migrate, nil
+}
+
+// CollectFollowUp asks for the follow-up text when continuing a previous prompt
+func (p *Prompter) CollectFollowUp() (string, error) {
+	followUpPrompt := &survey.Multiline{
+		Message: "Follow-up prompt:",
+	}
+
+	var followUp string
+	if err := survey.AskOne(followUpPrompt, &followUp, survey.WithValidator(survey.Required)); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(followUp), nil
+}
+
+// SetupChoices are the answers collected by the init wizard
+type SetupChoices struct {
+	Project          bool   // Set up .prmpt/ in the current directory instead of the global config
+	AskScope         bool   // Whether to ask for Project; false when given by a flag
+	Target           string // Default output target
+	Editor           string
+	StarterTemplates bool // Copy the built-in templates into the prompts directory
+}
+
+// CollectSetup asks the init wizard's questions, starting from the defaults in choices
+func (p *Prompter) CollectSetup(choices *SetupChoices) error {
+	if choices.AskScope {
+		scopePrompt := &survey.Select{
+			Message: "Where should prompter be set up?",
+			Options: []string{"Globally (~/.config/prompter)", "For this project (.prmpt/)"},
+			Help:    "Project setups keep templates and settings with the repository",
+		}
+		var scope int
+		if err := survey.AskOne(scopePrompt, &scope); err != nil {
+			return err
+		}
+		choices.Project = scope == 1
+	}
+
+	targetPrompt := &survey.Select{
+		Message: "Where should generated prompts go by default?",
+		Options: []string{models.TargetClipboard, models.TargetOSC52, models.TargetStdout},
+		Default: choices.Target,
+	}
+	if err := survey.AskOne(targetPrompt, &choices.Target); err != nil {
+		return err
+	}
+
+	// The editor is only read from the global config
+	if !choices.Project {
+		editorPrompt := &survey.Input{
+			Message: "Editor for templates and prompts:",
+			Default: choices.Editor,
+		}
+		if err := survey.AskOne(editorPrompt, &choices.Editor, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+	}
+
+	starterPrompt := &survey.Confirm{
+		Message: "Add the starter templates to the prompts directory?",
+		Default: choices.StarterTemplates,
+	}
+	return survey.AskOne(starterPrompt, &choices.StarterTemplates)
+}
+
+// ConfirmConfigOverwrite asks whether to replace an existing config file
+func (p *Prompter) ConfirmConfigOverwrite(filePath string) (bool, error) {
+	overwritePrompt := &survey.Confirm{
+		Message: fmt.Sprintf("Config file already exists: %s. Overwrite?", filePath),
+		Default: false,
+	}
+
+	var overwrite bool
+	if err := survey.AskOne(overwritePrompt, &overwrite); err != nil {
+		return false, err
+	}
+
+	return overwrite, nil
+}
@@ -3,8 +3,13 @@ package interactive
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/stats"
 	"prompter-cli/pkg/models"
 )
 
@@ -31,6 +36,39 @@ func TestCollectMissingInputs_NonInteractive(t *testing.T) {
 	}
 }
 
+func TestCollectMissingInputs_NonInteractiveMissing(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "pre"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	template := "---\nvariables:\n  - name: ticket\n    required: true\n  - name: tone\n    default: calm\n---\n{{.Vars.ticket}} {{.Vars.tone}}\n"
+	if err := os.WriteFile(filepath.Join(dir, "pre", "ticket.md"), []byte(template), 0644); err != nil {
+		t.Fatal(err)
+	}
+	prompter := NewPrompter(dir)
+
+	err := prompter.CollectMissingInputs(&models.PromptRequest{PreTemplate: "ticket"})
+	if err == nil {
+		t.Fatal("expected the missing inputs to be reported")
+	}
+	for _, want := range []string{"base prompt", "variable ticket of template ticket: pass --var ticket=VALUE"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't mention %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "tone") {
+		t.Errorf("error %q mentions a variable with a default", err)
+	}
+
+	request := &models.PromptRequest{PreTemplate: "ticket", BasePrompt: "review", Vars: map[string]string{"ticket": "42"}}
+	if err := prompter.CollectMissingInputs(request); err != nil {
+		t.Errorf("expected complete inputs to pass, got %v", err)
+	}
+	if err := prompter.CollectMissingInputs(&models.PromptRequest{FixMode: true}); err != nil {
+		t.Errorf("expected fix mode to need no base prompt, got %v", err)
+	}
+}
+
 func TestFindTemplates(t *testing.T) {
 	// Create temporary directory structure
 	tempDir := t.TempDir()
@@ -65,6 +103,63 @@ func TestFindTemplates(t *testing.T) {
 	}
 }
 
+func TestFindTemplates_MultipleLocations(t *testing.T) {
+	personal := t.TempDir()
+	team := t.TempDir()
+	for dir, files := range map[string][]string{
+		personal: {"review.md", "plan.md"},
+		team:     {"Review.md", "security.default.md"},
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, "pre"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range files {
+			if err := os.WriteFile(filepath.Join(dir, "pre", file), []byte("test"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	prompter := NewPrompter(personal)
+	prompter.SetPromptsLocations([]string{personal, "/nonexistent", team})
+	templates, err := prompter.findTemplates("pre")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"security", "plan", "review"}
+	if !reflect.DeepEqual(templates, want) {
+		t.Errorf("findTemplates() = %v, want %v", templates, want)
+	}
+	if options := prompter.buildOptionsWithNone(templates, "pre"); !reflect.DeepEqual(options, []string{"security", "None", "plan", "review"}) {
+		t.Errorf("buildOptionsWithNone() = %v", options)
+	}
+}
+
+func TestFindTemplates_Namespaces(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{"zeta.md", "go/review.md", "go/test.default.md", "docs/readme.md", "go/lint/strict.md", ".hidden/skip.md", "plan.md"} {
+		path := filepath.Join(dir, "pre", filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	prompter := NewPrompter(dir)
+	templates, err := prompter.findTemplates("pre")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"go/test", "plan", "zeta", "docs/readme", "go/review", "go/lint/strict"}
+	if !reflect.DeepEqual(templates, want) {
+		t.Errorf("findTemplates() = %v, want %v", templates, want)
+	}
+}
+
 func TestFindTemplates_NonExistentDirectory(t *testing.T) {
 	prompter := NewPrompter("/nonexistent")
 	templates, err := prompter.findTemplates("pre")
@@ -282,6 +377,35 @@ func TestSelectTemplate(t *testing.T) {
 	}
 }
 
+func TestRankByUsage(t *testing.T) {
+	prompter := NewPrompter("/test/prompts")
+	options := []string{"strict", "None", "explain", "review", "tests"}
+
+	// Without usage stats the order is unchanged
+	ranked, labels := prompter.rankByUsage(options, "pre")
+	if !reflect.DeepEqual(ranked, options) || labels != nil {
+		t.Errorf("Expected options unchanged without stats, got %v %v", ranked, labels)
+	}
+
+	base := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	usage := &stats.Stats{}
+	usage.Record("pre", "review", stats.SignalUse, base)
+	usage.Record("pre", "review", stats.SignalUse, base)
+	usage.Record("pre", "tests", stats.SignalAbort, base)
+	usage.Record("pre", "deleted", stats.SignalUse, base.Add(time.Hour))
+	usage.Record("post", "explain", stats.SignalUse, base)
+	prompter.SetUsage(usage)
+
+	ranked, labels = prompter.rankByUsage(options, "pre")
+	expected := []string{"review", "strict", "None", "explain", "tests"}
+	if !reflect.DeepEqual(ranked, expected) {
+		t.Errorf("Expected %v, got %v", expected, ranked)
+	}
+	if labels["review"] != "most used" || len(labels) != 1 {
+		t.Errorf("Unexpected labels %v", labels)
+	}
+}
+
 func TestTruncateString(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -301,4 +425,108 @@ func TestTruncateString(t *testing.T) {
 				test.input, test.maxLen, result, test.expected)
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestComposeInEditor(t *testing.T) {
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	script := "#!/bin/sh\ngrep -q draft \"$1\" || exit 1\nprintf '\\nfirst line\\nsecond line\\n\\n' > \"$1\"\n"
+	if err := os.WriteFile(editor, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	prompter := NewPrompter(t.TempDir())
+	prompter.SetEditor(editor)
+	composed, err := prompter.composeInEditor("draft")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if composed != "first line\nsecond line" {
+		t.Errorf("composed = %q", composed)
+	}
+
+	prompter.SetEditor(filepath.Join(t.TempDir(), "missing"))
+	if _, err := prompter.composeInEditor(""); err == nil {
+		t.Error("expected an error for an editor that can't be run")
+	}
+}
+
+func TestShowConfirmationSummary(t *testing.T) {
+	prompter := NewPrompter(t.TempDir())
+
+	// Without a confirmation set, inputs go straight to generation
+	if edit, err := prompter.showConfirmationSummary(&models.PromptRequest{}); edit || err != nil {
+		t.Errorf("expected no summary, got edit %v, error %v", edit, err)
+	}
+
+	previewed := false
+	prompter.SetConfirmation(func(request *models.PromptRequest) (*Preview, error) {
+		previewed = true
+		return &Preview{}, nil
+	})
+	if edit, err := prompter.showConfirmationSummary(&models.PromptRequest{FixMode: true}); edit || err != nil || previewed {
+		t.Error("expected fix mode to skip the summary")
+	}
+}
+
+func TestFormatSummary(t *testing.T) {
+	summary := formatSummary(&Preview{
+		Bytes:     2048,
+		Tokens:    512,
+		Files:     3,
+		Templates: []string{"review", "checklist"},
+		Target:    "clipboard",
+		Warnings:  []string{"skipping big.bin: binary file"},
+	})
+
+	for _, want := range []string{
+		"Templates: review, checklist\n",
+		"Files:     3\n",
+		"Size:      2048 bytes, ~512 tokens\n",
+		"Target:    clipboard\n",
+		"Warning: skipping big.bin: binary file\n",
+	} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("expected %q in summary:\n%s", want, summary)
+		}
+	}
+
+	if summary := formatSummary(&Preview{Target: "stdout"}); !strings.Contains(summary, "Templates: none\n") {
+		t.Errorf("expected no templates to be shown as none:\n%s", summary)
+	}
+}
+
+func TestReplaceTemplate_NotRequested(t *testing.T) {
+	prompter := NewPrompter(t.TempDir())
+	request := &models.PromptRequest{PreTemplate: "review", PostTemplate: "checklist"}
+
+	if err := prompter.ReplaceTemplate(request, "fix.md"); err == nil {
+		t.Error("expected an error for a template the request doesn't use")
+	}
+	if request.PreTemplate != "review" || request.PostTemplate != "checklist" {
+		t.Errorf("request changed: %+v", request)
+	}
+}
+
+func TestPromptForCustomTemplates_NothingToOffer(t *testing.T) {
+	location := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(location, "pre"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(location, "pre", "jira.md"), []byte("Ticket {{.Vars.ticket}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Selected already, or not meant for the guided flow, so nothing is asked
+	prompter := NewPrompter(t.TempDir())
+	prompter.SetCustomTemplates(map[string]interfaces.CustomTemplate{
+		"jira":  {Location: location, Type: "pre", Flag: "jira", Interactive: true, Variable: "ticket"},
+		"flags": {Location: location, Type: "post", Flag: "flags"},
+	})
+	request := &models.PromptRequest{PreTemplate: "review,Jira"}
+	if err := prompter.promptForCustomTemplates(request); err != nil {
+		t.Fatal(err)
+	}
+	if request.PreTemplate != "review,Jira" || request.PostTemplate != "" || request.Vars != nil {
+		t.Errorf("request changed: %+v", request)
+	}
+}
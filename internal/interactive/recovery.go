@@ -0,0 +1,100 @@
+package interactive
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"prompter-cli/internal/template"
+	"prompter-cli/pkg/models"
+)
+
+// maxSuggestions is how many templates with names like a missing one are listed first
+const maxSuggestions = 5
+
+// ReplaceTemplate asks which template to use in place of name, a pre or post
+// template of request that wasn't found, listing templates with similar names first.
+// The request is updated with the choice, or without the template for None, and the
+// variables the new template reads are asked for.
+func (p *Prompter) ReplaceTemplate(request *models.PromptRequest, name string) error {
+	var subdir string
+	var value *string
+	switch {
+	case slices.Contains(models.TemplateNames(request.PreTemplate), name):
+		subdir, value = "pre", &request.PreTemplate
+	case slices.Contains(models.TemplateNames(request.PostTemplate), name):
+		subdir, value = "post", &request.PostTemplate
+	default:
+		return fmt.Errorf("template '%s' is neither a pre nor a post template of the request", name)
+	}
+
+	templates, err := p.findTemplates(subdir)
+	if err != nil {
+		return fmt.Errorf("failed to find %s templates: %w", subdir, err)
+	}
+	templates = appendEmbeddedTemplates(templates, subdir)
+
+	suggestions := template.Closest(name, templates, maxSuggestions)
+	labels := make(map[string]string)
+	for _, suggestion := range suggestions {
+		labels[suggestion] = "similar name"
+	}
+	options := append(suggestions, "None")
+	for _, candidate := range templates {
+		if !slices.Contains(suggestions, candidate) {
+			options = append(options, candidate)
+		}
+	}
+	labels = p.describeTemplates(options, labels)
+
+	message := fmt.Sprintf("Template '%s' not found. Use instead:", name)
+	selected, err := p.selectLabeledTemplate(options, labels, message, "Choose None to continue without it", request.NumberSelect)
+	if err != nil {
+		return err
+	}
+	if selected == "None" {
+		selected = ""
+	}
+
+	names := models.TemplateNames(*value)
+	for i := range names {
+		if names[i] == name {
+			names[i] = selected
+		}
+	}
+	*value = models.JoinTemplateNames(names)
+
+	if selected == "" {
+		return nil
+	}
+	return p.promptForVariables(request)
+}
+
+// ChooseOutput asks where to send the prompt after sending it to target failed,
+// printed to stdout or written to a file, and returns that target
+func (p *Prompter) ChooseOutput(target string) (string, error) {
+	const printOption, fileOption = "Print to stdout", "Write to a file"
+
+	outputPrompt := &survey.Select{
+		Message: fmt.Sprintf("Couldn't output to %s. Instead:", target),
+		Options: []string{printOption, fileOption},
+	}
+	var choice string
+	if err := survey.AskOne(outputPrompt, &choice); err != nil {
+		return "", err
+	}
+	if choice == printOption {
+		return models.TargetStdout, nil
+	}
+
+	pathPrompt := &survey.Input{
+		Message: "File to write the prompt to:",
+		Default: "prompt.md",
+	}
+	var path string
+	if err := survey.AskOne(pathPrompt, &path, survey.WithValidator(survey.Required)); err != nil {
+		return "", err
+	}
+	return models.TargetFilePrefix + strings.TrimSpace(path), nil
+}
@@ -0,0 +1,922 @@
+package interactive
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/textarea"
+	"charm.land/bubbles/v2/textinput"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"prompter-cli/internal/template"
+	"prompter-cli/pkg/models"
+)
+
+// Preview is a rendered prompt shown in the TUI's preview pane and summarized
+// before output when confirm_before_output is set
+type Preview struct {
+	Prompt   string
+	System   string   // System prompt sent apart from Prompt to model targets
+	Tokens   int      // Tokens in Prompt and System together
+	Bytes    int      // Size of Prompt and System together
+	Files    int      // Files and directories added to the context
+	Warnings []string // Warnings generation would print
+
+	Templates []string // Pre and post templates used, after config defaults
+	Target    string   // Output target the prompt would be sent to
+}
+
+// PreviewFunc renders the prompt a request would produce
+type PreviewFunc func(request *models.PromptRequest) (*Preview, error)
+
+// previewDelay is how long the TUI waits after an edit before rendering the preview,
+// so typing doesn't render the prompt on every key
+const previewDelay = 150 * time.Millisecond
+
+// directoryEntry is the files pane entry that includes the whole current directory
+const directoryEntry = "./ (whole directory)"
+
+// pane is one of the TUI's panes, in tab order
+type pane int
+
+const (
+	panePrompt pane = iota
+	panePre
+	panePost
+	paneFiles
+	paneVariables
+	panePreview
+	paneCount
+)
+
+var paneTitles = [paneCount]string{"Prompt", "Pre", "Post", "Files", "Variables", "Preview"}
+
+var (
+	tuiActive  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	tuiMuted   = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	tuiWarning = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	tuiError   = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	tuiBorder  = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+)
+
+// previewTick fires previewDelay after an edit
+type previewTick struct{ version int }
+
+// previewResult carries a rendered preview back to the model
+type previewResult struct {
+	version int
+	preview *Preview
+	err     error
+}
+
+// editorFinished carries the prompt written in the editor back to the model
+type editorFinished struct {
+	text string
+	err  error
+}
+
+// templateList is a pane choosing one template, where the highlighted option is the
+// selected one so the preview follows the cursor
+type templateList struct {
+	options []string
+	labels  map[string]string
+	cursor  int
+}
+
+// selected returns the chosen template, empty for None
+func (l *templateList) selected() string {
+	if len(l.options) == 0 || l.options[l.cursor] == "None" {
+		return ""
+	}
+	return l.options[l.cursor]
+}
+
+// fileList is the pane choosing files to include, narrowed by a typed filter
+type fileList struct {
+	filter  textinput.Model
+	paths   []string // Candidates, starting with directoryEntry
+	checked map[string]bool
+	cursor  int // Index into visible()
+}
+
+// visible returns the candidates matching the filter
+func (l *fileList) visible() []string {
+	query := strings.ToLower(strings.TrimSpace(l.filter.Value()))
+	if query == "" {
+		return l.paths
+	}
+	var matches []string
+	for _, path := range l.paths {
+		if path != directoryEntry && strings.Contains(strings.ToLower(path), query) {
+			matches = append(matches, path)
+		}
+	}
+	return matches
+}
+
+// variableField is one template variable in the variables pane
+type variableField struct {
+	variable template.Variable
+	template string          // Template that declares the variable
+	input    textinput.Model // Value of string and number variables
+	choice   int             // Option index of bool and choice variables
+}
+
+// choices returns the values a bool or choice variable cycles through, nil for others
+func (f *variableField) choices() []string {
+	switch f.variable.Type {
+	case template.VariableBool:
+		return []string{"false", "true"}
+	case template.VariableChoice:
+		return f.variable.Options
+	}
+	return nil
+}
+
+// value returns the field's current value
+func (f *variableField) value() string {
+	if choices := f.choices(); choices != nil {
+		return choices[f.choice]
+	}
+	return strings.TrimSpace(f.input.Value())
+}
+
+// check reports why the field's value can't be used, nil when it can
+func (f *variableField) check() error {
+	value := f.value()
+	if value == "" {
+		if f.variable.Required {
+			return fmt.Errorf("%s is required", f.variable.Name)
+		}
+		return nil
+	}
+	if f.variable.Type == template.VariableNumber {
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%s must be a number", f.variable.Name)
+		}
+	}
+	return nil
+}
+
+// tuiModel is the state of the full-screen input mode
+type tuiModel struct {
+	prompter *Prompter
+	request  *models.PromptRequest // Filled in from the panes when the user accepts
+	preview  PreviewFunc
+	cwd      string
+
+	focus  pane
+	prompt textarea.Model
+	pre    templateList
+	post   templateList
+	files  fileList
+	fields []variableField
+	field  int               // Focused variable field
+	values map[string]string // Variable values entered so far, kept when templates change
+
+	viewport   viewport.Model
+	rendered   *Preview
+	previewErr error
+	version    int  // Bumped on every edit
+	rendering  bool // A preview is being rendered
+	stale      bool // Edits were made while rendering
+
+	status    string // Why the prompt can't be generated yet
+	width     int
+	height    int
+	accepted  bool
+	cancelled bool
+}
+
+// CollectInputsTUI collects the prompt, templates, files, and template variables in
+// a full-screen interface with a live preview of the generated prompt, as an
+// alternative to the question-by-question flow of CollectMissingInputs. files are the
+// candidates offered in the files pane, relative to the current directory.
+func (p *Prompter) CollectInputsTUI(request *models.PromptRequest, files []string, preview PreviewFunc) error {
+	if request.FromClipboard {
+		if err := p.appendClipboardToPrompt(request); err != nil {
+			return fmt.Errorf("failed to read from clipboard: %w", err)
+		}
+	}
+
+	model, err := p.newTUIModel(request, files, preview)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tea.NewProgram(model).Run(); err != nil {
+		return fmt.Errorf("failed to run the TUI: %w", err)
+	}
+	if !model.accepted {
+		return ErrSelectionCancelled
+	}
+
+	*request = *model.buildRequest()
+	return nil
+}
+
+// newTUIModel builds the panes from the request's current inputs
+func (p *Prompter) newTUIModel(request *models.PromptRequest, files []string, preview PreviewFunc) (*tuiModel, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	m := &tuiModel{
+		prompter: p,
+		request:  request,
+		preview:  preview,
+		cwd:      cwd,
+		values:   make(map[string]string),
+		viewport: viewport.New(),
+	}
+
+	m.prompt = textarea.New()
+	m.prompt.Placeholder = "What should the model do?"
+	m.prompt.ShowLineNumbers = false
+	m.prompt.CharLimit = 0
+	m.prompt.SetValue(request.BasePrompt)
+
+	if m.pre, err = p.templateList("pre", request.PreTemplate); err != nil {
+		return nil, err
+	}
+	if m.post, err = p.templateList("post", request.PostTemplate); err != nil {
+		return nil, err
+	}
+
+	m.files = fileList{filter: textinput.New(), checked: make(map[string]bool)}
+	m.files.filter.Prompt = "Filter: "
+	m.files.paths = append(m.files.paths, directoryEntry)
+	listed := make(map[string]bool, len(files))
+	for _, file := range files {
+		listed[file] = true
+	}
+	// Requested files that aren't candidates, such as line ranges, are offered first
+	for _, file := range request.Files {
+		if !listed[file] {
+			m.files.paths = append(m.files.paths, file)
+			listed[file] = true
+		}
+		m.files.checked[file] = true
+	}
+	m.files.paths = append(m.files.paths, files...)
+	m.files.checked[directoryEntry] = request.Directory != ""
+
+	for name, value := range request.Vars {
+		m.values[name] = value
+	}
+	m.refreshVariables()
+
+	if request.BasePrompt == "" {
+		m.setFocus(panePrompt)
+	} else {
+		m.setFocus(panePre)
+	}
+	return m, nil
+}
+
+// templateList lists the templates of one kind in the same order as the selectors,
+// with current selected; current is added when it isn't found on disk
+func (p *Prompter) templateList(kind, current string) (templateList, error) {
+	templates, err := p.findTemplates(kind)
+	if err != nil {
+		return templateList{}, fmt.Errorf("failed to find %s templates: %w", kind, err)
+	}
+	templates = appendEmbeddedTemplates(templates, kind)
+
+	options := p.buildOptionsWithNone(templates, kind)
+	options, labels := p.rankByUsage(options, kind)
+	labels = p.describeTemplates(options, labels)
+
+	list := templateList{options: options, labels: labels}
+	if current == "" {
+		return list, nil
+	}
+	for i, option := range options {
+		if option == current {
+			list.cursor = i
+			return list, nil
+		}
+	}
+	list.options = append([]string{current}, options...)
+	return list, nil
+}
+
+// Init renders the first preview
+func (m *tuiModel) Init() tea.Cmd {
+	return tea.Batch(textarea.Blink, m.renderPreview())
+}
+
+// Update handles keys, resizes, and rendered previews
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
+		return m, nil
+
+	case previewTick:
+		if msg.version != m.version {
+			return m, nil
+		}
+		if m.rendering {
+			m.stale = true
+			return m, nil
+		}
+		return m, m.renderPreview()
+
+	case previewResult:
+		m.rendering = false
+		m.rendered, m.previewErr = msg.preview, msg.err
+		m.resize() // The warnings above the preview take some of its lines
+		if m.stale || msg.version != m.version {
+			m.stale = false
+			return m, m.renderPreview()
+		}
+		return m, nil
+
+	case editorFinished:
+		if msg.err != nil {
+			m.status = msg.err.Error()
+			return m, nil
+		}
+		m.prompt.SetValue(msg.text)
+		return m, m.changed()
+
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "ctrl+o":
+			if m.focus == panePrompt && m.prompter.editor != "" {
+				return m, m.editPrompt()
+			}
+		case "ctrl+c":
+			m.cancelled = true
+			return m, tea.Quit
+		case "esc":
+			if m.focus == paneFiles && m.files.filter.Value() != "" {
+				m.files.filter.SetValue("")
+				m.files.cursor = 0
+				return m, nil
+			}
+			m.cancelled = true
+			return m, tea.Quit
+		case "ctrl+s":
+			if m.validate() {
+				m.accepted = true
+				return m, tea.Quit
+			}
+			return m, nil
+		case "tab":
+			return m, m.setFocus((m.focus + 1) % paneCount)
+		case "shift+tab":
+			return m, m.setFocus((m.focus + paneCount - 1) % paneCount)
+		}
+		m.status = ""
+		return m, m.updatePane(msg)
+	}
+
+	return m, m.updatePane(msg)
+}
+
+// updatePane passes a message to the focused pane, scheduling a preview when the
+// inputs change
+func (m *tuiModel) updatePane(msg tea.Msg) tea.Cmd {
+	key, isKey := msg.(tea.KeyPressMsg)
+	var cmd tea.Cmd
+
+	switch m.focus {
+	case panePrompt:
+		before := m.prompt.Value()
+		m.prompt, cmd = m.prompt.Update(msg)
+		if m.prompt.Value() != before {
+			return tea.Batch(cmd, m.changed())
+		}
+		return cmd
+
+	case panePre, panePost:
+		list := &m.pre
+		if m.focus == panePost {
+			list = &m.post
+		}
+		if isKey && moveCursor(&list.cursor, len(list.options), key.String()) {
+			m.refreshVariables()
+			return m.changed()
+		}
+		return nil
+
+	case paneFiles:
+		visible := m.files.visible()
+		if isKey {
+			if moveCursor(&m.files.cursor, len(visible), key.String()) {
+				return nil
+			}
+			if key.String() == "space" || key.String() == "enter" {
+				if len(visible) > 0 {
+					path := visible[m.files.cursor]
+					m.files.checked[path] = !m.files.checked[path]
+					return m.changed()
+				}
+				return nil
+			}
+		}
+		before := m.files.filter.Value()
+		m.files.filter, cmd = m.files.filter.Update(msg)
+		if m.files.filter.Value() != before {
+			m.files.cursor = 0
+		}
+		return cmd
+
+	case paneVariables:
+		if len(m.fields) == 0 {
+			return nil
+		}
+		if isKey && (key.String() == "up" || key.String() == "down") {
+			m.fields[m.field].input.Blur()
+			moveCursor(&m.field, len(m.fields), key.String())
+			return m.fields[m.field].input.Focus()
+		}
+		field := &m.fields[m.field]
+		if choices := field.choices(); choices != nil {
+			if !isKey {
+				return nil
+			}
+			switch key.String() {
+			case "left", "h":
+				field.choice = (field.choice + len(choices) - 1) % len(choices)
+			case "right", "l", "space", "enter":
+				field.choice = (field.choice + 1) % len(choices)
+			default:
+				return nil
+			}
+			m.values[field.variable.Name] = field.value()
+			return m.changed()
+		}
+		before := field.input.Value()
+		field.input, cmd = field.input.Update(msg)
+		if field.input.Value() != before {
+			m.values[field.variable.Name] = field.value()
+			return tea.Batch(cmd, m.changed())
+		}
+		return cmd
+
+	case panePreview:
+		m.viewport, cmd = m.viewport.Update(msg)
+		return cmd
+	}
+	return nil
+}
+
+// editPrompt suspends the TUI to edit the prompt in the editor
+func (m *tuiModel) editPrompt() tea.Cmd {
+	editor := m.prompter.editor
+	path, err := writePromptFile(m.prompt.Value())
+	if err != nil {
+		return func() tea.Msg { return editorFinished{err: err} }
+	}
+	return tea.ExecProcess(exec.Command(editor, path), func(err error) tea.Msg {
+		if err != nil {
+			os.Remove(path)
+			return editorFinished{err: fmt.Errorf("failed to launch editor %s: %w", editor, err)}
+		}
+		text, err := readPromptFile(path)
+		return editorFinished{text: text, err: err}
+	})
+}
+
+// moveCursor moves a list cursor for navigation keys, reporting whether it moved
+func moveCursor(cursor *int, length int, key string) bool {
+	if length == 0 {
+		return false
+	}
+	next := *cursor
+	switch key {
+	case "up", "ctrl+p":
+		next--
+	case "down", "ctrl+n":
+		next++
+	case "pgup":
+		next -= 10
+	case "pgdown":
+		next += 10
+	case "home":
+		next = 0
+	case "end":
+		next = length - 1
+	default:
+		return false
+	}
+	next = max(0, min(next, length-1))
+	if next == *cursor {
+		return false
+	}
+	*cursor = next
+	return true
+}
+
+// setFocus moves to a pane, focusing its text input
+func (m *tuiModel) setFocus(focus pane) tea.Cmd {
+	m.focus = focus
+	m.prompt.Blur()
+	m.files.filter.Blur()
+	for i := range m.fields {
+		m.fields[i].input.Blur()
+	}
+
+	switch focus {
+	case panePrompt:
+		return m.prompt.Focus()
+	case paneFiles:
+		return m.files.filter.Focus()
+	case paneVariables:
+		if len(m.fields) > 0 {
+			return m.fields[m.field].input.Focus()
+		}
+	}
+	return nil
+}
+
+// changed schedules a preview for the current inputs
+func (m *tuiModel) changed() tea.Cmd {
+	m.version++
+	version := m.version
+	return tea.Tick(previewDelay, func(time.Time) tea.Msg {
+		return previewTick{version: version}
+	})
+}
+
+// renderPreview renders the current inputs in the background. Only one render runs at
+// a time, since the preview isn't safe to call concurrently.
+func (m *tuiModel) renderPreview() tea.Cmd {
+	m.rendering = true
+	request := m.buildRequest()
+	version := m.version
+	preview := m.preview
+	return func() tea.Msg {
+		rendered, err := preview(request)
+		return previewResult{version: version, preview: rendered, err: err}
+	}
+}
+
+// refreshVariables lists the variables of the selected templates, keeping the values
+// entered for variables that are still listed
+func (m *tuiModel) refreshVariables() {
+	processor := m.prompter.processor()
+
+	var fields []variableField
+	seen := make(map[string]bool)
+	for _, name := range append(models.TemplateNames(m.pre.selected()), models.TemplateNames(m.post.selected())...) {
+		variables, err := processor.TemplateVariables(name)
+		if err != nil {
+			continue // Missing or broken templates are reported by the preview
+		}
+		for _, variable := range variables {
+			if seen[variable.Name] {
+				continue
+			}
+			seen[variable.Name] = true
+
+			value, ok := m.values[variable.Name]
+			if !ok {
+				value = variable.Default
+			}
+			field := variableField{variable: variable, template: name, input: textinput.New()}
+			field.input.Prompt = ""
+			field.input.SetValue(value)
+			for i, choice := range field.choices() {
+				if choice == value {
+					field.choice = i
+				}
+			}
+			fields = append(fields, field)
+		}
+	}
+
+	m.fields = fields
+	m.field = min(m.field, max(len(fields)-1, 0))
+	m.resize()
+}
+
+// buildRequest returns a copy of the request with the inputs from the panes
+func (m *tuiModel) buildRequest() *models.PromptRequest {
+	request := *m.request
+	request.BasePrompt = strings.TrimSpace(m.prompt.Value())
+	request.PreTemplate = m.pre.selected()
+	request.PostTemplate = m.post.selected()
+
+	request.Files = nil
+	request.Directory = ""
+	for _, path := range m.files.paths {
+		if !m.files.checked[path] {
+			continue
+		}
+		if path == directoryEntry {
+			request.Directory = m.cwd
+		} else {
+			request.Files = append(request.Files, path)
+		}
+	}
+
+	request.Vars = make(map[string]string, len(m.request.Vars)+len(m.fields))
+	for name, value := range m.request.Vars {
+		request.Vars[name] = value
+	}
+	for i := range m.fields {
+		// Empty values are left out so the template's default applies
+		if value := m.fields[i].value(); value != "" {
+			request.Vars[m.fields[i].variable.Name] = value
+		} else {
+			delete(request.Vars, m.fields[i].variable.Name)
+		}
+	}
+	return &request
+}
+
+// validate checks that the prompt can be generated, pointing at the first problem
+func (m *tuiModel) validate() bool {
+	if strings.TrimSpace(m.prompt.Value()) == "" {
+		m.status = "Enter a prompt before generating"
+		m.setFocus(panePrompt)
+		return false
+	}
+	for i := range m.fields {
+		if err := m.fields[i].check(); err != nil {
+			m.status = err.Error()
+			m.field = i
+			m.setFocus(paneVariables)
+			return false
+		}
+	}
+	return true
+}
+
+// Layout of the screen: the inputs column on the left and the preview on the right,
+// each in a border, above two lines for the status and key help
+const (
+	tuiChrome      = 4 // Border and padding width of each column
+	tuiFooter      = 2 // Status and key help lines
+	tuiSummary     = 5 // Blank line and one line per input in the summary
+	tuiMaxWarnings = 3
+)
+
+// columns returns the inner width of the inputs and preview columns and their height
+func (m *tuiModel) columns() (int, int, int) {
+	left := max(m.width*2/5, 32) - tuiChrome
+	right := max(m.width-left-2*tuiChrome, 10)
+	height := max(m.height-tuiFooter-2, 8)
+	return left, right, height
+}
+
+// bodyHeight is the number of lines left for the focused pane in the inputs column
+func (m *tuiModel) bodyHeight() int {
+	_, _, height := m.columns()
+	return max(height-2-tuiSummary, 3)
+}
+
+// resize fits the text areas and preview to the window
+func (m *tuiModel) resize() {
+	if m.width == 0 {
+		return
+	}
+	left, right, height := m.columns()
+	m.prompt.SetWidth(left)
+	m.prompt.SetHeight(m.bodyHeight())
+	m.files.filter.SetWidth(left - len(m.files.filter.Prompt) - 1)
+	for i := range m.fields {
+		m.fields[i].input.SetWidth(left - 3)
+	}
+	m.viewport.SetWidth(right)
+	m.viewport.SetHeight(max(height-1-len(m.warnings()), 1))
+	m.viewport.SetContent(m.previewText())
+}
+
+// warnings returns the preview's warnings that fit above it
+func (m *tuiModel) warnings() []string {
+	if m.rendered == nil || m.previewErr != nil {
+		return nil
+	}
+	if len(m.rendered.Warnings) > tuiMaxWarnings {
+		return append(m.rendered.Warnings[:tuiMaxWarnings-1:tuiMaxWarnings-1], fmt.Sprintf("and %d more warnings", len(m.rendered.Warnings)-tuiMaxWarnings+1))
+	}
+	return m.rendered.Warnings
+}
+
+// previewText is the content of the preview pane, wrapped to its width
+func (m *tuiModel) previewText() string {
+	var text string
+	switch {
+	case m.previewErr != nil:
+		text = tuiError.Render(m.previewErr.Error())
+	case m.rendered == nil:
+		text = tuiMuted.Render("Rendering...")
+	case m.rendered.System != "":
+		text = tuiMuted.Render("System prompt:") + "\n" + m.rendered.System + "\n\n" + tuiMuted.Render("Prompt:") + "\n" + m.rendered.Prompt
+	default:
+		text = m.rendered.Prompt
+	}
+	if m.viewport.Width() > 0 {
+		text = lipgloss.NewStyle().Width(m.viewport.Width()).Render(text)
+	}
+	return text
+}
+
+// View draws the screen on the alternate screen buffer
+func (m *tuiModel) View() tea.View {
+	view := tea.NewView(m.screen())
+	view.AltScreen = true
+	return view
+}
+
+// screen draws the panes, the status, and the key help
+func (m *tuiModel) screen() string {
+	if m.width == 0 {
+		return ""
+	}
+	left, right, height := m.columns()
+
+	var inputs []string
+	var tabs []string
+	for p := panePrompt; p < panePreview; p++ {
+		if p == m.focus {
+			tabs = append(tabs, tuiActive.Render(paneTitles[p]))
+		} else {
+			tabs = append(tabs, tuiMuted.Render(paneTitles[p]))
+		}
+	}
+	inputs = append(inputs, strings.Join(tabs, "  "), "")
+	inputs = append(inputs, m.paneView(left)...)
+	inputs = append(inputs, "")
+	inputs = append(inputs, m.summary(left)...)
+
+	header := "Preview"
+	if m.rendered != nil && m.previewErr == nil {
+		header = fmt.Sprintf("Preview - %d tokens", m.rendered.Tokens)
+	}
+	if m.rendering {
+		header += tuiMuted.Render(" (updating)")
+	}
+	if m.focus == panePreview {
+		header = tuiActive.Render(header)
+	}
+	preview := []string{header}
+	for _, warning := range m.warnings() {
+		preview = append(preview, tuiWarning.Render(fit("! "+warning, right)))
+	}
+	preview = append(preview, m.viewport.View())
+
+	leftStyle, rightStyle := tuiBorder, tuiBorder
+	if m.focus == panePreview {
+		rightStyle = rightStyle.BorderForeground(lipgloss.Color("12"))
+	} else {
+		leftStyle = leftStyle.BorderForeground(lipgloss.Color("12"))
+	}
+	columns := lipgloss.JoinHorizontal(lipgloss.Top,
+		leftStyle.Width(left+tuiChrome).Height(height+2).MaxHeight(height+2).Render(strings.Join(inputs, "\n")),
+		rightStyle.Width(right+tuiChrome).Height(height+2).MaxHeight(height+2).Render(strings.Join(preview, "\n")),
+	)
+
+	help := "tab next pane - shift+tab previous - ctrl+s generate - esc cancel"
+	switch m.focus {
+	case panePrompt:
+		if m.prompter.editor != "" {
+			help = "ctrl+o write in " + m.prompter.editor + " - " + help
+		}
+	case panePre, panePost:
+		help = "up/down choose - " + help
+	case paneFiles:
+		help = "type to filter - up/down move - space toggle - " + help
+	case paneVariables:
+		help = "up/down move - left/right change choices - " + help
+	case panePreview:
+		help = "up/down/pgup/pgdown scroll - " + help
+	}
+	return columns + "\n" + tuiError.Render(fit(m.status, m.width)) + "\n" + tuiMuted.Render(fit(help, m.width))
+}
+
+// paneView draws the focused input pane
+func (m *tuiModel) paneView(width int) []string {
+	height := m.bodyHeight()
+
+	switch m.focus {
+	case panePre, panePost:
+		list := m.pre
+		if m.focus == panePost {
+			list = m.post
+		}
+		var items []string
+		for _, option := range list.options {
+			if label := list.labels[option]; label != "" {
+				option += tuiMuted.Render(" - " + label)
+			}
+			items = append(items, option)
+		}
+		return listView(items, list.cursor, height, width)
+
+	case paneFiles:
+		visible := m.files.visible()
+		var items []string
+		for _, path := range visible {
+			box := "[ ] "
+			if m.files.checked[path] {
+				box = "[x] "
+			}
+			items = append(items, box+path)
+		}
+		lines := []string{m.files.filter.View()}
+		if len(items) == 0 {
+			return append(lines, tuiMuted.Render("No matching files"))
+		}
+		return append(lines, listView(items, m.files.cursor, height-1, width)...)
+
+	case paneVariables:
+		if len(m.fields) == 0 {
+			return []string{tuiMuted.Render("The selected templates have no variables")}
+		}
+		var lines []string
+		cursorLine := 0
+		for i := range m.fields {
+			field := &m.fields[i]
+			label := field.variable.Prompt
+			if label == "" {
+				label = field.variable.Name
+			}
+			label = fmt.Sprintf("%s (%s)", strings.TrimSuffix(label, ":"), field.template)
+			if field.variable.Required {
+				label += " *"
+			}
+
+			value := "  " + field.input.View()
+			if choices := field.choices(); choices != nil {
+				value = "  < " + choices[field.choice] + " >"
+			}
+			if i == m.field {
+				cursorLine = len(lines)
+				lines = append(lines, tuiActive.Render(fit(label, width)), value)
+				if field.variable.Description != "" && m.focus == paneVariables {
+					lines = append(lines, tuiMuted.Render(fit("  "+field.variable.Description, width)))
+				}
+			} else {
+				lines = append(lines, fit(label, width), value)
+			}
+		}
+		start := max(0, min(cursorLine-height/3, len(lines)-height))
+		return lines[start:min(start+height, len(lines))]
+	}
+
+	// The prompt is shown while the preview is focused as well
+	return strings.Split(m.prompt.View(), "\n")
+}
+
+// summary lists the current inputs below the focused pane
+func (m *tuiModel) summary(width int) []string {
+	orNone := func(name string) string {
+		if name == "" {
+			return "none"
+		}
+		return name
+	}
+
+	request := m.buildRequest()
+	files := fmt.Sprintf("%d files", len(request.Files))
+	if request.Directory != "" {
+		files += " and the directory"
+	}
+	return []string{
+		fit("Pre:       "+orNone(request.PreTemplate), width),
+		fit("Post:      "+orNone(request.PostTemplate), width),
+		fit("Files:     "+files, width),
+		fit(fmt.Sprintf("Variables: %d", len(m.fields)), width),
+	}
+}
+
+// listView draws the part of a list around the cursor that fits in height lines
+func listView(items []string, cursor, height, width int) []string {
+	start := max(0, min(cursor-height/2, len(items)-height))
+	end := min(start+height, len(items))
+
+	lines := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		if i == cursor {
+			lines = append(lines, tuiActive.Render("> ")+lipgloss.NewStyle().MaxWidth(width-2).Render(items[i]))
+		} else {
+			lines = append(lines, "  "+lipgloss.NewStyle().MaxWidth(width-2).Render(items[i]))
+		}
+	}
+	return lines
+}
+
+// fit shortens plain text to width columns
+func fit(text string, width int) string {
+	runes := []rune(text)
+	if width <= 0 || len(runes) <= width {
+		return text
+	}
+	if width <= 3 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-3]) + "..."
+}
@@ -0,0 +1,175 @@
+package interactive
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"prompter-cli/pkg/models"
+)
+
+// press sends keys to the model, dropping the commands they return
+func press(m *tuiModel, keys ...tea.KeyPressMsg) {
+	for _, key := range keys {
+		m.Update(key)
+	}
+}
+
+// typed returns the key presses for typing text
+func typed(text string) []tea.KeyPressMsg {
+	var keys []tea.KeyPressMsg
+	for _, r := range text {
+		keys = append(keys, tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+	return keys
+}
+
+func TestTUIModel(t *testing.T) {
+	promptsDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(promptsDir, "pre"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	review := "---\nvariables:\n  - name: tone\n    type: choice\n    options: [gentle, direct]\n    default: direct\n  - name: focus\n    required: true\n---\nReview {{.Vars.focus}}"
+	if err := os.WriteFile(filepath.Join(promptsDir, "pre", "review.md"), []byte(review), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	preview := func(request *models.PromptRequest) (*Preview, error) {
+		return &Preview{Prompt: request.BasePrompt, Tokens: 3}, nil
+	}
+
+	request := &models.PromptRequest{Files: []string{"main.go:1-10"}, Vars: map[string]string{"lang": "go"}}
+	m, err := NewPrompter(promptsDir).newTUIModel(request, []string{"go.mod", "main.go"}, preview)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+
+	if m.focus != panePrompt {
+		t.Errorf("expected to start in the prompt pane without a prompt, got %v", m.focus)
+	}
+	if m.pre.selected() != "" {
+		t.Errorf("expected no pre-template to be selected, got %q from %v", m.pre.selected(), m.pre.options)
+	}
+
+	// Accepting without a prompt points at the prompt pane
+	if _, cmd := m.Update(tea.KeyPressMsg{Code: 's', Mod: tea.ModCtrl}); cmd != nil || m.status == "" {
+		t.Error("expected a missing prompt to stop generation")
+	}
+
+	press(m, typed("explain")...)
+	press(m, tea.KeyPressMsg{Code: tea.KeyTab})
+	for m.pre.selected() != "review" {
+		press(m, tea.KeyPressMsg{Code: tea.KeyDown})
+	}
+	if len(m.fields) != 2 || m.fields[0].value() != "direct" {
+		t.Fatalf("expected the review template's variables, got %+v", m.fields)
+	}
+
+	// Select go.mod in the files pane by filtering
+	press(m, tea.KeyPressMsg{Code: tea.KeyTab}, tea.KeyPressMsg{Code: tea.KeyTab})
+	press(m, typed("mod")...)
+	press(m, tea.KeyPressMsg{Code: tea.KeySpace, Text: " "})
+
+	// The required variable has to be filled in before generating
+	m.Update(tea.KeyPressMsg{Code: 's', Mod: tea.ModCtrl})
+	if m.focus != paneVariables || m.field != 1 || m.status != "focus is required" {
+		t.Fatalf("expected the required variable to be focused, got pane %v field %d: %q", m.focus, m.field, m.status)
+	}
+	press(m, typed("errors")...)
+	press(m, tea.KeyPressMsg{Code: tea.KeyUp}, tea.KeyPressMsg{Code: tea.KeyLeft})
+
+	if _, cmd := m.Update(tea.KeyPressMsg{Code: 's', Mod: tea.ModCtrl}); cmd == nil || !m.accepted {
+		t.Fatalf("expected the inputs to be accepted: %q", m.status)
+	}
+
+	got := m.buildRequest()
+	if got.BasePrompt != "explain" || got.PreTemplate != "review" || got.PostTemplate != "" {
+		t.Errorf("unexpected prompt and templates: %+v", got)
+	}
+	if !reflect.DeepEqual(got.Files, []string{"main.go:1-10", "go.mod"}) || got.Directory != "" {
+		t.Errorf("unexpected files: %v, directory %q", got.Files, got.Directory)
+	}
+	wantVars := map[string]string{"lang": "go", "tone": "gentle", "focus": "errors"}
+	if !reflect.DeepEqual(got.Vars, wantVars) {
+		t.Errorf("Vars = %v, want %v", got.Vars, wantVars)
+	}
+	if request.BasePrompt != "" {
+		t.Error("expected the request to be left alone until the TUI finishes")
+	}
+}
+
+func TestTUIModel_Preview(t *testing.T) {
+	var rendered []string
+	preview := func(request *models.PromptRequest) (*Preview, error) {
+		rendered = append(rendered, request.BasePrompt)
+		return &Preview{Prompt: request.BasePrompt, Tokens: len(request.BasePrompt), Warnings: []string{"careful"}}, nil
+	}
+
+	m, err := NewPrompter(t.TempDir()).newTUIModel(&models.PromptRequest{BasePrompt: "a"}, nil, preview)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m.focus = panePrompt
+	m.prompt.Focus()
+
+	// The first render runs at start
+	first := m.renderPreview()
+	press(m, typed("b")...)
+	tick := m.version
+
+	// A tick for an edit made while rendering waits for the render to finish
+	if _, cmd := m.Update(previewTick{version: tick}); cmd != nil || !m.stale {
+		t.Fatal("expected the preview to be marked stale while rendering")
+	}
+	_, next := m.Update(first())
+	if next == nil || m.rendered.Prompt != "a" {
+		t.Fatalf("expected the stale preview to be shown and rendered again, got %+v", m.rendered)
+	}
+	m.Update(next())
+	if m.rendering || m.rendered.Prompt != "ab" || m.rendered.Tokens != 2 {
+		t.Errorf("expected the latest inputs to be previewed, got %+v", m.rendered)
+	}
+	if !reflect.DeepEqual(rendered, []string{"a", "ab"}) {
+		t.Errorf("rendered %v", rendered)
+	}
+
+	// Ticks for superseded edits are dropped
+	press(m, typed("c")...)
+	if _, cmd := m.Update(previewTick{version: tick}); cmd != nil {
+		t.Error("expected an old tick to be ignored")
+	}
+}
+
+func TestTUIModel_Editor(t *testing.T) {
+	preview := func(request *models.PromptRequest) (*Preview, error) {
+		return &Preview{Prompt: request.BasePrompt}, nil
+	}
+	prompter := NewPrompter(t.TempDir())
+	m, err := prompter.newTUIModel(&models.PromptRequest{}, nil, preview)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without an editor, ctrl+o does nothing
+	if _, cmd := m.Update(tea.KeyPressMsg{Code: 'o', Mod: tea.ModCtrl}); cmd != nil {
+		t.Error("expected ctrl+o to be ignored without an editor")
+	}
+
+	version := m.version
+	if _, cmd := m.Update(editorFinished{text: "line one\nline two"}); cmd == nil || m.version == version {
+		t.Error("expected the prompt from the editor to schedule a preview")
+	}
+	if m.prompt.Value() != "line one\nline two" {
+		t.Errorf("prompt = %q", m.prompt.Value())
+	}
+
+	m.Update(editorFinished{err: errors.New("failed to launch editor vi")})
+	if m.status != "failed to launch editor vi" || m.prompt.Value() != "line one\nline two" {
+		t.Errorf("expected an editor failure to keep the prompt and show the error, got %q", m.status)
+	}
+}
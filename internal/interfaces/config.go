@@ -8,20 +8,175 @@ type CustomTemplate struct {
 	Shorthand   string `toml:"shorthand"`
 	Type        string `toml:"type"`        // "pre" or "post", defaults to "pre"
 	Description string `toml:"description"` // Custom help description
+	Variable    string `toml:"variable"`    // When set, the flag takes a value that fills .Vars.<variable>
+}
+
+// TemplateSource is a git repository of templates cloned by templates sync and searched
+// after the prompts_location directories
+type TemplateSource struct {
+	Name string `toml:"name"` // Directory name in template_sources_location, defaults to the repository name
+	URL  string `toml:"url"`
+	Ref  string `toml:"ref"`  // Branch or tag, defaults to the remote's default branch
+	Path string `toml:"path"` // Directory in the repository holding pre/ and post/, defaults to its root
+}
+
+// Recipe bundles templates, content, and output settings into a named workflow
+type Recipe struct {
+	Description       string   `toml:"description"`
+	Prompt            string   `toml:"prompt"`             // Base prompt used when none is given
+	Pre               string   `toml:"pre"`
+	Post              string   `toml:"post"`
+	Files             []string `toml:"files"`
+	Directory         bool     `toml:"directory"`          // Include the current directory
+	DirectoryStrategy string   `toml:"directory_strategy"` // Overrides the global strategy when set
+	Fix               bool     `toml:"fix"`                // Capture command output as in fix mode
+	Outline           bool     `toml:"outline"`            // Embed outlines of supported source files
+	Target            string   `toml:"target"`
+}
+
+// Preset is a named combination of templates, content, variables, and target applied
+// to a run with --preset or as a command of its own
+type Preset struct {
+	Description       string            `toml:"description"`
+	Pre               string            `toml:"pre"`  // Template name, or several separated by commas
+	Post              string            `toml:"post"` // Template name, or several separated by commas
+	Files             []string          `toml:"files"`
+	Vars              map[string]string `toml:"vars"`               // Template variables, overridden by --var
+	Directory         bool              `toml:"directory"`          // Include the current directory
+	DirectoryStrategy string            `toml:"directory_strategy"` // Overrides the global strategy when set
+	Exclude           []string          `toml:"exclude"`            // Patterns left out of the directory, added to --exclude
+	Target            string            `toml:"target"`
+}
+
+// WasmPlugin represents a WebAssembly module that exports template helper functions
+type WasmPlugin struct {
+	Path      string   `toml:"path"`
+	Functions []string `toml:"functions"` // Exported function names registered as template helpers
+	TimeoutMS int      `toml:"timeout_ms"` // Per-call execution limit, defaults to 5000
+}
+
+// HelperCommand represents an external executable exposed as a template function
+type HelperCommand struct {
+	Command   string   `toml:"command"`
+	Args      []string `toml:"args"`       // Fixed arguments; template arguments arrive as JSON on stdin
+	TimeoutMS int      `toml:"timeout_ms"` // Per-call execution limit, defaults to 10000
+	CacheTTL  int      `toml:"cache_ttl"`  // Seconds to cache output on disk, 0 disables the disk cache
+}
+
+// OpenAIConfig configures the "openai" target, which sends the prompt to the Chat Completions API
+type OpenAIConfig struct {
+	Model       string   `toml:"model"`
+	APIKeyEnv   string   `toml:"api_key_env"` // Environment variable holding the API key
+	BaseURL     string   `toml:"base_url"`    // For OpenAI-compatible servers
+	Temperature *float64 `toml:"temperature"` // Left to the API default when unset
+}
+
+// AnthropicConfig configures the "anthropic" target, which sends the prompt to the Messages API
+type AnthropicConfig struct {
+	Model     string `toml:"model"`
+	APIKeyEnv string `toml:"api_key_env"` // Environment variable holding the API key
+	BaseURL   string `toml:"base_url"`
+	MaxTokens int    `toml:"max_tokens"` // Upper limit on the response length
+}
+
+// OllamaConfig configures the "ollama" target, which sends the prompt to a local Ollama server
+type OllamaConfig struct {
+	Model      string `toml:"model"`
+	BaseURL    string `toml:"base_url"`
+	OutputFile string `toml:"output_file"` // Also write the response here when set
+}
+
+// WebhookConfig configures the "http:<url>" target, which posts the prompt to a URL
+type WebhookConfig struct {
+	Format    string            `toml:"format"`     // "json" for the prompt with its metadata, "raw" for the prompt alone
+	Headers   map[string]string `toml:"headers"`    // Sent with each request, expanding $NAME from the environment
+	TimeoutMS int               `toml:"timeout_ms"` // Per-request limit
+}
+
+// ModelPreset describes a model prompts are written for, selected with the model setting
+// or --model. Empty fields leave the corresponding setting alone.
+type ModelPreset struct {
+	ContextWindow  int    `toml:"context_window"`  // Tokens the model accepts, prompt and reply together
+	ResponseTokens int    `toml:"response_tokens"` // Tokens of the window kept free for the reply
+	Tokenizer      string `toml:"tokenizer"`       // Replaces tokenizer
+	FileFormat     string `toml:"file_format"`     // Replaces file_format, e.g. "xml" or "markdown"
 }
 
 // Config represents the application configuration
 type Config struct {
-	PromptsLocation      string                     `toml:"prompts_location"`
+	ConfigVersion        int                        `toml:"config_version"` // Format version, upgraded automatically on load
+	StrictConfig         bool                       `toml:"strict_config"`  // Fail on unknown settings instead of warning
+	PromptsLocation      string                     `toml:"prompts_location"` // First of PromptsLocations, where new templates are written
+	PromptsLocations     []string                   `toml:"-"`                // Every prompts_location entry, searched in order
 	LocalPromptsLocation string                     `toml:"local_prompts_location"`
 	Editor               string                     `toml:"editor"`
 	DefaultPre           string                     `toml:"default_pre"`
 	DefaultPost          string                     `toml:"default_post"`
 	FixFile              string                     `toml:"fix_file"`
+	FixIncludeFiles      bool                       `toml:"fix_include_files"` // Include the files fix mode output refers to
+	FixContextLines      int                        `toml:"fix_context_lines"` // Lines kept around each line fix mode output refers to
 	DirectoryStrategy    string                     `toml:"directory_strategy"`
 	Target               string                     `toml:"target"`
+	TargetFallbacks      []string                   `toml:"target_fallbacks"` // Targets tried in order when a clipboard, osc52, or tmux target fails
+	Model                string                     `toml:"model"`  // Preset from Models applied to the budget, tokenizer, and file format
+	Models               map[string]ModelPreset     `toml:"models"` // Built-in presets merged with [models.<name>] tables
+	EditorTarget         string                     `toml:"editor_target"` // Where the editor target sends the edited prompt
 	InteractiveDefault   bool                       `toml:"interactive_default"`
+	TUI                  bool                       `toml:"tui"`                   // Collect interactive inputs in the full-screen interface
+	ConfirmBeforeOutput  bool                       `toml:"confirm_before_output"` // Show a summary of the interactive selections before generating
 	CustomTemplates      map[string]CustomTemplate `toml:"custom_template"`
+	WasmPlugins          map[string]WasmPlugin     `toml:"wasm_plugin"`
+	Helpers              map[string]HelperCommand  `toml:"helper"`
+	Recipes              map[string]Recipe         `toml:"recipe"`
+	Presets              map[string]Preset         `toml:"preset"`
+	TemplateSources      []TemplateSource          `toml:"template_sources"`          // Git repositories of shared templates
+	SourcesLocation      string                    `toml:"template_sources_location"` // Directory template sources are cloned into
+	TemplateRegistry     string                    `toml:"template_registry"`         // URL or directory of bundle manifests installed by name
+	Pipeline             []string                  `toml:"pipeline"` // Ordered generation stages, empty for the default
+	Vars                 map[string]string         `toml:"vars"`     // Template variables available as .Vars, overridden by --var
+	EmbedContent         bool                      `toml:"embed_content"`       // Embed file contents instead of listing paths
+	MaxTokens            int                       `toml:"max_tokens"`          // Token budget for embedded content, 0 for unlimited
+	BudgetStrategy       string                    `toml:"budget_strategy"`     // How files are chosen when over max_tokens: "priority" or "relevance"
+	Tokenizer            string                    `toml:"tokenizer"`           // Encoding or model name used to count tokens
+	TokenizerFile        string                    `toml:"tokenizer_file"`      // tiktoken rank file for byte pair encodings
+	MaxFileSizeBytes     int64                     `toml:"max_file_size_bytes"` // Larger files are truncated when embedded
+	DiffContextLines     int                       `toml:"diff_context_lines"`  // Context kept around changed hunks of truncated files
+	RangeContextLines    int                       `toml:"range_context_lines"` // Context kept around requested line ranges and symbols
+	FileFormat           string                    `toml:"file_format"`         // Preset name or template used to embed each file
+	IgnoreFile           string                    `toml:"ignore_file"`         // Global .prmptignore, defaults to the one beside the config file
+	ExcludePatterns      []string                  `toml:"exclude_patterns"`    // Gitignore-style patterns left out of included directories
+	SkipHeuristics       []string                  `toml:"skip_heuristics"`     // Checks leaving minified, lockfile, and generated directory files out
+	ContentCache         bool                      `toml:"content_cache"`       // Reuse files processed by earlier runs while they're unchanged
+	Redact               bool                      `toml:"redact"`              // Replace secrets in the prompt with placeholders
+	RedactPatterns       map[string]string         `toml:"redact_patterns"`     // Extra secret patterns by name, applied after the built-in ones
+	OpenAI               OpenAIConfig              `toml:"openai"`
+	Anthropic            AnthropicConfig           `toml:"anthropic"`
+	Ollama               OllamaConfig              `toml:"ollama"`
+	Webhook              WebhookConfig             `toml:"webhook"`
+	GitRecentCommits     int                       `toml:"git_recent_commits"`  // Commit subjects available to templates as .Git.RecentCommits
+	ProjectIndirectDeps  bool                      `toml:"project_indirect_dependencies"` // List indirect dependencies in .Project.Dependencies
+	TreeDepth            int                       `toml:"tree_depth"`          // Directory levels drawn in .Tree, 0 for none
+	IncludeDocs          bool                      `toml:"include_docs"`        // Add the README, CONTRIBUTING.md, and docs overviews to every prompt
+	DocsTokenShare       float64                   `toml:"docs_token_share"`    // Share of the token budget the documentation may use, 0 for the default
+	LogLevel             string                    `toml:"log_level"`           // Least severe log records written: debug, info, warn, or error
+	LogFile              string                    `toml:"log_file"`            // Log records go here instead of stderr, which still gets warnings
+	Quiet                bool                      `toml:"quiet"`               // Keep warnings and status messages off stderr; errors are still reported
+	HistoryEnabled       bool                      `toml:"history_enabled"`     // Record generated prompts for continue and replay
+	HistoryLocation      string                    `toml:"history_location"`
+	HistoryLimit         int                       `toml:"history_limit"`       // Oldest entries beyond this are removed, 0 keeps all
+	StatsEnabled         bool                      `toml:"stats_enabled"`       // Track template usage locally to rank the selectors
+	StatsLocation        string                    `toml:"stats_location"`
+	PluginsLocation      string                    `toml:"plugins_location"`    // Directory of executables that add helpers, sources, and targets
+	DisabledPlugins      []string                  `toml:"disabled_plugins"`    // File names in plugins_location that aren't run
+	PluginTimeoutMS      int                       `toml:"plugin_timeout_ms"`   // Per-call execution limit for plugins
+	TemplateStrict       bool                      `toml:"template_strict"`     // Fail on undefined template data instead of rendering <no value>
+	TemplateSeparator    string                    `toml:"template_separator"`  // Joins the output of several pre- or post-templates
+	AllowExec            bool                      `toml:"allow_exec"`          // Let templates run shell commands with the exec helper
+	ExecTimeoutMS        int                       `toml:"exec_timeout_ms"`     // Per-command limit for the exec helper
+	ExecMaxBytes         int                       `toml:"exec_max_bytes"`      // Output kept from each exec helper command
+	FetchAllow           []string                  `toml:"fetch_allow"`         // Hosts or URL prefixes the fetchURL helper may fetch
+	FetchTimeoutMS       int                       `toml:"fetch_timeout_ms"`    // Per-request limit for the fetchURL helper
+	FetchMaxBytes        int                       `toml:"fetch_max_bytes"`     // Body kept from each fetchURL response
 }
 
 // ConfigManager handles configuration loading and resolution
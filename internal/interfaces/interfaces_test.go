@@ -69,6 +69,14 @@ func (m *mockTemplateProcessor) RegisterHelpers() error {
 	return nil
 }
 
+func (m *mockTemplateProcessor) GetPromptLocations() []string {
+	return nil
+}
+
+func (m *mockTemplateProcessor) GetCustomTemplates() map[string]CustomTemplate {
+	return nil
+}
+
 
 
 type mockOutputHandler struct{}
@@ -77,18 +85,34 @@ func (m *mockOutputHandler) WriteToClipboard(content string) error {
 	return nil
 }
 
+func (m *mockOutputHandler) WriteToTerminalClipboard(content string) error {
+	return nil
+}
+
 func (m *mockOutputHandler) WriteToStdout(content string) error {
 	return nil
 }
 
+func (m *mockOutputHandler) WriteToTmuxPane(content string, pane string) error {
+	return nil
+}
+
 func (m *mockOutputHandler) WriteToFile(content string, path string) error {
 	return nil
 }
 
+func (m *mockOutputHandler) AppendToFile(content string, path string) error {
+	return nil
+}
+
 func (m *mockOutputHandler) OpenInEditor(content string, editor string) error {
 	return nil
 }
 
+func (m *mockOutputHandler) EditInEditor(content string, editor string) (string, error) {
+	return content, nil
+}
+
 // Test that mock implementations satisfy interfaces
 func TestInterfaceImplementations(t *testing.T) {
 	var _ ConfigManager = &mockConfigManager{}
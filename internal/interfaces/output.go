@@ -5,12 +5,25 @@ type OutputHandler interface {
 	// WriteToClipboard copies content to the system clipboard
 	WriteToClipboard(content string) error
 	
+	// WriteToTerminalClipboard copies content to the clipboard of the terminal
+	// prompter runs in with an OSC 52 escape sequence, which works over SSH and in tmux
+	WriteToTerminalClipboard(content string) error
+	
 	// WriteToStdout writes content to standard output
 	WriteToStdout(content string) error
 	
+	// WriteToTmuxPane pastes content into the tmux pane identified by pane
+	WriteToTmuxPane(content string, pane string) error
+	
 	// WriteToFile writes content to the specified file path
 	WriteToFile(content string, path string) error
 	
+	// AppendToFile adds content to the end of the specified file, creating it if needed
+	AppendToFile(content string, path string) error
+	
 	// OpenInEditor opens content in the specified editor
 	OpenInEditor(content string, editor string) error
+	
+	// EditInEditor opens content in the specified editor and returns it as saved
+	EditInEditor(content string, editor string) (string, error)
 }
\ No newline at end of file
@@ -7,14 +7,38 @@ import (
 
 // TemplateData contains all variables available to templates
 type TemplateData struct {
-	Prompt string                 `json:"prompt"`
-	Now    time.Time              `json:"now"`
-	CWD    string                 `json:"cwd"`
-	Files  []FileInfo             `json:"files"`
-	Git    GitInfo                `json:"git"`
-	Config map[string]interface{} `json:"config"`
-	Env    map[string]string      `json:"env"`
-	Fix    FixInfo                `json:"fix"`
+	Prompt   string                 `json:"prompt"`
+	Now      time.Time              `json:"now"`
+	CWD      string                 `json:"cwd"`
+	Files    []FileInfo             `json:"files"`
+	Git      GitInfo                `json:"git"`
+	Project  ProjectInfo            `json:"project"` // Languages and manifests detected in the project root
+	Tree     string                 `json:"tree"`    // Files under the working directory drawn as a tree, down to tree_depth
+	Todos    []TodoInfo             `json:"todos"`   // TODO, FIXME, and HACK comments in the included files, with --todos
+	Stats    StatsInfo              `json:"stats"`   // Sizes of the embedded files, empty when only paths are listed
+	Diff     DiffInfo               `json:"diff"`    // Changes from --diff, --staged, or --diff-against, empty otherwise
+	Config   map[string]interface{} `json:"config"`
+	Env      map[string]string      `json:"env"`
+	Fix      FixInfo                `json:"fix"`
+	Vars     map[string]string      `json:"vars"`     // User-supplied values, e.g. from an inputs file
+	Previous PreviousPrompt         `json:"previous"` // Earlier prompt being continued, empty otherwise
+	Model    ModelInfo              `json:"model"`    // Model the prompt is written for, empty without one
+}
+
+// ModelInfo describes the model selected with --model or the model setting
+type ModelInfo struct {
+	Name          string `json:"name"`
+	ContextWindow int    `json:"context_window"` // 0 when the preset doesn't set one
+	Tokenizer     string `json:"tokenizer"`
+	Format        string `json:"format"` // file_format in effect, e.g. "xml" or "markdown"
+}
+
+// PreviousPrompt is a prompt from history that a follow-up builds on
+type PreviousPrompt struct {
+	ID         string    `json:"id"`
+	Time       time.Time `json:"time"`
+	Prompt     string    `json:"prompt"`
+	BasePrompt string    `json:"base_prompt"`
 }
 
 // FileInfo represents information about a file for templates
@@ -27,18 +51,122 @@ type FileInfo struct {
 
 // GitInfo represents git repository information
 type GitInfo struct {
-	Root   string `json:"root"`
-	Branch string `json:"branch"`
-	Commit string `json:"commit"`
-	Dirty  bool   `json:"dirty"`
+	Root          string      `json:"root"`
+	Branch        string      `json:"branch"`         // Empty on a detached HEAD
+	Commit        string      `json:"commit"`
+	Dirty         bool        `json:"dirty"`          // Uncommitted changes, untracked files included
+	Upstream      string      `json:"upstream"`       // Upstream branch, e.g. origin/main
+	Remote        string      `json:"remote"`         // URL of the upstream remote, or of origin without one
+	Ahead         int         `json:"ahead"`          // Commits not yet pushed to the upstream
+	Behind        int         `json:"behind"`         // Upstream commits not yet pulled
+	RecentCommits []GitCommit `json:"recent_commits"` // Newest first
+}
+
+// ProjectInfo describes the languages and frameworks of a project, detected from the
+// manifests in its root
+type ProjectInfo struct {
+	Root         string              `json:"root"`         // Directory holding the manifests, empty when none was found
+	Languages    []string            `json:"languages"`    // e.g. "go", "typescript", "python", in manifest order
+	Frameworks   []string            `json:"frameworks"`   // Well-known frameworks among the dependencies, e.g. "react", "django"
+	Module       string              `json:"module"`       // Module or package name of the first manifest that has one
+	Manifests    []ProjectManifest   `json:"manifests"`
+	Dependencies []ProjectDependency `json:"dependencies"` // Of every manifest, indirect ones only with project_indirect_dependencies
+}
+
+// ProjectManifest is a manifest file found in the project root
+type ProjectManifest struct {
+	Path         string   `json:"path"` // File name in the project root, e.g. "go.mod"
+	Language     string   `json:"language"`
+	Name         string   `json:"name"`         // Module or package name, empty when the manifest has none
+	Version      string   `json:"version"`      // Package version, or the Go version of a go.mod
+	Dependencies []string `json:"dependencies"` // Names of direct dependencies, sorted
+	Summary      string   `json:"summary"`      // One line describing the manifest
+}
+
+// ProjectDependency is a package a project's manifest depends on
+type ProjectDependency struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`  // Version or requirement as written in the manifest, e.g. "v1.9.1" or "^18.2"
+	Manifest string `json:"manifest"` // Path of the manifest listing it
+	Indirect bool   `json:"indirect"` // Only needed by other dependencies, as marked in go.mod
+}
+
+// TodoInfo is a TODO, FIXME, or HACK comment in an included file
+type TodoInfo struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Tag  string `json:"tag"`  // "TODO", "FIXME", or "HACK"
+	Text string `json:"text"` // Rest of the comment
+}
+
+// StatsInfo describes how much of the prompt each embedded file takes up
+type StatsInfo struct {
+	Files  []FileStats `json:"files"`  // Largest first, project documentation included
+	Bytes  int64       `json:"bytes"`  // Total size of the files on disk
+	Tokens int         `json:"tokens"` // Total tokens of their embedded contents
+}
+
+// FileStats is the size of an embedded file
+type FileStats struct {
+	Path      string `json:"path"`
+	Bytes     int64  `json:"bytes"`  // Size on disk
+	Tokens    int    `json:"tokens"` // Estimated tokens of the embedded content
+	Truncated bool   `json:"truncated"`
+	Note      string `json:"note"` // Describes the truncation, empty when the file is complete
+}
+
+// GitCommit is a commit in the repository history
+type GitCommit struct {
+	Hash    string `json:"hash"` // Abbreviated
+	Subject string `json:"subject"`
+}
+
+// DiffInfo is a set of changes collected with git diff
+type DiffInfo struct {
+	Mode    string     `json:"mode"`    // "working", "staged", or "ref"
+	Base    string     `json:"base"`    // Ref compared against in "ref" mode
+	Command string     `json:"command"` // The git diff command that produced it
+	Raw     string     `json:"raw"`     // The whole diff
+	Files   []FileDiff `json:"files"`
+}
+
+// FileDiff is the change to one file
+type FileDiff struct {
+	Path      string     `json:"path"`
+	OldPath   string     `json:"old_path"` // Differs from Path for renames, empty for added files
+	Status    string     `json:"status"`   // "added", "deleted", "modified", or "renamed"
+	Binary    bool       `json:"binary"`
+	Additions int        `json:"additions"`
+	Deletions int        `json:"deletions"`
+	Hunks     []DiffHunk `json:"hunks"`
+	Patch     string     `json:"patch"` // The file's part of the diff, headers included
+}
+
+// DiffHunk is one changed region of a file
+type DiffHunk struct {
+	Header   string `json:"header"` // The @@ line, including the enclosing function when git finds one
+	OldStart int    `json:"old_start"`
+	OldLines int    `json:"old_lines"`
+	NewStart int    `json:"new_start"`
+	NewLines int    `json:"new_lines"`
+	Content  string `json:"content"` // Context, added, and removed lines
 }
 
 // FixInfo represents fix mode data
 type FixInfo struct {
-	Enabled bool   `json:"enabled"`
-	Raw     string `json:"raw"`
-	Command string `json:"command"`
-	Output  string `json:"output"`
+	Enabled   bool          `json:"enabled"`
+	Raw       string        `json:"raw"`
+	Command   string        `json:"command"`
+	Output    string        `json:"output"`
+	Locations []FixLocation `json:"locations"` // Source lines the output refers to, such as compiler errors
+}
+
+// FixLocation is a source line referenced by the output in fix mode
+type FixLocation struct {
+	Path    string `json:"path"` // Relative to the current directory when inside it
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message,omitempty"`
 }
 
 // TemplateProcessor handles template loading and execution
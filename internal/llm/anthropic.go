@@ -0,0 +1,143 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Anthropic defaults used when the [anthropic] config table leaves them out
+const (
+	DefaultAnthropicModel     = "claude-sonnet-4-5"
+	DefaultAnthropicKeyEnv    = "ANTHROPIC_API_KEY"
+	DefaultAnthropicBaseURL   = "https://api.anthropic.com/v1"
+	DefaultAnthropicMaxTokens = 4096
+	anthropicMessagesPath     = "/messages"
+	anthropicVersion          = "2023-06-01"
+	anthropicServiceName      = "Anthropic"
+)
+
+// Anthropic streams responses from the Anthropic Messages API
+type Anthropic struct {
+	APIKey     string
+	Model      string
+	BaseURL    string
+	MaxTokens  int // Upper limit on the response length
+	HTTPClient *http.Client
+}
+
+// anthropicRequest is the body of a messages request. The system prompt is a
+// top-level field rather than a message.
+type anthropicRequest struct {
+	Model     string    `json:"model"`
+	System    string    `json:"system,omitempty"`
+	Messages  []Message `json:"messages"`
+	MaxTokens int       `json:"max_tokens"`
+	Stream    bool      `json:"stream"`
+}
+
+// anthropicEvent is one streamed messages event
+type anthropicEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Stream sends messages to the Messages API and writes the response text to w as it
+// arrives. Messages with the "system" role are combined into the system prompt.
+func (c *Anthropic) Stream(ctx context.Context, messages []Message, w io.Writer) error {
+	if c.APIKey == "" {
+		return fmt.Errorf("no Anthropic API key configured")
+	}
+
+	model := c.Model
+	if model == "" {
+		model = DefaultAnthropicModel
+	}
+	baseURL := strings.TrimRight(c.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultAnthropicBaseURL
+	}
+	maxTokens := c.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultAnthropicMaxTokens
+	}
+
+	var system []string
+	var conversation []Message
+	for _, message := range messages {
+		if message.Role == "system" {
+			system = append(system, message.Content)
+			continue
+		}
+		conversation = append(conversation, message)
+	}
+
+	body, err := json.Marshal(anthropicRequest{
+		Model:     model,
+		System:    strings.Join(system, "\n\n"),
+		Messages:  conversation,
+		MaxTokens: maxTokens,
+		Stream:    true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+anthropicMessagesPath, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("X-Api-Key", c.APIKey)
+	req.Header.Set("Anthropic-Version", anthropicVersion)
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the %s API: %w", anthropicServiceName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(anthropicServiceName, resp)
+	}
+
+	return readEvents(resp.Body, func(data string) (bool, error) {
+		var event anthropicEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return false, fmt.Errorf("invalid %s stream event: %w", anthropicServiceName, err)
+		}
+
+		switch event.Type {
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" {
+				if _, err := io.WriteString(w, event.Delta.Text); err != nil {
+					return false, err
+				}
+			}
+		case "message_stop":
+			return true, nil
+		case "error":
+			message := "unknown error"
+			if event.Error != nil && event.Error.Message != "" {
+				message = event.Error.Message
+			}
+			return false, fmt.Errorf("%s API error: %s", anthropicServiceName, message)
+		}
+		return false, nil
+	})
+}
@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAnthropic_Stream(t *testing.T) {
+	var received anthropicRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if got := r.Header.Get("X-Api-Key"); got != "secret" {
+			t.Errorf("X-Api-Key = %q", got)
+		}
+		if r.Header.Get("Anthropic-Version") == "" {
+			t.Error("missing Anthropic-Version header")
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Fatal(err)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\"}\n\n")
+		for _, part := range []string{"Hello", ", ", "world"} {
+			fmt.Fprintf(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":%q}}\n\n", part)
+		}
+		fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer server.Close()
+
+	client := &Anthropic{APIKey: "secret", Model: "claude-test", BaseURL: server.URL + "/v1/", MaxTokens: 100}
+
+	messages := []Message{
+		{Role: "system", Content: "You are terse."},
+		{Role: "user", Content: "hi"},
+	}
+	var out strings.Builder
+	if err := client.Stream(context.Background(), messages, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Hello, world" {
+		t.Errorf("streamed %q", out.String())
+	}
+	if received.Model != "claude-test" || !received.Stream || received.MaxTokens != 100 {
+		t.Errorf("unexpected request: %+v", received)
+	}
+	if received.System != "You are terse." {
+		t.Errorf("system = %q", received.System)
+	}
+	if len(received.Messages) != 1 || received.Messages[0].Role != "user" || received.Messages[0].Content != "hi" {
+		t.Errorf("unexpected messages: %+v", received.Messages)
+	}
+}
+
+func TestAnthropic_StreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	}))
+	defer server.Close()
+
+	client := &Anthropic{APIKey: "secret", BaseURL: server.URL}
+	err := client.Stream(context.Background(), []Message{{Role: "user", Content: "hi"}}, &strings.Builder{})
+	if err == nil || !strings.Contains(err.Error(), "Overloaded") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := (&Anthropic{}).Stream(context.Background(), nil, &strings.Builder{}); err == nil {
+		t.Error("expected an error without an API key")
+	}
+}
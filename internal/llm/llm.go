@@ -0,0 +1,79 @@
+// Package llm sends prompts to model APIs and streams the responses back.
+package llm
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Message is one turn of a conversation
+type Message struct {
+	Role    string `json:"role"` // "system", "user", or "assistant"
+	Content string `json:"content"`
+}
+
+// readEvents calls fn with the data of each server-sent event in body until fn
+// reports done or the stream ends
+func readEvents(body io.Reader, fn func(data string) (done bool, err error)) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var data []string
+	flush := func() (bool, error) {
+		if len(data) == 0 {
+			return false, nil
+		}
+		payload := strings.Join(data, "\n")
+		data = data[:0]
+		return fn(payload)
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if done, err := flush(); done || err != nil {
+				return err
+			}
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			data = append(data, strings.TrimPrefix(value, " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read response stream: %w", err)
+	}
+	_, err := flush()
+	return err
+}
+
+// responseError builds an error from a failed API response, using the message from
+// a JSON error body when there is one
+func responseError(service string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	var parsed struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &parsed) == nil && len(parsed.Error) > 0 {
+		var detail struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(parsed.Error, &detail) == nil && detail.Message != "" {
+			return fmt.Errorf("%s API returned %s: %s", service, resp.Status, detail.Message)
+		}
+		var message string
+		if json.Unmarshal(parsed.Error, &message) == nil && message != "" {
+			return fmt.Errorf("%s API returned %s: %s", service, resp.Status, message)
+		}
+	}
+
+	if text := strings.TrimSpace(string(body)); text != "" {
+		return fmt.Errorf("%s API returned %s: %s", service, resp.Status, text)
+	}
+	return fmt.Errorf("%s API returned %s", service, resp.Status)
+}
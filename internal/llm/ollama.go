@@ -0,0 +1,112 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Ollama defaults used when the [ollama] config table leaves them out
+const (
+	DefaultOllamaModel   = "llama3.2"
+	DefaultOllamaBaseURL = "http://localhost:11434"
+	ollamaChatPath       = "/api/chat"
+	ollamaServiceName    = "Ollama"
+)
+
+// Ollama streams chat responses from a local Ollama server
+type Ollama struct {
+	Model      string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// ollamaRequest is the body of a chat request
+type ollamaRequest struct {
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+	Stream   bool      `json:"stream"`
+}
+
+// ollamaChunk is one line of a streamed chat response
+type ollamaChunk struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done  bool   `json:"done"`
+	Error string `json:"error"`
+}
+
+// Stream sends messages to the Ollama chat API and writes the response text to w as
+// it arrives. Ollama streams one JSON object per line rather than server-sent events.
+func (c *Ollama) Stream(ctx context.Context, messages []Message, w io.Writer) error {
+	model := c.Model
+	if model == "" {
+		model = DefaultOllamaModel
+	}
+	baseURL := strings.TrimRight(c.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultOllamaBaseURL
+	}
+
+	body, err := json.Marshal(ollamaRequest{
+		Model:    model,
+		Messages: messages,
+		Stream:   true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+ollamaChatPath, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the %s server at %s (is it running?): %w", ollamaServiceName, baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(ollamaServiceName, resp)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var chunk ollamaChunk
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return fmt.Errorf("invalid %s stream line: %w", ollamaServiceName, err)
+		}
+		if chunk.Error != "" {
+			return fmt.Errorf("%s error: %s", ollamaServiceName, chunk.Error)
+		}
+		if _, err := io.WriteString(w, chunk.Message.Content); err != nil {
+			return err
+		}
+		if chunk.Done {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read response stream: %w", err)
+	}
+	return nil
+}
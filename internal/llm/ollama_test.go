@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOllama_Stream(t *testing.T) {
+	var received ollamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Fatal(err)
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, part := range []string{"Hello", ", ", "world"} {
+			fmt.Fprintf(w, "{\"message\":{\"role\":\"assistant\",\"content\":%q},\"done\":false}\n", part)
+		}
+		fmt.Fprint(w, "{\"message\":{\"role\":\"assistant\",\"content\":\"\"},\"done\":true}\n")
+	}))
+	defer server.Close()
+
+	client := &Ollama{Model: "llama-test", BaseURL: server.URL + "/"}
+
+	var out strings.Builder
+	if err := client.Stream(context.Background(), []Message{{Role: "user", Content: "hi"}}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Hello, world" {
+		t.Errorf("streamed %q", out.String())
+	}
+	if received.Model != "llama-test" || !received.Stream {
+		t.Errorf("unexpected request: %+v", received)
+	}
+	if len(received.Messages) != 1 || received.Messages[0].Content != "hi" {
+		t.Errorf("unexpected messages: %+v", received.Messages)
+	}
+}
+
+func TestOllama_StreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"model \"missing\" not found, try pulling it first"}`)
+	}))
+	defer server.Close()
+
+	client := &Ollama{Model: "missing", BaseURL: server.URL}
+	err := client.Stream(context.Background(), []Message{{Role: "user", Content: "hi"}}, &strings.Builder{})
+	if err == nil || !strings.Contains(err.Error(), "404") || !strings.Contains(err.Error(), "try pulling it") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
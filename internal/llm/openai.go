@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// OpenAI defaults used when the [openai] config table leaves them out
+const (
+	DefaultOpenAIModel     = "gpt-4o"
+	DefaultOpenAIKeyEnv    = "OPENAI_API_KEY"
+	DefaultOpenAIBaseURL   = "https://api.openai.com/v1"
+	openAICompletionsPath  = "/chat/completions"
+	openAIServiceName      = "OpenAI"
+	openAIStreamTerminator = "[DONE]"
+)
+
+// OpenAI streams chat completions from the OpenAI API or a compatible server
+type OpenAI struct {
+	APIKey      string
+	Model       string
+	BaseURL     string
+	Temperature *float64 // Left to the API default when nil
+	HTTPClient  *http.Client
+}
+
+// openAIRequest is the body of a chat completions request
+type openAIRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	Stream      bool      `json:"stream"`
+}
+
+// openAIChunk is one streamed chat completion event
+type openAIChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Stream sends messages to the Chat Completions API and writes the response text to
+// w as it arrives
+func (c *OpenAI) Stream(ctx context.Context, messages []Message, w io.Writer) error {
+	if c.APIKey == "" {
+		return fmt.Errorf("no OpenAI API key configured")
+	}
+
+	model := c.Model
+	if model == "" {
+		model = DefaultOpenAIModel
+	}
+	baseURL := strings.TrimRight(c.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+
+	body, err := json.Marshal(openAIRequest{
+		Model:       model,
+		Messages:    messages,
+		Temperature: c.Temperature,
+		Stream:      true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+openAICompletionsPath, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the %s API: %w", openAIServiceName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(openAIServiceName, resp)
+	}
+
+	return readEvents(resp.Body, func(data string) (bool, error) {
+		if data == openAIStreamTerminator {
+			return true, nil
+		}
+
+		var chunk openAIChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, fmt.Errorf("invalid %s stream event: %w", openAIServiceName, err)
+		}
+		if chunk.Error != nil {
+			return false, fmt.Errorf("%s API error: %s", openAIServiceName, chunk.Error.Message)
+		}
+		for _, choice := range chunk.Choices {
+			if _, err := io.WriteString(w, choice.Delta.Content); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	})
+}
@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAI_Stream(t *testing.T) {
+	var received openAIRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Fatal(err)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, part := range []string{"Hello", ", ", "world"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", part)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	temperature := 0.2
+	client := &OpenAI{APIKey: "secret", Model: "gpt-test", BaseURL: server.URL + "/v1/", Temperature: &temperature}
+
+	var out strings.Builder
+	if err := client.Stream(context.Background(), []Message{{Role: "user", Content: "hi"}}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Hello, world" {
+		t.Errorf("streamed %q", out.String())
+	}
+	if received.Model != "gpt-test" || !received.Stream || received.Temperature == nil || *received.Temperature != 0.2 {
+		t.Errorf("unexpected request: %+v", received)
+	}
+	if len(received.Messages) != 1 || received.Messages[0].Content != "hi" {
+		t.Errorf("unexpected messages: %+v", received.Messages)
+	}
+}
+
+func TestOpenAI_StreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":{"message":"Incorrect API key provided"}}`)
+	}))
+	defer server.Close()
+
+	client := &OpenAI{APIKey: "wrong", BaseURL: server.URL}
+	err := client.Stream(context.Background(), []Message{{Role: "user", Content: "hi"}}, &strings.Builder{})
+	if err == nil || !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "Incorrect API key") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := (&OpenAI{}).Stream(context.Background(), nil, &strings.Builder{}); err == nil {
+		t.Error("expected an error without an API key")
+	}
+}
@@ -0,0 +1,229 @@
+// Package logging configures the slog logger prompter's warnings and diagnostics go
+// through. Records are written to stderr, or to a log file with log_file, from the
+// level set with log_level.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLevel is the log level used when none is configured
+const DefaultLevel = "warn"
+
+// Levels are the names accepted by log_level and --log-level, most verbose first
+var Levels = []string{"debug", "info", "warn", "error"}
+
+var (
+	mu      sync.Mutex
+	level   string
+	path    string
+	logFile *os.File
+	quiet   bool
+)
+
+func init() {
+	slog.SetDefault(slog.New(newTerminalHandler(slog.LevelWarn)))
+	level = DefaultLevel
+}
+
+// ParseLevel returns the slog level of a level name, case-insensitively
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q (must be one of %s)", name, strings.Join(Levels, ", "))
+}
+
+// Configure makes the default logger write records at levelName and above to file,
+// appending to it, or to stderr when file is empty. With a log file, warnings and
+// errors still reach stderr too. An empty levelName uses DefaultLevel, and calling
+// Configure again with the same settings keeps the open log file.
+func Configure(levelName, file string) error {
+	if levelName == "" {
+		levelName = DefaultLevel
+	}
+	minimum, err := ParseLevel(levelName)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if levelName == level && file == path {
+		return nil
+	}
+
+	var opened *os.File
+	if file != "" {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return fmt.Errorf("failed to create log directory: %w", err)
+		}
+		if opened, err = os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+	}
+
+	if logFile != nil {
+		logFile.Close()
+	}
+	logFile, level, path = opened, levelName, file
+	slog.SetDefault(slog.New(newHandler(minimum)))
+	return nil
+}
+
+// SetQuiet keeps records below errors off stderr when on, for --quiet. A log file
+// still receives everything at the configured level.
+func SetQuiet(on bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	if on == quiet {
+		return
+	}
+	quiet = on
+	minimum, _ := ParseLevel(level)
+	slog.SetDefault(slog.New(newHandler(minimum)))
+}
+
+// newHandler creates the handler for the current settings, writing records at
+// minimum and above to the open log file or to stderr. Called with mu held.
+func newHandler(minimum slog.Level) slog.Handler {
+	terminal := minimum
+	if logFile != nil {
+		terminal = max(minimum, slog.LevelWarn)
+	}
+	if quiet {
+		terminal = max(terminal, slog.LevelError)
+	}
+	if logFile == nil {
+		return newTerminalHandler(terminal)
+	}
+	return fanout{
+		newTerminalHandler(terminal),
+		slog.NewTextHandler(logFile, &slog.HandlerOptions{Level: minimum}),
+	}
+}
+
+// terminalHandler writes records for people reading stderr: the message after a
+// level prefix such as "Warning: ", followed by any attributes as key=value
+type terminalHandler struct {
+	minimum slog.Level
+	attrs   []slog.Attr
+	group   string
+}
+
+// newTerminalHandler creates a handler writing records at minimum and above to stderr
+func newTerminalHandler(minimum slog.Level) *terminalHandler {
+	return &terminalHandler{minimum: minimum}
+}
+
+func (h *terminalHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.minimum
+}
+
+func (h *terminalHandler) Handle(_ context.Context, record slog.Record) error {
+	var b strings.Builder
+	switch {
+	case record.Level >= slog.LevelError:
+		b.WriteString("Error: ")
+	case record.Level >= slog.LevelWarn:
+		b.WriteString("Warning: ")
+	case record.Level < slog.LevelInfo:
+		b.WriteString("Debug: ")
+	}
+	b.WriteString(record.Message)
+
+	write := func(attr slog.Attr) bool {
+		if attr.Equal(slog.Attr{}) {
+			return true
+		}
+		key := attr.Key
+		if h.group != "" {
+			key = h.group + "." + key
+		}
+		value := attr.Value.Resolve().String()
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		b.WriteString(" " + key + "=" + value)
+		return true
+	}
+	for _, attr := range h.attrs {
+		write(attr)
+	}
+	record.Attrs(write)
+	b.WriteString("\n")
+
+	// Looked up on every write so redirecting os.Stderr takes effect
+	_, err := io.WriteString(os.Stderr, b.String())
+	return err
+}
+
+func (h *terminalHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(slices.Clip(h.attrs), attrs...)
+	return &clone
+}
+
+func (h *terminalHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	if clone.group != "" {
+		name = clone.group + "." + name
+	}
+	clone.group = name
+	return &clone
+}
+
+// fanout passes records to every handler that accepts them
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, l slog.Level) bool {
+	for _, handler := range f {
+		if handler.Enabled(ctx, l) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, handler := range f {
+		if handler.Enabled(ctx, record.Level) {
+			errs = append(errs, handler.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanout, len(f))
+	for i, handler := range f {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	handlers := make(fanout, len(f))
+	for i, handler := range f {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return handlers
+}
@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureStderr returns what fn writes to stderr
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	fn()
+	w.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]slog.Level{
+		"debug":   slog.LevelDebug,
+		"INFO":    slog.LevelInfo,
+		"warn":    slog.LevelWarn,
+		"warning": slog.LevelWarn,
+		"error":   slog.LevelError,
+	}
+	for name, want := range tests {
+		if got, err := ParseLevel(name); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel(\"verbose\") should fail")
+	}
+}
+
+func TestConfigure_Stderr(t *testing.T) {
+	t.Cleanup(func() { Configure(DefaultLevel, "") })
+
+	output := captureStderr(t, func() {
+		slog.Debug("hidden")
+		slog.Warn("careful", "path", "a b.go")
+		if err := Configure("debug", ""); err != nil {
+			t.Fatal(err)
+		}
+		slog.Debug("shown", "count", 2)
+		slog.Info("plain")
+	})
+
+	want := "Warning: careful path=\"a b.go\"\nDebug: shown count=2\nplain\n"
+	if output != want {
+		t.Errorf("stderr = %q, want %q", output, want)
+	}
+}
+
+func TestConfigure_File(t *testing.T) {
+	t.Cleanup(func() { Configure(DefaultLevel, "") })
+	path := filepath.Join(t.TempDir(), "logs", "prompter.log")
+
+	output := captureStderr(t, func() {
+		if err := Configure("debug", path); err != nil {
+			t.Fatal(err)
+		}
+		slog.Debug("traced")
+		slog.Warn("careful")
+	})
+
+	if output != "Warning: careful\n" {
+		t.Errorf("stderr = %q, want only the warning", output)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := string(data)
+	if !strings.Contains(log, "level=DEBUG msg=traced") || !strings.Contains(log, "level=WARN msg=careful") {
+		t.Errorf("log file = %q, want both records", log)
+	}
+}
+
+func TestConfigure_InvalidLevel(t *testing.T) {
+	if err := Configure("loud", ""); err == nil {
+		t.Error("Configure with an unknown level should fail")
+	}
+}
+
+func TestSetQuiet(t *testing.T) {
+	t.Cleanup(func() { SetQuiet(false) })
+
+	output := captureStderr(t, func() {
+		SetQuiet(true)
+		slog.Warn("hidden")
+		slog.Error("failed")
+		SetQuiet(false)
+		slog.Warn("careful")
+	})
+
+	want := "Error: failed\nWarning: careful\n"
+	if output != want {
+		t.Errorf("stderr = %q, want %q", output, want)
+	}
+}
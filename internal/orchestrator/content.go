@@ -0,0 +1,208 @@
+package orchestrator
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"prompter-cli/internal/content"
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/pkg/models"
+)
+
+// ContentOptions returns the content collection options for a request, combining
+// the configured settings with the request's overrides
+func ContentOptions(cfg *interfaces.Config, request *models.PromptRequest) content.Options {
+	strategy := cfg.DirectoryStrategy
+	if request.DirectoryStrategy != "" {
+		strategy = request.DirectoryStrategy
+	}
+
+	var cacheDir string
+	if cfg.ContentCache {
+		cacheDir = content.DefaultCacheDir()
+	}
+
+	return content.Options{
+		Strategy:     strategy,
+		MaxFileSize:  cfg.MaxFileSizeBytes,
+		DiffContext:  cfg.DiffContextLines,
+		RangeContext: cfg.RangeContextLines,
+		IgnoreFiles:  []string{cfg.IgnoreFile},
+		Exclude:      append(append([]string{}, cfg.ExcludePatterns...), request.Exclude...),
+
+		SkipHeuristics:  cfg.SkipHeuristics,
+		Outline:         request.Outline,
+		DependencyDepth: request.WithDeps,
+		CacheDir:        cacheDir,
+	}
+}
+
+// contentBudget returns the token budget for embedded content, from --max-tokens or
+// the max_tokens setting, 0 when unlimited
+func contentBudget(cfg *interfaces.Config, request *models.PromptRequest) int {
+	if request.MaxTokens > 0 {
+		return request.MaxTokens
+	}
+	return cfg.MaxTokens
+}
+
+// embedContent collects the requested files and directory, packs the most relevant
+// ones into the token budget, and embeds their contents in the prompt
+func (o *Orchestrator) embedContent(state *PipelineState) error {
+	request := state.Request
+	cfg := state.Config
+
+	options := state.contentOptions()
+	options.Tokenizer = o.tokenizer
+	collector := content.NewCollector(options)
+	files, skipped, err := collector.Collect(request.Files, request.Directory)
+	if err != nil {
+		return fmt.Errorf("failed to collect content: %w", err)
+	}
+	if len(request.Symbols) > 0 {
+		root := request.Directory
+		if root == "" {
+			root = "."
+		}
+		symbols, missing, err := collector.CollectSymbols(request.Symbols, root)
+		if err != nil {
+			return fmt.Errorf("failed to collect symbols: %w", err)
+		}
+		files = append(files, symbols...)
+		skipped = append(skipped, missing...)
+	}
+	for _, spec := range request.Sources {
+		sourced, err := o.collectSource(state.Context, spec)
+		if err != nil {
+			return fmt.Errorf("failed to collect source %s: %w", spec, err)
+		}
+		files = append(files, sourced...)
+	}
+
+	// Files and symbols named explicitly are expected in the prompt, so say why they're missing
+	explicit := make(map[string]bool)
+	for _, path := range append(append([]string{}, request.Files...), request.Symbols...) {
+		explicit[path] = true
+	}
+	for _, skip := range skipped {
+		if explicit[skip.Path] {
+			o.warn("skipping %s: %s", skip.Path, skip.Reason)
+		} else if request.Verbose {
+			fmt.Fprintf(os.Stderr, "Skipped %s: %s\n", skip.Path, skip.Reason)
+		}
+	}
+
+	if state.Redactor != nil {
+		state.Redactions = append(state.Redactions, redactFiles(state.Redactor, files, o.tokenizer)...)
+	}
+
+	content.Score(files, request.BasePrompt)
+	// Project documentation has already taken its share of the budget
+	budget := contentBudget(cfg, request)
+	if budget > 0 {
+		budget = max(budget-state.DocsTokens, 1)
+	}
+	var packing content.Packing
+	if cfg.BudgetStrategy == content.StrategyRelevance {
+		packing = content.Pack(files, budget)
+	} else {
+		packing = content.Prioritize(files, budget, o.tokenizer)
+	}
+	state.Packing = &packing
+
+	if len(packing.Dropped) > 0 || len(packing.Shortened) > 0 {
+		o.warn("%s", packing.Report())
+	} else if request.Verbose {
+		fmt.Fprintln(os.Stderr, packing.Report())
+	}
+	addStats(&state.Data.Stats, packing.Selected)
+
+	for _, file := range packing.Selected {
+		o.emit(models.Event{Type: models.EventFileCollected, Path: file.Path, Bytes: int(file.Size)})
+		state.Data.Files = append(state.Data.Files, interfaces.FileInfo{
+			Path:     file.AbsPath,
+			RelPath:  file.Path,
+			Language: file.Language,
+			Content:  file.Content,
+		})
+	}
+
+	formatted, err := formatEmbeddedFiles(packing.Selected, cfg.FileFormat)
+	if err != nil {
+		return NewConfigurationError("failed to format embedded files", err)
+	}
+	// Tell the reader the context is incomplete rather than letting it look whole
+	if summary := packing.Summary(); summary != "" {
+		formatted = strings.TrimSpace(formatted + "\n\n" + summary)
+	}
+	state.Content = formatted
+	return nil
+}
+
+// embedFixFiles collects excerpts of the files fix mode output refers to, with
+// fix_context_lines around each referenced line, and packs them into the token budget.
+// Locations come closest to the failure first, so files are kept in that order rather
+// than by size.
+func (o *Orchestrator) embedFixFiles(state *PipelineState, locations []interfaces.FixLocation) error {
+	if len(locations) == 0 {
+		return nil
+	}
+	request := state.Request
+	cfg := state.Config
+
+	specs := make([]string, len(locations))
+	for i, location := range locations {
+		specs[i] = fmt.Sprintf("%s:%d", location.Path, location.Line)
+	}
+	options := state.contentOptions()
+	options.RangeContext = cfg.FixContextLines
+	options.Tokenizer = o.tokenizer
+	files, skipped, err := content.NewCollector(options).Collect(specs, "")
+	if err != nil {
+		return fmt.Errorf("failed to collect referenced files: %w", err)
+	}
+	if request.Verbose {
+		for _, skip := range skipped {
+			fmt.Fprintf(os.Stderr, "Skipped %s: %s\n", skip.Path, skip.Reason)
+		}
+	}
+
+	if state.Redactor != nil {
+		state.Redactions = append(state.Redactions, redactFiles(state.Redactor, files, o.tokenizer)...)
+	}
+
+	packing := content.FitInOrder(files, contentBudget(cfg, request), o.tokenizer)
+	state.Packing = &packing
+	if len(packing.Dropped) > 0 || len(packing.Shortened) > 0 {
+		o.warn("%s", packing.Report())
+	}
+	for _, file := range packing.Selected {
+		o.emit(models.Event{Type: models.EventFileCollected, Path: file.Path, Bytes: int(file.Size)})
+	}
+
+	formatted, err := formatEmbeddedFiles(packing.Selected, cfg.FileFormat)
+	if err != nil {
+		return NewConfigurationError("failed to format embedded files", err)
+	}
+	if summary := packing.Summary(); summary != "" {
+		formatted = strings.TrimSpace(formatted + "\n\n" + summary)
+	}
+	state.Content = formatted
+	return nil
+}
+
+// formatEmbeddedFiles renders files with the configured file_format under a
+// "Referencing files:" heading
+func formatEmbeddedFiles(files []content.File, format string) (string, error) {
+	if len(files) == 0 {
+		return "", nil
+	}
+
+	formatted, err := content.Format(files, format)
+	if err != nil {
+		return "", err
+	}
+
+	return "Referencing files:\n\n" + formatted, nil
+}
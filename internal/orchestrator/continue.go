@@ -0,0 +1,56 @@
+package orchestrator
+
+import (
+	"strings"
+
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/template"
+	"prompter-cli/pkg/models"
+)
+
+// ContinuationTemplate is the root template used to frame follow-up prompts
+const ContinuationTemplate = "continue"
+
+// GenerateContinuation renders a follow-up to a previous prompt. Only the follow-up,
+// framed by the continuation template, is returned; the previous prompt is available
+// to the template as .Previous.
+func (o *Orchestrator) GenerateContinuation(request *models.PromptRequest, previous interfaces.PreviousPrompt) (string, error) {
+	o.system = ""
+	o.edited = ""
+	if strings.TrimSpace(request.BasePrompt) == "" {
+		return "", RecoverFromError(NewValidationError("base_prompt", "", "a follow-up prompt is required"))
+	}
+
+	o.SetModel(request.Model)
+	cfg, err := o.loadConfiguration(request.ConfigPath)
+	if err != nil {
+		return "", RecoverFromError(NewConfigurationError("failed to load configuration", err))
+	}
+
+	data, err := o.buildTemplateData(request, cfg)
+	if err != nil {
+		return "", RecoverFromError(NewTemplateError(ContinuationTemplate, err))
+	}
+	data.Previous = previous
+
+	processor, ok := o.templateProcessor.(*template.Processor)
+	if !ok {
+		return request.BasePrompt, nil
+	}
+
+	tmpl, err := processor.LoadRootTemplate(ContinuationTemplate)
+	if err != nil {
+		return "", RecoverFromError(NewTemplateError(ContinuationTemplate, err))
+	}
+
+	rendered, err := processor.Execute(tmpl, *data)
+	if err != nil {
+		return "", RecoverFromError(NewTemplateError(ContinuationTemplate, err))
+	}
+
+	redactor, err := newRedactor(request, cfg)
+	if err != nil {
+		return "", RecoverFromError(err)
+	}
+	return o.finishRedaction(redactor, nil, strings.TrimRight(rendered, "\n")), nil
+}
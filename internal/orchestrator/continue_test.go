@@ -0,0 +1,49 @@
+package orchestrator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/pkg/models"
+)
+
+func TestGenerateContinuation(t *testing.T) {
+	promptsDir := t.TempDir()
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(configPath, []byte("prompts_location = \""+promptsDir+"\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := interfaces.PreviousPrompt{ID: "20240101-000000-abcdef", Prompt: "full earlier prompt", BasePrompt: "write a parser"}
+	request := &models.PromptRequest{BasePrompt: "now handle errors", ConfigPath: configPath}
+
+	// Built-in continuation template
+	got, err := New().GenerateContinuation(request, previous)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "This is a follow-up to my earlier request (\"write a parser\"). Everything from that request still applies; only the following is new:\n\nnow handle errors"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// A continue.md in the prompts directory overrides the built-in
+	override := "Re {{.Previous.ID}}: {{.Prompt}}"
+	if err := os.WriteFile(filepath.Join(promptsDir, "continue.md"), []byte(override), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err = New().GenerateContinuation(request, previous)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "Re 20240101-000000-abcdef: now handle errors" {
+		t.Errorf("got %q", got)
+	}
+
+	// A follow-up prompt is required
+	if _, err := New().GenerateContinuation(&models.PromptRequest{ConfigPath: configPath}, previous); err == nil {
+		t.Error("expected an error without a follow-up prompt")
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"prompter-cli/internal/template"
+	"prompter-cli/pkg/models"
 )
 
 // Error types for different categories of failures
@@ -24,6 +27,7 @@ type PrompterError struct {
 	Message  string
 	Guidance string
 	Cause    error
+	Subject  string // The template, path, or target the error is about, if any
 }
 
 func (e *PrompterError) Error() string {
@@ -37,6 +41,60 @@ func (e *PrompterError) Unwrap() error {
 	return e.Cause
 }
 
+// Is reports the error as its category, so errors.Is(err, ErrOutputFailed) holds for
+// every output error however deeply it was wrapped
+func (e *PrompterError) Is(target error) bool {
+	return target == e.Type
+}
+
+// categorized marks an error as belonging to a category without changing its message
+type categorized struct {
+	err      error
+	category error
+}
+
+func (e *categorized) Error() string   { return e.err.Error() }
+func (e *categorized) Unwrap() []error { return []error{e.err, e.category} }
+
+// WithCategory marks err as one of the error categories above, such as
+// ErrValidationFailed, for errors not built with a PrompterError constructor
+func WithCategory(err, category error) error {
+	if err == nil {
+		return nil
+	}
+	return &categorized{err: err, category: category}
+}
+
+// Exit codes prompter ends with, one per error category so scripts and editor
+// integrations can tell failures apart
+const (
+	ExitFailure       = 1 // Any other failure
+	ExitConfiguration = 2
+	ExitTemplate      = 3
+	ExitContent       = 4 // Includes fix mode input
+	ExitOutput        = 5
+	ExitValidation    = 6 // Invalid arguments or missing inputs
+)
+
+// ExitCode returns the exit code for err's category, or 0 for a nil error
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, ErrConfigurationInvalid):
+		return ExitConfiguration
+	case errors.Is(err, ErrTemplateNotFound), errors.Is(err, ErrTemplateInvalid):
+		return ExitTemplate
+	case errors.Is(err, ErrContentCollection), errors.Is(err, ErrFixModeInvalid):
+		return ExitContent
+	case errors.Is(err, ErrOutputFailed):
+		return ExitOutput
+	case errors.Is(err, ErrValidationFailed):
+		return ExitValidation
+	}
+	return ExitFailure
+}
+
 // Error constructors with actionable guidance
 
 func NewConfigurationError(message string, cause error) *PrompterError {
@@ -61,18 +119,45 @@ func NewTemplateError(templateName string, cause error) *PrompterError {
 	message := fmt.Sprintf("failed to process template '%s'", templateName)
 	guidance := "Run 'prompter --help' for template usage and configuration."
 	
-	if strings.Contains(cause.Error(), "not found") {
+	var notFound *template.NotFoundError
+	if errors.As(cause, &notFound) && len(notFound.Suggestions) > 0 {
+		guidance = fmt.Sprintf("Template '%s' not found. Did you mean %s? Run 'prompter list' to see all templates.", templateName, quotedAlternatives(notFound.Suggestions))
+	} else if strings.Contains(cause.Error(), "not found") {
 		guidance = fmt.Sprintf("Template '%s' not found. Run 'prompter --help' for template setup.", templateName)
 	} else if strings.Contains(cause.Error(), "parse") || strings.Contains(cause.Error(), "syntax") {
 		guidance = fmt.Sprintf("Template '%s' has syntax errors. Run 'prompter --help' for template format.", templateName)
 	}
+
+	// Errors located in the template file are only actionable with the line they point at
+	var located *template.Error
+	if errors.As(cause, &located) {
+		message = fmt.Sprintf("%s: %v", message, located)
+	}
+
+	errorType := ErrTemplateInvalid
+	if errors.Is(cause, template.ErrNotFound) {
+		errorType = ErrTemplateNotFound
+	}
 	
 	return &PrompterError{
-		Type:     ErrTemplateInvalid,
+		Type:     errorType,
 		Message:  message,
 		Guidance: guidance,
 		Cause:    cause,
+		Subject:  templateName,
+	}
+}
+
+// quotedAlternatives lists names as 'a', 'b', or 'c'
+func quotedAlternatives(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "'" + name + "'"
+	}
+	if len(quoted) <= 2 {
+		return strings.Join(quoted, " or ")
 	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + ", or " + quoted[len(quoted)-1]
 }
 
 func NewContentCollectionError(path string, cause error) *PrompterError {
@@ -90,6 +175,7 @@ func NewContentCollectionError(path string, cause error) *PrompterError {
 		Message:  message,
 		Guidance: guidance,
 		Cause:    cause,
+		Subject:  path,
 	}
 }
 
@@ -108,6 +194,7 @@ func NewFixModeError(fixFile string, cause error) *PrompterError {
 		Message:  message,
 		Guidance: guidance,
 		Cause:    cause,
+		Subject:  fixFile,
 	}
 }
 
@@ -117,8 +204,23 @@ func NewOutputError(target string, cause error) *PrompterError {
 	
 	if target == "clipboard" {
 		guidance = "Clipboard access failed. Try --target stdout or run 'prompter --help' for options."
-	} else if strings.HasPrefix(target, "file:") {
+	} else if target == models.TargetOSC52 {
+		guidance = "Copying through the terminal needs one attached. Try --target stdout or --target clipboard."
+	} else if strings.HasPrefix(target, models.TargetHTTPPrefix) {
+		message = fmt.Sprintf("failed to post to target '%s': %v", target, cause)
+		guidance = "Check the URL and the [webhook] settings in your config."
+	} else if strings.HasPrefix(target, models.TargetTmuxPrefix) {
+		message = fmt.Sprintf("failed to paste into target '%s': %v", target, cause)
+		guidance = "Check that tmux is running and the pane exists (tmux list-panes -a)."
+	} else if models.IsFileTarget(target) {
 		guidance = "File write failed. Run 'prompter --help' for output options."
+	} else if target == models.TargetOllama {
+		message = fmt.Sprintf("failed to send to target '%s': %v", target, cause)
+		guidance = "Check that Ollama is running with the model pulled, and the [ollama] settings in your config."
+	} else if models.IsModelTarget(target) {
+		// API failures are only actionable with the response's reason
+		message = fmt.Sprintf("failed to send to target '%s': %v", target, cause)
+		guidance = fmt.Sprintf("Check the API key and the [%s] settings in your config.", target)
 	} else if strings.Contains(cause.Error(), "editor") {
 		guidance = "Editor launch failed. Run 'prompter --help' for editor configuration."
 	}
@@ -128,6 +230,7 @@ func NewOutputError(target string, cause error) *PrompterError {
 		Message:  message,
 		Guidance: guidance,
 		Cause:    cause,
+		Subject:  target,
 	}
 }
 
@@ -0,0 +1,36 @@
+package orchestrator
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"prompter-cli/pkg/models"
+)
+
+// SetEventHandler registers a callback that receives progress events during
+// generation and output. Passing nil disables events.
+func (o *Orchestrator) SetEventHandler(handler models.EventHandler) {
+	o.eventHandler = handler
+}
+
+// emit sends an event to the registered handler, if any
+func (o *Orchestrator) emit(event models.Event) {
+	if o.eventHandler == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	o.eventHandler(event)
+}
+
+// warn logs a warning, reports it as an event, and records it for the report
+func (o *Orchestrator) warn(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	o.warnings = append(o.warnings, message)
+	if !o.silent {
+		slog.Warn(message)
+	}
+	o.emit(models.Event{Type: models.EventWarning, Message: message})
+}
@@ -0,0 +1,94 @@
+package orchestrator
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/pkg/models"
+)
+
+func TestEventHandler(t *testing.T) {
+	orch := New()
+	var events []models.Event
+	orch.SetEventHandler(func(event models.Event) {
+		events = append(events, event)
+	})
+
+	cfg := &interfaces.Config{PromptsLocation: t.TempDir()}
+	request := &models.PromptRequest{
+		BasePrompt: "explain this",
+		Files:      []string{"main.go"},
+	}
+
+	stages, err := BuildPipeline([]string{"files", "render"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := orch.runPipeline(context.Background(), stages, request, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	outPath := filepath.Join(t.TempDir(), "prompt.md")
+	request.Target = "file:" + outPath
+	if err := orch.OutputPrompt(context.Background(), state.Prompt, request, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantTypes := []models.EventType{
+		models.EventStageStarted,
+		models.EventFileCollected,
+		models.EventStageFinished,
+		models.EventStageStarted,
+		models.EventStageFinished,
+		models.EventBytesWritten,
+	}
+	if len(events) != len(wantTypes) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(wantTypes), events)
+	}
+	for i, want := range wantTypes {
+		if events[i].Type != want {
+			t.Errorf("event %d type = %s, want %s", i, events[i].Type, want)
+		}
+		if events[i].Time.IsZero() {
+			t.Errorf("event %d has no timestamp", i)
+		}
+	}
+
+	if events[0].Stage != "files" || events[3].Stage != "render" {
+		t.Errorf("unexpected stage names: %q, %q", events[0].Stage, events[3].Stage)
+	}
+	if events[1].Path != "main.go" {
+		t.Errorf("collected path = %q, want main.go", events[1].Path)
+	}
+	if last := events[len(events)-1]; last.Bytes != len(state.Prompt) || last.Path != outPath {
+		t.Errorf("bytes written event = %+v", last)
+	}
+}
+
+func TestWarnEmitsEvent(t *testing.T) {
+	orch := New()
+	var got models.Event
+	orch.SetEventHandler(func(event models.Event) { got = event })
+
+	orch.warn("template %s not found", "review")
+
+	if got.Type != models.EventWarning || got.Message != "template review not found" {
+		t.Errorf("unexpected event: %+v", got)
+	}
+}
+
+func TestChannelHandler(t *testing.T) {
+	ch := make(chan models.Event, 1)
+	handler := models.ChannelHandler(ch)
+
+	handler(models.Event{Type: models.EventWarning, Message: "first"})
+	// The channel is full, so this event is dropped instead of blocking
+	handler(models.Event{Type: models.EventWarning, Message: "second"})
+
+	if got := <-ch; got.Message != "first" {
+		t.Errorf("Message = %q, want first", got.Message)
+	}
+}
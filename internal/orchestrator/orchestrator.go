@@ -2,11 +2,14 @@ package orchestrator
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
@@ -14,8 +17,15 @@ import (
 	"github.com/AlecAivazis/survey/v2"
 	"golang.org/x/term"
 	"prompter-cli/internal/config"
+	"prompter-cli/internal/content"
+	gofix "prompter-cli/internal/fix"
+	"prompter-cli/internal/git"
 	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/logging"
+	"prompter-cli/internal/plugin"
+	"prompter-cli/internal/subprocess"
 	"prompter-cli/internal/template"
+	"prompter-cli/internal/tokenizer"
 	"prompter-cli/pkg/models"
 )
 
@@ -24,6 +34,17 @@ type Orchestrator struct {
 	configManager     interfaces.ConfigManager
 	templateProcessor interfaces.TemplateProcessor
 	outputHandler     interfaces.OutputHandler
+	eventHandler      models.EventHandler
+	tokenizer         tokenizer.Tokenizer // Counts tokens for budgets and reports, set with the config
+	quiet             bool                // Suppress output confirmation messages
+	system            string              // System prompt split off the last generated prompt for model targets
+	configWarned      bool                // Config warnings were reported by an earlier load
+	silent            bool                // Warnings are only reported as events, while previewing
+	warnings          []string            // Warnings reported while generating the last prompt
+	report            *Report             // Describes the last generated prompt
+	edited            string              // The last prompt as saved by the editor target
+	plugins           *plugin.Registry    // Plugins discovered in pluginsLocation
+	pluginsLocation   string
 }
 
 // New creates a new orchestrator with all required components
@@ -32,17 +53,29 @@ func New() *Orchestrator {
 		configManager:     config.NewManager(),
 		templateProcessor: template.NewProcessor(""),
 		outputHandler:     NewOutputHandler(),
+		tokenizer:         tokenizer.NewApproximate(),
 	}
 }
 
-// GeneratePrompt orchestrates the entire prompt generation process
-func (o *Orchestrator) GeneratePrompt(request *models.PromptRequest) (string, error) {
+// GeneratePrompt orchestrates the entire prompt generation process. When ctx is done,
+// generation stops at the next stage, file, or command and returns the context's cause.
+func (o *Orchestrator) GeneratePrompt(ctx context.Context, request *models.PromptRequest) (string, error) {
+	o.system = ""
+	o.warnings = nil
+	o.report = nil
+	o.edited = ""
+
+	if ctx.Err() != nil {
+		return "", context.Cause(ctx)
+	}
+
 	// Validate request first
 	if err := o.validateRequest(request); err != nil {
 		return "", RecoverFromError(err)
 	}
 
 	// Load and resolve configuration
+	o.SetModel(request.Model)
 	cfg, err := o.loadConfiguration(request.ConfigPath)
 	if err != nil {
 		configErr := NewConfigurationError("failed to load configuration", err)
@@ -52,12 +85,17 @@ func (o *Orchestrator) GeneratePrompt(request *models.PromptRequest) (string, er
 	// Apply configuration defaults to request
 	o.applyConfigDefaults(request, cfg)
 
+	// Commands and requests made by template helpers stop with the generation
+	if processor, ok := o.templateProcessor.(*template.Processor); ok {
+		processor.SetContext(ctx)
+	}
+
 	// Detect and handle mode (normal vs fix)
 	if request.FixMode {
-		return o.generateFixModePrompt(request, cfg)
+		return o.generateFixModePrompt(ctx, request, cfg)
 	}
 
-	return o.generateNormalPrompt(request, cfg)
+	return o.generateNormalPrompt(ctx, request, cfg)
 }
 
 // LoadConfiguration loads and resolves configuration with precedence (exported for app layer)
@@ -65,6 +103,22 @@ func (o *Orchestrator) LoadConfiguration(configPath string) (*interfaces.Config,
 	return o.loadConfiguration(configPath)
 }
 
+// SetQuiet suppresses the confirmation printed after copying or writing a prompt
+func (o *Orchestrator) SetQuiet(quiet bool) {
+	o.quiet = quiet
+}
+
+// SetSilent stops warnings from being printed to stderr; they are still reported
+// as events and in the report
+func (o *Orchestrator) SetSilent(silent bool) {
+	o.silent = silent
+}
+
+// Tokenizer returns the configured tokenizer (exported for app layer)
+func (o *Orchestrator) Tokenizer() tokenizer.Tokenizer {
+	return o.tokenizer
+}
+
 // GetTemplateProcessor returns the template processor (exported for app layer)
 func (o *Orchestrator) GetTemplateProcessor() interfaces.TemplateProcessor {
 	return o.templateProcessor
@@ -75,25 +129,73 @@ func (o *Orchestrator) loadConfiguration(configPath string) (*interfaces.Config,
 	// Load configuration from file first
 	_, err := o.configManager.Load(configPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load configuration: %w", err)
+		return nil, WithCategory(fmt.Errorf("failed to load configuration: %w", err), ErrConfigurationInvalid)
+	}
+
+	// Point out outdated settings that were upgraded in memory
+	if manager, ok := o.configManager.(*config.Manager); ok && !o.configWarned {
+		if migration := manager.PendingMigration(); migration != nil && len(migration.Changes) > 0 {
+			o.warn("config %s uses outdated settings (version %d); run 'prompter config migrate' to upgrade it", migration.Path, migration.FromVersion)
+		}
 	}
 
 	// Apply precedence resolution
 	cfg, err := o.configManager.Resolve()
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve configuration: %w", err)
+		return nil, WithCategory(fmt.Errorf("failed to resolve configuration: %w", err), ErrConfigurationInvalid)
 	}
 
 	// Validate configuration
 	if err := o.configManager.Validate(cfg); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
+		return nil, WithCategory(fmt.Errorf("invalid configuration: %w", err), ErrConfigurationInvalid)
+	}
+	if err := logging.Configure(cfg.LogLevel, cfg.LogFile); err != nil {
+		o.warn("%v; logging to stderr", err)
+	}
+	if cfg.Quiet {
+		logging.SetQuiet(true)
+		o.quiet = true
+	}
+
+	// Settings prompter doesn't read are usually typos that silently do nothing
+	if manager, ok := o.configManager.(*config.Manager); ok && !o.configWarned {
+		for _, key := range manager.UnknownKeys() {
+			o.warn("%s", key)
+		}
+	}
+	o.configWarned = true
+
+	// A tokenizer file that fails to load only costs accuracy, so fall back to the approximation
+	tok, err := tokenizer.New(cfg.Tokenizer, cfg.TokenizerFile)
+	if err != nil {
+		o.warn("%v; estimating tokens with the %s approximation", err, tokenizer.Claude)
+		tok = tokenizer.NewApproximate()
 	}
+	o.tokenizer = tok
+
+	o.discoverPlugins(cfg)
 
 	// Update template processor with the loaded configuration
 	if processor, ok := o.templateProcessor.(*template.Processor); ok {
-		processor.SetPromptsLocation(cfg.PromptsLocation)
+		processor.SetTokenizer(o.tokenizer)
+		processor.SetContentOptions(ContentOptions(cfg, &models.PromptRequest{}))
+		processor.SetExecOptions(template.ExecOptions{
+			Allowed:  cfg.AllowExec,
+			Timeout:  time.Duration(cfg.ExecTimeoutMS) * time.Millisecond,
+			MaxBytes: cfg.ExecMaxBytes,
+		})
+		processor.SetStrict(cfg.TemplateStrict)
+		processor.SetFetchOptions(template.FetchOptions{
+			Allow:    cfg.FetchAllow,
+			Timeout:  time.Duration(cfg.FetchTimeoutMS) * time.Millisecond,
+			MaxBytes: cfg.FetchMaxBytes,
+		})
+		processor.SetPromptsLocations(PromptsLocations(cfg))
 		processor.SetLocalPromptsFromConfig(cfg.LocalPromptsLocation)
 		processor.SetCustomTemplates(cfg.CustomTemplates)
+		processor.SetWasmPlugins(cfg.WasmPlugins)
+		processor.SetHelpers(cfg.Helpers)
+		processor.SetPlugins(o.plugins)
 	}
 
 	return cfg, nil
@@ -107,6 +209,8 @@ func (o *Orchestrator) applyConfigDefaults(request *models.PromptRequest, cfg *i
 	if request.PostTemplate == "" && cfg.DefaultPost != "" {
 		request.PostTemplate = cfg.DefaultPost
 	}
+	// The settings of the selected templates take precedence over the rest of the config
+	o.applyTemplateSettings(request)
 	if request.Target == "" && cfg.Target != "" {
 		request.Target = cfg.Target
 	}
@@ -117,116 +221,187 @@ func (o *Orchestrator) applyConfigDefaults(request *models.PromptRequest, cfg *i
 	}
 }
 
-// generateNormalPrompt generates a prompt in normal mode
-func (o *Orchestrator) generateNormalPrompt(request *models.PromptRequest, cfg *interfaces.Config) (string, error) {
-	var promptParts []string
-
-	// Process pre-template if specified
-	if request.PreTemplate != "" {
-		preContent, err := o.processTemplate(request.PreTemplate, request, cfg, "pre")
-		if err != nil {
-			templateErr := NewTemplateError(request.PreTemplate, err)
-			// Check if this is recoverable (template not found)
-			if IsRecoverableError(templateErr) {
-				// Log warning but continue without template
-				fmt.Fprintf(os.Stderr, "Warning: %s\n", templateErr.Error())
-			} else {
-				return "", RecoverFromError(templateErr)
-			}
-		} else if preContent != "" {
-			promptParts = append(promptParts, preContent)
-		}
+// generateNormalPrompt generates a prompt in normal mode by running the configured pipeline
+func (o *Orchestrator) generateNormalPrompt(ctx context.Context, request *models.PromptRequest, cfg *interfaces.Config) (string, error) {
+	stages, err := BuildPipeline(cfg.Pipeline)
+	if err != nil {
+		return "", RecoverFromError(NewConfigurationError("invalid pipeline", err))
 	}
 
-	// Add base prompt
-	if request.BasePrompt != "" {
-		promptParts = append(promptParts, request.BasePrompt)
+	state, err := o.runPipeline(ctx, stages, request, cfg)
+	if err != nil {
+		return "", err
 	}
 
-	// Include file content
-	if len(request.Files) > 0 || request.Directory != "" {
-		contentPart := o.formatContent(request)
-		if contentPart != "" {
-			promptParts = append(promptParts, contentPart)
-		}
-	}
+	o.system = state.System
+	state.Prompt = o.finishRedaction(state.Redactor, state.Redactions, state.Prompt)
+	o.report = o.newReport(state)
 
-	// Process post-template if specified
-	if request.PostTemplate != "" {
-		postContent, err := o.processTemplate(request.PostTemplate, request, cfg, "post")
-		if err != nil {
-			templateErr := NewTemplateError(request.PostTemplate, err)
-			// Check if this is recoverable (template not found)
-			if IsRecoverableError(templateErr) {
-				// Log warning but continue without template
-				fmt.Fprintf(os.Stderr, "Warning: %s\n", templateErr.Error())
-			} else {
-				return "", RecoverFromError(templateErr)
-			}
-		} else if postContent != "" {
-			promptParts = append(promptParts, postContent)
+	if request.Verbose {
+		if len(state.Data.Stats.Files) > 0 {
+			fmt.Fprintln(os.Stderr, formatStats(state.Data.Stats))
+		}
+		fmt.Fprintf(os.Stderr, "Prompt: %d tokens (%s)\n", o.tokenizer.Count(state.Prompt), o.tokenizer.Name())
+		if o.system != "" {
+			fmt.Fprintf(os.Stderr, "System prompt: %d tokens (%s)\n", o.tokenizer.Count(o.system), o.tokenizer.Name())
 		}
 	}
 
-	return strings.Join(promptParts, "\n\n"), nil
+	return state.Prompt, nil
 }
 
-// generateFixModePrompt generates a prompt in fix mode
-func (o *Orchestrator) generateFixModePrompt(request *models.PromptRequest, cfg *interfaces.Config) (string, error) {
-	// Load fix content from file, re-run command, or stdin
-	fixContent, err := o.loadFixContent(request.FixFile, request.Interactive, request.NumberSelect)
+// generateFixModePrompt generates a prompt in fix mode. It gathers its own content,
+// so of the configured pipeline only the stages after "render" run on its prompt.
+func (o *Orchestrator) generateFixModePrompt(ctx context.Context, request *models.PromptRequest, cfg *interfaces.Config) (string, error) {
+	stages, err := BuildPipeline(cfg.Pipeline)
+	if err != nil {
+		return "", RecoverFromError(NewConfigurationError("invalid pipeline", err))
+	}
+
+	// Load fix content from file, stdin, or by re-running the last command
+	fixContent, err := o.loadFixContent(ctx, request)
 	if err != nil {
 		fixErr := NewFixModeError(request.FixFile, err)
 		return "", RecoverFromError(fixErr)
 	}
 
+	redactor, err := newRedactor(request, cfg)
+	if err != nil {
+		return "", RecoverFromError(err)
+	}
+
+	// Find the source lines the output refers to, and include them when configured
+	fix := parseFixContent(fixContent)
+	cwd, _ := os.Getwd()
+	fix.Locations = gofix.Parse(fix.Output, cwd)
+	state := &PipelineState{Context: ctx, Request: request, Config: cfg, Redactor: redactor}
+	if cfg.FixIncludeFiles {
+		if err := o.embedFixFiles(state, fix.Locations); err != nil {
+			return "", RecoverFromError(NewFixModeError(request.FixFile, err))
+		}
+	}
+
 	var promptParts []string
 
-	// Try to load fix.md from prompts_location root, fallback to "Please fix"
-	fixPrompt, err := o.loadFixPrompt(cfg.PromptsLocation)
+	// Render fix.md from prompts_location root, fallback to "Please fix"
+	fixPrompt, err := o.loadFixPrompt(request, cfg, fix)
 	if err != nil {
-		// Fallback to default "Please fix" prompt
+		return "", RecoverFromError(NewTemplateError("fix.md", err))
+	}
+	if fixPrompt == "" {
 		fixPrompt = "Please fix"
 	}
-	
+
 	// Add the fix prompt
 	promptParts = append(promptParts, fixPrompt)
 
 	// Add the captured content (command + output) as a separate part
 	promptParts = append(promptParts, fixContent)
 
-	return strings.Join(promptParts, "\n\n"), nil
+	// Add the referenced files after the output
+	if state.Content != "" {
+		promptParts = append(promptParts, state.Content)
+	}
+
+	state.Prompt = strings.Join(promptParts, "\n\n")
+	state.Rendered = true
+	if err := o.runStages(state, finalizeStages(stages)); err != nil {
+		return "", err
+	}
+
+	state.Prompt = o.finishRedaction(redactor, state.Redactions, state.Prompt)
+	o.report = o.newReport(state)
+	return state.Prompt, nil
 }
 
-// processTemplate processes a template with the current context
-func (o *Orchestrator) processTemplate(templateName string, request *models.PromptRequest, cfg *interfaces.Config, templateType string) (string, error) {
+// processTemplate processes a template with the current context, returning its
+// system section, if any, apart from the rest
+func (o *Orchestrator) processTemplate(templateName string, templateData *interfaces.TemplateData, cfg *interfaces.Config) (string, string, error) {
 	// Update template processor with prompts location
 	if processor, ok := o.templateProcessor.(*template.Processor); ok {
-		processor.SetPromptsLocation(cfg.PromptsLocation)
+		processor.SetPromptsLocations(PromptsLocations(cfg))
 		processor.SetLocalPromptsFromConfig(cfg.LocalPromptsLocation)
 		processor.SetCustomTemplates(cfg.CustomTemplates)
+		processor.SetWasmPlugins(cfg.WasmPlugins)
+		processor.SetHelpers(cfg.Helpers)
+		processor.SetPlugins(o.plugins)
 	}
 
 	// Load template using the template processor's discovery mechanism
 	// The processor will find the correct file (including .default. files)
 	tmpl, err := o.templateProcessor.LoadTemplate(templateName)
 	if err != nil {
-		return "", fmt.Errorf("failed to load template %s: %w", templateName, err)
+		return "", "", fmt.Errorf("failed to load template %s: %w", templateName, err)
 	}
 
-	// Build template data
-	templateData, err := o.buildTemplateData(request, cfg)
+	// Execute template
+	result, err := o.templateProcessor.Execute(tmpl, *templateData)
 	if err != nil {
-		return "", fmt.Errorf("failed to build template data: %w", err)
+		return "", "", fmt.Errorf("failed to execute template %s: %w", templateName, err)
 	}
 
-	// Execute template
-	result, err := o.templateProcessor.Execute(tmpl, *templateData)
+	// Render the system section apart from the rest
+	processor, ok := o.templateProcessor.(*template.Processor)
+	if !ok {
+		return result, "", nil
+	}
+	system, err := processor.ExecuteSystem(tmpl, *templateData)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute template %s: %w", templateName, err)
+		return "", "", fmt.Errorf("failed to execute template %s: %w", templateName, err)
+	}
+	if system != "" {
+		result = strings.TrimSpace(result) // Drop the lines the section left behind
 	}
 
-	return result, nil
+	return result, system, nil
+}
+
+// isSystemTemplate reports whether a template's frontmatter marks it as a system prompt
+func (o *Orchestrator) isSystemTemplate(templateName string) bool {
+	processor, ok := o.templateProcessor.(*template.Processor)
+	if !ok {
+		return false
+	}
+	meta, err := processor.TemplateMetadata(templateName)
+	return err == nil && meta != nil && meta.System
+}
+
+// applyTemplateSettings fills in request settings from the frontmatter of the selected
+// templates, pre-templates first. Settings already on the request (from flags or an
+// earlier template) are kept; post-templates a template asks for are added.
+func (o *Orchestrator) applyTemplateSettings(request *models.PromptRequest) {
+	processor, ok := o.templateProcessor.(*template.Processor)
+	if !ok {
+		return
+	}
+
+	// Added post-templates are visited too, for their own settings
+	names := append(models.TemplateNames(request.PreTemplate), models.TemplateNames(request.PostTemplate)...)
+	for i := 0; i < len(names); i++ {
+		meta, err := processor.TemplateMetadata(names[i])
+		if err != nil || meta == nil {
+			continue // Rendering reports templates that don't load
+		}
+		settings := meta.Settings
+
+		if request.DirectoryStrategy == "" {
+			request.DirectoryStrategy = settings.DirectoryStrategy
+		}
+		if request.MaxTokens == 0 {
+			request.MaxTokens = settings.MaxTokens
+		}
+		if request.Target == "" {
+			request.Target = settings.Target
+		}
+		post := models.TemplateNames(request.PostTemplate)
+		for _, companion := range models.TemplateNames(settings.Post) {
+			if !slices.Contains(post, companion) {
+				post = append(post, companion)
+				names = append(names, companion)
+			}
+		}
+		request.PostTemplate = models.JoinTemplateNames(post)
+	}
 }
 
 // formatContent formats files and directory for inclusion in the prompt
@@ -238,31 +413,33 @@ func (o *Orchestrator) formatContent(request *models.PromptRequest) string {
 		parts = append(parts, "Referencing files:")
 		for _, file := range request.Files {
 			parts = append(parts, file)
+			o.emit(models.Event{Type: models.EventFileCollected, Path: file})
 		}
 	}
 
 	// Add directory reference using current working directory
 	if request.Directory != "" {
 		parts = append(parts, "Referencing dir:")
-		if request.Directory == "." {
-			if cwd, err := os.Getwd(); err == nil {
-				parts = append(parts, cwd)
-			} else {
-				parts = append(parts, request.Directory)
-			}
-		} else {
-			// Convert to absolute path
-			if absPath, err := filepath.Abs(request.Directory); err == nil {
-				parts = append(parts, absPath)
-			} else {
-				parts = append(parts, request.Directory)
-			}
-		}
+		dir := referencedDir(request.Directory)
+		parts = append(parts, dir)
+		o.emit(models.Event{Type: models.EventFileCollected, Path: dir})
 	}
 
 	return strings.Join(parts, "\n")
 }
 
+// referencedDir returns the absolute path a directory is referenced by
+func referencedDir(directory string) string {
+	if directory == "." {
+		if cwd, err := os.Getwd(); err == nil {
+			return cwd
+		}
+	} else if absPath, err := filepath.Abs(directory); err == nil {
+		return absPath
+	}
+	return directory
+}
+
 // buildTemplateData builds the template data context
 func (o *Orchestrator) buildTemplateData(request *models.PromptRequest, cfg *interfaces.Config) (*interfaces.TemplateData, error) {
 	cwd, _ := os.Getwd()
@@ -287,83 +464,154 @@ func (o *Orchestrator) buildTemplateData(request *models.PromptRequest, cfg *int
 		"target":             cfg.Target,
 	}
 
-	// Build git info
-	gitInfo := o.buildGitInfo()
-
-	// Build fix info
+	// Fix mode fills in the captured command and output
 	fixInfo := interfaces.FixInfo{
 		Enabled: request.FixMode,
 	}
-	if request.FixMode && request.FixFile != "" {
-		if content, err := o.loadFixContent(request.FixFile, request.Interactive, request.NumberSelect); err == nil {
-			fixInfo.Raw = content
-			// Try to parse command and output (simple implementation)
-			lines := strings.Split(content, "\n")
-			if len(lines) > 0 {
-				fixInfo.Command = lines[0]
-				if len(lines) > 1 {
-					fixInfo.Output = strings.Join(lines[1:], "\n")
-				}
-			}
-		}
-	}
 
 	return &interfaces.TemplateData{
 		Prompt: request.BasePrompt,
 		Now:    time.Now(),
 		CWD:    cwd,
 		Files:  []interfaces.FileInfo{}, // No longer used
-		Git:    gitInfo,
+		Git:    interfaces.GitInfo{}, // Populated by the git pipeline stage
 		Config: configMap,
 		Env:    envMap,
 		Fix:    fixInfo,
+		Vars:   MergeVars(cfg.Vars, request.Vars),
+		Model:  o.modelInfo(cfg),
 	}, nil
 }
 
-// buildGitInfo builds git repository information
-func (o *Orchestrator) buildGitInfo() interfaces.GitInfo {
-	gitInfo := interfaces.GitInfo{}
+// SetModel selects a model preset over the model setting for the configurations
+// loaded afterwards, as --model does. An empty name keeps the setting.
+func (o *Orchestrator) SetModel(name string) {
+	if manager, ok := o.configManager.(*config.Manager); ok {
+		manager.SetFlag("model", name)
+	}
+}
 
-	// This is a simple implementation - in a real scenario we'd use git libraries
-	// For now, we'll just try to detect if we're in a git repo
-	if _, err := os.Stat(".git"); err == nil {
-		if cwd, err := os.Getwd(); err == nil {
-			gitInfo.Root = cwd
-		}
-		// TODO: Implement proper git info extraction
-		gitInfo.Branch = "main" // Default
-		gitInfo.Commit = "unknown"
-		gitInfo.Dirty = false
+// modelInfo describes the selected model preset for templates
+func (o *Orchestrator) modelInfo(cfg *interfaces.Config) interfaces.ModelInfo {
+	if cfg.Model == "" {
+		return interfaces.ModelInfo{}
+	}
+	format := cfg.FileFormat
+	if _, ok := content.FormatPresets[format]; !ok {
+		format = "custom"
 	}
+	return interfaces.ModelInfo{
+		Name:          cfg.Model,
+		ContextWindow: cfg.Models[cfg.Model].ContextWindow,
+		Tokenizer:     o.tokenizer.Name(),
+		Format:        format,
+	}
+}
 
-	return gitInfo
+// PromptsLocations returns the prompts locations of cfg in search order, falling back
+// to PromptsLocation for configurations built without the list. The template sources
+// come last, so they never shadow templates of the same name in prompts_location.
+func PromptsLocations(cfg *interfaces.Config) []string {
+	locations := cfg.PromptsLocations
+	if len(locations) == 0 {
+		locations = []string{cfg.PromptsLocation}
+	}
+	if len(cfg.TemplateSources) == 0 {
+		return locations
+	}
+
+	locations = append([]string(nil), locations...)
+	for _, source := range cfg.TemplateSources {
+		locations = append(locations, config.TemplateSourceLocation(cfg, source))
+	}
+	return locations
+}
+
+// MergeVars combines template variables from the config with those given for a
+// single run; values from the run take precedence
+func MergeVars(configVars, requestVars map[string]string) map[string]string {
+	if len(configVars) == 0 {
+		return requestVars
+	}
+
+	merged := make(map[string]string, len(configVars)+len(requestVars))
+	for name, value := range configVars {
+		merged[name] = value
+	}
+	for name, value := range requestVars {
+		merged[name] = value
+	}
+	return merged
+}
+
+// buildGitInfo builds git repository information for the working directory
+func (o *Orchestrator) buildGitInfo(recentCommits int) interfaces.GitInfo {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return interfaces.GitInfo{}
+	}
+	return git.Info(cwd, recentCommits)
 }
 
-// loadFixContent loads content from the fix file, re-runs last command, or reads from stdin
-func (o *Orchestrator) loadFixContent(fixFile string, interactive bool, numberSelect bool) (string, error) {
-	if fixFile != "" {
+// loadFixContent loads content from the fix file or stdin, headed by the command that
+// produced it when --fix-command names it, or re-runs the last command
+func (o *Orchestrator) loadFixContent(ctx context.Context, request *models.PromptRequest) (string, error) {
+	var output string
+	switch {
+	case request.FixFile == models.FixFileStdin:
+		content, err := o.readFromStdin()
+		if err != nil {
+			return "", fmt.Errorf("failed to read stdin: %w", err)
+		}
+		if output = strings.TrimSpace(string(content)); output == "" {
+			return "", fmt.Errorf("no command output on stdin (stdin is empty)")
+		}
+
+	case request.FixFile != "":
 		// Read from specified file
-		content, err := os.ReadFile(fixFile)
+		content, err := os.ReadFile(request.FixFile)
 		if err != nil {
 			return "", err // Let the caller wrap this with appropriate error type
 		}
-
-		trimmedContent := strings.TrimSpace(string(content))
-		if trimmedContent == "" {
+		if output = strings.TrimSpace(string(content)); output == "" {
 			return "", fmt.Errorf("fix file is empty")
 		}
 
-		return trimmedContent, nil
+	case stdinIsPiped():
+		// Output piped in without naming stdin, as in: go test ./... 2>&1 | prompter --fix
+		content, err := o.readFromStdin()
+		if err != nil {
+			return "", fmt.Errorf("failed to read stdin: %w", err)
+		}
+		output = strings.TrimSpace(string(content))
+	}
+
+	if output != "" {
+		if request.FixCommand != "" && !strings.HasPrefix(output, "$ ") {
+			output = "$ " + request.FixCommand + "\n\n" + output
+		}
+		return output, nil
 	}
 
-	// No fix file specified - try to re-run the last command
-	if interactive {
+	// Nothing captured - try to re-run the last command
+	if request.Interactive {
 		// Interactive mode: prompt user to re-run last command
-		return o.promptAndRerunLastCommand(numberSelect)
+		return o.promptAndRerunLastCommand(ctx, request.NumberSelect)
 	} else {
 		// Non-interactive mode: automatically re-run last command
-		return o.rerunLastCommand()
+		return o.rerunLastCommand(ctx)
+	}
+}
+
+// parseFixContent splits captured fix content into the command, given as a leading
+// "$ command" line, and its output
+func parseFixContent(content string) interfaces.FixInfo {
+	fix := interfaces.FixInfo{Enabled: true, Raw: content, Output: content}
+	if first, rest, _ := strings.Cut(content, "\n"); strings.HasPrefix(first, "$ ") {
+		fix.Command = strings.TrimPrefix(first, "$ ")
+		fix.Output = strings.TrimSpace(rest)
 	}
+	return fix
 }
 
 // readFromStdin reads all content from stdin
@@ -371,6 +619,12 @@ func (o *Orchestrator) readFromStdin() ([]byte, error) {
 	return io.ReadAll(os.Stdin)
 }
 
+// stdinIsPiped reports whether stdin is a pipe or a redirected file rather than a terminal
+func stdinIsPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
 // captureTerminalOutput attempts to capture previous terminal output
 func (o *Orchestrator) captureTerminalOutput() (string, error) {
 	// Check if we can access terminal scroll buffer (advanced terminals)
@@ -474,7 +728,7 @@ func (o *Orchestrator) readRecentHistory(historyFile, shell string) (string, err
 }
 
 // promptAndRerunLastCommand prompts user to re-run the last command and captures output
-func (o *Orchestrator) promptAndRerunLastCommand(numberSelect bool) (string, error) {
+func (o *Orchestrator) promptAndRerunLastCommand(ctx context.Context, numberSelect bool) (string, error) {
 	// Get the last command from history
 	lastCmd, err := o.getLastCommand()
 	if err != nil {
@@ -497,11 +751,11 @@ func (o *Orchestrator) promptAndRerunLastCommand(numberSelect bool) (string, err
 	}
 
 	// Execute the command and capture output
-	return o.executeAndCaptureCommand(lastCmd)
+	return o.executeAndCaptureCommand(ctx, lastCmd)
 }
 
 // rerunLastCommand automatically re-runs the last command (non-interactive mode)
-func (o *Orchestrator) rerunLastCommand() (string, error) {
+func (o *Orchestrator) rerunLastCommand(ctx context.Context) (string, error) {
 	// Get the last command from history
 	lastCmd, err := o.getLastCommand()
 	if err != nil {
@@ -511,7 +765,7 @@ func (o *Orchestrator) rerunLastCommand() (string, error) {
 	fmt.Printf("Re-running last command: %s\n", lastCmd)
 
 	// Execute the command and capture output
-	return o.executeAndCaptureCommand(lastCmd)
+	return o.executeAndCaptureCommand(ctx, lastCmd)
 }
 
 // getLastCommand retrieves the last command from shell history
@@ -574,13 +828,17 @@ func (o *Orchestrator) getLastCommandFromHistory(historyFile, shell string) (str
 	return "", fmt.Errorf("no suitable command found in history")
 }
 
-// executeAndCaptureCommand executes a command and captures both stdout and stderr
-func (o *Orchestrator) executeAndCaptureCommand(command string) (string, error) {
+// executeAndCaptureCommand executes a command and captures both stdout and stderr,
+// killing it when ctx is done
+func (o *Orchestrator) executeAndCaptureCommand(ctx context.Context, command string) (string, error) {
 	// Execute the command using the shell
-	cmd := exec.Command("sh", "-c", command)
+	cmd := subprocess.Shell(ctx, command)
 
 	// Capture both stdout and stderr
 	output, _ := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return "", context.Cause(ctx)
+	}
 
 	// Format the result with command and output separated by a blank line
 	var result strings.Builder
@@ -592,8 +850,11 @@ func (o *Orchestrator) executeAndCaptureCommand(command string) (string, error)
 	return strings.TrimSpace(result.String()), nil
 }
 
-// OutputPrompt handles the final output of the generated prompt
-func (o *Orchestrator) OutputPrompt(prompt string, request *models.PromptRequest, cfg *interfaces.Config) error {
+// OutputPrompt handles the final output of the generated prompt. When a clipboard,
+// OSC 52, or tmux target fails, the prompt goes to the first of target_fallbacks that
+// works instead, with a warning naming each target that failed. Requests to model
+// APIs, webhooks, and plugins are stopped when ctx is done.
+func (o *Orchestrator) OutputPrompt(ctx context.Context, prompt string, request *models.PromptRequest, cfg *interfaces.Config) error {
 	target := request.Target
 	if target == "" {
 		target = cfg.Target
@@ -601,49 +862,212 @@ func (o *Orchestrator) OutputPrompt(prompt string, request *models.PromptRequest
 	if target == "" {
 		target = "stdout" // Default fallback
 	}
+	slog.Debug("dispatching prompt", "target", target, "bytes", len(prompt))
+
+	if err := o.deliver(ctx, prompt, target, request, cfg); err != nil {
+		if !models.UsesFallbacks(target) || !o.deliverFallback(ctx, prompt, target, err, request, cfg) {
+			return err
+		}
+	}
+
+	// Handle editor integration if explicitly requested
+	if request.EditorRequested {
+		editor := o.resolveEditor(request.Editor, cfg.Editor)
+		if err := o.outputHandler.OpenInEditor(prompt, editor); err != nil {
+			outputErr := NewOutputError("editor", err)
+			return RecoverFromError(outputErr)
+		}
+	}
+
+	return nil
+}
+
+// deliverFallback sends the prompt to the first of target_fallbacks that works after
+// target failed with err, warning about each failure, and reports whether one did
+func (o *Orchestrator) deliverFallback(ctx context.Context, prompt, target string, err error, request *models.PromptRequest, cfg *interfaces.Config) bool {
+	failed := target
+	for _, fallback := range cfg.TargetFallbacks {
+		if ctx.Err() != nil {
+			return false
+		}
+		if fallback == target {
+			continue
+		}
+		o.warn("couldn't output to %s (%v); trying %s", failed, outputCause(err), fallback)
+		if err = o.deliver(ctx, prompt, fallback, request, cfg); err == nil {
+			return true
+		}
+		failed = fallback
+	}
+	if failed != target {
+		o.warn("couldn't output to %s (%v)", failed, outputCause(err))
+	}
+	return false
+}
 
-	// Handle different output targets
+// outputCause returns what went wrong in an output error, without its guidance
+func outputCause(err error) error {
+	var prompterErr *PrompterError
+	if errors.As(err, &prompterErr) && prompterErr.Cause != nil {
+		return prompterErr.Cause
+	}
+	return err
+}
+
+// deliver sends the prompt to a single target
+func (o *Orchestrator) deliver(ctx context.Context, prompt, target string, request *models.PromptRequest, cfg *interfaces.Config) error {
 	switch {
 	case target == "clipboard":
 		if err := o.outputHandler.WriteToClipboard(prompt); err != nil {
-			outputErr := NewOutputError(target, err)
-			// Try to recover by falling back to stdout
-			if IsRecoverableError(outputErr) {
-				fmt.Fprintf(os.Stderr, "Warning: %s\nFalling back to stdout:\n\n", outputErr.Error())
-				return o.outputHandler.WriteToStdout(prompt)
-			}
-			return RecoverFromError(outputErr)
+			return RecoverFromError(NewOutputError(target, err))
+		}
+		if !o.quiet {
+			fmt.Println("Prompt copied to clipboard")
 		}
-		fmt.Println("Prompt copied to clipboard")
+		o.emit(models.Event{Type: models.EventBytesWritten, Target: target, Bytes: len(prompt)})
+
+	case target == models.TargetEditor:
+		return o.editAndOutput(ctx, prompt, request, cfg)
+
+	case target == models.TargetOSC52:
+		if err := o.outputHandler.WriteToTerminalClipboard(prompt); err != nil {
+			return RecoverFromError(NewOutputError(target, err))
+		}
+		if !o.quiet {
+			fmt.Println("Prompt copied to clipboard through the terminal")
+		}
+		o.emit(models.Event{Type: models.EventBytesWritten, Target: target, Bytes: len(prompt)})
 
 	case target == "stdout":
 		if err := o.outputHandler.WriteToStdout(prompt); err != nil {
 			outputErr := NewOutputError(target, err)
 			return RecoverFromError(outputErr)
 		}
+		o.emit(models.Event{Type: models.EventBytesWritten, Target: target, Bytes: len(prompt)})
+
+	case models.IsModelTarget(target):
+		if err := o.sendToModel(ctx, prompt, target, cfg); err != nil {
+			return RecoverFromError(NewOutputError(target, err))
+		}
+		o.emit(models.Event{Type: models.EventBytesWritten, Target: target, Bytes: len(prompt)})
+
+	case strings.HasPrefix(target, models.TargetPluginPrefix):
+		message, err := o.sendToPlugin(ctx, prompt, strings.TrimPrefix(target, models.TargetPluginPrefix))
+		if err != nil {
+			return RecoverFromError(NewOutputError(target, err))
+		}
+		if !o.quiet && message != "" {
+			fmt.Println(message)
+		}
+		o.emit(models.Event{Type: models.EventBytesWritten, Target: target, Bytes: len(prompt)})
+
+	case strings.HasPrefix(target, models.TargetHTTPPrefix):
+		url := strings.TrimPrefix(target, models.TargetHTTPPrefix)
+		if err := o.postToWebhook(ctx, prompt, url, cfg); err != nil {
+			return RecoverFromError(NewOutputError(target, err))
+		}
+		if !o.quiet {
+			fmt.Printf("Prompt posted to %s\n", url)
+		}
+		o.emit(models.Event{Type: models.EventBytesWritten, Target: target, Bytes: len(prompt)})
+
+	case strings.HasPrefix(target, models.TargetTmuxPrefix):
+		pane := strings.TrimPrefix(target, models.TargetTmuxPrefix)
+		if err := o.outputHandler.WriteToTmuxPane(prompt, pane); err != nil {
+			return RecoverFromError(NewOutputError(target, err))
+		}
+		if !o.quiet {
+			fmt.Printf("Prompt pasted into tmux pane %s\n", pane)
+		}
+		o.emit(models.Event{Type: models.EventBytesWritten, Target: target, Bytes: len(prompt)})
 
-	case strings.HasPrefix(target, "file:"):
-		filePath := strings.TrimPrefix(target, "file:")
-		if err := o.outputHandler.WriteToFile(prompt, filePath); err != nil {
+	case models.IsFileTarget(target):
+		filePath, err := FileTargetPath(target, request, cfg)
+		if err != nil {
+			return RecoverFromError(NewOutputError(target, err))
+		}
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return RecoverFromError(NewOutputError(target, err))
+		}
+		appending := strings.HasPrefix(target, models.TargetAppendPrefix)
+		if appending {
+			err = o.outputHandler.AppendToFile(prompt, filePath)
+		} else {
+			err = o.outputHandler.WriteToFile(prompt, filePath)
+		}
+		if err != nil {
 			outputErr := NewOutputError(target, err)
 			return RecoverFromError(outputErr)
 		}
-		fmt.Printf("Prompt written to %s\n", filePath)
+		if !o.quiet && appending {
+			fmt.Printf("Prompt appended to %s\n", filePath)
+		} else if !o.quiet {
+			fmt.Printf("Prompt written to %s\n", filePath)
+		}
+		o.emit(models.Event{Type: models.EventBytesWritten, Target: target, Path: filePath, Bytes: len(prompt)})
 
 	default:
 		return RecoverFromError(NewValidationError("target", target, "unsupported output target"))
 	}
 
-	// Handle editor integration if explicitly requested
-	if request.EditorRequested {
-		editor := o.resolveEditor(request.Editor, cfg.Editor)
-		if err := o.outputHandler.OpenInEditor(prompt, editor); err != nil {
-			outputErr := NewOutputError("editor", err)
-			return RecoverFromError(outputErr)
+	return nil
+}
+
+// editAndOutput opens the prompt in the editor and sends the version saved there to
+// editor_target. Saving an empty file sends nothing, as with git commit.
+func (o *Orchestrator) editAndOutput(ctx context.Context, prompt string, request *models.PromptRequest, cfg *interfaces.Config) error {
+	o.edited = ""
+	editor := o.resolveEditor(request.Editor, cfg.Editor)
+	edited, err := o.outputHandler.EditInEditor(prompt, editor)
+	if err != nil {
+		return RecoverFromError(NewOutputError(models.TargetEditor, err))
+	}
+	if strings.TrimSpace(edited) == "" {
+		if !o.quiet {
+			fmt.Fprintln(os.Stderr, "The prompt was emptied in the editor; nothing was sent")
 		}
+		return nil
 	}
+	o.edited = edited
 
-	return nil
+	next := *request
+	next.Target = cfg.EditorTarget
+	if next.Target == "" {
+		next.Target = models.TargetClipboard
+	}
+	next.EditorRequested = false // It was just open
+	return o.OutputPrompt(ctx, edited, &next, cfg)
+}
+
+// EditedPrompt returns the prompt as saved by the editor target, or "" when the
+// last prompt wasn't edited
+func (o *Orchestrator) EditedPrompt() string {
+	return o.edited
+}
+
+// FileTargetPath returns the file a file: or file+: target writes to, with ~ and the
+// path's strftime directives and template placeholders expanded
+func FileTargetPath(target string, request *models.PromptRequest, cfg *interfaces.Config) (string, error) {
+	pattern := strings.TrimPrefix(strings.TrimPrefix(target, models.TargetAppendPrefix), models.TargetFilePrefix)
+	path, err := template.RenderOutputPath(pattern, template.OutputPathData{
+		Now:    time.Now(),
+		Prompt: request.BasePrompt,
+		Pre:    request.PreTemplate,
+		Post:   request.PostTemplate,
+		Vars:   MergeVars(cfg.Vars, request.Vars),
+	})
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return "", fmt.Errorf("no path given; use file:<path> or file+:<path>")
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	return path, nil
 }
 
 // validateRequest validates the prompt request
@@ -658,18 +1082,8 @@ func (o *Orchestrator) validateRequest(request *models.PromptRequest) error {
 	}
 
 	// Validate target format if specified
-	if request.Target != "" {
-		validTargets := []string{"clipboard", "stdout"}
-		isValid := false
-		for _, valid := range validTargets {
-			if request.Target == valid || strings.HasPrefix(request.Target, "file:") {
-				isValid = true
-				break
-			}
-		}
-		if !isValid {
-			return NewValidationError("target", request.Target, "must be 'clipboard', 'stdout', or 'file:/path'")
-		}
+	if request.Target != "" && !models.ValidTarget(request.Target) {
+		return NewValidationError("target", request.Target, "must be "+models.TargetUsage)
 	}
 
 	// Validate config path if specified
@@ -811,16 +1225,29 @@ func (o *Orchestrator) fallbackYesNoSelection(defaultValue bool) (bool, error) {
 	}
 }
 
-// loadFixPrompt loads the fix prompt from prompts_location/fix.md
-func (o *Orchestrator) loadFixPrompt(promptsLocation string) (string, error) {
-	fixPath := filepath.Join(promptsLocation, "fix.md")
-	
-	content, err := os.ReadFile(fixPath)
+// loadFixPrompt renders prompts_location/fix.md with the captured command and output
+// as .Fix, returning "" when there's no fix.md
+func (o *Orchestrator) loadFixPrompt(request *models.PromptRequest, cfg *interfaces.Config, fix interfaces.FixInfo) (string, error) {
+	fixPath := filepath.Join(cfg.PromptsLocation, "fix.md")
+	if _, err := os.Stat(fixPath); err != nil {
+		return "", nil
+	}
+
+	tmpl, err := o.templateProcessor.LoadTemplate(fixPath)
 	if err != nil {
-		return "", fmt.Errorf("fix.md not found at %s: %w", fixPath, err)
+		return "", err
+	}
+	data, err := o.buildTemplateData(request, cfg)
+	if err != nil {
+		return "", err
+	}
+	data.Fix = fix
+
+	rendered, err := o.templateProcessor.Execute(tmpl, *data)
+	if err != nil {
+		return "", err
 	}
-	
-	return strings.TrimSpace(string(content)), nil
+	return strings.TrimSpace(rendered), nil
 }
 
 // resolveEditor resolves the editor using precedence rules
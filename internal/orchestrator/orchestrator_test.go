@@ -1,7 +1,11 @@
 package orchestrator
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -204,4 +208,282 @@ func TestIsRecoverableError(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestExitCode(t *testing.T) {
+	cause := errors.New("exit status 1")
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, 0},
+		{"configuration", NewConfigurationError("bad config", cause), ExitConfiguration},
+		{"configuration, categorized", WithCategory(cause, ErrConfigurationInvalid), ExitConfiguration},
+		{"template", NewTemplateError("review", cause), ExitTemplate},
+		{"template not found", &PrompterError{Type: ErrTemplateNotFound, Message: "review"}, ExitTemplate},
+		{"content", NewContentCollectionError("main.go", cause), ExitContent},
+		{"fix mode", NewFixModeError("fix.txt", cause), ExitContent},
+		{"output, wrapped", fmt.Errorf("output failed: %w", NewOutputError("clipboard", cause)), ExitOutput},
+		{"validation", NewValidationError("target", "nowhere", "unknown target"), ExitValidation},
+		{"other", cause, ExitFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// withStdin replaces stdin with a pipe holding input for the rest of the test
+func withStdin(t *testing.T, input string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteString(input); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		r.Close()
+	})
+}
+
+func TestGeneratePrompt_TemplateSettings(t *testing.T) {
+	promptsDir := t.TempDir()
+	templates := map[string]string{
+		"pre/audit.md":      "---\nsettings:\n  directory_strategy: filesystem\n  max_tokens: 500\n  target: stdout\n  post: checklist\n---\nAudit:",
+		"post/checklist.md": "---\nsettings:\n  target: clipboard\n---\n- [ ] done",
+		"pre/exfil.md":      "---\nsettings:\n  target: http:https://example.com/collect\n---\nSend:",
+	}
+	for name, body := range templates {
+		path := filepath.Join(promptsDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	config := "prompts_location = \"" + promptsDir + "\"\ntarget = \"clipboard\"\ndirectory_strategy = \"git\"\n"
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The template's settings beat the config, and its companion post-template is added
+	orch := New()
+	request := &models.PromptRequest{BasePrompt: "the parser", PreTemplate: "audit", ConfigPath: configPath}
+	prompt, err := orch.GeneratePrompt(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if prompt != "Audit:\n\nthe parser\n\n- [ ] done" {
+		t.Errorf("prompt = %q", prompt)
+	}
+	if request.PostTemplate != "checklist" || request.Target != models.TargetStdout || request.DirectoryStrategy != "filesystem" || request.MaxTokens != 500 {
+		t.Errorf("request = %+v", request)
+	}
+
+	// Flags given for the run beat the template
+	request = &models.PromptRequest{BasePrompt: "the parser", PreTemplate: "audit", Target: models.TargetOSC52, MaxTokens: 100, ConfigPath: configPath}
+	if _, err := orch.GeneratePrompt(context.Background(), request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if request.Target != models.TargetOSC52 || request.MaxTokens != 100 {
+		t.Errorf("request = %+v", request)
+	}
+
+	// A template can't send the prompt somewhere the user didn't choose
+	request = &models.PromptRequest{BasePrompt: "the parser", PreTemplate: "exfil", ConfigPath: configPath}
+	if _, err := orch.GeneratePrompt(context.Background(), request); err == nil || !strings.Contains(err.Error(), "exfil") {
+		t.Errorf("expected the template's webhook target to fail it, got %v", err)
+	}
+	if request.Target != "clipboard" {
+		t.Errorf("expected the configured target, got %q", request.Target)
+	}
+}
+
+func TestGeneratePrompt_MissingTemplate(t *testing.T) {
+	promptsDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(promptsDir, "pre"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(promptsDir, "pre", "review.md"), []byte("Review:"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	config := "prompts_location = \"" + promptsDir + "\"\ntarget = \"stdout\"\n"
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A missing template fails generation, recoverably, naming the template
+	request := &models.PromptRequest{BasePrompt: "the parser", PreTemplate: "reveiw", ConfigPath: configPath}
+	_, err := New().GeneratePrompt(context.Background(), request)
+	var prompterErr *PrompterError
+	if !errors.As(err, &prompterErr) || prompterErr.Type != ErrTemplateNotFound || prompterErr.Subject != "reveiw" {
+		t.Fatalf("error = %v, want a template not found error for reveiw", err)
+	}
+	if !IsRecoverableError(err) || ExitCode(err) != ExitTemplate {
+		t.Errorf("IsRecoverableError, ExitCode = %v, %d", IsRecoverableError(err), ExitCode(err))
+	}
+
+	// Templates with similar names are suggested
+	if !strings.Contains(prompterErr.Guidance, "Did you mean 'review'?") {
+		t.Errorf("guidance = %q", prompterErr.Guidance)
+	}
+}
+
+func TestGeneratePrompt_InvalidFrontmatter(t *testing.T) {
+	promptsDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(promptsDir, "pre"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	template := "---\nvariables:\n  name: ticket\n---\nReview:"
+	if err := os.WriteFile(filepath.Join(promptsDir, "pre", "review.md"), []byte(template), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(configPath, []byte("prompts_location = \""+promptsDir+"\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The message points at the frontmatter line and says what's wrong with it
+	request := &models.PromptRequest{BasePrompt: "the parser", PreTemplate: "review", ConfigPath: configPath}
+	_, err := New().GeneratePrompt(context.Background(), request)
+	var prompterErr *PrompterError
+	if !errors.As(err, &prompterErr) || prompterErr.Type != ErrTemplateInvalid {
+		t.Fatalf("error = %v, want an invalid template error", err)
+	}
+	if !strings.Contains(prompterErr.Message, "review.md:3: invalid frontmatter: cannot unmarshal") {
+		t.Errorf("message = %q", prompterErr.Message)
+	}
+}
+
+func TestGeneratePrompt_FixFromStdin(t *testing.T) {
+	promptsDir := t.TempDir()
+	fixTemplate := "Fix `{{.Fix.Command}}`, which failed with {{len (splitList \"\\n\" .Fix.Output)}} lines of output:"
+	if err := os.WriteFile(filepath.Join(promptsDir, "fix.md"), []byte(fixTemplate), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(configPath, []byte("prompts_location = \""+promptsDir+"\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	withStdin(t, "--- FAIL: TestParse\n    parse_test.go:12: unexpected EOF\n")
+	orch := New()
+	prompt, err := orch.GeneratePrompt(context.Background(), &models.PromptRequest{
+		FixMode:    true,
+		FixFile:    models.FixFileStdin,
+		FixCommand: "go test ./...",
+		ConfigPath: configPath,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "Fix `go test ./...`, which failed with 2 lines of output:\n\n$ go test ./...\n\n--- FAIL: TestParse\n    parse_test.go:12: unexpected EOF"
+	if prompt != want {
+		t.Errorf("prompt = %q, want %q", prompt, want)
+	}
+
+	withStdin(t, "  \n")
+	if _, err := orch.GeneratePrompt(context.Background(), &models.PromptRequest{FixMode: true, FixFile: models.FixFileStdin, ConfigPath: configPath}); err == nil {
+		t.Error("expected an error for empty stdin")
+	}
+}
+
+func TestGeneratePrompt_FixRunsFinalizeStages(t *testing.T) {
+	promptsDir := t.TempDir()
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	config := "prompts_location = \"" + promptsDir + "\"\npipeline = [\"files\", \"render\", \"exec:tr a-z A-Z\"]\n"
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	withStdin(t, "panic: nil map\n")
+	prompt, err := New().GeneratePrompt(context.Background(), &models.PromptRequest{FixMode: true, FixFile: models.FixFileStdin, ConfigPath: configPath})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if prompt != "PLEASE FIX\n\nPANIC: NIL MAP" {
+		t.Errorf("expected the exec stage to run on the fix prompt, got %q", prompt)
+	}
+}
+
+func TestGeneratePrompt_FixIncludesReferencedFiles(t *testing.T) {
+	promptsDir := t.TempDir()
+	fixTemplate := "{{range .Fix.Locations}}{{.Path}} line {{.Line}}: {{.Message}}{{end}}"
+	if err := os.WriteFile(filepath.Join(promptsDir, "fix.md"), []byte(fixTemplate), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	writeConfig := func(extra string) {
+		config := "prompts_location = \"" + promptsDir + "\"\nfix_context_lines = 1\n" + extra
+		if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	project := t.TempDir()
+	if err := os.WriteFile(filepath.Join(project, "go.mod"), []byte("module example.com/app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, "main.go"), []byte("package main\n\nimport (\n\t\"fmt\"\n)\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(project)
+
+	writeConfig("")
+	output := "# example.com/app\n./main.go:4:2: \"fmt\" imported and not used\n"
+	withStdin(t, output)
+	orch := New()
+	prompt, err := orch.GeneratePrompt(context.Background(), &models.PromptRequest{FixMode: true, FixFile: models.FixFileStdin, ConfigPath: configPath})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"main.go line 4: \"fmt\" imported and not used\n", "Referencing files:", "import (\n\t\"fmt\"\n)\n"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected %q in the prompt:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "package main") {
+		t.Errorf("expected only the lines around line 3:\n%s", prompt)
+	}
+	if files := orch.Report().Files; len(files) != 1 || files[0].Path != "main.go" || !files[0].Embedded {
+		t.Errorf("unexpected report files: %+v", files)
+	}
+
+	writeConfig("fix_include_files = false\n")
+	withStdin(t, output)
+	prompt, err = orch.GeneratePrompt(context.Background(), &models.PromptRequest{FixMode: true, FixFile: models.FixFileStdin, ConfigPath: configPath})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(prompt, "Referencing files:") || !strings.Contains(prompt, "main.go line 4") {
+		t.Errorf("expected the locations without the files:\n%s", prompt)
+	}
+}
+
+func TestParseFixContent(t *testing.T) {
+	fix := parseFixContent("$ make build\n\nmain.go:3: undefined: foo")
+	if fix.Command != "make build" || fix.Output != "main.go:3: undefined: foo" || !fix.Enabled {
+		t.Errorf("unexpected fix info: %+v", fix)
+	}
+
+	fix = parseFixContent("panic: nil map")
+	if fix.Command != "" || fix.Output != "panic: nil map" || fix.Raw != "panic: nil map" {
+		t.Errorf("unexpected fix info without a command: %+v", fix)
+	}
+}
@@ -1,10 +1,12 @@
 package orchestrator
 
 import (
+	"encoding/base64"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/atotto/clipboard"
 	"prompter-cli/internal/interfaces"
@@ -23,30 +25,124 @@ func (h *OutputHandler) WriteToClipboard(content string) error {
 	return clipboard.WriteAll(content)
 }
 
+// WriteToTerminalClipboard copies content through the controlling terminal with an
+// OSC 52 escape sequence. It's written to /dev/tty rather than stdout so redirected
+// output stays clean.
+func (h *OutputHandler) WriteToTerminalClipboard(content string) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("no terminal to copy through: %w", err)
+	}
+	defer tty.Close()
+
+	_, err = tty.WriteString(osc52Sequence(content, os.Getenv("TMUX") != ""))
+	return err
+}
+
+// osc52Sequence returns the escape sequence that sets the terminal's clipboard to
+// content. tmux only forwards it to the outer terminal with set-clipboard on, so
+// inside tmux it's also wrapped in a passthrough sequence, which needs
+// allow-passthrough on instead.
+func osc52Sequence(content string, tmux bool) string {
+	sequence := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(content)) + "\a"
+	if !tmux {
+		return sequence
+	}
+	// Escape characters inside the passthrough are doubled
+	return sequence + "\x1bPtmux;" + strings.ReplaceAll(sequence, "\x1b", "\x1b\x1b") + "\x1b\\"
+}
+
 // WriteToStdout writes content to standard output
 func (h *OutputHandler) WriteToStdout(content string) error {
 	_, err := fmt.Println(content)
 	return err
 }
 
+// WriteToTmuxPane loads content into a tmux buffer and pastes it into pane, as a
+// bracketed paste so programs in the pane don't treat its newlines as Enter
+func (h *OutputHandler) WriteToTmuxPane(content string, pane string) error {
+	if pane == "" {
+		return fmt.Errorf("no pane given; use tmux:<pane>, such as tmux:1.2 or tmux:%%3")
+	}
+	if _, err := exec.LookPath("tmux"); err != nil {
+		return fmt.Errorf("tmux is not installed: %w", err)
+	}
+
+	load := exec.Command("tmux", "load-buffer", "-b", tmuxBuffer, "-")
+	load.Stdin = strings.NewReader(content)
+	if output, err := load.CombinedOutput(); err != nil {
+		return fmt.Errorf("tmux load-buffer failed: %s", tmuxError(output, err))
+	}
+
+	paste := exec.Command("tmux", "paste-buffer", "-d", "-p", "-b", tmuxBuffer, "-t", pane)
+	if output, err := paste.CombinedOutput(); err != nil {
+		return fmt.Errorf("tmux paste-buffer failed: %s", tmuxError(output, err))
+	}
+	return nil
+}
+
+// tmuxBuffer names the buffer prompts pass through, leaving the user's buffers alone
+const tmuxBuffer = "prompter"
+
+// tmuxError describes a failed tmux command by what it printed, or err when it
+// printed nothing
+func tmuxError(output []byte, err error) string {
+	if message := strings.TrimSpace(string(output)); message != "" {
+		return message
+	}
+	return err.Error()
+}
+
 // WriteToFile writes content to the specified file path
 func (h *OutputHandler) WriteToFile(content string, path string) error {
 	return ioutil.WriteFile(path, []byte(content), 0644)
 }
 
+// AppendToFile adds content to the end of path, separated from the prompts already
+// there by a horizontal rule
+func (h *OutputHandler) AppendToFile(content string, path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > 0 {
+		content = "\n---\n\n" + content
+	}
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if _, err := file.WriteString(content); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
 // OpenInEditor opens content in the specified editor
 func (h *OutputHandler) OpenInEditor(content string, editor string) error {
+	_, err := h.EditInEditor(content, editor)
+	return err
+}
+
+// EditInEditor opens content in the specified editor and returns it as saved when
+// the editor exits
+func (h *OutputHandler) EditInEditor(content string, editor string) (string, error) {
 	// Create a temporary file
 	tmpFile, err := ioutil.TempFile("", "prompter-*.md")
 	if err != nil {
-		return fmt.Errorf("failed to create temporary file: %w", err)
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
 	}
 	defer os.Remove(tmpFile.Name()) // Clean up
 
 	// Write content to temporary file
 	if _, err := tmpFile.WriteString(content); err != nil {
 		tmpFile.Close()
-		return fmt.Errorf("failed to write to temporary file: %w", err)
+		return "", fmt.Errorf("failed to write to temporary file: %w", err)
 	}
 	tmpFile.Close()
 
@@ -57,8 +153,12 @@ func (h *OutputHandler) OpenInEditor(content string, editor string) error {
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to launch editor %s: %w", editor, err)
+		return "", fmt.Errorf("failed to launch editor %s: %w", editor, err)
 	}
 
-	return nil
+	edited, err := os.ReadFile(tmpFile.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read the edited prompt: %w", err)
+	}
+	return string(edited), nil
 }
\ No newline at end of file
@@ -0,0 +1,241 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/pkg/models"
+)
+
+func TestOSC52Sequence(t *testing.T) {
+	if got, want := osc52Sequence("hi", false), "\x1b]52;c;aGk=\a"; got != want {
+		t.Errorf("osc52Sequence() = %q, want %q", got, want)
+	}
+
+	want := "\x1b]52;c;aGk=\a" + "\x1bPtmux;\x1b\x1b]52;c;aGk=\a\x1b\\"
+	if got := osc52Sequence("hi", true); got != want {
+		t.Errorf("osc52Sequence() in tmux = %q, want %q", got, want)
+	}
+}
+
+// recordingOutput records where the prompt went, failing the system clipboard and,
+// without a terminal, the terminal clipboard
+type recordingOutput struct {
+	OutputHandler
+	terminal bool
+	wrote    []string
+}
+
+func (r *recordingOutput) WriteToClipboard(content string) error {
+	return errors.New("exec: \"xclip\": executable file not found in $PATH")
+}
+
+func (r *recordingOutput) WriteToTerminalClipboard(content string) error {
+	if !r.terminal {
+		return errors.New("no terminal to copy through")
+	}
+	r.wrote = append(r.wrote, models.TargetOSC52)
+	return nil
+}
+
+func (r *recordingOutput) WriteToStdout(content string) error {
+	r.wrote = append(r.wrote, models.TargetStdout)
+	return nil
+}
+
+func TestOutputPrompt_Fallbacks(t *testing.T) {
+	defaults := []string{models.TargetOSC52, models.TargetStdout}
+	tests := []struct {
+		name      string
+		target    string
+		fallbacks []string
+		terminal  bool
+		want      string
+		wantErr   bool
+	}{
+		{"clipboard falls back to the terminal", models.TargetClipboard, defaults, true, models.TargetOSC52, false},
+		{"clipboard falls back to stdout without a terminal", models.TargetClipboard, defaults, false, models.TargetStdout, false},
+		{"osc52", models.TargetOSC52, defaults, true, models.TargetOSC52, false},
+		{"osc52 without a terminal falls back to stdout", models.TargetOSC52, defaults, false, models.TargetStdout, false},
+		{"tmux falls back to stdout", models.TargetTmuxPrefix + "missing", []string{models.TargetStdout}, false, models.TargetStdout, false},
+		{"no fallbacks", models.TargetClipboard, nil, true, "", true},
+		{"model targets don't fall back", models.TargetOllama, defaults, true, "", true},
+		{"webhooks don't fall back", models.TargetHTTPPrefix + "http://127.0.0.1:1/hook", defaults, true, "", true},
+		{"files don't fall back", models.TargetFilePrefix + "/dev/null/prompt.md", defaults, true, "", true},
+		{"every fallback fails", models.TargetClipboard, []string{models.TargetOSC52}, false, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PATH", t.TempDir()) // No tmux to paste with
+			output := &recordingOutput{terminal: tt.terminal}
+			orch := New()
+			orch.SetQuiet(true)
+			orch.SetSilent(true)
+			orch.outputHandler = output
+
+			cfg := &interfaces.Config{TargetFallbacks: tt.fallbacks}
+			err := orch.OutputPrompt(context.Background(), "prompt", &models.PromptRequest{Target: tt.target}, cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OutputPrompt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want != "" && (len(output.wrote) != 1 || output.wrote[0] != tt.want) {
+				t.Errorf("prompt went to %v, want %s", output.wrote, tt.want)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), "'"+tt.target+"'") {
+				t.Errorf("expected the error of the target itself, got %v", err)
+			}
+			if tt.wantErr && ExitCode(err) != ExitOutput {
+				t.Errorf("ExitCode = %d, want %d", ExitCode(err), ExitOutput)
+			}
+			if tt.want != "" && tt.want != tt.target && !strings.Contains(strings.Join(orch.warnings, "\n"), "trying "+tt.want) {
+				t.Errorf("expected a warning naming %s, got %v", tt.want, orch.warnings)
+			}
+		})
+	}
+}
+
+func TestWriteToTmuxPane(t *testing.T) {
+	// A stand-in tmux records its arguments and what it reads
+	bin := t.TempDir()
+	log := filepath.Join(bin, "log")
+	script := "#!/bin/sh\n" +
+		"echo \"$*\" >> " + log + "\n" +
+		"if [ \"$1\" = load-buffer ]; then cat >> " + log + "; echo >> " + log + "; fi\n" +
+		"if [ \"$7\" = missing ]; then echo \"can't find pane: missing\" >&2; exit 1; fi\n"
+	if err := os.WriteFile(filepath.Join(bin, "tmux"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	handler := &OutputHandler{}
+	if err := handler.WriteToTmuxPane("fix this\nplease", "1.2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "load-buffer -b prompter -\nfix this\nplease\npaste-buffer -d -p -b prompter -t 1.2\n"
+	if string(data) != want {
+		t.Errorf("tmux calls = %q, want %q", data, want)
+	}
+
+	if err := handler.WriteToTmuxPane("hi", "missing"); err == nil || !strings.Contains(err.Error(), "can't find pane: missing") {
+		t.Errorf("expected tmux's error, got %v", err)
+	}
+	if err := handler.WriteToTmuxPane("hi", ""); err == nil {
+		t.Error("expected an error without a pane")
+	}
+}
+
+func TestOutputPrompt_Webhook(t *testing.T) {
+	var contentType, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		contentType, body = r.Header.Get("Content-Type"), string(data)
+	}))
+	defer server.Close()
+
+	orch := New()
+	orch.SetQuiet(true)
+	orch.report = &Report{Prompt: "generated", Templates: ReportTemplates{Pre: "review"}, Files: []ReportFile{{Path: "main.go"}}}
+	request := &models.PromptRequest{Target: models.TargetHTTPPrefix + server.URL + "/hook"}
+
+	if err := orch.OutputPrompt(context.Background(), "review <this>", request, &interfaces.Config{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var report Report
+	if err := json.Unmarshal([]byte(body), &report); err != nil {
+		t.Fatalf("expected a JSON report, got %q: %v", body, err)
+	}
+	if contentType != "application/json" || report.Prompt != "review <this>" || report.Templates.Pre != "review" || len(report.Files) != 1 {
+		t.Errorf("unexpected webhook body (%s): %s", contentType, body)
+	}
+
+	cfg := &interfaces.Config{Webhook: interfaces.WebhookConfig{Format: "raw"}}
+	if err := orch.OutputPrompt(context.Background(), "review <this>", request, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(contentType, "text/plain") || body != "review <this>" {
+		t.Errorf("unexpected raw webhook body (%s): %q", contentType, body)
+	}
+}
+
+func TestOutputPrompt_FileTarget(t *testing.T) {
+	dir := t.TempDir()
+	orch := New()
+	orch.SetQuiet(true)
+	cfg := &interfaces.Config{}
+	request := &models.PromptRequest{BasePrompt: "Why does parse panic?"}
+
+	// Placeholders name a new file in a new directory
+	request.Target = models.TargetFilePrefix + dir + `/%Y/{{slug .Prompt}}.md`
+	if err := orch.OutputPrompt(context.Background(), "first", request, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*", "why-does-parse-panic.md"))
+	if len(matches) != 1 {
+		t.Fatalf("expected the prompt under a year directory, found %v", matches)
+	}
+
+	// Appending keeps earlier prompts
+	log := filepath.Join(dir, "log.md")
+	request.Target = models.TargetAppendPrefix + log
+	for _, prompt := range []string{"first", "second\n"} {
+		if err := orch.OutputPrompt(context.Background(), prompt, request, cfg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "first\n\n---\n\nsecond\n"; string(data) != want {
+		t.Errorf("appended file = %q, want %q", data, want)
+	}
+}
+
+func TestOutputPrompt_EditorTarget(t *testing.T) {
+	dir := t.TempDir()
+	editor := filepath.Join(dir, "editor")
+	// The stand-in editor adds a line, or empties the file when the prompt says so
+	script := "#!/bin/sh\nif grep -q discard \"$1\"; then : > \"$1\"; else echo 'Keep it short.' >> \"$1\"; fi\n"
+	if err := os.WriteFile(editor, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "prompt.md")
+	cfg := &interfaces.Config{EditorTarget: models.TargetFilePrefix + out}
+	request := &models.PromptRequest{Target: models.TargetEditor, Editor: editor}
+
+	orch := New()
+	orch.SetQuiet(true)
+	if err := orch.OutputPrompt(context.Background(), "Review this\n", request, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Review this\nKeep it short.\n"; string(data) != want || orch.EditedPrompt() != want {
+		t.Errorf("sent %q (edited %q), want %q", data, orch.EditedPrompt(), want)
+	}
+
+	// An emptied prompt isn't sent
+	os.Remove(out)
+	if err := orch.OutputPrompt(context.Background(), "discard\n", request, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be written for an emptied prompt, got %v", err)
+	}
+}
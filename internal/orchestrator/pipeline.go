@@ -0,0 +1,580 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"prompter-cli/internal/content"
+	"prompter-cli/internal/git"
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/project"
+	"prompter-cli/internal/redact"
+	"prompter-cli/internal/subprocess"
+	"prompter-cli/pkg/models"
+)
+
+// StagePhase determines where a stage may appear relative to the render stage
+type StagePhase int
+
+const (
+	// PhasePrepare stages gather data for templates and run before "render"
+	PhasePrepare StagePhase = iota
+	// PhaseRender is the single stage that assembles the prompt
+	PhaseRender
+	// PhaseFinalize stages transform or check the assembled prompt after "render"
+	PhaseFinalize
+	// PhaseAny stages may run on either side of "render"
+	PhaseAny
+)
+
+// ExecStagePrefix names plugin stages that pipe the prompt through an external command
+const ExecStagePrefix = "exec:"
+
+// DefaultPipeline is used when the config doesn't define a pipeline
+var DefaultPipeline = []string{"git", "project", "tree", "docs", "files", "todos", "render"}
+
+// PipelineState is the data passed between pipeline stages
+type PipelineState struct {
+	Context    context.Context // Done when generation should stop, checked between stages
+	Request    *models.PromptRequest
+	Config     *interfaces.Config
+	Data       *interfaces.TemplateData
+	Content    string           // Formatted file and directory references
+	Docs       string           // Project documentation embedded with include_docs or --with-docs
+	DocsTokens int              // Tokens of Docs, taken from the budget before the files are packed
+	Packing    *content.Packing // Budget decision when file contents are embedded
+	Prompt     string           // Assembled prompt, set by the render stage
+	System     string           // System prompt sent apart from Prompt to model targets, set by the render stage
+	Rendered   bool             // Whether the render stage has run
+
+	Redactor   *redact.Redactor // Replaces secrets in collected content, nil when redaction is off
+	Redactions []redact.Finding // Secrets redacted by stages so far
+}
+
+// contentOptions returns the content options for the request, stopping listings and
+// collection with the pipeline and redacting files as they're read
+func (s *PipelineState) contentOptions() content.Options {
+	options := ContentOptions(s.Config, s.Request)
+	options.Context = s.Context
+	options.Redactor = s.Redactor
+	return options
+}
+
+// Stage is a named step of the generation pipeline
+type Stage struct {
+	Name  string
+	Phase StagePhase
+	Run   func(o *Orchestrator, state *PipelineState) error
+}
+
+// redactPrompt replaces the secrets in the assembled prompt and system prompt,
+// recording what was found
+func (s *PipelineState) redactPrompt() {
+	if s.Redactor == nil {
+		return
+	}
+	var counts map[string]int
+	if s.Prompt, counts = s.Redactor.Redact(s.Prompt); len(counts) > 0 {
+		s.Redactions = append(s.Redactions, redact.Finding{Source: "prompt", Counts: counts})
+	}
+	if s.System, counts = s.Redactor.Redact(s.System); len(counts) > 0 {
+		s.Redactions = append(s.Redactions, redact.Finding{Source: "system prompt", Counts: counts})
+	}
+}
+
+// stageRegistry holds every stage that can be named in the pipeline config
+var stageRegistry = map[string]Stage{}
+
+// RegisterStage makes a stage available to pipeline configurations
+func RegisterStage(stage Stage) {
+	stageRegistry[stage.Name] = stage
+}
+
+func init() {
+	RegisterStage(Stage{Name: "git", Phase: PhasePrepare, Run: gitStage})
+	RegisterStage(Stage{Name: "project", Phase: PhasePrepare, Run: projectStage})
+	RegisterStage(Stage{Name: "tree", Phase: PhasePrepare, Run: treeStage})
+	RegisterStage(Stage{Name: "docs", Phase: PhasePrepare, Run: docsStage})
+	RegisterStage(Stage{Name: "files", Phase: PhasePrepare, Run: filesStage})
+	RegisterStage(Stage{Name: "todos", Phase: PhasePrepare, Run: todosStage})
+	RegisterStage(Stage{Name: "render", Phase: PhaseRender, Run: renderStage})
+	RegisterStage(Stage{Name: "redact", Phase: PhaseAny, Run: redactStage})
+	RegisterStage(Stage{Name: "token-check", Phase: PhaseFinalize, Run: tokenCheckStage})
+}
+
+// BuildPipeline resolves stage names into stages, checking that they exist and are ordered correctly
+func BuildPipeline(names []string) ([]Stage, error) {
+	if len(names) == 0 {
+		names = DefaultPipeline
+	}
+
+	var stages []Stage
+	rendered := false
+	for _, name := range names {
+		stage, err := lookupStage(name)
+		if err != nil {
+			return nil, err
+		}
+
+		switch stage.Phase {
+		case PhaseRender:
+			if rendered {
+				return nil, fmt.Errorf("pipeline stage %q may only appear once", name)
+			}
+			rendered = true
+		case PhasePrepare:
+			if rendered {
+				return nil, fmt.Errorf("pipeline stage %q must come before \"render\"", name)
+			}
+		case PhaseFinalize:
+			if !rendered {
+				return nil, fmt.Errorf("pipeline stage %q must come after \"render\"", name)
+			}
+		case PhaseAny:
+		}
+
+		stages = append(stages, stage)
+	}
+
+	if !rendered {
+		return nil, fmt.Errorf("pipeline must include the \"render\" stage")
+	}
+
+	return stages, nil
+}
+
+// lookupStage finds a registered stage or builds an exec plugin stage
+func lookupStage(name string) (Stage, error) {
+	if strings.HasPrefix(name, ExecStagePrefix) {
+		command := strings.TrimSpace(strings.TrimPrefix(name, ExecStagePrefix))
+		if command == "" {
+			return Stage{}, fmt.Errorf("pipeline stage %q is missing a command", name)
+		}
+		return Stage{Name: name, Phase: PhaseFinalize, Run: execStage(command)}, nil
+	}
+
+	stage, ok := stageRegistry[name]
+	if !ok {
+		var known []string
+		for registered := range stageRegistry {
+			known = append(known, registered)
+		}
+		sort.Strings(known)
+		return Stage{}, fmt.Errorf("unknown pipeline stage %q (available: %s, or %s<command>)", name, strings.Join(known, ", "), ExecStagePrefix)
+	}
+	return stage, nil
+}
+
+// runPipeline executes stages in order against a fresh state, stopping before the
+// next stage once ctx is done
+func (o *Orchestrator) runPipeline(ctx context.Context, stages []Stage, request *models.PromptRequest, cfg *interfaces.Config) (*PipelineState, error) {
+	data, err := o.buildTemplateData(request, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build template data: %w", err)
+	}
+
+	redactor, err := newRedactor(request, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &PipelineState{
+		Context:  ctx,
+		Request:  request,
+		Config:   cfg,
+		Data:     data,
+		Redactor: redactor,
+	}
+	return state, o.runStages(state, stages)
+}
+
+// finalizeStages returns the stages that come after "render"
+func finalizeStages(stages []Stage) []Stage {
+	for i, stage := range stages {
+		if stage.Phase == PhaseRender {
+			return stages[i+1:]
+		}
+	}
+	return nil
+}
+
+// runStages runs stages in order against state, stopping before the next stage once
+// the state's context is done
+func (o *Orchestrator) runStages(state *PipelineState, stages []Stage) error {
+	ctx := state.Context
+	for _, stage := range stages {
+		if ctx.Err() != nil {
+			return fmt.Errorf("stopped before the %s stage: %w", stage.Name, context.Cause(ctx))
+		}
+		started := time.Now()
+		o.emit(models.Event{Type: models.EventStageStarted, Stage: stage.Name, Time: started})
+		if err := stage.Run(o, state); err != nil {
+			return err
+		}
+		o.emit(models.Event{Type: models.EventStageFinished, Stage: stage.Name, Duration: time.Since(started)})
+	}
+
+	return nil
+}
+
+// gitStage adds repository information and any requested diff to the template data
+func gitStage(o *Orchestrator, state *PipelineState) error {
+	state.Data.Git = o.buildGitInfo(state.Config.GitRecentCommits)
+	if state.Request.DiffMode == "" {
+		return nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	diff, err := git.Diff(cwd, state.Request.DiffMode, state.Request.DiffBase, state.Config.DiffContextLines)
+	if err != nil {
+		return err
+	}
+	state.Data.Diff = diff
+
+	if len(diff.Files) == 0 {
+		o.warn("no changes found by %s", diff.Command)
+	} else if state.Request.Verbose {
+		fmt.Fprintf(os.Stderr, "Diff: %d files changed (%s)\n", len(diff.Files), diff.Command)
+	}
+	return nil
+}
+
+// projectStage adds the languages and manifests of the project to the template data
+func projectStage(o *Orchestrator, state *PipelineState) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	state.Data.Project = project.Detect(cwd, state.Config.ProjectIndirectDeps)
+	if state.Request.Verbose && len(state.Data.Project.Languages) > 0 {
+		fmt.Fprintf(os.Stderr, "Project: %s (%s)\n", strings.Join(state.Data.Project.Languages, ", "), state.Data.Project.Root)
+	}
+	return nil
+}
+
+// treeStage draws the files under the working directory as a tree for the template
+// data, leaving out those ignored or excluded as directory listings do
+func treeStage(o *Orchestrator, state *PipelineState) error {
+	if state.Config.TreeDepth == 0 {
+		return nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	tree, err := content.Tree(cwd, state.Config.TreeDepth, state.contentOptions())
+	if err != nil {
+		o.warn("failed to list the project tree: %v", err)
+		return nil
+	}
+	state.Data.Tree = tree
+	return nil
+}
+
+// docsStage embeds the project's README, CONTRIBUTING.md, and docs overviews when
+// include_docs or --with-docs asks for them, cut to docs_token_share of the token
+// budget. Documents also requested with --file are left to the files stage.
+func docsStage(o *Orchestrator, state *PipelineState) error {
+	request := state.Request
+	cfg := state.Config
+	if !cfg.IncludeDocs && !request.WithDocs {
+		return nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	requested := make(map[string]bool)
+	for _, spec := range request.Files {
+		if path, _, err := content.SplitFileSpec(spec); err == nil {
+			if abs, err := filepath.Abs(path); err == nil {
+				requested[abs] = true
+			}
+		}
+	}
+	var specs []string
+	for _, path := range content.DocFiles(cwd) {
+		if requested[path] {
+			continue
+		}
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			path = rel
+		}
+		specs = append(specs, path)
+	}
+	if len(specs) == 0 {
+		if request.Verbose {
+			fmt.Fprintln(os.Stderr, "Docs: no README, CONTRIBUTING.md, or docs overviews found")
+		}
+		return nil
+	}
+
+	options := state.contentOptions()
+	options.Outline = false
+	options.DependencyDepth = 0
+	options.Tokenizer = o.tokenizer
+	files, skipped, err := content.NewCollector(options).Collect(specs, "")
+	if err != nil {
+		return fmt.Errorf("failed to collect project documentation: %w", err)
+	}
+	if request.Verbose {
+		for _, skip := range skipped {
+			fmt.Fprintf(os.Stderr, "Skipped %s: %s\n", skip.Path, skip.Reason)
+		}
+	}
+	if state.Redactor != nil {
+		state.Redactions = append(state.Redactions, redactFiles(state.Redactor, files, o.tokenizer)...)
+	}
+
+	share := cfg.DocsTokenShare
+	if share <= 0 {
+		share = content.DefaultDocsTokenShare
+	}
+	budget := 0
+	if total := contentBudget(cfg, request); total > 0 {
+		budget = max(int(float64(total)*share), 1)
+	}
+	packing := content.FitInOrder(files, budget, o.tokenizer)
+	if request.Verbose {
+		fmt.Fprintf(os.Stderr, "Docs: %s\n", packing.Report())
+	}
+	if len(packing.Selected) == 0 {
+		return nil
+	}
+	addStats(&state.Data.Stats, packing.Selected)
+
+	formatted, err := content.Format(packing.Selected, cfg.FileFormat)
+	if err != nil {
+		return NewConfigurationError("failed to format project documentation", err)
+	}
+	if summary := packing.Summary(); summary != "" {
+		formatted = strings.TrimSpace(formatted + "\n\n" + summary)
+	}
+	state.Docs = "Project documentation:\n\n" + formatted
+	state.DocsTokens = packing.Tokens
+	return nil
+}
+
+// filesStage formats the requested files and directory for the prompt, embedding
+// their contents when embed_content is enabled or the request asks for parts of them
+func filesStage(o *Orchestrator, state *PipelineState) error {
+	request := state.Request
+	if len(request.Files) == 0 && len(request.Symbols) == 0 && len(request.Sources) == 0 && request.Directory == "" {
+		return nil
+	}
+	if state.Config.EmbedContent || selectsContent(request) {
+		return o.embedContent(state)
+	}
+	state.Content = o.formatContent(request)
+	return nil
+}
+
+// todosStage adds the TODO, FIXME, and HACK comments of the requested files and
+// directory to the template data when the request asks for them
+func todosStage(o *Orchestrator, state *PipelineState) error {
+	request := state.Request
+	if !request.Todos {
+		return nil
+	}
+	if len(request.Files) == 0 && request.Directory == "" {
+		o.warn("--todos has no files to scan; include some with --file or --directory")
+		return nil
+	}
+
+	todos, err := content.FindTodos(request.Files, request.Directory, state.contentOptions())
+	if err != nil {
+		return err
+	}
+	for _, todo := range todos {
+		state.Data.Todos = append(state.Data.Todos, interfaces.TodoInfo{Path: todo.Path, Line: todo.Line, Tag: todo.Tag, Text: todo.Text})
+	}
+	if request.Verbose {
+		fmt.Fprintf(os.Stderr, "Todos: %d found\n", len(todos))
+	}
+	return nil
+}
+
+// selectsContent reports whether a request asks for outlines, line ranges, symbols,
+// plugin sources, or dependencies, which only mean something when contents are embedded
+func selectsContent(request *models.PromptRequest) bool {
+	if request.Outline || len(request.Symbols) > 0 || len(request.Sources) > 0 || request.WithDeps > 0 {
+		return true
+	}
+	for _, spec := range request.Files {
+		if _, lines, err := content.SplitFileSpec(spec); lines != nil || err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// renderStage assembles the pre-templates, base prompt, content, and post-templates.
+// For model targets and --json, the templates' system sections and a system
+// pre-template are split off as the system prompt; otherwise they lead the prompt.
+func renderStage(o *Orchestrator, state *PipelineState) error {
+	request := state.Request
+	split := models.IsModelTarget(request.Target) || request.JSON
+	var promptParts, systemParts []string
+
+	// Process the pre-templates, joined with the template separator
+	var preParts []string
+	for _, name := range models.TemplateNames(request.PreTemplate) {
+		preContent, preSystem, err := o.processTemplate(name, state.Data, state.Config)
+		if err != nil {
+			// A missing template is left to the caller, which may offer another
+			return RecoverFromError(NewTemplateError(name, err))
+		}
+		if preSystem != "" {
+			systemParts = append(systemParts, preSystem)
+		}
+		if preContent != "" && split && o.isSystemTemplate(name) {
+			systemParts = append(systemParts, preContent)
+		} else if preContent != "" {
+			preParts = append(preParts, preContent)
+		}
+	}
+	if len(preParts) > 0 {
+		promptParts = append(promptParts, strings.Join(preParts, state.Config.TemplateSeparator))
+	}
+
+	// Add base prompt
+	if request.BasePrompt != "" {
+		promptParts = append(promptParts, request.BasePrompt)
+	}
+
+	// Include the project documentation ahead of the files
+	if state.Docs != "" {
+		promptParts = append(promptParts, state.Docs)
+	}
+
+	// Include file content
+	if state.Content != "" {
+		promptParts = append(promptParts, state.Content)
+	}
+
+	// Include the requested changes
+	if state.Data.Diff.Raw != "" {
+		promptParts = append(promptParts, formatDiff(state.Data.Diff))
+	}
+
+	// Process the post-templates, joined with the template separator
+	var postParts []string
+	for _, name := range models.TemplateNames(request.PostTemplate) {
+		postContent, postSystem, err := o.processTemplate(name, state.Data, state.Config)
+		if err != nil {
+			// A missing template is left to the caller, which may offer another
+			return RecoverFromError(NewTemplateError(name, err))
+		}
+		if postSystem != "" {
+			systemParts = append(systemParts, postSystem)
+		}
+		if postContent != "" {
+			postParts = append(postParts, postContent)
+		}
+	}
+	if len(postParts) > 0 {
+		promptParts = append(promptParts, strings.Join(postParts, state.Config.TemplateSeparator))
+	}
+
+	if split {
+		state.System = strings.Join(systemParts, "\n\n")
+	} else if len(systemParts) > 0 {
+		promptParts = append(systemParts, promptParts...)
+	}
+	state.Prompt = strings.Join(promptParts, "\n\n")
+	state.Rendered = true
+	return nil
+}
+
+// redactStage replaces secrets as soon as it runs instead of only once the prompt is
+// finished: before "render" in the collected diff, and after it in the assembled
+// prompt. Files are redacted as they're read wherever the stage is placed.
+func redactStage(o *Orchestrator, state *PipelineState) error {
+	if state.Redactor == nil {
+		return nil
+	}
+	if state.Rendered {
+		state.redactPrompt()
+		return nil
+	}
+	diff := &state.Data.Diff
+	raw, counts := state.Redactor.Redact(diff.Raw)
+	if len(counts) == 0 {
+		return nil
+	}
+	diff.Raw = raw
+	for i := range diff.Files {
+		file := &diff.Files[i]
+		file.Patch, _ = state.Redactor.Redact(file.Patch)
+		for j := range file.Hunks {
+			file.Hunks[j].Content, _ = state.Redactor.Redact(file.Hunks[j].Content)
+		}
+	}
+	state.Redactions = append(state.Redactions, redact.Finding{Source: "diff", Counts: counts})
+	return nil
+}
+
+// tokenCheckStage fails generation when the prompt and system prompt together are
+// over the max_tokens budget, rather than leaving the model to reject or cut it
+func tokenCheckStage(o *Orchestrator, state *PipelineState) error {
+	budget := contentBudget(state.Config, state.Request)
+	if budget <= 0 {
+		return nil
+	}
+	tokens := o.tokenizer.Count(state.Prompt)
+	if state.System != "" {
+		tokens += o.tokenizer.Count(state.System)
+	}
+	if tokens > budget {
+		return NewValidationError("prompt", fmt.Sprintf("%d tokens", tokens), fmt.Sprintf("over the max_tokens budget of %d", budget))
+	}
+	return nil
+}
+
+// formatDiff renders collected changes for the prompt, with a fence longer than any
+// in the changed files
+func formatDiff(diff interfaces.DiffInfo) string {
+	fence := "```"
+	for strings.Contains(diff.Raw, fence) {
+		fence += "`"
+	}
+	return fmt.Sprintf("Changes (%s):\n%sdiff\n%s\n%s", diff.Command, fence, strings.TrimRight(diff.Raw, "\n"), fence)
+}
+
+// execStage returns a stage that pipes the prompt through a shell command and
+// replaces it with the command's stdout. The prompt is redacted first, so secrets
+// never reach the command.
+func execStage(command string) func(o *Orchestrator, state *PipelineState) error {
+	return func(o *Orchestrator, state *PipelineState) error {
+		state.redactPrompt()
+		cmd := subprocess.Shell(state.Context, command)
+		cmd.Stdin = strings.NewReader(state.Prompt)
+
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		if err := cmd.Run(); err != nil {
+			if state.Context.Err() != nil {
+				return fmt.Errorf("pipeline stage %s%s stopped: %w", ExecStagePrefix, command, context.Cause(state.Context))
+			}
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return fmt.Errorf("pipeline stage %s%s failed: %w: %s", ExecStagePrefix, command, err, msg)
+			}
+			return fmt.Errorf("pipeline stage %s%s failed: %w", ExecStagePrefix, command, err)
+		}
+
+		state.Prompt = strings.TrimRight(stdout.String(), "\n")
+		return nil
+	}
+}
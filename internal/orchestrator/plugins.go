@@ -0,0 +1,76 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"prompter-cli/internal/content"
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/plugin"
+)
+
+// Plugins returns the plugins discovered when the configuration was loaded
+func (o *Orchestrator) Plugins() *plugin.Registry {
+	return o.plugins
+}
+
+// discoverPlugins asks the executables in plugins_location what they provide, once
+// per location, warning about plugins that can't be used
+func (o *Orchestrator) discoverPlugins(cfg *interfaces.Config) {
+	if o.plugins != nil && o.pluginsLocation == cfg.PluginsLocation {
+		return
+	}
+
+	timeout := time.Duration(cfg.PluginTimeoutMS) * time.Millisecond
+	registry, errs := plugin.Discover(cfg.PluginsLocation, cfg.DisabledPlugins, timeout)
+	for _, err := range errs {
+		o.warn("%v", err)
+	}
+	o.plugins = registry
+	o.pluginsLocation = cfg.PluginsLocation
+}
+
+// collectSource runs the plugin source named in spec, written name or name:arg, and
+// returns what it collected as files to embed
+func (o *Orchestrator) collectSource(ctx context.Context, spec string) ([]content.File, error) {
+	name, arg, _ := strings.Cut(spec, ":")
+	provider, ok := o.plugins.Source(name)
+	if !ok {
+		return nil, fmt.Errorf("no plugin provides the source %q", name)
+	}
+
+	collected, err := provider.Collect(ctx, name, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]content.File, 0, len(collected))
+	for _, file := range collected {
+		language := file.Language
+		if language == "" {
+			language = content.LanguageFor(file.Path)
+		}
+		files = append(files, content.File{
+			Path:       file.Path,
+			Language:   language,
+			Content:    file.Content,
+			Size:       int64(len(file.Content)),
+			Tokens:     o.tokenizer.Count(file.Content),
+			Explicit:   true,
+			TotalLines: strings.Count(file.Content, "\n") + 1,
+		})
+	}
+	return files, nil
+}
+
+// sendToPlugin delivers the prompt to the plugin target name, returning the message
+// the plugin wants shown
+func (o *Orchestrator) sendToPlugin(ctx context.Context, prompt, name string) (string, error) {
+	provider, ok := o.plugins.Target(name)
+	if !ok {
+		return "", fmt.Errorf("no plugin provides the target %q", name)
+	}
+	return provider.Send(ctx, name, prompt)
+}
@@ -0,0 +1,63 @@
+package orchestrator
+
+import (
+	"context"
+
+	"prompter-cli/pkg/models"
+)
+
+// Preview is a prompt generated to be shown before it is output
+type Preview struct {
+	Prompt string
+	System string // System prompt sent apart from Prompt to model targets
+	Tokens int    // Tokens in Prompt and System together
+	Bytes  int    // Size of Prompt and System together
+	Files  int    // Files and directories added to the context
+
+	Templates []string // Pre and post templates used, after config defaults
+	Target    string   // Output target the prompt would be sent to
+	Warnings  []string // Warnings that generation would print
+}
+
+// PreviewPrompt generates the prompt for a copy of request without printing anything,
+// collecting warnings instead, so it can be called repeatedly while inputs are edited
+func (o *Orchestrator) PreviewPrompt(ctx context.Context, request *models.PromptRequest) (*Preview, error) {
+	previewRequest := *request
+	previewRequest.Verbose = false
+
+	preview := &Preview{}
+	handler, silent := o.eventHandler, o.silent
+	o.silent = true
+	o.eventHandler = func(event models.Event) {
+		switch event.Type {
+		case models.EventWarning:
+			preview.Warnings = append(preview.Warnings, event.Message)
+		case models.EventFileCollected:
+			preview.Files++
+		}
+	}
+	defer func() {
+		o.silent = silent
+		o.eventHandler = handler
+	}()
+
+	prompt, err := o.GeneratePrompt(ctx, &previewRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	preview.Prompt = prompt
+	preview.System = o.system
+	preview.Tokens = o.tokenizer.Count(prompt)
+	if o.system != "" {
+		preview.Tokens += o.tokenizer.Count(o.system)
+	}
+	preview.Bytes = len(prompt) + len(o.system)
+
+	preview.Templates = append(models.TemplateNames(previewRequest.PreTemplate), models.TemplateNames(previewRequest.PostTemplate)...)
+	preview.Target = previewRequest.Target
+	if preview.Target == "" {
+		preview.Target = models.TargetStdout
+	}
+	return preview, nil
+}
@@ -0,0 +1,55 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompter-cli/pkg/models"
+)
+
+func TestPreviewPrompt(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(configPath, []byte("prompts_location = \""+t.TempDir()+"\"\nmax_token = 100\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	orch := New()
+	var handled int
+	orch.SetEventHandler(func(event models.Event) { handled++ })
+
+	file := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(file, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	request := &models.PromptRequest{
+		BasePrompt:  "explain this",
+		Files:       []string{file},
+		Interactive: true,
+		Verbose:     true,
+		ConfigPath:  configPath,
+	}
+	preview, err := orch.PreviewPrompt(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(preview.Prompt, "explain this") || preview.Tokens == 0 || preview.Bytes != len(preview.Prompt) {
+		t.Errorf("unexpected preview: %+v", preview)
+	}
+	if preview.Files != 1 || preview.Target != models.TargetClipboard || len(preview.Templates) != 0 {
+		t.Errorf("unexpected summary: %d files, target %q, templates %v", preview.Files, preview.Target, preview.Templates)
+	}
+	if len(preview.Warnings) != 1 {
+		t.Errorf("expected the unknown setting to be reported as a warning, got %v", preview.Warnings)
+	}
+	if !request.Verbose || handled != 0 {
+		t.Error("expected the request and the event handler to be left alone")
+	}
+	if orch.silent {
+		t.Error("expected warnings to be printed again after the preview")
+	}
+}
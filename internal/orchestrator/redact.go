@@ -0,0 +1,68 @@
+package orchestrator
+
+import (
+	"prompter-cli/internal/content"
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/redact"
+	"prompter-cli/internal/tokenizer"
+	"prompter-cli/pkg/models"
+)
+
+// newRedactor returns the redactor for a request, or nil when redaction is turned off
+// with redact = false or --no-redact
+func newRedactor(request *models.PromptRequest, cfg *interfaces.Config) (*redact.Redactor, error) {
+	if !cfg.Redact || request.NoRedact {
+		return nil, nil
+	}
+	redactor, err := redact.New(cfg.RedactPatterns)
+	if err != nil {
+		return nil, NewConfigurationError("invalid redact_patterns", err)
+	}
+	return redactor, nil
+}
+
+// redactFiles replaces secrets in collected files before they are packed, so token
+// counts reflect what is sent, and returns what was found in each file. Files the
+// collector already redacted, as it does with the pipeline's redactor, keep their
+// counts; the rest, such as those from plugin sources, are redacted here.
+func redactFiles(redactor *redact.Redactor, files []content.File, tok tokenizer.Tokenizer) []redact.Finding {
+	var findings []redact.Finding
+	for i := range files {
+		counts := files[i].Redactions
+		if !files[i].Redacted {
+			var redacted string
+			if redacted, counts = redactor.Redact(files[i].Content); len(counts) > 0 {
+				files[i].Content = redacted
+				files[i].Tokens = tok.Count(redacted)
+			}
+		}
+		if len(counts) > 0 {
+			findings = append(findings, redact.Finding{Source: files[i].Path, Counts: counts})
+		}
+	}
+	return findings
+}
+
+// finishRedaction replaces the secrets left in the assembled prompt and system prompt,
+// such as those in templates, the clipboard, or a diff, and warns about everything
+// redacted during generation
+func (o *Orchestrator) finishRedaction(redactor *redact.Redactor, findings []redact.Finding, prompt string) string {
+	if redactor == nil {
+		return prompt
+	}
+
+	prompt, counts := redactor.Redact(prompt)
+	if len(counts) > 0 {
+		findings = append(findings, redact.Finding{Source: "prompt", Counts: counts})
+	}
+	if o.system != "" {
+		if o.system, counts = redactor.Redact(o.system); len(counts) > 0 {
+			findings = append(findings, redact.Finding{Source: "system prompt", Counts: counts})
+		}
+	}
+
+	if len(findings) > 0 {
+		o.warn("%s", redact.Report(findings))
+	}
+	return prompt
+}
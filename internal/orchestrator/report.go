@@ -0,0 +1,128 @@
+package orchestrator
+
+import (
+	"prompter-cli/internal/interfaces"
+)
+
+// Report describes a generated prompt and how it was assembled, for --json
+type Report struct {
+	Prompt     string                  `json:"prompt"`
+	System     string                  `json:"system,omitempty"` // Split off for model targets and --json
+	Messages   []ReportMessage         `json:"messages"`         // System and user messages, as sent to chat models
+	Templates  ReportTemplates         `json:"templates"`
+	Model      string                  `json:"model,omitempty"` // Preset selected with --model or the model setting
+	Files      []ReportFile            `json:"files"`
+	Tokens     ReportTokens            `json:"tokens"`
+	Truncation *ReportTruncation       `json:"truncation,omitempty"` // Set when files were dropped or cut to fit the budget
+	Git        *interfaces.GitInfo     `json:"git,omitempty"`        // Set inside a git repository
+	Project    *interfaces.ProjectInfo `json:"project,omitempty"`    // Set when manifests were found
+	Warnings   []string                `json:"warnings"`
+}
+
+// ReportMessage is a message of the conversation a prompt makes up for chat models
+type ReportMessage struct {
+	Role    string `json:"role"` // "system" or "user"
+	Content string `json:"content"`
+}
+
+// ReportTemplates names the templates a prompt was built from
+type ReportTemplates struct {
+	Pre  string `json:"pre,omitempty"`
+	Post string `json:"post,omitempty"`
+}
+
+// ReportFile is a file or directory added to the prompt
+type ReportFile struct {
+	Path      string `json:"path"`
+	Embedded  bool   `json:"embedded"` // Contents are in the prompt, not just the path
+	Bytes     int64  `json:"bytes,omitempty"`
+	Tokens    int    `json:"tokens,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Note      string `json:"note,omitempty"` // Describes the truncation
+}
+
+// ReportTokens counts the tokens of a prompt with the configured tokenizer
+type ReportTokens struct {
+	Prompt    int    `json:"prompt"`
+	System    int    `json:"system,omitempty"`
+	Files     int    `json:"files,omitempty"`  // Embedded file contents
+	Budget    int    `json:"budget,omitempty"` // Budget for embedded contents, 0 for unlimited
+	Tokenizer string `json:"tokenizer"`
+}
+
+// ReportTruncation lists the files left out or shortened to fit the token budget
+type ReportTruncation struct {
+	Method    string   `json:"method"`
+	Dropped   []string `json:"dropped,omitempty"`
+	Shortened []string `json:"shortened,omitempty"`
+}
+
+// Report returns the report of the last generated prompt, nil before one is generated
+func (o *Orchestrator) Report() *Report {
+	return o.report
+}
+
+// newReport describes the prompt assembled in state
+func (o *Orchestrator) newReport(state *PipelineState) *Report {
+	request := state.Request
+	report := &Report{
+		Prompt:    state.Prompt,
+		System:    state.System,
+		Templates: ReportTemplates{Pre: request.PreTemplate, Post: request.PostTemplate},
+		Model:     state.Config.Model,
+		Files:     []ReportFile{},
+		Tokens: ReportTokens{
+			Prompt:    o.tokenizer.Count(state.Prompt),
+			Tokenizer: o.tokenizer.Name(),
+		},
+		Warnings: append([]string{}, o.warnings...),
+	}
+	if state.System != "" {
+		report.Tokens.System = o.tokenizer.Count(state.System)
+		report.Messages = append(report.Messages, ReportMessage{Role: "system", Content: state.System})
+	}
+	report.Messages = append(report.Messages, ReportMessage{Role: "user", Content: state.Prompt})
+	if state.Data != nil && state.Data.Git.Root != "" {
+		git := state.Data.Git
+		report.Git = &git
+	}
+	if state.Data != nil && len(state.Data.Project.Manifests) > 0 {
+		project := state.Data.Project
+		report.Project = &project
+	}
+
+	if state.Packing == nil {
+		for _, file := range request.Files {
+			report.Files = append(report.Files, ReportFile{Path: file})
+		}
+		if request.Directory != "" {
+			report.Files = append(report.Files, ReportFile{Path: referencedDir(request.Directory)})
+		}
+		return report
+	}
+
+	packing := state.Packing
+	for _, file := range packing.Selected {
+		report.Files = append(report.Files, ReportFile{
+			Path:      file.Path,
+			Embedded:  true,
+			Bytes:     file.Size,
+			Tokens:    file.Tokens,
+			Truncated: file.Truncated,
+			Note:      file.Note,
+		})
+	}
+	report.Tokens.Files = packing.Tokens
+	report.Tokens.Budget = packing.Budget
+
+	if len(packing.Dropped) > 0 || len(packing.Shortened) > 0 {
+		report.Truncation = &ReportTruncation{Method: packing.Method}
+		for _, file := range packing.Dropped {
+			report.Truncation.Dropped = append(report.Truncation.Dropped, file.Path)
+		}
+		for _, file := range packing.Shortened {
+			report.Truncation.Shortened = append(report.Truncation.Shortened, file.Path)
+		}
+	}
+	return report
+}
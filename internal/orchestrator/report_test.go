@@ -0,0 +1,70 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"prompter-cli/pkg/models"
+)
+
+func TestReport(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.go")
+	large := filepath.Join(dir, "large.go")
+	if err := os.WriteFile(small, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(large, []byte("package main\n\n"+strings.Repeat("// filler line for the budget\n", 400)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	writeConfig := func(extra string) {
+		config := "prompts_location = \"" + t.TempDir() + "\"\n" + extra
+		if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	orch := New()
+	if orch.Report() != nil {
+		t.Error("expected no report before a prompt is generated")
+	}
+
+	// Referenced files are listed by path
+	writeConfig("")
+	prompt, err := orch.GeneratePrompt(context.Background(), &models.PromptRequest{BasePrompt: "explain", Files: []string{small}, ConfigPath: configPath})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	report := orch.Report()
+	if report.Prompt != prompt || report.Tokens.Prompt == 0 || report.Tokens.Tokenizer == "" {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if !reflect.DeepEqual(report.Files, []ReportFile{{Path: small}}) || report.Truncation != nil {
+		t.Errorf("unexpected files: %+v, truncation %+v", report.Files, report.Truncation)
+	}
+
+	// Embedded files report their size and what didn't fit the budget
+	writeConfig("embed_content = true\nmax_tokens = 100\n")
+	if _, err := orch.GeneratePrompt(context.Background(), &models.PromptRequest{BasePrompt: "explain", Files: []string{small, large}, ConfigPath: configPath}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	report = orch.Report()
+	if len(report.Files) == 0 || !report.Files[0].Embedded || report.Files[0].Bytes != 13 {
+		t.Errorf("unexpected files: %+v", report.Files)
+	}
+	if report.Tokens.Budget != 100 || report.Tokens.Files == 0 {
+		t.Errorf("unexpected tokens: %+v", report.Tokens)
+	}
+	if report.Truncation == nil || len(report.Truncation.Dropped)+len(report.Truncation.Shortened) != 1 {
+		t.Errorf("expected large.go to be dropped or shortened, got %+v", report.Truncation)
+	}
+	if len(report.Warnings) != 1 {
+		t.Errorf("expected the budget warning to be reported, got %v", report.Warnings)
+	}
+}
@@ -0,0 +1,131 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/llm"
+	"prompter-cli/pkg/models"
+)
+
+// modelClient streams a response for a conversation from a model API
+type modelClient interface {
+	Stream(ctx context.Context, messages []llm.Message, w io.Writer) error
+}
+
+// newModelClient builds the API client for a model target from the config
+func newModelClient(target string, cfg *interfaces.Config) (modelClient, string, error) {
+	switch target {
+	case models.TargetOpenAI:
+		apiKey := os.Getenv(cfg.OpenAI.APIKeyEnv)
+		if apiKey == "" {
+			return nil, "", fmt.Errorf("environment variable %s is not set; export your OpenAI API key or set openai.api_key_env", cfg.OpenAI.APIKeyEnv)
+		}
+		return &llm.OpenAI{
+			APIKey:      apiKey,
+			Model:       cfg.OpenAI.Model,
+			BaseURL:     cfg.OpenAI.BaseURL,
+			Temperature: cfg.OpenAI.Temperature,
+		}, cfg.OpenAI.Model, nil
+	case models.TargetAnthropic:
+		apiKey := os.Getenv(cfg.Anthropic.APIKeyEnv)
+		if apiKey == "" {
+			return nil, "", fmt.Errorf("environment variable %s is not set; export your Anthropic API key or set anthropic.api_key_env", cfg.Anthropic.APIKeyEnv)
+		}
+		return &llm.Anthropic{
+			APIKey:    apiKey,
+			Model:     cfg.Anthropic.Model,
+			BaseURL:   cfg.Anthropic.BaseURL,
+			MaxTokens: cfg.Anthropic.MaxTokens,
+		}, cfg.Anthropic.Model, nil
+	case models.TargetOllama:
+		return &llm.Ollama{
+			Model:   cfg.Ollama.Model,
+			BaseURL: cfg.Ollama.BaseURL,
+		}, cfg.Ollama.Model, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported model target %s", target)
+	}
+}
+
+// sendToModel submits the prompt to a model API and streams the response to stdout,
+// sending the system prompt split off by the last render separately. The request
+// stops when ctx is done.
+func (o *Orchestrator) sendToModel(ctx context.Context, prompt, target string, cfg *interfaces.Config) error {
+	client, model, err := newModelClient(target, cfg)
+	if err != nil {
+		return err
+	}
+
+	if !o.quiet {
+		fmt.Fprintf(os.Stderr, "Sending prompt to %s (%s)...\n\n", target, model)
+	}
+
+	var messages []llm.Message
+	if o.system != "" {
+		messages = append(messages, llm.Message{Role: "system", Content: o.system})
+	}
+	messages = append(messages, llm.Message{Role: "user", Content: prompt})
+
+	out := &trailingNewlineWriter{w: os.Stdout}
+	var response strings.Builder
+	responseFile := responseFile(target, cfg)
+	var w io.Writer = out
+	if responseFile != "" {
+		w = io.MultiWriter(out, &response)
+	}
+
+	if err := client.Stream(ctx, messages, w); err != nil {
+		out.finish()
+		return err
+	}
+	if err := out.finish(); err != nil {
+		return err
+	}
+
+	if responseFile != "" {
+		if err := o.outputHandler.WriteToFile(response.String(), responseFile); err != nil {
+			return fmt.Errorf("failed to write the response to %s: %w", responseFile, err)
+		}
+		if !o.quiet {
+			fmt.Fprintf(os.Stderr, "\nResponse written to %s\n", responseFile)
+		}
+	}
+	return nil
+}
+
+// responseFile returns where a model target's config asks for the response to be saved
+func responseFile(target string, cfg *interfaces.Config) string {
+	if target == models.TargetOllama {
+		return cfg.Ollama.OutputFile
+	}
+	return ""
+}
+
+// trailingNewlineWriter remembers whether the streamed output ended with a newline so
+// the terminal prompt doesn't end up on the response's last line
+type trailingNewlineWriter struct {
+	w    io.Writer
+	last byte
+}
+
+func (t *trailingNewlineWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		t.last = p[len(p)-1]
+	}
+	return t.w.Write(p)
+}
+
+// finish writes a final newline when the output didn't end with one
+func (t *trailingNewlineWriter) finish() error {
+	if t.last == 0 || t.last == '\n' {
+		return nil
+	}
+	t.last = '\n'
+	_, err := io.WriteString(t.w, "\n")
+	return err
+}
@@ -0,0 +1,46 @@
+package orchestrator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"prompter-cli/internal/content"
+	"prompter-cli/internal/interfaces"
+)
+
+// addStats records the sizes of embedded files in stats, keeping the largest first
+func addStats(stats *interfaces.StatsInfo, files []content.File) {
+	for _, file := range files {
+		stats.Files = append(stats.Files, interfaces.FileStats{
+			Path:      file.Path,
+			Bytes:     file.Size,
+			Tokens:    file.Tokens,
+			Truncated: file.Truncated || file.Outline,
+			Note:      file.Note,
+		})
+		stats.Bytes += file.Size
+		stats.Tokens += file.Tokens
+	}
+	sort.SliceStable(stats.Files, func(i, j int) bool { return stats.Files[i].Tokens > stats.Files[j].Tokens })
+}
+
+// formatStats lays out the sizes of the embedded files as a table for --verbose, with
+// each file's share of the embedded tokens
+func formatStats(stats interfaces.StatsInfo) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Embedded files: %d tokens, %d bytes\n", stats.Tokens, stats.Bytes)
+	fmt.Fprintf(&b, "  %8s %6s %10s  %s\n", "TOKENS", "SHARE", "BYTES", "PATH")
+	for _, file := range stats.Files {
+		share := 0.0
+		if stats.Tokens > 0 {
+			share = float64(file.Tokens) * 100 / float64(stats.Tokens)
+		}
+		path := file.Path
+		if file.Truncated {
+			path += " (truncated)"
+		}
+		fmt.Fprintf(&b, "  %8d %5.1f%% %10d  %s\n", file.Tokens, share, file.Bytes, path)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,40 @@
+package orchestrator
+
+import (
+	"reflect"
+	"testing"
+
+	"prompter-cli/internal/content"
+	"prompter-cli/internal/interfaces"
+)
+
+func TestAddStats(t *testing.T) {
+	var stats interfaces.StatsInfo
+	addStats(&stats, []content.File{
+		{Path: "small.go", Size: 40, Tokens: 10},
+		{Path: "large.go", Size: 9000, Tokens: 300, Truncated: true, Note: "showing lines 1-80 of 400"},
+	})
+	addStats(&stats, []content.File{{Path: "README.md", Size: 400, Tokens: 90}})
+
+	want := interfaces.StatsInfo{
+		Files: []interfaces.FileStats{
+			{Path: "large.go", Bytes: 9000, Tokens: 300, Truncated: true, Note: "showing lines 1-80 of 400"},
+			{Path: "README.md", Bytes: 400, Tokens: 90},
+			{Path: "small.go", Bytes: 40, Tokens: 10},
+		},
+		Bytes:  9440,
+		Tokens: 400,
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Fatalf("stats = %+v, want %+v", stats, want)
+	}
+
+	wantTable := "Embedded files: 400 tokens, 9440 bytes\n" +
+		"    TOKENS  SHARE      BYTES  PATH\n" +
+		"       300  75.0%       9000  large.go (truncated)\n" +
+		"        90  22.5%        400  README.md\n" +
+		"        10   2.5%         40  small.go"
+	if table := formatStats(stats); table != wantTable {
+		t.Errorf("formatStats() =\n%s\nwant\n%s", table, wantTable)
+	}
+}
@@ -0,0 +1,41 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/webhook"
+)
+
+// postToWebhook posts the prompt to the URL of an http: target, as the report of the
+// last generated prompt or as plain text depending on webhook.format. The request
+// stops when ctx is done.
+func (o *Orchestrator) postToWebhook(ctx context.Context, prompt, url string, cfg *interfaces.Config) error {
+	body, contentType := []byte(prompt), "text/plain; charset=utf-8"
+	if cfg.Webhook.Format != webhook.FormatRaw {
+		report := Report{Files: []ReportFile{}, Warnings: []string{}}
+		if o.report != nil {
+			report = *o.report
+		}
+		// The prompt being output, should it differ from the generated one
+		report.Prompt = prompt
+		report.Messages = []ReportMessage{{Role: "user", Content: prompt}}
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode the prompt: %w", err)
+		}
+		body, contentType = buf.Bytes(), "application/json"
+	}
+
+	client := &webhook.Client{
+		Headers: cfg.Webhook.Headers,
+		Timeout: time.Duration(cfg.Webhook.TimeoutMS) * time.Millisecond,
+	}
+	return client.Post(ctx, url, body, contentType)
+}
@@ -0,0 +1,178 @@
+// Package plugin runs executables from the plugins directory that add template
+// functions, content sources, and output targets to prompter.
+//
+// Plugins speak JSON over stdin and stdout. Each call starts the executable, writes
+// one Request to its stdin, and reads one Response from its stdout. When prompter
+// starts, every plugin is asked to describe itself with {"type": "describe"} and
+// answers with a Manifest:
+//
+//	{"description": "Jira issues",
+//	 "functions": [{"name": "jira", "description": "Summary of an issue"}],
+//	 "sources": [{"name": "jira"}],
+//	 "targets": [{"name": "jira-comment"}]}
+//
+// Functions are then called with {"type": "function", "name": "jira", "args": [...]}
+// and answer {"output": "..."}. Sources are called with {"type": "source", "name":
+// "jira", "arg": "PROJ-1"} and answer {"files": [{"path": "PROJ-1.md", "content":
+// "..."}]}. Targets are called with {"type": "target", "name": "jira-comment",
+// "prompt": "..."} and may answer {"output": "Commented on PROJ-1"}.
+// A response with "error" set fails the call.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"prompter-cli/internal/subprocess"
+)
+
+// DefaultTimeout bounds a plugin call when plugin_timeout_ms isn't set
+const DefaultTimeout = 10 * time.Second
+
+// Request types sent to plugins
+const (
+	RequestDescribe = "describe" // Answered with a Manifest
+	RequestFunction = "function" // Runs a template function
+	RequestSource   = "source"   // Collects content for the prompt
+	RequestTarget   = "target"   // Delivers the generated prompt
+)
+
+// Request is written to a plugin's stdin
+type Request struct {
+	Type   string        `json:"type"`
+	Name   string        `json:"name,omitempty"`   // Function, source, or target called
+	Args   []interface{} `json:"args,omitempty"`   // Template arguments, for functions
+	Arg    string        `json:"arg,omitempty"`    // What to collect, for sources
+	Prompt string        `json:"prompt,omitempty"` // Generated prompt, for targets
+}
+
+// Response is read from a plugin's stdout
+type Response struct {
+	Output string `json:"output,omitempty"` // Function result, or a message shown after a target
+	Files  []File `json:"files,omitempty"`  // Content collected by a source
+	Error  string `json:"error,omitempty"`  // Fails the call when set
+}
+
+// File is content collected by a source
+type File struct {
+	Path     string `json:"path"` // Name shown in the prompt
+	Content  string `json:"content"`
+	Language string `json:"language,omitempty"` // Code fence language, detected from Path when empty
+}
+
+// Capability is a function, source, or target a plugin provides
+type Capability struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Manifest describes what a plugin provides
+type Manifest struct {
+	Description string       `json:"description,omitempty"`
+	Functions   []Capability `json:"functions,omitempty"`
+	Sources     []Capability `json:"sources,omitempty"`
+	Targets     []Capability `json:"targets,omitempty"`
+}
+
+// Plugin is an executable in the plugins directory
+type Plugin struct {
+	Name string `json:"name"` // File name
+	Path string `json:"path"`
+	Manifest
+
+	timeout time.Duration
+}
+
+// Call runs the plugin with request on stdin and decodes its response. The plugin
+// is killed when ctx is done or its timeout passes.
+func (p *Plugin) Call(ctx context.Context, request Request) (*Response, error) {
+	var response Response
+	if err := p.call(ctx, request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// call runs the plugin with request on stdin and decodes its stdout into response,
+// failing when the plugin reports an error
+func (p *Plugin) call(parent context.Context, request Request, response interface{}) error {
+	input, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode request for plugin %s: %w", p.Name, err)
+	}
+
+	timeout := p.timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	cmd := subprocess.Command(ctx, p.Path)
+	cmd.Stdin = bytes.NewReader(input)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if parent.Err() != nil {
+			return context.Cause(parent)
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("plugin %s timed out after %s", p.Name, timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("plugin %s failed: %w: %s", p.Name, err, msg)
+		}
+		return fmt.Errorf("plugin %s failed: %w", p.Name, err)
+	}
+
+	var failure struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &failure); err != nil {
+		return fmt.Errorf("plugin %s returned invalid JSON: %w", p.Name, err)
+	}
+	if failure.Error != "" {
+		return fmt.Errorf("plugin %s: %s", p.Name, failure.Error)
+	}
+	if err := json.Unmarshal(stdout.Bytes(), response); err != nil {
+		return fmt.Errorf("plugin %s returned an invalid response: %w", p.Name, err)
+	}
+	return nil
+}
+
+// Function runs the template function name with args and returns its output
+func (p *Plugin) Function(ctx context.Context, name string, args []interface{}) (string, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	response, err := p.Call(ctx, Request{Type: RequestFunction, Name: name, Args: args})
+	if err != nil {
+		return "", err
+	}
+	return response.Output, nil
+}
+
+// Collect runs the source name for arg and returns the files it collected
+func (p *Plugin) Collect(ctx context.Context, name, arg string) ([]File, error) {
+	response, err := p.Call(ctx, Request{Type: RequestSource, Name: name, Arg: arg})
+	if err != nil {
+		return nil, err
+	}
+	return response.Files, nil
+}
+
+// Send delivers a prompt to the target name and returns the plugin's message
+func (p *Plugin) Send(ctx context.Context, name, prompt string) (string, error) {
+	response, err := p.Call(ctx, Request{Type: RequestTarget, Name: name, Prompt: prompt})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(response.Output), nil
+}
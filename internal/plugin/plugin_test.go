@@ -0,0 +1,124 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// jiraPlugin answers each request type from a shell script, echoing what it was sent
+const jiraPlugin = `#!/bin/sh
+input=$(cat)
+case "$input" in
+*'"type":"describe"'*)
+	echo '{"description": "Jira issues", "functions": [{"name": "jira", "description": "Summary of an issue"}], "sources": [{"name": "jira"}], "targets": [{"name": "jira-comment"}]}' ;;
+*'"type":"function"'*)
+	printf '{"output": "%s"}' "$(echo "$input" | sed 's/"/\\"/g')" ;;
+*'"type":"source"'*)
+	printf '%s' '{"files": [{"path": "PROJ-1.md", "content": "# PROJ-1\nFix the parser"}]}' ;;
+*'"type":"target"'*)
+	echo '{"error": "no credentials"}' ;;
+esac
+`
+
+// writePlugin writes an executable script into dir
+func writePlugin(t *testing.T, dir, name, script string, mode os.FileMode) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	writePlugin(t, dir, "jira", jiraPlugin, 0755)
+	writePlugin(t, dir, "kanban", "#!/bin/sh\necho '{\"functions\": [{\"name\": \"jira\"}, {\"name\": \"board\"}]}'\n", 0755)
+	writePlugin(t, dir, "broken", "#!/bin/sh\necho not json\n", 0755)
+	writePlugin(t, dir, "notes.txt", "not a plugin", 0644)
+	writePlugin(t, dir, "off", jiraPlugin, 0755)
+
+	registry, errs := Discover(dir, []string{"off"}, time.Second)
+
+	var names []string
+	for _, p := range registry.Plugins {
+		names = append(names, p.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"jira", "kanban"}) {
+		t.Errorf("plugins = %v", names)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("expected the broken plugin and the duplicate function to be reported, got %v", errs)
+	}
+	if !strings.Contains(errs[0].Error(), "plugin broken returned invalid JSON") {
+		t.Errorf("unexpected error: %v", errs[0])
+	}
+	if !strings.Contains(errs[1].Error(), `function "jira" is already provided by plugin jira`) {
+		t.Errorf("unexpected error: %v", errs[1])
+	}
+
+	if !reflect.DeepEqual(registry.FunctionNames(), []string{"board", "jira"}) {
+		t.Errorf("functions = %v", registry.FunctionNames())
+	}
+	if provider, ok := registry.Function("jira"); !ok || provider.Name != "jira" {
+		t.Errorf("expected jira to come from the first plugin, got %+v", provider)
+	}
+	if _, ok := registry.Target("jira-comment"); !ok {
+		t.Error("expected the jira-comment target")
+	}
+
+	// A second discovery reads the cached manifests
+	cached, errs := Discover(dir, []string{"off"}, time.Second)
+	if len(cached.Plugins) != 2 || len(errs) != 2 || cached.Plugins[0].Description != "Jira issues" {
+		t.Errorf("unexpected cached discovery: %+v, %v", cached.Plugins, errs)
+	}
+
+	if missing, errs := Discover(filepath.Join(dir, "missing"), nil, time.Second); len(missing.Plugins) != 0 || errs != nil {
+		t.Errorf("expected no plugins without a plugins directory, got %+v, %v", missing.Plugins, errs)
+	}
+}
+
+func TestPluginCalls(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	writePlugin(t, dir, "jira", jiraPlugin, 0755)
+	registry, errs := Discover(dir, nil, time.Second)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	jira, _ := registry.Function("jira")
+
+	output, err := jira.Function(context.Background(), "jira", []interface{}{"PROJ-1", 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output != `{"type":"function","name":"jira","args":["PROJ-1",2]}` {
+		t.Errorf("unexpected request sent to the function: %s", output)
+	}
+
+	files, err := jira.Collect(context.Background(), "jira", "PROJ-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "PROJ-1.md" || files[0].Content != "# PROJ-1\nFix the parser" {
+		t.Errorf("unexpected files: %+v", files)
+	}
+
+	if _, err := jira.Send(context.Background(), "jira-comment", "the prompt"); err == nil || err.Error() != "plugin jira: no credentials" {
+		t.Errorf("expected the plugin's error, got %v", err)
+	}
+}
+
+func TestPluginTimeout(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "slow", "#!/bin/sh\nsleep 5\n", 0755)
+	slow := &Plugin{Name: "slow", Path: filepath.Join(dir, "slow"), timeout: 100 * time.Millisecond}
+
+	if _, err := slow.Call(context.Background(), Request{Type: RequestDescribe}); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
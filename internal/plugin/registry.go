@@ -0,0 +1,175 @@
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Registry holds the plugins discovered in a directory and what each provides
+type Registry struct {
+	Plugins []*Plugin
+
+	functions map[string]*Plugin
+	sources   map[string]*Plugin
+	targets   map[string]*Plugin
+}
+
+// Discover asks every executable in dir, except those named in disabled, to describe
+// itself. A missing dir has no plugins. Plugins that fail to describe themselves, and
+// capabilities already provided by an earlier plugin in name order, are returned as
+// errors and left out.
+func Discover(dir string, disabled []string, timeout time.Duration) (*Registry, []error) {
+	registry := &Registry{
+		functions: make(map[string]*Plugin),
+		sources:   make(map[string]*Plugin),
+		targets:   make(map[string]*Plugin),
+	}
+	if dir == "" {
+		return registry, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return registry, nil
+		}
+		return registry, []error{fmt.Errorf("failed to read plugins directory: %w", err)}
+	}
+
+	skip := make(map[string]bool)
+	for _, name := range disabled {
+		skip[name] = true
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		if skip[entry.Name()] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			continue // Data files and scripts without the executable bit aren't plugins
+		}
+
+		plugin := &Plugin{Name: entry.Name(), Path: filepath.Join(dir, entry.Name()), timeout: timeout}
+		manifest, err := describe(plugin, info)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		plugin.Manifest = *manifest
+		errs = append(errs, registry.add(plugin)...)
+	}
+	return registry, errs
+}
+
+// add registers a plugin's capabilities, reporting names another plugin already provides
+func (r *Registry) add(plugin *Plugin) []error {
+	var errs []error
+	register := func(kind string, registered map[string]*Plugin, capabilities []Capability) []Capability {
+		var kept []Capability
+		for _, capability := range capabilities {
+			if other, ok := registered[capability.Name]; ok {
+				errs = append(errs, fmt.Errorf("plugin %s: %s %q is already provided by plugin %s", plugin.Name, kind, capability.Name, other.Name))
+				continue
+			}
+			registered[capability.Name] = plugin
+			kept = append(kept, capability)
+		}
+		return kept
+	}
+	plugin.Functions = register("function", r.functions, plugin.Functions)
+	plugin.Sources = register("source", r.sources, plugin.Sources)
+	plugin.Targets = register("target", r.targets, plugin.Targets)
+
+	r.Plugins = append(r.Plugins, plugin)
+	return errs
+}
+
+// Function returns the plugin providing the template function name
+func (r *Registry) Function(name string) (*Plugin, bool) {
+	if r == nil {
+		return nil, false
+	}
+	plugin, ok := r.functions[name]
+	return plugin, ok
+}
+
+// Source returns the plugin providing the content source name
+func (r *Registry) Source(name string) (*Plugin, bool) {
+	if r == nil {
+		return nil, false
+	}
+	plugin, ok := r.sources[name]
+	return plugin, ok
+}
+
+// Target returns the plugin providing the output target name
+func (r *Registry) Target(name string) (*Plugin, bool) {
+	if r == nil {
+		return nil, false
+	}
+	plugin, ok := r.targets[name]
+	return plugin, ok
+}
+
+// FunctionNames returns the names of every plugin template function, sorted
+func (r *Registry) FunctionNames() []string {
+	if r == nil {
+		return nil
+	}
+	names := make([]string, 0, len(r.functions))
+	for name := range r.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// describe returns a plugin's manifest, from the cache while the executable is unchanged
+func describe(plugin *Plugin, info os.FileInfo) (*Manifest, error) {
+	cachePath := manifestCachePath(plugin.Path, info)
+	if cachePath != "" {
+		if data, err := os.ReadFile(cachePath); err == nil {
+			var manifest Manifest
+			if json.Unmarshal(data, &manifest) == nil {
+				return &manifest, nil
+			}
+		}
+	}
+
+	manifest := &Manifest{}
+	if err := plugin.call(context.Background(), Request{Type: RequestDescribe}, manifest); err != nil {
+		return nil, err
+	}
+
+	if cachePath != "" {
+		if data, err := json.Marshal(manifest); err == nil && os.MkdirAll(filepath.Dir(cachePath), 0755) == nil {
+			_ = os.WriteFile(cachePath, data, 0600)
+		}
+	}
+	return manifest, nil
+}
+
+// manifestCachePath names the cache file for an executable's manifest, keyed by its
+// path, size, and modification time, or returns "" without a cache directory
+func manifestCachePath(path string, info os.FileInfo) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	hash := sha256.New()
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+	hash.Write([]byte(strconv.FormatInt(info.Size(), 10)))
+	hash.Write([]byte{0})
+	hash.Write([]byte(strconv.FormatInt(info.ModTime().UnixNano(), 10)))
+	return filepath.Join(dir, "prompter", "plugins", hex.EncodeToString(hash.Sum(nil))+".json")
+}
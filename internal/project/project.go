@@ -0,0 +1,428 @@
+// Package project detects the languages and frameworks of a project from its manifests
+package project
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"prompter-cli/internal/interfaces"
+)
+
+// detector reads one kind of manifest. Parse fills in the manifest's name and version
+// and returns its dependencies, and may be nil for manifests that are only a sign of
+// the language.
+type detector struct {
+	file     string
+	language string
+	parse    func(content []byte, manifest *interfaces.ProjectManifest) ([]interfaces.ProjectDependency, error)
+}
+
+// detectors are the manifests recognized, in the order they're reported
+var detectors = []detector{
+	{file: "go.mod", language: "go", parse: parseGoMod},
+	{file: "package.json", language: "javascript", parse: parsePackageJSON},
+	{file: "pyproject.toml", language: "python", parse: parsePyproject},
+	{file: "requirements.txt", language: "python", parse: parseRequirements},
+	{file: "setup.py", language: "python"},
+	{file: "Cargo.toml", language: "rust", parse: parseCargo},
+	{file: "Gemfile", language: "ruby", parse: parseGemfile},
+	{file: "pom.xml", language: "java", parse: parsePom},
+	{file: "build.gradle", language: "java"},
+	{file: "build.gradle.kts", language: "kotlin"},
+	{file: "composer.json", language: "php", parse: parseComposer},
+	{file: "mix.exs", language: "elixir"},
+	{file: "Package.swift", language: "swift"},
+}
+
+// frameworks maps dependency names to the framework they indicate
+var frameworks = map[string]string{
+	"github.com/gin-gonic/gin":    "gin",
+	"github.com/labstack/echo/v4": "echo",
+	"github.com/gofiber/fiber/v2": "fiber",
+	"github.com/go-chi/chi/v5":    "chi",
+	"github.com/spf13/cobra":      "cobra",
+	"react":                       "react",
+	"next":                        "next",
+	"vue":                         "vue",
+	"nuxt":                        "nuxt",
+	"svelte":                      "svelte",
+	"@angular/core":               "angular",
+	"express":                     "express",
+	"@nestjs/core":                "nestjs",
+	"django":                      "django",
+	"flask":                       "flask",
+	"fastapi":                     "fastapi",
+	"actix-web":                   "actix-web",
+	"axum":                        "axum",
+	"rocket":                      "rocket",
+	"rails":                       "rails",
+	"sinatra":                     "sinatra",
+	"spring-boot-starter":         "spring-boot",
+	"spring-boot-starter-web":     "spring-boot",
+	"laravel/framework":           "laravel",
+	"symfony/framework-bundle":    "symfony",
+}
+
+// Detect finds the project root by walking up from dir to the first directory holding a
+// recognized manifest, stopping at the root of a git repository, and describes the
+// project from its manifests. Without any, it returns an empty ProjectInfo. Manifests
+// that fail to parse still count for their language. Indirect dependencies are only
+// listed when indirect is set.
+func Detect(dir string, indirect bool) interfaces.ProjectInfo {
+	for current := filepath.Clean(dir); ; current = filepath.Dir(current) {
+		if info := detectIn(current, indirect); len(info.Manifests) > 0 {
+			return info
+		}
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil || filepath.Dir(current) == current {
+			return interfaces.ProjectInfo{}
+		}
+	}
+}
+
+// detectIn describes the project whose manifests are in root
+func detectIn(root string, indirect bool) interfaces.ProjectInfo {
+	info := interfaces.ProjectInfo{Root: root}
+	for _, d := range detectors {
+		content, err := os.ReadFile(filepath.Join(root, d.file))
+		if err != nil {
+			continue
+		}
+
+		manifest := interfaces.ProjectManifest{Path: d.file, Language: d.language}
+		var dependencies []interfaces.ProjectDependency
+		if d.parse != nil {
+			var err error
+			if dependencies, err = d.parse(content, &manifest); err != nil {
+				manifest.Summary = fmt.Sprintf("%s: %v", d.file, err)
+			}
+		}
+		sort.SliceStable(dependencies, func(i, j int) bool { return dependencies[i].Name < dependencies[j].Name })
+		for _, dependency := range dependencies {
+			if !dependency.Indirect {
+				manifest.Dependencies = append(manifest.Dependencies, dependency.Name)
+			}
+			if !dependency.Indirect || indirect {
+				dependency.Manifest = d.file
+				info.Dependencies = append(info.Dependencies, dependency)
+			}
+		}
+		if manifest.Language == "javascript" && isTypeScript(root, manifest.Dependencies) {
+			manifest.Language = "typescript"
+		}
+		if manifest.Summary == "" {
+			manifest.Summary = summarize(manifest)
+		}
+
+		info.Manifests = append(info.Manifests, manifest)
+		if !slices.Contains(info.Languages, manifest.Language) {
+			info.Languages = append(info.Languages, manifest.Language)
+		}
+		if info.Module == "" {
+			info.Module = manifest.Name
+		}
+		for _, dependency := range manifest.Dependencies {
+			if framework, ok := frameworks[dependency]; ok && !slices.Contains(info.Frameworks, framework) {
+				info.Frameworks = append(info.Frameworks, framework)
+			}
+		}
+	}
+	return info
+}
+
+// isTypeScript reports whether a JavaScript project is written in TypeScript
+func isTypeScript(root string, dependencies []string) bool {
+	if _, err := os.Stat(filepath.Join(root, "tsconfig.json")); err == nil {
+		return true
+	}
+	return slices.Contains(dependencies, "typescript")
+}
+
+// summarize describes a manifest in one line, e.g.
+// "go.mod: module example.com/app, go 1.22, 4 dependencies"
+func summarize(manifest interfaces.ProjectManifest) string {
+	var parts []string
+	if manifest.Name != "" {
+		kind := "package"
+		if manifest.Language == "go" {
+			kind = "module"
+		}
+		parts = append(parts, kind+" "+manifest.Name)
+	}
+	if manifest.Version != "" {
+		if manifest.Language == "go" {
+			parts = append(parts, "go "+manifest.Version)
+		} else {
+			parts = append(parts, "version "+manifest.Version)
+		}
+	}
+	switch count := len(manifest.Dependencies); count {
+	case 0:
+	case 1:
+		parts = append(parts, "1 dependency")
+	default:
+		parts = append(parts, strconv.Itoa(count)+" dependencies")
+	}
+	if len(parts) == 0 {
+		return manifest.Path + ": " + manifest.Language
+	}
+	return manifest.Path + ": " + strings.Join(parts, ", ")
+}
+
+// parseGoMod reads the module path, Go version, and requirements of a go.mod, marking
+// those commented // indirect
+func parseGoMod(content []byte, manifest *interfaces.ProjectManifest) ([]interfaces.ProjectDependency, error) {
+	var dependencies []interfaces.ProjectDependency
+	require := func(fields []string, comment string) {
+		dependencies = append(dependencies, interfaces.ProjectDependency{
+			Name:     unquote(fields[0]),
+			Version:  fields[1],
+			Indirect: strings.TrimSpace(comment) == "indirect",
+		})
+	}
+
+	inRequire := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line, comment, _ := strings.Cut(scanner.Text(), "//")
+		line = strings.TrimSpace(line)
+		fields := strings.Fields(line)
+
+		switch {
+		case inRequire && line == ")":
+			inRequire = false
+		case inRequire && len(fields) >= 2:
+			require(fields, comment)
+		case len(fields) == 2 && fields[0] == "module":
+			manifest.Name = unquote(fields[1])
+		case len(fields) == 2 && fields[0] == "go":
+			manifest.Version = fields[1]
+		case len(fields) == 2 && fields[0] == "require" && fields[1] == "(":
+			inRequire = true
+		case len(fields) >= 3 && fields[0] == "require":
+			require(fields[1:], comment)
+		}
+	}
+	return dependencies, scanner.Err()
+}
+
+// unquote removes the quotes go.mod allows around paths
+func unquote(path string) string {
+	if unquoted, err := strconv.Unquote(path); err == nil {
+		return unquoted
+	}
+	return path
+}
+
+// parsePackageJSON reads the name, version, and dependencies of a package.json,
+// development dependencies included
+func parsePackageJSON(content []byte, manifest *interfaces.ProjectManifest) ([]interfaces.ProjectDependency, error) {
+	var pkg struct {
+		Name            string            `json:"name"`
+		Version         string            `json:"version"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		return nil, err
+	}
+	manifest.Name, manifest.Version = pkg.Name, pkg.Version
+	return appendVersions(appendVersions(nil, pkg.Dependencies), pkg.DevDependencies), nil
+}
+
+// parsePyproject reads a pyproject.toml's [project] table, or Poetry's
+// [tool.poetry] table when it has none
+func parsePyproject(content []byte, manifest *interfaces.ProjectManifest) ([]interfaces.ProjectDependency, error) {
+	var pyproject struct {
+		Project struct {
+			Name         string   `toml:"name"`
+			Version      string   `toml:"version"`
+			Dependencies []string `toml:"dependencies"`
+		} `toml:"project"`
+		Tool struct {
+			Poetry struct {
+				Name         string                 `toml:"name"`
+				Version      string                 `toml:"version"`
+				Dependencies map[string]interface{} `toml:"dependencies"`
+			} `toml:"poetry"`
+		} `toml:"tool"`
+	}
+	if err := toml.Unmarshal(content, &pyproject); err != nil {
+		return nil, err
+	}
+
+	var dependencies []interfaces.ProjectDependency
+	if project := pyproject.Project; project.Name != "" {
+		manifest.Name, manifest.Version = project.Name, project.Version
+		for _, requirement := range project.Dependencies {
+			dependencies = append(dependencies, parseRequirement(requirement))
+		}
+		return dependencies, nil
+	}
+	poetry := pyproject.Tool.Poetry
+	manifest.Name, manifest.Version = poetry.Name, poetry.Version
+	for name, spec := range poetry.Dependencies {
+		if name != "python" {
+			dependencies = append(dependencies, interfaces.ProjectDependency{Name: strings.ToLower(name), Version: specVersion(spec)})
+		}
+	}
+	return dependencies, nil
+}
+
+// parseRequirements reads the packages of a requirements.txt
+func parseRequirements(content []byte, manifest *interfaces.ProjectManifest) ([]interfaces.ProjectDependency, error) {
+	var dependencies []interfaces.ProjectDependency
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue // Options such as -r other.txt or -e .
+		}
+		dependencies = append(dependencies, parseRequirement(line))
+	}
+	return dependencies, scanner.Err()
+}
+
+// parseRequirement returns the package name and version specifier of a Python
+// requirement such as "requests[socks]>=2.31; python_version > '3.8'"
+func parseRequirement(requirement string) interfaces.ProjectDependency {
+	requirement, _, _ = strings.Cut(requirement, ";")
+	name, version := requirement, ""
+	if end := strings.IndexAny(requirement, " <>=!~[(@"); end >= 0 {
+		name, version = requirement[:end], requirement[end:]
+		if strings.HasPrefix(version, "[") {
+			if _, rest, ok := strings.Cut(version, "]"); ok {
+				version = rest
+			}
+		}
+	}
+	version = strings.Trim(strings.TrimSpace(version), "()")
+	return interfaces.ProjectDependency{Name: strings.ToLower(strings.TrimSpace(name)), Version: strings.TrimSpace(version)}
+}
+
+// parseCargo reads the package and dependencies of a Cargo.toml
+func parseCargo(content []byte, manifest *interfaces.ProjectManifest) ([]interfaces.ProjectDependency, error) {
+	var cargo struct {
+		Package struct {
+			Name    string      `toml:"name"`
+			Version interface{} `toml:"version"` // A string, or { workspace = true }
+		} `toml:"package"`
+		Dependencies map[string]interface{} `toml:"dependencies"`
+	}
+	if err := toml.Unmarshal(content, &cargo); err != nil {
+		return nil, err
+	}
+	manifest.Name = cargo.Package.Name
+	if version, ok := cargo.Package.Version.(string); ok {
+		manifest.Version = version
+	}
+
+	var dependencies []interfaces.ProjectDependency
+	for name, spec := range cargo.Dependencies {
+		dependencies = append(dependencies, interfaces.ProjectDependency{Name: name, Version: specVersion(spec)})
+	}
+	return dependencies, nil
+}
+
+// specVersion returns the version of a TOML dependency given either as a version
+// string or as a table such as { version = "1", features = [...] }
+func specVersion(spec interface{}) string {
+	switch spec := spec.(type) {
+	case string:
+		return spec
+	case map[string]interface{}:
+		if version, ok := spec["version"].(string); ok {
+			return version
+		}
+	}
+	return ""
+}
+
+// parseGemfile reads the gems of a Gemfile and the version requirement given with each
+func parseGemfile(content []byte, manifest *interfaces.ProjectManifest) ([]interfaces.ProjectDependency, error) {
+	var dependencies []interfaces.ProjectDependency
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "gem" {
+			continue
+		}
+
+		args := strings.Split(strings.Join(fields[1:], " "), ",")
+		dependency := interfaces.ProjectDependency{Name: strings.Trim(strings.TrimSpace(args[0]), `"'`)}
+		if len(args) > 1 {
+			// A quoted second argument is a version; options such as require: false aren't
+			if version := strings.TrimSpace(args[1]); strings.HasPrefix(version, `"`) || strings.HasPrefix(version, "'") {
+				dependency.Version = strings.Trim(version, `"'`)
+			}
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	return dependencies, scanner.Err()
+}
+
+// parsePom reads the artifact, version, and dependencies of a Maven pom.xml
+func parsePom(content []byte, manifest *interfaces.ProjectManifest) ([]interfaces.ProjectDependency, error) {
+	var pom struct {
+		ArtifactID   string `xml:"artifactId"`
+		Version      string `xml:"version"`
+		Dependencies []struct {
+			ArtifactID string `xml:"artifactId"`
+			Version    string `xml:"version"`
+		} `xml:"dependencies>dependency"`
+	}
+	if err := xml.Unmarshal(content, &pom); err != nil {
+		return nil, err
+	}
+	manifest.Name, manifest.Version = pom.ArtifactID, pom.Version
+
+	var dependencies []interfaces.ProjectDependency
+	for _, dependency := range pom.Dependencies {
+		dependencies = append(dependencies, interfaces.ProjectDependency{Name: dependency.ArtifactID, Version: dependency.Version})
+	}
+	return dependencies, nil
+}
+
+// parseComposer reads the name, version, and requirements of a composer.json,
+// leaving out PHP itself and its extensions
+func parseComposer(content []byte, manifest *interfaces.ProjectManifest) ([]interfaces.ProjectDependency, error) {
+	var composer struct {
+		Name    string            `json:"name"`
+		Version string            `json:"version"`
+		Require map[string]string `json:"require"`
+	}
+	if err := json.Unmarshal(content, &composer); err != nil {
+		return nil, err
+	}
+	manifest.Name, manifest.Version = composer.Name, composer.Version
+
+	var dependencies []interfaces.ProjectDependency
+	for name, version := range composer.Require {
+		if name != "php" && !strings.HasPrefix(name, "ext-") {
+			dependencies = append(dependencies, interfaces.ProjectDependency{Name: name, Version: version})
+		}
+	}
+	return dependencies, nil
+}
+
+// appendVersions appends the packages of versions, a map of names to version
+// requirements, leaving out those already in dependencies
+func appendVersions(dependencies []interfaces.ProjectDependency, versions map[string]string) []interfaces.ProjectDependency {
+	for name, version := range versions {
+		if !slices.ContainsFunc(dependencies, func(d interfaces.ProjectDependency) bool { return d.Name == name }) {
+			dependencies = append(dependencies, interfaces.ProjectDependency{Name: name, Version: version})
+		}
+	}
+	return dependencies
+}
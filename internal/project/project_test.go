@@ -0,0 +1,166 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"prompter-cli/internal/interfaces"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name           string
+		files          map[string]string
+		wantLanguages  []string
+		wantFrameworks []string
+		wantModule     string
+		wantSummaries  []string
+	}{
+		{
+			name: "go module",
+			files: map[string]string{
+				"go.mod": "module example.com/app\n\ngo 1.22\n\nrequire (\n\tgithub.com/gin-gonic/gin v1.9.1\n\tgolang.org/x/sys v0.1.0 // indirect\n)\n\nrequire github.com/google/uuid v1.6.0\n",
+			},
+			wantLanguages:  []string{"go"},
+			wantFrameworks: []string{"gin"},
+			wantModule:     "example.com/app",
+			wantSummaries:  []string{"go.mod: module example.com/app, go 1.22, 2 dependencies"},
+		},
+		{
+			name: "typescript with a python backend",
+			files: map[string]string{
+				"package.json":   `{"name": "web", "version": "1.0.0", "dependencies": {"react": "^18"}, "devDependencies": {"typescript": "^5"}}`,
+				"pyproject.toml": "[project]\nname = \"api\"\nversion = \"0.3.0\"\ndependencies = [\"fastapi>=0.110\", \"uvicorn[standard]\"]\n",
+			},
+			wantLanguages:  []string{"typescript", "python"},
+			wantFrameworks: []string{"react", "fastapi"},
+			wantModule:     "web",
+			wantSummaries: []string{
+				"package.json: package web, version 1.0.0, 2 dependencies",
+				"pyproject.toml: package api, version 0.3.0, 2 dependencies",
+			},
+		},
+		{
+			name: "poetry, requirements, and cargo",
+			files: map[string]string{
+				"pyproject.toml":   "[tool.poetry]\nname = \"tool\"\n\n[tool.poetry.dependencies]\npython = \"^3.11\"\nDjango = \"^5\"\n",
+				"requirements.txt": "-r base.txt\nflask==3.0 # web\n\n",
+				"Cargo.toml":       "[package]\nname = \"cli\"\nversion = \"0.1.0\"\n\n[dependencies]\naxum = \"0.7\"\ntokio = { version = \"1\" }\n",
+			},
+			wantLanguages:  []string{"python", "rust"},
+			wantFrameworks: []string{"django", "flask", "axum"},
+			wantModule:     "tool",
+			wantSummaries: []string{
+				"pyproject.toml: package tool, 1 dependency",
+				"requirements.txt: 1 dependency",
+				"Cargo.toml: package cli, version 0.1.0, 2 dependencies",
+			},
+		},
+		{
+			name:          "manifest without details",
+			files:         map[string]string{"mix.exs": "defmodule App.MixProject do\nend\n"},
+			wantLanguages: []string{"elixir"},
+			wantSummaries: []string{"mix.exs: elixir"},
+		},
+		{
+			name:          "broken manifest",
+			files:         map[string]string{"package.json": "{"},
+			wantLanguages: []string{"javascript"},
+			wantSummaries: []string{"package.json: unexpected end of JSON input"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+
+			info := Detect(dir, false)
+			if info.Root != dir || info.Module != tt.wantModule {
+				t.Errorf("Root, Module = %q, %q", info.Root, info.Module)
+			}
+			if !reflect.DeepEqual(info.Languages, tt.wantLanguages) {
+				t.Errorf("Languages = %v, want %v", info.Languages, tt.wantLanguages)
+			}
+			if !reflect.DeepEqual(info.Frameworks, tt.wantFrameworks) {
+				t.Errorf("Frameworks = %v, want %v", info.Frameworks, tt.wantFrameworks)
+			}
+			var summaries []string
+			for _, manifest := range info.Manifests {
+				summaries = append(summaries, manifest.Summary)
+			}
+			if !reflect.DeepEqual(summaries, tt.wantSummaries) {
+				t.Errorf("summaries = %q, want %q", summaries, tt.wantSummaries)
+			}
+		})
+	}
+}
+
+func TestDetect_Dependencies(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":           "module example.com/app\n\nrequire (\n\tgithub.com/spf13/cobra v1.8.0\n\tgolang.org/x/sys v0.1.0 // indirect\n)\n",
+		"package.json":     `{"dependencies": {"react": "^18.2"}, "devDependencies": {"vitest": "1.6.0"}}`,
+		"requirements.txt": "requests[socks]>=2.31; python_version > '3.8'\nflask\n",
+		"Cargo.toml":       "[dependencies]\nserde = { version = \"1\", features = [\"derive\"] }\ntokio = \"1.37\"\n",
+		"Gemfile":          "gem 'rails', '~> 7.1'\ngem \"pg\", require: false\n",
+	})
+
+	want := []interfaces.ProjectDependency{
+		{Name: "github.com/spf13/cobra", Version: "v1.8.0", Manifest: "go.mod"},
+		{Name: "react", Version: "^18.2", Manifest: "package.json"},
+		{Name: "vitest", Version: "1.6.0", Manifest: "package.json"},
+		{Name: "flask", Manifest: "requirements.txt"},
+		{Name: "requests", Version: ">=2.31", Manifest: "requirements.txt"},
+		{Name: "serde", Version: "1", Manifest: "Cargo.toml"},
+		{Name: "tokio", Version: "1.37", Manifest: "Cargo.toml"},
+		{Name: "pg", Manifest: "Gemfile"},
+		{Name: "rails", Version: "~> 7.1", Manifest: "Gemfile"},
+	}
+	if got := Detect(dir, false).Dependencies; !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependencies = %+v\nwant %+v", got, want)
+	}
+
+	indirect := interfaces.ProjectDependency{Name: "golang.org/x/sys", Version: "v0.1.0", Manifest: "go.mod", Indirect: true}
+	info := Detect(dir, true)
+	if len(info.Dependencies) != len(want)+1 || info.Dependencies[1] != indirect {
+		t.Errorf("Dependencies with indirect ones = %+v", info.Dependencies)
+	}
+	if !reflect.DeepEqual(info.Manifests[0].Dependencies, []string{"github.com/spf13/cobra"}) {
+		t.Errorf("go.mod Dependencies = %v, want only the direct one", info.Manifests[0].Dependencies)
+	}
+}
+
+func TestDetect_Root(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":             "module example.com/app\n",
+		"internal/api/a.go":  "package api\n",
+		"repo/.git/HEAD":     "ref: refs/heads/main\n",
+		"repo/src/README.md": "# nested\n",
+	})
+
+	// Manifests are found above the working directory
+	if info := Detect(filepath.Join(dir, "internal", "api"), false); info.Root != dir || info.Module != "example.com/app" {
+		t.Errorf("Detect from a subdirectory = %+v", info)
+	}
+	// but not above the root of a repository
+	if info := Detect(filepath.Join(dir, "repo", "src"), false); len(info.Manifests) != 0 {
+		t.Errorf("Detect crossed the repository root: %+v", info)
+	}
+}
@@ -0,0 +1,183 @@
+// Package redact replaces secrets such as API keys, tokens, and private keys in
+// prompt text with placeholders before the prompt leaves the machine
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SecretGroup is the capture group that, when a pattern has it, marks the part of
+// each match to replace; the rest of the match is kept for context
+const SecretGroup = "secret"
+
+// Rule is a named pattern for one kind of secret
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// builtinPatterns are the secrets recognized without any configuration, in the order
+// they are applied. More specific patterns come before the ones they overlap with.
+var builtinPatterns = []struct{ name, pattern string }{
+	{"private-key", `-----BEGIN [A-Z ]*PRIVATE KEY(?: BLOCK)?-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY(?: BLOCK)?-----`},
+	{"aws-access-key", `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{"aws-secret-key", `(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})`},
+	{"github-token", `\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`},
+	{"anthropic-key", `\bsk-ant-[A-Za-z0-9_-]{20,}`},
+	{"openai-key", `\bsk-(?:proj-|svcacct-|admin-)?[A-Za-z0-9_-]{20,}`},
+	{"stripe-key", `\b[rs]k_(?:live|test)_[A-Za-z0-9]{16,}`},
+	{"slack-token", `\bxox[abposr]-[A-Za-z0-9-]{10,}`},
+	{"google-api-key", `\bAIza[0-9A-Za-z_-]{35}\b`},
+	{"jwt", `\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`},
+	{"url-password", `[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:(?P<secret>[^/\s@]+)@`},
+	// KEY=value lines as in .env files, where the key names a secret and the value is a
+	// plain literal rather than a reference such as ${VAR} or a function call
+	{"env-assignment", `(?m)^[ \t]*(?:export[ \t]+)?[A-Z0-9_]*(?:SECRET|TOKEN|PASSWORD|PASSWD|API_?KEY|ACCESS_?KEY|PRIVATE_?KEY|CREDENTIALS?)[A-Z0-9_]*[ \t]*=[ \t]*["']?(?P<secret>[^\s"'()\[\]{}$]{4,})["']?[ \t]*(?:#.*)?$`},
+}
+
+// BuiltinRules returns the rules applied by default
+func BuiltinRules() []Rule {
+	rules := make([]Rule, len(builtinPatterns))
+	for i, builtin := range builtinPatterns {
+		rules[i] = Rule{Name: builtin.name, Pattern: regexp.MustCompile(builtin.pattern)}
+	}
+	return rules
+}
+
+// CompilePatterns compiles configured patterns, keyed by rule name, into rules sorted
+// by name
+func CompilePatterns(patterns map[string]string) ([]Rule, error) {
+	names := make([]string, 0, len(patterns))
+	for name := range patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rules := make([]Rule, 0, len(names))
+	for _, name := range names {
+		if patterns[name] == "" {
+			return nil, fmt.Errorf("redact_patterns.%s: pattern is required", name)
+		}
+		pattern, err := regexp.Compile(patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("redact_patterns.%s: invalid pattern: %w", name, err)
+		}
+		rules = append(rules, Rule{Name: name, Pattern: pattern})
+	}
+	return rules, nil
+}
+
+// Redactor replaces the matches of its rules with placeholders
+type Redactor struct {
+	rules []Rule
+}
+
+// New creates a redactor with the built-in rules followed by the configured patterns
+func New(patterns map[string]string) (*Redactor, error) {
+	custom, err := CompilePatterns(patterns)
+	if err != nil {
+		return nil, err
+	}
+	return &Redactor{rules: append(BuiltinRules(), custom...)}, nil
+}
+
+// Key identifies the redactor's rules, so text redacted by it can be cached apart
+// from text redacted with other patterns
+func (r *Redactor) Key() string {
+	var b strings.Builder
+	for _, rule := range r.rules {
+		b.WriteString(rule.Name)
+		b.WriteByte(0)
+		b.WriteString(rule.Pattern.String())
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// placeholderPrefix starts every placeholder
+const placeholderPrefix = "[REDACTED:"
+
+// Placeholder returns the text that replaces a secret found by the named rule
+func Placeholder(rule string) string {
+	return placeholderPrefix + rule + "]"
+}
+
+// Redact replaces every secret in text and returns the result with the number of
+// secrets replaced by each rule
+func (r *Redactor) Redact(text string) (string, map[string]int) {
+	var counts map[string]int
+	for _, rule := range r.rules {
+		var count int
+		text, count = redactRule(text, rule)
+		if count > 0 {
+			if counts == nil {
+				counts = make(map[string]int)
+			}
+			counts[rule.Name] += count
+		}
+	}
+	return text, counts
+}
+
+// redactRule replaces the matches of one rule, or only their secret group when the
+// pattern has one
+func redactRule(text string, rule Rule) (string, int) {
+	matches := rule.Pattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text, 0
+	}
+	group := rule.Pattern.SubexpIndex(SecretGroup)
+	placeholder := Placeholder(rule.Name)
+
+	var b strings.Builder
+	last, count := 0, 0
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		if group > 0 {
+			start, end = match[2*group], match[2*group+1]
+		}
+		// A placeholder from an earlier pass isn't a secret, so text can be redacted again
+		if start < 0 || start == end || strings.HasPrefix(text[start:end], placeholderPrefix) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(placeholder)
+		last = end
+		count++
+	}
+	b.WriteString(text[last:])
+	return b.String(), count
+}
+
+// Finding records the secrets redacted from one source, such as an embedded file
+type Finding struct {
+	Source string         // Where the secrets were found
+	Counts map[string]int // Secrets replaced by rule name
+}
+
+// Report describes findings in a few human-readable lines
+func Report(findings []Finding) string {
+	total := 0
+	var lines []string
+	for _, finding := range findings {
+		rules := make([]string, 0, len(finding.Counts))
+		for rule, count := range finding.Counts {
+			total += count
+			if count > 1 {
+				rule = fmt.Sprintf("%s x%d", rule, count)
+			}
+			rules = append(rules, rule)
+		}
+		sort.Strings(rules)
+		lines = append(lines, fmt.Sprintf("\n  %s: %s", finding.Source, strings.Join(rules, ", ")))
+	}
+
+	noun := "secrets"
+	if total == 1 {
+		noun = "secret"
+	}
+	return fmt.Sprintf("redacted %d possible %s (use --no-redact to keep them):%s", total, noun, strings.Join(lines, ""))
+}
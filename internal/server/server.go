@@ -0,0 +1,178 @@
+// Package server serves prompt generation over a local HTTP API, so web UIs,
+// launcher extensions, and other tools can drive one long-lived process instead of
+// starting the CLI for every prompt.
+//
+//	POST /generate   body: a PromptRequest as JSON, answered with the --json report
+//	GET  /templates  answered with the template catalog of `prompter templates list --json`
+//
+// Failures are answered with {"error": "..."}. Prompts are returned, never sent to
+// a target, so requests can't set a target, an editor, or fix mode.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/internal/template"
+	"prompter-cli/pkg/models"
+)
+
+// maxRequestBytes bounds the body of a generate request
+const maxRequestBytes = 1 << 20
+
+// Server answers API requests with one orchestrator, keeping its plugins, compiled
+// helpers, and tokenizer loaded between requests
+type Server struct {
+	mu         sync.Mutex // Guards orch, which keeps state for the prompt being generated
+	orch       *orchestrator.Orchestrator
+	configPath string
+	origins    map[string]bool // Browser origins allowed to call the API
+}
+
+// New creates a server that generates prompts with orch using the config at
+// configPath, empty for the default. Browsers may only call it from origins.
+func New(orch *orchestrator.Orchestrator, configPath string, origins []string) *Server {
+	s := &Server{
+		orch:       orch,
+		configPath: configPath,
+		origins:    make(map[string]bool),
+	}
+	for _, origin := range origins {
+		s.origins[strings.TrimSuffix(origin, "/")] = true
+	}
+	return s
+}
+
+// Handler returns the API's routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /generate", s.handleGenerate)
+	mux.HandleFunc("GET /templates", s.handleTemplates)
+	return s.checkOrigin(mux)
+}
+
+// checkOrigin refuses requests from browser pages on origins that weren't allowed,
+// so other sites can't read local files through the API, and answers preflight
+// requests from the allowed ones
+func (s *Server) checkOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		if !s.origins[origin] {
+			writeError(w, http.StatusForbidden, fmt.Errorf("origin %s is not allowed; start the server with --allow-origin %s", origin, origin))
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleGenerate generates the prompt for the request in the body
+func (s *Server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, errors.New("the request body must be JSON (Content-Type: application/json)"))
+		return
+	}
+
+	request, err := decodeRequest(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	request.ConfigPath = s.configPath
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.orch.GeneratePrompt(r.Context(), request); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.orch.Report())
+}
+
+// decodeRequest reads a prompt request, rejecting settings that only make sense
+// for the CLI
+func decodeRequest(body io.Reader) (*models.PromptRequest, error) {
+	request := models.NewPromptRequest()
+
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(request); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	switch {
+	case request.BasePrompt == "":
+		return nil, errors.New("base_prompt is required")
+	case request.FixMode || request.FixFile != "":
+		return nil, errors.New("fix mode is not supported by the server")
+	case request.Target != "" || request.Editor != "" || request.EditorRequested:
+		return nil, errors.New("the prompt is returned in the response; target and editor can't be set")
+	case request.ConfigPath != "":
+		return nil, errors.New("config_path can't be set; start the server with --config instead")
+	case request.FromClipboard || request.TUI:
+		return nil, errors.New("from_clipboard and tui are not supported by the server")
+	}
+
+	// Nothing is asked for, whatever the request says
+	request.Interactive = false
+	request.ForceInteractive = false
+	request.ForceNonInteractive = true
+	return request, nil
+}
+
+// handleTemplates lists the pre and post templates, rereading the config so new
+// templates and prompts directories are picked up
+func (s *Server) handleTemplates(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.orch.LoadConfiguration(s.configPath); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("configuration error: %w", err))
+		return
+	}
+	processor, ok := s.orch.GetTemplateProcessor().(*template.Processor)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("template processor does not support listing templates"))
+		return
+	}
+
+	catalog := processor.Catalog()
+	if catalog == nil {
+		catalog = []template.TemplateInfo{}
+	}
+	writeJSON(w, http.StatusOK, catalog)
+}
+
+// writeJSON writes value as the response, leaving the markup common in prompts unescaped
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(value)
+}
+
+// writeError answers with err as {"error": "..."}
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/internal/template"
+)
+
+// newTestServer returns a server whose config has a "review" pre-template, allowing
+// calls from http://localhost:3000
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	promptsDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(promptsDir, "pre"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(promptsDir, "pre", "review.md"), []byte("---\ndescription: Review code\n---\nReview for {{.Vars.team}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	config := "prompts_location = \"" + promptsDir + "\"\nembed_content = true\n[vars]\nteam = \"core\"\n"
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	orch := orchestrator.New()
+	orch.SetSilent(true)
+	if _, err := orch.LoadConfiguration(configPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ts := httptest.NewServer(New(orch, configPath, []string{"http://localhost:3000/"}).Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// post sends body to /generate as JSON and decodes the response into out
+func post(t *testing.T, ts *httptest.Server, body string, out interface{}) *http.Response {
+	t.Helper()
+	resp, err := http.Post(ts.URL+"/generate", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func TestGenerate(t *testing.T) {
+	ts := newTestServer(t)
+	file := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(file, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var report orchestrator.Report
+	body := `{"base_prompt": "explain <this>", "pre_template": "review", "files": ["` + file + `"], "vars": {"team": "platform"}}`
+	resp := post(t, ts, body, &report)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	for _, want := range []string{"Review for platform", "explain <this>", "package main"} {
+		if !strings.Contains(report.Prompt, want) {
+			t.Errorf("expected %q in the prompt:\n%s", want, report.Prompt)
+		}
+	}
+	if report.Templates.Pre != "review" || len(report.Files) != 1 || !report.Files[0].Embedded {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	// Later requests reuse the same process
+	resp = post(t, ts, `{"base_prompt": "again"}`, &report)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(report.Prompt, "again") || strings.Contains(report.Prompt, "package main") {
+		t.Errorf("unexpected second prompt (%d):\n%s", resp.StatusCode, report.Prompt)
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	ts := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   string
+	}{
+		{"malformed", `{"base_prompt": `, http.StatusBadRequest, "invalid request"},
+		{"unknown field", `{"prompt": "hi"}`, http.StatusBadRequest, `unknown field "prompt"`},
+		{"no prompt", `{"pre_template": "review"}`, http.StatusBadRequest, "base_prompt is required"},
+		{"target", `{"base_prompt": "hi", "target": "stdout"}`, http.StatusBadRequest, "target and editor can't be set"},
+		{"fix mode", `{"base_prompt": "hi", "fix_mode": true}`, http.StatusBadRequest, "fix mode is not supported"},
+		{"config path", `{"base_prompt": "hi", "config_path": "/etc/passwd"}`, http.StatusBadRequest, "config_path can't be set"},
+		{"missing template", `{"base_prompt": "hi", "pre_template": "nope"}`, http.StatusUnprocessableEntity, "nope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var failure struct{ Error string }
+			resp := post(t, ts, tt.body, &failure)
+			if resp.StatusCode != tt.status || !strings.Contains(failure.Error, tt.want) {
+				t.Errorf("got %d %q, want %d containing %q", resp.StatusCode, failure.Error, tt.status, tt.want)
+			}
+		})
+	}
+
+	resp, err := http.Post(ts.URL+"/generate", "text/plain", strings.NewReader(`{"base_prompt": "hi"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("expected a plain text body to be refused, got %d", resp.StatusCode)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, err := http.Get(ts.URL + "/templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var catalog []template.TemplateInfo
+	if err := json.NewDecoder(resp.Body).Decode(&catalog); err != nil {
+		t.Fatal(err)
+	}
+	var review *template.TemplateInfo
+	for i := range catalog {
+		if catalog[i].Name == "review" && catalog[i].Kind == "pre" && catalog[i].Source != template.SourceBuiltin {
+			review = &catalog[i]
+		}
+	}
+	if review == nil || review.Description != "Review code" {
+		t.Errorf("expected the review template in the catalog, got %+v", catalog)
+	}
+}
+
+func TestOrigins(t *testing.T) {
+	ts := newTestServer(t)
+
+	request := func(method, origin string) *http.Response {
+		req, _ := http.NewRequest(method, ts.URL+"/templates", nil)
+		req.Header.Set("Origin", origin)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	if resp := request(http.MethodGet, "https://example.com"); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected another site to be refused, got %d", resp.StatusCode)
+	}
+
+	resp := request(http.MethodOptions, "http://localhost:3000")
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Access-Control-Allow-Origin") != "http://localhost:3000" {
+		t.Errorf("unexpected preflight response: %d %v", resp.StatusCode, resp.Header)
+	}
+	if resp := request(http.MethodGet, "http://localhost:3000"); resp.StatusCode != http.StatusOK || resp.Header.Get("Access-Control-Allow-Origin") != "http://localhost:3000" {
+		t.Errorf("unexpected response for an allowed origin: %d %v", resp.StatusCode, resp.Header)
+	}
+}
@@ -0,0 +1,205 @@
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultLocation is where stats are stored when stats_location isn't configured
+const DefaultLocation = "~/.local/share/prompter/stats.json"
+
+// Signal is something that happened to a template
+type Signal int
+
+const (
+	SignalUse          Signal = iota // The template was used to generate a prompt
+	SignalRerun                      // The same request was generated again right after
+	SignalContinuation               // A follow-up was built on a prompt using the template
+	SignalAbort                      // The user cancelled after selecting the template
+)
+
+// TemplateStats holds the usage counters of a single template
+type TemplateStats struct {
+	Uses          int       `json:"uses"`
+	Reruns        int       `json:"reruns"`
+	Continuations int       `json:"continuations"`
+	Aborts        int       `json:"aborts"`
+	LastUsed      time.Time `json:"last_used"`
+}
+
+// Stats is the full usage record, keyed by "<kind>/<name>" where kind is pre or post
+type Stats struct {
+	Templates map[string]*TemplateStats `json:"templates"`
+}
+
+// Entry is a template and its counters, as returned by Entries
+type Entry struct {
+	Kind string
+	Name string
+	TemplateStats
+}
+
+// Record counts a signal for a template. Empty names are ignored so callers can
+// pass request fields directly.
+func (s *Stats) Record(kind, name string, signal Signal, at time.Time) {
+	if name == "" {
+		return
+	}
+	if s.Templates == nil {
+		s.Templates = make(map[string]*TemplateStats)
+	}
+
+	key := kind + "/" + name
+	entry := s.Templates[key]
+	if entry == nil {
+		entry = &TemplateStats{}
+		s.Templates[key] = entry
+	}
+
+	switch signal {
+	case SignalUse:
+		entry.Uses++
+		entry.LastUsed = at
+	case SignalRerun:
+		entry.Reruns++
+	case SignalContinuation:
+		entry.Continuations++
+	case SignalAbort:
+		entry.Aborts++
+	}
+}
+
+// Entries returns the counters of every template of kind (all kinds when empty),
+// most used first
+func (s *Stats) Entries(kind string) []Entry {
+	var entries []Entry
+	for key, counters := range s.Templates {
+		entryKind, name, ok := strings.Cut(key, "/")
+		if !ok || (kind != "" && entryKind != kind) {
+			continue
+		}
+		entries = append(entries, Entry{Kind: entryKind, Name: name, TemplateStats: *counters})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Uses != entries[j].Uses {
+			return entries[i].Uses > entries[j].Uses
+		}
+		if entries[i].Kind != entries[j].Kind {
+			return entries[i].Kind < entries[j].Kind
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// MostUsed returns up to n template names of kind with the most uses
+func (s *Stats) MostUsed(kind string, n int) []string {
+	var names []string
+	for _, entry := range s.Entries(kind) {
+		if len(names) == n {
+			break
+		}
+		if entry.Uses > 0 {
+			names = append(names, entry.Name)
+		}
+	}
+	return names
+}
+
+// RecentlyUsed returns up to n template names of kind, most recently used first
+func (s *Stats) RecentlyUsed(kind string, n int) []string {
+	entries := s.Entries(kind)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+
+	var names []string
+	for _, entry := range entries {
+		if len(names) == n {
+			break
+		}
+		if !entry.LastUsed.IsZero() {
+			names = append(names, entry.Name)
+		}
+	}
+	return names
+}
+
+// Store keeps stats in a single JSON file on the local machine
+type Store struct {
+	path string
+}
+
+// NewStore creates a store backed by the file at path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the file the store reads and writes
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load reads the stats, returning empty stats when nothing has been recorded yet
+func (s *Store) Load() (*Stats, error) {
+	stats := &Stats{Templates: make(map[string]*TemplateStats)}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats: %w", err)
+	}
+
+	if err := json.Unmarshal(data, stats); err != nil {
+		return nil, fmt.Errorf("failed to parse stats %s: %w", s.path, err)
+	}
+	if stats.Templates == nil {
+		stats.Templates = make(map[string]*TemplateStats)
+	}
+	return stats, nil
+}
+
+// Update loads the stats, applies fn, and writes the result back
+func (s *Store) Update(fn func(*Stats)) error {
+	stats, err := s.Load()
+	if err != nil {
+		return err
+	}
+	fn(stats)
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create stats directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode stats: %w", err)
+	}
+
+	// Write to a temporary file first so an interrupted write can't corrupt the stats
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write stats: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write stats: %w", err)
+	}
+	return nil
+}
+
+// Reset deletes all recorded stats
+func (s *Store) Reset() error {
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stats: %w", err)
+	}
+	return nil
+}
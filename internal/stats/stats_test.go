@@ -0,0 +1,74 @@
+package stats
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStats_RecordAndRank(t *testing.T) {
+	base := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	stats := &Stats{}
+
+	stats.Record("pre", "review", SignalUse, base)
+	stats.Record("pre", "review", SignalUse, base.Add(time.Minute))
+	stats.Record("pre", "explain", SignalUse, base.Add(2*time.Minute))
+	stats.Record("pre", "review", SignalRerun, base)
+	stats.Record("pre", "explain", SignalAbort, base)
+	stats.Record("post", "tests", SignalUse, base.Add(3*time.Minute))
+	stats.Record("post", "", SignalUse, base)
+
+	if got := stats.MostUsed("pre", 5); !reflect.DeepEqual(got, []string{"review", "explain"}) {
+		t.Errorf("MostUsed = %v", got)
+	}
+	if got := stats.RecentlyUsed("pre", 1); !reflect.DeepEqual(got, []string{"explain"}) {
+		t.Errorf("RecentlyUsed = %v", got)
+	}
+
+	review := stats.Templates["pre/review"]
+	if review.Uses != 2 || review.Reruns != 1 || !review.LastUsed.Equal(base.Add(time.Minute)) {
+		t.Errorf("unexpected review stats %+v", review)
+	}
+	if stats.Templates["pre/explain"].Aborts != 1 {
+		t.Errorf("expected one abort for explain")
+	}
+	if len(stats.Entries("")) != 3 {
+		t.Errorf("expected empty names to be ignored, got %+v", stats.Entries(""))
+	}
+}
+
+func TestStore_UpdateLoadReset(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "nested", "stats.json"))
+
+	empty, err := store.Load()
+	if err != nil || len(empty.Templates) != 0 {
+		t.Fatalf("Load before any update = %+v, %v", empty, err)
+	}
+
+	err = store.Update(func(s *Stats) {
+		s.Record("post", "tests", SignalUse, time.Now())
+		s.Record("post", "tests", SignalContinuation, time.Now())
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Templates["post/tests"]; got == nil || got.Uses != 1 || got.Continuations != 1 {
+		t.Errorf("unexpected stats after reload: %+v", got)
+	}
+
+	if err := store.Reset(); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if err := store.Reset(); err != nil {
+		t.Errorf("Reset of missing stats should succeed, got %v", err)
+	}
+	if reset, _ := store.Load(); len(reset.Templates) != 0 {
+		t.Errorf("expected no stats after reset, got %+v", reset.Templates)
+	}
+}
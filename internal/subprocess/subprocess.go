@@ -0,0 +1,26 @@
+// Package subprocess builds the commands prompter runs for helpers, plugins, and
+// pipeline stages.
+package subprocess
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// waitDelay bounds how long a killed command's output is waited for
+const waitDelay = time.Second
+
+// Command returns a command running name with args that is killed when ctx is done.
+// Grandchildren that keep its output pipes open after a kill, such as those of a
+// shell, are waited on for at most a second.
+func Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
+
+// Shell returns a command running command with sh -c, as Command does
+func Shell(ctx context.Context, command string) *exec.Cmd {
+	return Command(ctx, "sh", "-c", command)
+}
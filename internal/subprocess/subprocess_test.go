@@ -0,0 +1,22 @@
+package subprocess
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestShell_KilledDespiteGrandchildren(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// The backgrounded sleep keeps stdout open after sh is killed
+	start := time.Now()
+	output, err := Shell(ctx, "sleep 30 & sleep 30").Output()
+	if err == nil {
+		t.Fatalf("expected the command to be killed, got output %q", output)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("waited %s for a killed command", elapsed)
+	}
+}
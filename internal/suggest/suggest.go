@@ -0,0 +1,25 @@
+// Package suggest measures how alike names are, for suggesting the setting or
+// template a misspelled name was meant to be.
+package suggest
+
+// Distance returns the Levenshtein distance between a and b: the number of single
+// byte insertions, deletions, and substitutions turning one into the other
+func Distance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
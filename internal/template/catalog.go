@@ -0,0 +1,248 @@
+package template
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"prompter-cli/internal/interfaces"
+)
+
+// Template sources reported in TemplateInfo.Source
+const (
+	SourceLocal   = "local"
+	SourceGlobal  = "global"
+	SourceShared  = "shared" // Any prompts_location entry after the first
+	SourceBuiltin = "built-in"
+	SourceCustom  = "custom" // Reported as "custom:<name>"
+)
+
+// TemplateInfo describes a template available to the processor
+type TemplateInfo struct {
+	Name        string   `json:"name"`
+	Kind        string   `json:"kind"` // "pre" or "post"
+	Path        string   `json:"path"`
+	Source      string   `json:"source"`
+	Default     bool     `json:"default"` // Named with .default, listed first in the selectors
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Error       string   `json:"error,omitempty"` // Set when the template fails to load
+}
+
+// templateDir is a directory searched for templates and the source it represents
+type templateDir struct {
+	dir    string
+	source string
+}
+
+// searchDirs returns the template directories in lookup order, matching discoverTemplate
+func (p *Processor) searchDirs() []templateDir {
+	var dirs []templateDir
+	if p.localPromptsLocation != "" {
+		dirs = append(dirs, templateDir{p.localPromptsLocation, SourceLocal})
+	}
+	for i, location := range p.promptsLocations {
+		source := SourceGlobal
+		if i > 0 {
+			source = SourceShared
+		}
+		dirs = append(dirs, templateDir{location, source})
+	}
+
+	names := make([]string, 0, len(p.customTemplates))
+	for name := range p.customTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		dirs = append(dirs, templateDir{p.customTemplates[name].Location, SourceCustom + ":" + name})
+	}
+
+	return dirs
+}
+
+// Catalog lists every pre and post template the processor can resolve, including
+// built-in ones. Templates shadowed by a same-named template earlier in the lookup
+// order are omitted. The result is sorted by kind and then name.
+func (p *Processor) Catalog() []TemplateInfo {
+	var infos []TemplateInfo
+	seen := make(map[string]bool)
+
+	add := func(info TemplateInfo) {
+		key := info.Kind + "/" + strings.ToLower(info.Name)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		p.describe(&info)
+		infos = append(infos, info)
+	}
+
+	for _, dir := range p.searchDirs() {
+		for _, kind := range []string{"pre", "post"} {
+			files, _ := ReadTemplateDir(filepath.Join(dir.dir, kind))
+			for _, file := range files {
+				add(TemplateInfo{
+					Name:    file.Name,
+					Kind:    kind,
+					Path:    file.Path,
+					Source:  dir.source,
+					Default: file.Default,
+				})
+			}
+		}
+	}
+
+	for _, kind := range []string{"pre", "post"} {
+		for _, name := range EmbeddedTemplateNames(kind) {
+			add(TemplateInfo{
+				Name:   name,
+				Kind:   kind,
+				Path:   EmbeddedPrefix + path.Join(kind, name+".md"),
+				Source: SourceBuiltin,
+			})
+		}
+	}
+
+	sort.SliceStable(infos, func(i, j int) bool {
+		if infos[i].Kind != infos[j].Kind {
+			return infos[i].Kind == "pre"
+		}
+		return strings.ToLower(infos[i].Name) < strings.ToLower(infos[j].Name)
+	})
+
+	return infos
+}
+
+// describe fills in the frontmatter fields of a template, or its load error
+func (p *Processor) describe(info *TemplateInfo) {
+	tmpl, err := p.loadTemplateFromPath(info.Path)
+	if err != nil {
+		info.Error = err.Error()
+		return
+	}
+	if meta := p.metadata[tmpl]; meta != nil {
+		info.Description = meta.Description
+		info.Tags = meta.Tags
+	}
+}
+
+// TemplateFile is a template in a pre or post directory
+type TemplateFile struct {
+	Name    string // Display name, namespaced by the subdirectories it's in: go/review
+	Stem    string // Namespaced file name without .md: go/review.default
+	Path    string
+	Default bool
+}
+
+// ReadTemplateDir lists the .md templates in dir and its subdirectories, which become
+// namespaces: dir/go/review.md is go/review. Hidden directories are skipped, and a
+// missing dir has no templates. Files come before subdirectories at each level.
+func ReadTemplateDir(dir string) ([]TemplateFile, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files, nested []TemplateFile
+	for _, entry := range entries {
+		switch {
+		case entry.IsDir():
+			if strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			children, err := ReadTemplateDir(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			for _, child := range children {
+				child.Name = entry.Name() + "/" + child.Name
+				child.Stem = entry.Name() + "/" + child.Stem
+				nested = append(nested, child)
+			}
+		case entry.Type()&fs.ModeType == 0 && strings.HasSuffix(entry.Name(), ".md"):
+			stem := strings.TrimSuffix(entry.Name(), ".md")
+			name, isDefault := DisplayName(stem)
+			files = append(files, TemplateFile{
+				Name:    name,
+				Stem:    stem,
+				Path:    filepath.Join(dir, entry.Name()),
+				Default: isDefault,
+			})
+		}
+	}
+	return append(files, nested...), nil
+}
+
+// CustomTemplateName returns the name of the template a custom template selects: the
+// first in the pre or post directory of its location
+func CustomTemplateName(custom interfaces.CustomTemplate) (string, error) {
+	dir := filepath.Join(custom.Location, "pre")
+	if custom.Type == "post" {
+		dir = filepath.Join(custom.Location, "post")
+	}
+	files, err := ReadTemplateDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read custom template location %s: %w", dir, err)
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no templates found in custom template location %s", dir)
+	}
+	return files[0].Name, nil
+}
+
+// Namespace returns the namespace of a template name, empty for a template at the top
+// of its directory: go/review is in go
+func Namespace(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// ValidTemplateName reports whether name can name a template file, optionally in a
+// namespace such as go/review
+func ValidTemplateName(name string) bool {
+	if name == "" || strings.Contains(name, `\`) {
+		return false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || strings.HasPrefix(part, ".") {
+			return false
+		}
+	}
+	return true
+}
+
+// IsTemplatePath reports whether LoadTemplate treats nameOrPath as a file path rather
+// than a template name: absolute, ending in .md, or naming an existing file. go/review
+// is a namespaced name unless a file of that name exists.
+func IsTemplatePath(nameOrPath string) bool {
+	if filepath.IsAbs(nameOrPath) || IsEmbeddedPath(nameOrPath) || strings.HasSuffix(nameOrPath, ".md") {
+		return true
+	}
+	if !strings.ContainsAny(nameOrPath, `/\`) {
+		return false
+	}
+	info, err := os.Stat(nameOrPath)
+	return err == nil && !info.IsDir()
+}
+
+// DisplayName strips the .default marker from a template file stem, reporting
+// whether it was present ("review.default" and "review.default.v2" are defaults)
+func DisplayName(stem string) (string, bool) {
+	if strings.Contains(stem, ".default.") {
+		return strings.Trim(strings.ReplaceAll(stem, ".default.", "."), "."), true
+	}
+	if strings.HasSuffix(stem, ".default") {
+		return strings.TrimSuffix(stem, ".default"), true
+	}
+	return stem, false
+}
@@ -0,0 +1,234 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompter-cli/internal/interfaces"
+)
+
+func TestProcessor_Namespaces(t *testing.T) {
+	global := t.TempDir()
+	for file, content := range map[string]string{
+		"pre/review.md":              "top-level review",
+		"pre/go/review.md":           "go review",
+		"pre/go/test.default.md":     "go tests",
+		"pre/.drafts/review.md":      "hidden",
+		"tests/go/review/input.json": `{"Prompt": "p"}`,
+	} {
+		path := filepath.Join(global, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	processor := NewProcessor(global)
+
+	for name, want := range map[string]string{
+		"review":          "pre/review.md",
+		"go/review":       "pre/go/review.md",
+		"Go/Review":       "pre/go/review.md",
+		"go/test":         "pre/go/test.default.md",
+		"go/test.default": "pre/go/test.default.md",
+	} {
+		path, err := processor.ResolveTemplate(name)
+		if err != nil {
+			t.Errorf("ResolveTemplate(%q) error = %v", name, err)
+			continue
+		}
+		if path != filepath.Join(global, filepath.FromSlash(want)) {
+			t.Errorf("ResolveTemplate(%q) = %q, want %s", name, path, want)
+		}
+	}
+	if _, err := processor.ResolveTemplate(".drafts/review"); err == nil {
+		t.Error("templates in hidden directories should not resolve")
+	}
+
+	tmpl, err := processor.LoadTemplate("go/review")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output, err := processor.Execute(tmpl, interfaces.TemplateData{}); err != nil || output != "go review" {
+		t.Errorf("Execute() = %q, %v", output, err)
+	}
+
+	var names []string
+	for _, info := range processor.Catalog() {
+		if info.Kind == "pre" && info.Source == SourceGlobal {
+			names = append(names, info.Name)
+		}
+	}
+	if strings.Join(names, ",") != "go/review,go/test,review" {
+		t.Errorf("Catalog() names = %v", names)
+	}
+
+	results, err := processor.RunSnapshots([]string{"go/review"}, true)
+	if err != nil || len(results) != 1 || results[0].TemplatePath != filepath.Join(global, "pre", "go", "review.md") {
+		t.Errorf("RunSnapshots() = %+v, %v", results, err)
+	}
+}
+
+func TestProcessor_Catalog(t *testing.T) {
+	global := t.TempDir()
+	local := t.TempDir()
+
+	write := func(dir, kind, file, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, kind), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, kind, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(global, "pre", "review.md", "global review")
+	write(local, "pre", "Review.md", "---\ndescription: Local review\ntags: [code]\n---\nlocal review")
+	write(global, "post", "tests.default.md", "tests")
+	write(global, "post", "broken.md", "{{.Prompt")
+	write(global, "post", "question.md", "shadows nothing, question is a pre built-in")
+
+	processor := NewProcessor(global)
+	processor.SetLocalPromptsLocation(local)
+
+	byKey := make(map[string]TemplateInfo)
+	for _, info := range processor.Catalog() {
+		byKey[info.Kind+"/"+info.Name] = info
+	}
+
+	review, ok := byKey["pre/Review"]
+	if !ok || review.Source != SourceLocal || review.Description != "Local review" || len(review.Tags) != 1 {
+		t.Errorf("expected the local review to shadow the global one, got %+v", review)
+	}
+	if _, ok := byKey["pre/review"]; ok {
+		t.Error("shadowed global review should not be listed")
+	}
+
+	if tests := byKey["post/tests"]; !tests.Default || tests.Source != SourceGlobal {
+		t.Errorf("expected tests to be a global default, got %+v", tests)
+	}
+	if broken := byKey["post/broken"]; broken.Error == "" {
+		t.Errorf("expected a load error for broken, got %+v", broken)
+	}
+	if question := byKey["pre/question"]; question.Source != SourceBuiltin {
+		t.Errorf("expected the built-in question template, got %+v", question)
+	}
+	if question := byKey["post/question"]; question.Source != SourceGlobal {
+		t.Errorf("expected the global post question template, got %+v", question)
+	}
+}
+
+func TestProcessor_SharedPromptsLocations(t *testing.T) {
+	personal := t.TempDir()
+	team := t.TempDir()
+	for dir, files := range map[string]map[string]string{
+		personal: {"review.md": "personal review"},
+		team:     {"review.md": "team review", "Security.md": "team security"},
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, "pre"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		for file, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, "pre", file), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	processor := NewProcessor("")
+	processor.SetPromptsLocations([]string{personal, team})
+
+	for name, want := range map[string]string{"review": "personal review", "security": "team security"} {
+		tmpl, err := processor.LoadTemplate(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := processor.Execute(tmpl, interfaces.TemplateData{}); err != nil || got != want {
+			t.Errorf("%s = %q, %v; want %q", name, got, err, want)
+		}
+	}
+
+	sources := make(map[string]string)
+	for _, info := range processor.Catalog() {
+		if info.Kind == "pre" {
+			sources[info.Name] = info.Source
+		}
+	}
+	if sources["review"] != SourceGlobal || sources["Security"] != SourceShared {
+		t.Errorf("expected review from the first location and Security shared, got %v", sources)
+	}
+}
+
+func TestDisplayName(t *testing.T) {
+	tests := []struct {
+		stem        string
+		wantName    string
+		wantDefault bool
+	}{
+		{"review", "review", false},
+		{"review.default", "review", true},
+		{"review.default.v2", "review.v2", true},
+	}
+	for _, tt := range tests {
+		name, isDefault := DisplayName(tt.stem)
+		if name != tt.wantName || isDefault != tt.wantDefault {
+			t.Errorf("DisplayName(%q) = %q, %v", tt.stem, name, isDefault)
+		}
+	}
+}
+
+func TestProcessor_BuiltinTemplates(t *testing.T) {
+	processor := NewProcessor(t.TempDir())
+
+	want := map[string][]string{
+		"pre":  {"explain", "fix", "question", "review"},
+		"post": {"clarify", "strict"},
+	}
+	for kind, names := range want {
+		if got := EmbeddedTemplateNames(kind); strings.Join(got, ",") != strings.Join(names, ",") {
+			t.Errorf("EmbeddedTemplateNames(%s) = %v, want %v", kind, got, names)
+		}
+	}
+
+	for _, info := range processor.Catalog() {
+		if info.Source != SourceBuiltin {
+			continue
+		}
+		if info.Description == "" || info.Error != "" {
+			t.Errorf("built-in %s/%s should load and have a description, got %+v", info.Kind, info.Name, info)
+		}
+
+		tmpl, err := processor.LoadTemplate(info.Name)
+		if err != nil {
+			t.Fatalf("failed to load built-in %s: %v", info.Name, err)
+		}
+		result, err := processor.Execute(tmpl, interfaces.TemplateData{})
+		if err != nil || !strings.HasPrefix(result, "# ") {
+			t.Errorf("built-in %s rendered %q, %v", info.Name, result, err)
+		}
+	}
+}
+
+func TestCustomTemplateName(t *testing.T) {
+	location := t.TempDir()
+	for _, name := range []string{"post/checklist.default.md", "post/zz.md"} {
+		path := filepath.Join(location, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("body"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	name, err := CustomTemplateName(interfaces.CustomTemplate{Location: location, Type: "post"})
+	if err != nil || name != "checklist" {
+		t.Errorf("CustomTemplateName(post) = %q, %v; want checklist", name, err)
+	}
+	if _, err := CustomTemplateName(interfaces.CustomTemplate{Location: location, Type: "pre"}); err == nil {
+		t.Error("expected an error for a location without pre-templates")
+	}
+}
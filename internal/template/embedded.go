@@ -0,0 +1,69 @@
+package template
+
+import (
+	"embed"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// EmbeddedPrefix marks template paths that refer to templates compiled into the binary
+const EmbeddedPrefix = "builtin:"
+
+// builtinTemplates holds the fallback templates shipped with the binary. They are
+// resolved after the local, global, and custom prompts directories so user files
+// with the same name always win.
+//
+//go:embed builtin
+var builtinTemplates embed.FS
+
+// EmbeddedTemplateNames returns the names of the built-in templates in a subdirectory ("pre" or "post")
+func EmbeddedTemplateNames(subdir string) []string {
+	entries, err := fs.ReadDir(builtinTemplates, path.Join("builtin", subdir))
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(entry.Name(), ".md"))
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// EmbeddedTemplate returns the content of a built-in template by subdirectory and name
+func EmbeddedTemplate(subdir, name string) (string, bool) {
+	content, err := builtinTemplates.ReadFile(path.Join("builtin", subdir, name+".md"))
+	if err != nil {
+		return "", false
+	}
+	return string(content), true
+}
+
+// IsEmbeddedPath reports whether a template path refers to a built-in template
+func IsEmbeddedPath(templatePath string) bool {
+	return strings.HasPrefix(templatePath, EmbeddedPrefix)
+}
+
+// discoverEmbeddedTemplate finds a built-in template by name (case-insensitive)
+func discoverEmbeddedTemplate(name string) (string, bool) {
+	for _, subdir := range []string{"pre", "post"} {
+		for _, candidate := range EmbeddedTemplateNames(subdir) {
+			if strings.EqualFold(candidate, name) {
+				return EmbeddedPrefix + path.Join(subdir, candidate+".md"), true
+			}
+		}
+	}
+	return "", false
+}
+
+// readEmbeddedTemplate reads a built-in template addressed by an EmbeddedPrefix path
+func readEmbeddedTemplate(templatePath string) ([]byte, error) {
+	return builtinTemplates.ReadFile(path.Join("builtin", strings.TrimPrefix(templatePath, EmbeddedPrefix)))
+}
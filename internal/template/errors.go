@@ -0,0 +1,183 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// ErrNotFound is returned when no prompts directory or built-in template has a
+// template of the name
+var ErrNotFound = errors.New("template not found")
+
+// NotFoundError is the ErrNotFound of a template looked up by name, with the names of
+// available templates that may have been meant
+type NotFoundError struct {
+	Name        string
+	Suggestions []string // Closest names first, empty when none are close
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrNotFound, e.Name)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// templateError matches the location text/template puts at the start of its parse and
+// execution errors, and the action an execution error happened at
+var templateError = regexp.MustCompile(`^template: [^:]*:(\d+)(?::(\d+))?: (?:executing "[^"]*" at <([^>]*)>: )?(.*)$`)
+
+// missingKey matches the error strict mode reports for undefined map keys
+var missingKey = regexp.MustCompile(`^map has no entry for key "([^"]*)"$`)
+
+// source records where a loaded template came from so errors can point into its file
+type source struct {
+	path   string
+	offset int      // Lines taken by the frontmatter above the template body
+	lines  []string // Lines of the template body
+}
+
+// Error is a template parse or execution error located in the template file
+type Error struct {
+	Path    string
+	Line    int // Line in the file, counting the frontmatter
+	Column  int // 1-based column, 0 when unknown as for parse errors
+	Message string
+	Source  string // Text of the line, empty when unavailable
+	Err     error  // The error reported by text/template
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Path)
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ":%d", e.Line)
+		if e.Column > 0 {
+			fmt.Fprintf(&b, ":%d", e.Column)
+		}
+	}
+	b.WriteString(": " + e.Message)
+
+	if e.Source != "" {
+		gutter := strconv.Itoa(e.Line)
+		fmt.Fprintf(&b, "\n  %s | %s", gutter, e.Source)
+		if e.Column > 0 && e.Column <= len(e.Source)+1 {
+			// Keep tabs so the caret lines up under the column
+			padding := strings.Map(func(r rune) rune {
+				if r == '\t' {
+					return r
+				}
+				return ' '
+			}, e.Source[:e.Column-1])
+			fmt.Fprintf(&b, "\n  %s | %s^", strings.Repeat(" ", len(gutter)), padding)
+		}
+	}
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// yamlLine matches the line the YAML decoder reports an error at, taking the first of
+// several
+var yamlLine = regexp.MustCompile(`line (\d+): (.*)`)
+
+// frontmatterError turns an error parsing the frontmatter of the template at path into
+// an Error, pointing at the line when the YAML decoder reported one
+func frontmatterError(path string, content []byte, err error) error {
+	located := &Error{Path: path, Message: err.Error(), Err: err}
+	match := yamlLine.FindStringSubmatch(err.Error())
+	if match == nil {
+		return located
+	}
+
+	// The YAML starts below the opening delimiter
+	line, _ := strconv.Atoi(match[1])
+	located.Line = line + 1
+	located.Message = "invalid frontmatter: " + match[2]
+	if lines := strings.Split(string(content), "\n"); located.Line <= len(lines) {
+		located.Source = strings.TrimRight(lines[located.Line-1], "\r")
+	}
+	return located
+}
+
+// newSource records the template body of the file at path, given the file content
+// and the body left after stripping the frontmatter
+func newSource(path string, content, body []byte) *source {
+	frontmatter := content[:len(content)-len(body)]
+	return &source{
+		path:   path,
+		offset: strings.Count(string(frontmatter), "\n"),
+		lines:  strings.Split(string(body), "\n"),
+	}
+}
+
+// locate turns an error from text/template into an Error pointing at the line of the
+// template file, and an undefined key into a message naming the missing field.
+// Other errors are returned unchanged.
+func (s *source) locate(err error) error {
+	if s == nil || err == nil {
+		return err
+	}
+	match := templateError.FindStringSubmatch(err.Error())
+	if match == nil {
+		return err
+	}
+
+	line, _ := strconv.Atoi(match[1])
+	located := &Error{
+		Path:    s.path,
+		Line:    line + s.offset,
+		Message: match[4],
+		Err:     err,
+	}
+	if match[2] != "" {
+		// text/template counts columns from 0
+		column, _ := strconv.Atoi(match[2])
+		located.Column = s.column(line, column+1)
+	}
+	if line > 0 && line <= len(s.lines) {
+		located.Source = strings.TrimRight(s.lines[line-1], "\r")
+	}
+
+	if action := match[3]; action != "" {
+		if missingKey.MatchString(match[4]) {
+			located.Message = action + " is not defined"
+			if name, ok := strings.CutPrefix(action, ".Vars."); ok {
+				located.Message += fmt.Sprintf(" (set it with --var %s=... or declare it in the frontmatter)", name)
+			}
+		} else {
+			located.Message = action + ": " + match[4]
+		}
+	}
+	return located
+}
+
+// column maps a column of the parsed text back to the template file, where each
+// {{system}} action is shorter than the define action it's parsed as
+func (s *source) column(line, column int) int {
+	if line < 1 || line > len(s.lines) {
+		return column
+	}
+	text := s.lines[line-1]
+	shift := 0
+	for _, match := range systemAction.FindAllStringIndex(text, -1) {
+		if match[0]+shift >= column-1 {
+			break
+		}
+		action := text[match[0]:match[1]]
+		shift += len(systemAction.ReplaceAllString(action, systemDefine)) - len(action)
+	}
+	return max(column-shift, 1)
+}
+
+// locateError locates an error from parsing or executing tmpl in its file
+func (p *Processor) locateError(tmpl *template.Template, err error) error {
+	return p.sources[tmpl].locate(err)
+}
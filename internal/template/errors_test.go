@@ -0,0 +1,97 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompter-cli/internal/interfaces"
+)
+
+func TestProcessor_StrictTemplates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "review.md")
+	content := "---\ndescription: Review code\n---\nReview this code.\nBe {{.Vars.tone}}.\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	processor := NewProcessor("")
+	tmpl, err := processor.LoadTemplate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := processor.Execute(tmpl, interfaces.TemplateData{})
+	if err != nil || result != "Review this code.\nBe <no value>.\n" {
+		t.Errorf("Execute() = %q, %v; want <no value> without strict mode", result, err)
+	}
+
+	processor.SetStrict(true)
+	tmpl, err = processor.LoadTemplate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = processor.Execute(tmpl, interfaces.TemplateData{})
+	want := path + ":5:11: .Vars.tone is not defined (set it with --var tone=... or declare it in the frontmatter)\n" +
+		"  5 | Be {{.Vars.tone}}.\n" +
+		"    |           ^"
+	if err == nil || !strings.HasSuffix(err.Error(), want) {
+		t.Errorf("Execute() error = %v, want it to end with\n%s", err, want)
+	}
+
+	result, err = processor.Execute(tmpl, interfaces.TemplateData{Vars: map[string]string{"tone": "direct"}})
+	if err != nil || result != "Review this code.\nBe direct.\n" {
+		t.Errorf("Execute() = %q, %v", result, err)
+	}
+}
+
+func TestProcessor_TemplateErrorLocations(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "unknown helper",
+			content: "---\ndescription: x\n---\nfirst\n{{nope .Diff}}\n",
+			want:    ":5: function \"nope\" not defined\n  5 | {{nope .Diff}}",
+		},
+		{
+			name:    "unclosed action",
+			content: "first\nsecond {{.Diff }\n",
+			want:    ":2: unexpected \"}\" in operand\n  2 | second {{.Diff }",
+		},
+		{
+			name:    "unknown field",
+			content: "---\n---\n\n{{.Nope}}",
+			want:    ":4:3: .Nope: can't evaluate field Nope in type interfaces.TemplateData\n  4 | {{.Nope}}\n    |   ^",
+		},
+		{
+			name:    "frontmatter type",
+			content: "---\ndescription: x\nvariables:\n  name: ticket\n---\nbody\n",
+			want:    ":4: invalid frontmatter: cannot unmarshal !!map into []template.Variable\n  4 |   name: ticket",
+		},
+		{
+			name:    "frontmatter not closed",
+			content: "---\ndescription: x\n",
+			want:    ": frontmatter is not closed with \"---\"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "prompt.md")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			processor := NewProcessor("")
+			tmpl, err := processor.LoadTemplate(path)
+			if err == nil {
+				_, err = processor.Execute(tmpl, interfaces.TemplateData{})
+			}
+			if err == nil || !strings.HasSuffix(err.Error(), path+tt.want) {
+				t.Errorf("error = %v, want it to end with\n%s", err, path+tt.want)
+			}
+		})
+	}
+}
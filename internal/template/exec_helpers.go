@@ -0,0 +1,206 @@
+package template
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/subprocess"
+)
+
+// defaultHelperTimeout bounds a helper process when the config doesn't set timeout_ms
+const defaultHelperTimeout = 10 * time.Second
+
+// maxHelperOutput is the most stdout a helper may write; one writing more is stopped
+// and the call fails rather than holding it all in memory
+const maxHelperOutput = 1 << 20
+
+// maxHelperStderr is how much of a failing helper's stderr is kept for its error
+const maxHelperStderr = 4096
+
+// processHelpers runs external executables as template functions.
+//
+// Calling {{jira "PROJ-1"}} runs the configured command with the template
+// arguments written to stdin as a JSON array, and inlines its stdout with the
+// trailing newline removed. Results are memoized for the lifetime of the
+// processor and optionally cached on disk for cache_ttl seconds.
+type processHelpers struct {
+	mu       sync.Mutex
+	memo     map[string]string
+	cacheDir string
+}
+
+// newProcessHelpers creates a helper runner using the user cache directory for disk caching
+func newProcessHelpers() *processHelpers {
+	cacheDir := ""
+	if dir, err := os.UserCacheDir(); err == nil {
+		cacheDir = filepath.Join(dir, "prompter", "helpers")
+	}
+	return &processHelpers{
+		memo:     make(map[string]string),
+		cacheDir: cacheDir,
+	}
+}
+
+// call runs a helper command, consulting the memo and disk caches first
+func (h *processHelpers) call(ctx context.Context, name string, helper interfaces.HelperCommand, args []interface{}) (string, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	input, err := json.Marshal(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode arguments for helper %s: %w", name, err)
+	}
+
+	key := cacheKey(name, helper, input)
+
+	h.mu.Lock()
+	cached, ok := h.memo[key]
+	h.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	if output, ok := h.readDiskCache(key, helper.CacheTTL); ok {
+		h.remember(key, output)
+		return output, nil
+	}
+
+	output, err := runHelperCommand(ctx, name, helper, input)
+	if err != nil {
+		return "", err
+	}
+
+	h.remember(key, output)
+	h.writeDiskCache(key, helper.CacheTTL, output)
+
+	return output, nil
+}
+
+// remember stores a result in the in-memory cache
+func (h *processHelpers) remember(key, output string) {
+	h.mu.Lock()
+	h.memo[key] = output
+	h.mu.Unlock()
+}
+
+// readDiskCache returns a cached result if one exists and is younger than ttl seconds
+func (h *processHelpers) readDiskCache(key string, ttl int) (string, bool) {
+	if ttl <= 0 || h.cacheDir == "" {
+		return "", false
+	}
+
+	path := filepath.Join(h.cacheDir, key)
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > time.Duration(ttl)*time.Second {
+		return "", false
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+
+	return string(content), true
+}
+
+// writeDiskCache persists a result when disk caching is enabled; failures are ignored
+func (h *processHelpers) writeDiskCache(key string, ttl int, output string) {
+	if ttl <= 0 || h.cacheDir == "" {
+		return
+	}
+
+	if err := os.MkdirAll(h.cacheDir, 0755); err != nil {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(h.cacheDir, key), []byte(output), 0600)
+}
+
+// funcMap returns template functions for every configured helper command
+func (h *processHelpers) funcMap(contextOf func() context.Context, helpers map[string]interfaces.HelperCommand) template.FuncMap {
+	funcs := template.FuncMap{}
+	for name, helper := range helpers {
+		helperName, helperConfig := name, helper
+		funcs[name] = func(args ...interface{}) (string, error) {
+			return h.call(contextOf(), helperName, helperConfig, args)
+		}
+	}
+	return funcs
+}
+
+// runHelperCommand executes the helper process with a timeout, killing it early when
+// parent is done
+func runHelperCommand(parent context.Context, name string, helper interfaces.HelperCommand, input []byte) (string, error) {
+	timeout := defaultHelperTimeout
+	if helper.TimeoutMS > 0 {
+		timeout = time.Duration(helper.TimeoutMS) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	cmd := subprocess.Command(ctx, helper.Command, helper.Args...)
+	cmd.Stdin = bytes.NewReader(input)
+	stderr := &cappedBuffer{limit: maxHelperStderr}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", fmt.Errorf("helper %s failed: %w", name, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("helper %s failed: %w", name, err)
+	}
+
+	// Reading stops once the helper is killed, even if its children keep stdout open
+	stop := context.AfterFunc(ctx, func() { stdout.Close() })
+	defer stop()
+	output, readErr := io.ReadAll(io.LimitReader(stdout, maxHelperOutput+1))
+	if len(output) > maxHelperOutput {
+		cancel() // Stops the helper instead of waiting for the rest
+		cmd.Wait()
+		return "", fmt.Errorf("helper %s wrote more than %d bytes", name, maxHelperOutput)
+	}
+	err = cmd.Wait()
+	if err == nil && readErr != nil {
+		err = readErr
+	}
+	if err != nil {
+		if parent.Err() != nil {
+			return "", context.Cause(parent)
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("helper %s timed out after %s", name, timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("helper %s failed: %w: %s", name, err, msg)
+		}
+		return "", fmt.Errorf("helper %s failed: %w", name, err)
+	}
+
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
+
+// cacheKey identifies a helper invocation by its command line and arguments
+func cacheKey(name string, helper interfaces.HelperCommand, input []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(name))
+	hash.Write([]byte{0})
+	hash.Write([]byte(helper.Command))
+	for _, arg := range helper.Args {
+		hash.Write([]byte{0})
+		hash.Write([]byte(arg))
+	}
+	hash.Write([]byte{0})
+	hash.Write(input)
+	return hex.EncodeToString(hash.Sum(nil))
+}
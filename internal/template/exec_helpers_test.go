@@ -0,0 +1,119 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"prompter-cli/internal/interfaces"
+)
+
+// writeHelperScript writes an executable shell script into dir
+func writeHelperScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestProcessor_ExternalHelpers(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper scripts require a POSIX shell")
+	}
+
+	tempDir := t.TempDir()
+	counter := filepath.Join(tempDir, "calls")
+	script := writeHelperScript(t, tempDir, "jira", `echo x >> "`+counter+`"; printf 'issue: '; cat`)
+
+	templatePath := filepath.Join(tempDir, "ticket.md")
+	if err := os.WriteFile(templatePath, []byte(`{{jira "PROJ-1"}} / {{jira "PROJ-1"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	processor := NewProcessor(tempDir)
+	processor.SetHelpers(map[string]interfaces.HelperCommand{
+		"jira": {Command: script},
+	})
+
+	tmpl, err := processor.LoadTemplate(templatePath)
+	if err != nil {
+		t.Fatalf("failed to load template: %v", err)
+	}
+
+	result, err := processor.Execute(tmpl, interfaces.TemplateData{})
+	if err != nil {
+		t.Fatalf("failed to execute template: %v", err)
+	}
+
+	want := `issue: ["PROJ-1"] / issue: ["PROJ-1"]`
+	if result != want {
+		t.Errorf("result = %q, want %q", result, want)
+	}
+
+	calls, _ := os.ReadFile(counter)
+	if n := strings.Count(string(calls), "x"); n != 1 {
+		t.Errorf("expected identical calls to be memoized, helper ran %d times", n)
+	}
+}
+
+func TestProcessor_ExternalHelperTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper scripts require a POSIX shell")
+	}
+
+	tempDir := t.TempDir()
+	script := writeHelperScript(t, tempDir, "slow", "sleep 5")
+
+	templatePath := filepath.Join(tempDir, "slow.md")
+	if err := os.WriteFile(templatePath, []byte(`{{slow}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	processor := NewProcessor(tempDir)
+	processor.SetHelpers(map[string]interfaces.HelperCommand{
+		"slow": {Command: script, TimeoutMS: 50},
+	})
+
+	tmpl, err := processor.LoadTemplate(templatePath)
+	if err != nil {
+		t.Fatalf("failed to load template: %v", err)
+	}
+
+	_, err = processor.Execute(tmpl, interfaces.TemplateData{})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
+
+func TestProcessor_ExternalHelperOutputLimit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper scripts require a POSIX shell")
+	}
+
+	tempDir := t.TempDir()
+	script := writeHelperScript(t, tempDir, "noisy", "head -c 2000000 /dev/zero")
+
+	templatePath := filepath.Join(tempDir, "noisy.md")
+	if err := os.WriteFile(templatePath, []byte(`{{noisy}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	processor := NewProcessor(tempDir)
+	processor.SetHelpers(map[string]interfaces.HelperCommand{
+		"noisy": {Command: script},
+	})
+
+	tmpl, err := processor.LoadTemplate(templatePath)
+	if err != nil {
+		t.Fatalf("failed to load template: %v", err)
+	}
+
+	_, err = processor.Execute(tmpl, interfaces.TemplateData{})
+	if err == nil || !strings.Contains(err.Error(), "wrote more than") {
+		t.Errorf("expected an output limit error, got %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package template
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Limits of the fetchURL helper when none are configured
+const (
+	DefaultFetchTimeout  = 10 * time.Second
+	DefaultFetchMaxBytes = 64 * 1024
+)
+
+// FetchOptions controls the fetchURL helper, which only fetches URLs matched by Allow
+type FetchOptions struct {
+	// Allow holds host patterns such as "docs.github.com" or "*.example.com", or URL
+	// prefixes such as "https://example.com/docs/", which match URLs with the same
+	// scheme and host whose path is within the prefix's. "*" allows every URL.
+	Allow      []string
+	Timeout    time.Duration // Limit on each request, 0 uses DefaultFetchTimeout
+	MaxBytes   int           // Body beyond this is cut, 0 uses DefaultFetchMaxBytes
+	HTTPClient *http.Client
+}
+
+// SetFetchOptions sets which URLs the fetchURL helper may fetch and its limits
+func (p *Processor) SetFetchOptions(options FetchOptions) {
+	p.fetchOptions = options
+	p.fetched = nil
+}
+
+// allowed reports whether the URL matches an entry of the allowlist
+func (o FetchOptions) allowed(target *url.URL) bool {
+	for _, entry := range o.Allow {
+		switch {
+		case entry == "*":
+			return true
+		case strings.Contains(entry, "://"):
+			if prefixAllows(entry, target) {
+				return true
+			}
+		default:
+			if matched, _ := path.Match(strings.ToLower(entry), strings.ToLower(target.Hostname())); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// prefixAllows reports whether target has the scheme and host of the URL prefix and a
+// path within its path, comparing whole segments after resolving "." and ".." so
+// "https://host/docs/" matches neither "https://host/docs/../admin" nor
+// "https://host/docsearch"
+func prefixAllows(prefix string, target *url.URL) bool {
+	allowed, err := url.Parse(prefix)
+	if err != nil || allowed.User != nil {
+		return false
+	}
+	if !strings.EqualFold(allowed.Scheme, target.Scheme) || !strings.EqualFold(allowed.Host, target.Host) {
+		return false
+	}
+
+	allowedSegments, targetSegments := pathSegments(allowed.Path), pathSegments(target.Path)
+	if len(allowedSegments) > len(targetSegments) {
+		return false
+	}
+	for i, segment := range allowedSegments {
+		if targetSegments[i] != segment {
+			return false
+		}
+	}
+	return true
+}
+
+// pathSegments returns the segments of a URL path once "." and ".." are resolved
+func pathSegments(urlPath string) []string {
+	cleaned := path.Clean("/" + urlPath)
+	if cleaned == "/" {
+		return nil
+	}
+	return strings.Split(cleaned[1:], "/")
+}
+
+// fetchURL implements the fetchURL helper, returning the body of a text response.
+// Each URL is fetched once per processor; redirects must stay within the allowlist.
+func (p *Processor) fetchURL(rawURL string) (string, error) {
+	if body, ok := p.fetched[rawURL]; ok {
+		return body, nil
+	}
+
+	options := p.fetchOptions
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return "", fmt.Errorf("fetchURL %q: must be an http or https URL", rawURL)
+	}
+	if len(options.Allow) == 0 {
+		return "", fmt.Errorf("fetchURL %q: fetching is disabled until fetch_allow lists the hosts templates may fetch from", rawURL)
+	}
+	if !options.allowed(target) {
+		return "", fmt.Errorf("fetchURL %q: %s is not in fetch_allow", rawURL, target.Hostname())
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultFetchTimeout
+	}
+	if options.MaxBytes <= 0 {
+		options.MaxBytes = DefaultFetchMaxBytes
+	}
+
+	client := http.Client{Timeout: options.Timeout}
+	if options.HTTPClient != nil {
+		client = *options.HTTPClient
+		client.Timeout = options.Timeout
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		if !options.allowed(req.URL) {
+			return fmt.Errorf("redirected to %s, which is not in fetch_allow", req.URL.Hostname())
+		}
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(p.context(), http.MethodGet, target.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("fetchURL %q: %w", rawURL, err)
+	}
+	req.Header.Set("User-Agent", "prompter")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetchURL %q: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("fetchURL %q: %s", rawURL, resp.Status)
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil && !textMediaType(mediaType) {
+		return "", fmt.Errorf("fetchURL %q: %s is not text", rawURL, mediaType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(options.MaxBytes)+1))
+	if err != nil {
+		return "", fmt.Errorf("fetchURL %q: %w", rawURL, err)
+	}
+	body := string(data)
+	if len(data) > options.MaxBytes {
+		body = string(data[:options.MaxBytes]) + fmt.Sprintf("\n... (cut at %d bytes)", options.MaxBytes)
+	}
+	body = strings.TrimRight(body, "\r\n")
+
+	if p.fetched == nil {
+		p.fetched = make(map[string]string)
+	}
+	p.fetched[rawURL] = body
+	return body, nil
+}
+
+// textMediaType reports whether a response of the media type can go in a prompt
+func textMediaType(mediaType string) bool {
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/yaml", "application/x-yaml", "application/javascript", "application/toml":
+		return true
+	}
+	return strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
@@ -0,0 +1,153 @@
+package template
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"prompter-cli/internal/interfaces"
+)
+
+func TestFetchURLHelper(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/docs/api", "/admin":
+			w.Write([]byte(r.URL.Path))
+		case "/changelog":
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			w.Write([]byte("## v1.2.0\n- Faster\n"))
+		case "/big":
+			w.Write([]byte(strings.Repeat("x", 100)))
+		case "/logo.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("\x89PNG"))
+		case "/away":
+			http.Redirect(w, r, "https://elsewhere.invalid/", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	hostname, _, _ := strings.Cut(host, ":")
+
+	tests := []struct {
+		name     string
+		allow    []string
+		maxBytes int
+		template string
+		expected string
+		wantErr  string
+	}{
+		{
+			name:     "disabled without an allowlist",
+			template: `{{fetchURL "` + server.URL + `/changelog"}}`,
+			wantErr:  "fetch_allow",
+		},
+		{
+			name:     "allowed host",
+			allow:    []string{hostname},
+			template: `{{fetchURL "` + server.URL + `/changelog"}}`,
+			expected: "## v1.2.0\n- Faster",
+		},
+		{
+			name:     "allowed prefix",
+			allow:    []string{server.URL + "/docs/"},
+			template: `{{fetchURL "` + server.URL + `/docs/api"}}`,
+			expected: "/docs/api",
+		},
+		{
+			name:     "prefix doesn't match",
+			allow:    []string{server.URL + "/docs/"},
+			template: `{{fetchURL "` + server.URL + `/changelog"}}`,
+			wantErr:  "not in fetch_allow",
+		},
+		{
+			name:     "prefix matches whole segments",
+			allow:    []string{server.URL + "/change"},
+			template: `{{fetchURL "` + server.URL + `/changelog"}}`,
+			wantErr:  "not in fetch_allow",
+		},
+		{
+			name:     "dot segments leaving the prefix",
+			allow:    []string{server.URL + "/docs/"},
+			template: `{{fetchURL "` + server.URL + `/docs/../admin"}}`,
+			wantErr:  "not in fetch_allow",
+		},
+		{
+			name:     "userinfo hiding another host",
+			allow:    []string{server.URL},
+			template: `{{fetchURL "` + server.URL + `@elsewhere.invalid/"}}`,
+			wantErr:  "elsewhere.invalid is not in fetch_allow",
+		},
+		{
+			name:     "size cap",
+			allow:    []string{"*"},
+			maxBytes: 10,
+			template: `{{fetchURL "` + server.URL + `/big"}}`,
+			expected: strings.Repeat("x", 10) + "\n... (cut at 10 bytes)",
+		},
+		{
+			name:     "not text",
+			allow:    []string{"*"},
+			template: `{{fetchURL "` + server.URL + `/logo.png"}}`,
+			wantErr:  "image/png is not text",
+		},
+		{
+			name:     "error status",
+			allow:    []string{"*"},
+			template: `{{fetchURL "` + server.URL + `/missing"}}`,
+			wantErr:  "404 Not Found",
+		},
+		{
+			name:     "redirect outside the allowlist",
+			allow:    []string{hostname},
+			template: `{{fetchURL "` + server.URL + `/away"}}`,
+			wantErr:  "elsewhere.invalid, which is not in fetch_allow",
+		},
+		{
+			name:     "not http",
+			allow:    []string{"*"},
+			template: `{{fetchURL "file:///etc/passwd"}}`,
+			wantErr:  "must be an http or https URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := NewProcessor("")
+			processor.SetFetchOptions(FetchOptions{Allow: tt.allow, MaxBytes: tt.maxBytes})
+			tmpl := processor.createTestTemplate(t, tt.template)
+
+			result, err := processor.Execute(tmpl, interfaces.TemplateData{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+
+	// Each URL is fetched once per processor
+	processor := NewProcessor("")
+	processor.SetFetchOptions(FetchOptions{Allow: []string{hostname}})
+	requests.Store(0)
+	tmpl := processor.createTestTemplate(t, `{{fetchURL "`+server.URL+`/changelog"}}{{fetchURL "`+server.URL+`/changelog"}}`)
+	if _, err := processor.Execute(tmpl, interfaces.TemplateData{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("fetched %d times, want 1", n)
+	}
+}
@@ -0,0 +1,43 @@
+package template
+
+import (
+	"fmt"
+
+	"prompter-cli/internal/content"
+)
+
+// readFile implements the readFile helper. The file is read as --file reads it:
+// binary files are refused, files over max_file_size_bytes are truncated with a note,
+// and path may end in a line range such as :10-40.
+func (p *Processor) readFile(path string) (string, error) {
+	options := p.contentOptions
+	options.Tokenizer = p.tokenizer
+	files, skipped, err := content.NewCollector(options).Collect([]string{path}, "")
+	if err != nil {
+		return "", fmt.Errorf("readFile %s: %w", path, err)
+	}
+	if len(skipped) > 0 {
+		return "", fmt.Errorf("readFile %s: %s", path, skipped[0].Reason)
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("readFile %s: not found", path)
+	}
+
+	file := files[0]
+	if file.Truncated {
+		return file.Content + fmt.Sprintf("\n(truncated: %s)", file.Note), nil
+	}
+	return file.Content, nil
+}
+
+// glob implements the glob helper, listing the files under the current directory
+// that match pattern and aren't ignored or excluded
+func (p *Processor) glob(pattern string) ([]string, error) {
+	options := p.contentOptions
+	options.Context = p.context()
+	matches, err := content.Glob(".", pattern, options)
+	if err != nil {
+		return nil, fmt.Errorf("glob %s: %w", pattern, err)
+	}
+	return matches, nil
+}
@@ -0,0 +1,99 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompter-cli/internal/content"
+	"prompter-cli/internal/interfaces"
+)
+
+func TestFileHelpers(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"docs/ARCHITECTURE.md": "# Architecture\nLayers.\n",
+		"proto/user.proto":     "message User {}\n",
+		"proto/order.proto":    "message Order {}\n",
+		"vendor/dep/dep.proto": "message Dep {}\n",
+		"big.txt":              strings.Repeat("line of text\n", 100),
+		"image.png":            "\x89PNG\x00\x00",
+	}
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	processor := NewProcessor("")
+	processor.SetContentOptions(content.Options{Strategy: "filesystem", MaxFileSize: 200, Exclude: []string{"vendor/"}})
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "read a file",
+			template: `{{readFile "docs/ARCHITECTURE.md"}}`,
+			expected: "# Architecture\nLayers.\n",
+		},
+		{
+			name:     "read a line range",
+			template: `{{readFile "docs/ARCHITECTURE.md:2"}}`,
+			expected: "...\nLayers.\n\n(truncated: showing lines 2 of 2)",
+		},
+		{
+			name:     "glob skips excluded files",
+			template: `{{range glob "**/*.proto"}}{{.}}: {{readFile .}}{{end}}`,
+			expected: "proto/order.proto: message Order {}\nproto/user.proto: message User {}\n",
+		},
+		{
+			name:     "missing file",
+			template: `{{readFile "nope.md"}}`,
+			wantErr:  true,
+		},
+		{
+			name:     "binary file",
+			template: `{{readFile "image.png"}}`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := processor.createTestTemplate(t, tt.template)
+
+			result, err := processor.Execute(tmpl, interfaces.TemplateData{})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+
+	// Files over the size limit are truncated like included files
+	tmpl := processor.createTestTemplate(t, `{{readFile "big.txt"}}`)
+	result, err := processor.Execute(tmpl, interfaces.TemplateData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) > 300 || !strings.Contains(result, "(truncated: ") {
+		t.Errorf("expected a truncated file, got %d bytes: %q", len(result), result)
+	}
+}
@@ -0,0 +1,246 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/pkg/models"
+)
+
+// frontmatterDelimiter opens and closes the YAML block at the top of a template
+const frontmatterDelimiter = "---"
+
+// Metadata is the optional YAML frontmatter of a template:
+//
+//	---
+//	description: Review code for bugs
+//	tags: [review, quality]
+//	system: true
+//	variables:
+//	  - name: language
+//	    description: Language of the code under review
+//	    required: true
+//	  - name: tone
+//	    prompt: How blunt should the review be?
+//	    type: choice
+//	    options: [gentle, direct]
+//	    default: direct
+//	settings:
+//	  directory_strategy: git
+//	  max_tokens: 20000
+//	  target: stdout
+//	  post: checklist
+//	---
+type Metadata struct {
+	Description string      `yaml:"description"`
+	Tags        []string    `yaml:"tags"`
+	System      bool        `yaml:"system"` // As a pre-template, sent as the system prompt to model targets
+	Variables   []Variable  `yaml:"variables"`
+	Settings    Settings    `yaml:"settings"`
+	Provenance  *Provenance `yaml:"provenance"` // Where the template was installed from, set by templates install
+}
+
+// Settings are request settings a template applies when it is selected, for templates
+// that only make sense with certain context rules. Flags given for the run still take
+// precedence, and the template's settings take precedence over the config.
+type Settings struct {
+	DirectoryStrategy string `yaml:"directory_strategy"` // "git" or "filesystem"
+	MaxTokens         int    `yaml:"max_tokens"`         // Token budget for embedded content
+	Target            string `yaml:"target"`
+	Post              string `yaml:"post"` // Post-template, or several separated by commas, added to the run
+}
+
+// Variable types accepted in frontmatter. Values are always passed to templates as strings.
+const (
+	VariableString = "string"
+	VariableNumber = "number"
+	VariableBool   = "bool"   // "true" or "false"
+	VariableChoice = "choice" // One of Options
+)
+
+// Variable is a value a template reads from .Vars
+type Variable struct {
+	Name        string   `yaml:"name"`
+	Prompt      string   `yaml:"prompt"` // Question asked interactively, defaults to the name
+	Description string   `yaml:"description"`
+	Type        string   `yaml:"type"` // One of the Variable* types, empty for string
+	Options     []string `yaml:"options"`
+	Default     string   `yaml:"default"`
+	Required    bool     `yaml:"required"`
+}
+
+// ParseFrontmatter splits a template into its frontmatter and body. Templates
+// without frontmatter return empty metadata and the content unchanged.
+func ParseFrontmatter(content []byte) (*Metadata, []byte, error) {
+	meta := &Metadata{}
+
+	firstLine, rest, found := bytes.Cut(content, []byte("\n"))
+	if !found || strings.TrimSpace(string(firstLine)) != frontmatterDelimiter {
+		return meta, content, nil
+	}
+
+	// Find the closing delimiter on a line of its own
+	var block []byte
+	closed := false
+	for len(rest) > 0 {
+		var line []byte
+		line, rest, _ = bytes.Cut(rest, []byte("\n"))
+		if strings.TrimSpace(string(line)) == frontmatterDelimiter {
+			closed = true
+			break
+		}
+		block = append(block, line...)
+		block = append(block, '\n')
+	}
+	if !closed {
+		return nil, nil, fmt.Errorf("frontmatter is not closed with %q", frontmatterDelimiter)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(block))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(meta); err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, fmt.Errorf("invalid frontmatter: %w", err)
+	}
+
+	if err := meta.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	return meta, rest, nil
+}
+
+// validate checks that every declared variable has a unique name and known type, and
+// that the settings are valid
+func (m *Metadata) validate() error {
+	if err := m.Settings.validate(); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, variable := range m.Variables {
+		if variable.Name == "" {
+			return fmt.Errorf("invalid frontmatter: variable without a name")
+		}
+		if seen[variable.Name] {
+			return fmt.Errorf("invalid frontmatter: variable %s is declared twice", variable.Name)
+		}
+		seen[variable.Name] = true
+
+		switch variable.Type {
+		case "", VariableString, VariableNumber, VariableBool:
+		case VariableChoice:
+			if len(variable.Options) == 0 {
+				return fmt.Errorf("invalid frontmatter: choice variable %s has no options", variable.Name)
+			}
+		default:
+			return fmt.Errorf("invalid frontmatter: variable %s has unknown type %q (must be string, number, bool, or choice)", variable.Name, variable.Type)
+		}
+	}
+	return nil
+}
+
+// validate checks the settings take values the request accepts
+func (s Settings) validate() error {
+	if s.DirectoryStrategy != "" && s.DirectoryStrategy != "git" && s.DirectoryStrategy != "filesystem" {
+		return fmt.Errorf("invalid frontmatter: settings.directory_strategy %q (must be 'git' or 'filesystem')", s.DirectoryStrategy)
+	}
+	if s.MaxTokens < 0 {
+		return fmt.Errorf("invalid frontmatter: settings.max_tokens %d (must be 0 for the configured budget or positive)", s.MaxTokens)
+	}
+	if s.Target != "" && !models.ValidTarget(s.Target) {
+		return fmt.Errorf("invalid frontmatter: settings.target %q (must be %s)", s.Target, models.TargetUsage)
+	}
+	if reason := untrustedTarget(s.Target); reason != "" {
+		return fmt.Errorf("invalid frontmatter: settings.target %q (%s; choose it with --target instead)", s.Target, reason)
+	}
+	return nil
+}
+
+// untrustedTarget returns why a template, which may come from anywhere, can't choose
+// target: it can't have the prompt posted or handed to a plugin, or written to a file
+// outside the project. It returns "" for targets a template may choose.
+func untrustedTarget(target string) string {
+	switch {
+	case strings.HasPrefix(target, models.TargetHTTPPrefix):
+		return "templates can't post prompts to a webhook"
+	case strings.HasPrefix(target, models.TargetPluginPrefix):
+		return "templates can't send prompts to a plugin"
+	}
+	for _, prefix := range []string{models.TargetAppendPrefix, models.TargetFilePrefix} {
+		if !strings.HasPrefix(target, prefix) {
+			continue
+		}
+		file := strings.TrimPrefix(target, prefix)
+		cleaned := path.Clean(filepath.ToSlash(file))
+		if filepath.IsAbs(file) || path.IsAbs(cleaned) || strings.HasPrefix(file, "~") || strings.Contains(file, "$") || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			return "templates may only write files within the project"
+		}
+	}
+	return ""
+}
+
+// ApplyVariables returns data with declared defaults filled into Vars, or an error
+// listing the required variables that have no value. The caller's Vars map is not modified.
+func (m *Metadata) ApplyVariables(data interfaces.TemplateData) (interfaces.TemplateData, error) {
+	if m == nil || len(m.Variables) == 0 {
+		return data, nil
+	}
+
+	vars := make(map[string]string, len(data.Vars)+len(m.Variables))
+	for name, value := range data.Vars {
+		vars[name] = value
+	}
+
+	var missing []string
+	for _, variable := range m.Variables {
+		if value, ok := vars[variable.Name]; ok {
+			if err := variable.check(value); err != nil {
+				return data, err
+			}
+			continue
+		}
+		if variable.Required {
+			missing = append(missing, variable.Name)
+			continue
+		}
+		vars[variable.Name] = variable.Default
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return data, fmt.Errorf("missing required variables: %s", strings.Join(missing, ", "))
+	}
+
+	data.Vars = vars
+	return data, nil
+}
+
+// check reports whether value is valid for the variable's type
+func (v Variable) check(value string) error {
+	switch v.Type {
+	case VariableNumber:
+		if _, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+			return fmt.Errorf("variable %s must be a number, got %q", v.Name, value)
+		}
+	case VariableBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("variable %s must be true or false, got %q", v.Name, value)
+		}
+	case VariableChoice:
+		for _, option := range v.Options {
+			if value == option {
+				return nil
+			}
+		}
+		return fmt.Errorf("variable %s must be one of %s, got %q", v.Name, strings.Join(v.Options, ", "), value)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompter-cli/internal/interfaces"
+)
+
+func TestParseFrontmatter(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantBody  string
+		wantDesc  string
+		wantVars  int
+		wantError string
+	}{
+		{
+			name:     "no frontmatter",
+			content:  "Hello {{.Prompt}}",
+			wantBody: "Hello {{.Prompt}}",
+		},
+		{
+			name:     "full frontmatter",
+			content:  "---\ndescription: Review code\ntags: [review]\nvariables:\n  - name: language\n    required: true\n  - name: tone\n    default: direct\n---\nBody\n",
+			wantBody: "Body\n",
+			wantDesc: "Review code",
+			wantVars: 2,
+		},
+		{
+			name:     "empty frontmatter with CRLF line endings",
+			content:  "---\r\n---\r\nBody",
+			wantBody: "Body",
+		},
+		{
+			name:      "unclosed",
+			content:   "---\ndescription: x\nBody",
+			wantError: "not closed",
+		},
+		{
+			name:      "unknown field",
+			content:   "---\ndescripton: typo\n---\nBody",
+			wantError: "invalid frontmatter",
+		},
+		{
+			name:      "choice without options",
+			content:   "---\nvariables:\n  - name: tone\n    type: choice\n---\n",
+			wantError: "no options",
+		},
+		{
+			name:      "unknown variable type",
+			content:   "---\nvariables:\n  - name: n\n    type: integer\n---\n",
+			wantError: "unknown type",
+		},
+		{
+			name:      "duplicate variable",
+			content:   "---\nvariables:\n  - name: a\n  - name: a\n---\n",
+			wantError: "declared twice",
+		},
+		{
+			name:     "settings",
+			content:  "---\nsettings:\n  directory_strategy: git\n  max_tokens: 2000\n  target: stdout\n  post: checklist\n---\nBody",
+			wantBody: "Body",
+		},
+		{
+			name:      "invalid settings target",
+			content:   "---\nsettings:\n  target: printer\n---\n",
+			wantError: "settings.target",
+		},
+		{
+			name:      "webhook settings target",
+			content:   "---\nsettings:\n  target: http:https://example.com/collect\n---\n",
+			wantError: "can't post prompts to a webhook",
+		},
+		{
+			name:      "plugin settings target",
+			content:   "---\nsettings:\n  target: plugin:upload\n---\n",
+			wantError: "can't send prompts to a plugin",
+		},
+		{
+			name:      "file settings target outside the project",
+			content:   "---\nsettings:\n  target: file+:~/.bashrc\n---\n",
+			wantError: "within the project",
+		},
+		{
+			name:      "file settings target escaping the project",
+			content:   "---\nsettings:\n  target: file:notes/../../outside.md\n---\n",
+			wantError: "within the project",
+		},
+		{
+			name:     "file settings target in the project",
+			content:  "---\nsettings:\n  target: file:notes/{{.Pre}}.md\n---\nBody",
+			wantBody: "Body",
+		},
+		{
+			name:      "invalid settings strategy",
+			content:   "---\nsettings:\n  directory_strategy: svn\n---\n",
+			wantError: "settings.directory_strategy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, body, err := ParseFrontmatter([]byte(tt.content))
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+			if meta.Description != tt.wantDesc || len(meta.Variables) != tt.wantVars {
+				t.Errorf("unexpected metadata %+v", meta)
+			}
+		})
+	}
+}
+
+func TestProcessor_FrontmatterVariables(t *testing.T) {
+	tempDir := t.TempDir()
+	preDir := filepath.Join(tempDir, "pre")
+	if err := os.MkdirAll(preDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "---\ndescription: Review code\nvariables:\n  - name: language\n    required: true\n  - name: tone\n    default: direct\n---\nReview this {{.Vars.language}} code, be {{.Vars.tone}}."
+	if err := os.WriteFile(filepath.Join(preDir, "review.md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	processor := NewProcessor(tempDir)
+
+	meta, err := processor.TemplateMetadata("review")
+	if err != nil || meta.Description != "Review code" {
+		t.Fatalf("TemplateMetadata = %+v, %v", meta, err)
+	}
+
+	tmpl, err := processor.LoadTemplate("review")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := processor.Execute(tmpl, interfaces.TemplateData{}); err == nil || !strings.Contains(err.Error(), "language") {
+		t.Errorf("expected missing required variable error, got %v", err)
+	}
+
+	vars := map[string]string{"language": "Go"}
+	result, err := processor.Execute(tmpl, interfaces.TemplateData{Vars: vars})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "Review this Go code, be direct." {
+		t.Errorf("unexpected result %q", result)
+	}
+	if len(vars) != 1 {
+		t.Errorf("defaults should not be written into the caller's vars, got %v", vars)
+	}
+}
+
+func TestMetadata_ApplyVariablesChecksTypes(t *testing.T) {
+	meta := &Metadata{Variables: []Variable{
+		{Name: "count", Type: VariableNumber},
+		{Name: "tone", Type: VariableChoice, Options: []string{"gentle", "direct"}},
+		{Name: "strict", Type: VariableBool, Default: "false"},
+	}}
+
+	data, err := meta.ApplyVariables(interfaces.TemplateData{Vars: map[string]string{"count": "3", "tone": "direct"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data.Vars["strict"] != "false" {
+		t.Errorf("expected bool default, got %v", data.Vars)
+	}
+
+	for _, vars := range []map[string]string{{"count": "three"}, {"tone": "harsh"}, {"strict": "maybe"}} {
+		if _, err := meta.ApplyVariables(interfaces.TemplateData{Vars: vars}); err == nil {
+			t.Errorf("expected an error for %v", vars)
+		}
+	}
+}
+
+func TestProcessor_TemplateVariables(t *testing.T) {
+	tempDir := t.TempDir()
+	preDir := filepath.Join(tempDir, "pre")
+	if err := os.MkdirAll(preDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "---\nvariables:\n  - name: tone\n    prompt: How blunt?\n---\n{{.Vars.ticket}} {{if .Vars.tone}}{{range .Files}}{{$.Vars.team}}{{end}}{{end}} {{.Vars.ticket}}"
+	if err := os.WriteFile(filepath.Join(preDir, "ticket.md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	variables, err := NewProcessor(tempDir).TemplateVariables("ticket")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, variable := range variables {
+		names = append(names, variable.Name)
+	}
+	if strings.Join(names, ",") != "tone,ticket,team" {
+		t.Errorf("expected declared then referenced variables, got %v", names)
+	}
+	if variables[0].Prompt != "How blunt?" {
+		t.Errorf("expected declared prompt to be kept, got %+v", variables[0])
+	}
+}
+
+func TestScaffold(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "post"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "post", "checks.md"), []byte(Scaffold("checks", "post")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	processor := NewProcessor(dir)
+	tmpl, err := processor.LoadTemplate("checks")
+	if err != nil {
+		t.Fatalf("scaffold should load: %v", err)
+	}
+	if meta := processor.metadata[tmpl]; len(meta.Variables) != 0 || meta.Description != "" {
+		t.Errorf("commented frontmatter should declare nothing, got %+v", meta)
+	}
+
+	result, err := processor.Execute(tmpl, interfaces.TemplateData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "# checks\n" {
+		t.Errorf("unexpected scaffold output %q", result)
+	}
+}
@@ -0,0 +1,323 @@
+package template
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"prompter-cli/internal/interfaces"
+)
+
+// Helper sources reported in helper documentation
+const (
+	HelperSourceBuiltin = "built-in"
+	HelperSourceSprig   = "sprig"
+	HelperSourceWasm    = "wasm"
+	HelperSourceProcess = "process"
+	HelperSourcePlugin  = "plugin"
+)
+
+// HelperDoc documents a template function
+type HelperDoc struct {
+	Name        string `json:"name"`
+	Signature   string `json:"signature"`
+	Description string `json:"description"`
+	Example     string `json:"example,omitempty"`
+	Source      string `json:"source"`
+}
+
+// helper pairs a template function with its documentation
+type helper struct {
+	HelperDoc
+	fn   interface{}
+	bind func(p *Processor) interface{} // Builds the function for helpers that need processor state
+}
+
+// builtinHelpers is the registry of helpers implemented by prompter itself
+var builtinHelpers = []helper{
+	{
+		HelperDoc: HelperDoc{
+			Name:        "truncate",
+			Signature:   "truncate LENGTH TEXT",
+			Description: "Shortens TEXT to at most LENGTH characters, ending with \"...\" when cut.",
+			Example:     `{{truncate 12 "a fairly long sentence"}}`,
+		},
+		fn: truncateFunc,
+	},
+	{
+		HelperDoc: HelperDoc{
+			Name:        "mdFence",
+			Signature:   "mdFence LANGUAGE CONTENT",
+			Description: "Wraps CONTENT in a markdown code fence tagged with LANGUAGE (may be empty).",
+			Example:     `{{mdFence "go" "fmt.Println(\"hi\")"}}`,
+		},
+		fn: mdFenceFunc,
+	},
+	{
+		HelperDoc: HelperDoc{
+			Name:        "indent",
+			Signature:   "indent SPACES TEXT",
+			Description: "Indents every non-empty line of TEXT by SPACES spaces.",
+			Example:     `{{indent 4 "first\nsecond"}}`,
+		},
+		fn: indentFunc,
+	},
+	{
+		HelperDoc: HelperDoc{
+			Name:        "dedent",
+			Signature:   "dedent TEXT",
+			Description: "Removes the leading whitespace common to every line of TEXT.",
+			Example:     `{{dedent "    first\n      second"}}`,
+		},
+		fn: dedentFunc,
+	},
+	{
+		HelperDoc: HelperDoc{
+			Name:        "slug",
+			Signature:   "slug TEXT",
+			Description: "Turns TEXT into a lower case, hyphenated name for files and URLs, at most 60 characters.",
+			Example:     `{{slug "Why does the parser panic?"}}`,
+		},
+		fn: slugFunc,
+	},
+	{
+		HelperDoc: HelperDoc{
+			Name:        "xmlTag",
+			Signature:   "xmlTag NAME CONTENT [ATTR VALUE]...",
+			Description: "Wraps CONTENT in a NAME tag on lines of its own, with escaped attribute values, for tag-delimited context in prompts for Claude.",
+			Example:     `{{xmlTag "document" "Use tabs." "source" "CONTRIBUTING.md"}}`,
+		},
+		fn: xmlTagFunc,
+	},
+	{
+		HelperDoc: HelperDoc{
+			Name:        "xmlEscape",
+			Signature:   "xmlEscape TEXT",
+			Description: "Escapes &, <, >, and quotes in TEXT so it can't break surrounding XML tags.",
+			Example:     `{{xmlEscape "a < b && c"}}`,
+		},
+		fn: xmlEscapeFunc,
+	},
+	{
+		HelperDoc: HelperDoc{
+			Name:        "mdTable",
+			Signature:   "mdTable ITEMS [COLUMN]...",
+			Description: "Renders a list of structs or maps as a markdown table of the named fields or keys (every field when none are named); a map without columns becomes a table of its keys and values.",
+			Example:     `{{mdTable (list (dict "Path" "main.go" "Lines" 42)) "Path" "Lines"}}`,
+		},
+		fn: mdTableFunc,
+	},
+	{
+		HelperDoc: HelperDoc{
+			Name:        "mdList",
+			Signature:   "mdList ITEMS [FIELD]",
+			Description: "Renders a list as markdown bullets, or a map as \"key: value\" bullets sorted by key. With FIELD, each bullet shows that field of the element.",
+			Example:     `{{mdList (list "parse config" "add tests")}}`,
+		},
+		fn: mdListFunc,
+	},
+	{
+		HelperDoc: HelperDoc{
+			Name:        "readFile",
+			Signature:   "readFile PATH",
+			Description: "Reads the file at PATH (relative to the current directory, optionally with a :start-end line range), truncated to max_file_size_bytes like included files.",
+			Example:     "",
+		},
+		bind: func(p *Processor) interface{} { return p.readFile },
+	},
+	{
+		HelperDoc: HelperDoc{
+			Name:        "glob",
+			Signature:   "glob PATTERN",
+			Description: "Lists the files under the current directory matching PATTERN, where **/ matches any number of directories. Ignored and excluded files are left out.",
+			Example:     "",
+		},
+		bind: func(p *Processor) interface{} { return p.glob },
+	},
+	{
+		HelperDoc: HelperDoc{
+			Name:        "exec",
+			Signature:   "exec COMMAND",
+			Description: "Runs COMMAND with sh -c and returns its output, followed by the exit status when it fails. Only available with allow_exec = true; limited by exec_timeout_ms and exec_max_bytes.",
+			Example:     "",
+		},
+		bind: func(p *Processor) interface{} { return p.execCommand },
+	},
+	{
+		HelperDoc: HelperDoc{
+			Name:        "fetchURL",
+			Signature:   "fetchURL URL",
+			Description: "Fetches URL and returns the body of its text response. Only URLs matched by fetch_allow are fetched; limited by fetch_timeout_ms and fetch_max_bytes.",
+			Example:     "",
+		},
+		bind: func(p *Processor) interface{} { return p.fetchURL },
+	},
+	{
+		HelperDoc: HelperDoc{
+			Name:        "tokens",
+			Signature:   "tokens VALUE",
+			Description: "Counts the tokens in VALUE with the configured tokenizer. VALUE may be text, a file, a list of files, or the template data (prompt plus files).",
+			Example:     `{{tokens "How many tokens is this?"}}`,
+		},
+		bind: func(p *Processor) interface{} { return p.countTokens },
+	},
+}
+
+// sprigHelperDocs documents the commonly used subset of the Sprig function library.
+// Every Sprig function is available in templates; see https://masterminds.github.io/sprig/.
+var sprigHelperDocs = []HelperDoc{
+	{Name: "upper", Signature: "upper TEXT", Description: "Converts TEXT to upper case.", Example: `{{upper "hello"}}`},
+	{Name: "lower", Signature: "lower TEXT", Description: "Converts TEXT to lower case.", Example: `{{lower "HELLO"}}`},
+	{Name: "title", Signature: "title TEXT", Description: "Converts TEXT to title case.", Example: `{{title "hello world"}}`},
+	{Name: "trim", Signature: "trim TEXT", Description: "Removes leading and trailing whitespace.", Example: `{{trim "  hello  "}}`},
+	{Name: "replace", Signature: "replace OLD NEW TEXT", Description: "Replaces every OLD in TEXT with NEW.", Example: `{{replace "-" " " "a-b-c"}}`},
+	{Name: "contains", Signature: "contains SUBSTR TEXT", Description: "Reports whether TEXT contains SUBSTR.", Example: `{{contains "ell" "hello"}}`},
+	{Name: "default", Signature: "default FALLBACK VALUE", Description: "Returns FALLBACK when VALUE is empty.", Example: `{{default "none" ""}}`},
+	{Name: "join", Signature: "join SEP LIST", Description: "Joins LIST elements with SEP.", Example: `{{join ", " (list "a" "b")}}`},
+	{Name: "splitList", Signature: "splitList SEP TEXT", Description: "Splits TEXT into a list on SEP.", Example: `{{splitList "," "a,b" | len}}`},
+	{Name: "list", Signature: "list VALUES...", Description: "Builds a list from its arguments.", Example: `{{list 1 2 3 | len}}`},
+	{Name: "now", Signature: "now", Description: "Returns the current time.", Example: `{{now | date "2006"}}`},
+	{Name: "date", Signature: "date FORMAT TIME", Description: "Formats TIME using a Go layout string.", Example: `{{date "2006-01-02" now}}`},
+	{Name: "toJson", Signature: "toJson VALUE", Description: "Encodes VALUE as JSON.", Example: `{{toJson (list "a" "b")}}`},
+	{Name: "nindent", Signature: "nindent SPACES TEXT", Description: "Like indent but prefixes a newline.", Example: `{{nindent 2 "item"}}`},
+	{Name: "env", Signature: "env NAME", Description: "Reads an environment variable.", Example: ""},
+}
+
+// helperFuncs returns all helper functions available to templates
+func (p *Processor) helperFuncs() template.FuncMap {
+	// Start with sprig functions
+	funcMap := sprig.TxtFuncMap()
+
+	// Add built-in helper functions
+	for _, h := range builtinHelpers {
+		if h.bind != nil {
+			funcMap[h.Name] = h.bind(p)
+		} else {
+			funcMap[h.Name] = h.fn
+		}
+	}
+
+	// Add helpers exported by WebAssembly plugins
+	if len(p.wasmPlugins) > 0 {
+		if p.wasm == nil {
+			p.wasm = newWasmRuntime(context.Background())
+		}
+		for name, fn := range p.wasm.funcMap(p.context, p.wasmPlugins) {
+			funcMap[name] = fn
+		}
+	}
+
+	// Add functions provided by plugins
+	for _, name := range p.plugins.FunctionNames() {
+		provider, _ := p.plugins.Function(name)
+		functionName := name
+		funcMap[name] = func(args ...interface{}) (string, error) {
+			return provider.Function(p.context(), functionName, args)
+		}
+	}
+
+	// Add helpers backed by external processes
+	if len(p.helpers) > 0 {
+		if p.processHelpers == nil {
+			p.processHelpers = newProcessHelpers()
+		}
+		for name, fn := range p.processHelpers.funcMap(p.context, p.helpers) {
+			funcMap[name] = fn
+		}
+	}
+
+	return funcMap
+}
+
+// HelperDocs returns documentation for every registered helper: built-ins, the
+// documented Sprig subset, and helpers provided by plugins, sorted by source then name
+func (p *Processor) HelperDocs() []HelperDoc {
+	var docs []HelperDoc
+
+	for _, h := range builtinHelpers {
+		doc := h.HelperDoc
+		doc.Source = HelperSourceBuiltin
+		docs = append(docs, doc)
+	}
+
+	for _, doc := range sprigHelperDocs {
+		doc.Source = HelperSourceSprig
+		docs = append(docs, doc)
+	}
+
+	for name, plugin := range p.wasmPlugins {
+		for _, function := range plugin.Functions {
+			docs = append(docs, HelperDoc{
+				Name:        function,
+				Signature:   function + " ARGS...",
+				Description: fmt.Sprintf("Provided by WebAssembly plugin %q (%s).", name, plugin.Path),
+				Source:      HelperSourceWasm,
+			})
+		}
+	}
+
+	for name, command := range p.helpers {
+		docs = append(docs, HelperDoc{
+			Name:        name,
+			Signature:   name + " ARGS...",
+			Description: fmt.Sprintf("Runs %s with the arguments as JSON on stdin.", describeCommand(command)),
+			Source:      HelperSourceProcess,
+		})
+	}
+
+	if p.plugins != nil {
+		for _, provider := range p.plugins.Plugins {
+			for _, function := range provider.Functions {
+				description := function.Description
+				if description == "" {
+					description = fmt.Sprintf("Provided by plugin %q (%s).", provider.Name, provider.Path)
+				}
+				docs = append(docs, HelperDoc{
+					Name:        function.Name,
+					Signature:   function.Name + " ARGS...",
+					Description: description,
+					Source:      HelperSourcePlugin,
+				})
+			}
+		}
+	}
+
+	sourceOrder := map[string]int{
+		HelperSourceBuiltin: 0,
+		HelperSourceSprig:   1,
+		HelperSourceWasm:    2,
+		HelperSourceProcess: 3,
+		HelperSourcePlugin:  4,
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		if docs[i].Source != docs[j].Source {
+			return sourceOrder[docs[i].Source] < sourceOrder[docs[j].Source]
+		}
+		return docs[i].Name < docs[j].Name
+	})
+
+	return docs
+}
+
+// RenderExample renders a helper example against empty template data
+func (p *Processor) RenderExample(example string) (string, error) {
+	tmpl := template.New("example")
+	if err := p.registerHelpersToTemplate(tmpl); err != nil {
+		return "", err
+	}
+
+	tmpl, err := tmpl.Parse(example)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse example: %w", err)
+	}
+
+	return p.Execute(tmpl, interfaces.TemplateData{})
+}
+
+// describeCommand formats a helper command line for documentation
+func describeCommand(command interfaces.HelperCommand) string {
+	return strings.TrimSpace(command.Command + " " + strings.Join(command.Args, " "))
+}
@@ -0,0 +1,80 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/sprig/v3"
+	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/tokenizer"
+)
+
+func TestProcessor_HelperDocs(t *testing.T) {
+	processor := NewProcessor(t.TempDir())
+	processor.SetHelpers(map[string]interfaces.HelperCommand{
+		"jira": {Command: "prompter-helper-jira"},
+	})
+
+	funcs := processor.helperFuncs()
+	sprigFuncs := sprig.TxtFuncMap()
+
+	seen := make(map[string]bool)
+	for _, doc := range processor.HelperDocs() {
+		seen[doc.Name] = true
+
+		if _, ok := funcs[doc.Name]; !ok {
+			t.Errorf("documented helper %q is not registered", doc.Name)
+		}
+		if doc.Source == HelperSourceSprig {
+			if _, ok := sprigFuncs[doc.Name]; !ok {
+				t.Errorf("helper %q is documented as sprig but sprig doesn't provide it", doc.Name)
+			}
+		}
+		if doc.Example == "" || doc.Source == HelperSourceProcess {
+			continue
+		}
+		if _, err := processor.RenderExample(doc.Example); err != nil {
+			t.Errorf("example for %q failed to render: %v", doc.Name, err)
+		}
+	}
+
+	for _, name := range []string{"truncate", "mdFence", "indent", "dedent", "slug", "xmlTag", "xmlEscape", "mdTable", "mdList", "readFile", "glob", "exec", "fetchURL", "tokens", "jira"} {
+		if !seen[name] {
+			t.Errorf("expected helper %q to be documented", name)
+		}
+	}
+}
+
+func TestProcessor_TokensHelper(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "pre"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	body := "{{tokens .Prompt}} {{tokens .Files}} {{tokens .}}"
+	if err := os.WriteFile(filepath.Join(dir, "pre", "budget.md"), []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	processor := NewProcessor(dir)
+	tok, err := tokenizer.New(tokenizer.Bytes, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	processor.SetTokenizer(tok)
+
+	tmpl, err := processor.LoadTemplate("budget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := processor.Execute(tmpl, interfaces.TemplateData{
+		Prompt: "12345678",
+		Files:  []interfaces.FileInfo{{Content: "1234"}, {Content: "12345"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "2 3 5" {
+		t.Errorf("tokens rendered %q, want \"2 3 5\"", result)
+	}
+}
@@ -0,0 +1,419 @@
+package template
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"go.yaml.in/yaml/v3"
+)
+
+// InstallMaxBytes is the largest template or manifest Install accepts
+const InstallMaxBytes = 1 << 20
+
+// Status of a template after Install
+const (
+	InstallAdded     = "installed"
+	InstallUpdated   = "updated"   // Replaced an earlier install from the same URL
+	InstallUnchanged = "unchanged" // Already installed from the same URL with the same content
+	InstallReplaced  = "replaced"  // Overwrote a different template of the same name, with Force
+)
+
+// Provenance records where an installed template came from. Install writes it to the
+// template's frontmatter:
+//
+//	provenance:
+//	  url: https://example.com/prompts/pre/review.md
+//	  bundle: https://example.com/prompts/review.toml
+//	  sha256: 9f86d081...
+//	  installed: "2026-01-02"
+type Provenance struct {
+	URL       string `yaml:"url"`
+	Bundle    string `yaml:"bundle,omitempty"` // Manifest that listed the template
+	SHA256    string `yaml:"sha256"`           // Of the template as downloaded
+	Installed string `yaml:"installed"`        // Date of the install
+}
+
+// Manifest lists the templates of a bundle, as a TOML file:
+//
+//	description = "Code review prompts"
+//
+//	[[templates]]
+//	url = "pre/review.md"  # Relative to the manifest
+//	kind = "pre"           # Defaults to pre
+//	name = "review"        # Defaults to the file name
+type Manifest struct {
+	Description string          `toml:"description"`
+	Templates   []ManifestEntry `toml:"templates"`
+}
+
+// ManifestEntry is a template of a bundle
+type ManifestEntry struct {
+	URL  string `toml:"url"`
+	Kind string `toml:"kind"`
+	Name string `toml:"name"`
+}
+
+// InstallOptions controls where Install writes templates and how it fetches them
+type InstallOptions struct {
+	PromptsLocation string // Templates are written to its pre and post directories
+	Registry        string // URL or directory holding <name>.toml manifests, for sources given by name
+	Kind            string // Kind of a single template, inferred from its URL when empty
+	Name            string // Name of a single template, its file name when empty
+	Force           bool   // Overwrite templates of the same name installed from elsewhere
+	Timeout         time.Duration
+	HTTPClient      *http.Client
+}
+
+// InstallResult is a template Install wrote, or found already installed
+type InstallResult struct {
+	Kind   string
+	Name   string
+	Path   string
+	URL    string
+	Status string
+}
+
+// pendingInstall is a downloaded template waiting for its collisions to be checked
+type pendingInstall struct {
+	InstallResult
+	content    []byte
+	provenance Provenance
+}
+
+// Install downloads a template, or the templates of a bundle, into the prompts
+// location. source is an http(s) URL or local path of a template (.md) or manifest
+// (.toml), or the name of a bundle in the registry. Every template is downloaded and
+// checked before any is written, so a bundle is installed whole or not at all. A
+// template of the same name is only replaced when it was installed from the same URL,
+// or with Force.
+func Install(source string, options InstallOptions) ([]InstallResult, error) {
+	location, err := options.locate(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []*pendingInstall
+	if strings.HasSuffix(strings.ToLower(locationPath(location)), ".toml") {
+		if options.Name != "" || options.Kind != "" {
+			return nil, fmt.Errorf("a name or kind can only be given for a single template, not the bundle %s", location)
+		}
+		if pending, err = options.downloadBundle(location); err != nil {
+			return nil, err
+		}
+	} else {
+		kind := options.Kind
+		if kind == "" {
+			kind = kindFromLocation(location)
+		}
+		name := options.Name
+		if name == "" {
+			name = strings.TrimSuffix(path.Base(locationPath(location)), ".md")
+		}
+		entry, err := options.download(location, kind, name)
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, entry)
+	}
+
+	seen := make(map[string]bool, len(pending))
+	for _, entry := range pending {
+		entry.Path = filepath.Join(options.PromptsLocation, entry.Kind, filepath.FromSlash(entry.Name)+".md")
+		if seen[entry.Path] {
+			return nil, fmt.Errorf("bundle lists the %s template %s twice", entry.Kind, entry.Name)
+		}
+		seen[entry.Path] = true
+		if err := entry.checkCollision(options.Force); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]InstallResult, 0, len(pending))
+	for _, entry := range pending {
+		if entry.Status != InstallUnchanged {
+			if err := os.MkdirAll(filepath.Dir(entry.Path), 0755); err != nil {
+				return results, fmt.Errorf("failed to create template directory: %w", err)
+			}
+			if err := os.WriteFile(entry.Path, withProvenance(entry.content, entry.provenance), 0644); err != nil {
+				return results, fmt.Errorf("failed to write template file: %w", err)
+			}
+		}
+		results = append(results, entry.InstallResult)
+	}
+	return results, nil
+}
+
+// locate turns an install source into the URL or path of a template or manifest
+func (o InstallOptions) locate(source string) (string, error) {
+	source = strings.TrimSpace(source)
+	switch {
+	case source == "":
+		return "", fmt.Errorf("no template to install")
+	case isRemote(source):
+		return source, nil
+	case strings.ContainsAny(source, `/\`) || strings.HasSuffix(source, ".md") || strings.HasSuffix(source, ".toml"):
+		return filepath.Abs(source)
+	}
+
+	// A name from the registry
+	if !ValidTemplateName(source) {
+		return "", fmt.Errorf("invalid template name %q", source)
+	}
+	if o.Registry == "" {
+		return "", fmt.Errorf("%q is not a URL or path, and no template_registry is configured to look it up in", source)
+	}
+	if isRemote(o.Registry) {
+		return strings.TrimRight(o.Registry, "/") + "/" + url.PathEscape(source) + ".toml", nil
+	}
+	return filepath.Join(o.Registry, source+".toml"), nil
+}
+
+// downloadBundle downloads the manifest at location and every template it lists
+func (o InstallOptions) downloadBundle(location string) ([]*pendingInstall, error) {
+	data, err := o.read(location)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	decoder := toml.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid bundle manifest %s: %w", location, err)
+	}
+	if len(manifest.Templates) == 0 {
+		return nil, fmt.Errorf("bundle manifest %s lists no templates", location)
+	}
+
+	pending := make([]*pendingInstall, 0, len(manifest.Templates))
+	for i, entry := range manifest.Templates {
+		if entry.URL == "" {
+			return nil, fmt.Errorf("bundle manifest %s: templates[%d]: url is required", location, i)
+		}
+		kind := entry.Kind
+		if kind == "" {
+			kind = "pre"
+		}
+		templateLocation, err := resolveLocation(location, entry.URL)
+		if err != nil {
+			return nil, fmt.Errorf("bundle manifest %s: templates[%d]: %w", location, i, err)
+		}
+		name := entry.Name
+		if name == "" {
+			name = strings.TrimSuffix(path.Base(locationPath(templateLocation)), ".md")
+		}
+
+		installed, err := o.download(templateLocation, kind, name)
+		if err != nil {
+			return nil, err
+		}
+		installed.provenance.Bundle = location
+		pending = append(pending, installed)
+	}
+	return pending, nil
+}
+
+// download downloads the template at location and checks its frontmatter
+func (o InstallOptions) download(location, kind, name string) (*pendingInstall, error) {
+	if kind != "pre" && kind != "post" {
+		return nil, fmt.Errorf("invalid kind %q for %s (must be pre or post)", kind, location)
+	}
+	if !ValidTemplateName(name) {
+		return nil, fmt.Errorf("invalid template name %q for %s", name, location)
+	}
+
+	content, err := o.read(location)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := ParseFrontmatter(content); err != nil {
+		return nil, fmt.Errorf("%s: %w", location, err)
+	}
+
+	sum := sha256.Sum256(content)
+	return &pendingInstall{
+		InstallResult: InstallResult{Kind: kind, Name: name, URL: location, Status: InstallAdded},
+		content:       content,
+		provenance: Provenance{
+			URL:       location,
+			SHA256:    hex.EncodeToString(sum[:]),
+			Installed: time.Now().Format("2006-01-02"),
+		},
+	}, nil
+}
+
+// checkCollision decides what happens to a template already at the entry's path
+func (p *pendingInstall) checkCollision(force bool) error {
+	existing, err := os.ReadFile(p.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", p.Path, err)
+	}
+
+	meta, _, err := ParseFrontmatter(existing)
+	if err == nil && meta.Provenance != nil && meta.Provenance.URL == p.provenance.URL {
+		p.Status = InstallUpdated
+		if meta.Provenance.SHA256 == p.provenance.SHA256 {
+			p.Status = InstallUnchanged
+		}
+		return nil
+	}
+	if !force {
+		from := "was not installed"
+		if err == nil && meta.Provenance != nil {
+			from = "was installed from " + meta.Provenance.URL
+		}
+		return fmt.Errorf("%s template %s already exists at %s and %s (use --force to replace it, or --name to install under another name)", p.Kind, p.Name, p.Path, from)
+	}
+	p.Status = InstallReplaced
+	return nil
+}
+
+// read returns the content of a URL or local file, up to InstallMaxBytes
+func (o InstallOptions) read(location string) ([]byte, error) {
+	var body io.Reader
+	if isRemote(location) {
+		timeout := o.Timeout
+		if timeout <= 0 {
+			timeout = DefaultFetchTimeout
+		}
+		client := http.Client{Timeout: timeout}
+		if o.HTTPClient != nil {
+			client = *o.HTTPClient
+			client.Timeout = timeout
+		}
+
+		req, err := http.NewRequest(http.MethodGet, location, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", location, err)
+		}
+		req.Header.Set("User-Agent", "prompter")
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", location, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, fmt.Errorf("failed to download %s: %s", location, resp.Status)
+		}
+		body = resp.Body
+	} else {
+		file, err := os.Open(location)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		body = file
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, InstallMaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", location, err)
+	}
+	if len(data) > InstallMaxBytes {
+		return nil, fmt.Errorf("%s is larger than %d bytes", location, InstallMaxBytes)
+	}
+	return data, nil
+}
+
+// withProvenance returns content with provenance set in its frontmatter, replacing
+// any recorded before and adding the frontmatter when the template has none
+func withProvenance(content []byte, provenance Provenance) []byte {
+	var encoded bytes.Buffer
+	encoder := yaml.NewEncoder(&encoded)
+	encoder.SetIndent(2)
+	encoder.Encode(map[string]Provenance{"provenance": provenance})
+	encoder.Close()
+	block := encoded.Bytes()
+
+	lines := strings.SplitAfter(string(content), "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != frontmatterDelimiter {
+		return append([]byte(frontmatterDelimiter+"\n"+string(block)+frontmatterDelimiter+"\n"), content...)
+	}
+
+	var b strings.Builder
+	b.WriteString(lines[0])
+	skipping := false
+	for i := 1; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == frontmatterDelimiter {
+			b.Write(block)
+			for _, rest := range lines[i:] {
+				b.WriteString(rest)
+			}
+			return []byte(b.String())
+		}
+		// Drop an earlier provenance block: its key and the indented lines below it
+		if strings.HasPrefix(line, "provenance:") {
+			skipping = true
+			continue
+		}
+		if skipping && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			continue
+		}
+		skipping = false
+		b.WriteString(line)
+	}
+	return content // Unclosed frontmatter is rejected before this is reached
+}
+
+// isRemote reports whether location is an http or https URL
+func isRemote(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// locationPath returns the path of a URL, or location itself when it is a file path
+func locationPath(location string) string {
+	if isRemote(location) {
+		if parsed, err := url.Parse(location); err == nil {
+			return parsed.Path
+		}
+	}
+	return filepath.ToSlash(location)
+}
+
+// resolveLocation resolves ref, from a manifest at base, to a URL or path. A remote
+// manifest may only list URLs, so it can't have local files such as ~/.ssh/config
+// read and installed.
+func resolveLocation(base, ref string) (string, error) {
+	if isRemote(ref) {
+		return ref, nil
+	}
+	if isRemote(base) {
+		baseURL, err := url.Parse(base)
+		refURL, refErr := url.Parse(ref)
+		if err != nil || refErr != nil || refURL.Scheme != "" || filepath.IsAbs(ref) || strings.HasPrefix(ref, "~") {
+			return "", fmt.Errorf("invalid url %q (a remote manifest may only list URLs or paths relative to it)", ref)
+		}
+		resolved := baseURL.ResolveReference(refURL).String()
+		if !isRemote(resolved) {
+			return "", fmt.Errorf("invalid url %q (a remote manifest may only list URLs or paths relative to it)", ref)
+		}
+		return resolved, nil
+	}
+	if filepath.IsAbs(ref) {
+		return ref, nil
+	}
+	return filepath.Join(filepath.Dir(base), filepath.FromSlash(ref)), nil
+}
+
+// kindFromLocation infers the kind of a template from the directory it's in, as in
+// .../post/strict.md, and defaults to pre
+func kindFromLocation(location string) string {
+	if path.Base(path.Dir(locationPath(location))) == "post" {
+		return "post"
+	}
+	return "pre"
+}
@@ -0,0 +1,192 @@
+package template
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstall(t *testing.T) {
+	files := map[string]string{
+		"/pre/review.md":     "---\ndescription: Review code\n---\nReview: {{.Prompt}}\n",
+		"/post/strict.md":    "Be strict.\n",
+		"/bundle.toml":       "description = \"Review kit\"\n\n[[templates]]\nurl = \"pre/review.md\"\n\n[[templates]]\nurl = \"post/strict.md\"\nkind = \"post\"\nname = \"exact\"\n",
+		"/registry/kit.toml": "[[templates]]\nurl = \"../post/strict.md\"\nkind = \"post\"\n",
+		"/broken.md":         "---\ndescription: [unclosed\n---\nbody\n",
+		"/absolute.toml":     "[[templates]]\nurl = \"/etc/passwd\"\n",
+		"/file-url.toml":     "[[templates]]\nurl = \"file:///etc/passwd\"\n",
+		"/home.toml":         "[[templates]]\nurl = \"~/.ssh/config\"\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	install := func(t *testing.T, dir, source string, options InstallOptions) ([]InstallResult, error) {
+		t.Helper()
+		options.PromptsLocation = dir
+		options.Registry = server.URL + "/registry"
+		return Install(source, options)
+	}
+	readTemplate := func(t *testing.T, path string) (*Metadata, string) {
+		t.Helper()
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		meta, body, err := ParseFrontmatter(content)
+		if err != nil {
+			t.Fatalf("installed template has invalid frontmatter: %v\n%s", err, content)
+		}
+		return meta, string(body)
+	}
+
+	t.Run("single template with provenance", func(t *testing.T) {
+		dir := t.TempDir()
+		results, err := install(t, dir, server.URL+"/pre/review.md", InstallOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || results[0].Kind != "pre" || results[0].Name != "review" || results[0].Status != InstallAdded {
+			t.Fatalf("results = %+v", results)
+		}
+
+		meta, body := readTemplate(t, filepath.Join(dir, "pre", "review.md"))
+		if meta.Description != "Review code" || body != "Review: {{.Prompt}}\n" {
+			t.Errorf("description, body = %q, %q", meta.Description, body)
+		}
+		if meta.Provenance == nil || meta.Provenance.URL != server.URL+"/pre/review.md" || len(meta.Provenance.SHA256) != 64 || meta.Provenance.Installed == "" {
+			t.Errorf("provenance = %+v", meta.Provenance)
+		}
+
+		// Installing again from the same URL updates in place
+		results, err = install(t, dir, server.URL+"/pre/review.md", InstallOptions{})
+		if err != nil || results[0].Status != InstallUnchanged {
+			t.Errorf("reinstall = %+v, %v; want unchanged", results, err)
+		}
+	})
+
+	t.Run("kind from the directory and frontmatter added", func(t *testing.T) {
+		dir := t.TempDir()
+		if _, err := install(t, dir, server.URL+"/post/strict.md", InstallOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		meta, body := readTemplate(t, filepath.Join(dir, "post", "strict.md"))
+		if meta.Provenance == nil || body != "Be strict.\n" {
+			t.Errorf("provenance, body = %+v, %q", meta.Provenance, body)
+		}
+	})
+
+	t.Run("collision", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "pre", "review.md")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("my own review\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := install(t, dir, server.URL+"/pre/review.md", InstallOptions{}); err == nil || !strings.Contains(err.Error(), "--force") {
+			t.Errorf("Install() error = %v, want a collision", err)
+		}
+		if _, err := install(t, dir, server.URL+"/pre/review.md", InstallOptions{Name: "team-review"}); err != nil {
+			t.Errorf("Install() under another name error = %v", err)
+		}
+		results, err := install(t, dir, server.URL+"/pre/review.md", InstallOptions{Force: true})
+		if err != nil || results[0].Status != InstallReplaced {
+			t.Errorf("forced install = %+v, %v", results, err)
+		}
+	})
+
+	t.Run("bundle", func(t *testing.T) {
+		dir := t.TempDir()
+		results, err := install(t, dir, server.URL+"/bundle.toml", InstallOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("results = %+v", results)
+		}
+		meta, _ := readTemplate(t, filepath.Join(dir, "post", "exact.md"))
+		if meta.Provenance == nil || meta.Provenance.Bundle != server.URL+"/bundle.toml" || meta.Provenance.URL != server.URL+"/post/strict.md" {
+			t.Errorf("provenance = %+v", meta.Provenance)
+		}
+	})
+
+	t.Run("bundle is all or nothing", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "post", "exact.md")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("mine\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := install(t, dir, server.URL+"/bundle.toml", InstallOptions{}); err == nil {
+			t.Fatal("Install() succeeded over a colliding template")
+		}
+		if _, err := os.Stat(filepath.Join(dir, "pre", "review.md")); !os.IsNotExist(err) {
+			t.Errorf("bundle was partly installed: %v", err)
+		}
+	})
+
+	t.Run("registry name", func(t *testing.T) {
+		dir := t.TempDir()
+		if _, err := install(t, dir, "kit", InstallOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "post", "strict.md")); err != nil {
+			t.Error(err)
+		}
+		if _, err := Install("kit", InstallOptions{PromptsLocation: dir}); err == nil || !strings.Contains(err.Error(), "template_registry") {
+			t.Errorf("Install() without a registry error = %v", err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		dir := t.TempDir()
+		if _, err := install(t, dir, server.URL+"/missing.md", InstallOptions{}); err == nil || !strings.Contains(err.Error(), "404") {
+			t.Errorf("missing template error = %v", err)
+		}
+		if _, err := install(t, dir, server.URL+"/broken.md", InstallOptions{}); err == nil || !strings.Contains(err.Error(), "frontmatter") {
+			t.Errorf("broken template error = %v", err)
+		}
+	})
+
+	t.Run("remote bundle listing local files", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, manifest := range []string{"/absolute.toml", "/file-url.toml", "/home.toml"} {
+			if _, err := install(t, dir, server.URL+manifest, InstallOptions{}); err == nil || !strings.Contains(err.Error(), "remote manifest") {
+				t.Errorf("Install() of %s error = %v", manifest, err)
+			}
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("expected nothing installed from a remote bundle listing local files, got %v", entries)
+		}
+	})
+}
+
+func TestWithProvenance(t *testing.T) {
+	provenance := Provenance{URL: "https://example.com/new.md", SHA256: "abc", Installed: "2026-01-02"}
+	content := "---\ndescription: Old\nprovenance:\n  url: https://example.com/old.md\n  sha256: def\ntags: [a]\n---\nbody\n"
+
+	meta, body, err := ParseFrontmatter(withProvenance([]byte(content), provenance))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Description != "Old" || len(meta.Tags) != 1 || string(body) != "body\n" {
+		t.Errorf("meta, body = %+v, %q", meta, body)
+	}
+	if meta.Provenance == nil || *meta.Provenance != provenance {
+		t.Errorf("provenance = %+v, want %+v", meta.Provenance, provenance)
+	}
+}
@@ -0,0 +1,245 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+
+	"prompter-cli/internal/interfaces"
+)
+
+// templateDataType is the type templates are executed with
+var templateDataType = reflect.TypeOf(interfaces.TemplateData{})
+
+// LintIssue is a problem Lint found in a template
+type LintIssue struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line,omitempty"`   // 0 when the issue is about the whole file
+	Column  int    `json:"column,omitempty"` // 0 when unknown
+	Message string `json:"message"`
+	Warning bool   `json:"warning,omitempty"` // The template still renders, but may not as intended
+}
+
+// String formats the issue as path:line:column: message, the form editors and
+// pre-commit hooks link to
+func (i LintIssue) String() string {
+	location := i.Path
+	if i.Line > 0 {
+		location += ":" + strconv.Itoa(i.Line)
+		if i.Column > 0 {
+			location += ":" + strconv.Itoa(i.Column)
+		}
+	}
+	if i.Warning {
+		return location + ": warning: " + i.Message
+	}
+	return location + ": " + i.Message
+}
+
+// Lint parses the template at path and checks the data it references. Syntax errors
+// and unknown helpers fail the parse and are reported on their own; otherwise every
+// field must exist in the template data, and every .Vars field should be declared in
+// the frontmatter. Issues are sorted by position.
+func (p *Processor) Lint(path string) []LintIssue {
+	tmpl, err := p.loadTemplateFromPath(path)
+	if err != nil {
+		var located *Error
+		if errors.As(err, &located) {
+			return []LintIssue{{Path: located.Path, Line: located.Line, Column: located.Column, Message: located.Message}}
+		}
+		if cause := errors.Unwrap(err); cause != nil {
+			err = cause
+		}
+		return []LintIssue{{Path: path, Message: err.Error()}}
+	}
+
+	l := &linter{
+		source:   p.sources[tmpl],
+		declared: make(map[string]bool),
+		warned:   make(map[string]bool),
+	}
+	if meta := p.metadata[tmpl]; meta != nil {
+		for _, variable := range meta.Variables {
+			l.declared[variable.Name] = true
+		}
+	}
+
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		// The template and its system section run with the prompt data; what other
+		// defined templates get depends on how they're called
+		l.tree, l.root = t.Tree, nil
+		if t.Name() == tmpl.Name() || t.Name() == SystemSection {
+			l.root = templateDataType
+		}
+		l.walk(t.Tree.Root, l.root)
+	}
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		if l.issues[i].Line != l.issues[j].Line {
+			return l.issues[i].Line < l.issues[j].Line
+		}
+		return l.issues[i].Column < l.issues[j].Column
+	})
+	return l.issues
+}
+
+// linter follows the type of dot through a template's parse tree. A nil type means
+// the data is unknown, and nothing below it is checked.
+type linter struct {
+	source   *source
+	tree     *parse.Tree
+	root     reflect.Type // Type of $
+	declared map[string]bool
+	warned   map[string]bool // Undeclared variables already reported
+	issues   []LintIssue
+}
+
+func (l *linter) walk(node parse.Node, dot reflect.Type) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			l.walk(child, dot)
+		}
+	case *parse.ActionNode:
+		l.pipe(n.Pipe, dot)
+	case *parse.IfNode:
+		l.pipe(n.Pipe, dot)
+		l.walk(n.List, dot)
+		l.walk(n.ElseList, dot)
+	case *parse.WithNode:
+		l.walk(n.List, l.pipe(n.Pipe, dot))
+		l.walk(n.ElseList, dot)
+	case *parse.RangeNode:
+		l.walk(n.List, elementType(l.pipe(n.Pipe, dot)))
+		l.walk(n.ElseList, dot)
+	case *parse.TemplateNode:
+		l.pipe(n.Pipe, dot)
+	}
+}
+
+// pipe checks the arguments of a pipeline and returns its type when it is a single
+// field or variable
+func (l *linter) pipe(pipe *parse.PipeNode, dot reflect.Type) reflect.Type {
+	if pipe == nil {
+		return nil
+	}
+	var result reflect.Type
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			result = l.arg(arg, dot)
+		}
+	}
+	if len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return nil
+	}
+	return result
+}
+
+// arg checks an argument of a command and returns its type, if known
+func (l *linter) arg(node parse.Node, dot reflect.Type) reflect.Type {
+	switch n := node.(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		return l.field(n, "", n.Ident, dot)
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" {
+			return l.field(n, "$", n.Ident[1:], l.root)
+		}
+	case *parse.ChainNode:
+		l.arg(n.Node, dot)
+	case *parse.PipeNode:
+		return l.pipe(n, dot)
+	}
+	return nil
+}
+
+// field follows a chain of field names from a value of type t, reporting the first
+// one that doesn't exist, and returns the type it ends at
+func (l *linter) field(node parse.Node, chain string, names []string, t reflect.Type) reflect.Type {
+	for i, name := range names {
+		chain += "." + name
+		if t == nil {
+			return nil
+		}
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		switch t.Kind() {
+		case reflect.Struct:
+			if method, ok := reflect.PointerTo(t).MethodByName(name); ok {
+				t = nil
+				if method.Type.NumOut() > 0 {
+					t = method.Type.Out(0)
+				}
+				continue
+			}
+			field, ok := t.FieldByName(name)
+			if !ok || !field.IsExported() {
+				l.report(node, false, fmt.Sprintf("%s: %s has no field %s", chain, t, name))
+				return nil
+			}
+			if t == templateDataType && name == "Vars" && i+1 < len(names) {
+				variable := names[i+1]
+				if !l.declared[variable] && !l.warned[variable] {
+					l.warned[variable] = true
+					l.report(node, true, fmt.Sprintf("%s.%s: variable %q is not declared in the frontmatter", chain, variable, variable))
+				}
+			}
+			t = field.Type
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Interface:
+			return nil
+		default:
+			l.report(node, false, fmt.Sprintf("%s: can't evaluate field %s in type %s", chain, name, t))
+			return nil
+		}
+	}
+	return t
+}
+
+// report records an issue at the position of node in the template file
+func (l *linter) report(node parse.Node, warning bool, message string) {
+	issue := LintIssue{Path: l.source.path, Message: message, Warning: warning}
+
+	// ErrorContext locates the node as name:line:column, with columns counted from 0
+	location, _ := l.tree.ErrorContext(node)
+	parts := strings.Split(location, ":")
+	if len(parts) >= 3 {
+		line, _ := strconv.Atoi(parts[len(parts)-2])
+		column, _ := strconv.Atoi(parts[len(parts)-1])
+		issue.Line = line + l.source.offset
+		issue.Column = l.source.column(line, column+1)
+	}
+	l.issues = append(l.issues, issue)
+}
+
+// elementType returns the type range sets dot to when ranging over a value of type t
+func elementType(t reflect.Type) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		return t.Elem()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return t
+	}
+	return nil
+}
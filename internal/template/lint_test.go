@@ -0,0 +1,65 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProcessor_Lint(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "valid",
+			content: "---\nvariables:\n  - name: tone\n---\n{{.Prompt}} {{.Vars.tone}}\n{{range .Files}}{{.RelPath}}{{end}}\n{{with .Git}}{{.Branch}}{{end}}\n",
+		},
+		{
+			name:    "syntax error",
+			content: "---\ndescription: x\n---\nfirst\n{{if .Prompt}}\n",
+			want:    []string{":6: unexpected EOF"},
+		},
+		{
+			name:    "unknown helper",
+			content: "{{shout .Prompt}}\n",
+			want:    []string{":1: function \"shout\" not defined"},
+		},
+		{
+			name:    "unknown fields",
+			content: "---\ndescription: x\n---\n{{.Git.Branchh}}\n{{range .Files}}{{.Nme}}{{end}}\n{{system}}{{.Model.Nam}}{{end}}\n",
+			want: []string{
+				":4:7: .Git.Branchh: interfaces.GitInfo has no field Branchh",
+				":5:19: .Nme: interfaces.FileInfo has no field Nme",
+				":6:19: .Model.Nam: interfaces.ModelInfo has no field Nam",
+			},
+		},
+		{
+			name:    "undeclared variables",
+			content: "{{.Vars.tone}} {{$.Vars.tone}}\n{{.Config.anything.deep}}\n",
+			want:    []string{":1:8: warning: .Vars.tone: variable \"tone\" is not declared in the frontmatter"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "prompt.md")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, issue := range NewProcessor("").Lint(path) {
+				got = append(got, issue.String())
+			}
+			var want []string
+			for _, issue := range tt.want {
+				want = append(want, path+issue)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Lint() = %q, want %q", got, want)
+			}
+		})
+	}
+}
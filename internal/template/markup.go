@@ -0,0 +1,233 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// xmlName matches the tag and attribute names xmlTag accepts
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// xmlEscaper replaces the characters with a meaning in XML markup
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+
+// xmlEscapeFunc escapes text for use inside XML markup
+func xmlEscapeFunc(text string) string {
+	return xmlEscaper.Replace(text)
+}
+
+// xmlTagFunc wraps content in a tag named name, with attributes given as name and
+// value pairs. The content is kept as written, since models read code better
+// unescaped; only a closing tag of the same name is escaped so the tag stays
+// well-formed.
+func xmlTagFunc(name string, content interface{}, attrs ...string) (string, error) {
+	if !xmlName.MatchString(name) {
+		return "", fmt.Errorf("xmlTag: invalid tag name %q", name)
+	}
+	if len(attrs)%2 != 0 {
+		return "", fmt.Errorf("xmlTag: attributes must come in name and value pairs")
+	}
+
+	var b strings.Builder
+	b.WriteString("<" + name)
+	for i := 0; i < len(attrs); i += 2 {
+		if !xmlName.MatchString(attrs[i]) {
+			return "", fmt.Errorf("xmlTag: invalid attribute name %q", attrs[i])
+		}
+		fmt.Fprintf(&b, ` %s="%s"`, attrs[i], xmlEscapeFunc(attrs[i+1]))
+	}
+	b.WriteString(">")
+
+	text := ""
+	if content != nil {
+		text = strings.Trim(fmt.Sprint(content), "\n")
+	}
+	closing := "</" + name + ">"
+	if text != "" {
+		b.WriteString("\n" + strings.ReplaceAll(text, closing, "&lt;/"+name+">") + "\n")
+	}
+	b.WriteString(closing)
+	return b.String(), nil
+}
+
+// mdTableFunc renders a slice of structs or maps as a markdown table with the given
+// columns, which name struct fields or map keys. Without columns, structs show every
+// exported field, and a map becomes a table of its keys and values.
+func mdTableFunc(items interface{}, columns ...string) (string, error) {
+	value := indirect(reflect.ValueOf(items))
+	if !value.IsValid() {
+		return "", nil
+	}
+
+	var rows []reflect.Value
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			rows = append(rows, indirect(value.Index(i)))
+		}
+	case reflect.Map:
+		keys := sortedKeys(value)
+		if len(columns) == 0 {
+			columns = []string{"Key", "Value"}
+			for _, key := range keys {
+				rows = append(rows, reflect.ValueOf(map[string]interface{}{
+					"Key":   key.Interface(),
+					"Value": value.MapIndex(key).Interface(),
+				}))
+			}
+			break
+		}
+		for _, key := range keys {
+			rows = append(rows, indirect(value.MapIndex(key)))
+		}
+	default:
+		return "", fmt.Errorf("mdTable: expected a list or map, got %s", value.Type())
+	}
+	if len(rows) == 0 {
+		return "", nil
+	}
+
+	if len(columns) == 0 {
+		if rows[0].Kind() != reflect.Struct {
+			return "", fmt.Errorf("mdTable: columns are required for a list of %s", value.Type().Elem())
+		}
+		for _, field := range reflect.VisibleFields(rows[0].Type()) {
+			if field.IsExported() && !field.Anonymous {
+				columns = append(columns, field.Name)
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("| " + strings.Join(escapeCells(columns), " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(columns)))
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			cell, err := lookupField(row, column)
+			if err != nil {
+				return "", fmt.Errorf("mdTable: %w", err)
+			}
+			cells[i] = cell
+		}
+		b.WriteString("\n| " + strings.Join(escapeCells(cells), " | ") + " |")
+	}
+	return b.String(), nil
+}
+
+// mdListFunc renders a slice as a markdown bullet list, one item per element, and a
+// map as "key: value" items sorted by key. A field name renders that field of each
+// element instead of the whole element.
+func mdListFunc(items interface{}, field ...string) (string, error) {
+	if len(field) > 1 {
+		return "", fmt.Errorf("mdList: expected at most one field name")
+	}
+	value := indirect(reflect.ValueOf(items))
+	if !value.IsValid() {
+		return "", nil
+	}
+
+	var lines []string
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			element := indirect(value.Index(i))
+			text := formatValue(element)
+			if len(field) == 1 {
+				var err error
+				if text, err = lookupField(element, field[0]); err != nil {
+					return "", fmt.Errorf("mdList: %w", err)
+				}
+			}
+			lines = append(lines, "- "+listItem(text))
+		}
+	case reflect.Map:
+		for _, key := range sortedKeys(value) {
+			element := indirect(value.MapIndex(key))
+			text := formatValue(element)
+			if len(field) == 1 {
+				var err error
+				if text, err = lookupField(element, field[0]); err != nil {
+					return "", fmt.Errorf("mdList: %w", err)
+				}
+			}
+			lines = append(lines, fmt.Sprintf("- %v: %s", key, listItem(text)))
+		}
+	default:
+		return "", fmt.Errorf("mdList: expected a list or map, got %s", value.Type())
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// indirect follows pointers and interfaces to the value they hold
+func indirect(value reflect.Value) reflect.Value {
+	for value.IsValid() && (value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface) {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+// sortedKeys returns the keys of a map value in the order of their text
+func sortedKeys(value reflect.Value) []reflect.Value {
+	keys := value.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
+
+// lookupField formats the field or map key named name of value. Struct fields
+// match their Go name or, failing that, the name case-insensitively.
+func lookupField(value reflect.Value, name string) (string, error) {
+	switch value.Kind() {
+	case reflect.Struct:
+		field := value.FieldByName(name)
+		if !field.IsValid() {
+			field = value.FieldByNameFunc(func(candidate string) bool {
+				return strings.EqualFold(candidate, name)
+			})
+		}
+		if !field.IsValid() || !field.CanInterface() {
+			return "", fmt.Errorf("%s has no field %q", value.Type(), name)
+		}
+		return formatValue(indirect(field)), nil
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			break
+		}
+		element := value.MapIndex(reflect.ValueOf(name).Convert(value.Type().Key()))
+		return formatValue(indirect(element)), nil
+	case reflect.Invalid:
+		return "", nil
+	}
+	return "", fmt.Errorf("can't look up %q in %s", name, value.Type())
+}
+
+// formatValue formats value as fmt.Sprint would, and a missing value as empty text
+func formatValue(value reflect.Value) string {
+	if !value.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(value.Interface())
+}
+
+// escapeCells keeps cell text from breaking the table row
+func escapeCells(cells []string) []string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		cell = strings.ReplaceAll(cell, "|", `\|`)
+		escaped[i] = strings.ReplaceAll(strings.TrimRight(cell, "\n"), "\n", "<br>")
+	}
+	return escaped
+}
+
+// listItem indents the continuation lines of a multi-line item under its bullet
+func listItem(text string) string {
+	return strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", "\n  ")
+}
@@ -0,0 +1,167 @@
+package template
+
+import (
+	"testing"
+
+	"prompter-cli/internal/interfaces"
+)
+
+func TestXMLTagHelper(t *testing.T) {
+	processor := NewProcessor("")
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "content on its own lines",
+			template: `{{xmlTag "context" .Prompt}}`,
+			expected: "<context>\nfix the parser\n</context>",
+		},
+		{
+			name:     "escaped attributes",
+			template: `{{xmlTag "file" "x := 1" "path" "a&b.go" "note" "say \"hi\""}}`,
+			expected: "<file path=\"a&amp;b.go\" note=\"say &quot;hi&quot;\">\nx := 1\n</file>",
+		},
+		{
+			name:     "nested closing tag escaped",
+			template: `{{xmlTag "doc" "see </doc> here"}}`,
+			expected: "<doc>\nsee &lt;/doc> here\n</doc>",
+		},
+		{
+			name:     "empty content",
+			template: `{{xmlTag "notes" ""}}`,
+			expected: "<notes></notes>",
+		},
+		{
+			name:     "escape",
+			template: `{{xmlEscape "if a < b && c > 'd'"}}`,
+			expected: "if a &lt; b &amp;&amp; c &gt; &apos;d&apos;",
+		},
+		{
+			name:     "invalid tag name",
+			template: `{{xmlTag "two words" "x"}}`,
+			wantErr:  true,
+		},
+		{
+			name:     "odd attributes",
+			template: `{{xmlTag "file" "x" "path"}}`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := processor.createTestTemplate(t, tt.template)
+
+			result, err := processor.Execute(tmpl, interfaces.TemplateData{Prompt: "fix the parser"})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestMarkdownHelpers(t *testing.T) {
+	processor := NewProcessor("")
+	data := interfaces.TemplateData{
+		Files: []interfaces.FileInfo{
+			{Path: "main.go", Language: "go"},
+			{Path: "a|b.md", Language: "markdown"},
+		},
+		Vars: map[string]string{"team": "core", "lang": "Go"},
+		Git: interfaces.GitInfo{RecentCommits: []interfaces.GitCommit{
+			{Hash: "abc123", Subject: "Fix parser"},
+			{Hash: "def456", Subject: "Add tests"},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "table of struct fields",
+			template: `{{mdTable .Files "Path" "language"}}`,
+			expected: "| Path | language |\n| --- | --- |\n| main.go | go |\n| a\\|b.md | markdown |",
+		},
+		{
+			name:     "table of map keys and values",
+			template: `{{mdTable .Vars}}`,
+			expected: "| Key | Value |\n| --- | --- |\n| lang | Go |\n| team | core |",
+		},
+		{
+			name:     "table of every field",
+			template: `{{mdTable .Git.RecentCommits}}`,
+			expected: "| Hash | Subject |\n| --- | --- |\n| abc123 | Fix parser |\n| def456 | Add tests |",
+		},
+		{
+			name:     "table of dicts",
+			template: `{{mdTable (list (dict "name" "x" "note" "two\nlines")) "name" "note" "missing"}}`,
+			expected: "| name | note | missing |\n| --- | --- | --- |\n| x | two<br>lines |  |",
+		},
+		{
+			name:     "empty list",
+			template: `{{mdTable .Diff.Files "Path"}}`,
+			expected: "",
+		},
+		{
+			name:     "not a list",
+			template: `{{mdTable .Previous.ID}}`,
+			wantErr:  true,
+		},
+		{
+			name:     "unknown field",
+			template: `{{mdTable .Files "Size"}}`,
+			wantErr:  true,
+		},
+		{
+			name:     "list of strings",
+			template: `{{mdList (list "one" "two\nlines")}}`,
+			expected: "- one\n- two\n  lines",
+		},
+		{
+			name:     "list of a field",
+			template: `{{mdList .Git.RecentCommits "Subject"}}`,
+			expected: "- Fix parser\n- Add tests",
+		},
+		{
+			name:     "list of a map",
+			template: `{{mdList .Vars}}`,
+			expected: "- lang: Go\n- team: core",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := processor.createTestTemplate(t, tt.template)
+
+			result, err := processor.Execute(tmpl, data)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package template
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+// maxSlugLength bounds slugs so file names built from prompts stay readable
+const maxSlugLength = 60
+
+// OutputPathData is available to the placeholders in the path of a file target
+type OutputPathData struct {
+	Now    time.Time         // When the prompt was generated
+	Prompt string            // The base prompt
+	Pre    string            // Pre-template name
+	Post   string            // Post-template name
+	Vars   map[string]string // Template variables
+}
+
+// RenderOutputPath expands the strftime directives (%Y, %m, %d, ...) and template
+// placeholders ({{.Now.Format "2006-01-02"}}, {{slug .Prompt}}, ...) in the path of a
+// file target, so each prompt can be written to a file of its own
+func RenderOutputPath(pattern string, data OutputPathData) (string, error) {
+	// strftime directives only apply outside placeholders, where % has meanings of
+	// its own
+	var expanded strings.Builder
+	rest := pattern
+	for rest != "" {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			expanded.WriteString(strftime(rest, data.Now))
+			break
+		}
+		end := strings.Index(rest[start:], "}}")
+		if end < 0 {
+			expanded.WriteString(strftime(rest[:start], data.Now) + rest[start:])
+			break
+		}
+		end += start + 2
+		expanded.WriteString(strftime(rest[:start], data.Now) + rest[start:end])
+		rest = rest[end:]
+	}
+	if !strings.Contains(pattern, "{{") {
+		return expanded.String(), nil
+	}
+
+	funcs := sprig.TxtFuncMap()
+	funcs["slug"] = slugFunc
+	tmpl, err := template.New("path").Funcs(funcs).Option("missingkey=zero").Parse(expanded.String())
+	if err != nil {
+		return "", fmt.Errorf("invalid placeholder in path %q: %w", pattern, err)
+	}
+	var path strings.Builder
+	if err := tmpl.Execute(&path, data); err != nil {
+		return "", fmt.Errorf("failed to expand path %q: %w", pattern, err)
+	}
+	return path.String(), nil
+}
+
+// strftime replaces the common strftime directives in text with parts of t, leaving
+// unknown directives as they are
+func strftime(text string, t time.Time) string {
+	if !strings.Contains(text, "%") {
+		return text
+	}
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '%' || i == len(text)-1 {
+			b.WriteByte(text[i])
+			continue
+		}
+		i++
+		switch text[i] {
+		case 'Y':
+			b.WriteString(t.Format("2006"))
+		case 'y':
+			b.WriteString(t.Format("06"))
+		case 'm':
+			b.WriteString(t.Format("01"))
+		case 'd':
+			b.WriteString(t.Format("02"))
+		case 'H':
+			b.WriteString(t.Format("15"))
+		case 'M':
+			b.WriteString(t.Format("04"))
+		case 'S':
+			b.WriteString(t.Format("05"))
+		case 'b':
+			b.WriteString(t.Format("Jan"))
+		case 'B':
+			b.WriteString(t.Format("January"))
+		case 'a':
+			b.WriteString(t.Format("Mon"))
+		case 'A':
+			b.WriteString(t.Format("Monday"))
+		case 'j':
+			b.WriteString(fmt.Sprintf("%03d", t.YearDay()))
+		case 'F':
+			b.WriteString(t.Format("2006-01-02"))
+		case 'T':
+			b.WriteString(t.Format("15:04:05"))
+		case 's':
+			b.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String()
+}
+
+// slugFunc turns text into a lower case, hyphenated name safe for files and URLs,
+// cut at a word boundary to at most maxSlugLength characters
+func slugFunc(text string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+
+	slug := b.String()
+	if len(slug) <= maxSlugLength {
+		return slug
+	}
+	slug = slug[:maxSlugLength]
+	if cut := strings.LastIndexByte(slug, '-'); cut > 0 {
+		return slug[:cut]
+	}
+	return strings.ToValidUTF8(slug, "")
+}
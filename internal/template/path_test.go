@@ -0,0 +1,59 @@
+package template
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderOutputPath(t *testing.T) {
+	data := OutputPathData{
+		Now:    time.Date(2026, 3, 7, 14, 5, 9, 0, time.UTC),
+		Prompt: "Why does the parser panic on empty input?",
+		Pre:    "debug",
+		Vars:   map[string]string{"team": "core"},
+	}
+
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"/tmp/prompt.md", "/tmp/prompt.md"},
+		{"out/%Y-%m-%d/%H%M%S.md", "out/2026-03-07/140509.md"},
+		{"out/%F-%q-100%%.md", "out/2026-03-07-%q-100%.md"},
+		{`out/{{.Now.Format "2006-01-02"}}-{{slug .Prompt}}.md`, "out/2026-03-07-why-does-the-parser-panic-on-empty-input.md"},
+		{`out/%Y/{{.Vars.team}}-{{.Pre}}-{{printf "%03d" 7}}.md`, "out/2026/core-debug-007.md"},
+	}
+	for _, tt := range tests {
+		got, err := RenderOutputPath(tt.pattern, data)
+		if err != nil {
+			t.Errorf("RenderOutputPath(%q) failed: %v", tt.pattern, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("RenderOutputPath(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+
+	if _, err := RenderOutputPath("out/{{.Nope}.md", data); err == nil {
+		t.Error("expected an error for a malformed placeholder")
+	}
+}
+
+func TestSlugFunc(t *testing.T) {
+	tests := map[string]string{
+		"Fix the --watch flag!": "fix-the-watch-flag",
+		"  Überprüfe  das API ": "überprüfe-das-api",
+		"":                      "",
+	}
+	for text, want := range tests {
+		if got := slugFunc(text); got != want {
+			t.Errorf("slugFunc(%q) = %q, want %q", text, got, want)
+		}
+	}
+
+	long := slugFunc(strings.Repeat("refactor the parser ", 10))
+	if len(long) > maxSlugLength || strings.HasSuffix(long, "-") || !strings.HasPrefix(long, "refactor-the-parser-") {
+		t.Errorf("unexpected long slug %q", long)
+	}
+}
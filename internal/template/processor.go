@@ -1,35 +1,98 @@
 package template
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"strings"
 	"text/template"
 
-	"github.com/Masterminds/sprig/v3"
+	"prompter-cli/internal/config"
+	"prompter-cli/internal/content"
 	"prompter-cli/internal/interfaces"
+	"prompter-cli/internal/plugin"
+	"prompter-cli/internal/tokenizer"
 )
 
+// SystemSection names the part of a template sent to chat models as the system prompt,
+// written as {{system}}...{{end}} or {{define "system"}}...{{end}}
+const SystemSection = "system"
+
+// systemAction matches the {{system}} action opening a system section, with optional
+// whitespace trimming
+var systemAction = regexp.MustCompile(`\{\{(-?\s*)system(\s*-?)\}\}`)
+
+// systemDefine is what systemAction matches are rewritten to before parsing
+const systemDefine = `{{${1}define "` + SystemSection + `"${2}}}`
+
 // Processor implements the TemplateProcessor interface
 type Processor struct {
-	promptsLocation      string
+	promptsLocations     []string                              // Configured prompts locations in search order
 	localPromptsLocation string                                // Additional location for local prompts
 	customTemplates      map[string]interfaces.CustomTemplate // Custom template configurations
+	wasmPlugins          map[string]interfaces.WasmPlugin     // WebAssembly helper plugins
+	wasm                 *wasmRuntime                         // Lazily created when plugins are configured
+	helpers              map[string]interfaces.HelperCommand  // External-process helpers
+	processHelpers       *processHelpers                      // Lazily created when helpers are configured
+	plugins              *plugin.Registry                     // Plugins whose functions are registered as helpers
+	metadata             map[*template.Template]*Metadata     // Frontmatter of loaded templates
+	sources              map[*template.Template]*source       // Files of loaded templates, for locating errors
+	compiled             map[string]compiledTemplate          // Parsed templates by path, reused while their file is unchanged
+	strict               bool                                 // Fail on undefined data instead of rendering <no value>
+	tokenizer            tokenizer.Tokenizer                  // Used by the tokens helper
+	contentOptions       content.Options                      // Limits and ignore rules for the readFile and glob helpers
+	execOptions          ExecOptions                          // Whether and how the exec helper runs commands
+	fetchOptions         FetchOptions                         // URLs the fetchURL helper may fetch, and its limits
+	fetched              map[string]string                    // Bodies already fetched by fetchURL
+	ctx                  context.Context                      // Stops helpers that run commands or make requests, nil never does
+}
+
+// compiledTemplate is a parsed template and the version of the file it was parsed from
+type compiledTemplate struct {
+	tmpl  *template.Template
+	stamp templateStamp
+}
+
+// templateStamp identifies a version of a template file; built-in templates never
+// change and all have the zero stamp
+type templateStamp struct {
+	modTime int64
+	size    int64
 }
 
 // NewProcessor creates a new template processor
 func NewProcessor(promptsLocation string) *Processor {
 	return &Processor{
-		promptsLocation:      promptsLocation,
+		promptsLocations:     []string{promptsLocation},
 		localPromptsLocation: "", // Will be set by SetLocalPromptsLocation
 		customTemplates:      make(map[string]interfaces.CustomTemplate),
+		metadata:             make(map[*template.Template]*Metadata),
+		tokenizer:            tokenizer.NewApproximate(),
 	}
 }
 
 // SetPromptsLocation updates the prompts location
 func (p *Processor) SetPromptsLocation(location string) {
-	p.promptsLocation = location
+	p.promptsLocations = []string{location}
+}
+
+// SetPromptsLocations sets the prompts locations searched in order after the local
+// one, such as a personal, a team-shared, and a project directory
+func (p *Processor) SetPromptsLocations(locations []string) {
+	p.promptsLocations = locations
+}
+
+// SetStrict makes executing templates fail when they reference undefined data, such
+// as a variable that was never set, instead of rendering "<no value>"
+func (p *Processor) SetStrict(strict bool) {
+	if strict != p.strict {
+		p.Invalidate()
+	}
+	p.strict = strict
 }
 
 // SetLocalPromptsLocation updates the local prompts location
@@ -43,13 +106,16 @@ func (p *Processor) SetLocalPromptsFromConfig(configLocation string) {
 		// Use the configured location
 		p.localPromptsLocation = configLocation
 	} else {
-		// Default: check for "prompts" directory in current working directory
+		// Default: check for a "prompts" directory in the current working directory,
+		// then for the one created by 'prompter init --project'
+		p.localPromptsLocation = ""
 		if cwd, err := os.Getwd(); err == nil {
-			localPath := filepath.Join(cwd, "prompts")
-			if _, err := os.Stat(localPath); err == nil {
-				p.localPromptsLocation = localPath
-			} else {
-				p.localPromptsLocation = ""
+			for _, dir := range []string{"prompts", filepath.Join(config.ProjectDir, "prompts")} {
+				localPath := filepath.Join(cwd, dir)
+				if _, err := os.Stat(localPath); err == nil {
+					p.localPromptsLocation = localPath
+					break
+				}
 			}
 		}
 	}
@@ -60,13 +126,69 @@ func (p *Processor) SetCustomTemplates(customTemplates map[string]interfaces.Cus
 	p.customTemplates = customTemplates
 }
 
+// SetWasmPlugins sets the WebAssembly plugins whose exports are registered as helpers.
+// Changing them closes the modules loaded for the previous set.
+func (p *Processor) SetWasmPlugins(plugins map[string]interfaces.WasmPlugin) {
+	if !reflect.DeepEqual(plugins, p.wasmPlugins) {
+		p.Invalidate()
+		if p.wasm != nil {
+			if err := p.wasm.close(context.Background()); err != nil {
+				slog.Debug("failed to close wasm runtime", "error", err)
+			}
+			p.wasm = nil
+		}
+	}
+	p.wasmPlugins = plugins
+}
+
+// SetTokenizer sets the tokenizer used by the tokens helper
+func (p *Processor) SetTokenizer(tok tokenizer.Tokenizer) {
+	p.tokenizer = tok
+}
+
+// SetContentOptions sets the size limits and ignore rules the readFile and glob
+// helpers apply, as content collection does
+func (p *Processor) SetContentOptions(options content.Options) {
+	p.contentOptions = options
+}
+
+// SetContext sets the context that stops the exec, fetchURL, helper command, plugin,
+// and WebAssembly helpers of templates executed from now on
+func (p *Processor) SetContext(ctx context.Context) {
+	p.ctx = ctx
+}
+
+// context returns the context set with SetContext, or a background context
+func (p *Processor) context() context.Context {
+	if p.ctx == nil {
+		return context.Background()
+	}
+	return p.ctx
+}
+
+// SetPlugins sets the plugins whose template functions are registered as helpers
+func (p *Processor) SetPlugins(plugins *plugin.Registry) {
+	if plugins != p.plugins {
+		p.Invalidate()
+	}
+	p.plugins = plugins
+}
+
+// SetHelpers sets the external-process helpers registered as template functions
+func (p *Processor) SetHelpers(helpers map[string]interfaces.HelperCommand) {
+	if !reflect.DeepEqual(helpers, p.helpers) {
+		p.Invalidate()
+	}
+	p.helpers = helpers
+}
+
 // GetPromptLocations returns all prompt locations (local first, then configured, then custom)
 func (p *Processor) GetPromptLocations() []string {
 	var locations []string
 	if p.localPromptsLocation != "" {
 		locations = append(locations, p.localPromptsLocation)
 	}
-	locations = append(locations, p.promptsLocation)
+	locations = append(locations, p.promptsLocations...)
 	
 	// Add custom template locations
 	for _, customTemplate := range p.customTemplates {
@@ -83,8 +205,9 @@ func (p *Processor) GetCustomTemplates() map[string]interfaces.CustomTemplate {
 
 // LoadTemplate loads a template from the specified path or discovers it by name
 func (p *Processor) LoadTemplate(nameOrPath string) (*template.Template, error) {
-	// If it's an absolute path or contains path separators, load directly
-	if filepath.IsAbs(nameOrPath) || strings.Contains(nameOrPath, string(filepath.Separator)) {
+	// Paths are loaded directly; go/review is a namespaced name unless it's a file
+	if IsTemplatePath(nameOrPath) {
+		slog.Debug("loading template by path", "path", nameOrPath)
 		return p.loadTemplateFromPath(nameOrPath)
 	}
 
@@ -97,8 +220,46 @@ func (p *Processor) LoadTemplate(nameOrPath string) (*template.Template, error)
 	return p.loadTemplateFromPath(templatePath)
 }
 
-// discoverTemplate finds a template file by name (case-insensitive matching by stem)
-func (p *Processor) discoverTemplate(name string) (string, error) {
+// ResolveTemplate returns the path of the template a name refers to, using the same
+// case-insensitive, .default-aware lookup as LoadTemplate. Built-in templates are
+// returned as EmbeddedPrefix paths.
+func (p *Processor) ResolveTemplate(name string) (string, error) {
+	return p.discoverTemplate(name)
+}
+
+// TemplateMetadata returns the frontmatter of a template found by name or path
+func (p *Processor) TemplateMetadata(nameOrPath string) (*Metadata, error) {
+	tmpl, err := p.LoadTemplate(nameOrPath)
+	if err != nil {
+		return nil, err
+	}
+	return p.metadata[tmpl], nil
+}
+
+// LoadRootTemplate loads a special-purpose template (such as "continue") stored as
+// <name>.md at the root of the local or global prompts directory, falling back to
+// the built-in version
+func (p *Processor) LoadRootTemplate(name string) (*template.Template, error) {
+	for _, dir := range append([]string{p.localPromptsLocation}, p.promptsLocations...) {
+		if dir == "" {
+			continue
+		}
+		path := filepath.Join(dir, name+".md")
+		if _, err := os.Stat(path); err == nil {
+			return p.loadTemplateFromPath(path)
+		}
+	}
+
+	if _, ok := EmbeddedTemplate("", name); ok {
+		return p.loadTemplateFromPath(EmbeddedPrefix + name + ".md")
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// discoverTemplate finds a template file by name (case-insensitive matching by stem),
+// looking in subdirectories for namespaced names
+func (p *Processor) discoverTemplate(name string) (found string, err error) {
 	// Build list of directories to check
 	// Priority: local prompts first, then configured prompts location, then custom templates
 	var directories []string
@@ -112,10 +273,12 @@ func (p *Processor) discoverTemplate(name string) (string, error) {
 	}
 	
 	// Add configured prompts location directories
-	directories = append(directories,
-		filepath.Join(p.promptsLocation, "pre"),
-		filepath.Join(p.promptsLocation, "post"),
-	)
+	for _, location := range p.promptsLocations {
+		directories = append(directories,
+			filepath.Join(location, "pre"),
+			filepath.Join(location, "post"),
+		)
+	}
 	
 	// Add custom template directories
 	for _, customTemplate := range p.customTemplates {
@@ -125,7 +288,27 @@ func (p *Processor) discoverTemplate(name string) (string, error) {
 		)
 	}
 
+	defer func() {
+		if err != nil {
+			slog.Debug("template not found", "name", name, "searched", strings.Join(directories, ","))
+		} else {
+			slog.Debug("resolved template", "name", name, "path", found)
+		}
+	}()
+
+	// Namespaced names such as go/review are found in subdirectories
+	name = filepath.ToSlash(name)
 	for _, dir := range directories {
+		if strings.Contains(name, "/") {
+			files, _ := ReadTemplateDir(dir)
+			for _, file := range files {
+				if strings.EqualFold(file.Stem, name) || strings.EqualFold(file.Name, name) {
+					return file.Path, nil
+				}
+			}
+			continue
+		}
+
 		if _, err := os.Stat(dir); os.IsNotExist(err) {
 			continue
 		}
@@ -170,45 +353,147 @@ func (p *Processor) discoverTemplate(name string) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("template not found: %s", name)
+	// Fall back to templates built into the binary
+	if embeddedPath, ok := discoverEmbeddedTemplate(name); ok {
+		return embeddedPath, nil
+	}
+
+	return "", &NotFoundError{Name: name, Suggestions: Closest(name, p.templateNames(), maxSuggestions)}
 }
 
-// loadTemplateFromPath loads a template from a specific file path
+// Invalidate forgets every parsed template, so the next load of each rereads and
+// parses its file. Templates are already reparsed when their file's modification
+// time or size changes, and when the strict setting or the helpers change.
+func (p *Processor) Invalidate() {
+	for _, compiled := range p.compiled {
+		delete(p.metadata, compiled.tmpl)
+		delete(p.sources, compiled.tmpl)
+	}
+	p.compiled = nil
+}
+
+// loadTemplateFromPath loads a template from a specific file path, reusing the one
+// parsed by an earlier load while the file is unchanged
 func (p *Processor) loadTemplateFromPath(path string) (*template.Template, error) {
-	content, err := os.ReadFile(path)
+	key := path
+	var stamp templateStamp
+	if !IsEmbeddedPath(path) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template file %s: %w", path, err)
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			key = abs
+		}
+		stamp = templateStamp{modTime: info.ModTime().UnixNano(), size: info.Size()}
+	}
+	previous, ok := p.compiled[key]
+	if ok && previous.stamp == stamp {
+		return previous.tmpl, nil
+	}
+
+	tmpl, err := p.parseTemplateFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		delete(p.metadata, previous.tmpl)
+		delete(p.sources, previous.tmpl)
+	}
+	if p.compiled == nil {
+		p.compiled = make(map[string]compiledTemplate)
+	}
+	p.compiled[key] = compiledTemplate{tmpl: tmpl, stamp: stamp}
+	return tmpl, nil
+}
+
+// parseTemplateFile reads and parses a template file, recording its frontmatter and
+// source
+func (p *Processor) parseTemplateFile(path string) (*template.Template, error) {
+	var content []byte
+	var err error
+	if IsEmbeddedPath(path) {
+		content, err = readEmbeddedTemplate(path)
+	} else {
+		content, err = os.ReadFile(path)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to read template file %s: %w", path, err)
 	}
 
+	// Strip the frontmatter so it never appears in the rendered prompt
+	meta, body, err := ParseFrontmatter(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", frontmatterError(path, content, err))
+	}
+	src := newSource(path, content, body)
+
 	// Create template with custom delimiters and helper functions
 	tmpl := template.New(filepath.Base(path))
+	if p.strict {
+		tmpl.Option("missingkey=error")
+	}
 	
 	// Register helper functions before parsing
 	if err := p.registerHelpersToTemplate(tmpl); err != nil {
 		return nil, fmt.Errorf("failed to register helper functions: %w", err)
 	}
 
-	// Parse the template content
-	tmpl, err = tmpl.Parse(string(content))
+	// Parse the template content, with system sections as the template they define
+	text := systemAction.ReplaceAllString(string(body), systemDefine)
+	tmpl, err = tmpl.Parse(text)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse template %s: %w", path, err)
+		return nil, fmt.Errorf("failed to parse template: %w", src.locate(err))
 	}
 
+	if p.metadata == nil {
+		p.metadata = make(map[*template.Template]*Metadata)
+	}
+	p.metadata[tmpl] = meta
+	if p.sources == nil {
+		p.sources = make(map[*template.Template]*source)
+	}
+	p.sources[tmpl] = src
+
 	return tmpl, nil
 }
 
 // Execute executes a template with the provided data
 func (p *Processor) Execute(tmpl *template.Template, data interfaces.TemplateData) (string, error) {
+	// Fill in declared variable defaults and check required ones
+	data, err := p.metadata[tmpl].ApplyVariables(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+
 	var buf strings.Builder
 	
-	err := tmpl.Execute(&buf, data)
+	err = tmpl.Execute(&buf, data)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute template: %w", err)
+		return "", fmt.Errorf("failed to execute template: %w", p.locateError(tmpl, err))
 	}
 
 	return buf.String(), nil
 }
 
+// ExecuteSystem renders the system section of a template, returning "" when it has
+// none
+func (p *Processor) ExecuteSystem(tmpl *template.Template, data interfaces.TemplateData) (string, error) {
+	if tmpl.Lookup(SystemSection) == nil {
+		return "", nil
+	}
+	data, err := p.metadata[tmpl].ApplyVariables(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	var buf strings.Builder
+	if err := tmpl.ExecuteTemplate(&buf, SystemSection, data); err != nil {
+		return "", fmt.Errorf("failed to execute the system section: %w", p.locateError(tmpl, err))
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
 // RegisterHelpers registers custom template helper functions (placeholder for now)
 func (p *Processor) RegisterHelpers() error {
 	// This method is for global registration if needed
@@ -218,24 +503,8 @@ func (p *Processor) RegisterHelpers() error {
 
 // registerHelpersToTemplate registers both sprig and custom helper functions to a template
 func (p *Processor) registerHelpersToTemplate(tmpl *template.Template) error {
-	// Start with sprig functions
-	funcMap := sprig.TxtFuncMap()
-	
-	// Add custom helper functions
-	customFuncs := template.FuncMap{
-		"truncate": truncateFunc,
-		"mdFence":  mdFenceFunc,
-		"indent":   indentFunc,
-		"dedent":   dedentFunc,
-	}
-	
-	// Merge custom functions into sprig functions
-	for name, fn := range customFuncs {
-		funcMap[name] = fn
-	}
-	
-	// Apply the function map to the template
-	tmpl.Funcs(funcMap)
+	// Apply the function map from the helper registry to the template
+	tmpl.Funcs(p.helperFuncs())
 	
 	return nil
 }
@@ -344,4 +613,33 @@ func dedentFunc(text string) string {
 	}
 	
 	return strings.Join(lines, "\n")
-}
\ No newline at end of file
+}
+
+// countTokens implements the tokens helper
+func (p *Processor) countTokens(value interface{}) int {
+	switch v := value.(type) {
+	case string:
+		return p.tokenizer.Count(v)
+	case []byte:
+		return p.tokenizer.Count(string(v))
+	case interfaces.FileInfo:
+		return p.tokenizer.Count(v.Content)
+	case []interfaces.FileInfo:
+		total := 0
+		for _, file := range v {
+			total += p.tokenizer.Count(file.Content)
+		}
+		return total
+	case interfaces.TemplateData:
+		return p.tokenizer.Count(v.Prompt) + p.countTokens(v.Files)
+	case *interfaces.TemplateData:
+		if v == nil {
+			return 0
+		}
+		return p.countTokens(*v)
+	case nil:
+		return 0
+	default:
+		return p.tokenizer.Count(fmt.Sprint(v))
+	}
+}
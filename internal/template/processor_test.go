@@ -1,8 +1,11 @@
 package template
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"text/template"
 	"time"
@@ -368,4 +371,106 @@ func TestHelperFunctions(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestProcessor_LoadTemplate_EmbeddedFallback(t *testing.T) {
+	tempDir := t.TempDir()
+	preDir := filepath.Join(tempDir, "pre")
+	if err := os.MkdirAll(preDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	processor := NewProcessor(tempDir)
+
+	// Built-in template is used when nothing exists on disk
+	tmpl, err := processor.LoadTemplate("question")
+	if err != nil {
+		t.Fatalf("expected built-in template to load, got: %v", err)
+	}
+	result, err := processor.Execute(tmpl, interfaces.TemplateData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, "# Question") {
+		t.Errorf("unexpected built-in template output: %q", result)
+	}
+
+	// A user template with the same name takes precedence
+	if err := os.WriteFile(filepath.Join(preDir, "question.md"), []byte("mine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err = processor.LoadTemplate("Question")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, _ = processor.Execute(tmpl, interfaces.TemplateData{})
+	if result != "mine" {
+		t.Errorf("expected user template to override built-in, got %q", result)
+	}
+}
+
+func TestProcessor_LoadTemplate_NotFoundSuggestions(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"pre/review.md", "post/reviewed.md", "post/checklist.md"} {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("body"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	processor := NewProcessor(tempDir)
+
+	// Pre and post templates are both suggested, closest first
+	_, err := processor.LoadTemplate("reviewd")
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) || !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a NotFoundError, got %v", err)
+	}
+	if want := []string{"review", "reviewed"}; !reflect.DeepEqual(notFound.Suggestions, want) {
+		t.Errorf("suggestions = %v, want %v", notFound.Suggestions, want)
+	}
+	if err.Error() != "template not found: reviewd" {
+		t.Errorf("error = %q", err)
+	}
+}
+
+func TestProcessor_LoadTemplate_Cached(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "review.md")
+	if err := os.WriteFile(path, []byte("Review {{.Prompt}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	processor := NewProcessor("")
+
+	first, err := processor.LoadTemplate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := processor.LoadTemplate(path); again != first {
+		t.Error("expected an unchanged template to be reused")
+	}
+
+	// A changed file is parsed again, even when its modification time can't tell
+	if err := os.WriteFile(path, []byte("Please review {{.Prompt}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := processor.LoadTemplate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := processor.Execute(changed, interfaces.TemplateData{Prompt: "main.go"}); result != "Please review main.go" {
+		t.Errorf("expected the changed template, got %q", result)
+	}
+
+	processor.Invalidate()
+	if reloaded, _ := processor.LoadTemplate(path); reloaded == changed {
+		t.Error("expected Invalidate to drop the parsed template")
+	}
+
+	reloaded, _ := processor.LoadTemplate(path)
+	processor.SetStrict(true)
+	if strict, _ := processor.LoadTemplate(path); strict == reloaded {
+		t.Error("expected changing the strict setting to parse templates again")
+	}
+}
@@ -0,0 +1,158 @@
+package template
+
+import (
+	"time"
+
+	"prompter-cli/internal/config"
+	"prompter-cli/internal/interfaces"
+)
+
+// sampleHunkHeader and sampleHunk are the one changed region of the sample diff
+const (
+	sampleHunkHeader = "@@ -12,5 +12,7 @@ func Parse(input string) (*Document, error) {"
+	sampleHunk       = " \tif input == \"\" {\n-\t\treturn nil, nil\n+\t\treturn nil, ErrEmpty\n \t}\n+\tinput = strings.TrimSpace(input)\n+\n \treturn parse(input)\n }\n"
+)
+
+// samplePatch is the whole sample diff
+const samplePatch = "diff --git a/internal/parser/parser.go b/internal/parser/parser.go\n" +
+	"--- a/internal/parser/parser.go\n+++ b/internal/parser/parser.go\n" +
+	sampleHunkHeader + "\n" + sampleHunk
+
+// sampleTree is the project tree in the sample data
+const sampleTree = "project/\n├── internal/\n│   └── parser/\n│       ├── parser.go\n│       └── parser_test.go\n├── README.md\n└── go.mod"
+
+// sampleOutput is the captured command output in the sample fix data
+const sampleOutput = "--- FAIL: TestParse (0.00s)\n    parser_test.go:14: Parse(\"\") error = <nil>, want ErrEmpty\nFAIL"
+
+// SampleData returns representative template data for previewing a template: a
+// placeholder prompt, two files with a TODO comment, a repository with recent commits,
+// a Go project and its tree, a small diff, and captured command output. Fix mode is left disabled, and
+// variables to the caller.
+func SampleData() interfaces.TemplateData {
+	model := config.BuiltinModels["claude-sonnet"]
+
+	return interfaces.TemplateData{
+		Prompt: "<your prompt>",
+		Now:    time.Now(),
+		CWD:    "/home/you/project",
+		Files: []interfaces.FileInfo{
+			{
+				Path:     "/home/you/project/internal/parser/parser.go",
+				RelPath:  "internal/parser/parser.go",
+				Language: "go",
+				Content:  "package parser\n\n// Parse reads a document from input\nfunc Parse(input string) (*Document, error) {\n\treturn parse(input)\n}\n",
+			},
+			{
+				Path:     "/home/you/project/README.md",
+				RelPath:  "README.md",
+				Language: "markdown",
+				Content:  "# project\n\nA sample project.\n",
+			},
+		},
+		Git: interfaces.GitInfo{
+			Root:     "/home/you/project",
+			Branch:   "feature/parser",
+			Commit:   "3f2a9c1",
+			Dirty:    true,
+			Upstream: "origin/feature/parser",
+			Remote:   "git@example.com:you/project.git",
+			Ahead:    2,
+			RecentCommits: []interfaces.GitCommit{
+				{Hash: "3f2a9c1", Subject: "Handle empty input in the parser"},
+				{Hash: "8b71e0d", Subject: "Add the document type"},
+			},
+		},
+		Project: interfaces.ProjectInfo{
+			Root:      "/home/you/project",
+			Languages: []string{"go"},
+			Module:    "example.com/project",
+			Manifests: []interfaces.ProjectManifest{{
+				Path:         "go.mod",
+				Language:     "go",
+				Name:         "example.com/project",
+				Version:      "1.22",
+				Dependencies: []string{"github.com/spf13/cobra"},
+				Summary:      "go.mod: module example.com/project, go 1.22, 1 dependency",
+			}},
+			Frameworks: []string{"cobra"},
+			Dependencies: []interfaces.ProjectDependency{
+				{Name: "github.com/spf13/cobra", Version: "v1.8.0", Manifest: "go.mod"},
+			},
+		},
+		Tree: sampleTree,
+		Stats: interfaces.StatsInfo{
+			Files: []interfaces.FileStats{
+				{Path: "internal/parser/parser.go", Bytes: 113, Tokens: 30},
+				{Path: "README.md", Bytes: 29, Tokens: 9},
+			},
+			Bytes:  142,
+			Tokens: 39,
+		},
+		Todos: []interfaces.TodoInfo{
+			{Path: "internal/parser/parser.go", Line: 27, Tag: "TODO", Text: "report the position of syntax errors"},
+		},
+		Diff: interfaces.DiffInfo{
+			Mode:    "working",
+			Command: "git diff",
+			Raw:     samplePatch,
+			Files: []interfaces.FileDiff{{
+				Path:      "internal/parser/parser.go",
+				Status:    "modified",
+				Additions: 3,
+				Deletions: 1,
+				Hunks: []interfaces.DiffHunk{{
+					Header:   sampleHunkHeader,
+					OldStart: 12,
+					OldLines: 5,
+					NewStart: 12,
+					NewLines: 7,
+					Content:  sampleHunk,
+				}},
+				Patch: samplePatch,
+			}},
+		},
+		Config: map[string]interface{}{
+			"target": "clipboard",
+		},
+		Env: map[string]string{
+			"HOME":  "/home/you",
+			"SHELL": "/bin/bash",
+			"USER":  "you",
+		},
+		Fix: interfaces.FixInfo{
+			Command: "go test ./...",
+			Raw:     sampleOutput,
+			Output:  sampleOutput,
+			Locations: []interfaces.FixLocation{
+				{Path: "internal/parser/parser_test.go", Line: 14, Message: "Parse(\"\") error = <nil>, want ErrEmpty"},
+			},
+		},
+		Model: interfaces.ModelInfo{
+			Name:          "claude-sonnet",
+			ContextWindow: model.ContextWindow,
+			Tokenizer:     model.Tokenizer,
+			Format:        model.FileFormat,
+		},
+	}
+}
+
+// SampleVars returns a value for each variable: its default, its first option, a
+// value of its type, or a placeholder naming it
+func SampleVars(variables []Variable) map[string]string {
+	vars := make(map[string]string, len(variables))
+	for _, variable := range variables {
+		switch {
+		case variable.Default != "":
+			vars[variable.Name] = variable.Default
+		case len(variable.Options) > 0:
+			vars[variable.Name] = variable.Options[0]
+		case variable.Type == VariableNumber:
+			vars[variable.Name] = "1"
+		case variable.Type == VariableBool:
+			vars[variable.Name] = "false"
+		default:
+			vars[variable.Name] = "<" + variable.Name + ">"
+		}
+	}
+	return vars
+}
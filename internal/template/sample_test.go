@@ -0,0 +1,46 @@
+package template
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSampleData_RendersBuiltinTemplates(t *testing.T) {
+	processor := NewProcessor(t.TempDir())
+	processor.SetStrict(true)
+
+	catalog := processor.Catalog()
+	if len(catalog) == 0 {
+		t.Fatal("expected the built-in templates in the catalog")
+	}
+	for _, info := range catalog {
+		variables, err := processor.TemplateVariables(info.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl, err := processor.LoadTemplate(info.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data := SampleData()
+		data.Vars = SampleVars(variables)
+		if _, err := processor.Execute(tmpl, data); err != nil {
+			t.Errorf("%s: %v", info.Name, err)
+		}
+	}
+}
+
+func TestSampleVars(t *testing.T) {
+	vars := SampleVars([]Variable{
+		{Name: "tone", Default: "direct", Options: []string{"gentle", "direct"}},
+		{Name: "level", Type: VariableChoice, Options: []string{"low", "high"}},
+		{Name: "count", Type: VariableNumber},
+		{Name: "verbose", Type: VariableBool},
+		{Name: "language"},
+	})
+	want := map[string]string{"tone": "direct", "level": "low", "count": "1", "verbose": "false", "language": "<language>"}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("SampleVars() = %v, want %v", vars, want)
+	}
+}
@@ -0,0 +1,54 @@
+package template
+
+import "fmt"
+
+// scaffoldTemplate is the starting point written by `prompter templates new`. The
+// frontmatter is commented out and the data reference is a template comment, so a
+// fresh template renders nothing but its heading until it is edited.
+const scaffoldTemplate = `---
+# description: One line shown in the selectors and 'prompter templates list'
+# tags: [example]
+# variables:                      # Values read as .Vars.<name>, asked for interactively
+#   - name: ticket
+#     prompt: Which ticket is this for?
+#     type: string                # string, number, bool, or choice (with options: [...])
+#     default: ""
+#     required: false
+---
+{{/*
+A %s-template, added %s the base prompt. Available data:
+
+  .Prompt     the base prompt
+  .Files      included files: .Path, .RelPath, .Language, .Content
+  .Git        repository info: .Root, .Branch, .Commit, .Dirty, .Upstream, .Remote,
+              .Ahead, .Behind, .RecentCommits (.Hash, .Subject)
+  .Project    detected project: .Languages, .Frameworks, .Module, .Manifests
+              (.Path, .Name, .Version, .Dependencies, .Summary), .Dependencies
+              (.Name, .Version, .Manifest, .Indirect)
+  .Tree       files under the working directory drawn as a tree
+  .Todos      TODO, FIXME, and HACK comments found with --todos: .Path, .Line, .Tag, .Text
+  .Stats      sizes of the embedded files: .Bytes, .Tokens, .Files (.Path, .Bytes,
+              .Tokens, .Truncated, .Note), largest first
+  .Diff       changes from --diff, --staged, or --diff-against: .Command, .Raw,
+              .Files (.Path, .Status, .Additions, .Deletions, .Hunks, .Patch)
+  .CWD        working directory
+  .Now        time the prompt was generated
+  .Env        environment variables, e.g. .Env.USER
+  .Config     configuration values
+  .Fix        fix mode data: .Enabled, .Command, .Output
+  .Vars       template variables from --var, the [vars] config table, or frontmatter
+
+Run 'prompter helpers' for the functions available in templates. This comment is
+not included in the prompt.
+*/ -}}
+# %s
+`
+
+// Scaffold returns the content of a new template of kind ("pre" or "post")
+func Scaffold(name, kind string) string {
+	position := "before"
+	if kind == "post" {
+		position = "after"
+	}
+	return fmt.Sprintf(scaffoldTemplate, kind, position, name)
+}
@@ -0,0 +1,129 @@
+package template
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"prompter-cli/internal/content"
+	"prompter-cli/internal/subprocess"
+)
+
+// Limits of the exec helper when none are configured
+const (
+	DefaultExecTimeout  = 10 * time.Second
+	DefaultExecMaxBytes = 64 * 1024
+)
+
+// execEnvKeys are the environment variables exec commands inherit; the rest, such as
+// API keys and tokens, are kept from commands a template chose
+var execEnvKeys = []string{"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TMPDIR", "TERM", "LANG", "LC_ALL", "LC_CTYPE", "TZ"}
+
+// ExecOptions controls the exec helper, which runs shell commands with the user's
+// permissions and is off unless Allowed is set
+type ExecOptions struct {
+	Allowed  bool
+	Timeout  time.Duration // Commands still running are killed, 0 uses DefaultExecTimeout
+	MaxBytes int           // Output beyond this is cut, 0 uses DefaultExecMaxBytes
+}
+
+// SetExecOptions sets whether and how the exec helper runs commands
+func (p *Processor) SetExecOptions(options ExecOptions) {
+	p.execOptions = options
+}
+
+// execCommand implements the exec helper. The command runs sandboxed with sh -c from
+// the project root, with only the environment in execEnvKeys and without stdin, and is
+// killed after the timeout; it still runs as the user. Its stdout and stderr are
+// returned together, cut at the output cap, with the trailing newline removed. A
+// failing command still returns its output, followed by the exit status, so
+// templates can embed the output of failing tests.
+func (p *Processor) execCommand(command string) (string, error) {
+	options := p.execOptions
+	if !options.Allowed {
+		return "", fmt.Errorf("exec %q: running commands from templates is disabled (set allow_exec = true to enable it)", command)
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultExecTimeout
+	}
+	if options.MaxBytes <= 0 {
+		options.MaxBytes = DefaultExecMaxBytes
+	}
+
+	parent := p.context()
+	ctx, cancel := context.WithTimeout(parent, options.Timeout)
+	defer cancel()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("exec %q: %w", command, err)
+	}
+	cmd := subprocess.Shell(ctx, command)
+	cmd.Dir = content.ProjectRoot(cwd)
+	cmd.Env = execEnv()
+	output := &cappedBuffer{limit: options.MaxBytes}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	err = cmd.Run()
+	if parent.Err() != nil {
+		return "", context.Cause(parent)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("exec %q timed out after %s", command, options.Timeout)
+	}
+
+	result := strings.TrimRight(output.String(), "\r\n")
+	if output.dropped > 0 {
+		result += fmt.Sprintf("\n... (output cut at %d bytes, %d more not shown)", options.MaxBytes, output.dropped)
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		result += fmt.Sprintf("\n(exit status %d)", exitErr.ExitCode())
+	case err != nil:
+		return "", fmt.Errorf("exec %q: %w", command, err)
+	}
+	return strings.TrimLeft(result, "\n"), nil
+}
+
+// execEnv returns the variables of execEnvKeys that are set
+func execEnv() []string {
+	var env []string
+	for _, key := range execEnvKeys {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	return env
+}
+
+// cappedBuffer keeps the first limit bytes written to it and counts the rest
+type cappedBuffer struct {
+	buf     bytes.Buffer
+	limit   int
+	dropped int
+}
+
+// Write implements io.Writer, never failing so the command isn't cut off early
+func (b *cappedBuffer) Write(data []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room < len(data) {
+		if room > 0 {
+			b.buf.Write(data[:room])
+		}
+		b.dropped += len(data) - max(room, 0)
+		return len(data), nil
+	}
+	return b.buf.Write(data)
+}
+
+// String returns the bytes kept
+func (b *cappedBuffer) String() string {
+	return b.buf.String()
+}
@@ -0,0 +1,101 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"prompter-cli/internal/interfaces"
+)
+
+func TestExecHelper(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  ExecOptions
+		template string
+		expected string
+		wantErr  string
+	}{
+		{
+			name:     "disabled by default",
+			template: `{{exec "echo hi"}}`,
+			wantErr:  "allow_exec = true",
+		},
+		{
+			name:     "output",
+			options:  ExecOptions{Allowed: true},
+			template: `{{exec "echo one; echo two >&2"}}`,
+			expected: "one\ntwo",
+		},
+		{
+			name:     "failing command",
+			options:  ExecOptions{Allowed: true},
+			template: `{{exec "echo FAIL; exit 3"}}`,
+			expected: "FAIL\n(exit status 3)",
+		},
+		{
+			name:     "output cap",
+			options:  ExecOptions{Allowed: true, MaxBytes: 4},
+			template: `{{exec "printf abcdefgh"}}`,
+			expected: "abcd\n... (output cut at 4 bytes, 4 more not shown)",
+		},
+		{
+			name:     "timeout",
+			options:  ExecOptions{Allowed: true, Timeout: 50 * time.Millisecond},
+			template: `{{exec "sleep 5"}}`,
+			wantErr:  "timed out after 50ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := NewProcessor("")
+			processor.SetExecOptions(tt.options)
+			tmpl := processor.createTestTemplate(t, tt.template)
+
+			result, err := processor.Execute(tmpl, interfaces.TemplateData{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestExecHelper_Sandbox(t *testing.T) {
+	repo, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(repo, "internal", "api")
+	for _, dir := range []string{filepath.Join(repo, ".git"), sub} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(sub)
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+
+	processor := NewProcessor("")
+	processor.SetExecOptions(ExecOptions{Allowed: true})
+	tmpl := processor.createTestTemplate(t, `{{exec "pwd; echo ${OPENAI_API_KEY:-unset}; test -n \"$PATH\" && echo path"}}`)
+
+	result, err := processor.Execute(tmpl, interfaces.TemplateData{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Commands run from the project root without the user's secrets, but can find tools
+	if want := repo + "\nunset\npath"; result != want {
+		t.Errorf("expected %q, got %q", want, result)
+	}
+}
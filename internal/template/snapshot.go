@@ -0,0 +1,310 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"prompter-cli/internal/interfaces"
+)
+
+const (
+	// FixtureSuffix is appended to a template filename to name its fixture data file
+	FixtureSuffix = ".testdata.json"
+	// GoldenSuffix is appended to a template filename to name its expected output file
+	GoldenSuffix = ".golden"
+
+	// TestsDir is the directory of a prompts location holding a <template>/ directory
+	// of fixture data and expected output for each tested template
+	TestsDir = "tests"
+	// TestInputFile holds the fixture data in a tests/<template>/ directory
+	TestInputFile = "input.json"
+	// TestExpectedFile holds the expected output in a tests/<template>/ directory
+	TestExpectedFile = "expected.md"
+)
+
+// fixture pairs a template with the data it is rendered with and its expected output
+type fixture struct {
+	name         string
+	templatePath string // Empty when no template has the name
+	dataPath     string
+	goldenPath   string
+}
+
+// SnapshotResult describes the outcome of rendering one template fixture
+type SnapshotResult struct {
+	Name         string
+	TemplatePath string
+	GoldenPath   string
+	Passed       bool
+	Updated      bool
+	Diff         string
+	Err          error
+}
+
+// RunSnapshots renders every template that has a fixture file beside it, or a
+// tests/<template>/ directory in a prompts location, and compares the output against
+// its golden file. When update is true the golden
+// files are rewritten instead of compared. An empty names slice runs all fixtures.
+func (p *Processor) RunSnapshots(names []string, update bool) ([]SnapshotResult, error) {
+	fixtures, err := p.findFixtures()
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		wanted[strings.ToLower(name)] = true
+	}
+
+	var results []SnapshotResult
+	seen := make(map[string]bool)
+	for _, fixture := range fixtures {
+		if len(wanted) > 0 && !wanted[strings.ToLower(fixture.name)] {
+			continue
+		}
+		seen[strings.ToLower(fixture.name)] = true
+
+		results = append(results, p.runSnapshot(fixture, update))
+	}
+
+	for _, name := range names {
+		if !seen[strings.ToLower(name)] {
+			return results, fmt.Errorf("no fixture found for template: %s", name)
+		}
+	}
+
+	return results, nil
+}
+
+// runSnapshot renders a single template against its fixture
+func (p *Processor) runSnapshot(fixture fixture, update bool) SnapshotResult {
+	result := SnapshotResult{
+		Name:         fixture.name,
+		TemplatePath: fixture.templatePath,
+		GoldenPath:   fixture.goldenPath,
+	}
+	if fixture.templatePath == "" {
+		result.Err = fmt.Errorf("no template found for %s", filepath.Dir(fixture.dataPath))
+		return result
+	}
+
+	data, err := loadFixture(fixture.dataPath)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	tmpl, err := p.loadTemplateFromPath(fixture.templatePath)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	actual, err := p.Execute(tmpl, *data)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	if update {
+		if err := os.WriteFile(result.GoldenPath, []byte(actual), 0644); err != nil {
+			result.Err = fmt.Errorf("failed to write golden file %s: %w", result.GoldenPath, err)
+			return result
+		}
+		result.Passed = true
+		result.Updated = true
+		return result
+	}
+
+	expected, err := os.ReadFile(result.GoldenPath)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to read golden file %s (run with --update to create it): %w", result.GoldenPath, err)
+		return result
+	}
+
+	if string(expected) == actual {
+		result.Passed = true
+		return result
+	}
+
+	result.Diff = LineDiff(string(expected), actual)
+	return result
+}
+
+// findFixtures returns the fixture files in the pre and post directories of every
+// prompt location, followed by the tests/<template>/ directories, each sorted by path.
+// Templates of tests directories are looked up by name like any other template, with
+// nested directories such as tests/go/review/ naming namespaced templates.
+func (p *Processor) findFixtures() ([]fixture, error) {
+	type found struct{ name, path string }
+	var beside, tests []found
+	searched := make(map[string]bool)
+	for _, location := range p.GetPromptLocations() {
+		if location == "" {
+			continue
+		}
+		// The local prompts location is often the global one when run from its parent
+		if absolute, err := filepath.Abs(location); err == nil {
+			location = absolute
+		}
+		if searched[location] {
+			continue
+		}
+		searched[location] = true
+		for _, subdir := range []string{"pre", "post"} {
+			root := filepath.Join(location, subdir)
+			matches, err := findFiles(root, func(name string) bool {
+				return strings.HasSuffix(name, ".md"+FixtureSuffix)
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to search for fixtures: %w", err)
+			}
+			for _, match := range matches {
+				name := templateStem(strings.TrimSuffix(match, FixtureSuffix))
+				if namespace := path.Dir(match); namespace != "." {
+					name = namespace + "/" + name
+				}
+				beside = append(beside, found{name, filepath.Join(root, filepath.FromSlash(match))})
+			}
+		}
+
+		root := filepath.Join(location, TestsDir)
+		matches, err := findFiles(root, func(name string) bool { return name == TestInputFile })
+		if err != nil {
+			return nil, fmt.Errorf("failed to search for fixtures: %w", err)
+		}
+		for _, match := range matches {
+			if name := path.Dir(match); name != "." {
+				tests = append(tests, found{name, filepath.Join(root, filepath.FromSlash(match))})
+			}
+		}
+	}
+	byPath := func(list []found) func(i, j int) bool {
+		return func(i, j int) bool { return list[i].path < list[j].path }
+	}
+	sort.Slice(beside, byPath(beside))
+	sort.Slice(tests, byPath(tests))
+
+	var fixtures []fixture
+	for _, data := range beside {
+		templatePath := strings.TrimSuffix(data.path, FixtureSuffix)
+		fixtures = append(fixtures, fixture{
+			name:         data.name,
+			templatePath: templatePath,
+			dataPath:     data.path,
+			goldenPath:   templatePath + GoldenSuffix,
+		})
+	}
+	for _, data := range tests {
+		templatePath, _ := p.discoverTemplate(data.name)
+		fixtures = append(fixtures, fixture{
+			name:         data.name,
+			templatePath: templatePath,
+			dataPath:     data.path,
+			goldenPath:   filepath.Join(filepath.Dir(data.path), TestExpectedFile),
+		})
+	}
+	return fixtures, nil
+}
+
+// findFiles returns the paths of the files below root whose names match, relative to
+// root and with forward slashes. Hidden directories are skipped, and a missing root
+// has no files.
+func findFiles(root string, match func(name string) bool) ([]string, error) {
+	var found []string
+	err := filepath.WalkDir(root, func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if current == root && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() {
+			if current != root && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if match(entry.Name()) {
+			rel, err := filepath.Rel(root, current)
+			if err != nil {
+				return err
+			}
+			found = append(found, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return found, err
+}
+
+// loadFixture decodes a fixture file into template data
+func loadFixture(path string) (*interfaces.TemplateData, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+	}
+
+	var data interfaces.TemplateData
+	if err := json.Unmarshal(content, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+
+	return &data, nil
+}
+
+// templateStem returns the template display name for a template path
+func templateStem(path string) string {
+	stem := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	stem = strings.ReplaceAll(stem, ".default.", ".")
+	stem = strings.TrimSuffix(stem, ".default")
+	return strings.Trim(stem, ".")
+}
+
+// LineDiff returns a minimal line-based diff between expected and actual text.
+// Removed lines are prefixed with "-" and added lines with "+".
+func LineDiff(expected, actual string) string {
+	a := strings.Split(expected, "\n")
+	b := strings.Split(actual, "\n")
+
+	// Longest common subsequence table
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			out.WriteString("+ " + b[j] + "\n")
+			j++
+		default:
+			out.WriteString("- " + a[i] + "\n")
+			i++
+		}
+	}
+
+	return out.String()
+}
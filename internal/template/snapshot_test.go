@@ -0,0 +1,126 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessor_RunSnapshots(t *testing.T) {
+	tempDir := t.TempDir()
+	preDir := filepath.Join(tempDir, "pre")
+	if err := os.MkdirAll(preDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	templatePath := filepath.Join(preDir, "review.md")
+	if err := os.WriteFile(templatePath, []byte("Review: {{.Prompt}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(templatePath+FixtureSuffix, []byte(`{"prompt": "the parser"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	processor := NewProcessor(tempDir)
+
+	// Missing golden file is reported as an error
+	results, err := processor.RunSnapshots(nil, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("expected one result with missing golden error, got %+v", results)
+	}
+
+	// Update writes the golden file
+	results, err = processor.RunSnapshots([]string{"review"}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !results[0].Updated {
+		t.Fatalf("expected golden file to be updated")
+	}
+	golden, err := os.ReadFile(templatePath + GoldenSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(golden) != "Review: the parser" {
+		t.Errorf("golden = %q, want %q", golden, "Review: the parser")
+	}
+
+	// Subsequent runs pass
+	results, _ = processor.RunSnapshots(nil, false)
+	if !results[0].Passed {
+		t.Errorf("expected snapshot to pass, got diff:\n%s", results[0].Diff)
+	}
+
+	// Changing the template produces a diff
+	if err := os.WriteFile(templatePath, []byte("Please review: {{.Prompt}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results, _ = processor.RunSnapshots(nil, false)
+	if results[0].Passed {
+		t.Fatalf("expected snapshot to fail after template change")
+	}
+	if !strings.Contains(results[0].Diff, "- Review: the parser") || !strings.Contains(results[0].Diff, "+ Please review: the parser") {
+		t.Errorf("unexpected diff:\n%s", results[0].Diff)
+	}
+
+	// Unknown names are an error
+	if _, err := processor.RunSnapshots([]string{"missing"}, false); err == nil {
+		t.Errorf("expected error for template without fixture")
+	}
+}
+
+func TestProcessor_RunSnapshotsTestsDir(t *testing.T) {
+	tempDir := t.TempDir()
+	for path, content := range map[string]string{
+		"pre/review.md":             "Review: {{.Prompt}}",
+		"tests/review/input.json":   `{"prompt": "the parser"}`,
+		"tests/review/expected.md":  "Review: the lexer",
+		"tests/missing/input.json":  `{}`,
+		"tests/missing/expected.md": "",
+	} {
+		path = filepath.Join(tempDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	processor := NewProcessor(tempDir)
+
+	results, err := processor.RunSnapshots(nil, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected two results, got %+v", results)
+	}
+	if results[0].Name != "missing" || results[0].Err == nil {
+		t.Errorf("expected an error for the test without a template, got %+v", results[0])
+	}
+	if results[1].Name != "review" || results[1].Passed || !strings.Contains(results[1].Diff, "+ Review: the parser") {
+		t.Errorf("expected a diff against expected.md, got %+v", results[1])
+	}
+
+	// Update rewrites expected.md
+	if _, err := processor.RunSnapshots([]string{"review"}, true); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := os.ReadFile(filepath.Join(tempDir, "tests", "review", "expected.md"))
+	if err != nil || string(expected) != "Review: the parser" {
+		t.Errorf("expected.md = %q, %v", expected, err)
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	diff := LineDiff("a\nb\nc", "a\nx\nc")
+	want := "  a\n+ x\n- b\n  c\n"
+	if diff != want {
+		t.Errorf("LineDiff() = %q, want %q", diff, want)
+	}
+}
@@ -0,0 +1,72 @@
+package template
+
+import (
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"prompter-cli/internal/suggest"
+)
+
+// maxSuggestions is how many similar names a NotFoundError suggests
+const maxSuggestions = 3
+
+// templateNames returns the names of the pre and post templates the processor can
+// resolve, built-in ones included, without loading them
+func (p *Processor) templateNames() []string {
+	var names []string
+	for _, dir := range p.searchDirs() {
+		for _, kind := range []string{"pre", "post"} {
+			files, _ := ReadTemplateDir(filepath.Join(dir.dir, kind))
+			for _, file := range files {
+				names = append(names, file.Name)
+			}
+		}
+	}
+	for _, kind := range []string{"pre", "post"} {
+		names = append(names, EmbeddedTemplateNames(kind)...)
+	}
+
+	// A template shadowing another of the same name is suggested once
+	slices.SortFunc(names, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+	return slices.CompactFunc(names, strings.EqualFold)
+}
+
+// Closest returns up to limit of names that look like a misspelling of name, closest
+// first. Names containing name, or contained in it, count as close, so "review" finds
+// "code-review" too.
+func Closest(name string, names []string, limit int) []string {
+	type match struct {
+		name     string
+		distance int
+	}
+
+	wanted := strings.ToLower(name)
+	threshold := max(len(wanted)/3, 2)
+	var matches []match
+	for _, candidate := range names {
+		lower := strings.ToLower(candidate)
+		distance := suggest.Distance(wanted, lower)
+		if strings.Contains(lower, wanted) || strings.Contains(wanted, lower) {
+			distance = min(distance, 1)
+		}
+		if distance <= threshold {
+			matches = append(matches, match{candidate, distance})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+
+	var closest []string
+	for _, m := range matches {
+		if len(closest) == limit {
+			break
+		}
+		closest = append(closest, m.name)
+	}
+	return closest
+}
@@ -0,0 +1,26 @@
+package template
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClosest(t *testing.T) {
+	names := []string{"code-review", "explain", "review", "reviewer", "tests"}
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{"reveiw", 5, []string{"review"}},
+		{"Review", 2, []string{"review", "code-review"}},
+		{"explian", 5, []string{"explain"}},
+		{"deploy", 5, nil},
+	}
+	for _, tt := range tests {
+		if got := Closest(tt.name, names, tt.limit); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Closest(%q, %d) = %v, want %v", tt.name, tt.limit, got, tt.want)
+		}
+	}
+}
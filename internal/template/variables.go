@@ -0,0 +1,102 @@
+package template
+
+import (
+	"text/template"
+	"text/template/parse"
+)
+
+// TemplateVariables returns the variables a template reads: those declared in its
+// frontmatter, followed by any other .Vars fields it references
+func (p *Processor) TemplateVariables(nameOrPath string) ([]Variable, error) {
+	tmpl, err := p.LoadTemplate(nameOrPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var variables []Variable
+	declared := make(map[string]bool)
+	if meta := p.metadata[tmpl]; meta != nil {
+		for _, variable := range meta.Variables {
+			variables = append(variables, variable)
+			declared[variable.Name] = true
+		}
+	}
+
+	for _, name := range referencedVars(tmpl) {
+		if !declared[name] {
+			variables = append(variables, Variable{Name: name})
+			declared[name] = true
+		}
+	}
+
+	return variables, nil
+}
+
+// referencedVars lists the .Vars fields used in a template (such as ticket in
+// {{.Vars.ticket}} or {{$.Vars.ticket}}) in order of first use
+func referencedVars(tmpl *template.Template) []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	addIdent := func(ident []string) {
+		if len(ident) > 0 && ident[0] == "$" {
+			ident = ident[1:]
+		}
+		if len(ident) >= 2 && ident[0] == "Vars" && !seen[ident[1]] {
+			seen[ident[1]] = true
+			names = append(names, ident[1])
+		}
+	}
+
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			addIdent(append([]string{"$"}, n.Ident...))
+		case *parse.VariableNode:
+			addIdent(n.Ident)
+		}
+	}
+
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			walk(t.Tree.Root)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,215 @@
+package template
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"prompter-cli/internal/interfaces"
+)
+
+// defaultWasmTimeout bounds a single helper call when the plugin doesn't set timeout_ms
+const defaultWasmTimeout = 5 * time.Second
+
+// wasmMaxMemoryPages caps the linear memory of each module at 64MiB (64KiB pages);
+// growing past it fails the call instead of taking the host's memory
+const wasmMaxMemoryPages = 1024
+
+// wasmRuntime loads WebAssembly plugins and exposes their exports as template functions.
+//
+// Plugin ABI: a module exports its linear memory as "memory", an allocator
+// "alloc(size i32) i32", and one function per helper with the signature
+// "(ptr i32, len i32) i64". The input is a JSON array of the helper arguments
+// and the result is packed as (ptr << 32 | len) pointing at UTF-8 output.
+// Modules may export "dealloc(ptr i32, size i32)" to free the input and the output,
+// which must be allocated separately, after each call; those that don't are
+// instantiated again for the next call, so memory they never free doesn't build up.
+//
+// Modules run sandboxed: WASI is available for language runtimes that need it
+// but no filesystem, environment, or arguments are exposed, and memory is capped at
+// wasmMaxMemoryPages.
+type wasmRuntime struct {
+	mu       sync.Mutex
+	runtime  wazero.Runtime
+	compiled map[string]wazero.CompiledModule
+	modules  map[string]api.Module
+	stamps   map[string]templateStamp // Version of the file each module was compiled from
+}
+
+// newWasmRuntime creates a runtime that aborts calls when their context expires
+func newWasmRuntime(ctx context.Context) *wasmRuntime {
+	config := wazero.NewRuntimeConfig().WithCloseOnContextDone(true).WithMemoryLimitPages(wasmMaxMemoryPages)
+	rt := wazero.NewRuntimeWithConfig(ctx, config)
+	wasi_snapshot_preview1.MustInstantiate(ctx, rt)
+	return &wasmRuntime{
+		runtime:  rt,
+		compiled: make(map[string]wazero.CompiledModule),
+		modules:  make(map[string]api.Module),
+		stamps:   make(map[string]templateStamp),
+	}
+}
+
+// close closes every module and the runtime
+func (w *wasmRuntime) close(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.compiled = make(map[string]wazero.CompiledModule)
+	w.modules = make(map[string]api.Module)
+	w.stamps = make(map[string]templateStamp)
+	return w.runtime.Close(ctx)
+}
+
+// load compiles and instantiates a plugin module, reusing the one loaded earlier
+// while its file is unchanged
+func (w *wasmRuntime) load(ctx context.Context, name string, plugin interfaces.WasmPlugin) (api.Module, error) {
+	info, err := os.Stat(plugin.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wasm plugin %s: %w", name, err)
+	}
+	stamp := templateStamp{modTime: info.ModTime().UnixNano(), size: info.Size()}
+	if compiled, ok := w.compiled[name]; ok && w.stamps[name] != stamp {
+		// Rebuilt since it was loaded; the name must be free to instantiate it again
+		w.unload(ctx, name)
+		compiled.Close(ctx)
+		delete(w.compiled, name)
+	}
+	if mod, ok := w.modules[name]; ok {
+		return mod, nil
+	}
+
+	compiled, ok := w.compiled[name]
+	if !ok {
+		wasm, err := os.ReadFile(plugin.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read wasm plugin %s: %w", name, err)
+		}
+		if compiled, err = w.runtime.CompileModule(ctx, wasm); err != nil {
+			return nil, fmt.Errorf("failed to compile wasm plugin %s: %w", name, err)
+		}
+		w.compiled[name] = compiled
+		w.stamps[name] = stamp
+	}
+
+	config := wazero.NewModuleConfig().WithName(name).WithStartFunctions("_initialize")
+	mod, err := w.runtime.InstantiateModule(ctx, compiled, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate wasm plugin %s: %w", name, err)
+	}
+
+	if mod.ExportedFunction("alloc") == nil || mod.Memory() == nil {
+		mod.Close(ctx)
+		return nil, fmt.Errorf("wasm plugin %s must export \"memory\" and \"alloc\"", name)
+	}
+
+	w.modules[name] = mod
+	return mod, nil
+}
+
+// unload closes the instance of a plugin module, if any, so the next call starts
+// from fresh memory
+func (w *wasmRuntime) unload(ctx context.Context, name string) {
+	if mod, ok := w.modules[name]; ok {
+		mod.Close(ctx)
+		delete(w.modules, name)
+	}
+}
+
+// call invokes an exported helper with JSON-encoded arguments
+func (w *wasmRuntime) call(parent context.Context, name string, plugin interfaces.WasmPlugin, function string, args []interface{}) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	timeout := defaultWasmTimeout
+	if plugin.TimeoutMS > 0 {
+		timeout = time.Duration(plugin.TimeoutMS) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	mod, err := w.load(ctx, name, plugin)
+	if err != nil {
+		return "", err
+	}
+
+	fn := mod.ExportedFunction(function)
+	if fn == nil {
+		return "", fmt.Errorf("wasm plugin %s does not export %s", name, function)
+	}
+
+	if args == nil {
+		args = []interface{}{}
+	}
+	input, err := json.Marshal(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode arguments for %s: %w", function, err)
+	}
+
+	allocated, err := mod.ExportedFunction("alloc").Call(ctx, uint64(len(input)))
+	if err != nil {
+		return "", fmt.Errorf("wasm plugin %s alloc failed: %w", name, err)
+	}
+	ptr := uint32(allocated[0])
+	if !mod.Memory().Write(ptr, input) {
+		return "", fmt.Errorf("wasm plugin %s returned an out of range allocation", name)
+	}
+
+	results, err := fn.Call(ctx, uint64(ptr), uint64(len(input)))
+	if err != nil {
+		// A timed-out call closes the module, so drop it and reinstantiate next time
+		w.unload(ctx, name)
+		return "", fmt.Errorf("wasm helper %s failed: %w", function, err)
+	}
+
+	outPtr, outLen := uint32(results[0]>>32), uint32(results[0])
+	view, ok := mod.Memory().Read(outPtr, outLen)
+	if !ok {
+		w.unload(ctx, name)
+		return "", fmt.Errorf("wasm helper %s returned an out of range result", function)
+	}
+	output := string(view) // Copied, since freeing may reuse the memory
+
+	dealloc := mod.ExportedFunction("dealloc")
+	if dealloc == nil {
+		w.unload(ctx, name)
+		return output, nil
+	}
+	for _, buffer := range [][2]uint32{{ptr, uint32(len(input))}, {outPtr, outLen}} {
+		if _, err := dealloc.Call(ctx, uint64(buffer[0]), uint64(buffer[1])); err != nil {
+			w.unload(ctx, name)
+			return "", fmt.Errorf("wasm plugin %s dealloc failed: %w", name, err)
+		}
+	}
+	return output, nil
+}
+
+// funcMap returns template functions for every configured plugin export
+func (w *wasmRuntime) funcMap(contextOf func() context.Context, plugins map[string]interfaces.WasmPlugin) template.FuncMap {
+	funcs := template.FuncMap{}
+
+	// Register in a stable order so name collisions resolve deterministically
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		plugin := plugins[name]
+		for _, function := range plugin.Functions {
+			pluginName, pluginConfig, functionName := name, plugin, function
+			funcs[function] = func(args ...interface{}) (string, error) {
+				return w.call(contextOf(), pluginName, pluginConfig, functionName, args)
+			}
+		}
+	}
+
+	return funcs
+}
@@ -0,0 +1,184 @@
+package template
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompter-cli/internal/interfaces"
+)
+
+// echoWasm is a hand-assembled module implementing the plugin ABI. Its "echo"
+// export returns the JSON-encoded argument array it receives.
+var echoWasm = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, // magic + version
+	// type section: (i32)->i32, (i32,i32)->i64
+	0x01, 0x0c, 0x02, 0x60, 0x01, 0x7f, 0x01, 0x7f, 0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e,
+	// function section: alloc, echo
+	0x03, 0x03, 0x02, 0x00, 0x01,
+	// memory section: one page
+	0x05, 0x03, 0x01, 0x00, 0x01,
+	// global section: mutable i32 heap pointer starting at 1024
+	0x06, 0x07, 0x01, 0x7f, 0x01, 0x41, 0x80, 0x08, 0x0b,
+	// export section: memory, alloc, echo
+	0x07, 0x19, 0x03,
+	0x06, 'm', 'e', 'm', 'o', 'r', 'y', 0x02, 0x00,
+	0x05, 'a', 'l', 'l', 'o', 'c', 0x00, 0x00,
+	0x04, 'e', 'c', 'h', 'o', 0x00, 0x01,
+	// code section
+	0x0a, 0x1a, 0x02,
+	// alloc: return heap pointer and bump it by size
+	0x0b, 0x00, 0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, 0x0b,
+	// echo: return (ptr << 32) | len
+	0x0c, 0x00, 0x20, 0x00, 0xad, 0x42, 0x20, 0x86, 0x20, 0x01, 0xad, 0x84, 0x0b,
+}
+
+func TestProcessor_WasmPluginHelpers(t *testing.T) {
+	tempDir := t.TempDir()
+	pluginPath := filepath.Join(tempDir, "echo.wasm")
+	if err := os.WriteFile(pluginPath, echoWasm, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	templatePath := filepath.Join(tempDir, "echo.md")
+	if err := os.WriteFile(templatePath, []byte(`{{echo "hi" 3}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	processor := NewProcessor(tempDir)
+	processor.SetWasmPlugins(map[string]interfaces.WasmPlugin{
+		"echo": {Path: pluginPath, Functions: []string{"echo"}},
+	})
+
+	tmpl, err := processor.LoadTemplate(templatePath)
+	if err != nil {
+		t.Fatalf("failed to load template: %v", err)
+	}
+
+	result, err := processor.Execute(tmpl, interfaces.TemplateData{})
+	if err != nil {
+		t.Fatalf("failed to execute template: %v", err)
+	}
+
+	if result != `["hi",3]` {
+		t.Errorf("result = %q, want %q", result, `["hi",3]`)
+	}
+}
+
+func TestProcessor_WasmPluginMissingExport(t *testing.T) {
+	tempDir := t.TempDir()
+	pluginPath := filepath.Join(tempDir, "echo.wasm")
+	if err := os.WriteFile(pluginPath, echoWasm, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	templatePath := filepath.Join(tempDir, "missing.md")
+	if err := os.WriteFile(templatePath, []byte(`{{shout "hi"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	processor := NewProcessor(tempDir)
+	processor.SetWasmPlugins(map[string]interfaces.WasmPlugin{
+		"echo": {Path: pluginPath, Functions: []string{"shout"}},
+	})
+
+	tmpl, err := processor.LoadTemplate(templatePath)
+	if err != nil {
+		t.Fatalf("failed to load template: %v", err)
+	}
+
+	if _, err := processor.Execute(tmpl, interfaces.TemplateData{}); err == nil {
+		t.Errorf("expected error calling a function the module doesn't export")
+	}
+}
+
+func TestProcessor_WasmPluginReload(t *testing.T) {
+	tempDir := t.TempDir()
+	pluginPath := filepath.Join(tempDir, "echo.wasm")
+	if err := os.WriteFile(pluginPath, echoWasm, 0644); err != nil {
+		t.Fatal(err)
+	}
+	templatePath := filepath.Join(tempDir, "echo.md")
+	if err := os.WriteFile(templatePath, []byte(`{{echo "hi"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	processor := NewProcessor(tempDir)
+	execute := func() error {
+		t.Helper()
+		tmpl, err := processor.LoadTemplate(templatePath)
+		if err != nil {
+			t.Fatalf("failed to load template: %v", err)
+		}
+		_, err = processor.Execute(tmpl, interfaces.TemplateData{})
+		return err
+	}
+
+	processor.SetWasmPlugins(map[string]interfaces.WasmPlugin{
+		"echo": {Path: pluginPath, Functions: []string{"echo"}},
+	})
+	if err := execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A plugin pointed at another file is loaded from it rather than the old module
+	processor.SetWasmPlugins(map[string]interfaces.WasmPlugin{
+		"echo": {Path: filepath.Join(tempDir, "missing.wasm"), Functions: []string{"echo"}},
+	})
+	if err := execute(); err == nil {
+		t.Error("expected an error after pointing the plugin at a missing file")
+	}
+
+	// A rebuilt module file is reloaded
+	processor.SetWasmPlugins(map[string]interfaces.WasmPlugin{
+		"echo": {Path: pluginPath, Functions: []string{"echo"}},
+	})
+	if err := execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(pluginPath, []byte("not wasm"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := execute(); err == nil {
+		t.Error("expected an error after the plugin file was replaced")
+	}
+}
+
+func TestWasmRuntime_NoDeallocStartsFresh(t *testing.T) {
+	pluginPath := filepath.Join(t.TempDir(), "echo.wasm")
+	if err := os.WriteFile(pluginPath, echoWasm, 0644); err != nil {
+		t.Fatal(err)
+	}
+	runtime := newWasmRuntime(context.Background())
+	defer runtime.close(context.Background())
+	plugin := interfaces.WasmPlugin{Path: pluginPath, Functions: []string{"echo"}}
+
+	// echo never frees and has one page, so reusing the instance would run out
+	// after a few dozen calls
+	arg := strings.Repeat("x", 4096)
+	for i := range 100 {
+		if _, err := runtime.call(context.Background(), "echo", plugin, "echo", []interface{}{arg}); err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+	}
+}
+
+func TestWasmRuntime_MemoryLimit(t *testing.T) {
+	// The same module asking for 2048 pages (128MiB) of memory up front
+	memory := []byte{0x05, 0x03, 0x01, 0x00, 0x01}
+	greedy := bytes.Replace(echoWasm, memory, []byte{0x05, 0x04, 0x01, 0x00, 0x80, 0x10}, 1)
+	pluginPath := filepath.Join(t.TempDir(), "greedy.wasm")
+	if err := os.WriteFile(pluginPath, greedy, 0644); err != nil {
+		t.Fatal(err)
+	}
+	runtime := newWasmRuntime(context.Background())
+	defer runtime.close(context.Background())
+
+	plugin := interfaces.WasmPlugin{Path: pluginPath, Functions: []string{"echo"}}
+	if _, err := runtime.call(context.Background(), "greedy", plugin, "echo", nil); err == nil {
+		t.Error("expected a module over the memory limit to fail")
+	}
+}
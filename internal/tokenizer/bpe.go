@@ -0,0 +1,146 @@
+package tokenizer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Pre-tokenization patterns of the tiktoken encodings. The originals end with
+// `\s+(?!\S)|\s+`; Go's regexp has no lookahead, so pretokenize emulates it.
+var (
+	cl100kPattern = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`)
+	o200kPattern  = regexp.MustCompile(`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+`)
+)
+
+// BPE is a tiktoken-compatible byte pair encoding
+type BPE struct {
+	name    string
+	ranks   map[string]int
+	pattern *regexp.Regexp
+}
+
+// NewBPE creates an encoding from merge ranks, where a lower rank merges first.
+// The pre-tokenization pattern is chosen by name (o200k_base or cl100k_base).
+func NewBPE(name string, ranks map[string]int) *BPE {
+	pattern := cl100kPattern
+	if name == O200KBase {
+		pattern = o200kPattern
+	}
+	return &BPE{name: name, ranks: ranks, pattern: pattern}
+}
+
+// LoadBPE reads a tiktoken rank file, one base64 token and its rank per line
+func LoadBPE(name, path string) (*BPE, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tokenizer file: %w", err)
+	}
+	defer file.Close()
+
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected a token and a rank", path, line)
+		}
+		token, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid token: %w", path, line, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid rank: %w", path, line, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tokenizer file: %w", err)
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("tokenizer file %s has no tokens", path)
+	}
+
+	return NewBPE(name, ranks), nil
+}
+
+// Name returns the encoding name
+func (b *BPE) Name() string { return b.name }
+
+// Count returns the number of tokens text encodes to
+func (b *BPE) Count(text string) int {
+	count := 0
+	for _, piece := range pretokenize(text, b.pattern) {
+		if _, ok := b.ranks[piece]; ok {
+			count++
+			continue
+		}
+		count += b.mergeCount(piece)
+	}
+	return count
+}
+
+// mergeCount applies the lowest-ranked merges to the bytes of piece until none
+// apply, returning the number of tokens left
+func (b *BPE) mergeCount(piece string) int {
+	// bounds[i] is where the i-th part starts; the last entry is len(piece)
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+
+	for len(bounds) > 2 {
+		best, at := math.MaxInt, -1
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := b.ranks[piece[bounds[i]:bounds[i+2]]]; ok && rank < best {
+				best, at = rank, i
+			}
+		}
+		if at < 0 {
+			break
+		}
+		bounds = append(bounds[:at+1], bounds[at+2:]...)
+	}
+
+	return len(bounds) - 1
+}
+
+// pretokenize splits text into the pieces byte pair merges are applied within
+func pretokenize(text string, pattern *regexp.Regexp) []string {
+	var pieces []string
+	for pos := 0; pos < len(text); {
+		loc := pattern.FindStringIndex(text[pos:])
+		if loc == nil || loc[1] == 0 {
+			pieces = append(pieces, text[pos:])
+			break
+		}
+		start, end := pos+loc[0], pos+loc[1]
+		if start > pos {
+			pieces = append(pieces, text[pos:start])
+		}
+
+		// `\s+(?!\S)`: a whitespace run followed by text leaves its last character to
+		// the next piece, so " word" stays together
+		match := text[start:end]
+		if end < len(text) && strings.TrimSpace(match) == "" && !strings.ContainsAny(match, "\r\n") {
+			if _, size := utf8.DecodeLastRuneInString(match); size < len(match) {
+				end -= size
+			}
+		}
+
+		pieces = append(pieces, text[start:end])
+		pos = end
+	}
+	return pieces
+}
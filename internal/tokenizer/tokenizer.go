@@ -0,0 +1,172 @@
+// Package tokenizer counts the tokens a language model would see in a prompt.
+// Exact counts come from tiktoken-compatible byte pair encodings loaded from a rank
+// file; without one, an approximation of Claude's tokenizer is used.
+package tokenizer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer names accepted by New, besides the model names mapped by EncodingForModel
+const (
+	Claude     = "claude"      // Approximation of Claude's tokenizer, needs no data
+	Bytes      = "bytes"       // Four bytes per token, the original estimate
+	CL100KBase = "cl100k_base" // GPT-4 and GPT-3.5 byte pair encoding, needs a rank file
+	O200KBase  = "o200k_base"  // GPT-4o and later byte pair encoding, needs a rank file
+)
+
+// Default is the tokenizer used when none is configured
+const Default = Claude
+
+// Tokenizer counts tokens in text
+type Tokenizer interface {
+	// Name identifies the encoding, e.g. "claude" or "cl100k_base"
+	Name() string
+
+	// Count returns the number of tokens text encodes to
+	Count(text string) int
+}
+
+// modelEncodings maps model name prefixes to encodings; the longest matching prefix wins
+var modelEncodings = []struct {
+	prefix   string
+	encoding string
+}{
+	{"gpt-3.5", CL100KBase},
+	{"gpt-4o", O200KBase},
+	{"gpt-4.1", O200KBase},
+	{"gpt-4.5", O200KBase},
+	{"gpt-4", CL100KBase},
+	{"gpt-5", O200KBase},
+	{"chatgpt-4o", O200KBase},
+	{"o1", O200KBase},
+	{"o3", O200KBase},
+	{"o4", O200KBase},
+	{"text-embedding-3", CL100KBase},
+	{"text-embedding-ada-002", CL100KBase},
+	{"claude", Claude},
+}
+
+// EncodingForModel returns the encoding for a tokenizer or model name, such as
+// "gpt-4o" or "claude-sonnet-4", reporting whether the name is known
+func EncodingForModel(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "":
+		return Default, true
+	case Claude, Bytes, CL100KBase, O200KBase:
+		return name, true
+	}
+
+	best := ""
+	encoding := ""
+	for _, model := range modelEncodings {
+		if strings.HasPrefix(name, model.prefix) && len(model.prefix) > len(best) {
+			best, encoding = model.prefix, model.encoding
+		}
+	}
+	return encoding, encoding != ""
+}
+
+// Names lists the encodings accepted by New
+func Names() []string {
+	names := []string{Claude, Bytes, CL100KBase, O200KBase}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that a tokenizer name can be resolved and that byte pair
+// encodings have a rank file
+func Validate(name, rankFile string) error {
+	encoding, ok := EncodingForModel(name)
+	if !ok {
+		return fmt.Errorf("unknown tokenizer %q (must be a model name or one of %s)", name, strings.Join(Names(), ", "))
+	}
+	if (encoding == CL100KBase || encoding == O200KBase) && rankFile == "" {
+		return fmt.Errorf("tokenizer %s needs tokenizer_file pointing at its .tiktoken rank file", encoding)
+	}
+	return nil
+}
+
+// New returns the tokenizer for a tokenizer or model name. Byte pair encodings are
+// loaded from rankFile, a tiktoken rank file such as cl100k_base.tiktoken.
+func New(name, rankFile string) (Tokenizer, error) {
+	if err := Validate(name, rankFile); err != nil {
+		return nil, err
+	}
+
+	encoding, _ := EncodingForModel(name)
+	switch encoding {
+	case Claude:
+		return NewApproximate(), nil
+	case Bytes:
+		return byteEstimate{}, nil
+	default:
+		return LoadBPE(encoding, rankFile)
+	}
+}
+
+// byteEstimate counts four bytes per token
+type byteEstimate struct{}
+
+func (byteEstimate) Name() string { return Bytes }
+
+func (byteEstimate) Count(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// Approximate estimates Claude's tokenization from the shape of the text: short words
+// and numbers are single tokens, long words split every few letters, CJK characters
+// cost a token each, and runs of punctuation or whitespace merge.
+type Approximate struct{}
+
+// NewApproximate returns the Claude approximation
+func NewApproximate() Approximate {
+	return Approximate{}
+}
+
+// Name returns "claude"
+func (Approximate) Name() string { return Claude }
+
+// Count estimates the tokens in text
+func (Approximate) Count(text string) int {
+	count := 0
+	for _, piece := range pretokenize(text, cl100kPattern) {
+		count += approximatePiece(piece)
+	}
+	return count
+}
+
+// approximatePiece estimates the tokens in one pre-tokenized piece
+func approximatePiece(piece string) int {
+	var letters, wide, other, digits, punct int
+	for _, r := range piece {
+		switch {
+		case r >= 0x2E80 && (unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+			unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)):
+			wide++
+		case unicode.IsLetter(r) && r < utf8.RuneSelf:
+			letters++
+		case unicode.IsLetter(r) || unicode.IsMark(r):
+			other++
+		case unicode.IsDigit(r):
+			digits++
+		case unicode.IsSpace(r):
+		default:
+			punct++
+		}
+	}
+
+	tokens := wide + (other+1)/2 + (digits+2)/3 + (letters+4)/5
+	if punct > 0 {
+		tokens += (punct + 1) / 2
+	}
+	if tokens == 0 && piece != "" {
+		tokens = 1 // Whitespace
+	}
+	return tokens
+}
@@ -0,0 +1,109 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodingForModel(t *testing.T) {
+	tests := map[string]string{
+		"":                  Claude,
+		"claude":            Claude,
+		"claude-sonnet-4-5": Claude,
+		"gpt-4":             CL100KBase,
+		"gpt-4-turbo":       CL100KBase,
+		"gpt-4o-mini":       O200KBase,
+		"GPT-4.1":           O200KBase,
+		"o3-mini":           O200KBase,
+		"cl100k_base":       CL100KBase,
+		"bytes":             Bytes,
+	}
+	for name, want := range tests {
+		if got, ok := EncodingForModel(name); !ok || got != want {
+			t.Errorf("EncodingForModel(%q) = %q, %v, want %q", name, got, ok, want)
+		}
+	}
+	if _, ok := EncodingForModel("llama-3"); ok {
+		t.Error("expected an unknown model to be rejected")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate("gpt-4o", ""); err == nil {
+		t.Error("expected a byte pair encoding without a rank file to be rejected")
+	}
+	if err := Validate("gpt-4o", "o200k_base.tiktoken"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := Validate("unknown", ""); err == nil {
+		t.Error("expected an unknown tokenizer to be rejected")
+	}
+}
+
+func TestPretokenize(t *testing.T) {
+	got := pretokenize("Hello world!  It's 12345\n\n  done", cl100kPattern)
+	want := []string{"Hello", " world", "!", " ", " It", "'s", " ", "123", "45", "\n\n", " ", " done"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pretokenize = %q, want %q", got, want)
+	}
+}
+
+func TestBPE_Count(t *testing.T) {
+	// Every byte is a token, plus merges for "he", "ll", "hell", and "hello"
+	var lines []string
+	rank := 0
+	for b := 0; b < 256; b++ {
+		lines = append(lines, fmt.Sprintf("%s %d", base64.StdEncoding.EncodeToString([]byte{byte(b)}), rank))
+		rank++
+	}
+	for _, merge := range []string{"he", "ll", "hell", "hello", " w"} {
+		lines = append(lines, fmt.Sprintf("%s %d", base64.StdEncoding.EncodeToString([]byte(merge)), rank))
+		rank++
+	}
+	path := filepath.Join(t.TempDir(), "test.tiktoken")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bpe, err := New(CL100KBase, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]int{
+		"hello":       1, // Whole piece is a token
+		"hellos":      2, // "hello" + "s"
+		"help":        3, // "he" + "l" + "p"
+		"hello world": 6, // "hello", then " w" + "o" + "r" + "l" + "d"
+		"":            0,
+	}
+	for text, want := range tests {
+		if got := bpe.Count(text); got != want {
+			t.Errorf("Count(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestApproximate_Count(t *testing.T) {
+	approx := NewApproximate()
+	tests := []struct {
+		text     string
+		min, max int
+	}{
+		{"", 0, 0},
+		{"Hello, world!", 3, 5},
+		{"The quick brown fox jumps over the lazy dog.", 9, 12},
+		{"func main() {\n\tfmt.Println(\"hi\")\n}\n", 10, 20},
+		{"こんにちは世界", 6, 8},
+	}
+	for _, tt := range tests {
+		if got := approx.Count(tt.text); got < tt.min || got > tt.max {
+			t.Errorf("Count(%q) = %d, want between %d and %d", tt.text, got, tt.min, tt.max)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package webhook posts prompts to HTTP endpoints for the http: target, so internal
+// tools and chat bots can receive them.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Body formats accepted by webhook.format
+const (
+	FormatJSON = "json" // The prompt with its templates, files, and token counts, as --json prints
+	FormatRaw  = "raw"  // The prompt alone, as plain text
+)
+
+// Defaults used when the [webhook] config table leaves them out
+const (
+	DefaultFormat  = FormatJSON
+	DefaultTimeout = 10 * time.Second
+)
+
+// ValidFormat reports whether format is a supported body format. Empty means
+// DefaultFormat.
+func ValidFormat(format string) bool {
+	return format == "" || format == FormatJSON || format == FormatRaw
+}
+
+// Client posts prompts to webhooks
+type Client struct {
+	Headers    map[string]string // Sent with every request; values may reference environment variables as $NAME or ${NAME}
+	Timeout    time.Duration     // Limit on each request, DefaultTimeout when zero
+	HTTPClient *http.Client
+}
+
+// Post sends body to target with contentType, failing unless the webhook answers
+// with a 2xx status
+func (c *Client) Post(ctx context.Context, target string, body []byte, contentType string) error {
+	endpoint, err := url.Parse(target)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q (must be an http or https URL, as in http:https://example.com/hook)", target)
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "prompter")
+	for name, value := range c.Headers {
+		expanded, err := expandEnv(value)
+		if err != nil {
+			return fmt.Errorf("webhook header %s: %w", name, err)
+		}
+		req.Header.Set(name, expanded)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the webhook at %s: %w", endpoint.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// A short plain reply usually says what's wrong; error pages don't
+		reply, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		text, _, _ := strings.Cut(strings.TrimSpace(string(reply)), "\n")
+		if text == "" || strings.Contains(resp.Header.Get("Content-Type"), "html") {
+			return fmt.Errorf("webhook returned %s", resp.Status)
+		}
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, text)
+	}
+	return nil
+}
+
+// expandEnv replaces $NAME and ${NAME} in value with environment variables, so tokens
+// can stay out of the config file. Unset variables are an error rather than sending
+// an empty credential.
+func expandEnv(value string) (string, error) {
+	var missing []string
+	expanded := os.Expand(value, func(name string) string {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
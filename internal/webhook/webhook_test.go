@@ -0,0 +1,69 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPost(t *testing.T) {
+	var got struct {
+		contentType, auth, team, body string
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got.contentType = r.Header.Get("Content-Type")
+		got.auth = r.Header.Get("Authorization")
+		got.team = r.Header.Get("X-Team")
+		got.body = string(body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	t.Setenv("HOOK_TOKEN", "secret")
+	client := &Client{Headers: map[string]string{
+		"authorization": "Bearer ${HOOK_TOKEN}",
+		"x-team":        "core",
+	}}
+	if err := client.Post(context.Background(), server.URL+"/hook", []byte("fix this"), "text/plain; charset=utf-8"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.contentType != "text/plain; charset=utf-8" || got.auth != "Bearer secret" || got.team != "core" || got.body != "fix this" {
+		t.Errorf("unexpected request: %+v", got)
+	}
+}
+
+func TestPost_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+			return
+		}
+		http.Error(w, "channel not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		client *Client
+		url    string
+		want   string
+	}{
+		{"status", &Client{}, server.URL, "404 Not Found: channel not found"},
+		{"invalid URL", &Client{}, "//example.com/hook", "invalid webhook URL"},
+		{"unset variable", &Client{Headers: map[string]string{"authorization": "Bearer $PROMPTER_UNSET_TOKEN"}}, server.URL, "PROMPTER_UNSET_TOKEN is not set"},
+		{"timeout", &Client{Timeout: 20 * time.Millisecond}, server.URL + "/slow", "deadline exceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.client.Post(context.Background(), tt.url, []byte("hi"), "text/plain")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Post() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
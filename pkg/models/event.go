@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// EventType identifies the kind of progress event emitted during prompt generation
+type EventType string
+
+const (
+	EventStageStarted  EventType = "stage_started"  // A pipeline stage began
+	EventStageFinished EventType = "stage_finished" // A pipeline stage completed
+	EventFileCollected EventType = "file_collected" // A file or directory was added to the context
+	EventBytesWritten  EventType = "bytes_written"  // The prompt was written to an output target
+	EventWarning       EventType = "warning"        // A recoverable problem occurred
+)
+
+// Event describes progress during prompt generation so callers can report it
+type Event struct {
+	Type     EventType     `json:"type"`
+	Stage    string        `json:"stage,omitempty"`
+	Path     string        `json:"path,omitempty"`
+	Target   string        `json:"target,omitempty"`
+	Bytes    int           `json:"bytes,omitempty"`
+	Message  string        `json:"message,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"` // Set on stage_finished
+	Time     time.Time     `json:"time"`
+}
+
+// EventHandler receives progress events. Handlers are called synchronously on the
+// generating goroutine and should return quickly.
+type EventHandler func(Event)
+
+// ChannelHandler returns an EventHandler that forwards events to ch, for callers
+// that prefer consuming events from a channel. Events are dropped when ch is full
+// so a slow consumer never blocks generation.
+func ChannelHandler(ch chan<- Event) EventHandler {
+	return func(event Event) {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -1,23 +1,70 @@
 package models
 
+import "strings"
+
 // PromptRequest represents the main application request with all user inputs
 type PromptRequest struct {
 	BasePrompt        string   `json:"base_prompt"`
-	PreTemplate       string   `json:"pre_template"`
-	PostTemplate      string   `json:"post_template"`
-	Files             []string `json:"files"`
+	PreTemplate       string   `json:"pre_template"`  // Template name, or several separated by commas and rendered in order
+	PostTemplate      string   `json:"post_template"` // Template name, or several separated by commas and rendered in order
+	Files             []string `json:"files"`                    // Paths, optionally with a line range as path:start-end
+	Symbols           []string `json:"symbols,omitempty"`        // Go functions, methods, or types included by name
+	Sources           []string `json:"sources,omitempty"`        // Plugin content sources, as name or name:arg
 	Directory         string   `json:"directory"`
+	DirectoryStrategy string   `json:"directory_strategy,omitempty"` // Overrides the configured strategy when set
+	Exclude           []string `json:"exclude,omitempty"`            // Patterns left out of the directory, added to the configured ones
 	FixMode           bool     `json:"fix_mode"`
-	FixFile           string   `json:"fix_file"`
+	FixFile           string   `json:"fix_file"`                 // Command output to fix, FixFileStdin to read it from stdin
+	FixCommand        string   `json:"fix_command,omitempty"`    // Command that produced the output in FixFile or on stdin
 	Target            string   `json:"target"`
 	Editor            string   `json:"editor"`
 	EditorRequested   bool     `json:"editor_requested"`   // Track if --editor flag was explicitly used
 	Interactive       bool     `json:"interactive"`
 	ConfigPath        string   `json:"config_path"`
 	NumberSelect      bool     `json:"number_select"`      // Enable number key selection for templates
+	TUI               bool     `json:"tui,omitempty"`      // Collect inputs in the full-screen interface
+	JSON              bool     `json:"json,omitempty"`     // Print the prompt and its metadata as JSON instead of sending it to the target
 	FromClipboard     bool     `json:"from_clipboard"`     // Read base prompt from clipboard
 	ForceInteractive  bool     `json:"force_interactive"`  // -i flag was used
 	ForceNonInteractive bool   `json:"force_non_interactive"` // -y flag was used
+	Verbose           bool     `json:"verbose,omitempty"`  // Report skipped files and similar details on stderr
+	MaxTokens         int      `json:"max_tokens,omitempty"` // Token budget for embedded content, overrides the config when set
+	Model             string   `json:"model,omitempty"`      // Model preset applied to the budget, tokenizer, and file format
+	Outline           bool     `json:"outline,omitempty"`    // Embed outlines of supported source files instead of their contents
+	WithDeps          int      `json:"with_deps,omitempty"`  // Levels of same-module imports followed from explicit Go files
+	Todos             bool     `json:"todos,omitempty"`      // Collect TODO, FIXME, and HACK comments from the files and directory as .Todos
+	WithDocs          bool     `json:"with_docs,omitempty"`  // Add the project's README and docs, as include_docs does
+	NoRedact          bool     `json:"no_redact,omitempty"`  // Keep secrets that would otherwise be replaced with placeholders
+	Vars              map[string]string `json:"vars,omitempty"` // Values exposed to templates as .Vars
+	DiffMode          string   `json:"diff_mode,omitempty"`  // Changes included as .Diff: DiffWorking, DiffStaged, or DiffRef
+	DiffBase          string   `json:"diff_base,omitempty"`  // Ref compared against in DiffRef mode
+}
+
+// FixFileStdin as the fix file reads the command output to fix from stdin
+const FixFileStdin = "-"
+
+// Change sets a request can include, collected with git diff
+const (
+	DiffWorking = "working" // Unstaged changes in the working tree
+	DiffStaged  = "staged"  // Changes staged for commit
+	DiffRef     = "ref"     // The working tree compared against DiffBase
+)
+
+// TemplateNames splits a PreTemplate or PostTemplate value into the names of the
+// templates it lists, in order
+func TemplateNames(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// JoinTemplateNames joins template names into a PreTemplate or PostTemplate value
+func JoinTemplateNames(names []string) string {
+	return strings.Join(TemplateNames(strings.Join(names, ",")), ",")
 }
 
 // NewPromptRequest creates a new PromptRequest with default values
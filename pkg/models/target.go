@@ -0,0 +1,55 @@
+package models
+
+import "strings"
+
+// Output targets accepted by --target, the target setting, recipes, and inputs files
+const (
+	TargetClipboard    = "clipboard"
+	TargetStdout       = "stdout"
+	TargetOSC52        = "osc52"     // Copies through the terminal with an OSC 52 escape sequence
+	TargetEditor       = "editor"    // Opens the prompt in the editor, then sends the saved version to editor_target
+	TargetOpenAI       = "openai"    // Sends the prompt to the OpenAI Chat Completions API
+	TargetAnthropic    = "anthropic" // Sends the prompt to the Anthropic Messages API
+	TargetOllama       = "ollama"    // Sends the prompt to a local Ollama server
+	TargetFilePrefix   = "file:"     // Followed by the path to write
+	TargetAppendPrefix = "file+:"    // Followed by the path to append to
+	TargetPluginPrefix = "plugin:"   // Followed by a target provided by a plugin
+	TargetTmuxPrefix   = "tmux:"     // Followed by the tmux pane to paste into
+	TargetHTTPPrefix   = "http:"     // Followed by the URL to post the prompt to
+)
+
+// TargetUsage lists the accepted targets for error messages
+const TargetUsage = "'clipboard', 'stdout', 'osc52', 'editor', 'openai', 'anthropic', 'ollama', 'file:/path', 'file+:/path', 'tmux:pane', 'http:url', or 'plugin:name'"
+
+// IsModelTarget reports whether target sends the prompt to a model API rather than
+// storing it
+func IsModelTarget(target string) bool {
+	switch target {
+	case TargetOpenAI, TargetAnthropic, TargetOllama:
+		return true
+	}
+	return false
+}
+
+// IsFileTarget reports whether target writes or appends the prompt to a file
+func IsFileTarget(target string) bool {
+	return strings.HasPrefix(target, TargetFilePrefix) || strings.HasPrefix(target, TargetAppendPrefix)
+}
+
+// UsesFallbacks reports whether target_fallbacks apply when target fails: only the
+// clipboard, OSC 52, and tmux targets, which fail on machines without a clipboard or
+// terminal, while a model, webhook, plugin, or file target failing is an error
+func UsesFallbacks(target string) bool {
+	return target == TargetClipboard || target == TargetOSC52 || strings.HasPrefix(target, TargetTmuxPrefix)
+}
+
+// ValidTarget reports whether target names a supported output target
+func ValidTarget(target string) bool {
+	switch target {
+	case TargetClipboard, TargetStdout, TargetOSC52, TargetEditor:
+		return true
+	}
+	return IsModelTarget(target) || IsFileTarget(target) ||
+		strings.HasPrefix(target, TargetPluginPrefix) || strings.HasPrefix(target, TargetTmuxPrefix) ||
+		strings.HasPrefix(target, TargetHTTPPrefix)
+}
@@ -0,0 +1,174 @@
+// Package prompter generates prompts the way the prompter command does, for Go
+// programs such as editor plugins and bots that embed prompt generation instead of
+// running the CLI.
+//
+//	client, err := prompter.New(prompter.WithConfig("prompter.toml"))
+//	if err != nil {
+//		return err
+//	}
+//	result, err := client.GeneratePrompt(ctx, prompter.Request{
+//		Prompt: "explain this",
+//		Pre:    "review",
+//		Files:  []string{"main.go"},
+//	})
+//
+// Templates, file collection, token budgets, and redaction follow the same config
+// as the CLI. Nothing is printed: warnings are returned in the Result.
+package prompter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"prompter-cli/internal/orchestrator"
+	"prompter-cli/pkg/models"
+)
+
+// Client generates prompts. Its methods are safe for concurrent use, though
+// prompts are generated one at a time.
+type Client struct {
+	mu         sync.Mutex // Guards orch, which keeps state for the prompt being generated
+	orch       *orchestrator.Orchestrator
+	configPath string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithConfig reads settings from the config file at path instead of the default
+// ~/.config/prompter/config.toml and project config
+func WithConfig(path string) Option {
+	return func(c *Client) {
+		c.configPath = path
+	}
+}
+
+// WithEventHandler receives progress events while prompts are generated
+func WithEventHandler(handler models.EventHandler) Option {
+	return func(c *Client) {
+		c.orch.SetEventHandler(handler)
+	}
+}
+
+// New creates a client, loading the config so problems with it are reported early
+func New(options ...Option) (*Client, error) {
+	client := &Client{orch: orchestrator.New()}
+	client.orch.SetSilent(true)
+	for _, option := range options {
+		option(client)
+	}
+
+	if _, err := client.orch.LoadConfiguration(client.configPath); err != nil {
+		return nil, fmt.Errorf("configuration error: %w", err)
+	}
+	return client, nil
+}
+
+// Request describes a prompt to generate
+type Request struct {
+	Prompt    string            // Base prompt, required
+	Pre       string            // Pre-template name, empty for the configured default_pre
+	Post      string            // Post-template name, empty for the configured default_post
+	Files     []string          // Paths, optionally with a line range as path:start-end
+	Symbols   []string          // Go functions, methods, or types included by name, as pkg.Name
+	Directory string            // Directory whose files are included, empty for none
+	Exclude   []string          // Gitignore-style patterns left out of Directory
+	Vars      map[string]string // Template variables, overriding the configured ones
+	MaxTokens int               // Token budget for embedded contents, 0 for the configured max_tokens
+	Outline   bool              // Embed outlines of Go files instead of their contents
+	NoRedact  bool              // Keep secrets that would otherwise be replaced with placeholders
+}
+
+// Result is a generated prompt and how it was assembled
+type Result struct {
+	Prompt    string
+	System    string   // System prompt split off by the templates, sent apart to model APIs
+	Pre       string   // Pre-template used, empty for none
+	Post      string   // Post-template used, empty for none
+	Files     []File   // Files and directories added to the prompt
+	Tokens    int      // Tokens in Prompt and System together
+	Truncated bool     // Files were dropped or shortened to fit the token budget
+	Warnings  []string // Problems that didn't stop generation, such as skipped files
+}
+
+// File is a file or directory added to a prompt
+type File struct {
+	Path      string
+	Embedded  bool // Contents are in the prompt, not just the path
+	Tokens    int  // Tokens of the embedded contents
+	Truncated bool // Only part of the contents is embedded
+}
+
+// GeneratePrompt builds the prompt for request. When ctx is done it returns right
+// away, and generation already underway stops at its next stage, file, or command.
+func (c *Client) GeneratePrompt(ctx context.Context, request Request) (*Result, error) {
+	if request.Prompt == "" {
+		return nil, errors.New("a base prompt is required")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type generated struct {
+		result *Result
+		err    error
+	}
+	done := make(chan generated, 1)
+	go func() {
+		result, err := c.generate(ctx, request)
+		done <- generated{result, err}
+	}()
+
+	select {
+	case g := <-done:
+		return g.result, g.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// generate runs the orchestrator for request without asking for anything
+func (c *Client) generate(ctx context.Context, request Request) (*Result, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, err := c.orch.GeneratePrompt(ctx, &models.PromptRequest{
+		BasePrompt:   request.Prompt,
+		PreTemplate:  request.Pre,
+		PostTemplate: request.Post,
+		Files:        request.Files,
+		Symbols:      request.Symbols,
+		Directory:    request.Directory,
+		Exclude:      request.Exclude,
+		Vars:         request.Vars,
+		MaxTokens:    request.MaxTokens,
+		Outline:      request.Outline,
+		NoRedact:     request.NoRedact,
+		ConfigPath:   c.configPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := c.orch.Report()
+	result := &Result{
+		Prompt:    report.Prompt,
+		System:    report.System,
+		Pre:       report.Templates.Pre,
+		Post:      report.Templates.Post,
+		Tokens:    report.Tokens.Prompt + report.Tokens.System,
+		Truncated: report.Truncation != nil,
+		Warnings:  report.Warnings,
+	}
+	for _, file := range report.Files {
+		result.Files = append(result.Files, File{
+			Path:      file.Path,
+			Embedded:  file.Embedded,
+			Tokens:    file.Tokens,
+			Truncated: file.Truncated,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,109 @@
+package prompter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestClient returns a client with a config whose prompts location holds a
+// "review" pre-template
+func newTestClient(t *testing.T, extraConfig string) *Client {
+	t.Helper()
+
+	promptsDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(promptsDir, "pre"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(promptsDir, "pre", "review.md"), []byte("Review for {{.Vars.team}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	config := "prompts_location = \"" + promptsDir + "\"\n" + extraConfig + "\n[vars]\nteam = \"core\"\n"
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := New(WithConfig(configPath))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return client
+}
+
+func TestGeneratePrompt(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(file, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := newTestClient(t, "embed_content = true")
+	result, err := client.GeneratePrompt(context.Background(), Request{
+		Prompt: "explain this",
+		Pre:    "review",
+		Files:  []string{file},
+		Vars:   map[string]string{"team": "platform"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{"Review for platform", "explain this", "package main"} {
+		if !strings.Contains(result.Prompt, want) {
+			t.Errorf("expected %q in the prompt:\n%s", want, result.Prompt)
+		}
+	}
+	if result.Pre != "review" || result.Post != "" || result.Tokens == 0 || result.Truncated {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if len(result.Files) != 1 || !result.Files[0].Embedded || result.Files[0].Tokens == 0 {
+		t.Errorf("unexpected files: %+v", result.Files)
+	}
+}
+
+func TestGeneratePrompt_Warnings(t *testing.T) {
+	client := newTestClient(t, "embed_content = true")
+
+	// A missing file is reported rather than printed
+	result, err := client.GeneratePrompt(context.Background(), Request{
+		Prompt: "explain this",
+		Files:  []string{filepath.Join(t.TempDir(), "missing.go")},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("expected the missing file to be reported, got %v", result.Warnings)
+	}
+}
+
+func TestGeneratePrompt_Errors(t *testing.T) {
+	client := newTestClient(t, "")
+
+	if _, err := client.GeneratePrompt(context.Background(), Request{}); err == nil {
+		t.Error("expected an error without a base prompt")
+	}
+	if _, err := client.GeneratePrompt(context.Background(), Request{Prompt: "explain", Pre: "missing"}); err == nil {
+		t.Error("expected an error for a missing template")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.GeneratePrompt(ctx, Request{Prompt: "explain"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the cancelled context's error, got %v", err)
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(configPath, []byte("target = \"nowhere\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(WithConfig(configPath)); err == nil {
+		t.Error("expected an invalid config to be reported")
+	}
+}